@@ -4,7 +4,16 @@ import (
 	"andikawhy/go-user-management/repository"
 	"andikawhy/go-user-management/router"
 	"andikawhy/go-user-management/usecase"
+	"crypto/tls"
+	"encoding/json"
+	"flag"
+	"fmt"
 	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -14,17 +23,219 @@ func main() {
 	db := repository.ConnectDB()
 
 	userRepository := repository.NewUserRepositoryImpl(db)
+	auditRepository := repository.NewAuditRepositoryImpl(db)
+	tokenRepository := repository.NewPersonalAccessTokenRepositoryImpl(db)
+	clientRepository := repository.NewOAuthClientRepositoryImpl(db)
+	oauthRepository := repository.NewOAuthRepositoryImpl(db)
+	federationRepository := repository.NewFederationRepositoryImpl(db)
+	passkeyRepository := repository.NewPasskeyRepositoryImpl(db)
+	magicLinkRepository := repository.NewMagicLinkRepositoryImpl(db)
+	sessionRepository := repository.NewSessionRepositoryImpl(db)
+	organizationRepository := repository.NewOrganizationRepositoryImpl(db)
+	groupRepository := repository.NewGroupRepositoryImpl(db)
+	invitationRepository := repository.NewInvitationRepositoryImpl(db)
+	attributeRepository := repository.NewAttributeRepositoryImpl(db)
+	importRepository := repository.NewImportRepositoryImpl(db)
+	privacyRepository := repository.NewPrivacyRepositoryImpl(db)
 
-	userUsecase := usecase.NewUserUsecaseImpl(userRepository)
-	authUsecase := usecase.NewAuthUsecaseImpl(userRepository)
+	// SCIM clients and LDAP binds are not tied to a tenant and manage the default organization.
+	defaultUserRepository := userRepository.ForOrganization(repository.DefaultOrganizationID)
+
+	mailer, err := usecase.NewMailer()
+	if err != nil {
+		log.Fatal("Invalid mail configuration: ", err)
+	}
+	blobStore := usecase.NewBlobStore()
+
+	auditUsecase := usecase.NewAuditUsecaseImpl(auditRepository)
+	userUsecase := usecase.NewUserUsecaseImpl(userRepository, auditUsecase)
+	authenticator := usecase.NewAuthenticator(userRepository, federationRepository, auditUsecase)
+	authUsecase := usecase.NewAuthUsecaseImpl(userRepository, auditUsecase, tokenRepository, clientRepository, oauthRepository, authenticator, sessionRepository, groupRepository, attributeRepository)
+	tokenUsecase := usecase.NewTokenUsecaseImpl(tokenRepository, auditUsecase)
+	oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepository, oauthRepository, userRepository, sessionRepository, authUsecase, auditUsecase)
+	oidcUsecase := usecase.NewOIDCUsecaseImpl(userRepository, clientRepository, oauthRepository, sessionRepository, auditUsecase)
+	federationUsecase := usecase.NewFederationUsecaseImpl(federationRepository, userRepository, passkeyRepository, sessionRepository, auditUsecase)
+	directoryUsecase := usecase.NewDirectoryUsecaseImpl(defaultUserRepository, auditUsecase)
+	scimUsecase := usecase.NewSCIMUsecaseImpl(defaultUserRepository, attributeRepository, auditUsecase)
+	passkeyUsecase := usecase.NewPasskeyUsecaseImpl(passkeyRepository, userRepository, authenticator, sessionRepository, auditUsecase)
+	magicLinkUsecase := usecase.NewMagicLinkUsecaseImpl(magicLinkRepository, userRepository, sessionRepository, mailer, auditUsecase)
+	sessionUsecase := usecase.NewSessionUsecaseImpl(sessionRepository, userRepository, auditUsecase)
+	organizationUsecase := usecase.NewOrganizationUsecaseImpl(organizationRepository, userRepository, sessionRepository, auditUsecase)
+	groupUsecase := usecase.NewGroupUsecaseImpl(groupRepository, userRepository, auditUsecase)
+	policyUsecase := usecase.NewPolicyUsecaseImpl(userRepository, organizationRepository, groupRepository)
+	avatarUsecase := usecase.NewAvatarUsecaseImpl(userRepository, blobStore, auditUsecase)
+	attributeUsecase := usecase.NewAttributeUsecaseImpl(attributeRepository, userRepository, auditUsecase)
+	invitationUsecase := usecase.NewInvitationUsecaseImpl(invitationRepository, userRepository, attributeRepository, mailer, auditUsecase)
+	importUsecase := usecase.NewImportUsecaseImpl(importRepository, userRepository, attributeRepository, policyUsecase, auditUsecase)
+	privacyUsecase := usecase.NewPrivacyUsecaseImpl(privacyRepository, userRepository, sessionRepository, blobStore, auditUsecase)
+
+	if len(os.Args) > 1 {
+		runCommand(os.Args[1], os.Args[2:], auditUsecase, importUsecase, privacyUsecase, organizationRepository)
+		return
+	}
 
 	userRouter := router.NewUserRouterImpl(userUsecase, authUsecase)
 	authRouter := router.NewAuthRouterImpl(userUsecase, authUsecase)
+	auditRouter := router.NewAuditRouterImpl(auditUsecase)
+	tokenRouter := router.NewTokenRouterImpl(tokenUsecase)
+	oauthRouter := router.NewOAuthRouterImpl(oauthUsecase)
+	oidcRouter := router.NewOIDCRouterImpl(oidcUsecase)
+	federationRouter := router.NewFederationRouterImpl(federationUsecase)
+	ldapRouter := router.NewLDAPRouterImpl(directoryUsecase)
+	scimRouter := router.NewSCIMRouterImpl(scimUsecase)
+	passkeyRouter := router.NewPasskeyRouterImpl(passkeyUsecase)
+	magicLinkRouter := router.NewMagicLinkRouterImpl(magicLinkUsecase)
+	sessionRouter := router.NewSessionRouterImpl(sessionUsecase)
+	organizationRouter := router.NewOrganizationRouterImpl(organizationUsecase)
+	groupRouter := router.NewGroupRouterImpl(groupUsecase)
+	policyRouter := router.NewPolicyRouterImpl(policyUsecase)
+	invitationRouter := router.NewInvitationRouterImpl(invitationUsecase)
+	attributeRouter := router.NewAttributeRouterImpl(attributeUsecase)
+	avatarRouter := router.NewAvatarRouterImpl(avatarUsecase)
+	importRouter := router.NewImportRouterImpl(importUsecase)
+	privacyRouter := router.NewPrivacyRouterImpl(privacyUsecase)
+
+	if err := usecase.CheckOIDCSigningKey(); err != nil {
+		log.Fatal("Invalid OIDC signing key: ", err)
+	}
+
+	if interrupted := importUsecase.FailInterruptedImports(); interrupted > 0 {
+		log.Printf("Marked %d interrupted imports as failed", interrupted)
+	}
+
+	if address := os.Getenv("LDAP_SERVER_ADDRESS"); address != "" {
+		go serveLDAP(address, ldapRouter)
+	}
+	go processErasures(privacyUsecase)
+	go deleteExpiredSessions(sessionUsecase)
 
-	ginRouter := router.SetupRouter(userRouter, authRouter, authUsecase)
+	ginRouter := router.SetupRouter(userRouter, authRouter, auditRouter, tokenRouter, oauthRouter, oidcRouter, federationRouter, scimRouter, passkeyRouter, magicLinkRouter, sessionRouter, organizationRouter, groupRouter, policyRouter, invitationRouter, attributeRouter, avatarRouter, importRouter, privacyRouter, authUsecase, organizationUsecase, policyUsecase)
 	ginRouter.Run()
 }
 
+func runCommand(command string, args []string, auditUsecase usecase.AuditUsecase, importUsecase usecase.ImportUsecase, privacyUsecase usecase.PrivacyUsecase, organizationRepository repository.OrganizationRepository) {
+	switch command {
+	case "verify-audit":
+		verification, err := auditUsecase.Verify()
+		if err != nil && err.Error != nil {
+			log.Fatal("Failed to verify audit chain: ", err.Error)
+		}
+
+		output, _ := json.MarshalIndent(verification, "", "  ")
+		fmt.Println(string(output))
+
+		if !verification.Valid {
+			os.Exit(1)
+		}
+	case "import-users":
+		importUsers(args, importUsecase, organizationRepository)
+	case "process-erasures":
+		completed, err := privacyUsecase.ProcessErasures()
+		fmt.Printf("Erased %d users\n", completed)
+		if err != nil && err.Error != nil {
+			log.Fatal("Failed to process erasures: ", err.Error)
+		}
+	default:
+		log.Fatal("Unknown command: ", command)
+	}
+}
+
+// importUsers runs an import in the foreground and prints the finished job, exiting 1 when any row failed.
+func importUsers(args []string, importUsecase usecase.ImportUsecase, organizationRepository repository.OrganizationRepository) {
+	flags := flag.NewFlagSet("import-users", flag.ExitOnError)
+	format := flags.String("format", "", "csv or ndjson, detected from the file extension when empty")
+	organizationSlug := flags.String("organization", "", "slug of the organization to import into, the default organization when empty")
+	dryRun := flags.Bool("dry-run", false, "validate the file without writing users")
+	upsert := flags.Bool("upsert", false, "update users that already exist instead of rejecting them")
+	updatePasswords := flags.Bool("update-passwords", false, "let an upsert replace the passwords of existing users")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		log.Fatal("Usage: import-users [--format=csv|ndjson] [--organization=slug] [--dry-run] [--upsert [--update-passwords]] <file>")
+	}
+	file := flags.Arg(0)
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		log.Fatal("Failed to read import file: ", err)
+	}
+
+	if *format == "" {
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".csv":
+			*format = repository.ImportFormatCSV
+		case ".ndjson", ".jsonl":
+			*format = repository.ImportFormatNDJSON
+		}
+	}
+
+	var organizationId uint64
+	if *organizationSlug != "" {
+		organization := organizationRepository.FindBySlug(*organizationSlug)
+		if organization.ID == 0 {
+			log.Fatal("Unknown organization: ", *organizationSlug)
+		}
+		organizationId = organization.ID
+	}
+
+	job, importError := importUsecase.RunImport(organizationId, repository.ImportOptions{Format: *format, DryRun: *dryRun, Upsert: *upsert, UpdatePasswords: *updatePasswords}, data, 0)
+	if importError != nil && importError.Error != nil {
+		log.Fatal("Failed to import users: ", importError.Error)
+	}
+
+	output, _ := json.MarshalIndent(job, "", "  ")
+	fmt.Println(string(output))
+
+	if job.Failed != 0 {
+		os.Exit(1)
+	}
+}
+
+// processErasures runs due erasures in the background, so they happen without an external scheduler.
+func processErasures(privacyUsecase usecase.PrivacyUsecase) {
+	ticker := time.NewTicker(usecase.ErasureCheckInterval())
+	defer ticker.Stop()
+
+	for {
+		if completed, err := privacyUsecase.ProcessErasures(); err != nil && err.Error != nil {
+			log.Printf("Failed to process erasures: %v", err.Error)
+		} else if completed > 0 {
+			log.Printf("Erased %d users", completed)
+		}
+		<-ticker.C
+	}
+}
+
+// deleteExpiredSessions keeps the session table from growing with sessions nobody can use anymore.
+func deleteExpiredSessions(sessionUsecase usecase.SessionUsecase) {
+	ticker := time.NewTicker(usecase.SessionCleanupInterval())
+	defer ticker.Stop()
+
+	for {
+		if deleted := sessionUsecase.DeleteExpiredSessions(); deleted > 0 {
+			log.Printf("Deleted %d expired sessions", deleted)
+		}
+		<-ticker.C
+	}
+}
+
+func serveLDAP(address string, ldapRouter router.LDAPRouter) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		log.Fatal("Failed to start LDAP server: ", err)
+	}
+
+	if certFile := os.Getenv("LDAP_SERVER_TLS_CERT"); certFile != "" {
+		certificate, err := tls.LoadX509KeyPair(certFile, os.Getenv("LDAP_SERVER_TLS_KEY"))
+		if err != nil {
+			log.Fatal("Failed to load LDAP server certificate: ", err)
+		}
+		listener = tls.NewListener(listener, &tls.Config{Certificates: []tls.Certificate{certificate}, MinVersion: tls.VersionTLS12})
+	}
+
+	log.Fatal("LDAP server stopped: ", ldapRouter.Serve(listener))
+}
+
 func loadEnvs() {
 	err := godotenv.Load()
 	if err != nil {
@@ -0,0 +1,46 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type AttributeRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *AttributeRepositoryMock) Save(definition repository.AttributeDefinition) repository.AttributeDefinition {
+	args := m.Called(definition)
+	return args.Get(0).(repository.AttributeDefinition)
+}
+
+func (m *AttributeRepositoryMock) Update(definition repository.AttributeDefinition) repository.AttributeDefinition {
+	args := m.Called(definition)
+	return args.Get(0).(repository.AttributeDefinition)
+}
+
+func (m *AttributeRepositoryMock) FindById(organizationId uint64, id uint64) repository.AttributeDefinition {
+	args := m.Called(organizationId, id)
+	return args.Get(0).(repository.AttributeDefinition)
+}
+
+func (m *AttributeRepositoryMock) FindByName(organizationId uint64, name string) repository.AttributeDefinition {
+	args := m.Called(organizationId, name)
+	return args.Get(0).(repository.AttributeDefinition)
+}
+
+func (m *AttributeRepositoryMock) FindByOrganizationId(organizationId uint64) []repository.AttributeDefinition {
+	args := m.Called(organizationId)
+	return args.Get(0).([]repository.AttributeDefinition)
+}
+
+func (m *AttributeRepositoryMock) Delete(organizationId uint64, id uint64) bool {
+	args := m.Called(organizationId, id)
+	return args.Bool(0)
+}
+
+func (m *AttributeRepositoryMock) IndexUsers(definition repository.AttributeDefinition) error {
+	args := m.Called(definition)
+	return args.Error(0)
+}
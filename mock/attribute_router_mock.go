@@ -0,0 +1,37 @@
+package mocks
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+)
+
+type AttributeRouterMock struct {
+	mock.Mock
+}
+
+func (m *AttributeRouterMock) CreateDefinition(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "attribute created"})
+}
+
+func (m *AttributeRouterMock) ListDefinitions(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "attributes listed"})
+}
+
+func (m *AttributeRouterMock) UpdateDefinition(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "attribute updated"})
+}
+
+func (m *AttributeRouterMock) DeleteDefinition(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "attribute deleted"})
+}
+
+func (m *AttributeRouterMock) SetUserAttributes(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "user attributes set"})
+}
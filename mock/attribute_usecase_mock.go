@@ -0,0 +1,37 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type AttributeUsecaseMock struct {
+	mock.Mock
+}
+
+func (m *AttributeUsecaseMock) CreateDefinition(organizationId uint64, definitionData repository.SaveAttributeDefinition, actorId uint64) (*repository.AttributeDefinition, *helper.StandardError) {
+	args := m.Called(organizationId, definitionData, actorId)
+	return args.Get(0).(*repository.AttributeDefinition), args.Get(1).(*helper.StandardError)
+}
+
+func (m *AttributeUsecaseMock) ListDefinitions(organizationId uint64) (*[]repository.AttributeDefinition, *helper.StandardError) {
+	args := m.Called(organizationId)
+	return args.Get(0).(*[]repository.AttributeDefinition), args.Get(1).(*helper.StandardError)
+}
+
+func (m *AttributeUsecaseMock) UpdateDefinition(organizationId uint64, definitionId uint64, definitionData repository.SaveAttributeDefinition, actorId uint64) (*repository.AttributeDefinition, *helper.StandardError) {
+	args := m.Called(organizationId, definitionId, definitionData, actorId)
+	return args.Get(0).(*repository.AttributeDefinition), args.Get(1).(*helper.StandardError)
+}
+
+func (m *AttributeUsecaseMock) DeleteDefinition(organizationId uint64, definitionId uint64, actorId uint64) (*repository.AttributeDefinition, *helper.StandardError) {
+	args := m.Called(organizationId, definitionId, actorId)
+	return args.Get(0).(*repository.AttributeDefinition), args.Get(1).(*helper.StandardError)
+}
+
+func (m *AttributeUsecaseMock) SetUserAttributes(organizationId uint64, userId uint64, attributes repository.UserAttributes, actorId uint64) (*repository.UserResponse, *helper.StandardError) {
+	args := m.Called(organizationId, userId, attributes, actorId)
+	return args.Get(0).(*repository.UserResponse), args.Get(1).(*helper.StandardError)
+}
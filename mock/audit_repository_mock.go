@@ -0,0 +1,41 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type AuditRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *AuditRepositoryMock) Save(event repository.AuditEvent) repository.AuditEvent {
+	args := m.Called()
+	return args.Get(0).(repository.AuditEvent)
+}
+
+func (m *AuditRepositoryMock) FindLast() repository.AuditEvent {
+	args := m.Called()
+	return args.Get(0).(repository.AuditEvent)
+}
+
+func (m *AuditRepositoryMock) FindAfter(id uint64, limit int) []repository.AuditEvent {
+	args := m.Called(id)
+	return args.Get(0).([]repository.AuditEvent)
+}
+
+func (m *AuditRepositoryMock) SaveCheckpoint(checkpoint repository.AuditCheckpoint) repository.AuditCheckpoint {
+	args := m.Called()
+	return args.Get(0).(repository.AuditCheckpoint)
+}
+
+func (m *AuditRepositoryMock) FindCheckpoints() []repository.AuditCheckpoint {
+	args := m.Called()
+	return args.Get(0).([]repository.AuditCheckpoint)
+}
+
+func (m *AuditRepositoryMock) FindLastCheckpoint() repository.AuditCheckpoint {
+	args := m.Called()
+	return args.Get(0).(repository.AuditCheckpoint)
+}
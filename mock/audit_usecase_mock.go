@@ -0,0 +1,25 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type AuditUsecaseMock struct {
+	mock.Mock
+}
+
+func (m *AuditUsecaseMock) Record(action string, actorID uint64, subjectID uint64, detail string) *helper.StandardError {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*helper.StandardError)
+}
+
+func (m *AuditUsecaseMock) Verify() (*repository.AuditVerification, *helper.StandardError) {
+	args := m.Called()
+	return args.Get(0).(*repository.AuditVerification), args.Get(1).(*helper.StandardError)
+}
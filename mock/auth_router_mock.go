@@ -20,3 +20,8 @@ func (m *AuthRouterMock) Login(c *gin.Context) {
 	m.Called(c)
 	c.JSON(http.StatusOK, gin.H{"status": "logged in"})
 }
+
+func (m *AuthRouterMock) Logout(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "logged out"})
+}
@@ -10,14 +10,22 @@ import (
 
 type AuthUsecaseMock struct {
 	mock.Mock
+	OrganizationID uint64
 }
 
-func (m *AuthUsecaseMock) Login(loginData repository.Login) (string, *helper.StandardError) {
+// Authenticate remembers the organization of the login so tests can assert it.
+func (m *AuthUsecaseMock) Authenticate(loginData repository.Login) (*repository.User, *helper.StandardError) {
+	m.OrganizationID = loginData.OrganizationID
+	args := m.Called()
+	return args.Get(0).(*repository.User), args.Get(1).(*helper.StandardError)
+}
+
+func (m *AuthUsecaseMock) Login(loginData repository.Login, loginContext repository.LoginContext) (string, *helper.StandardError) {
 	args := m.Called()
 	return args.Get(0).(string), args.Get(1).(*helper.StandardError)
 }
 
-func (m *AuthUsecaseMock) Register(registerData repository.Register) (*repository.UserResponse, *helper.StandardError) {
+func (m *AuthUsecaseMock) Register(registerData repository.Register, organizationId uint64) (*repository.UserResponse, *helper.StandardError) {
 	args := m.Called()
 	return args.Get(0).(*repository.UserResponse), args.Get(1).(*helper.StandardError)
 }
@@ -25,3 +33,29 @@ func (m *AuthUsecaseMock) Register(registerData repository.Register) (*repositor
 func (m *AuthUsecaseMock) ValidateToken(c *gin.Context) {
 
 }
+
+func (m *AuthUsecaseMock) ParseToken(tokenString string) (*repository.TokenInfo, *helper.StandardError) {
+	args := m.Called(tokenString)
+	return args.Get(0).(*repository.TokenInfo), args.Get(1).(*helper.StandardError)
+}
+
+func (m *AuthUsecaseMock) RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+	}
+}
+
+func (m *AuthUsecaseMock) CreateSession(loginData repository.Login, loginContext repository.LoginContext) (*repository.CreatedSession, *helper.StandardError) {
+	args := m.Called()
+	return args.Get(0).(*repository.CreatedSession), args.Get(1).(*helper.StandardError)
+}
+
+func (m *AuthUsecaseMock) FindSession(sessionToken string) (*repository.User, *repository.Session) {
+	args := m.Called(sessionToken)
+	return args.Get(0).(*repository.User), args.Get(1).(*repository.Session)
+}
+
+func (m *AuthUsecaseMock) Logout(sessionId uint64) *helper.StandardError {
+	args := m.Called(sessionId)
+	return args.Get(0).(*helper.StandardError)
+}
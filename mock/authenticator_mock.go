@@ -0,0 +1,17 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type AuthenticatorMock struct {
+	mock.Mock
+}
+
+func (m *AuthenticatorMock) Authenticate(loginData repository.Login) (*repository.User, *helper.StandardError) {
+	args := m.Called()
+	return args.Get(0).(*repository.User), args.Get(1).(*helper.StandardError)
+}
@@ -0,0 +1,27 @@
+package mocks
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+)
+
+type AvatarRouterMock struct {
+	mock.Mock
+}
+
+func (m *AvatarRouterMock) UploadAvatar(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "avatar uploaded"})
+}
+
+func (m *AvatarRouterMock) RemoveAvatar(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "avatar removed"})
+}
+
+func (m *AvatarRouterMock) GetAvatar(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "avatar served"})
+}
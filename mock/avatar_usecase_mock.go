@@ -0,0 +1,27 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type AvatarUsecaseMock struct {
+	mock.Mock
+}
+
+func (m *AvatarUsecaseMock) SetAvatar(userId uint64, data []byte) (*repository.UserResponse, *helper.StandardError) {
+	args := m.Called(userId, data)
+	return args.Get(0).(*repository.UserResponse), args.Get(1).(*helper.StandardError)
+}
+
+func (m *AvatarUsecaseMock) RemoveAvatar(userId uint64) (*repository.UserResponse, *helper.StandardError) {
+	args := m.Called(userId)
+	return args.Get(0).(*repository.UserResponse), args.Get(1).(*helper.StandardError)
+}
+
+func (m *AvatarUsecaseMock) GetAvatar(key string) ([]byte, *helper.StandardError) {
+	args := m.Called(key)
+	return args.Get(0).([]byte), args.Get(1).(*helper.StandardError)
+}
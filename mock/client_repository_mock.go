@@ -0,0 +1,36 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type OAuthClientRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *OAuthClientRepositoryMock) Save(client repository.OAuthClient) repository.OAuthClient {
+	args := m.Called()
+	return args.Get(0).(repository.OAuthClient)
+}
+
+func (m *OAuthClientRepositoryMock) Update(client repository.OAuthClient) repository.OAuthClient {
+	args := m.Called()
+	return args.Get(0).(repository.OAuthClient)
+}
+
+func (m *OAuthClientRepositoryMock) FindById(organizationId uint64, id uint64) repository.OAuthClient {
+	args := m.Called()
+	return args.Get(0).(repository.OAuthClient)
+}
+
+func (m *OAuthClientRepositoryMock) FindByClientId(clientId string) repository.OAuthClient {
+	args := m.Called()
+	return args.Get(0).(repository.OAuthClient)
+}
+
+func (m *OAuthClientRepositoryMock) FindByOrganizationId(organizationId uint64) []repository.OAuthClient {
+	args := m.Called()
+	return args.Get(0).([]repository.OAuthClient)
+}
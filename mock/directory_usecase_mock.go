@@ -0,0 +1,22 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type DirectoryUsecaseMock struct {
+	mock.Mock
+}
+
+func (m *DirectoryUsecaseMock) Bind(dn string, password string) *helper.StandardError {
+	args := m.Called(dn, password)
+	return args.Get(0).(*helper.StandardError)
+}
+
+func (m *DirectoryUsecaseMock) Search(search repository.DirectorySearch) ([]repository.DirectoryEntry, *helper.StandardError) {
+	args := m.Called(search)
+	return args.Get(0).([]repository.DirectoryEntry), args.Get(1).(*helper.StandardError)
+}
@@ -0,0 +1,50 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type FederationRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *FederationRepositoryMock) SaveIdentity(identity repository.Identity) repository.Identity {
+	args := m.Called(identity)
+	return args.Get(0).(repository.Identity)
+}
+
+func (m *FederationRepositoryMock) UpdateIdentity(identity repository.Identity) repository.Identity {
+	args := m.Called()
+	return args.Get(0).(repository.Identity)
+}
+
+func (m *FederationRepositoryMock) FindIdentity(provider string, subject string) repository.Identity {
+	args := m.Called(provider, subject)
+	return args.Get(0).(repository.Identity)
+}
+
+func (m *FederationRepositoryMock) FindIdentitiesByUserId(userId uint64) []repository.Identity {
+	args := m.Called()
+	return args.Get(0).([]repository.Identity)
+}
+
+func (m *FederationRepositoryMock) DeleteIdentity(id uint64) {
+	m.Called()
+}
+
+func (m *FederationRepositoryMock) SaveState(state repository.FederationState) repository.FederationState {
+	args := m.Called(state)
+	return args.Get(0).(repository.FederationState)
+}
+
+func (m *FederationRepositoryMock) FindStateByHash(stateHash string) repository.FederationState {
+	args := m.Called()
+	return args.Get(0).(repository.FederationState)
+}
+
+func (m *FederationRepositoryMock) DeleteState(id uint64) bool {
+	args := m.Called()
+	return args.Bool(0)
+}
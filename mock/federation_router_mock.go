@@ -0,0 +1,37 @@
+package mocks
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+)
+
+type FederationRouterMock struct {
+	mock.Mock
+}
+
+func (m *FederationRouterMock) Login(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "redirected"})
+}
+
+func (m *FederationRouterMock) Callback(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "logged in"})
+}
+
+func (m *FederationRouterMock) LinkIdentity(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "link started"})
+}
+
+func (m *FederationRouterMock) ListIdentities(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "identities listed"})
+}
+
+func (m *FederationRouterMock) UnlinkIdentity(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "identity unlinked"})
+}
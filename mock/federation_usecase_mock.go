@@ -0,0 +1,32 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type FederationUsecaseMock struct {
+	mock.Mock
+}
+
+func (m *FederationUsecaseMock) BeginLogin(providerName string, linkUserId uint64) (*repository.FederationRedirect, *helper.StandardError) {
+	args := m.Called(providerName, linkUserId)
+	return args.Get(0).(*repository.FederationRedirect), args.Get(1).(*helper.StandardError)
+}
+
+func (m *FederationUsecaseMock) Callback(providerName string, callback repository.FederationCallback, loginContext repository.LoginContext) (*repository.FederationResult, *helper.StandardError) {
+	args := m.Called(providerName, callback)
+	return args.Get(0).(*repository.FederationResult), args.Get(1).(*helper.StandardError)
+}
+
+func (m *FederationUsecaseMock) ListIdentities(userId uint64) (*[]repository.Identity, *helper.StandardError) {
+	args := m.Called()
+	return args.Get(0).(*[]repository.Identity), args.Get(1).(*helper.StandardError)
+}
+
+func (m *FederationUsecaseMock) UnlinkIdentity(userId uint64, identityId uint64) (*repository.Identity, *helper.StandardError) {
+	args := m.Called()
+	return args.Get(0).(*repository.Identity), args.Get(1).(*helper.StandardError)
+}
@@ -0,0 +1,66 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type GroupRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *GroupRepositoryMock) Save(group repository.Group) repository.Group {
+	args := m.Called(group)
+	return args.Get(0).(repository.Group)
+}
+
+func (m *GroupRepositoryMock) Update(group repository.Group) repository.Group {
+	args := m.Called(group)
+	return args.Get(0).(repository.Group)
+}
+
+func (m *GroupRepositoryMock) FindById(organizationId uint64, id uint64) repository.Group {
+	args := m.Called(organizationId, id)
+	return args.Get(0).(repository.Group)
+}
+
+func (m *GroupRepositoryMock) FindByName(organizationId uint64, name string) repository.Group {
+	args := m.Called(organizationId, name)
+	return args.Get(0).(repository.Group)
+}
+
+func (m *GroupRepositoryMock) FindByIds(ids []uint64) []repository.Group {
+	args := m.Called(ids)
+	return args.Get(0).([]repository.Group)
+}
+
+func (m *GroupRepositoryMock) FindByOrganizationId(organizationId uint64) []repository.Group {
+	args := m.Called(organizationId)
+	return args.Get(0).([]repository.Group)
+}
+
+func (m *GroupRepositoryMock) Delete(organizationId uint64, id uint64) bool {
+	args := m.Called(organizationId, id)
+	return args.Bool(0)
+}
+
+func (m *GroupRepositoryMock) SaveMember(member repository.GroupMember) repository.GroupMember {
+	args := m.Called(member)
+	return args.Get(0).(repository.GroupMember)
+}
+
+func (m *GroupRepositoryMock) FindMembers(groupIds []uint64) []repository.GroupMember {
+	args := m.Called(groupIds)
+	return args.Get(0).([]repository.GroupMember)
+}
+
+func (m *GroupRepositoryMock) FindParents(userId uint64, groupIds []uint64) []repository.GroupMember {
+	args := m.Called(userId, groupIds)
+	return args.Get(0).([]repository.GroupMember)
+}
+
+func (m *GroupRepositoryMock) DeleteMember(member repository.GroupMember) bool {
+	args := m.Called(member)
+	return args.Bool(0)
+}
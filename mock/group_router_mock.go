@@ -0,0 +1,57 @@
+package mocks
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+)
+
+type GroupRouterMock struct {
+	mock.Mock
+}
+
+func (m *GroupRouterMock) CreateGroup(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "group created"})
+}
+
+func (m *GroupRouterMock) ListGroups(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "groups listed"})
+}
+
+func (m *GroupRouterMock) RenameGroup(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "group renamed"})
+}
+
+func (m *GroupRouterMock) DeleteGroup(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "group deleted"})
+}
+
+func (m *GroupRouterMock) ListMembers(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "group members listed"})
+}
+
+func (m *GroupRouterMock) AddUser(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "user added"})
+}
+
+func (m *GroupRouterMock) RemoveUser(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "user removed"})
+}
+
+func (m *GroupRouterMock) AddGroup(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "group added"})
+}
+
+func (m *GroupRouterMock) RemoveGroup(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "group removed"})
+}
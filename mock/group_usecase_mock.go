@@ -0,0 +1,47 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type GroupUsecaseMock struct {
+	mock.Mock
+}
+
+func (m *GroupUsecaseMock) CreateGroup(organizationId uint64, groupData repository.SaveGroup, actorId uint64) (*repository.Group, *helper.StandardError) {
+	args := m.Called(organizationId, groupData, actorId)
+	return args.Get(0).(*repository.Group), args.Get(1).(*helper.StandardError)
+}
+
+func (m *GroupUsecaseMock) ListGroups(organizationId uint64) (*[]repository.Group, *helper.StandardError) {
+	args := m.Called(organizationId)
+	return args.Get(0).(*[]repository.Group), args.Get(1).(*helper.StandardError)
+}
+
+func (m *GroupUsecaseMock) RenameGroup(organizationId uint64, groupId uint64, groupData repository.SaveGroup, actorId uint64) (*repository.Group, *helper.StandardError) {
+	args := m.Called(organizationId, groupId, groupData, actorId)
+	return args.Get(0).(*repository.Group), args.Get(1).(*helper.StandardError)
+}
+
+func (m *GroupUsecaseMock) DeleteGroup(organizationId uint64, groupId uint64, actorId uint64) (*repository.Group, *helper.StandardError) {
+	args := m.Called(organizationId, groupId, actorId)
+	return args.Get(0).(*repository.Group), args.Get(1).(*helper.StandardError)
+}
+
+func (m *GroupUsecaseMock) ListMembers(organizationId uint64, groupId uint64) (*repository.GroupMembers, *helper.StandardError) {
+	args := m.Called(organizationId, groupId)
+	return args.Get(0).(*repository.GroupMembers), args.Get(1).(*helper.StandardError)
+}
+
+func (m *GroupUsecaseMock) AddMember(organizationId uint64, groupId uint64, member repository.GroupMember, actorId uint64) (*repository.GroupMember, *helper.StandardError) {
+	args := m.Called(organizationId, groupId, member, actorId)
+	return args.Get(0).(*repository.GroupMember), args.Get(1).(*helper.StandardError)
+}
+
+func (m *GroupUsecaseMock) RemoveMember(organizationId uint64, groupId uint64, member repository.GroupMember, actorId uint64) *helper.StandardError {
+	args := m.Called(organizationId, groupId, member, actorId)
+	return args.Get(0).(*helper.StandardError)
+}
@@ -0,0 +1,55 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type ImportRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *ImportRepositoryMock) SaveJob(job repository.ImportJob) repository.ImportJob {
+	args := m.Called(job)
+	if save, ok := args.Get(0).(func(repository.ImportJob) repository.ImportJob); ok {
+		return save(job)
+	}
+	return args.Get(0).(repository.ImportJob)
+}
+
+func (m *ImportRepositoryMock) UpdateJob(job repository.ImportJob) repository.ImportJob {
+	args := m.Called(job)
+	if update, ok := args.Get(0).(func(repository.ImportJob) repository.ImportJob); ok {
+		return update(job)
+	}
+	return args.Get(0).(repository.ImportJob)
+}
+
+func (m *ImportRepositoryMock) FindJobById(organizationId uint64, id uint64) repository.ImportJob {
+	args := m.Called(organizationId, id)
+	return args.Get(0).(repository.ImportJob)
+}
+
+func (m *ImportRepositoryMock) FindJobsByOrganizationId(organizationId uint64) []repository.ImportJob {
+	args := m.Called(organizationId)
+	return args.Get(0).([]repository.ImportJob)
+}
+
+// SaveUsers returns the configured written flags, or reports every user as written when they are nil.
+func (m *ImportRepositoryMock) SaveUsers(users []repository.User) ([]bool, error) {
+	args := m.Called(users)
+	written, ok := args.Get(0).([]bool)
+	if !ok && args.Error(1) == nil {
+		written = make([]bool, len(users))
+		for i := range written {
+			written[i] = true
+		}
+	}
+	return written, args.Error(1)
+}
+
+func (m *ImportRepositoryMock) FailRunningJobs(reason string) int64 {
+	args := m.Called(reason)
+	return args.Get(0).(int64)
+}
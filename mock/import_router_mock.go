@@ -0,0 +1,27 @@
+package mocks
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+)
+
+type ImportRouterMock struct {
+	mock.Mock
+}
+
+func (m *ImportRouterMock) ImportUsers(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusAccepted, gin.H{"status": "import started"})
+}
+
+func (m *ImportRouterMock) ListImports(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "imports listed"})
+}
+
+func (m *ImportRouterMock) GetImport(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "import found"})
+}
@@ -0,0 +1,37 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type ImportUsecaseMock struct {
+	mock.Mock
+}
+
+func (m *ImportUsecaseMock) StartImport(organizationId uint64, options repository.ImportOptions, data []byte, actorId uint64) (*repository.ImportJob, *helper.StandardError) {
+	args := m.Called(organizationId, options, data, actorId)
+	return args.Get(0).(*repository.ImportJob), args.Get(1).(*helper.StandardError)
+}
+
+func (m *ImportUsecaseMock) RunImport(organizationId uint64, options repository.ImportOptions, data []byte, actorId uint64) (*repository.ImportJob, *helper.StandardError) {
+	args := m.Called(organizationId, options, data, actorId)
+	return args.Get(0).(*repository.ImportJob), args.Get(1).(*helper.StandardError)
+}
+
+func (m *ImportUsecaseMock) GetImport(organizationId uint64, jobId uint64) (*repository.ImportJob, *helper.StandardError) {
+	args := m.Called(organizationId, jobId)
+	return args.Get(0).(*repository.ImportJob), args.Get(1).(*helper.StandardError)
+}
+
+func (m *ImportUsecaseMock) ListImports(organizationId uint64) (*[]repository.ImportJob, *helper.StandardError) {
+	args := m.Called(organizationId)
+	return args.Get(0).(*[]repository.ImportJob), args.Get(1).(*helper.StandardError)
+}
+
+func (m *ImportUsecaseMock) FailInterruptedImports() int64 {
+	args := m.Called()
+	return args.Get(0).(int64)
+}
@@ -0,0 +1,46 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type InvitationRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *InvitationRepositoryMock) Save(invitation repository.Invitation) repository.Invitation {
+	args := m.Called(invitation)
+	return args.Get(0).(repository.Invitation)
+}
+
+func (m *InvitationRepositoryMock) Update(invitation repository.Invitation) repository.Invitation {
+	args := m.Called(invitation)
+	return args.Get(0).(repository.Invitation)
+}
+
+func (m *InvitationRepositoryMock) FindById(organizationId uint64, id uint64) repository.Invitation {
+	args := m.Called(organizationId, id)
+	return args.Get(0).(repository.Invitation)
+}
+
+func (m *InvitationRepositoryMock) FindByTokenHash(tokenHash string) repository.Invitation {
+	args := m.Called(tokenHash)
+	return args.Get(0).(repository.Invitation)
+}
+
+func (m *InvitationRepositoryMock) FindByOrganizationId(organizationId uint64) []repository.PendingInvitation {
+	args := m.Called(organizationId)
+	return args.Get(0).([]repository.PendingInvitation)
+}
+
+func (m *InvitationRepositoryMock) Delete(id uint64) bool {
+	args := m.Called(id)
+	return args.Bool(0)
+}
+
+func (m *InvitationRepositoryMock) Accept(invitationId uint64, user repository.User) error {
+	args := m.Called(invitationId, user)
+	return args.Error(0)
+}
@@ -0,0 +1,37 @@
+package mocks
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+)
+
+type InvitationRouterMock struct {
+	mock.Mock
+}
+
+func (m *InvitationRouterMock) CreateInvitation(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "invitation created"})
+}
+
+func (m *InvitationRouterMock) ListInvitations(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "invitations listed"})
+}
+
+func (m *InvitationRouterMock) ResendInvitation(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "invitation resent"})
+}
+
+func (m *InvitationRouterMock) RevokeInvitation(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "invitation revoked"})
+}
+
+func (m *InvitationRouterMock) AcceptInvitation(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "invitation accepted"})
+}
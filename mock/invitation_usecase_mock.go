@@ -0,0 +1,37 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type InvitationUsecaseMock struct {
+	mock.Mock
+}
+
+func (m *InvitationUsecaseMock) CreateInvitation(organizationId uint64, invitationData repository.CreateInvitation, actorId uint64) (*repository.PendingInvitation, *helper.StandardError) {
+	args := m.Called(organizationId, invitationData, actorId)
+	return args.Get(0).(*repository.PendingInvitation), args.Get(1).(*helper.StandardError)
+}
+
+func (m *InvitationUsecaseMock) ListInvitations(organizationId uint64) (*[]repository.PendingInvitation, *helper.StandardError) {
+	args := m.Called(organizationId)
+	return args.Get(0).(*[]repository.PendingInvitation), args.Get(1).(*helper.StandardError)
+}
+
+func (m *InvitationUsecaseMock) ResendInvitation(organizationId uint64, invitationId uint64, actorId uint64) (*repository.PendingInvitation, *helper.StandardError) {
+	args := m.Called(organizationId, invitationId, actorId)
+	return args.Get(0).(*repository.PendingInvitation), args.Get(1).(*helper.StandardError)
+}
+
+func (m *InvitationUsecaseMock) RevokeInvitation(organizationId uint64, invitationId uint64, actorId uint64) (*repository.PendingInvitation, *helper.StandardError) {
+	args := m.Called(organizationId, invitationId, actorId)
+	return args.Get(0).(*repository.PendingInvitation), args.Get(1).(*helper.StandardError)
+}
+
+func (m *InvitationUsecaseMock) AcceptInvitation(acceptData repository.AcceptInvitation) (*repository.UserResponse, *helper.StandardError) {
+	args := m.Called(acceptData)
+	return args.Get(0).(*repository.UserResponse), args.Get(1).(*helper.StandardError)
+}
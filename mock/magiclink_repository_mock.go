@@ -0,0 +1,32 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/repository"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MagicLinkRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *MagicLinkRepositoryMock) Save(link repository.MagicLink) repository.MagicLink {
+	args := m.Called(link)
+	return args.Get(0).(repository.MagicLink)
+}
+
+func (m *MagicLinkRepositoryMock) FindByTokenHash(tokenHash string) repository.MagicLink {
+	args := m.Called()
+	return args.Get(0).(repository.MagicLink)
+}
+
+func (m *MagicLinkRepositoryMock) CountByUserIdSince(userId uint64, since time.Time) int64 {
+	args := m.Called()
+	return args.Get(0).(int64)
+}
+
+func (m *MagicLinkRepositoryMock) Delete(id uint64) bool {
+	args := m.Called()
+	return args.Bool(0)
+}
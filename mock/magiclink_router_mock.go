@@ -0,0 +1,22 @@
+package mocks
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+)
+
+type MagicLinkRouterMock struct {
+	mock.Mock
+}
+
+func (m *MagicLinkRouterMock) RequestLink(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "requested"})
+}
+
+func (m *MagicLinkRouterMock) ConsumeLink(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "consumed"})
+}
@@ -0,0 +1,22 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MagicLinkUsecaseMock struct {
+	mock.Mock
+}
+
+func (m *MagicLinkUsecaseMock) RequestLink(requestData repository.MagicLinkRequest, organizationId uint64) (string, *helper.StandardError) {
+	args := m.Called(requestData)
+	return args.String(0), args.Get(1).(*helper.StandardError)
+}
+
+func (m *MagicLinkUsecaseMock) ConsumeLink(token string, nonce string, loginContext repository.LoginContext) (string, *helper.StandardError) {
+	args := m.Called(token, nonce)
+	return args.String(0), args.Get(1).(*helper.StandardError)
+}
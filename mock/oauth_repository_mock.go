@@ -0,0 +1,103 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/repository"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type OAuthRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *OAuthRepositoryMock) SaveAuthorizationCode(code repository.AuthorizationCode) repository.AuthorizationCode {
+	args := m.Called(code)
+	return args.Get(0).(repository.AuthorizationCode)
+}
+
+func (m *OAuthRepositoryMock) FindAuthorizationCodeByHash(codeHash string) repository.AuthorizationCode {
+	args := m.Called()
+	return args.Get(0).(repository.AuthorizationCode)
+}
+
+func (m *OAuthRepositoryMock) MarkAuthorizationCodeUsed(id uint64) bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+func (m *OAuthRepositoryMock) SaveRefreshToken(refreshToken repository.RefreshToken) repository.RefreshToken {
+	args := m.Called()
+	return args.Get(0).(repository.RefreshToken)
+}
+
+func (m *OAuthRepositoryMock) FindRefreshTokenByHash(tokenHash string) repository.RefreshToken {
+	args := m.Called()
+	return args.Get(0).(repository.RefreshToken)
+}
+
+func (m *OAuthRepositoryMock) RevokeRefreshToken(id uint64) bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+func (m *OAuthRepositoryMock) RevokeRefreshTokens(userId uint64, clientId string) {
+	m.Called()
+}
+
+func (m *OAuthRepositoryMock) SaveConsent(consent repository.Consent) repository.Consent {
+	args := m.Called()
+	return args.Get(0).(repository.Consent)
+}
+
+func (m *OAuthRepositoryMock) FindConsent(userId uint64, clientId string) repository.Consent {
+	args := m.Called()
+	return args.Get(0).(repository.Consent)
+}
+
+func (m *OAuthRepositoryMock) FindConsentsByUserId(userId uint64) []repository.Consent {
+	args := m.Called()
+	return args.Get(0).([]repository.Consent)
+}
+
+func (m *OAuthRepositoryMock) DeleteConsent(id uint64) {
+	m.Called()
+}
+
+func (m *OAuthRepositoryMock) SaveRevokedToken(revokedToken repository.RevokedToken) {
+	m.Called(revokedToken)
+}
+
+func (m *OAuthRepositoryMock) IsTokenRevoked(jti string) bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+func (m *OAuthRepositoryMock) SaveDeviceCode(deviceCode repository.DeviceCode) repository.DeviceCode {
+	args := m.Called(deviceCode)
+	return args.Get(0).(repository.DeviceCode)
+}
+
+func (m *OAuthRepositoryMock) FindDeviceCodeByHash(deviceCodeHash string) repository.DeviceCode {
+	args := m.Called()
+	return args.Get(0).(repository.DeviceCode)
+}
+
+func (m *OAuthRepositoryMock) FindDeviceCodeByUserCode(userCode string) repository.DeviceCode {
+	args := m.Called(userCode)
+	return args.Get(0).(repository.DeviceCode)
+}
+
+func (m *OAuthRepositoryMock) UpdateDeviceCodePoll(id uint64, polledAt time.Time, interval int) {
+	m.Called(interval)
+}
+
+func (m *OAuthRepositoryMock) DecideDeviceCode(id uint64, userId uint64, status string, sessionId uint64) bool {
+	args := m.Called(userId, status)
+	return args.Bool(0)
+}
+
+func (m *OAuthRepositoryMock) MarkDeviceCodeUsed(id uint64) bool {
+	args := m.Called()
+	return args.Bool(0)
+}
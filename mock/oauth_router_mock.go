@@ -0,0 +1,82 @@
+package mocks
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+)
+
+type OAuthRouterMock struct {
+	mock.Mock
+}
+
+func (m *OAuthRouterMock) Token(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "token issued"})
+}
+
+func (m *OAuthRouterMock) CreateClient(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "client created"})
+}
+
+func (m *OAuthRouterMock) ListClients(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "clients listed"})
+}
+
+func (m *OAuthRouterMock) RotateClientSecret(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "client secret rotated"})
+}
+
+func (m *OAuthRouterMock) DisableClient(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "client disabled"})
+}
+
+func (m *OAuthRouterMock) Authorize(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "authorize page"})
+}
+
+func (m *OAuthRouterMock) AuthorizeDecision(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "authorize decision"})
+}
+
+func (m *OAuthRouterMock) ListConsents(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "consents listed"})
+}
+
+func (m *OAuthRouterMock) RevokeConsent(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "consent revoked"})
+}
+
+func (m *OAuthRouterMock) Introspect(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "token introspected"})
+}
+
+func (m *OAuthRouterMock) Revoke(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "token revoked"})
+}
+
+func (m *OAuthRouterMock) DeviceAuthorization(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "device authorized"})
+}
+
+func (m *OAuthRouterMock) DeviceVerification(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "device verification"})
+}
+
+func (m *OAuthRouterMock) DeviceDecision(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "device decided"})
+}
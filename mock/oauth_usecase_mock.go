@@ -0,0 +1,87 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type OAuthUsecaseMock struct {
+	mock.Mock
+}
+
+func (m *OAuthUsecaseMock) CreateClient(organizationId uint64, createClientData repository.CreateClient, currentUserId uint64) (*repository.CreatedClientResponse, *helper.StandardError) {
+	args := m.Called()
+	return args.Get(0).(*repository.CreatedClientResponse), args.Get(1).(*helper.StandardError)
+}
+
+func (m *OAuthUsecaseMock) ListClients(organizationId uint64) (*[]repository.OAuthClient, *helper.StandardError) {
+	args := m.Called()
+	return args.Get(0).(*[]repository.OAuthClient), args.Get(1).(*helper.StandardError)
+}
+
+func (m *OAuthUsecaseMock) RotateClientSecret(organizationId uint64, id uint64, currentUserId uint64) (*repository.CreatedClientResponse, *helper.StandardError) {
+	args := m.Called()
+	return args.Get(0).(*repository.CreatedClientResponse), args.Get(1).(*helper.StandardError)
+}
+
+func (m *OAuthUsecaseMock) DisableClient(organizationId uint64, id uint64, currentUserId uint64) (*repository.OAuthClient, *helper.StandardError) {
+	args := m.Called()
+	return args.Get(0).(*repository.OAuthClient), args.Get(1).(*helper.StandardError)
+}
+
+func (m *OAuthUsecaseMock) Token(tokenRequest repository.TokenRequest) (*repository.TokenResponse, *helper.StandardError) {
+	args := m.Called(tokenRequest)
+	return args.Get(0).(*repository.TokenResponse), args.Get(1).(*helper.StandardError)
+}
+
+func (m *OAuthUsecaseMock) Authorize(authorizeRequest repository.AuthorizeRequest) (*repository.AuthorizePrompt, *helper.StandardError) {
+	args := m.Called()
+	return args.Get(0).(*repository.AuthorizePrompt), args.Get(1).(*helper.StandardError)
+}
+
+func (m *OAuthUsecaseMock) Approve(decision repository.AuthorizeDecision) (*repository.AuthorizePrompt, *helper.StandardError) {
+	args := m.Called()
+	return args.Get(0).(*repository.AuthorizePrompt), args.Get(1).(*helper.StandardError)
+}
+
+func (m *OAuthUsecaseMock) ListConsents(userId uint64) (*[]repository.Consent, *helper.StandardError) {
+	args := m.Called()
+	return args.Get(0).(*[]repository.Consent), args.Get(1).(*helper.StandardError)
+}
+
+func (m *OAuthUsecaseMock) RevokeConsent(userId uint64, consentId uint64) (*repository.Consent, *helper.StandardError) {
+	args := m.Called()
+	return args.Get(0).(*repository.Consent), args.Get(1).(*helper.StandardError)
+}
+
+func (m *OAuthUsecaseMock) Introspect(introspectRequest repository.TokenHintRequest) (*repository.IntrospectionResponse, *helper.StandardError) {
+	args := m.Called(introspectRequest)
+	return args.Get(0).(*repository.IntrospectionResponse), args.Get(1).(*helper.StandardError)
+}
+
+func (m *OAuthUsecaseMock) Revoke(revokeRequest repository.TokenHintRequest) *helper.StandardError {
+	args := m.Called(revokeRequest)
+	return args.Get(0).(*helper.StandardError)
+}
+
+func (m *OAuthUsecaseMock) DeviceAuthorization(deviceRequest repository.DeviceAuthorizationRequest) (*repository.DeviceAuthorizationResponse, *helper.StandardError) {
+	args := m.Called(deviceRequest)
+	return args.Get(0).(*repository.DeviceAuthorizationResponse), args.Get(1).(*helper.StandardError)
+}
+
+func (m *OAuthUsecaseMock) DevicePrompt(userCode string) (*repository.DevicePrompt, *helper.StandardError) {
+	args := m.Called(userCode)
+	return args.Get(0).(*repository.DevicePrompt), args.Get(1).(*helper.StandardError)
+}
+
+func (m *OAuthUsecaseMock) SessionUser(sessionToken string) *repository.User {
+	args := m.Called(sessionToken)
+	return args.Get(0).(*repository.User)
+}
+
+func (m *OAuthUsecaseMock) ApproveDevice(decision repository.DeviceDecision) (*repository.DevicePrompt, *helper.StandardError) {
+	args := m.Called(decision)
+	return args.Get(0).(*repository.DevicePrompt), args.Get(1).(*helper.StandardError)
+}
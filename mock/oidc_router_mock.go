@@ -0,0 +1,32 @@
+package mocks
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+)
+
+type OIDCRouterMock struct {
+	mock.Mock
+}
+
+func (m *OIDCRouterMock) Discovery(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "discovery document"})
+}
+
+func (m *OIDCRouterMock) JWKS(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "key set"})
+}
+
+func (m *OIDCRouterMock) UserInfo(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "user info"})
+}
+
+func (m *OIDCRouterMock) Logout(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "logged out"})
+}
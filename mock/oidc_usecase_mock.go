@@ -0,0 +1,32 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type OIDCUsecaseMock struct {
+	mock.Mock
+}
+
+func (m *OIDCUsecaseMock) Discovery() *repository.DiscoveryDocument {
+	args := m.Called()
+	return args.Get(0).(*repository.DiscoveryDocument)
+}
+
+func (m *OIDCUsecaseMock) JWKS() (*repository.JSONWebKeySet, *helper.StandardError) {
+	args := m.Called()
+	return args.Get(0).(*repository.JSONWebKeySet), args.Get(1).(*helper.StandardError)
+}
+
+func (m *OIDCUsecaseMock) UserInfo(userId uint64, scopes []string) (*repository.UserInfo, *helper.StandardError) {
+	args := m.Called(scopes)
+	return args.Get(0).(*repository.UserInfo), args.Get(1).(*helper.StandardError)
+}
+
+func (m *OIDCUsecaseMock) Logout(logoutRequest repository.LogoutRequest) (*repository.LogoutResult, *helper.StandardError) {
+	args := m.Called(logoutRequest)
+	return args.Get(0).(*repository.LogoutResult), args.Get(1).(*helper.StandardError)
+}
@@ -0,0 +1,51 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type OrganizationRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *OrganizationRepositoryMock) Save(organization repository.Organization) repository.Organization {
+	args := m.Called(organization)
+	return args.Get(0).(repository.Organization)
+}
+
+func (m *OrganizationRepositoryMock) FindById(id uint64) repository.Organization {
+	args := m.Called(id)
+	return args.Get(0).(repository.Organization)
+}
+
+func (m *OrganizationRepositoryMock) FindBySlug(slug string) repository.Organization {
+	args := m.Called(slug)
+	return args.Get(0).(repository.Organization)
+}
+
+func (m *OrganizationRepositoryMock) FindByUserId(userId uint64) []repository.OrganizationMembership {
+	args := m.Called(userId)
+	return args.Get(0).([]repository.OrganizationMembership)
+}
+
+func (m *OrganizationRepositoryMock) SaveMembership(membership repository.Membership) repository.Membership {
+	args := m.Called(membership)
+	return args.Get(0).(repository.Membership)
+}
+
+func (m *OrganizationRepositoryMock) FindMembership(organizationId uint64, userId uint64) repository.Membership {
+	args := m.Called(organizationId, userId)
+	return args.Get(0).(repository.Membership)
+}
+
+func (m *OrganizationRepositoryMock) FindMemberships(organizationId uint64) []repository.Membership {
+	args := m.Called(organizationId)
+	return args.Get(0).([]repository.Membership)
+}
+
+func (m *OrganizationRepositoryMock) DeleteMembership(organizationId uint64, userId uint64) bool {
+	args := m.Called(organizationId, userId)
+	return args.Bool(0)
+}
@@ -0,0 +1,42 @@
+package mocks
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+)
+
+type OrganizationRouterMock struct {
+	mock.Mock
+}
+
+func (m *OrganizationRouterMock) CreateOrganization(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "organization created"})
+}
+
+func (m *OrganizationRouterMock) ListOrganizations(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "organizations listed"})
+}
+
+func (m *OrganizationRouterMock) ListMembers(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "members listed"})
+}
+
+func (m *OrganizationRouterMock) SetMember(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "member set"})
+}
+
+func (m *OrganizationRouterMock) RemoveMember(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "member removed"})
+}
+
+func (m *OrganizationRouterMock) SwitchOrganization(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "organization switched"})
+}
@@ -0,0 +1,55 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+)
+
+type OrganizationUsecaseMock struct {
+	mock.Mock
+}
+
+func (m *OrganizationUsecaseMock) ResolveOrganization(c *gin.Context) {
+	c.Next()
+}
+
+func (m *OrganizationUsecaseMock) RequireAdmin(c *gin.Context) {
+	c.Next()
+}
+
+func (m *OrganizationUsecaseMock) RequireOpenRegistration(c *gin.Context) {
+	c.Next()
+}
+
+func (m *OrganizationUsecaseMock) CreateOrganization(userId uint64, createData repository.CreateOrganization) (*repository.Organization, *helper.StandardError) {
+	args := m.Called(userId, createData)
+	return args.Get(0).(*repository.Organization), args.Get(1).(*helper.StandardError)
+}
+
+func (m *OrganizationUsecaseMock) ListOrganizations(userId uint64) (*[]repository.OrganizationMembership, *helper.StandardError) {
+	args := m.Called(userId)
+	return args.Get(0).(*[]repository.OrganizationMembership), args.Get(1).(*helper.StandardError)
+}
+
+func (m *OrganizationUsecaseMock) ListMembers(organizationId uint64, actorId uint64) (*[]repository.Membership, *helper.StandardError) {
+	args := m.Called(organizationId, actorId)
+	return args.Get(0).(*[]repository.Membership), args.Get(1).(*helper.StandardError)
+}
+
+func (m *OrganizationUsecaseMock) SetMember(organizationId uint64, userId uint64, membershipData repository.SetMembership, actorId uint64) (*repository.Membership, *helper.StandardError) {
+	args := m.Called(organizationId, userId, membershipData, actorId)
+	return args.Get(0).(*repository.Membership), args.Get(1).(*helper.StandardError)
+}
+
+func (m *OrganizationUsecaseMock) RemoveMember(organizationId uint64, userId uint64, actorId uint64) *helper.StandardError {
+	args := m.Called(organizationId, userId, actorId)
+	return args.Get(0).(*helper.StandardError)
+}
+
+func (m *OrganizationUsecaseMock) SwitchOrganization(organizationId uint64, userId uint64, loginContext repository.LoginContext) (string, *helper.StandardError) {
+	args := m.Called(organizationId, userId)
+	return args.String(0), args.Get(1).(*helper.StandardError)
+}
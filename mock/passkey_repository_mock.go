@@ -0,0 +1,50 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type PasskeyRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *PasskeyRepositoryMock) SaveCredential(credential repository.PasskeyCredential) repository.PasskeyCredential {
+	args := m.Called(credential)
+	return args.Get(0).(repository.PasskeyCredential)
+}
+
+func (m *PasskeyRepositoryMock) UpdateCredential(credential repository.PasskeyCredential) repository.PasskeyCredential {
+	args := m.Called(credential)
+	return args.Get(0).(repository.PasskeyCredential)
+}
+
+func (m *PasskeyRepositoryMock) FindCredentialsByUserId(userId uint64) []repository.PasskeyCredential {
+	args := m.Called()
+	return args.Get(0).([]repository.PasskeyCredential)
+}
+
+func (m *PasskeyRepositoryMock) FindCredentialByCredentialId(credentialId string) repository.PasskeyCredential {
+	args := m.Called()
+	return args.Get(0).(repository.PasskeyCredential)
+}
+
+func (m *PasskeyRepositoryMock) DeleteCredential(id uint64) {
+	m.Called()
+}
+
+func (m *PasskeyRepositoryMock) SaveSession(session repository.PasskeySession) repository.PasskeySession {
+	args := m.Called(session)
+	return args.Get(0).(repository.PasskeySession)
+}
+
+func (m *PasskeyRepositoryMock) FindSessionByHash(sessionHash string) repository.PasskeySession {
+	args := m.Called()
+	return args.Get(0).(repository.PasskeySession)
+}
+
+func (m *PasskeyRepositoryMock) DeleteSession(id uint64) bool {
+	args := m.Called()
+	return args.Bool(0)
+}
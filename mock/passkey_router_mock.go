@@ -0,0 +1,47 @@
+package mocks
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+)
+
+type PasskeyRouterMock struct {
+	mock.Mock
+}
+
+func (m *PasskeyRouterMock) BeginRegistration(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "begun"})
+}
+
+func (m *PasskeyRouterMock) FinishRegistration(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "registered"})
+}
+
+func (m *PasskeyRouterMock) ListPasskeys(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "listed"})
+}
+
+func (m *PasskeyRouterMock) RemovePasskey(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "removed"})
+}
+
+func (m *PasskeyRouterMock) UpdateSettings(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "updated"})
+}
+
+func (m *PasskeyRouterMock) BeginLogin(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "begun"})
+}
+
+func (m *PasskeyRouterMock) FinishLogin(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "logged in"})
+}
@@ -0,0 +1,47 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type PasskeyUsecaseMock struct {
+	mock.Mock
+}
+
+func (m *PasskeyUsecaseMock) BeginRegistration(userId uint64) (*repository.PasskeyCeremony, *helper.StandardError) {
+	args := m.Called(userId)
+	return args.Get(0).(*repository.PasskeyCeremony), args.Get(1).(*helper.StandardError)
+}
+
+func (m *PasskeyUsecaseMock) FinishRegistration(userId uint64, registration repository.PasskeyRegistration) (*repository.PasskeyCredential, *helper.StandardError) {
+	args := m.Called(userId, registration)
+	return args.Get(0).(*repository.PasskeyCredential), args.Get(1).(*helper.StandardError)
+}
+
+func (m *PasskeyUsecaseMock) BeginLogin(loginData repository.PasskeyLoginBegin, organizationId uint64) (*repository.PasskeyCeremony, *helper.StandardError) {
+	args := m.Called(loginData)
+	return args.Get(0).(*repository.PasskeyCeremony), args.Get(1).(*helper.StandardError)
+}
+
+func (m *PasskeyUsecaseMock) FinishLogin(loginData repository.PasskeyLoginFinish, loginContext repository.LoginContext) (string, *helper.StandardError) {
+	args := m.Called(loginData)
+	return args.String(0), args.Get(1).(*helper.StandardError)
+}
+
+func (m *PasskeyUsecaseMock) ListPasskeys(userId uint64) (*[]repository.PasskeyCredential, *helper.StandardError) {
+	args := m.Called(userId)
+	return args.Get(0).(*[]repository.PasskeyCredential), args.Get(1).(*helper.StandardError)
+}
+
+func (m *PasskeyUsecaseMock) RemovePasskey(userId uint64, passkeyId uint64) (*repository.PasskeyCredential, *helper.StandardError) {
+	args := m.Called(userId, passkeyId)
+	return args.Get(0).(*repository.PasskeyCredential), args.Get(1).(*helper.StandardError)
+}
+
+func (m *PasskeyUsecaseMock) UpdateSettings(userId uint64, settings repository.PasskeySettings) (*repository.PasskeySettings, *helper.StandardError) {
+	args := m.Called(userId, settings)
+	return args.Get(0).(*repository.PasskeySettings), args.Get(1).(*helper.StandardError)
+}
@@ -0,0 +1,17 @@
+package mocks
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+)
+
+type PolicyRouterMock struct {
+	mock.Mock
+}
+
+func (m *PolicyRouterMock) Check(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "authorization checked"})
+}
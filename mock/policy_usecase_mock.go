@@ -0,0 +1,29 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+)
+
+type PolicyUsecaseMock struct {
+	mock.Mock
+}
+
+func (m *PolicyUsecaseMock) Authorize(action string, resourceType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+	}
+}
+
+func (m *PolicyUsecaseMock) Check(organizationId uint64, request repository.AuthorizationRequest) (*repository.AuthorizationDecision, *helper.StandardError) {
+	args := m.Called(organizationId, request)
+	return args.Get(0).(*repository.AuthorizationDecision), args.Get(1).(*helper.StandardError)
+}
+
+func (m *PolicyUsecaseMock) Enforce(organizationId uint64, subject map[string]interface{}, action string, resource map[string]interface{}) *helper.StandardError {
+	args := m.Called(organizationId, subject, action, resource)
+	return args.Get(0).(*helper.StandardError)
+}
@@ -0,0 +1,48 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/repository"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type PrivacyRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *PrivacyRepositoryMock) SaveErasure(request repository.ErasureRequest) repository.ErasureRequest {
+	args := m.Called(request)
+	if save, ok := args.Get(0).(func(repository.ErasureRequest) repository.ErasureRequest); ok {
+		return save(request)
+	}
+	return args.Get(0).(repository.ErasureRequest)
+}
+
+func (m *PrivacyRepositoryMock) UpdateErasure(request repository.ErasureRequest) repository.ErasureRequest {
+	args := m.Called(request)
+	if update, ok := args.Get(0).(func(repository.ErasureRequest) repository.ErasureRequest); ok {
+		return update(request)
+	}
+	return args.Get(0).(repository.ErasureRequest)
+}
+
+func (m *PrivacyRepositoryMock) FindLatestErasure(userId uint64) repository.ErasureRequest {
+	args := m.Called(userId)
+	return args.Get(0).(repository.ErasureRequest)
+}
+
+func (m *PrivacyRepositoryMock) FindDueErasures(now time.Time) []repository.ErasureRequest {
+	args := m.Called(now)
+	return args.Get(0).([]repository.ErasureRequest)
+}
+
+func (m *PrivacyRepositoryMock) FindPersonalData(userId uint64) repository.PersonalData {
+	args := m.Called(userId)
+	return args.Get(0).(repository.PersonalData)
+}
+
+func (m *PrivacyRepositoryMock) EraseUser(anonymized repository.User) error {
+	args := m.Called(anonymized)
+	return args.Error(0)
+}
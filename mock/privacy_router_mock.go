@@ -0,0 +1,32 @@
+package mocks
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+)
+
+type PrivacyRouterMock struct {
+	mock.Mock
+}
+
+func (m *PrivacyRouterMock) ExportData(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "data exported"})
+}
+
+func (m *PrivacyRouterMock) RequestErasure(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "erasure requested"})
+}
+
+func (m *PrivacyRouterMock) GetErasure(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "erasure found"})
+}
+
+func (m *PrivacyRouterMock) CancelErasure(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "erasure cancelled"})
+}
@@ -0,0 +1,37 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type PrivacyUsecaseMock struct {
+	mock.Mock
+}
+
+func (m *PrivacyUsecaseMock) ExportData(userId uint64) ([]byte, *helper.StandardError) {
+	args := m.Called(userId)
+	return args.Get(0).([]byte), args.Get(1).(*helper.StandardError)
+}
+
+func (m *PrivacyUsecaseMock) RequestErasure(userId uint64, sessionId uint64, confirmation repository.ErasureConfirmation) (*repository.ErasureRequest, *helper.StandardError) {
+	args := m.Called(userId, confirmation)
+	return args.Get(0).(*repository.ErasureRequest), args.Get(1).(*helper.StandardError)
+}
+
+func (m *PrivacyUsecaseMock) GetErasure(userId uint64) (*repository.ErasureRequest, *helper.StandardError) {
+	args := m.Called(userId)
+	return args.Get(0).(*repository.ErasureRequest), args.Get(1).(*helper.StandardError)
+}
+
+func (m *PrivacyUsecaseMock) CancelErasure(userId uint64) (*repository.ErasureRequest, *helper.StandardError) {
+	args := m.Called(userId)
+	return args.Get(0).(*repository.ErasureRequest), args.Get(1).(*helper.StandardError)
+}
+
+func (m *PrivacyUsecaseMock) ProcessErasures() (int, *helper.StandardError) {
+	args := m.Called()
+	return args.Int(0), args.Get(1).(*helper.StandardError)
+}
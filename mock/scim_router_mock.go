@@ -0,0 +1,42 @@
+package mocks
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+)
+
+type SCIMRouterMock struct {
+	mock.Mock
+}
+
+func (m *SCIMRouterMock) ListUsers(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "listed"})
+}
+
+func (m *SCIMRouterMock) GetUser(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "found"})
+}
+
+func (m *SCIMRouterMock) CreateUser(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusCreated, gin.H{"status": "created"})
+}
+
+func (m *SCIMRouterMock) ReplaceUser(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "replaced"})
+}
+
+func (m *SCIMRouterMock) PatchUser(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "patched"})
+}
+
+func (m *SCIMRouterMock) DeleteUser(c *gin.Context) {
+	m.Called(c)
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,42 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type SCIMUsecaseMock struct {
+	mock.Mock
+}
+
+func (m *SCIMUsecaseMock) ListUsers(listRequest repository.SCIMListRequest) (*repository.SCIMListResponse, *helper.StandardError) {
+	args := m.Called(listRequest)
+	return args.Get(0).(*repository.SCIMListResponse), args.Get(1).(*helper.StandardError)
+}
+
+func (m *SCIMUsecaseMock) GetUser(id string) (*repository.SCIMUser, *helper.StandardError) {
+	args := m.Called(id)
+	return args.Get(0).(*repository.SCIMUser), args.Get(1).(*helper.StandardError)
+}
+
+func (m *SCIMUsecaseMock) CreateUser(resource repository.SCIMUser) (*repository.SCIMUser, *helper.StandardError) {
+	args := m.Called(resource)
+	return args.Get(0).(*repository.SCIMUser), args.Get(1).(*helper.StandardError)
+}
+
+func (m *SCIMUsecaseMock) ReplaceUser(id string, resource repository.SCIMUser, ifMatch string) (*repository.SCIMUser, *helper.StandardError) {
+	args := m.Called(id, resource, ifMatch)
+	return args.Get(0).(*repository.SCIMUser), args.Get(1).(*helper.StandardError)
+}
+
+func (m *SCIMUsecaseMock) PatchUser(id string, patchRequest repository.SCIMPatchRequest, ifMatch string) (*repository.SCIMUser, *helper.StandardError) {
+	args := m.Called(id, patchRequest, ifMatch)
+	return args.Get(0).(*repository.SCIMUser), args.Get(1).(*helper.StandardError)
+}
+
+func (m *SCIMUsecaseMock) DeleteUser(id string, ifMatch string) *helper.StandardError {
+	args := m.Called(id, ifMatch)
+	return args.Get(0).(*helper.StandardError)
+}
@@ -0,0 +1,51 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/repository"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type SessionRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *SessionRepositoryMock) Save(session repository.Session) repository.Session {
+	args := m.Called(session)
+	return args.Get(0).(repository.Session)
+}
+
+func (m *SessionRepositoryMock) FindByHash(sessionHash string) repository.Session {
+	args := m.Called()
+	return args.Get(0).(repository.Session)
+}
+
+func (m *SessionRepositoryMock) Touch(id uint64, lastSeenAt time.Time, ipAddress string, expiresAt time.Time) {
+	m.Called(id, expiresAt)
+}
+
+func (m *SessionRepositoryMock) Delete(id uint64) bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+func (m *SessionRepositoryMock) FindById(id uint64) repository.Session {
+	args := m.Called()
+	return args.Get(0).(repository.Session)
+}
+
+func (m *SessionRepositoryMock) FindByUserId(userId uint64) []repository.Session {
+	args := m.Called()
+	return args.Get(0).([]repository.Session)
+}
+
+func (m *SessionRepositoryMock) DeleteExpired() int64 {
+	args := m.Called()
+	return args.Get(0).(int64)
+}
+
+func (m *SessionRepositoryMock) DeleteByUserIdAndOrganizationId(userId uint64, organizationId uint64) int64 {
+	args := m.Called(userId, organizationId)
+	return args.Get(0).(int64)
+}
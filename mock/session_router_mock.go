@@ -0,0 +1,32 @@
+package mocks
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+)
+
+type SessionRouterMock struct {
+	mock.Mock
+}
+
+func (m *SessionRouterMock) ListSessions(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "sessions listed"})
+}
+
+func (m *SessionRouterMock) RevokeSession(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "session revoked"})
+}
+
+func (m *SessionRouterMock) ListUserSessions(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "user sessions listed"})
+}
+
+func (m *SessionRouterMock) RevokeUserSession(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "user session revoked"})
+}
@@ -0,0 +1,37 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type SessionUsecaseMock struct {
+	mock.Mock
+}
+
+func (m *SessionUsecaseMock) ListSessions(userId uint64, currentSessionId uint64) (*[]repository.Session, *helper.StandardError) {
+	args := m.Called(userId, currentSessionId)
+	return args.Get(0).(*[]repository.Session), args.Get(1).(*helper.StandardError)
+}
+
+func (m *SessionUsecaseMock) RevokeSession(userId uint64, sessionId uint64, actorId uint64) *helper.StandardError {
+	args := m.Called(userId, sessionId, actorId)
+	return args.Get(0).(*helper.StandardError)
+}
+
+func (m *SessionUsecaseMock) ListUserSessions(organizationId uint64, userId uint64) (*[]repository.Session, *helper.StandardError) {
+	args := m.Called(organizationId, userId)
+	return args.Get(0).(*[]repository.Session), args.Get(1).(*helper.StandardError)
+}
+
+func (m *SessionUsecaseMock) DeleteExpiredSessions() int64 {
+	args := m.Called()
+	return args.Get(0).(int64)
+}
+
+func (m *SessionUsecaseMock) RevokeUserSession(organizationId uint64, userId uint64, sessionId uint64, actorId uint64) *helper.StandardError {
+	args := m.Called(organizationId, userId, sessionId, actorId)
+	return args.Get(0).(*helper.StandardError)
+}
@@ -0,0 +1,41 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/repository"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type PersonalAccessTokenRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *PersonalAccessTokenRepositoryMock) Save(token repository.PersonalAccessToken) repository.PersonalAccessToken {
+	args := m.Called()
+	return args.Get(0).(repository.PersonalAccessToken)
+}
+
+func (m *PersonalAccessTokenRepositoryMock) Update(token repository.PersonalAccessToken) repository.PersonalAccessToken {
+	args := m.Called()
+	return args.Get(0).(repository.PersonalAccessToken)
+}
+
+func (m *PersonalAccessTokenRepositoryMock) UpdateLastUsed(id uint64, usedAt time.Time, ip string) {
+	m.Called(id, ip)
+}
+
+func (m *PersonalAccessTokenRepositoryMock) FindById(id uint64) repository.PersonalAccessToken {
+	args := m.Called()
+	return args.Get(0).(repository.PersonalAccessToken)
+}
+
+func (m *PersonalAccessTokenRepositoryMock) FindByHash(tokenHash string) repository.PersonalAccessToken {
+	args := m.Called()
+	return args.Get(0).(repository.PersonalAccessToken)
+}
+
+func (m *PersonalAccessTokenRepositoryMock) FindByUserId(userId uint64) []repository.PersonalAccessToken {
+	args := m.Called()
+	return args.Get(0).([]repository.PersonalAccessToken)
+}
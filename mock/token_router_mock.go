@@ -0,0 +1,27 @@
+package mocks
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+)
+
+type TokenRouterMock struct {
+	mock.Mock
+}
+
+func (m *TokenRouterMock) CreateToken(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "token created"})
+}
+
+func (m *TokenRouterMock) ListTokens(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "tokens listed"})
+}
+
+func (m *TokenRouterMock) RevokeToken(c *gin.Context) {
+	m.Called(c)
+	c.JSON(http.StatusOK, gin.H{"status": "token revoked"})
+}
@@ -0,0 +1,27 @@
+package mocks
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type TokenUsecaseMock struct {
+	mock.Mock
+}
+
+func (m *TokenUsecaseMock) CreateToken(userId uint64, callerScopes []string, createTokenData repository.CreateToken) (*repository.CreatedTokenResponse, *helper.StandardError) {
+	args := m.Called(callerScopes)
+	return args.Get(0).(*repository.CreatedTokenResponse), args.Get(1).(*helper.StandardError)
+}
+
+func (m *TokenUsecaseMock) ListTokens(userId uint64) (*[]repository.PersonalAccessToken, *helper.StandardError) {
+	args := m.Called()
+	return args.Get(0).(*[]repository.PersonalAccessToken), args.Get(1).(*helper.StandardError)
+}
+
+func (m *TokenUsecaseMock) RevokeToken(userId uint64, tokenId uint64) (*repository.PersonalAccessToken, *helper.StandardError) {
+	args := m.Called()
+	return args.Get(0).(*repository.PersonalAccessToken), args.Get(1).(*helper.StandardError)
+}
@@ -2,12 +2,15 @@ package mocks
 
 import (
 	"andikawhy/go-user-management/repository"
+	"fmt"
 
 	"github.com/stretchr/testify/mock"
 )
 
 type UserRepositoryMock struct {
 	mock.Mock
+	OrganizationID   uint64
+	AttributeFilters map[string]string
 }
 
 func (m *UserRepositoryMock) FindByUsername(username string) repository.User {
@@ -15,6 +18,11 @@ func (m *UserRepositoryMock) FindByUsername(username string) repository.User {
 	return args.Get(0).(repository.User)
 }
 
+func (m *UserRepositoryMock) FindByEmail(email string) repository.User {
+	args := m.Called()
+	return args.Get(0).(repository.User)
+}
+
 func (m *UserRepositoryMock) FindById(id uint64) repository.User {
 	args := m.Called()
 	return args.Get(0).(repository.User)
@@ -30,7 +38,67 @@ func (m *UserRepositoryMock) Save(user repository.User) repository.User {
 	return args.Get(0).(repository.User)
 }
 
+// filtered keeps the configured users that match the filters of WithAttributes, like the database would.
+func (m *UserRepositoryMock) filtered(users []repository.User) []repository.User {
+	matched := []repository.User{}
+	for _, user := range users {
+		matches := true
+		for name, expected := range m.AttributeFilters {
+			if value, ok := user.Attributes[name]; !ok || fmt.Sprint(value) != expected {
+				matches = false
+			}
+		}
+		if matches {
+			matched = append(matched, user)
+		}
+	}
+	return matched
+}
+
 func (m *UserRepositoryMock) FindAll() []repository.User {
 	args := m.Called()
-	return args.Get(0).([]repository.User)
+	return m.filtered(args.Get(0).([]repository.User))
+}
+
+// Each calls callback for every configured user, then returns the configured error.
+func (m *UserRepositoryMock) Each(callback func(user repository.User) error) error {
+	args := m.Called()
+	for _, user := range m.filtered(args.Get(0).([]repository.User)) {
+		if err := callback(user); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+// Update returns the configured user, or the result of calling a configured func(repository.User) repository.User.
+func (m *UserRepositoryMock) Update(user repository.User) repository.User {
+	args := m.Called(user)
+	if update, ok := args.Get(0).(func(repository.User) repository.User); ok {
+		return update(user)
+	}
+	return args.Get(0).(repository.User)
+}
+
+func (m *UserRepositoryMock) ReplacePassword(id uint64, currentHash string, passwordHash string) bool {
+	args := m.Called(passwordHash)
+	return args.Bool(0)
+}
+
+func (m *UserRepositoryMock) UpdateAvatar(id uint64, avatarKey string, avatarURL string) bool {
+	args := m.Called(avatarKey, avatarURL)
+	return args.Bool(0)
+}
+
+// ForOrganization returns the same mock and remembers the organization so tests can assert the scope.
+func (m *UserRepositoryMock) ForOrganization(organizationId uint64) repository.UserRepository {
+	m.OrganizationID = organizationId
+	m.AttributeFilters = nil
+	return m
+}
+
+// WithAttributes returns the same mock; FindAll and Each then only return users matching filters.
+func (m *UserRepositoryMock) WithAttributes(filters map[string]string) repository.UserRepository {
+	m.AttributeFilters = filters
+	return m
 }
@@ -25,3 +25,8 @@ func (m *UserRouterMock) RemoveUser(c *gin.Context) {
 	m.Called(c)
 	c.JSON(http.StatusOK, gin.H{"status": "user removed"})
 }
+
+func (m *UserRouterMock) ExportUsers(c *gin.Context) {
+	m.Called(c)
+	c.String(http.StatusOK, "id,username\n")
+}
@@ -3,6 +3,7 @@ package mocks
 import (
 	"andikawhy/go-user-management/helper"
 	"andikawhy/go-user-management/repository"
+	"io"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -11,12 +12,17 @@ type UserUsecaseMock struct {
 	mock.Mock
 }
 
-func (m *UserUsecaseMock) RemoveUser(deletedUserID uint64, currentUserId uint64) (*repository.UserResponse, *helper.StandardError) {
+func (m *UserUsecaseMock) RemoveUser(organizationId uint64, deletedUserID uint64, currentUserId uint64) (*repository.UserResponse, *helper.StandardError) {
 	args := m.Called()
 	return args.Get(0).(*repository.UserResponse), args.Get(1).(*helper.StandardError)
 }
 
-func (m *UserUsecaseMock) ListUsers() (*[]repository.UserResponse, *helper.StandardError) {
+func (m *UserUsecaseMock) ListUsers(organizationId uint64, attributeFilters map[string]string) (*[]repository.UserResponse, *helper.StandardError) {
 	args := m.Called()
 	return args.Get(0).(*[]repository.UserResponse), args.Get(1).(*helper.StandardError)
 }
+
+func (m *UserUsecaseMock) ExportUsers(organizationId uint64, exportData repository.UserExport, attributeFilters map[string]string, writer io.Writer, actorId uint64) *helper.StandardError {
+	args := m.Called(organizationId, exportData, attributeFilters, writer, actorId)
+	return args.Get(0).(*helper.StandardError)
+}
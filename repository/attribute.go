@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	AttributeTypeString  = "string"
+	AttributeTypeNumber  = "number"
+	AttributeTypeBoolean = "boolean"
+)
+
+// UserAttributes holds the values of the custom attributes defined for the user's organization.
+// It is stored as JSONB on postgres and as JSON text elsewhere.
+type UserAttributes map[string]interface{}
+
+func (a UserAttributes) Value() (driver.Value, error) {
+	if a == nil {
+		return "{}", nil
+	}
+	value, err := json.Marshal(a)
+	return string(value), err
+}
+
+func (a *UserAttributes) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported user attributes value")
+	}
+	return json.Unmarshal(data, a)
+}
+
+func (UserAttributes) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	if db.Dialector.Name() == "postgres" {
+		return "JSONB"
+	}
+	return "JSON"
+}
+
+// attributeValueSQL is the text form of a user attribute, as filters and unique checks compare it. The name is
+// inlined rather than bound so that queries match the expression indexes of IndexUsers. SQLite returns JSON
+// booleans as 1 and 0, so they are spelled out there.
+func attributeValueSQL(db *gorm.DB, name string) string {
+	key := "'" + strings.ReplaceAll(name, "'", "''") + "'"
+	if db.Dialector.Name() == "postgres" {
+		return fmt.Sprintf("(attributes->>%s)", key)
+	}
+	return fmt.Sprintf("(CASE json_type(attributes->%s) WHEN 'true' THEN 'true' WHEN 'false' THEN 'false' ELSE CAST(attributes->>%s AS TEXT) END)", key, key)
+}
+
+func attributeIndexName(definitionId uint64) string {
+	return fmt.Sprintf("idx_users_attribute_%d", definitionId)
+}
+
+// attributeIndexSQL creates the index of a definition on the users of its organization. Unique definitions get a
+// unique index, so concurrent writes cannot store the same value twice.
+func attributeIndexSQL(db *gorm.DB, definition AttributeDefinition) string {
+	unique := ""
+	if definition.Unique {
+		unique = "UNIQUE "
+	}
+	return fmt.Sprintf("CREATE %sINDEX IF NOT EXISTS %s ON users (%s) WHERE organization_id = %d",
+		unique, attributeIndexName(definition.ID), attributeValueSQL(db, definition.Name), definition.OrganizationID)
+}
+
+// AttributeDefinition declares a custom attribute users of the organization may or must carry.
+// Pattern and Enum only apply to string attributes.
+type AttributeDefinition struct {
+	ID             uint64    `json:"id" gorm:"primary_key"`
+	OrganizationID uint64    `json:"organizationid" gorm:"uniqueIndex:idx_attribute_definitions_organization_name"`
+	Name           string    `json:"name" gorm:"uniqueIndex:idx_attribute_definitions_organization_name"`
+	Type           string    `json:"type"`
+	Required       bool      `json:"required"`
+	Unique         bool      `json:"unique"`
+	Pattern        string    `json:"pattern"`
+	Enum           []string  `json:"enum" gorm:"serializer:json"`
+	CreatedAt      time.Time `json:"createdat"`
+	UpdatedAt      time.Time `json:"updatedat"`
+}
+
+type SaveAttributeDefinition struct {
+	Name     string   `json:"name" binding:"required,max=64"`
+	Type     string   `json:"type" binding:"required,oneof=string number boolean"`
+	Required bool     `json:"required"`
+	Unique   bool     `json:"unique"`
+	Pattern  string   `json:"pattern"`
+	Enum     []string `json:"enum"`
+}
+
+type SetUserAttributes struct {
+	Attributes UserAttributes `json:"attributes" binding:"required"`
+}
+
+type AttributeRepository interface {
+	Save(definition AttributeDefinition) AttributeDefinition
+	Update(definition AttributeDefinition) AttributeDefinition
+	FindById(organizationId uint64, id uint64) AttributeDefinition
+	FindByName(organizationId uint64, name string) AttributeDefinition
+	FindByOrganizationId(organizationId uint64) []AttributeDefinition
+	Delete(organizationId uint64, id uint64) bool
+	IndexUsers(definition AttributeDefinition) error
+}
+
+type AttributeRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func (t *AttributeRepositoryImpl) Save(definition AttributeDefinition) AttributeDefinition {
+	t.Db.Create(&definition)
+	return definition
+}
+
+func (t *AttributeRepositoryImpl) Update(definition AttributeDefinition) AttributeDefinition {
+	t.Db.Save(&definition)
+	return definition
+}
+
+func (t *AttributeRepositoryImpl) FindById(organizationId uint64, id uint64) AttributeDefinition {
+	var definition AttributeDefinition
+	t.Db.Where("organization_id=? AND id=?", organizationId, id).Find(&definition)
+	return definition
+}
+
+func (t *AttributeRepositoryImpl) FindByName(organizationId uint64, name string) AttributeDefinition {
+	var definition AttributeDefinition
+	t.Db.Where("organization_id=? AND name=?", organizationId, name).Find(&definition)
+	return definition
+}
+
+func (t *AttributeRepositoryImpl) FindByOrganizationId(organizationId uint64) []AttributeDefinition {
+	var definitions []AttributeDefinition
+	t.Db.Where("organization_id=?", organizationId).Order("name asc").Find(&definitions)
+	return definitions
+}
+
+// Delete removes the definition and its index; values already stored on users stay until their attributes are next
+// replaced.
+func (t *AttributeRepositoryImpl) Delete(organizationId uint64, id uint64) bool {
+	result := t.Db.Where("organization_id=? AND id=?", organizationId, id).Delete(&AttributeDefinition{})
+	if result.Error != nil || result.RowsAffected != 1 {
+		return false
+	}
+	t.Db.Exec("DROP INDEX IF EXISTS " + attributeIndexName(id))
+	return true
+}
+
+// IndexUsers rebuilds the index of the definition. Building a unique index fails while stored values repeat, and the
+// previous index is then kept.
+func (t *AttributeRepositoryImpl) IndexUsers(definition AttributeDefinition) error {
+	return t.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DROP INDEX IF EXISTS " + attributeIndexName(definition.ID)).Error; err != nil {
+			return err
+		}
+		return tx.Exec(attributeIndexSQL(tx, definition)).Error
+	})
+}
+
+func NewAttributeRepositoryImpl(Db *gorm.DB) AttributeRepository {
+	return &AttributeRepositoryImpl{Db: Db}
+}
@@ -0,0 +1,48 @@
+package repository_test
+
+import (
+	"andikawhy/go-user-management/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestAttributeRepositoryImpl(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	err := db.AutoMigrate(&repository.AttributeDefinition{}, &repository.User{})
+	if err != nil {
+		t.Fatalf("Error migrating database: %v", err)
+	}
+	repo := repository.NewAttributeRepositoryImpl(db)
+
+	department := repo.Save(repository.AttributeDefinition{OrganizationID: 1, Name: "department", Type: repository.AttributeTypeString, Enum: []string{"eng", "sales"}})
+	repo.Save(repository.AttributeDefinition{OrganizationID: 1, Name: "clearance", Type: repository.AttributeTypeNumber})
+	other := repo.Save(repository.AttributeDefinition{OrganizationID: 2, Name: "department", Type: repository.AttributeTypeString})
+	assert.NotEqual(t, uint64(0), other.ID)
+	assert.Equal(t, []string{"eng", "sales"}, repo.FindById(1, department.ID).Enum)
+	assert.Equal(t, uint64(0), repo.FindById(2, department.ID).ID)
+	assert.Equal(t, department.ID, repo.FindByName(1, "department").ID)
+	assert.Equal(t, "clearance", repo.FindByOrganizationId(1)[0].Name)
+
+	department.Required = true
+	repo.Update(department)
+	assert.True(t, repo.FindById(1, department.ID).Required)
+
+	assert.False(t, repo.Delete(2, department.ID))
+	assert.True(t, repo.Delete(1, department.ID))
+	assert.Equal(t, 1, len(repo.FindByOrganizationId(1)))
+
+	users := repository.NewUserRepositoryImpl(db).ForOrganization(1)
+	user := users.Save(repository.User{Username: "attributes", Attributes: repository.UserAttributes{"department": "eng", "clearance": float64(3)}})
+	assert.Equal(t, repository.UserAttributes{"department": "eng", "clearance": float64(3)}, users.FindById(user.ID).Attributes)
+
+	clearance := repo.FindByName(1, "clearance")
+	clearance.Unique = true
+	assert.Nil(t, repo.IndexUsers(repo.Update(clearance)))
+	assert.Equal(t, user.ID, users.WithAttributes(map[string]string{"clearance": "3"}).FindAll()[0].ID)
+	assert.Equal(t, 0, len(users.WithAttributes(map[string]string{"clearance": "4"}).FindAll()))
+	assert.Equal(t, uint64(0), users.Save(repository.User{Username: "repeated", Attributes: repository.UserAttributes{"clearance": float64(3)}}).ID)
+	assert.NotEqual(t, uint64(0), repository.NewUserRepositoryImpl(db).ForOrganization(2).Save(repository.User{Username: "other", Attributes: repository.UserAttributes{"clearance": float64(3)}}).ID)
+}
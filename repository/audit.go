@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AuditEvent struct {
+	ID        uint64    `json:"id" gorm:"primary_key"`
+	Action    string    `json:"action"`
+	ActorID   uint64    `json:"actorid"`
+	SubjectID uint64    `json:"subjectid"`
+	Detail    string    `json:"detail"`
+	CreatedAt time.Time `json:"createdat"`
+	PrevHash  string    `json:"prevhash" gorm:"uniqueIndex"`
+	Hash      string    `json:"hash" gorm:"uniqueIndex"`
+}
+
+type AuditCheckpoint struct {
+	ID        uint64    `json:"id" gorm:"primary_key"`
+	EventID   uint64    `json:"eventid" gorm:"uniqueIndex"`
+	Hash      string    `json:"hash"`
+	Signature string    `json:"signature"`
+	CreatedAt time.Time `json:"createdat"`
+}
+
+type AuditVerification struct {
+	Valid              bool   `json:"valid"`
+	CheckedEvents      uint64 `json:"checkedevents"`
+	CheckedCheckpoints uint64 `json:"checkedcheckpoints"`
+	SignaturesChecked  bool   `json:"signatureschecked"`
+	BrokenEventID      uint64 `json:"brokeneventid,omitempty"`
+	Reason             string `json:"reason,omitempty"`
+}
+
+type AuditRepository interface {
+	Save(event AuditEvent) AuditEvent
+	FindLast() AuditEvent
+	FindAfter(id uint64, limit int) []AuditEvent
+	SaveCheckpoint(checkpoint AuditCheckpoint) AuditCheckpoint
+	FindCheckpoints() []AuditCheckpoint
+	FindLastCheckpoint() AuditCheckpoint
+}
+
+type AuditRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func (t *AuditRepositoryImpl) Save(event AuditEvent) AuditEvent {
+	t.Db.Create(&event)
+	return event
+}
+
+func (t *AuditRepositoryImpl) FindLast() AuditEvent {
+	var event AuditEvent
+	t.Db.Order("id desc").Limit(1).Find(&event)
+	return event
+}
+
+func (t *AuditRepositoryImpl) FindAfter(id uint64, limit int) []AuditEvent {
+	var events []AuditEvent
+	t.Db.Where("id>?", id).Order("id asc").Limit(limit).Find(&events)
+	return events
+}
+
+func (t *AuditRepositoryImpl) SaveCheckpoint(checkpoint AuditCheckpoint) AuditCheckpoint {
+	t.Db.Create(&checkpoint)
+	return checkpoint
+}
+
+func (t *AuditRepositoryImpl) FindCheckpoints() []AuditCheckpoint {
+	var checkpoints []AuditCheckpoint
+	t.Db.Order("event_id asc").Find(&checkpoints)
+	return checkpoints
+}
+
+func (t *AuditRepositoryImpl) FindLastCheckpoint() AuditCheckpoint {
+	var checkpoint AuditCheckpoint
+	t.Db.Order("event_id desc").Limit(1).Find(&checkpoint)
+	return checkpoint
+}
+
+func NewAuditRepositoryImpl(Db *gorm.DB) AuditRepository {
+	return &AuditRepositoryImpl{Db: Db}
+}
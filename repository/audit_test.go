@@ -0,0 +1,33 @@
+package repository_test
+
+import (
+	"andikawhy/go-user-management/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestAuditRepositoryImpl(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	err := db.AutoMigrate(&repository.AuditEvent{}, &repository.AuditCheckpoint{})
+	if err != nil {
+		t.Fatalf("Error migrating database: %v", err)
+	}
+	repo := repository.NewAuditRepositoryImpl(db)
+
+	first := repo.Save(repository.AuditEvent{Action: "user.login", PrevHash: "", Hash: "a"})
+	second := repo.Save(repository.AuditEvent{Action: "user.login", PrevHash: "a", Hash: "b"})
+	fork := repo.Save(repository.AuditEvent{Action: "user.login", PrevHash: "a", Hash: "c"})
+
+	assert.NotZero(t, first.ID)
+	assert.NotZero(t, second.ID)
+	assert.Zero(t, fork.ID)
+	assert.Equal(t, "b", repo.FindLast().Hash)
+	assert.Len(t, repo.FindAfter(first.ID, 10), 1)
+
+	repo.SaveCheckpoint(repository.AuditCheckpoint{EventID: second.ID, Hash: "b", Signature: "sig"})
+	assert.Len(t, repo.FindCheckpoints(), 1)
+	assert.Equal(t, second.ID, repo.FindLastCheckpoint().EventID)
+}
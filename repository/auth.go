@@ -1,12 +1,15 @@
 package repository
 
 type Register struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
-	Email    string `json:"email" binding:"required"`
+	Username   string         `json:"username" binding:"required"`
+	Password   string         `json:"password" binding:"required"`
+	Email      string         `json:"email" binding:"required"`
+	Attributes UserAttributes `json:"attributes"`
 }
 
 type Login struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	Username       string `json:"username" binding:"required"`
+	Password       string `json:"password" binding:"required"`
+	Session        bool   `json:"session"`
+	OrganizationID uint64 `json:"-"`
 }
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type OAuthClient struct {
+	ID                     uint64     `json:"id" gorm:"primary_key"`
+	ClientID               string     `json:"clientid" gorm:"uniqueIndex"`
+	OrganizationID         uint64     `json:"organizationid" gorm:"index"`
+	SecretHash             string     `json:"-"`
+	Name                   string     `json:"name"`
+	Scopes                 string     `json:"scopes"`
+	GrantTypes             string     `json:"granttypes"`
+	RedirectURIs           string     `json:"redirecturis"`
+	PostLogoutRedirectURIs string     `json:"postlogoutredirecturis"`
+	Public                 bool       `json:"public"`
+	DisabledAt             *time.Time `json:"disabledat"`
+	CreatedAt              time.Time  `json:"createdat"`
+	UpdatedAt              time.Time  `json:"updatedat"`
+}
+
+type CreateClient struct {
+	Name                   string   `json:"name" binding:"required"`
+	Scopes                 []string `json:"scopes" binding:"required"`
+	GrantTypes             []string `json:"granttypes"`
+	RedirectURIs           []string `json:"redirecturis"`
+	PostLogoutRedirectURIs []string `json:"postlogoutredirecturis"`
+	Public                 bool     `json:"public"`
+}
+
+type CreatedClientResponse struct {
+	OAuthClient
+	ClientSecret string `json:"clientsecret"`
+}
+
+type OAuthClientRepository interface {
+	Save(client OAuthClient) OAuthClient
+	Update(client OAuthClient) OAuthClient
+	FindById(organizationId uint64, id uint64) OAuthClient
+	FindByClientId(clientId string) OAuthClient
+	FindByOrganizationId(organizationId uint64) []OAuthClient
+}
+
+type OAuthClientRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func (t *OAuthClientRepositoryImpl) Save(client OAuthClient) OAuthClient {
+	t.Db.Create(&client)
+	return client
+}
+
+func (t *OAuthClientRepositoryImpl) Update(client OAuthClient) OAuthClient {
+	t.Db.Save(&client)
+	return client
+}
+
+func (t *OAuthClientRepositoryImpl) FindById(organizationId uint64, id uint64) OAuthClient {
+	var client OAuthClient
+	t.Db.Where("organization_id=? AND id=?", organizationId, id).Find(&client)
+	return client
+}
+
+func (t *OAuthClientRepositoryImpl) FindByClientId(clientId string) OAuthClient {
+	var client OAuthClient
+	t.Db.Where("client_id=?", clientId).Find(&client)
+	return client
+}
+
+func (t *OAuthClientRepositoryImpl) FindByOrganizationId(organizationId uint64) []OAuthClient {
+	var clients []OAuthClient
+	t.Db.Where("organization_id=?", organizationId).Order("id asc").Find(&clients)
+	return clients
+}
+
+func NewOAuthClientRepositoryImpl(Db *gorm.DB) OAuthClientRepository {
+	return &OAuthClientRepositoryImpl{Db: Db}
+}
@@ -17,10 +17,35 @@ func ConnectDB() *gorm.DB {
 		log.Fatal("Failed to connect to DB:", err)
 	}
 
-	err = DB.AutoMigrate(&User{})
+	// Usernames used to be unique across the whole instance; they are now unique per organization.
+	if DB.Migrator().HasConstraint(&User{}, "users_username_key") {
+		DB.Migrator().DropConstraint(&User{}, "users_username_key")
+	}
+
+	err = DB.AutoMigrate(&User{}, &AuditEvent{}, &AuditCheckpoint{}, &PersonalAccessToken{}, &OAuthClient{}, &AuthorizationCode{}, &RefreshToken{}, &Consent{}, &RevokedToken{}, &DeviceCode{}, &Identity{}, &FederationState{}, &PasskeyCredential{}, &PasskeySession{}, &MagicLink{}, &Session{}, &Organization{}, &Membership{}, &Group{}, &GroupMember{}, &Invitation{}, &AttributeDefinition{}, &ImportJob{}, &ErasureRequest{})
 	if err != nil {
 		return nil
 	}
 
+	// Existing users default to organization 1, which is the first organization ever created.
+	var organizations int64
+	DB.Model(&Organization{}).Count(&organizations)
+	if organizations == 0 {
+		DB.Create(&Organization{Slug: DefaultOrganizationSlug, Name: "Default"})
+	}
+
+	DB.Model(&OAuthClient{}).Where("grant_types = '' OR grant_types IS NULL").Update("grant_types", "client_credentials")
+	// Clients registered before they belonged to an organization served the default organization.
+	DB.Model(&OAuthClient{}).Where("organization_id = 0 OR organization_id IS NULL").Update("organization_id", DefaultOrganizationID)
+
+	// Definitions created before attributes were indexed get their index here.
+	var definitions []AttributeDefinition
+	DB.Find(&definitions)
+	for _, definition := range definitions {
+		if err := DB.Exec(attributeIndexSQL(DB, definition)).Error; err != nil {
+			log.Printf("Failed to index attribute %s of organization %d: %v", definition.Name, definition.OrganizationID, err)
+		}
+	}
+
 	return DB
 }
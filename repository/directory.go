@@ -0,0 +1,13 @@
+package repository
+
+type DirectoryEntry struct {
+	DN         string
+	Attributes map[string][]string
+}
+
+type DirectorySearch struct {
+	BaseDN     string
+	Scope      int
+	Filter     string
+	Attributes []string
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Identity struct {
+	ID          uint64     `json:"id" gorm:"primary_key"`
+	UserID      uint64     `json:"userid" gorm:"index"`
+	Provider    string     `json:"provider" gorm:"uniqueIndex:idx_identity_provider_subject"`
+	Subject     string     `json:"subject" gorm:"uniqueIndex:idx_identity_provider_subject"`
+	Email       string     `json:"email"`
+	LastLoginAt *time.Time `json:"lastloginat"`
+	CreatedAt   time.Time  `json:"createdat"`
+}
+
+type FederationState struct {
+	ID           uint64    `json:"id" gorm:"primary_key"`
+	StateHash    string    `json:"-" gorm:"uniqueIndex"`
+	Provider     string    `json:"provider"`
+	Nonce        string    `json:"-"`
+	CodeVerifier string    `json:"-"`
+	LinkUserID   uint64    `json:"linkuserid"`
+	ExpiresAt    time.Time `json:"expiresat"`
+	CreatedAt    time.Time `json:"createdat"`
+}
+
+type FederationProvider struct {
+	Name                  string            `json:"name"`
+	Issuer                string            `json:"issuer"`
+	AuthorizationEndpoint string            `json:"authorizationendpoint"`
+	TokenEndpoint         string            `json:"tokenendpoint"`
+	UserInfoEndpoint      string            `json:"userinfoendpoint"`
+	ClientID              string            `json:"clientid"`
+	ClientSecret          string            `json:"clientsecret"`
+	Scopes                []string          `json:"scopes"`
+	Claims                map[string]string `json:"claims"`
+}
+
+type FederationCallback struct {
+	Code             string `form:"code"`
+	State            string `form:"state"`
+	Error            string `form:"error"`
+	ErrorDescription string `form:"error_description"`
+}
+
+// FederationResult carries the cookie session of a login, which is nil when the callback linked an identity, and the
+// frontend page the browser continues to.
+type FederationResult struct {
+	Session    *CreatedSession `json:"-"`
+	Identity   Identity        `json:"identity"`
+	RedirectTo string          `json:"redirectto"`
+}
+
+// FederationRedirect sends the browser to the identity provider. State is also set as a cookie, so the callback
+// only completes in the browser that started the login.
+type FederationRedirect struct {
+	RedirectTo string `json:"redirectto"`
+	State      string `json:"-"`
+}
+
+type FederationRepository interface {
+	SaveIdentity(identity Identity) Identity
+	UpdateIdentity(identity Identity) Identity
+	FindIdentity(provider string, subject string) Identity
+	FindIdentitiesByUserId(userId uint64) []Identity
+	DeleteIdentity(id uint64)
+	SaveState(state FederationState) FederationState
+	FindStateByHash(stateHash string) FederationState
+	DeleteState(id uint64) bool
+}
+
+type FederationRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func (t *FederationRepositoryImpl) SaveIdentity(identity Identity) Identity {
+	t.Db.Create(&identity)
+	return identity
+}
+
+func (t *FederationRepositoryImpl) UpdateIdentity(identity Identity) Identity {
+	t.Db.Save(&identity)
+	return identity
+}
+
+func (t *FederationRepositoryImpl) FindIdentity(provider string, subject string) Identity {
+	var identity Identity
+	t.Db.Where("provider=? AND subject=?", provider, subject).Find(&identity)
+	return identity
+}
+
+func (t *FederationRepositoryImpl) FindIdentitiesByUserId(userId uint64) []Identity {
+	var identities []Identity
+	t.Db.Where("user_id=?", userId).Order("id asc").Find(&identities)
+	return identities
+}
+
+func (t *FederationRepositoryImpl) DeleteIdentity(id uint64) {
+	t.Db.Where("id=?", id).Delete(&Identity{})
+}
+
+func (t *FederationRepositoryImpl) SaveState(state FederationState) FederationState {
+	t.Db.Where("expires_at < ?", time.Now()).Delete(&FederationState{})
+	t.Db.Create(&state)
+	return state
+}
+
+func (t *FederationRepositoryImpl) FindStateByHash(stateHash string) FederationState {
+	var state FederationState
+	t.Db.Where("state_hash=?", stateHash).Find(&state)
+	return state
+}
+
+func (t *FederationRepositoryImpl) DeleteState(id uint64) bool {
+	result := t.Db.Where("id=?", id).Delete(&FederationState{})
+	return result.Error == nil && result.RowsAffected == 1
+}
+
+func NewFederationRepositoryImpl(Db *gorm.DB) FederationRepository {
+	return &FederationRepositoryImpl{Db: Db}
+}
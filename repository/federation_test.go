@@ -0,0 +1,36 @@
+package repository_test
+
+import (
+	"andikawhy/go-user-management/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestFederationRepositoryImpl(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	err := db.AutoMigrate(&repository.Identity{}, &repository.FederationState{})
+	if err != nil {
+		t.Fatalf("Error migrating database: %v", err)
+	}
+	repo := repository.NewFederationRepositoryImpl(db)
+
+	identity := repo.SaveIdentity(repository.Identity{UserID: 1, Provider: "company", Subject: "sub"})
+	duplicate := repo.SaveIdentity(repository.Identity{UserID: 2, Provider: "company", Subject: "sub"})
+	assert.Equal(t, uint64(0), duplicate.ID)
+	assert.Equal(t, identity.ID, repo.FindIdentity("company", "sub").ID)
+	assert.Len(t, repo.FindIdentitiesByUserId(1), 1)
+
+	repo.DeleteIdentity(identity.ID)
+	assert.Equal(t, uint64(0), repo.FindIdentity("company", "sub").ID)
+
+	repo.SaveState(repository.FederationState{StateHash: "expired", Provider: "company", ExpiresAt: time.Now().Add(-time.Minute)})
+	state := repo.SaveState(repository.FederationState{StateHash: "active", Provider: "company", ExpiresAt: time.Now().Add(time.Minute)})
+	assert.Equal(t, uint64(0), repo.FindStateByHash("expired").ID)
+	assert.Equal(t, state.ID, repo.FindStateByHash("active").ID)
+	assert.True(t, repo.DeleteState(state.ID))
+	assert.False(t, repo.DeleteState(state.ID))
+}
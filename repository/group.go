@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Group struct {
+	ID             uint64    `json:"id" gorm:"primary_key"`
+	OrganizationID uint64    `json:"organizationid" gorm:"uniqueIndex:idx_groups_organization_name"`
+	Name           string    `json:"name" gorm:"uniqueIndex:idx_groups_organization_name"`
+	CreatedAt      time.Time `json:"createdat"`
+	UpdatedAt      time.Time `json:"updatedat"`
+}
+
+// GroupMember puts either a user or another group into a group; the unused member column is zero.
+type GroupMember struct {
+	ID            uint64    `json:"id" gorm:"primary_key"`
+	GroupID       uint64    `json:"groupid" gorm:"uniqueIndex:idx_group_members_member"`
+	UserID        uint64    `json:"userid" gorm:"uniqueIndex:idx_group_members_member;index"`
+	MemberGroupID uint64    `json:"membergroupid" gorm:"uniqueIndex:idx_group_members_member;index"`
+	CreatedAt     time.Time `json:"createdat"`
+}
+
+type SaveGroup struct {
+	Name string `json:"name" binding:"required,max=255"`
+}
+
+type GroupMembers struct {
+	Members        []GroupMember `json:"members"`
+	EffectiveUsers []uint64      `json:"effectiveusers"`
+}
+
+type GroupRepository interface {
+	Save(group Group) Group
+	Update(group Group) Group
+	FindById(organizationId uint64, id uint64) Group
+	FindByName(organizationId uint64, name string) Group
+	FindByIds(ids []uint64) []Group
+	FindByOrganizationId(organizationId uint64) []Group
+	Delete(organizationId uint64, id uint64) bool
+	SaveMember(member GroupMember) GroupMember
+	FindMembers(groupIds []uint64) []GroupMember
+	FindParents(userId uint64, groupIds []uint64) []GroupMember
+	DeleteMember(member GroupMember) bool
+}
+
+type GroupRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func (t *GroupRepositoryImpl) Save(group Group) Group {
+	t.Db.Create(&group)
+	return group
+}
+
+func (t *GroupRepositoryImpl) Update(group Group) Group {
+	t.Db.Save(&group)
+	return group
+}
+
+func (t *GroupRepositoryImpl) FindById(organizationId uint64, id uint64) Group {
+	var group Group
+	t.Db.Where("organization_id=? AND id=?", organizationId, id).Find(&group)
+	return group
+}
+
+func (t *GroupRepositoryImpl) FindByName(organizationId uint64, name string) Group {
+	var group Group
+	t.Db.Where("organization_id=? AND name=?", organizationId, name).Find(&group)
+	return group
+}
+
+func (t *GroupRepositoryImpl) FindByIds(ids []uint64) []Group {
+	var groups []Group
+	if len(ids) == 0 {
+		return groups
+	}
+	t.Db.Where("id IN ?", ids).Order("name asc").Find(&groups)
+	return groups
+}
+
+func (t *GroupRepositoryImpl) FindByOrganizationId(organizationId uint64) []Group {
+	var groups []Group
+	t.Db.Where("organization_id=?", organizationId).Order("name asc").Find(&groups)
+	return groups
+}
+
+// Delete removes the group together with its members and its membership in other groups.
+func (t *GroupRepositoryImpl) Delete(organizationId uint64, id uint64) bool {
+	deleted := false
+	t.Db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("organization_id=? AND id=?", organizationId, id).Delete(&Group{})
+		if result.Error != nil || result.RowsAffected != 1 {
+			return result.Error
+		}
+		if err := tx.Where("group_id=? OR member_group_id=?", id, id).Delete(&GroupMember{}).Error; err != nil {
+			return err
+		}
+		deleted = true
+		return nil
+	})
+	return deleted
+}
+
+func (t *GroupRepositoryImpl) SaveMember(member GroupMember) GroupMember {
+	var existing GroupMember
+	t.Db.Where("group_id=? AND user_id=? AND member_group_id=?", member.GroupID, member.UserID, member.MemberGroupID).Find(&existing)
+	if existing.ID != 0 {
+		return existing
+	}
+	t.Db.Create(&member)
+	return member
+}
+
+// FindMembers returns the users and groups directly inside any of the given groups.
+func (t *GroupRepositoryImpl) FindMembers(groupIds []uint64) []GroupMember {
+	var members []GroupMember
+	if len(groupIds) == 0 {
+		return members
+	}
+	t.Db.Where("group_id IN ?", groupIds).Order("id asc").Find(&members)
+	return members
+}
+
+// FindParents returns the memberships that put the user, or any of the given groups, directly into a group.
+func (t *GroupRepositoryImpl) FindParents(userId uint64, groupIds []uint64) []GroupMember {
+	var members []GroupMember
+	query := t.Db.Where("user_id=? AND user_id<>0", userId)
+	if len(groupIds) != 0 {
+		query = query.Or("member_group_id IN ?", groupIds)
+	}
+	query.Order("id asc").Find(&members)
+	return members
+}
+
+func (t *GroupRepositoryImpl) DeleteMember(member GroupMember) bool {
+	result := t.Db.Where("group_id=? AND user_id=? AND member_group_id=?", member.GroupID, member.UserID, member.MemberGroupID).Delete(&GroupMember{})
+	return result.Error == nil && result.RowsAffected == 1
+}
+
+func NewGroupRepositoryImpl(Db *gorm.DB) GroupRepository {
+	return &GroupRepositoryImpl{Db: Db}
+}
@@ -0,0 +1,44 @@
+package repository_test
+
+import (
+	"andikawhy/go-user-management/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestGroupRepositoryImpl(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	err := db.AutoMigrate(&repository.Group{}, &repository.GroupMember{})
+	if err != nil {
+		t.Fatalf("Error migrating database: %v", err)
+	}
+	repo := repository.NewGroupRepositoryImpl(db)
+
+	engineering := repo.Save(repository.Group{OrganizationID: 1, Name: "engineering"})
+	backend := repo.Save(repository.Group{OrganizationID: 1, Name: "backend"})
+	other := repo.Save(repository.Group{OrganizationID: 2, Name: "engineering"})
+	assert.NotEqual(t, uint64(0), other.ID)
+	assert.Equal(t, backend.ID, repo.FindByName(1, "backend").ID)
+	assert.Equal(t, uint64(0), repo.FindById(2, backend.ID).ID)
+	assert.Equal(t, 2, len(repo.FindByOrganizationId(1)))
+	assert.Equal(t, "backend", repo.FindByIds([]uint64{engineering.ID, backend.ID})[0].Name)
+
+	nested := repo.SaveMember(repository.GroupMember{GroupID: engineering.ID, MemberGroupID: backend.ID})
+	user := repo.SaveMember(repository.GroupMember{GroupID: backend.ID, UserID: 7})
+	assert.Equal(t, user.ID, repo.SaveMember(repository.GroupMember{GroupID: backend.ID, UserID: 7}).ID)
+
+	assert.Equal(t, 1, len(repo.FindMembers([]uint64{engineering.ID})))
+	assert.Equal(t, user.ID, repo.FindParents(7, nil)[0].ID)
+	assert.Equal(t, 2, len(repo.FindParents(7, []uint64{backend.ID})))
+	assert.Equal(t, 0, len(repo.FindParents(0, nil)))
+
+	assert.True(t, repo.DeleteMember(repository.GroupMember{GroupID: backend.ID, UserID: 7}))
+	assert.False(t, repo.DeleteMember(repository.GroupMember{GroupID: backend.ID, UserID: 7}))
+
+	assert.False(t, repo.Delete(2, backend.ID))
+	assert.True(t, repo.Delete(1, backend.ID))
+	assert.Equal(t, 0, len(repo.FindMembers([]uint64{nested.GroupID})))
+}
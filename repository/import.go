@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ImportFormatCSV    = "csv"
+	ImportFormatNDJSON = "ndjson"
+
+	ImportStatusRunning   = "running"
+	ImportStatusCompleted = "completed"
+	ImportStatusFailed    = "failed"
+)
+
+// ImportJob tracks a bulk user import. Created and Updated count the users written, or that would be written in a dry run.
+type ImportJob struct {
+	ID              uint64           `json:"id" gorm:"primary_key"`
+	OrganizationID  uint64           `json:"organizationid" gorm:"index"`
+	CreatedBy       uint64           `json:"createdby"`
+	Format          string           `json:"format"`
+	DryRun          bool             `json:"dryrun"`
+	Upsert          bool             `json:"upsert"`
+	UpdatePasswords bool             `json:"updatepasswords"`
+	Status          string           `json:"status"`
+	Total           int              `json:"total"`
+	Processed       int              `json:"processed"`
+	Created         int              `json:"created"`
+	Updated         int              `json:"updated"`
+	Failed          int              `json:"failed"`
+	Errors          []ImportRowError `json:"errors" gorm:"serializer:json"`
+	Error           string           `json:"error,omitempty"`
+	CreatedAt       time.Time        `json:"createdat"`
+	UpdatedAt       time.Time        `json:"updatedat"`
+	CompletedAt     *time.Time       `json:"completedat"`
+}
+
+// ImportRowError reports why a row was not imported; Row is the line number in the uploaded file.
+type ImportRowError struct {
+	Row      int    `json:"row"`
+	Username string `json:"username,omitempty"`
+	Error    string `json:"error"`
+}
+
+// ImportOptions configure an import. UpdatePasswords lets an upsert replace the passwords of existing users.
+// Subject is the caller checked against the policy for each user; imports from the command line have none.
+type ImportOptions struct {
+	Format          string                 `form:"format" binding:"omitempty,oneof=csv ndjson"`
+	DryRun          bool                   `form:"dry_run"`
+	Upsert          bool                   `form:"upsert"`
+	UpdatePasswords bool                   `form:"update_passwords"`
+	Subject         map[string]interface{} `form:"-"`
+}
+
+// ImportRecord is one user of an import file. PasswordHash takes a password hashed elsewhere, e.g. by a previous system.
+type ImportRecord struct {
+	Username     string         `json:"username"`
+	Email        string         `json:"email"`
+	Password     string         `json:"password"`
+	PasswordHash string         `json:"password_hash"`
+	Roles        string         `json:"roles"`
+	Attributes   UserAttributes `json:"attributes"`
+}
+
+type ImportRepository interface {
+	SaveJob(job ImportJob) ImportJob
+	UpdateJob(job ImportJob) ImportJob
+	FindJobById(organizationId uint64, id uint64) ImportJob
+	FindJobsByOrganizationId(organizationId uint64) []ImportJob
+	SaveUsers(users []User) ([]bool, error)
+	FailRunningJobs(reason string) int64
+}
+
+type ImportRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func (t *ImportRepositoryImpl) SaveJob(job ImportJob) ImportJob {
+	t.Db.Create(&job)
+	return job
+}
+
+func (t *ImportRepositoryImpl) UpdateJob(job ImportJob) ImportJob {
+	t.Db.Save(&job)
+	return job
+}
+
+func (t *ImportRepositoryImpl) FindJobById(organizationId uint64, id uint64) ImportJob {
+	var job ImportJob
+	t.Db.Where("organization_id=? AND id=?", organizationId, id).Find(&job)
+	return job
+}
+
+func (t *ImportRepositoryImpl) FindJobsByOrganizationId(organizationId uint64) []ImportJob {
+	var jobs []ImportJob
+	t.Db.Where("organization_id=?", organizationId).Order("id desc").Find(&jobs)
+	return jobs
+}
+
+// SaveUsers creates new users and updates existing ones in a single transaction, so a batch is written completely or
+// not at all, and reports which users were written. Existing users only get the imported columns: the email, and the
+// roles, attributes and password when set. A user deleted or moved to another organization since it was read is
+// skipped rather than written back.
+func (t *ImportRepositoryImpl) SaveUsers(users []User) ([]bool, error) {
+	written := make([]bool, len(users))
+	err := t.Db.Transaction(func(tx *gorm.DB) error {
+		for i := range users {
+			if users[i].ID == 0 {
+				if err := tx.Create(&users[i]).Error; err != nil {
+					return err
+				}
+				written[i] = true
+				continue
+			}
+
+			columns := map[string]interface{}{"email": users[i].Email}
+			if users[i].Roles != "" {
+				columns["roles"] = users[i].Roles
+			}
+			if users[i].Attributes != nil {
+				columns["attributes"] = users[i].Attributes
+			}
+			if users[i].Password != "" {
+				columns["password"] = users[i].Password
+			}
+			result := tx.Model(&User{}).Where("id=? AND organization_id=?", users[i].ID, users[i].OrganizationID).Updates(columns)
+			if result.Error != nil {
+				return result.Error
+			}
+			written[i] = result.RowsAffected == 1
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return written, nil
+}
+
+// FailRunningJobs marks every running job as failed and returns how many there were.
+func (t *ImportRepositoryImpl) FailRunningJobs(reason string) int64 {
+	return t.Db.Model(&ImportJob{}).Where("status=?", ImportStatusRunning).
+		Updates(map[string]interface{}{"status": ImportStatusFailed, "error": reason, "completed_at": time.Now()}).RowsAffected
+}
+
+func NewImportRepositoryImpl(Db *gorm.DB) ImportRepository {
+	return &ImportRepositoryImpl{Db: Db}
+}
@@ -0,0 +1,61 @@
+package repository_test
+
+import (
+	"andikawhy/go-user-management/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestImportRepositoryImpl(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	err := db.AutoMigrate(&repository.ImportJob{}, &repository.User{})
+	if err != nil {
+		t.Fatalf("Error migrating database: %v", err)
+	}
+	repo := repository.NewImportRepositoryImpl(db)
+
+	job := repo.SaveJob(repository.ImportJob{OrganizationID: 2, Format: repository.ImportFormatCSV, Status: repository.ImportStatusRunning, Total: 2})
+	job.Status = repository.ImportStatusCompleted
+	job.Errors = []repository.ImportRowError{{Row: 3, Username: "dup", Error: "duplicate username in import"}}
+	repo.UpdateJob(job)
+	assert.Equal(t, job.Errors, repo.FindJobById(2, job.ID).Errors)
+	assert.Equal(t, uint64(0), repo.FindJobById(3, job.ID).ID)
+	assert.Equal(t, 1, len(repo.FindJobsByOrganizationId(2)))
+
+	existing := repository.User{OrganizationID: 2, Username: "existing", Password: "hash", Roles: "member"}
+	db.Create(&existing)
+	written, err := repo.SaveUsers([]repository.User{{OrganizationID: 2, Username: "new"}, {ID: existing.ID, OrganizationID: 2, Username: "existing", Email: "existing@example.com"}})
+	assert.Nil(t, err)
+	assert.Equal(t, []bool{true, true}, written)
+
+	var users []repository.User
+	db.Order("id asc").Find(&users)
+	assert.Equal(t, 2, len(users))
+	assert.Equal(t, "existing@example.com", users[0].Email)
+	assert.Equal(t, "hash", users[0].Password)
+	assert.Equal(t, "member", users[0].Roles)
+
+	// A user that is gone, or in another organization, is not written back.
+	written, err = repo.SaveUsers([]repository.User{{ID: existing.ID, OrganizationID: 3, Email: "moved@example.com"}, {ID: 99, OrganizationID: 2, Email: "deleted@example.com"}})
+	assert.Nil(t, err)
+	assert.Equal(t, []bool{false, false}, written)
+	assert.Equal(t, "existing@example.com", repository.NewUserRepositoryImpl(db).FindById(existing.ID).Email)
+
+	// The duplicate username rolls back the whole batch.
+	_, err = repo.SaveUsers([]repository.User{{OrganizationID: 2, Username: "batch"}, {OrganizationID: 2, Username: "new"}})
+	assert.NotNil(t, err)
+	var count int64
+	db.Model(&repository.User{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+
+	running := repo.SaveJob(repository.ImportJob{OrganizationID: 2, Status: repository.ImportStatusRunning})
+	assert.Equal(t, int64(1), repo.FailRunningJobs("interrupted by a restart"))
+	failed := repo.FindJobById(2, running.ID)
+	assert.Equal(t, repository.ImportStatusFailed, failed.Status)
+	assert.Equal(t, "interrupted by a restart", failed.Error)
+	assert.NotNil(t, failed.CompletedAt)
+	assert.Equal(t, repository.ImportStatusCompleted, repo.FindJobById(2, job.ID).Status)
+}
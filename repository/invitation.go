@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvitationNotFound is returned by Accept when the invitation was already used or revoked.
+var ErrInvitationNotFound = errors.New("invitation not found")
+
+type Invitation struct {
+	ID             uint64    `json:"id" gorm:"primary_key"`
+	TokenHash      string    `json:"-" gorm:"uniqueIndex"`
+	OrganizationID uint64    `json:"organizationid" gorm:"index"`
+	UserID         uint64    `json:"userid" gorm:"uniqueIndex"`
+	InvitedBy      uint64    `json:"invitedby"`
+	ExpiresAt      time.Time `json:"expiresat"`
+	CreatedAt      time.Time `json:"createdat"`
+	UpdatedAt      time.Time `json:"updatedat"`
+}
+
+type CreateInvitation struct {
+	Username   string         `json:"username" binding:"required"`
+	Email      string         `json:"email" binding:"required,email"`
+	Attributes UserAttributes `json:"attributes"`
+}
+
+type AcceptInvitation struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type PendingInvitation struct {
+	Invitation
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+type InvitationRepository interface {
+	Save(invitation Invitation) Invitation
+	Update(invitation Invitation) Invitation
+	FindById(organizationId uint64, id uint64) Invitation
+	FindByTokenHash(tokenHash string) Invitation
+	FindByOrganizationId(organizationId uint64) []PendingInvitation
+	Delete(id uint64) bool
+	Accept(invitationId uint64, user User) error
+}
+
+type InvitationRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func (t *InvitationRepositoryImpl) Save(invitation Invitation) Invitation {
+	t.Db.Create(&invitation)
+	return invitation
+}
+
+func (t *InvitationRepositoryImpl) Update(invitation Invitation) Invitation {
+	t.Db.Save(&invitation)
+	return invitation
+}
+
+func (t *InvitationRepositoryImpl) FindById(organizationId uint64, id uint64) Invitation {
+	var invitation Invitation
+	t.Db.Where("organization_id=? AND id=?", organizationId, id).Find(&invitation)
+	return invitation
+}
+
+func (t *InvitationRepositoryImpl) FindByTokenHash(tokenHash string) Invitation {
+	var invitation Invitation
+	t.Db.Where("token_hash=?", tokenHash).Find(&invitation)
+	return invitation
+}
+
+// FindByOrganizationId returns every invitation not yet accepted or revoked, including expired ones that can be resent.
+func (t *InvitationRepositoryImpl) FindByOrganizationId(organizationId uint64) []PendingInvitation {
+	var invitations []PendingInvitation
+	t.Db.Model(&Invitation{}).
+		Select("invitations.*, users.username, users.email").
+		Joins("JOIN users ON users.id = invitations.user_id").
+		Where("invitations.organization_id=?", organizationId).
+		Order("invitations.id asc").
+		Scan(&invitations)
+	return invitations
+}
+
+func (t *InvitationRepositoryImpl) Delete(id uint64) bool {
+	result := t.Db.Where("id=?", id).Delete(&Invitation{})
+	return result.Error == nil && result.RowsAffected == 1
+}
+
+// Accept uses up the invitation and saves the activated user in one transaction, so a token is only spent on a
+// user that was written.
+func (t *InvitationRepositoryImpl) Accept(invitationId uint64, user User) error {
+	return t.Db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id=? AND user_id=?", invitationId, user.ID).Delete(&Invitation{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return ErrInvitationNotFound
+		}
+		return tx.Save(&user).Error
+	})
+}
+
+func NewInvitationRepositoryImpl(Db *gorm.DB) InvitationRepository {
+	return &InvitationRepositoryImpl{Db: Db}
+}
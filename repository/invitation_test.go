@@ -0,0 +1,59 @@
+package repository_test
+
+import (
+	"andikawhy/go-user-management/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestInvitationRepositoryImpl(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	err := db.AutoMigrate(&repository.User{}, &repository.Invitation{})
+	if err != nil {
+		t.Fatalf("Error migrating database: %v", err)
+	}
+	db.Exec("DELETE FROM users")
+	repo := repository.NewInvitationRepositoryImpl(db)
+
+	user := repository.User{OrganizationID: 2, Username: "invitee", Email: "invitee@example.com"}
+	db.Create(&user)
+
+	invitation := repo.Save(repository.Invitation{TokenHash: "hash", OrganizationID: 2, UserID: user.ID, InvitedBy: 100, ExpiresAt: time.Now().Add(time.Hour)})
+	assert.NotEqual(t, uint64(0), invitation.ID)
+	assert.Equal(t, invitation.ID, repo.FindByTokenHash("hash").ID)
+	assert.Equal(t, invitation.ID, repo.FindById(2, invitation.ID).ID)
+	assert.Equal(t, uint64(0), repo.FindById(3, invitation.ID).ID)
+
+	invitation.TokenHash = "resent"
+	repo.Update(invitation)
+	assert.Equal(t, uint64(0), repo.FindByTokenHash("hash").ID)
+
+	invitations := repo.FindByOrganizationId(2)
+	assert.Len(t, invitations, 1)
+	assert.Equal(t, "invitee", invitations[0].Username)
+	assert.Equal(t, "invitee@example.com", invitations[0].Email)
+	assert.Equal(t, uint64(100), invitations[0].InvitedBy)
+	assert.Len(t, repo.FindByOrganizationId(3), 0)
+
+	assert.True(t, repo.Delete(invitation.ID))
+	assert.False(t, repo.Delete(invitation.ID))
+
+	accepted := repo.Save(repository.Invitation{TokenHash: "accepted", OrganizationID: 2, UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour)})
+	user.EmailVerified = true
+	assert.Nil(t, repo.Accept(accepted.ID, user))
+	assert.Equal(t, uint64(0), repo.FindByTokenHash("accepted").ID)
+	var saved repository.User
+	db.First(&saved, user.ID)
+	assert.True(t, saved.EmailVerified)
+	assert.Equal(t, repository.ErrInvitationNotFound, repo.Accept(accepted.ID, user))
+
+	// A failed user write keeps the invitation usable.
+	retried := repo.Save(repository.Invitation{TokenHash: "retried", OrganizationID: 2, UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour)})
+	user.Attributes = repository.UserAttributes{"invalid": make(chan int)}
+	assert.NotNil(t, repo.Accept(retried.ID, user))
+	assert.Equal(t, retried.ID, repo.FindByTokenHash("retried").ID)
+}
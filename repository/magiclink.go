@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type MagicLink struct {
+	ID        uint64    `json:"id" gorm:"primary_key"`
+	TokenHash string    `json:"-" gorm:"uniqueIndex"`
+	NonceHash string    `json:"-"`
+	UserID    uint64    `json:"userid" gorm:"index"`
+	ExpiresAt time.Time `json:"expiresat"`
+	CreatedAt time.Time `json:"createdat"`
+}
+
+type MagicLinkRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+type MagicLinkConsume struct {
+	Token string `form:"token" binding:"required"`
+}
+
+type MagicLinkRepository interface {
+	Save(link MagicLink) MagicLink
+	FindByTokenHash(tokenHash string) MagicLink
+	CountByUserIdSince(userId uint64, since time.Time) int64
+	Delete(id uint64) bool
+}
+
+type MagicLinkRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func (t *MagicLinkRepositoryImpl) Save(link MagicLink) MagicLink {
+	t.Db.Where("expires_at < ?", time.Now()).Delete(&MagicLink{})
+	t.Db.Create(&link)
+	return link
+}
+
+func (t *MagicLinkRepositoryImpl) FindByTokenHash(tokenHash string) MagicLink {
+	var link MagicLink
+	t.Db.Where("token_hash=?", tokenHash).Find(&link)
+	return link
+}
+
+func (t *MagicLinkRepositoryImpl) CountByUserIdSince(userId uint64, since time.Time) int64 {
+	var count int64
+	t.Db.Model(&MagicLink{}).Where("user_id=? AND created_at >= ?", userId, since).Count(&count)
+	return count
+}
+
+func (t *MagicLinkRepositoryImpl) Delete(id uint64) bool {
+	result := t.Db.Where("id=?", id).Delete(&MagicLink{})
+	return result.Error == nil && result.RowsAffected == 1
+}
+
+func NewMagicLinkRepositoryImpl(Db *gorm.DB) MagicLinkRepository {
+	return &MagicLinkRepositoryImpl{Db: Db}
+}
@@ -0,0 +1,31 @@
+package repository_test
+
+import (
+	"andikawhy/go-user-management/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestMagicLinkRepositoryImpl(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	err := db.AutoMigrate(&repository.MagicLink{})
+	if err != nil {
+		t.Fatalf("Error migrating database: %v", err)
+	}
+	repo := repository.NewMagicLinkRepositoryImpl(db)
+
+	repo.Save(repository.MagicLink{TokenHash: "expired", UserID: 1, ExpiresAt: time.Now().Add(-time.Minute)})
+	link := repo.Save(repository.MagicLink{TokenHash: "active", NonceHash: "nonce", UserID: 1, ExpiresAt: time.Now().Add(time.Minute)})
+	assert.Equal(t, uint64(0), repo.FindByTokenHash("expired").ID)
+	assert.Equal(t, link.ID, repo.FindByTokenHash("active").ID)
+
+	assert.Equal(t, int64(1), repo.CountByUserIdSince(1, time.Now().Add(-time.Minute)))
+	assert.Equal(t, int64(0), repo.CountByUserIdSince(2, time.Now().Add(-time.Minute)))
+
+	assert.True(t, repo.Delete(link.ID))
+	assert.False(t, repo.Delete(link.ID))
+}
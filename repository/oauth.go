@@ -0,0 +1,317 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AuthorizationCode struct {
+	ID            uint64     `json:"id" gorm:"primary_key"`
+	CodeHash      string     `json:"-" gorm:"uniqueIndex"`
+	ClientID      string     `json:"clientid"`
+	UserID        uint64     `json:"userid"`
+	RedirectURI   string     `json:"redirecturi"`
+	Scope         string     `json:"scope"`
+	CodeChallenge string     `json:"-"`
+	Nonce         string     `json:"-"`
+	SessionID     uint64     `json:"-"`
+	ExpiresAt     time.Time  `json:"expiresat"`
+	UsedAt        *time.Time `json:"usedat"`
+	CreatedAt     time.Time  `json:"createdat"`
+}
+
+type RefreshToken struct {
+	ID        uint64     `json:"id" gorm:"primary_key"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex"`
+	ClientID  string     `json:"clientid" gorm:"index"`
+	UserID    uint64     `json:"userid" gorm:"index"`
+	Scope     string     `json:"scope"`
+	SessionID uint64     `json:"-" gorm:"index"`
+	ExpiresAt time.Time  `json:"expiresat"`
+	RevokedAt *time.Time `json:"revokedat"`
+	CreatedAt time.Time  `json:"createdat"`
+}
+
+type Consent struct {
+	ID        uint64    `json:"id" gorm:"primary_key"`
+	UserID    uint64    `json:"userid" gorm:"uniqueIndex:idx_consent_user_client"`
+	ClientID  string    `json:"clientid" gorm:"uniqueIndex:idx_consent_user_client"`
+	Scope     string    `json:"scope"`
+	CreatedAt time.Time `json:"createdat"`
+	UpdatedAt time.Time `json:"updatedat"`
+}
+
+type RevokedToken struct {
+	ID        uint64    `json:"id" gorm:"primary_key"`
+	JTI       string    `json:"jti" gorm:"uniqueIndex"`
+	ExpiresAt time.Time `json:"expiresat" gorm:"index"`
+	CreatedAt time.Time `json:"createdat"`
+}
+
+const (
+	DeviceCodePending  = "pending"
+	DeviceCodeApproved = "approved"
+	DeviceCodeDenied   = "denied"
+	DeviceCodeUsed     = "used"
+)
+
+type DeviceCode struct {
+	ID             uint64     `json:"id" gorm:"primary_key"`
+	DeviceCodeHash string     `json:"-" gorm:"uniqueIndex"`
+	UserCode       string     `json:"-" gorm:"uniqueIndex"`
+	ClientID       string     `json:"clientid"`
+	UserID         uint64     `json:"userid"`
+	Scope          string     `json:"scope"`
+	SessionID      uint64     `json:"sessionid"`
+	Status         string     `json:"status"`
+	Interval       int        `json:"interval"`
+	LastPolledAt   *time.Time `json:"lastpolledat"`
+	ExpiresAt      time.Time  `json:"expiresat"`
+	CreatedAt      time.Time  `json:"createdat"`
+}
+
+type TokenRequest struct {
+	GrantType    string `form:"grant_type"`
+	Scope        string `form:"scope"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	RefreshToken string `form:"refresh_token"`
+	DeviceCode   string `form:"device_code"`
+}
+
+type DeviceAuthorizationRequest struct {
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+	Scope        string `form:"scope"`
+}
+
+type DeviceAuthorizationResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// DeviceDecision is the submitted device verification form. A browser with a cookie session sends its SessionToken
+// and the CSRF token instead of a username and password; OrganizationID is the organization of the request.
+type DeviceDecision struct {
+	UserCode       string       `form:"user_code"`
+	Username       string       `form:"username"`
+	Password       string       `form:"password"`
+	Approve        bool         `form:"approve"`
+	CSRFToken      string       `form:"csrf_token"`
+	SessionToken   string       `form:"-"`
+	OrganizationID uint64       `form:"-"`
+	LoginContext   LoginContext `form:"-"`
+}
+
+type DevicePrompt struct {
+	Client   OAuthClient
+	Scopes   []string
+	UserCode string
+	Approved bool
+}
+
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	Scope        string `json:"scope,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+}
+
+type TokenInfo struct {
+	UserID         uint64
+	Username       string
+	OrganizationID uint64
+	ClientID       string
+	Scopes         []string
+	JTI            string
+	IssuedAt       int64
+	ExpiresAt      int64
+}
+
+type TokenHintRequest struct {
+	Token         string `form:"token"`
+	TokenTypeHint string `form:"token_type_hint"`
+	ClientID      string `form:"client_id"`
+	ClientSecret  string `form:"client_secret"`
+}
+
+type IntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	JTI       string `json:"jti,omitempty"`
+}
+
+type AuthorizeRequest struct {
+	ResponseType        string `form:"response_type"`
+	ClientID            string `form:"client_id"`
+	RedirectURI         string `form:"redirect_uri"`
+	Scope               string `form:"scope"`
+	State               string `form:"state"`
+	CodeChallenge       string `form:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method"`
+	Nonce               string `form:"nonce"`
+}
+
+// AuthorizeDecision is the submitted login and consent form. LoginContext describes the browser and the organization
+// of the request the user signs in to.
+type AuthorizeDecision struct {
+	AuthorizeRequest
+	Username     string       `form:"username"`
+	Password     string       `form:"password"`
+	Approve      bool         `form:"approve"`
+	LoginContext LoginContext `form:"-"`
+}
+
+type AuthorizePrompt struct {
+	Client      OAuthClient
+	Scopes      []string
+	RedirectURI string
+	RedirectTo  string
+}
+
+type OAuthRepository interface {
+	SaveAuthorizationCode(code AuthorizationCode) AuthorizationCode
+	FindAuthorizationCodeByHash(codeHash string) AuthorizationCode
+	MarkAuthorizationCodeUsed(id uint64) bool
+	SaveRefreshToken(refreshToken RefreshToken) RefreshToken
+	FindRefreshTokenByHash(tokenHash string) RefreshToken
+	RevokeRefreshToken(id uint64) bool
+	RevokeRefreshTokens(userId uint64, clientId string)
+	SaveConsent(consent Consent) Consent
+	FindConsent(userId uint64, clientId string) Consent
+	FindConsentsByUserId(userId uint64) []Consent
+	DeleteConsent(id uint64)
+	SaveRevokedToken(revokedToken RevokedToken)
+	IsTokenRevoked(jti string) bool
+	SaveDeviceCode(deviceCode DeviceCode) DeviceCode
+	FindDeviceCodeByHash(deviceCodeHash string) DeviceCode
+	FindDeviceCodeByUserCode(userCode string) DeviceCode
+	UpdateDeviceCodePoll(id uint64, polledAt time.Time, interval int)
+	DecideDeviceCode(id uint64, userId uint64, status string, sessionId uint64) bool
+	MarkDeviceCodeUsed(id uint64) bool
+}
+
+type OAuthRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func (t *OAuthRepositoryImpl) SaveAuthorizationCode(code AuthorizationCode) AuthorizationCode {
+	t.Db.Create(&code)
+	return code
+}
+
+func (t *OAuthRepositoryImpl) FindAuthorizationCodeByHash(codeHash string) AuthorizationCode {
+	var code AuthorizationCode
+	t.Db.Where("code_hash=?", codeHash).Find(&code)
+	return code
+}
+
+func (t *OAuthRepositoryImpl) MarkAuthorizationCodeUsed(id uint64) bool {
+	result := t.Db.Model(&AuthorizationCode{}).Where("id=? AND used_at IS NULL", id).Update("used_at", time.Now())
+	return result.Error == nil && result.RowsAffected == 1
+}
+
+func (t *OAuthRepositoryImpl) SaveRefreshToken(refreshToken RefreshToken) RefreshToken {
+	t.Db.Create(&refreshToken)
+	return refreshToken
+}
+
+func (t *OAuthRepositoryImpl) FindRefreshTokenByHash(tokenHash string) RefreshToken {
+	var refreshToken RefreshToken
+	t.Db.Where("token_hash=?", tokenHash).Find(&refreshToken)
+	return refreshToken
+}
+
+func (t *OAuthRepositoryImpl) RevokeRefreshToken(id uint64) bool {
+	result := t.Db.Model(&RefreshToken{}).Where("id=? AND revoked_at IS NULL", id).Update("revoked_at", time.Now())
+	return result.Error == nil && result.RowsAffected == 1
+}
+
+func (t *OAuthRepositoryImpl) RevokeRefreshTokens(userId uint64, clientId string) {
+	t.Db.Model(&RefreshToken{}).Where("user_id=? AND client_id=? AND revoked_at IS NULL", userId, clientId).Update("revoked_at", time.Now())
+}
+
+func (t *OAuthRepositoryImpl) SaveConsent(consent Consent) Consent {
+	t.Db.Save(&consent)
+	return consent
+}
+
+func (t *OAuthRepositoryImpl) FindConsent(userId uint64, clientId string) Consent {
+	var consent Consent
+	t.Db.Where("user_id=? AND client_id=?", userId, clientId).Find(&consent)
+	return consent
+}
+
+func (t *OAuthRepositoryImpl) FindConsentsByUserId(userId uint64) []Consent {
+	var consents []Consent
+	t.Db.Where("user_id=?", userId).Order("id asc").Find(&consents)
+	return consents
+}
+
+func (t *OAuthRepositoryImpl) DeleteConsent(id uint64) {
+	t.Db.Where("id=?", id).Delete(&Consent{})
+}
+
+func (t *OAuthRepositoryImpl) SaveRevokedToken(revokedToken RevokedToken) {
+	t.Db.Where("expires_at < ?", time.Now()).Delete(&RevokedToken{})
+	t.Db.Clauses(clause.OnConflict{DoNothing: true}).Create(&revokedToken)
+}
+
+func (t *OAuthRepositoryImpl) IsTokenRevoked(jti string) bool {
+	var count int64
+	t.Db.Model(&RevokedToken{}).Where("jti=?", jti).Count(&count)
+	return count > 0
+}
+
+func (t *OAuthRepositoryImpl) SaveDeviceCode(deviceCode DeviceCode) DeviceCode {
+	t.Db.Where("expires_at < ?", time.Now()).Delete(&DeviceCode{})
+	t.Db.Create(&deviceCode)
+	return deviceCode
+}
+
+func (t *OAuthRepositoryImpl) FindDeviceCodeByHash(deviceCodeHash string) DeviceCode {
+	var deviceCode DeviceCode
+	t.Db.Where("device_code_hash=?", deviceCodeHash).Find(&deviceCode)
+	return deviceCode
+}
+
+func (t *OAuthRepositoryImpl) FindDeviceCodeByUserCode(userCode string) DeviceCode {
+	var deviceCode DeviceCode
+	t.Db.Where("user_code=?", userCode).Find(&deviceCode)
+	return deviceCode
+}
+
+func (t *OAuthRepositoryImpl) UpdateDeviceCodePoll(id uint64, polledAt time.Time, interval int) {
+	t.Db.Model(&DeviceCode{}).Where("id=?", id).Updates(map[string]interface{}{"last_polled_at": polledAt, "interval": interval})
+}
+
+func (t *OAuthRepositoryImpl) DecideDeviceCode(id uint64, userId uint64, status string, sessionId uint64) bool {
+	result := t.Db.Model(&DeviceCode{}).Where("id=? AND status=?", id, DeviceCodePending).Updates(map[string]interface{}{"user_id": userId, "status": status, "session_id": sessionId})
+	return result.Error == nil && result.RowsAffected == 1
+}
+
+func (t *OAuthRepositoryImpl) MarkDeviceCodeUsed(id uint64) bool {
+	result := t.Db.Model(&DeviceCode{}).Where("id=? AND status=?", id, DeviceCodeApproved).Update("status", DeviceCodeUsed)
+	return result.Error == nil && result.RowsAffected == 1
+}
+
+func NewOAuthRepositoryImpl(Db *gorm.DB) OAuthRepository {
+	return &OAuthRepositoryImpl{Db: Db}
+}
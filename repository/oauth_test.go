@@ -0,0 +1,62 @@
+package repository_test
+
+import (
+	"andikawhy/go-user-management/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestOAuthRepositoryImpl(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	err := db.AutoMigrate(&repository.AuthorizationCode{}, &repository.RefreshToken{}, &repository.RevokedToken{})
+	if err != nil {
+		t.Fatalf("Error migrating database: %v", err)
+	}
+	repo := repository.NewOAuthRepositoryImpl(db)
+
+	code := repo.SaveAuthorizationCode(repository.AuthorizationCode{CodeHash: "code", ClientID: "client", UserID: 1, ExpiresAt: time.Now().Add(time.Minute)})
+	assert.True(t, repo.MarkAuthorizationCodeUsed(code.ID))
+	assert.False(t, repo.MarkAuthorizationCodeUsed(code.ID))
+
+	refreshToken := repo.SaveRefreshToken(repository.RefreshToken{TokenHash: "refresh", ClientID: "client", UserID: 1, ExpiresAt: time.Now().Add(time.Hour)})
+	assert.True(t, repo.RevokeRefreshToken(refreshToken.ID))
+	assert.False(t, repo.RevokeRefreshToken(refreshToken.ID))
+
+	repo.SaveRevokedToken(repository.RevokedToken{JTI: "expired", ExpiresAt: time.Now().Add(-time.Minute)})
+	repo.SaveRevokedToken(repository.RevokedToken{JTI: "active", ExpiresAt: time.Now().Add(time.Hour)})
+	repo.SaveRevokedToken(repository.RevokedToken{JTI: "active", ExpiresAt: time.Now().Add(time.Hour)})
+
+	assert.True(t, repo.IsTokenRevoked("active"))
+	assert.False(t, repo.IsTokenRevoked("expired"))
+	assert.False(t, repo.IsTokenRevoked("unknown"))
+}
+
+func TestOAuthRepositoryImpl_DeviceCode(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	err := db.AutoMigrate(&repository.DeviceCode{})
+	if err != nil {
+		t.Fatalf("Error migrating database: %v", err)
+	}
+	repo := repository.NewOAuthRepositoryImpl(db)
+
+	deviceCode := repo.SaveDeviceCode(repository.DeviceCode{DeviceCodeHash: "device", UserCode: "BCDFGHJK", ClientID: "client", Status: repository.DeviceCodePending, Interval: 5, ExpiresAt: time.Now().Add(time.Minute)})
+	assert.Equal(t, deviceCode.ID, repo.FindDeviceCodeByHash("device").ID)
+	assert.Equal(t, deviceCode.ID, repo.FindDeviceCodeByUserCode("BCDFGHJK").ID)
+
+	polledAt := time.Now()
+	repo.UpdateDeviceCodePoll(deviceCode.ID, polledAt, 10)
+	assert.Equal(t, 10, repo.FindDeviceCodeByHash("device").Interval)
+	assert.NotNil(t, repo.FindDeviceCodeByHash("device").LastPolledAt)
+
+	assert.False(t, repo.MarkDeviceCodeUsed(deviceCode.ID))
+	assert.True(t, repo.DecideDeviceCode(deviceCode.ID, 1, repository.DeviceCodeApproved, 7))
+	assert.False(t, repo.DecideDeviceCode(deviceCode.ID, 2, repository.DeviceCodeDenied, 0))
+	assert.Equal(t, uint64(1), repo.FindDeviceCodeByHash("device").UserID)
+	assert.Equal(t, uint64(7), repo.FindDeviceCodeByHash("device").SessionID)
+	assert.True(t, repo.MarkDeviceCodeUsed(deviceCode.ID))
+	assert.False(t, repo.MarkDeviceCodeUsed(deviceCode.ID))
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultOrganizationID   uint64 = 1
+	DefaultOrganizationSlug        = "default"
+)
+
+const (
+	OrganizationRoleAdmin  = "admin"
+	OrganizationRoleMember = "member"
+)
+
+type Organization struct {
+	ID                uint64    `json:"id" gorm:"primary_key"`
+	Slug              string    `json:"slug" gorm:"uniqueIndex"`
+	Name              string    `json:"name"`
+	AllowRegistration bool      `json:"allowregistration"`
+	CreatedAt         time.Time `json:"createdat"`
+	UpdatedAt         time.Time `json:"updatedat"`
+}
+
+type Membership struct {
+	ID             uint64    `json:"id" gorm:"primary_key"`
+	OrganizationID uint64    `json:"organizationid" gorm:"uniqueIndex:idx_memberships_organization_user"`
+	UserID         uint64    `json:"userid" gorm:"uniqueIndex:idx_memberships_organization_user;index"`
+	Role           string    `json:"role"`
+	CreatedAt      time.Time `json:"createdat"`
+}
+
+type CreateOrganization struct {
+	Slug              string `json:"slug" binding:"required,lowercase,alphanum,max=63"`
+	Name              string `json:"name" binding:"required"`
+	AllowRegistration bool   `json:"allowregistration"`
+}
+
+type SetMembership struct {
+	Role string `json:"role" binding:"required,oneof=admin member"`
+}
+
+type OrganizationMembership struct {
+	Organization
+	Role string `json:"role"`
+}
+
+type OrganizationRepository interface {
+	Save(organization Organization) Organization
+	FindById(id uint64) Organization
+	FindBySlug(slug string) Organization
+	FindByUserId(userId uint64) []OrganizationMembership
+	SaveMembership(membership Membership) Membership
+	FindMembership(organizationId uint64, userId uint64) Membership
+	FindMemberships(organizationId uint64) []Membership
+	DeleteMembership(organizationId uint64, userId uint64) bool
+}
+
+type OrganizationRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func (t *OrganizationRepositoryImpl) Save(organization Organization) Organization {
+	t.Db.Create(&organization)
+	return organization
+}
+
+func (t *OrganizationRepositoryImpl) FindById(id uint64) Organization {
+	var organization Organization
+	t.Db.Where("id=?", id).Find(&organization)
+	return organization
+}
+
+func (t *OrganizationRepositoryImpl) FindBySlug(slug string) Organization {
+	var organization Organization
+	t.Db.Where("slug=?", slug).Find(&organization)
+	return organization
+}
+
+// FindByUserId returns the user's own organization and every organization it is a member of.
+func (t *OrganizationRepositoryImpl) FindByUserId(userId uint64) []OrganizationMembership {
+	var organizations []OrganizationMembership
+	t.Db.Model(&Organization{}).
+		Select("organizations.*, COALESCE(memberships.role, ?) AS role", OrganizationRoleMember).
+		Joins("LEFT JOIN memberships ON memberships.organization_id = organizations.id AND memberships.user_id = ?", userId).
+		Where("memberships.id IS NOT NULL OR organizations.id = (SELECT organization_id FROM users WHERE id = ?)", userId).
+		Order("organizations.id asc").
+		Scan(&organizations)
+	return organizations
+}
+
+func (t *OrganizationRepositoryImpl) SaveMembership(membership Membership) Membership {
+	existing := t.FindMembership(membership.OrganizationID, membership.UserID)
+	if existing.ID != 0 {
+		existing.Role = membership.Role
+		t.Db.Save(&existing)
+		return existing
+	}
+	t.Db.Create(&membership)
+	return membership
+}
+
+func (t *OrganizationRepositoryImpl) FindMembership(organizationId uint64, userId uint64) Membership {
+	var membership Membership
+	t.Db.Where("organization_id=? AND user_id=?", organizationId, userId).Find(&membership)
+	return membership
+}
+
+func (t *OrganizationRepositoryImpl) FindMemberships(organizationId uint64) []Membership {
+	var memberships []Membership
+	t.Db.Where("organization_id=?", organizationId).Order("id asc").Find(&memberships)
+	return memberships
+}
+
+func (t *OrganizationRepositoryImpl) DeleteMembership(organizationId uint64, userId uint64) bool {
+	result := t.Db.Where("organization_id=? AND user_id=?", organizationId, userId).Delete(&Membership{})
+	return result.Error == nil && result.RowsAffected == 1
+}
+
+func NewOrganizationRepositoryImpl(Db *gorm.DB) OrganizationRepository {
+	return &OrganizationRepositoryImpl{Db: Db}
+}
@@ -0,0 +1,44 @@
+package repository_test
+
+import (
+	"andikawhy/go-user-management/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestOrganizationRepositoryImpl(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	err := db.AutoMigrate(&repository.User{}, &repository.Organization{}, &repository.Membership{})
+	if err != nil {
+		t.Fatalf("Error migrating database: %v", err)
+	}
+	repo := repository.NewOrganizationRepositoryImpl(db)
+	userRepo := repository.NewUserRepositoryImpl(db)
+
+	defaultOrganization := repo.Save(repository.Organization{Slug: repository.DefaultOrganizationSlug, Name: "Default"})
+	sales := repo.Save(repository.Organization{Slug: "sales", Name: "Sales"})
+	repo.Save(repository.Organization{Slug: "support", Name: "Support"})
+	assert.Equal(t, sales.ID, repo.FindBySlug("sales").ID)
+	assert.Equal(t, "Sales", repo.FindById(sales.ID).Name)
+
+	user := userRepo.ForOrganization(defaultOrganization.ID).Save(repository.User{Username: "johndoe"})
+	membership := repo.SaveMembership(repository.Membership{OrganizationID: sales.ID, UserID: user.ID, Role: repository.OrganizationRoleMember})
+	updated := repo.SaveMembership(repository.Membership{OrganizationID: sales.ID, UserID: user.ID, Role: repository.OrganizationRoleAdmin})
+	assert.Equal(t, membership.ID, updated.ID)
+	assert.Equal(t, repository.OrganizationRoleAdmin, repo.FindMembership(sales.ID, user.ID).Role)
+	assert.Equal(t, 1, len(repo.FindMemberships(sales.ID)))
+
+	organizations := repo.FindByUserId(user.ID)
+	assert.Equal(t, 2, len(organizations))
+	assert.Equal(t, repository.DefaultOrganizationSlug, organizations[0].Slug)
+	assert.Equal(t, repository.OrganizationRoleMember, organizations[0].Role)
+	assert.Equal(t, "sales", organizations[1].Slug)
+	assert.Equal(t, repository.OrganizationRoleAdmin, organizations[1].Role)
+
+	assert.True(t, repo.DeleteMembership(sales.ID, user.ID))
+	assert.False(t, repo.DeleteMembership(sales.ID, user.ID))
+	assert.Equal(t, 1, len(repo.FindByUserId(user.ID)))
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PasskeyCredential struct {
+	ID              uint64     `json:"id" gorm:"primary_key"`
+	UserID          uint64     `json:"userid" gorm:"index"`
+	Name            string     `json:"name"`
+	CredentialID    string     `json:"credentialid" gorm:"uniqueIndex"`
+	PublicKey       []byte     `json:"-"`
+	AttestationType string     `json:"attestationtype"`
+	AAGUID          []byte     `json:"-"`
+	SignCount       uint32     `json:"signcount"`
+	Transports      string     `json:"transports"`
+	BackupEligible  bool       `json:"backupeligible"`
+	BackupState     bool       `json:"backupstate"`
+	LastUsedAt      *time.Time `json:"lastusedat"`
+	CreatedAt       time.Time  `json:"createdat"`
+}
+
+type PasskeySession struct {
+	ID          uint64    `json:"id" gorm:"primary_key"`
+	SessionHash string    `json:"-" gorm:"uniqueIndex"`
+	UserID      uint64    `json:"userid"`
+	Purpose     string    `json:"purpose"`
+	Data        string    `json:"-"`
+	ExpiresAt   time.Time `json:"expiresat"`
+	CreatedAt   time.Time `json:"createdat"`
+}
+
+type PasskeyCeremony struct {
+	SessionID string      `json:"sessionid"`
+	Options   interface{} `json:"options"`
+}
+
+type PasskeyRegistration struct {
+	SessionID  string          `json:"sessionid" binding:"required"`
+	Name       string          `json:"name"`
+	Credential json.RawMessage `json:"credential" binding:"required"`
+}
+
+type PasskeyLoginBegin struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type PasskeyLoginFinish struct {
+	SessionID  string          `json:"sessionid" binding:"required"`
+	Credential json.RawMessage `json:"credential" binding:"required"`
+}
+
+type PasskeySettings struct {
+	Required bool `json:"required"`
+}
+
+type PasskeyRepository interface {
+	SaveCredential(credential PasskeyCredential) PasskeyCredential
+	UpdateCredential(credential PasskeyCredential) PasskeyCredential
+	FindCredentialsByUserId(userId uint64) []PasskeyCredential
+	FindCredentialByCredentialId(credentialId string) PasskeyCredential
+	DeleteCredential(id uint64)
+	SaveSession(session PasskeySession) PasskeySession
+	FindSessionByHash(sessionHash string) PasskeySession
+	DeleteSession(id uint64) bool
+}
+
+type PasskeyRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func (t *PasskeyRepositoryImpl) SaveCredential(credential PasskeyCredential) PasskeyCredential {
+	t.Db.Create(&credential)
+	return credential
+}
+
+func (t *PasskeyRepositoryImpl) UpdateCredential(credential PasskeyCredential) PasskeyCredential {
+	t.Db.Save(&credential)
+	return credential
+}
+
+func (t *PasskeyRepositoryImpl) FindCredentialsByUserId(userId uint64) []PasskeyCredential {
+	var credentials []PasskeyCredential
+	t.Db.Where("user_id=?", userId).Order("id asc").Find(&credentials)
+	return credentials
+}
+
+func (t *PasskeyRepositoryImpl) FindCredentialByCredentialId(credentialId string) PasskeyCredential {
+	var credential PasskeyCredential
+	t.Db.Where("credential_id=?", credentialId).Find(&credential)
+	return credential
+}
+
+func (t *PasskeyRepositoryImpl) DeleteCredential(id uint64) {
+	t.Db.Where("id=?", id).Delete(&PasskeyCredential{})
+}
+
+func (t *PasskeyRepositoryImpl) SaveSession(session PasskeySession) PasskeySession {
+	t.Db.Where("expires_at < ?", time.Now()).Delete(&PasskeySession{})
+	t.Db.Create(&session)
+	return session
+}
+
+func (t *PasskeyRepositoryImpl) FindSessionByHash(sessionHash string) PasskeySession {
+	var session PasskeySession
+	t.Db.Where("session_hash=?", sessionHash).Find(&session)
+	return session
+}
+
+func (t *PasskeyRepositoryImpl) DeleteSession(id uint64) bool {
+	result := t.Db.Where("id=?", id).Delete(&PasskeySession{})
+	return result.Error == nil && result.RowsAffected == 1
+}
+
+func NewPasskeyRepositoryImpl(Db *gorm.DB) PasskeyRepository {
+	return &PasskeyRepositoryImpl{Db: Db}
+}
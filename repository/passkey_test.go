@@ -0,0 +1,39 @@
+package repository_test
+
+import (
+	"andikawhy/go-user-management/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestPasskeyRepositoryImpl(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	err := db.AutoMigrate(&repository.PasskeyCredential{}, &repository.PasskeySession{})
+	if err != nil {
+		t.Fatalf("Error migrating database: %v", err)
+	}
+	repo := repository.NewPasskeyRepositoryImpl(db)
+
+	credential := repo.SaveCredential(repository.PasskeyCredential{UserID: 1, CredentialID: "cred", PublicKey: []byte{1, 2, 3}})
+	duplicate := repo.SaveCredential(repository.PasskeyCredential{UserID: 2, CredentialID: "cred"})
+	assert.Equal(t, uint64(0), duplicate.ID)
+	assert.Equal(t, credential.ID, repo.FindCredentialByCredentialId("cred").ID)
+
+	credential.SignCount = 5
+	repo.UpdateCredential(credential)
+	assert.Equal(t, uint32(5), repo.FindCredentialsByUserId(1)[0].SignCount)
+
+	repo.DeleteCredential(credential.ID)
+	assert.Len(t, repo.FindCredentialsByUserId(1), 0)
+
+	repo.SaveSession(repository.PasskeySession{SessionHash: "expired", ExpiresAt: time.Now().Add(-time.Minute)})
+	session := repo.SaveSession(repository.PasskeySession{SessionHash: "active", Purpose: "login", ExpiresAt: time.Now().Add(time.Minute)})
+	assert.Equal(t, uint64(0), repo.FindSessionByHash("expired").ID)
+	assert.Equal(t, session.ID, repo.FindSessionByHash("active").ID)
+	assert.True(t, repo.DeleteSession(session.ID))
+	assert.False(t, repo.DeleteSession(session.ID))
+}
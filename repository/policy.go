@@ -0,0 +1,42 @@
+package repository
+
+const (
+	PolicyEffectAllow = "allow"
+	PolicyEffectDeny  = "deny"
+)
+
+// Policy is the declarative rule set read from POLICY_FILE.
+type Policy struct {
+	Rules []PolicyRule `json:"rules"`
+}
+
+// PolicyRule applies to a request when its action and resource type are listed and every condition holds.
+// Actions may end in "*" to match a prefix, e.g. "users:*".
+type PolicyRule struct {
+	Name       string            `json:"name"`
+	Effect     string            `json:"effect"`
+	Actions    []string          `json:"actions"`
+	Resources  []string          `json:"resources"`
+	Conditions []PolicyCondition `json:"conditions"`
+}
+
+// PolicyCondition compares an attribute such as "subject.organization_role" with a literal value,
+// or with another attribute when Reference is set.
+type PolicyCondition struct {
+	Attribute string      `json:"attribute"`
+	Operator  string      `json:"operator"`
+	Value     interface{} `json:"value"`
+	Reference string      `json:"reference"`
+}
+
+type AuthorizationRequest struct {
+	Subject     map[string]interface{} `json:"subject"`
+	Action      string                 `json:"action" binding:"required"`
+	Resource    map[string]interface{} `json:"resource" binding:"required"`
+	Environment map[string]interface{} `json:"environment"`
+}
+
+type AuthorizationDecision struct {
+	Allowed bool   `json:"allowed"`
+	Rule    string `json:"rule,omitempty"`
+}
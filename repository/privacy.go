@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ErasureStatusPending   = "pending"
+	ErasureStatusCompleted = "completed"
+	ErasureStatusCancelled = "cancelled"
+)
+
+// ErasureRequest schedules the anonymization of a user; it runs once ScheduledAt has passed unless cancelled.
+type ErasureRequest struct {
+	ID          uint64     `json:"id" gorm:"primary_key"`
+	UserID      uint64     `json:"userid" gorm:"index"`
+	RequestedBy uint64     `json:"requestedby"`
+	Status      string     `json:"status" gorm:"index"`
+	ScheduledAt time.Time  `json:"scheduledat" gorm:"index"`
+	CompletedAt *time.Time `json:"completedat"`
+	CancelledAt *time.Time `json:"cancelledat"`
+	CreatedAt   time.Time  `json:"createdat"`
+	UpdatedAt   time.Time  `json:"updatedat"`
+}
+
+// ErasureConfirmation re-authenticates an erasure request with the current password. Users without a password
+// confirm by having signed in recently instead.
+type ErasureConfirmation struct {
+	Password string `json:"password"`
+}
+
+// PersonalProfile is the user record as handed out in a data export, without the password hash.
+type PersonalProfile struct {
+	ID              uint64         `json:"id"`
+	OrganizationID  uint64         `json:"organizationid"`
+	Username        string         `json:"username"`
+	Email           string         `json:"email"`
+	EmailVerified   bool           `json:"emailverified"`
+	Roles           string         `json:"roles"`
+	ExternalID      string         `json:"externalid"`
+	PasskeyRequired bool           `json:"passkeyrequired"`
+	DisabledAt      *time.Time     `json:"disabledat"`
+	Attributes      UserAttributes `json:"attributes"`
+	AvatarURL       string         `json:"avatarurl"`
+	CreatedAt       time.Time      `json:"createdat"`
+	UpdatedAt       time.Time      `json:"updatedat"`
+}
+
+// PersonalData is everything stored about a user besides the user record itself.
+type PersonalData struct {
+	Sessions    []Session             `json:"sessions"`
+	AuditEvents []AuditEvent          `json:"auditevents"`
+	Consents    []Consent             `json:"consents"`
+	Identities  []Identity            `json:"identities"`
+	Tokens      []PersonalAccessToken `json:"tokens"`
+	Passkeys    []PasskeyCredential   `json:"passkeys"`
+	Memberships []Membership          `json:"memberships"`
+}
+
+type PrivacyRepository interface {
+	SaveErasure(request ErasureRequest) ErasureRequest
+	UpdateErasure(request ErasureRequest) ErasureRequest
+	FindLatestErasure(userId uint64) ErasureRequest
+	FindDueErasures(now time.Time) []ErasureRequest
+	FindPersonalData(userId uint64) PersonalData
+	EraseUser(anonymized User) error
+}
+
+type PrivacyRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func (t *PrivacyRepositoryImpl) SaveErasure(request ErasureRequest) ErasureRequest {
+	t.Db.Create(&request)
+	return request
+}
+
+func (t *PrivacyRepositoryImpl) UpdateErasure(request ErasureRequest) ErasureRequest {
+	t.Db.Save(&request)
+	return request
+}
+
+func (t *PrivacyRepositoryImpl) FindLatestErasure(userId uint64) ErasureRequest {
+	var request ErasureRequest
+	t.Db.Where("user_id=?", userId).Order("id desc").Limit(1).Find(&request)
+	return request
+}
+
+func (t *PrivacyRepositoryImpl) FindDueErasures(now time.Time) []ErasureRequest {
+	var requests []ErasureRequest
+	t.Db.Where("status=? AND scheduled_at<=?", ErasureStatusPending, now).Order("scheduled_at asc").Find(&requests)
+	return requests
+}
+
+func (t *PrivacyRepositoryImpl) FindPersonalData(userId uint64) PersonalData {
+	data := PersonalData{}
+	t.Db.Where("user_id=?", userId).Order("id asc").Find(&data.Sessions)
+	t.Db.Where("actor_id=? OR subject_id=?", userId, userId).Order("id asc").Find(&data.AuditEvents)
+	t.Db.Where("user_id=?", userId).Order("id asc").Find(&data.Consents)
+	t.Db.Where("user_id=?", userId).Order("id asc").Find(&data.Identities)
+	t.Db.Where("user_id=?", userId).Order("id asc").Find(&data.Tokens)
+	t.Db.Where("user_id=?", userId).Order("id asc").Find(&data.Passkeys)
+	t.Db.Where("user_id=?", userId).Order("id asc").Find(&data.Memberships)
+	return data
+}
+
+// erasedUserTables hold rows that belong to a single user and are deleted on erasure. Audit events are kept:
+// they only reference the user by ID, and their hash chain must stay intact.
+var erasedUserTables = []interface{}{
+	&Session{}, &PersonalAccessToken{}, &AuthorizationCode{}, &RefreshToken{}, &Consent{}, &DeviceCode{},
+	&Identity{}, &PasskeyCredential{}, &PasskeySession{}, &MagicLink{}, &Invitation{}, &GroupMember{}, &Membership{},
+}
+
+// EraseUser overwrites the user record with its anonymized version and deletes the user's rows from other
+// tables in one transaction. The user row itself stays, so audit events keep pointing at an existing user.
+func (t *PrivacyRepositoryImpl) EraseUser(anonymized User) error {
+	return t.Db.Transaction(func(tx *gorm.DB) error {
+		var original User
+		if err := tx.Select("id", "username").First(&original, anonymized.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Save(&anonymized).Error; err != nil {
+			return err
+		}
+		if err := scrubImportErrors(tx, anonymized.OrganizationID, original.Username, anonymized.Username); err != nil {
+			return err
+		}
+		for _, table := range erasedUserTables {
+			if err := tx.Where("user_id=?", anonymized.ID).Delete(table).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// scrubImportErrors replaces an erased username in the row errors of the organization's imports. Row errors name
+// users only by username; their texts never include personal data, so they are kept.
+func scrubImportErrors(tx *gorm.DB, organizationId uint64, username string, erasedUsername string) error {
+	// The LIKE only narrows the jobs down; rows are matched exactly below.
+	encoded, _ := json.Marshal(username)
+	var jobs []ImportJob
+	if err := tx.Where("organization_id=? AND errors LIKE ?", organizationId, "%"+string(encoded)+"%").Find(&jobs).Error; err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		scrubbed := false
+		for i := range job.Errors {
+			if job.Errors[i].Username == username {
+				job.Errors[i].Username = erasedUsername
+				scrubbed = true
+			}
+		}
+		if !scrubbed {
+			continue
+		}
+		if err := tx.Model(&ImportJob{ID: job.ID}).Select("errors").Updates(&ImportJob{Errors: job.Errors}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func NewPrivacyRepositoryImpl(Db *gorm.DB) PrivacyRepository {
+	return &PrivacyRepositoryImpl{Db: Db}
+}
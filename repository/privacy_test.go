@@ -0,0 +1,78 @@
+package repository_test
+
+import (
+	"andikawhy/go-user-management/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestPrivacyRepositoryImpl(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	err := db.AutoMigrate(&repository.User{}, &repository.ErasureRequest{}, &repository.AuditEvent{}, &repository.Session{}, &repository.PersonalAccessToken{},
+		&repository.AuthorizationCode{}, &repository.RefreshToken{}, &repository.Consent{}, &repository.DeviceCode{}, &repository.Identity{},
+		&repository.PasskeyCredential{}, &repository.PasskeySession{}, &repository.MagicLink{}, &repository.Invitation{}, &repository.GroupMember{}, &repository.Membership{}, &repository.ImportJob{})
+	if err != nil {
+		t.Fatalf("Error migrating database: %v", err)
+	}
+	repo := repository.NewPrivacyRepositoryImpl(db)
+
+	now := time.Now()
+	due := repo.SaveErasure(repository.ErasureRequest{UserID: 1, RequestedBy: 1, Status: repository.ErasureStatusPending, ScheduledAt: now.Add(-time.Minute)})
+	repo.SaveErasure(repository.ErasureRequest{UserID: 2, RequestedBy: 2, Status: repository.ErasureStatusPending, ScheduledAt: now.Add(time.Hour)})
+	cancelled := repo.SaveErasure(repository.ErasureRequest{UserID: 3, RequestedBy: 3, Status: repository.ErasureStatusPending, ScheduledAt: now.Add(-time.Hour)})
+	cancelled.Status = repository.ErasureStatusCancelled
+	repo.UpdateErasure(cancelled)
+
+	dueErasures := repo.FindDueErasures(now)
+	assert.Equal(t, 1, len(dueErasures))
+	assert.Equal(t, due.ID, dueErasures[0].ID)
+	assert.Equal(t, repository.ErasureStatusCancelled, repo.FindLatestErasure(3).Status)
+	assert.Equal(t, uint64(0), repo.FindLatestErasure(4).ID)
+
+	user := repository.User{OrganizationID: 1, Username: "alice", Email: "alice@example.com", Password: "hash"}
+	other := repository.User{OrganizationID: 1, Username: "bob"}
+	db.Create(&user)
+	db.Create(&other)
+	db.Create(&repository.Session{SessionHash: "a", UserID: user.ID})
+	db.Create(&repository.Session{SessionHash: "b", UserID: other.ID})
+	db.Create(&repository.Identity{UserID: user.ID, Provider: "google", Subject: "1", Email: "alice@gmail.com"})
+	db.Create(&repository.AuditEvent{Action: "user.login", ActorID: user.ID, SubjectID: user.ID, PrevHash: "0", Hash: "1"})
+	db.Create(&repository.AuditEvent{Action: "user.update", ActorID: other.ID, SubjectID: user.ID, PrevHash: "1", Hash: "2"})
+	db.Create(&repository.AuditEvent{Action: "user.login", ActorID: other.ID, SubjectID: other.ID, PrevHash: "2", Hash: "3"})
+	importJob := repository.ImportJob{OrganizationID: 1, Errors: []repository.ImportRowError{{Row: 2, Username: "alice", Error: "user already exist"}, {Row: 3, Username: "bob", Error: "invalid email"}}}
+	db.Create(&importJob)
+	otherOrganizationJob := repository.ImportJob{OrganizationID: 2, Errors: []repository.ImportRowError{{Row: 2, Username: "alice", Error: "invalid email"}}}
+	db.Create(&otherOrganizationJob)
+
+	data := repo.FindPersonalData(user.ID)
+	assert.Equal(t, 1, len(data.Sessions))
+	assert.Equal(t, 2, len(data.AuditEvents))
+	assert.Equal(t, "alice@gmail.com", data.Identities[0].Email)
+	assert.Equal(t, 0, len(data.Consents))
+
+	erasedAt := time.Now()
+	assert.Nil(t, repo.EraseUser(repository.User{ID: user.ID, OrganizationID: 1, Username: "erased-1", DisabledAt: &erasedAt, ErasedAt: &erasedAt}))
+
+	var erased repository.User
+	db.First(&erased, user.ID)
+	assert.Equal(t, "erased-1", erased.Username)
+	assert.Equal(t, "", erased.Email)
+	assert.Equal(t, "", erased.Password)
+	assert.NotNil(t, erased.ErasedAt)
+
+	data = repo.FindPersonalData(user.ID)
+	assert.Equal(t, 0, len(data.Sessions))
+	assert.Equal(t, 0, len(data.Identities))
+	assert.Equal(t, 2, len(data.AuditEvents))
+	assert.Equal(t, 1, len(repo.FindPersonalData(other.ID).Sessions))
+
+	var scrubbed, untouched repository.ImportJob
+	db.First(&scrubbed, importJob.ID)
+	db.First(&untouched, otherOrganizationJob.ID)
+	assert.Equal(t, []repository.ImportRowError{{Row: 2, Username: "erased-1", Error: "user already exist"}, {Row: 3, Username: "bob", Error: "invalid email"}}, scrubbed.Errors)
+	assert.Equal(t, "alice", untouched.Errors[0].Username)
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	SessionKindCookie = "cookie"
+	SessionKindToken  = "token"
+)
+
+type Session struct {
+	ID                uint64    `json:"id" gorm:"primary_key"`
+	SessionHash       string    `json:"-" gorm:"uniqueIndex"`
+	CSRFTokenHash     string    `json:"-"`
+	UserID            uint64    `json:"userid" gorm:"index"`
+	OrganizationID    uint64    `json:"organizationid"`
+	Kind              string    `json:"kind"`
+	Method            string    `json:"method"`
+	Device            string    `json:"device"`
+	UserAgent         string    `json:"useragent"`
+	IPAddress         string    `json:"ipaddress"`
+	LastSeenAt        time.Time `json:"lastseenat"`
+	ExpiresAt         time.Time `json:"expiresat" gorm:"index"`
+	AbsoluteExpiresAt time.Time `json:"absoluteexpiresat"`
+	CreatedAt         time.Time `json:"createdat"`
+	Current           bool      `json:"current" gorm:"-"`
+}
+
+type LoginContext struct {
+	UserAgent      string
+	IPAddress      string
+	OrganizationID uint64
+}
+
+type CreatedSession struct {
+	Session
+	SessionToken string `json:"-"`
+	CSRFToken    string `json:"csrftoken"`
+}
+
+type SessionRepository interface {
+	Save(session Session) Session
+	FindByHash(sessionHash string) Session
+	FindById(id uint64) Session
+	FindByUserId(userId uint64) []Session
+	Touch(id uint64, lastSeenAt time.Time, ipAddress string, expiresAt time.Time)
+	Delete(id uint64) bool
+	DeleteByUserIdAndOrganizationId(userId uint64, organizationId uint64) int64
+	DeleteExpired() int64
+}
+
+type SessionRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func (t *SessionRepositoryImpl) Save(session Session) Session {
+	t.Db.Create(&session)
+	return session
+}
+
+func (t *SessionRepositoryImpl) FindByHash(sessionHash string) Session {
+	var session Session
+	t.Db.Where("session_hash=?", sessionHash).Find(&session)
+	return session
+}
+
+func (t *SessionRepositoryImpl) FindById(id uint64) Session {
+	var session Session
+	t.Db.Where("id=?", id).Find(&session)
+	return session
+}
+
+func (t *SessionRepositoryImpl) FindByUserId(userId uint64) []Session {
+	var sessions []Session
+	t.Db.Where("user_id=? AND expires_at > ?", userId, time.Now()).Order("last_seen_at desc").Find(&sessions)
+	return sessions
+}
+
+// Touch only writes the activity columns, so a session deleted by a concurrent logout or revocation stays deleted.
+func (t *SessionRepositoryImpl) Touch(id uint64, lastSeenAt time.Time, ipAddress string, expiresAt time.Time) {
+	t.Db.Model(&Session{}).Where("id=?", id).Updates(map[string]interface{}{"last_seen_at": lastSeenAt, "ip_address": ipAddress, "expires_at": expiresAt})
+}
+
+func (t *SessionRepositoryImpl) Delete(id uint64) bool {
+	result := t.Db.Where("id=?", id).Delete(&Session{})
+	return result.Error == nil && result.RowsAffected == 1
+}
+
+func (t *SessionRepositoryImpl) DeleteByUserIdAndOrganizationId(userId uint64, organizationId uint64) int64 {
+	return t.Db.Where("user_id=? AND organization_id=?", userId, organizationId).Delete(&Session{}).RowsAffected
+}
+
+func (t *SessionRepositoryImpl) DeleteExpired() int64 {
+	return t.Db.Where("expires_at < ?", time.Now()).Delete(&Session{}).RowsAffected
+}
+
+func NewSessionRepositoryImpl(Db *gorm.DB) SessionRepository {
+	return &SessionRepositoryImpl{Db: Db}
+}
@@ -0,0 +1,47 @@
+package repository_test
+
+import (
+	"andikawhy/go-user-management/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestSessionRepositoryImpl(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	err := db.AutoMigrate(&repository.Session{})
+	if err != nil {
+		t.Fatalf("Error migrating database: %v", err)
+	}
+	repo := repository.NewSessionRepositoryImpl(db)
+
+	repo.Save(repository.Session{SessionHash: "expired", UserID: 1, ExpiresAt: time.Now().Add(-time.Minute)})
+	session := repo.Save(repository.Session{SessionHash: "active", UserID: 1, LastSeenAt: time.Now(), ExpiresAt: time.Now().Add(time.Minute)})
+	assert.NotEqual(t, uint64(0), repo.FindByHash("expired").ID)
+	assert.Equal(t, int64(1), repo.DeleteExpired())
+	assert.Equal(t, uint64(0), repo.FindByHash("expired").ID)
+	assert.Equal(t, session.ID, repo.FindByHash("active").ID)
+
+	older := repo.Save(repository.Session{SessionHash: "older", UserID: 1, LastSeenAt: time.Now().Add(-time.Hour), ExpiresAt: time.Now().Add(time.Minute)})
+	repo.Save(repository.Session{SessionHash: "other user", UserID: 2, ExpiresAt: time.Now().Add(time.Minute)})
+	sessions := repo.FindByUserId(1)
+	assert.Equal(t, 2, len(sessions))
+	assert.Equal(t, session.ID, sessions[0].ID)
+	assert.Equal(t, older.ID, sessions[1].ID)
+	assert.Equal(t, older.ID, repo.FindById(older.ID).ID)
+
+	repo.Touch(session.ID, time.Now(), "10.0.0.1", session.ExpiresAt)
+	assert.Equal(t, "10.0.0.1", repo.FindByHash("active").IPAddress)
+
+	assert.True(t, repo.Delete(session.ID))
+	assert.False(t, repo.Delete(session.ID))
+	repo.Touch(session.ID, time.Now(), "10.0.0.2", session.ExpiresAt)
+	assert.Equal(t, uint64(0), repo.FindById(session.ID).ID)
+
+	repo.Save(repository.Session{SessionHash: "sales", UserID: 1, OrganizationID: 2, ExpiresAt: time.Now().Add(time.Minute)})
+	assert.Equal(t, int64(1), repo.DeleteByUserIdAndOrganizationId(1, 2))
+	assert.Equal(t, 1, len(repo.FindByUserId(1)))
+}
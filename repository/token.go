@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PersonalAccessToken struct {
+	ID         uint64     `json:"id" gorm:"primary_key"`
+	UserID     uint64     `json:"userid" gorm:"index"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	TokenHash  string     `json:"-" gorm:"uniqueIndex"`
+	Scopes     string     `json:"scopes"`
+	ExpiresAt  time.Time  `json:"expiresat"`
+	LastUsedAt *time.Time `json:"lastusedat"`
+	LastUsedIP string     `json:"lastusedip"`
+	RevokedAt  *time.Time `json:"revokedat"`
+	CreatedAt  time.Time  `json:"createdat"`
+}
+
+type CreateToken struct {
+	Name          string   `json:"name" binding:"required"`
+	Scopes        []string `json:"scopes" binding:"required"`
+	ExpiresInDays uint     `json:"expiresindays"`
+}
+
+type CreatedTokenResponse struct {
+	PersonalAccessToken
+	Token string `json:"token"`
+}
+
+type PersonalAccessTokenRepository interface {
+	Save(token PersonalAccessToken) PersonalAccessToken
+	Update(token PersonalAccessToken) PersonalAccessToken
+	UpdateLastUsed(id uint64, usedAt time.Time, ip string)
+	FindById(id uint64) PersonalAccessToken
+	FindByHash(tokenHash string) PersonalAccessToken
+	FindByUserId(userId uint64) []PersonalAccessToken
+}
+
+type PersonalAccessTokenRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func (t *PersonalAccessTokenRepositoryImpl) Save(token PersonalAccessToken) PersonalAccessToken {
+	t.Db.Create(&token)
+	return token
+}
+
+func (t *PersonalAccessTokenRepositoryImpl) Update(token PersonalAccessToken) PersonalAccessToken {
+	t.Db.Save(&token)
+	return token
+}
+
+// UpdateLastUsed only writes the usage columns of a token still in use, so it never undoes a concurrent revocation
+// or brings back a deleted token.
+func (t *PersonalAccessTokenRepositoryImpl) UpdateLastUsed(id uint64, usedAt time.Time, ip string) {
+	t.Db.Model(&PersonalAccessToken{}).Where("id=? AND revoked_at IS NULL", id).Updates(map[string]interface{}{"last_used_at": usedAt, "last_used_ip": ip})
+}
+
+func (t *PersonalAccessTokenRepositoryImpl) FindById(id uint64) PersonalAccessToken {
+	var token PersonalAccessToken
+	t.Db.Where("id=?", id).Find(&token)
+	return token
+}
+
+func (t *PersonalAccessTokenRepositoryImpl) FindByHash(tokenHash string) PersonalAccessToken {
+	var token PersonalAccessToken
+	t.Db.Where("token_hash=?", tokenHash).Find(&token)
+	return token
+}
+
+func (t *PersonalAccessTokenRepositoryImpl) FindByUserId(userId uint64) []PersonalAccessToken {
+	var tokens []PersonalAccessToken
+	t.Db.Where("user_id=?", userId).Order("id asc").Find(&tokens)
+	return tokens
+}
+
+func NewPersonalAccessTokenRepositoryImpl(Db *gorm.DB) PersonalAccessTokenRepository {
+	return &PersonalAccessTokenRepositoryImpl{Db: Db}
+}
@@ -0,0 +1,38 @@
+package repository_test
+
+import (
+	"andikawhy/go-user-management/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestPersonalAccessTokenRepositoryImpl_UpdateLastUsed(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	err := db.AutoMigrate(&repository.PersonalAccessToken{})
+	if err != nil {
+		t.Fatalf("Error migrating database: %v", err)
+	}
+	repo := repository.NewPersonalAccessTokenRepositoryImpl(db)
+
+	token := repo.Save(repository.PersonalAccessToken{UserID: 1, TokenHash: "active", ExpiresAt: time.Now().Add(time.Hour)})
+	repo.UpdateLastUsed(token.ID, time.Now(), "10.0.0.1")
+	assert.Equal(t, "10.0.0.1", repo.FindById(token.ID).LastUsedIP)
+
+	// A request that loaded the token before it was revoked does not undo the revocation.
+	token = repo.FindById(token.ID)
+	revokedAt := time.Now()
+	token.RevokedAt = &revokedAt
+	repo.Update(token)
+	repo.UpdateLastUsed(token.ID, time.Now(), "10.0.0.2")
+	assert.NotNil(t, repo.FindById(token.ID).RevokedAt)
+	assert.Equal(t, "10.0.0.1", repo.FindById(token.ID).LastUsedIP)
+
+	// Nor does it bring back a deleted token.
+	db.Delete(&repository.PersonalAccessToken{}, token.ID)
+	repo.UpdateLastUsed(token.ID, time.Now(), "10.0.0.3")
+	assert.Equal(t, uint64(0), repo.FindById(token.ID).ID)
+}
@@ -7,19 +7,44 @@ import (
 )
 
 type User struct {
-	ID        uint64    `json:"id" gorm:"primary_key"`
-	Username  string    `json:"username" gorm:"unique"`
-	Email     string    `json:"email"`
-	Password  string    `json:"password"`
-	CreatedAt time.Time `json:"createdat"`
-	UpdatedAt time.Time `json:"updatedat"`
+	ID              uint64         `json:"id" gorm:"primary_key"`
+	OrganizationID  uint64         `json:"organizationid" gorm:"uniqueIndex:idx_users_organization_username;default:1"`
+	Username        string         `json:"username" gorm:"uniqueIndex:idx_users_organization_username"`
+	Email           string         `json:"email"`
+	EmailVerified   bool           `json:"emailverified"`
+	Password        string         `json:"password"`
+	Roles           string         `json:"roles"`
+	ExternalID      string         `json:"externalid" gorm:"index"`
+	PasskeyRequired bool           `json:"passkeyrequired"`
+	DisabledAt      *time.Time     `json:"disabledat"`
+	ErasedAt        *time.Time     `json:"erasedat"`
+	Attributes      UserAttributes `json:"attributes"`
+	AvatarKey       string         `json:"-"`
+	AvatarURL       string         `json:"avatarurl"`
+	CreatedAt       time.Time      `json:"createdat"`
+	UpdatedAt       time.Time      `json:"updatedat"`
 }
 
 type UserResponse struct {
-	ID        uint64    `json:"id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"createdat"`
+	ID             uint64         `json:"id"`
+	OrganizationID uint64         `json:"organizationid"`
+	Username       string         `json:"username"`
+	Email          string         `json:"email"`
+	Attributes     UserAttributes `json:"attributes"`
+	AvatarURL      string         `json:"avatarurl"`
+	CreatedAt      time.Time      `json:"createdat"`
+}
+
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+	ExportFormatXLSX   = "xlsx"
+)
+
+// UserExport selects the format of a user export and its columns as a comma separated list.
+type UserExport struct {
+	Format  string `form:"format" binding:"omitempty,oneof=csv ndjson xlsx"`
+	Columns string `form:"columns"`
 }
 
 type UserRepository interface {
@@ -27,42 +52,146 @@ type UserRepository interface {
 	Delete(id uint64) User
 	FindById(id uint64) User
 	FindByUsername(username string) User
+	FindByEmail(email string) User
 	FindAll() []User
+	Each(callback func(user User) error) error
+	Update(user User) User
+	ReplacePassword(id uint64, currentHash string, passwordHash string) bool
+	UpdateAvatar(id uint64, avatarKey string, avatarURL string) bool
+	ForOrganization(organizationId uint64) UserRepository
+	WithAttributes(filters map[string]string) UserRepository
 }
 
+// UserRepositoryImpl reads and writes users of a single organization once scoped with ForOrganization.
+// The unscoped repository is only meant for lookups by an ID the server itself stored, e.g. on a session.
 type UserRepositoryImpl struct {
-	Db *gorm.DB
+	Db               *gorm.DB
+	OrganizationID   uint64
+	AttributeFilters map[string]string
 }
 
+func (t *UserRepositoryImpl) ForOrganization(organizationId uint64) UserRepository {
+	if organizationId == 0 {
+		organizationId = DefaultOrganizationID
+	}
+	return &UserRepositoryImpl{Db: t.Db, OrganizationID: organizationId}
+}
+
+func (t *UserRepositoryImpl) scoped() *gorm.DB {
+	if t.OrganizationID == 0 {
+		return t.Db
+	}
+	return t.Db.Where("organization_id=?", t.OrganizationID)
+}
+
+// Delete removes the user and, in the same transaction, the rows of erasedUserTables that belong to them, so no
+// session, token, identity or membership outlives the account.
 func (t *UserRepositoryImpl) Delete(id uint64) User {
 	var user User
-	t.Db.Where("id=?", id).Delete(&user)
+	t.Db.Transaction(func(tx *gorm.DB) error {
+		scoped := &UserRepositoryImpl{Db: tx, OrganizationID: t.OrganizationID}
+		result := scoped.scoped().Where("id=?", id).Delete(&user)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		for _, table := range erasedUserTables {
+			if err := tx.Where("user_id=?", id).Delete(table).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	return user
 }
 
+// WithAttributes narrows FindAll and Each to users carrying every filtered attribute with the given value.
+func (t *UserRepositoryImpl) WithAttributes(filters map[string]string) UserRepository {
+	return &UserRepositoryImpl{Db: t.Db, OrganizationID: t.OrganizationID, AttributeFilters: filters}
+}
+
+func (t *UserRepositoryImpl) filtered() *gorm.DB {
+	query := t.scoped()
+	for name, value := range t.AttributeFilters {
+		query = query.Where(attributeValueSQL(t.Db, name)+" = ?", value)
+	}
+	return query
+}
+
 func (t *UserRepositoryImpl) FindAll() []User {
 	var users []User
-	t.Db.Find(&users)
+	t.filtered().Find(&users)
 	return users
 }
 
+// Each reads users through a database cursor, so exports never hold more than one user in memory.
+// Iteration stops at the first error returned by callback.
+func (t *UserRepositoryImpl) Each(callback func(user User) error) error {
+	rows, err := t.filtered().Model(&User{}).Order("id asc").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user User
+		if err := t.Db.ScanRows(rows, &user); err != nil {
+			return err
+		}
+		if err := callback(user); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
 func (t *UserRepositoryImpl) FindById(id uint64) User {
 	var foundUser User
-	t.Db.Where("id=?", id).Find(&foundUser)
+	t.scoped().Where("id=?", id).Find(&foundUser)
 	return foundUser
 }
 
 func (t *UserRepositoryImpl) FindByUsername(username string) User {
 	var foundUser User
-	t.Db.Where("username=?", username).Find(&foundUser)
+	t.scoped().Where("username=?", username).Find(&foundUser)
+	return foundUser
+}
+
+func (t *UserRepositoryImpl) FindByEmail(email string) User {
+	var foundUser User
+	t.scoped().Where("LOWER(email)=LOWER(?)", email).Order("id asc").Limit(1).Find(&foundUser)
 	return foundUser
 }
 
 func (t *UserRepositoryImpl) Save(user User) User {
+	if t.OrganizationID != 0 {
+		user.OrganizationID = t.OrganizationID
+	}
 	t.Db.Create(&user)
 	return user
 }
 
+func (t *UserRepositoryImpl) Update(user User) User {
+	// Save would insert a missing row, so a user of another organization is never written through a scoped repository.
+	if t.OrganizationID != 0 && t.FindById(user.ID).ID == 0 {
+		return User{}
+	}
+	t.Db.Save(&user)
+	return user
+}
+
+// ReplacePassword only writes the password, and only while it still holds currentHash, so it never undoes a
+// concurrent change to the user or a password set in the meantime.
+func (t *UserRepositoryImpl) ReplacePassword(id uint64, currentHash string, passwordHash string) bool {
+	result := t.scoped().Model(&User{}).Where("id=? AND password=?", id, currentHash).Update("password", passwordHash)
+	return result.Error == nil && result.RowsAffected == 1
+}
+
+// UpdateAvatar only writes the avatar columns, so the upload does not undo changes made while it was processed.
+func (t *UserRepositoryImpl) UpdateAvatar(id uint64, avatarKey string, avatarURL string) bool {
+	result := t.scoped().Model(&User{}).Where("id=?", id).Updates(map[string]interface{}{"avatar_key": avatarKey, "avatar_url": avatarURL})
+	return result.Error == nil && result.RowsAffected == 1
+}
+
 func NewUserRepositoryImpl(Db *gorm.DB) UserRepository {
 	return &UserRepositoryImpl{Db: Db}
 }
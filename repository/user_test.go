@@ -119,3 +119,151 @@ func TestUserRepositoryImpl_FindByUsername(t *testing.T) {
 		repo.FindByUsername("johndoe")
 	})
 }
+
+func TestUserRepositoryImpl_FindByEmail(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	err := db.AutoMigrate(&repository.User{})
+	if err != nil {
+		t.Fatalf("Error migrating database: %v", err)
+	}
+	repo := repository.NewUserRepositoryImpl(db)
+
+	user := repo.Save(repository.User{Username: "johndoe", Email: "John@Example.com"})
+
+	assert.Equal(t, user.ID, repo.FindByEmail("john@example.com").ID)
+	assert.Equal(t, uint64(0), repo.FindByEmail("jane@example.com").ID)
+}
+
+func TestUserRepositoryImpl_ForOrganization(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	err := db.AutoMigrate(&repository.User{})
+	if err != nil {
+		t.Fatalf("Error migrating database: %v", err)
+	}
+	repo := repository.NewUserRepositoryImpl(db)
+	defaultOrganization := repo.ForOrganization(0)
+	otherOrganization := repo.ForOrganization(2)
+
+	defaultUser := defaultOrganization.Save(repository.User{Username: "johndoe"})
+	otherUser := otherOrganization.Save(repository.User{Username: "johndoe"})
+	assert.Equal(t, repository.DefaultOrganizationID, defaultUser.OrganizationID)
+	assert.Equal(t, uint64(2), otherUser.OrganizationID)
+	assert.NotEqual(t, uint64(0), otherUser.ID)
+
+	assert.Equal(t, defaultUser.ID, defaultOrganization.FindByUsername("johndoe").ID)
+	assert.Equal(t, otherUser.ID, otherOrganization.FindByUsername("johndoe").ID)
+	assert.Equal(t, 1, len(otherOrganization.FindAll()))
+	assert.Equal(t, 2, len(repo.FindAll()))
+	assert.Equal(t, uint64(0), otherOrganization.FindById(defaultUser.ID).ID)
+
+	defaultUser.Email = "john@example.com"
+	assert.Equal(t, uint64(0), otherOrganization.Update(defaultUser).ID)
+	assert.Equal(t, "", repo.FindById(defaultUser.ID).Email)
+
+	otherOrganization.Delete(defaultUser.ID)
+	assert.Equal(t, defaultUser.ID, repo.FindById(defaultUser.ID).ID)
+}
+
+func TestUserRepositoryImpl_ReplacePassword(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	err := db.AutoMigrate(&repository.User{})
+	if err != nil {
+		t.Fatalf("Error migrating database: %v", err)
+	}
+	repo := repository.NewUserRepositoryImpl(db).ForOrganization(0)
+	user := repo.Save(repository.User{Username: "johndoe", Password: "legacy"})
+
+	// Changes made while the login verified the old hash are kept.
+	db.Model(&repository.User{}).Where("id=?", user.ID).Update("email", "john@example.com")
+	assert.True(t, repo.ReplacePassword(user.ID, "legacy", "bcrypt"))
+	assert.Equal(t, "bcrypt", repo.FindById(user.ID).Password)
+	assert.Equal(t, "john@example.com", repo.FindById(user.ID).Email)
+
+	assert.False(t, repo.ReplacePassword(user.ID, "legacy", "other"))
+	assert.Equal(t, "bcrypt", repo.FindById(user.ID).Password)
+	assert.False(t, repository.NewUserRepositoryImpl(db).ForOrganization(2).ReplacePassword(user.ID, "bcrypt", "other"))
+}
+
+func TestUserRepositoryImpl_UpdateAvatar(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	err := db.AutoMigrate(&repository.User{})
+	if err != nil {
+		t.Fatalf("Error migrating database: %v", err)
+	}
+	repo := repository.NewUserRepositoryImpl(db).ForOrganization(0)
+	user := repo.Save(repository.User{Username: "johndoe"})
+
+	db.Model(&repository.User{}).Where("id=?", user.ID).Update("email", "john@example.com")
+	assert.True(t, repo.UpdateAvatar(user.ID, "avatars/1/key", "http://localhost/avatars/1/key/256.png"))
+	assert.Equal(t, "avatars/1/key", repo.FindById(user.ID).AvatarKey)
+	assert.Equal(t, "john@example.com", repo.FindById(user.ID).Email)
+	db.Delete(&repository.User{}, user.ID)
+	repo.Delete(user.ID)
+	assert.False(t, repo.UpdateAvatar(user.ID, "", ""))
+	assert.Equal(t, uint64(0), repo.FindById(user.ID).ID)
+}
+
+func TestUserRepositoryImpl_Each(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	err := db.AutoMigrate(&repository.User{})
+	if err != nil {
+		t.Fatalf("Error migrating database: %v", err)
+	}
+	repo := repository.NewUserRepositoryImpl(db)
+	repo.ForOrganization(2).Save(repository.User{Username: "alice", Attributes: repository.UserAttributes{"department": "eng"}})
+	repo.ForOrganization(0).Save(repository.User{Username: "bob"})
+	repo.ForOrganization(2).Save(repository.User{Username: "carol"})
+
+	var usernames []string
+	assert.Nil(t, repo.ForOrganization(2).Each(func(user repository.User) error {
+		usernames = append(usernames, user.Username)
+		if user.Username == "alice" {
+			assert.Equal(t, "eng", user.Attributes["department"])
+		}
+		return nil
+	}))
+	assert.Equal(t, []string{"alice", "carol"}, usernames)
+
+	stop := assert.AnError
+	calls := 0
+	assert.Equal(t, stop, repo.Each(func(user repository.User) error {
+		calls++
+		return stop
+	}))
+	assert.Equal(t, 1, calls)
+}
+
+func TestUserRepositoryImpl_DeleteRemovesUserData(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	err := db.AutoMigrate(&repository.User{}, &repository.Session{}, &repository.PersonalAccessToken{}, &repository.AuthorizationCode{},
+		&repository.RefreshToken{}, &repository.Consent{}, &repository.DeviceCode{}, &repository.Identity{}, &repository.PasskeyCredential{},
+		&repository.PasskeySession{}, &repository.MagicLink{}, &repository.Invitation{}, &repository.GroupMember{}, &repository.Membership{})
+	if err != nil {
+		t.Fatalf("Error migrating database: %v", err)
+	}
+	repo := repository.NewUserRepositoryImpl(db)
+
+	user := repo.Save(repository.User{Username: "alice"})
+	other := repo.Save(repository.User{Username: "bob"})
+	db.Create(&repository.Session{SessionHash: "a", UserID: user.ID})
+	db.Create(&repository.Session{SessionHash: "b", UserID: other.ID})
+	db.Create(&repository.PersonalAccessToken{TokenHash: "a", UserID: user.ID})
+	db.Create(&repository.RefreshToken{TokenHash: "a", UserID: user.ID})
+	db.Create(&repository.Identity{UserID: user.ID, Provider: "google", Subject: "1"})
+	db.Create(&repository.GroupMember{GroupID: 1, UserID: user.ID})
+
+	repo.ForOrganization(2).Delete(user.ID)
+	assert.Equal(t, user.ID, repo.FindById(user.ID).ID)
+	var count int64
+	db.Model(&repository.Session{}).Where("user_id=?", user.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	repo.Delete(user.ID)
+	assert.Equal(t, uint64(0), repo.FindById(user.ID).ID)
+	for _, table := range []interface{}{&repository.Session{}, &repository.PersonalAccessToken{}, &repository.RefreshToken{}, &repository.Identity{}, &repository.GroupMember{}} {
+		db.Model(table).Where("user_id=?", user.ID).Count(&count)
+		assert.Equal(t, int64(0), count)
+	}
+	db.Model(&repository.Session{}).Where("user_id=?", other.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
@@ -0,0 +1,122 @@
+package router
+
+import (
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AttributeRouter interface {
+	CreateDefinition(c *gin.Context)
+	ListDefinitions(c *gin.Context)
+	UpdateDefinition(c *gin.Context)
+	DeleteDefinition(c *gin.Context)
+	SetUserAttributes(c *gin.Context)
+}
+
+type AttributeRouterImpl struct {
+	attributeUsecase usecase.AttributeUsecase
+}
+
+func NewAttributeRouterImpl(attributeUsecase usecase.AttributeUsecase) AttributeRouter {
+	return &AttributeRouterImpl{
+		attributeUsecase: attributeUsecase,
+	}
+}
+
+func (t *AttributeRouterImpl) CreateDefinition(c *gin.Context) {
+	var definitionData repository.SaveAttributeDefinition
+
+	if err := c.ShouldBindJSON(&definitionData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	definition, err := t.attributeUsecase.CreateDefinition(getCurrentOrganizationId(c), definitionData, getActorId(c))
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": definition, "message": "successfully create attribute"})
+}
+
+func (t *AttributeRouterImpl) ListDefinitions(c *gin.Context) {
+	definitions, err := t.attributeUsecase.ListDefinitions(getCurrentOrganizationId(c))
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": definitions, "message": "successfully list attributes"})
+}
+
+func (t *AttributeRouterImpl) UpdateDefinition(c *gin.Context) {
+	definitionId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to convert requested attribute ID"})
+		return
+	}
+
+	var definitionData repository.SaveAttributeDefinition
+
+	if err := c.ShouldBindJSON(&definitionData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	definition, updateError := t.attributeUsecase.UpdateDefinition(getCurrentOrganizationId(c), definitionId, definitionData, getActorId(c))
+
+	if updateError != nil && updateError.Error != nil {
+		c.JSON(int(updateError.ErrorCode), gin.H{"error": updateError.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": definition, "message": "successfully update attribute"})
+}
+
+func (t *AttributeRouterImpl) DeleteDefinition(c *gin.Context) {
+	definitionId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to convert requested attribute ID"})
+		return
+	}
+
+	definition, deleteError := t.attributeUsecase.DeleteDefinition(getCurrentOrganizationId(c), definitionId, getActorId(c))
+
+	if deleteError != nil && deleteError.Error != nil {
+		c.JSON(int(deleteError.ErrorCode), gin.H{"error": deleteError.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": definition, "message": "successfully delete attribute"})
+}
+
+func (t *AttributeRouterImpl) SetUserAttributes(c *gin.Context) {
+	userId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to convert requested user ID"})
+		return
+	}
+
+	var attributesData repository.SetUserAttributes
+
+	if err := c.ShouldBindJSON(&attributesData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, setError := t.attributeUsecase.SetUserAttributes(getCurrentOrganizationId(c), userId, attributesData.Attributes, getActorId(c))
+
+	if setError != nil && setError.Error != nil {
+		c.JSON(int(setError.ErrorCode), gin.H{"error": setError.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": user, "message": "successfully set user attributes"})
+}
@@ -0,0 +1,96 @@
+package router_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/router"
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestCreateAttributeDefinition(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockAttributeUsecase := new(mocks.AttributeUsecaseMock)
+		attributeRouter := router.NewAttributeRouterImpl(mockAttributeUsecase)
+
+		definitionData := repository.SaveAttributeDefinition{Name: "department", Type: "string", Required: true, Enum: []string{"eng", "sales"}}
+		mockAttributeUsecase.On("CreateDefinition", repository.DefaultOrganizationID, definitionData, uint64(100)).
+			Return(&repository.AttributeDefinition{ID: 1, OrganizationID: 1, Name: "department", Type: "string", Required: true, Enum: []string{"eng", "sales"}}, (*helper.StandardError)(nil))
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.POST("/attributes", attributeRouter.CreateDefinition)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/attributes", bytes.NewBufferString(`{"name":"department","type":"string","required":true,"enum":["eng","sales"]}`))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), `"enum":\["eng","sales"\]`)
+	})
+
+	t.Run("Unsupported type", func(t *testing.T) {
+		attributeRouter := router.NewAttributeRouterImpl(nil)
+
+		router := gin.Default()
+		router.POST("/attributes", attributeRouter.CreateDefinition)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/attributes", bytes.NewBufferString(`{"name":"birthday","type":"date"}`))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestSetUserAttributes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockAttributeUsecase := new(mocks.AttributeUsecaseMock)
+		attributeRouter := router.NewAttributeRouterImpl(mockAttributeUsecase)
+
+		attributes := repository.UserAttributes{"department": "eng", "level": float64(3)}
+		mockAttributeUsecase.On("SetUserAttributes", repository.DefaultOrganizationID, uint64(101), attributes, uint64(100)).
+			Return(&repository.UserResponse{ID: 101, Username: "member", Attributes: attributes}, (*helper.StandardError)(nil))
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.PUT("/users/:id/attributes", attributeRouter.SetUserAttributes)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPut, "/users/101/attributes", bytes.NewBufferString(`{"attributes":{"department":"eng","level":3}}`))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), `"attributes":\{"department":"eng","level":3\}`)
+	})
+
+	t.Run("Validation error", func(t *testing.T) {
+		mockAttributeUsecase := new(mocks.AttributeUsecaseMock)
+		attributeRouter := router.NewAttributeRouterImpl(mockAttributeUsecase)
+
+		mockAttributeUsecase.On("SetUserAttributes", repository.DefaultOrganizationID, uint64(101), repository.UserAttributes{"department": "legal"}, uint64(100)).
+			Return((*repository.UserResponse)(nil), &helper.StandardError{Error: errors.New("attribute department must be one of [eng sales]"), ErrorCode: http.StatusBadRequest})
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.PUT("/users/:id/attributes", attributeRouter.SetUserAttributes)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPut, "/users/101/attributes", bytes.NewBufferString(`{"attributes":{"department":"legal"}}`))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "must be one of")
+	})
+}
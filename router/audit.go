@@ -0,0 +1,43 @@
+package router
+
+import (
+	"andikawhy/go-user-management/usecase"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditRouter interface {
+	VerifyAudit(c *gin.Context)
+}
+
+type AuditRouterImpl struct {
+	auditUsecase usecase.AuditUsecase
+}
+
+func NewAuditRouterImpl(auditUsecase usecase.AuditUsecase) AuditRouter {
+	return &AuditRouterImpl{
+		auditUsecase: auditUsecase,
+	}
+}
+
+func (t *AuditRouterImpl) VerifyAudit(c *gin.Context) {
+	verification, verifyError := t.auditUsecase.Verify()
+
+	if verifyError != nil && verifyError.Error != nil {
+		c.JSON(int(verifyError.ErrorCode), gin.H{"error": verifyError.Error.Error()})
+		return
+	}
+
+	if !verification.Valid && verification.BrokenEventID == 0 {
+		c.JSON(http.StatusOK, gin.H{"data": verification, "message": "audit chain is intact, signatures unchecked"})
+		return
+	}
+
+	if !verification.Valid {
+		c.JSON(http.StatusConflict, gin.H{"data": verification, "message": "audit chain is broken"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": verification, "message": "successfully verify audit"})
+}
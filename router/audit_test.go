@@ -0,0 +1,95 @@
+package router_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/router"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestVerifyAudit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockAuditUsecase := new(mocks.AuditUsecaseMock)
+		auditRouter := router.NewAuditRouterImpl(mockAuditUsecase)
+
+		mockError := &helper.StandardError{Error: nil, ErrorCode: http.StatusOK}
+
+		mockAuditUsecase.On("Verify").Return(&repository.AuditVerification{Valid: true, CheckedEvents: 3}, mockError)
+
+		router := gin.Default()
+		router.GET("/audit/verify", auditRouter.VerifyAudit)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/audit/verify", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "successfully verify audit")
+	})
+
+	t.Run("Broken chain", func(t *testing.T) {
+		mockAuditUsecase := new(mocks.AuditUsecaseMock)
+		auditRouter := router.NewAuditRouterImpl(mockAuditUsecase)
+
+		mockError := &helper.StandardError{Error: nil, ErrorCode: http.StatusOK}
+
+		mockAuditUsecase.On("Verify").Return(&repository.AuditVerification{Valid: false, BrokenEventID: 7, Reason: "event content does not match its hash"}, mockError)
+
+		router := gin.Default()
+		router.GET("/audit/verify", auditRouter.VerifyAudit)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/audit/verify", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.MatchRegex(t, w.Body.String(), `"brokeneventid":7`)
+	})
+
+	t.Run("Signatures unchecked", func(t *testing.T) {
+		mockAuditUsecase := new(mocks.AuditUsecaseMock)
+		auditRouter := router.NewAuditRouterImpl(mockAuditUsecase)
+
+		mockError := &helper.StandardError{Error: nil, ErrorCode: http.StatusOK}
+
+		mockAuditUsecase.On("Verify").Return(&repository.AuditVerification{Valid: false, CheckedEvents: 3, Reason: "signatures unchecked"}, mockError)
+
+		router := gin.Default()
+		router.GET("/audit/verify", auditRouter.VerifyAudit)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/audit/verify", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "audit chain is intact, signatures unchecked")
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockAuditUsecase := new(mocks.AuditUsecaseMock)
+		auditRouter := router.NewAuditRouterImpl(mockAuditUsecase)
+
+		mockError := &helper.StandardError{Error: errors.New("error message"), ErrorCode: http.StatusInternalServerError}
+
+		mockAuditUsecase.On("Verify").Return(&repository.AuditVerification{}, mockError)
+
+		router := gin.Default()
+		router.GET("/audit/verify", auditRouter.VerifyAudit)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/audit/verify", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "error message")
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"andikawhy/go-user-management/repository"
 	"andikawhy/go-user-management/usecase"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,6 +12,7 @@ import (
 type AuthRouter interface {
 	Register(c *gin.Context)
 	Login(c *gin.Context)
+	Logout(c *gin.Context)
 }
 
 type AuthRouterImpl struct {
@@ -33,7 +35,7 @@ func (t *AuthRouterImpl) Register(c *gin.Context) {
 		return
 	}
 
-	user, registerError := t.authUsecase.Register(registerData)
+	user, registerError := t.authUsecase.Register(registerData, getCurrentOrganizationId(c))
 
 	if registerError != nil && registerError.Error != nil {
 		c.JSON(int(registerError.ErrorCode), gin.H{"error": registerError.Error.Error()})
@@ -51,7 +53,12 @@ func (t *AuthRouterImpl) Login(c *gin.Context) {
 		return
 	}
 
-	token, loginError := t.authUsecase.Login(loginData)
+	if loginData.Session {
+		t.loginWithSession(c, loginData)
+		return
+	}
+
+	token, loginError := t.authUsecase.Login(loginData, requestLoginContext(c))
 
 	if loginError != nil && loginError.Error != nil {
 		c.JSON(int(loginError.ErrorCode), gin.H{"error": loginError.Error.Error()})
@@ -60,3 +67,34 @@ func (t *AuthRouterImpl) Login(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"token": token, "message": "successfully login"})
 }
+
+// setSessionCookies sets the HttpOnly session cookie and the script-readable CSRF cookie the frontend echoes back in a header.
+func setSessionCookies(c *gin.Context, sessionToken string, csrfToken string, maxAge int) {
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie(usecase.SessionCookie, sessionToken, maxAge, "/", "", true, true)
+	c.SetCookie(usecase.CSRFCookie, csrfToken, maxAge, "/", "", true, false)
+}
+
+func (t *AuthRouterImpl) loginWithSession(c *gin.Context, loginData repository.Login) {
+	session, loginError := t.authUsecase.CreateSession(loginData, requestLoginContext(c))
+
+	if loginError != nil && loginError.Error != nil {
+		c.JSON(int(loginError.ErrorCode), gin.H{"error": loginError.Error.Error()})
+		return
+	}
+
+	setSessionCookies(c, session.SessionToken, session.CSRFToken, int(time.Until(session.AbsoluteExpiresAt).Seconds()))
+	c.JSON(http.StatusOK, gin.H{"data": session, "message": "successfully login"})
+}
+
+func (t *AuthRouterImpl) Logout(c *gin.Context) {
+	logoutError := t.authUsecase.Logout(getCurrentSessionId(c))
+
+	if logoutError != nil && logoutError.Error != nil {
+		c.JSON(int(logoutError.ErrorCode), gin.H{"error": logoutError.Error.Error()})
+		return
+	}
+
+	setSessionCookies(c, "", "", -1)
+	c.JSON(http.StatusOK, gin.H{"message": "successfully logout"})
+}
@@ -3,12 +3,15 @@ package router_test
 import (
 	"andikawhy/go-user-management/helper"
 	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
 	"andikawhy/go-user-management/router"
+	"andikawhy/go-user-management/usecase"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/assert/v2"
@@ -130,4 +133,82 @@ func TestLogin(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
 
+	t.Run("Session", func(t *testing.T) {
+		mockUserUsecase := new(mocks.UserUsecaseMock)
+		mockAuthUsecase := new(mocks.AuthUsecaseMock)
+		authRouter := router.NewAuthRouterImpl(mockUserUsecase, mockAuthUsecase)
+
+		mockError := &helper.StandardError{Error: nil, ErrorCode: http.StatusOK}
+		mockSession := &repository.CreatedSession{
+			Session:      repository.Session{ID: 1, UserID: 100, AbsoluteExpiresAt: time.Now().Add(time.Hour)},
+			SessionToken: "gum_sess_token",
+			CSRFToken:    "csrf",
+		}
+
+		mockAuthUsecase.On("CreateSession").Return(mockSession, mockError)
+
+		router := gin.Default()
+		router.POST("/login", authRouter.Login)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"username": "username", "password": "password", "session": true}`))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), `"csrftoken":"csrf"`)
+		assert.Equal(t, strings.Contains(w.Body.String(), "gum_sess_token"), false)
+
+		cookies := w.Result().Cookies()
+		assert.Equal(t, len(cookies), 2)
+		assert.Equal(t, cookies[0].Name, usecase.SessionCookie)
+		assert.Equal(t, cookies[0].Value, "gum_sess_token")
+		assert.Equal(t, cookies[0].HttpOnly, true)
+		assert.Equal(t, cookies[0].Secure, true)
+		assert.Equal(t, cookies[0].SameSite, http.SameSiteStrictMode)
+		assert.Equal(t, cookies[1].Name, usecase.CSRFCookie)
+		assert.Equal(t, cookies[1].HttpOnly, false)
+		mockAuthUsecase.AssertNotCalled(t, "Login")
+	})
+}
+
+func TestLogoutSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockAuthUsecase := new(mocks.AuthUsecaseMock)
+		authRouter := router.NewAuthRouterImpl(nil, mockAuthUsecase)
+
+		mockAuthUsecase.On("Logout", uint64(1)).Return((*helper.StandardError)(nil))
+
+		router := gin.Default()
+		router.POST("/logout", func(c *gin.Context) {
+			c.Set("currentSessionId", uint64(1))
+		}, authRouter.Logout)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/logout", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "successfully logout")
+		assert.Equal(t, w.Result().Cookies()[0].MaxAge, -1)
+	})
+
+	t.Run("Bearer token", func(t *testing.T) {
+		mockAuthUsecase := new(mocks.AuthUsecaseMock)
+		authRouter := router.NewAuthRouterImpl(nil, mockAuthUsecase)
+
+		mockError := &helper.StandardError{Error: errors.New("request is not authenticated with a session"), ErrorCode: http.StatusBadRequest}
+		mockAuthUsecase.On("Logout", uint64(0)).Return(mockError)
+
+		router := gin.Default()
+		router.POST("/logout", authRouter.Logout)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/logout", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, len(w.Result().Cookies()), 0)
+	})
 }
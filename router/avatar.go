@@ -0,0 +1,110 @@
+package router
+
+import (
+	"andikawhy/go-user-management/usecase"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead allows for the boundaries and part headers around the uploaded file.
+const multipartOverhead = 64 << 10
+
+type AvatarRouter interface {
+	UploadAvatar(c *gin.Context)
+	RemoveAvatar(c *gin.Context)
+	GetAvatar(c *gin.Context)
+}
+
+type AvatarRouterImpl struct {
+	avatarUsecase usecase.AvatarUsecase
+}
+
+func NewAvatarRouterImpl(avatarUsecase usecase.AvatarUsecase) AvatarRouter {
+	return &AvatarRouterImpl{
+		avatarUsecase: avatarUsecase,
+	}
+}
+
+// UploadAvatar expects a multipart form with the image in the "avatar" field.
+func (t *AvatarRouterImpl) UploadAvatar(c *gin.Context) {
+	currentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	maxBytes := usecase.AvatarMaxBytes()
+	tooLarge := gin.H{"error": fmt.Sprintf("avatar must be at most %d bytes", maxBytes)}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+multipartOverhead)
+
+	fileHeader, err := c.FormFile("avatar")
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			c.JSON(http.StatusRequestEntityTooLarge, tooLarge)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "avatar file is required"})
+		return
+	}
+	if fileHeader.Size > maxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, tooLarge)
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read avatar"})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read avatar"})
+		return
+	}
+
+	user, uploadError := t.avatarUsecase.SetAvatar(currentUserId, data)
+
+	if uploadError != nil && uploadError.Error != nil {
+		c.JSON(int(uploadError.ErrorCode), gin.H{"error": uploadError.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": user, "message": "successfully upload avatar"})
+}
+
+func (t *AvatarRouterImpl) RemoveAvatar(c *gin.Context) {
+	currentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	user, removeError := t.avatarUsecase.RemoveAvatar(currentUserId)
+
+	if removeError != nil && removeError.Error != nil {
+		c.JSON(int(removeError.ErrorCode), gin.H{"error": removeError.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": user, "message": "successfully remove avatar"})
+}
+
+// GetAvatar serves avatars kept in the local blob store. Every upload gets a new key, so they can be cached forever.
+func (t *AvatarRouterImpl) GetAvatar(c *gin.Context) {
+	data, err := t.avatarUsecase.GetAvatar(strings.TrimPrefix(c.Param("key"), "/"))
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Data(http.StatusOK, "image/png", data)
+}
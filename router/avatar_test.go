@@ -0,0 +1,107 @@
+package router_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/router"
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/stretchr/testify/mock"
+)
+
+func avatarUpload(field string, data []byte) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, _ := writer.CreateFormFile(field, "avatar.png")
+	part.Write(data)
+	writer.Close()
+
+	req, _ := http.NewRequest(http.MethodPut, "/me/avatar", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestUploadAvatar(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockAvatarUsecase := new(mocks.AvatarUsecaseMock)
+		avatarRouter := router.NewAvatarRouterImpl(mockAvatarUsecase)
+
+		mockAvatarUsecase.On("SetAvatar", uint64(100), []byte("image bytes")).
+			Return(&repository.UserResponse{ID: 100, Username: "username", AvatarURL: "http://localhost:3000/blobs/avatars/100/key/256.png"}, (*helper.StandardError)(nil))
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.PUT("/me/avatar", avatarRouter.UploadAvatar)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, avatarUpload("avatar", []byte("image bytes")))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), `"avatarurl":"http://localhost:3000/blobs/avatars/100/key/256.png"`)
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		avatarRouter := router.NewAvatarRouterImpl(nil)
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.PUT("/me/avatar", avatarRouter.UploadAvatar)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, avatarUpload("picture", []byte("image bytes")))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Too large", func(t *testing.T) {
+		t.Setenv("AVATAR_MAX_BYTES", "10")
+		mockAvatarUsecase := new(mocks.AvatarUsecaseMock)
+		avatarRouter := router.NewAvatarRouterImpl(mockAvatarUsecase)
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.PUT("/me/avatar", avatarRouter.UploadAvatar)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, avatarUpload("avatar", bytes.Repeat([]byte("x"), 100)))
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		mockAvatarUsecase.AssertNotCalled(t, "SetAvatar", mock.Anything, mock.Anything)
+	})
+}
+
+func TestGetAvatar(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockAvatarUsecase := new(mocks.AvatarUsecaseMock)
+	avatarRouter := router.NewAvatarRouterImpl(mockAvatarUsecase)
+	mockAvatarUsecase.On("GetAvatar", "avatars/100/key/64.png").Return([]byte("png"), (*helper.StandardError)(nil))
+	mockAvatarUsecase.On("GetAvatar", "avatars/100/key/32.png").Return([]byte(nil), &helper.StandardError{Error: errors.New("avatar not found"), ErrorCode: http.StatusNotFound})
+
+	router := gin.Default()
+	router.GET("/blobs/*key", avatarRouter.GetAvatar)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/blobs/avatars/100/key/64.png", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, w.Header().Get("Content-Type"), "image/png")
+	assert.Equal(t, w.Body.String(), "png")
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/blobs/avatars/100/key/32.png", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,138 @@
+package router
+
+import (
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	federationStateCookie     = "federation_state"
+	federationStateCookiePath = "/api/v1/federation"
+)
+
+type FederationRouter interface {
+	Login(c *gin.Context)
+	Callback(c *gin.Context)
+	LinkIdentity(c *gin.Context)
+	ListIdentities(c *gin.Context)
+	UnlinkIdentity(c *gin.Context)
+}
+
+type FederationRouterImpl struct {
+	federationUsecase usecase.FederationUsecase
+}
+
+func NewFederationRouterImpl(federationUsecase usecase.FederationUsecase) FederationRouter {
+	return &FederationRouterImpl{
+		federationUsecase: federationUsecase,
+	}
+}
+
+// setStateCookie binds the login to the browser; without it a state could be completed elsewhere, e.g. to sign a victim
+// into an attacker's account.
+func setStateCookie(c *gin.Context, state string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(federationStateCookie, state, maxAge, federationStateCookiePath, "", isSecureRequest(c), true)
+}
+
+func (t *FederationRouterImpl) Login(c *gin.Context) {
+	redirect, err := t.federationUsecase.BeginLogin(c.Param("provider"), 0)
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	setStateCookie(c, redirect.State, int(usecase.FederationStateExpiry.Seconds()))
+	c.Redirect(http.StatusFound, redirect.RedirectTo)
+}
+
+func (t *FederationRouterImpl) Callback(c *gin.Context) {
+	var callback repository.FederationCallback
+
+	if err := c.ShouldBindQuery(&callback); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	state, _ := c.Cookie(federationStateCookie)
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(callback.State)) != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired state"})
+		return
+	}
+	setStateCookie(c, "", -1)
+
+	result, err := t.federationUsecase.Callback(c.Param("provider"), callback, requestLoginContext(c))
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	if result.Session != nil {
+		setSessionCookies(c, result.Session.SessionToken, result.Session.CSRFToken, int(time.Until(result.Session.AbsoluteExpiresAt).Seconds()))
+	}
+	c.Redirect(http.StatusFound, result.RedirectTo)
+}
+
+func (t *FederationRouterImpl) LinkIdentity(c *gin.Context) {
+	currentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	redirect, err := t.federationUsecase.BeginLogin(c.Param("provider"), currentUserId)
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	setStateCookie(c, redirect.State, int(usecase.FederationStateExpiry.Seconds()))
+	c.JSON(http.StatusOK, gin.H{"data": redirect, "message": "continue at the identity provider to link the identity"})
+}
+
+func (t *FederationRouterImpl) ListIdentities(c *gin.Context) {
+	currentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	identities, err := t.federationUsecase.ListIdentities(currentUserId)
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": identities, "message": "successfully list identities"})
+}
+
+func (t *FederationRouterImpl) UnlinkIdentity(c *gin.Context) {
+	identityId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to convert requested identity ID"})
+		return
+	}
+
+	currentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	identity, unlinkError := t.federationUsecase.UnlinkIdentity(currentUserId, identityId)
+
+	if unlinkError != nil && unlinkError.Error != nil {
+		c.JSON(int(unlinkError.ErrorCode), gin.H{"error": unlinkError.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": identity, "message": "successfully unlink identity"})
+}
@@ -0,0 +1,260 @@
+package router_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/router"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestFederationLogin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Redirects to identity provider", func(t *testing.T) {
+		mockFederationUsecase := new(mocks.FederationUsecaseMock)
+		federationRouter := router.NewFederationRouterImpl(mockFederationUsecase)
+
+		mockError := &helper.StandardError{Error: nil, ErrorCode: http.StatusOK}
+
+		mockFederationUsecase.On("BeginLogin", "company", uint64(0)).Return(&repository.FederationRedirect{RedirectTo: "https://idp.example.com/authorize?state=abc", State: "abc"}, mockError)
+
+		router := gin.Default()
+		router.GET("/api/v1/federation/:provider/login", federationRouter.Login)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/federation/company/login", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusFound, w.Code)
+		assert.Equal(t, w.Header().Get("Location"), "https://idp.example.com/authorize?state=abc")
+		assert.MatchRegex(t, w.Header().Get("Set-Cookie"), "federation_state=abc; Path=/api/v1/federation; Max-Age=600; HttpOnly")
+	})
+
+	t.Run("Unknown provider", func(t *testing.T) {
+		mockFederationUsecase := new(mocks.FederationUsecaseMock)
+		federationRouter := router.NewFederationRouterImpl(mockFederationUsecase)
+
+		mockError := &helper.StandardError{Error: errors.New("identity provider not found"), ErrorCode: http.StatusNotFound}
+
+		mockFederationUsecase.On("BeginLogin", mock.Anything, mock.Anything).Return((*repository.FederationRedirect)(nil), mockError)
+
+		router := gin.Default()
+		router.GET("/api/v1/federation/:provider/login", federationRouter.Login)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/federation/github/login", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "identity provider not found")
+	})
+}
+
+func TestFederationCallback(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success login", func(t *testing.T) {
+		mockFederationUsecase := new(mocks.FederationUsecaseMock)
+		federationRouter := router.NewFederationRouterImpl(mockFederationUsecase)
+
+		mockError := &helper.StandardError{Error: nil, ErrorCode: http.StatusOK}
+		expectedCallback := repository.FederationCallback{Code: "abc", State: "xyz"}
+
+		session := &repository.CreatedSession{Session: repository.Session{AbsoluteExpiresAt: time.Now().Add(time.Hour)}, SessionToken: "gum_sess_abc", CSRFToken: "csrf"}
+		mockFederationUsecase.On("Callback", "company", expectedCallback).Return(&repository.FederationResult{Session: session, Identity: repository.Identity{ID: 1, UserID: 100}, RedirectTo: "https://app.example.com/"}, mockError)
+
+		router := gin.Default()
+		router.GET("/api/v1/federation/:provider/callback", federationRouter.Callback)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/federation/company/callback?code=abc&state=xyz", nil)
+		req.AddCookie(&http.Cookie{Name: "federation_state", Value: "xyz"})
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusFound, w.Code)
+		assert.Equal(t, w.Header().Get("Cache-Control"), "no-store")
+		assert.Equal(t, w.Header().Get("Location"), "https://app.example.com/")
+		assert.MatchRegex(t, strings.Join(w.Header().Values("Set-Cookie"), "\n"), "gum_session=gum_sess_abc; .*HttpOnly")
+		assert.NotMatchRegex(t, w.Body.String(), "gum_sess_abc")
+	})
+
+	t.Run("Success link", func(t *testing.T) {
+		mockFederationUsecase := new(mocks.FederationUsecaseMock)
+		federationRouter := router.NewFederationRouterImpl(mockFederationUsecase)
+
+		mockError := &helper.StandardError{Error: nil, ErrorCode: http.StatusOK}
+
+		mockFederationUsecase.On("Callback", mock.Anything, mock.Anything).Return(&repository.FederationResult{Identity: repository.Identity{ID: 1, UserID: 100}, RedirectTo: "https://app.example.com/"}, mockError)
+
+		router := gin.Default()
+		router.GET("/api/v1/federation/:provider/callback", federationRouter.Callback)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/federation/company/callback?code=abc&state=xyz", nil)
+		req.AddCookie(&http.Cookie{Name: "federation_state", Value: "xyz"})
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusFound, w.Code)
+		assert.Equal(t, w.Header().Get("Location"), "https://app.example.com/")
+		assert.NotMatchRegex(t, strings.Join(w.Header().Values("Set-Cookie"), "\n"), "gum_session")
+	})
+
+	t.Run("Invalid state", func(t *testing.T) {
+		mockFederationUsecase := new(mocks.FederationUsecaseMock)
+		federationRouter := router.NewFederationRouterImpl(mockFederationUsecase)
+
+		mockError := &helper.StandardError{Error: errors.New("invalid or expired state"), ErrorCode: http.StatusBadRequest}
+
+		mockFederationUsecase.On("Callback", mock.Anything, mock.Anything).Return((*repository.FederationResult)(nil), mockError)
+
+		router := gin.Default()
+		router.GET("/api/v1/federation/:provider/callback", federationRouter.Callback)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/federation/company/callback?code=abc&state=forged", nil)
+		req.AddCookie(&http.Cookie{Name: "federation_state", Value: "forged"})
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "invalid or expired state")
+	})
+
+	t.Run("State from another browser", func(t *testing.T) {
+		mockFederationUsecase := new(mocks.FederationUsecaseMock)
+		federationRouter := router.NewFederationRouterImpl(mockFederationUsecase)
+
+		router := gin.Default()
+		router.GET("/api/v1/federation/:provider/callback", federationRouter.Callback)
+
+		for _, cookie := range []*http.Cookie{nil, {Name: "federation_state", Value: "other"}} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/api/v1/federation/company/callback?code=abc&state=xyz", nil)
+			if cookie != nil {
+				req.AddCookie(cookie)
+			}
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.MatchRegex(t, w.Body.String(), "invalid or expired state")
+		}
+		mockFederationUsecase.AssertNotCalled(t, "Callback", mock.Anything, mock.Anything)
+	})
+}
+
+func TestLinkIdentity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockFederationUsecase := new(mocks.FederationUsecaseMock)
+		federationRouter := router.NewFederationRouterImpl(mockFederationUsecase)
+
+		mockError := &helper.StandardError{Error: nil, ErrorCode: http.StatusOK}
+
+		mockFederationUsecase.On("BeginLogin", "company", uint64(100)).Return(&repository.FederationRedirect{RedirectTo: "https://idp.example.com/authorize?state=abc", State: "abc"}, mockError)
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.POST("/api/v1/me/identities/:provider", federationRouter.LinkIdentity)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/v1/me/identities/company", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), `"redirectto":"https://idp.example.com/authorize\?state=abc"`)
+		assert.MatchRegex(t, w.Header().Get("Set-Cookie"), "federation_state=abc")
+	})
+}
+
+func TestListIdentities(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockFederationUsecase := new(mocks.FederationUsecaseMock)
+		federationRouter := router.NewFederationRouterImpl(mockFederationUsecase)
+
+		mockError := &helper.StandardError{Error: nil, ErrorCode: http.StatusOK}
+
+		mockFederationUsecase.On("ListIdentities").Return(&[]repository.Identity{{ID: 1, UserID: 100, Provider: "company"}}, mockError)
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.GET("/api/v1/me/identities", federationRouter.ListIdentities)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/me/identities", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), `"provider":"company"`)
+	})
+}
+
+func TestUnlinkIdentity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockFederationUsecase := new(mocks.FederationUsecaseMock)
+		federationRouter := router.NewFederationRouterImpl(mockFederationUsecase)
+
+		mockError := &helper.StandardError{Error: nil, ErrorCode: http.StatusOK}
+
+		mockFederationUsecase.On("UnlinkIdentity").Return(&repository.Identity{ID: 1, UserID: 100, Provider: "company"}, mockError)
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.DELETE("/api/v1/me/identities/:id", federationRouter.UnlinkIdentity)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/api/v1/me/identities/1", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "successfully unlink identity")
+	})
+
+	t.Run("Only sign-in method", func(t *testing.T) {
+		mockFederationUsecase := new(mocks.FederationUsecaseMock)
+		federationRouter := router.NewFederationRouterImpl(mockFederationUsecase)
+
+		mockError := &helper.StandardError{Error: errors.New("cannot unlink the only sign-in method of the account"), ErrorCode: http.StatusConflict}
+
+		mockFederationUsecase.On("UnlinkIdentity").Return((*repository.Identity)(nil), mockError)
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.DELETE("/api/v1/me/identities/:id", federationRouter.UnlinkIdentity)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/api/v1/me/identities/1", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Invalid identity ID", func(t *testing.T) {
+		mockFederationUsecase := new(mocks.FederationUsecaseMock)
+		federationRouter := router.NewFederationRouterImpl(mockFederationUsecase)
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.DELETE("/api/v1/me/identities/:id", federationRouter.UnlinkIdentity)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/api/v1/me/identities/abc", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "Failed to convert requested identity ID")
+	})
+}
@@ -0,0 +1,194 @@
+package router
+
+import (
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GroupRouter interface {
+	CreateGroup(c *gin.Context)
+	ListGroups(c *gin.Context)
+	RenameGroup(c *gin.Context)
+	DeleteGroup(c *gin.Context)
+	ListMembers(c *gin.Context)
+	AddUser(c *gin.Context)
+	RemoveUser(c *gin.Context)
+	AddGroup(c *gin.Context)
+	RemoveGroup(c *gin.Context)
+}
+
+type GroupRouterImpl struct {
+	groupUsecase usecase.GroupUsecase
+}
+
+func NewGroupRouterImpl(groupUsecase usecase.GroupUsecase) GroupRouter {
+	return &GroupRouterImpl{
+		groupUsecase: groupUsecase,
+	}
+}
+
+// getActorId returns the current user, or 0 for admin requests made with a client credentials token.
+func getActorId(c *gin.Context) uint64 {
+	actorId, _ := c.Get("currentUserId")
+	currentUserId, _ := actorId.(uint64)
+	return currentUserId
+}
+
+func (t *GroupRouterImpl) CreateGroup(c *gin.Context) {
+	var groupData repository.SaveGroup
+
+	if err := c.ShouldBindJSON(&groupData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	group, err := t.groupUsecase.CreateGroup(getCurrentOrganizationId(c), groupData, getActorId(c))
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": group, "message": "successfully create group"})
+}
+
+func (t *GroupRouterImpl) ListGroups(c *gin.Context) {
+	groups, err := t.groupUsecase.ListGroups(getCurrentOrganizationId(c))
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": groups, "message": "successfully list groups"})
+}
+
+func (t *GroupRouterImpl) RenameGroup(c *gin.Context) {
+	groupId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to convert requested group ID"})
+		return
+	}
+
+	var groupData repository.SaveGroup
+
+	if err := c.ShouldBindJSON(&groupData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	group, renameError := t.groupUsecase.RenameGroup(getCurrentOrganizationId(c), groupId, groupData, getActorId(c))
+
+	if renameError != nil && renameError.Error != nil {
+		c.JSON(int(renameError.ErrorCode), gin.H{"error": renameError.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": group, "message": "successfully rename group"})
+}
+
+func (t *GroupRouterImpl) DeleteGroup(c *gin.Context) {
+	groupId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to convert requested group ID"})
+		return
+	}
+
+	group, deleteError := t.groupUsecase.DeleteGroup(getCurrentOrganizationId(c), groupId, getActorId(c))
+
+	if deleteError != nil && deleteError.Error != nil {
+		c.JSON(int(deleteError.ErrorCode), gin.H{"error": deleteError.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": group, "message": "successfully delete group"})
+}
+
+func (t *GroupRouterImpl) ListMembers(c *gin.Context) {
+	groupId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to convert requested group ID"})
+		return
+	}
+
+	members, listError := t.groupUsecase.ListMembers(getCurrentOrganizationId(c), groupId)
+
+	if listError != nil && listError.Error != nil {
+		c.JSON(int(listError.ErrorCode), gin.H{"error": listError.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": members, "message": "successfully list group members"})
+}
+
+// memberFromPath reads the group and the user or nested group addressed by a membership route.
+func memberFromPath(c *gin.Context, nestedGroup bool) (uint64, repository.GroupMember, bool) {
+	groupId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to convert requested group ID"})
+		return 0, repository.GroupMember{}, false
+	}
+
+	memberId, err := strconv.ParseUint(c.Param("memberId"), 10, 64)
+	if err != nil || memberId == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to convert requested member ID"})
+		return 0, repository.GroupMember{}, false
+	}
+
+	if nestedGroup {
+		return groupId, repository.GroupMember{MemberGroupID: memberId}, true
+	}
+	return groupId, repository.GroupMember{UserID: memberId}, true
+}
+
+func (t *GroupRouterImpl) addMember(c *gin.Context, nestedGroup bool) {
+	groupId, member, ok := memberFromPath(c, nestedGroup)
+	if !ok {
+		return
+	}
+
+	membership, err := t.groupUsecase.AddMember(getCurrentOrganizationId(c), groupId, member, getActorId(c))
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": membership, "message": "successfully add group member"})
+}
+
+func (t *GroupRouterImpl) removeMember(c *gin.Context, nestedGroup bool) {
+	groupId, member, ok := memberFromPath(c, nestedGroup)
+	if !ok {
+		return
+	}
+
+	err := t.groupUsecase.RemoveMember(getCurrentOrganizationId(c), groupId, member, getActorId(c))
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "successfully remove group member"})
+}
+
+func (t *GroupRouterImpl) AddUser(c *gin.Context) {
+	t.addMember(c, false)
+}
+
+func (t *GroupRouterImpl) RemoveUser(c *gin.Context) {
+	t.removeMember(c, false)
+}
+
+func (t *GroupRouterImpl) AddGroup(c *gin.Context) {
+	t.addMember(c, true)
+}
+
+func (t *GroupRouterImpl) RemoveGroup(c *gin.Context) {
+	t.removeMember(c, true)
+}
@@ -0,0 +1,143 @@
+package router_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/router"
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestCreateGroup(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockGroupUsecase := new(mocks.GroupUsecaseMock)
+		groupRouter := router.NewGroupRouterImpl(mockGroupUsecase)
+
+		mockGroupUsecase.On("CreateGroup", repository.DefaultOrganizationID, repository.SaveGroup{Name: "engineering"}, uint64(100)).Return(&repository.Group{ID: 1, OrganizationID: 1, Name: "engineering"}, (*helper.StandardError)(nil))
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.POST("/groups", groupRouter.CreateGroup)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/groups", bytes.NewBufferString(`{"name":"engineering"}`))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), `"name":"engineering"`)
+	})
+
+	t.Run("Missing name", func(t *testing.T) {
+		groupRouter := router.NewGroupRouterImpl(nil)
+
+		router := gin.Default()
+		router.POST("/groups", groupRouter.CreateGroup)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/groups", bytes.NewBufferString(`{}`))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestListGroupMembers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockGroupUsecase := new(mocks.GroupUsecaseMock)
+	groupRouter := router.NewGroupRouterImpl(mockGroupUsecase)
+
+	members := repository.GroupMembers{Members: []repository.GroupMember{{ID: 1, GroupID: 1, MemberGroupID: 2}}, EffectiveUsers: []uint64{100}}
+	mockGroupUsecase.On("ListMembers", repository.DefaultOrganizationID, uint64(1)).Return(&members, (*helper.StandardError)(nil))
+
+	router := gin.Default()
+	router.GET("/groups/:id/members", groupRouter.ListMembers)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/groups/1/members", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.MatchRegex(t, w.Body.String(), `"effectiveusers":\[100\]`)
+}
+
+func TestAddGroupMember(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("User", func(t *testing.T) {
+		mockGroupUsecase := new(mocks.GroupUsecaseMock)
+		groupRouter := router.NewGroupRouterImpl(mockGroupUsecase)
+
+		mockGroupUsecase.On("AddMember", repository.DefaultOrganizationID, uint64(1), repository.GroupMember{UserID: 101}, uint64(100)).Return(&repository.GroupMember{ID: 1, GroupID: 1, UserID: 101}, (*helper.StandardError)(nil))
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.PUT("/groups/:id/members/users/:memberId", groupRouter.AddUser)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPut, "/groups/1/members/users/101", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Cycle", func(t *testing.T) {
+		mockGroupUsecase := new(mocks.GroupUsecaseMock)
+		groupRouter := router.NewGroupRouterImpl(mockGroupUsecase)
+
+		mockGroupUsecase.On("AddMember", repository.DefaultOrganizationID, uint64(2), repository.GroupMember{MemberGroupID: 1}, uint64(100)).Return((*repository.GroupMember)(nil), &helper.StandardError{Error: errors.New("group membership would create a cycle"), ErrorCode: http.StatusConflict})
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.PUT("/groups/:id/members/groups/:memberId", groupRouter.AddGroup)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPut, "/groups/2/members/groups/1", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, w.Body.String(), `{"error":"group membership would create a cycle"}`)
+	})
+
+	t.Run("Invalid member ID", func(t *testing.T) {
+		groupRouter := router.NewGroupRouterImpl(nil)
+
+		router := gin.Default()
+		router.PUT("/groups/:id/members/groups/:memberId", groupRouter.AddGroup)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPut, "/groups/2/members/groups/abc", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, w.Body.String(), `{"error":"Failed to convert requested member ID"}`)
+	})
+}
+
+func TestRemoveGroupMember(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockGroupUsecase := new(mocks.GroupUsecaseMock)
+	groupRouter := router.NewGroupRouterImpl(mockGroupUsecase)
+
+	mockGroupUsecase.On("RemoveMember", repository.DefaultOrganizationID, uint64(1), repository.GroupMember{UserID: 101}, uint64(100)).Return(&helper.StandardError{Error: errors.New("group member not found"), ErrorCode: http.StatusNotFound})
+
+	router := gin.Default()
+	router.Use(withCurrentUser)
+	router.DELETE("/groups/:id/members/users/:memberId", groupRouter.RemoveUser)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodDelete, "/groups/1/members/users/101", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,106 @@
+package router
+
+import (
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ImportRouter interface {
+	ImportUsers(c *gin.Context)
+	ListImports(c *gin.Context)
+	GetImport(c *gin.Context)
+}
+
+type ImportRouterImpl struct {
+	importUsecase usecase.ImportUsecase
+}
+
+func NewImportRouterImpl(importUsecase usecase.ImportUsecase) ImportRouter {
+	return &ImportRouterImpl{
+		importUsecase: importUsecase,
+	}
+}
+
+// importFormat falls back to the request's content type when the format query parameter is missing.
+func importFormat(c *gin.Context, format string) string {
+	if format != "" {
+		return format
+	}
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		return repository.ImportFormatCSV
+	case "application/x-ndjson", "application/ndjson", "application/jsonl":
+		return repository.ImportFormatNDJSON
+	}
+	return ""
+}
+
+// ImportUsers takes the file as the raw request body and answers 202 with the job to poll.
+func (t *ImportRouterImpl) ImportUsers(c *gin.Context) {
+	var options repository.ImportOptions
+
+	if err := c.ShouldBindQuery(&options); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	options.Format = importFormat(c, options.Format)
+	options.Subject = usecase.PolicySubject(c)
+
+	maxBytes := usecase.ImportMaxBytes()
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes))
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("import file must be at most %d bytes", maxBytes)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read import file"})
+		return
+	}
+
+	job, importError := t.importUsecase.StartImport(getCurrentOrganizationId(c), options, data, getActorId(c))
+
+	if importError != nil && importError.Error != nil {
+		c.JSON(int(importError.ErrorCode), gin.H{"error": importError.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"data": job, "message": "successfully start import"})
+}
+
+func (t *ImportRouterImpl) ListImports(c *gin.Context) {
+	jobs, err := t.importUsecase.ListImports(getCurrentOrganizationId(c))
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": jobs, "message": "successfully list imports"})
+}
+
+func (t *ImportRouterImpl) GetImport(c *gin.Context) {
+	jobId, err := strconv.ParseUint(c.Param("importId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to convert requested import ID"})
+		return
+	}
+
+	job, getError := t.importUsecase.GetImport(getCurrentOrganizationId(c), jobId)
+
+	if getError != nil && getError.Error != nil {
+		c.JSON(int(getError.ErrorCode), gin.H{"error": getError.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": job, "message": "successfully get import"})
+}
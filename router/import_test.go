@@ -0,0 +1,103 @@
+package router_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/router"
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestImportUsers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockImportUsecase := new(mocks.ImportUsecaseMock)
+		importRouter := router.NewImportRouterImpl(mockImportUsecase)
+
+		options := repository.ImportOptions{Format: repository.ImportFormatNDJSON, DryRun: true, Subject: map[string]interface{}{}}
+		mockImportUsecase.On("StartImport", uint64(1), options, []byte(`{"username":"alice"}`), uint64(0)).
+			Return(&repository.ImportJob{ID: 1, Status: repository.ImportStatusRunning, Total: 1}, (*helper.StandardError)(nil))
+
+		router := gin.Default()
+		router.POST("/users/import", importRouter.ImportUsers)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/users/import?dry_run=true", bytes.NewBufferString(`{"username":"alice"}`))
+		req.Header.Set("Content-Type", "application/x-ndjson")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		assert.MatchRegex(t, w.Body.String(), `"status":"running"`)
+	})
+
+	t.Run("Invalid format", func(t *testing.T) {
+		mockImportUsecase := new(mocks.ImportUsecaseMock)
+		importRouter := router.NewImportRouterImpl(mockImportUsecase)
+
+		router := gin.Default()
+		router.POST("/users/import", importRouter.ImportUsers)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/users/import?format=xlsx", bytes.NewBufferString("data"))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockImportUsecase.AssertNotCalled(t, "StartImport", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Too large", func(t *testing.T) {
+		t.Setenv("IMPORT_MAX_BYTES", "10")
+		mockImportUsecase := new(mocks.ImportUsecaseMock)
+		importRouter := router.NewImportRouterImpl(mockImportUsecase)
+
+		router := gin.Default()
+		router.POST("/users/import", importRouter.ImportUsers)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/users/import?format=csv", bytes.NewReader(bytes.Repeat([]byte("x"), 100)))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		mockImportUsecase.AssertNotCalled(t, "StartImport", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestGetImport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockImportUsecase := new(mocks.ImportUsecaseMock)
+	importRouter := router.NewImportRouterImpl(mockImportUsecase)
+	mockImportUsecase.On("GetImport", uint64(1), uint64(1)).Return(&repository.ImportJob{ID: 1, Failed: 1, Errors: []repository.ImportRowError{{Row: 2, Error: "invalid email"}}}, (*helper.StandardError)(nil))
+	mockImportUsecase.On("GetImport", uint64(1), uint64(2)).Return((*repository.ImportJob)(nil), &helper.StandardError{Error: errors.New("import not found"), ErrorCode: http.StatusNotFound})
+
+	router := gin.Default()
+	router.GET("/users/import/:importId", importRouter.GetImport)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/users/import/1", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.MatchRegex(t, w.Body.String(), `"errors":\[\{"row":2,"error":"invalid email"\}\]`)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/users/import/2", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/users/import/abc", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,109 @@
+package router
+
+import (
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InvitationRouter interface {
+	CreateInvitation(c *gin.Context)
+	ListInvitations(c *gin.Context)
+	ResendInvitation(c *gin.Context)
+	RevokeInvitation(c *gin.Context)
+	AcceptInvitation(c *gin.Context)
+}
+
+type InvitationRouterImpl struct {
+	invitationUsecase usecase.InvitationUsecase
+}
+
+func NewInvitationRouterImpl(invitationUsecase usecase.InvitationUsecase) InvitationRouter {
+	return &InvitationRouterImpl{
+		invitationUsecase: invitationUsecase,
+	}
+}
+
+func (t *InvitationRouterImpl) CreateInvitation(c *gin.Context) {
+	var invitationData repository.CreateInvitation
+
+	if err := c.ShouldBindJSON(&invitationData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invitation, err := t.invitationUsecase.CreateInvitation(getCurrentOrganizationId(c), invitationData, getActorId(c))
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": invitation, "message": "successfully create invitation"})
+}
+
+func (t *InvitationRouterImpl) ListInvitations(c *gin.Context) {
+	invitations, err := t.invitationUsecase.ListInvitations(getCurrentOrganizationId(c))
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": invitations, "message": "successfully list invitations"})
+}
+
+func (t *InvitationRouterImpl) ResendInvitation(c *gin.Context) {
+	invitationId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to convert requested invitation ID"})
+		return
+	}
+
+	invitation, resendError := t.invitationUsecase.ResendInvitation(getCurrentOrganizationId(c), invitationId, getActorId(c))
+
+	if resendError != nil && resendError.Error != nil {
+		c.JSON(int(resendError.ErrorCode), gin.H{"error": resendError.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": invitation, "message": "successfully resend invitation"})
+}
+
+func (t *InvitationRouterImpl) RevokeInvitation(c *gin.Context) {
+	invitationId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to convert requested invitation ID"})
+		return
+	}
+
+	invitation, revokeError := t.invitationUsecase.RevokeInvitation(getCurrentOrganizationId(c), invitationId, getActorId(c))
+
+	if revokeError != nil && revokeError.Error != nil {
+		c.JSON(int(revokeError.ErrorCode), gin.H{"error": revokeError.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": invitation, "message": "successfully revoke invitation"})
+}
+
+func (t *InvitationRouterImpl) AcceptInvitation(c *gin.Context) {
+	var acceptData repository.AcceptInvitation
+
+	if err := c.ShouldBindJSON(&acceptData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := t.invitationUsecase.AcceptInvitation(acceptData)
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": user, "message": "successfully accept invitation"})
+}
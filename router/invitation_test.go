@@ -0,0 +1,106 @@
+package router_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/router"
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestCreateInvitation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockInvitationUsecase := new(mocks.InvitationUsecaseMock)
+		invitationRouter := router.NewInvitationRouterImpl(mockInvitationUsecase)
+
+		invitation := repository.PendingInvitation{Invitation: repository.Invitation{ID: 1, UserID: 101}, Username: "invitee", Email: "invitee@example.com"}
+		mockInvitationUsecase.On("CreateInvitation", repository.DefaultOrganizationID, repository.CreateInvitation{Username: "invitee", Email: "invitee@example.com"}, uint64(100)).Return(&invitation, (*helper.StandardError)(nil))
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.POST("/invitations", invitationRouter.CreateInvitation)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/invitations", bytes.NewBufferString(`{"username":"invitee","email":"invitee@example.com"}`))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), `"username":"invitee"`)
+	})
+
+	t.Run("Invalid email", func(t *testing.T) {
+		invitationRouter := router.NewInvitationRouterImpl(nil)
+
+		router := gin.Default()
+		router.POST("/invitations", invitationRouter.CreateInvitation)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/invitations", bytes.NewBufferString(`{"username":"invitee","email":"invitee"}`))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestRevokeInvitation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Not found", func(t *testing.T) {
+		mockInvitationUsecase := new(mocks.InvitationUsecaseMock)
+		invitationRouter := router.NewInvitationRouterImpl(mockInvitationUsecase)
+
+		mockInvitationUsecase.On("RevokeInvitation", repository.DefaultOrganizationID, uint64(1), uint64(100)).Return((*repository.PendingInvitation)(nil), &helper.StandardError{Error: errors.New("invitation not found"), ErrorCode: http.StatusNotFound})
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.DELETE("/invitations/:id", invitationRouter.RevokeInvitation)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/invitations/1", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, w.Body.String(), `{"error":"invitation not found"}`)
+	})
+
+	t.Run("Invalid ID", func(t *testing.T) {
+		invitationRouter := router.NewInvitationRouterImpl(nil)
+
+		router := gin.Default()
+		router.DELETE("/invitations/:id", invitationRouter.RevokeInvitation)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/invitations/abc", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestAcceptInvitation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockInvitationUsecase := new(mocks.InvitationUsecaseMock)
+	invitationRouter := router.NewInvitationRouterImpl(mockInvitationUsecase)
+
+	mockInvitationUsecase.On("AcceptInvitation", repository.AcceptInvitation{Token: "gum_inv_token", Password: "chosen password"}).Return(&repository.UserResponse{ID: 101, Username: "invitee"}, (*helper.StandardError)(nil))
+
+	router := gin.Default()
+	router.POST("/invitations/accept", invitationRouter.AcceptInvitation)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/invitations/accept", bytes.NewBufferString(`{"token":"gum_inv_token","password":"chosen password"}`))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.MatchRegex(t, w.Body.String(), `"username":"invitee"`)
+}
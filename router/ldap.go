@@ -0,0 +1,247 @@
+package router
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+const (
+	ldapIdleTimeout    = 5 * time.Minute
+	ldapMaxMessageSize = 1 << 20
+)
+
+type LDAPRouter interface {
+	Serve(listener net.Listener) error
+}
+
+type LDAPRouterImpl struct {
+	directoryUsecase usecase.DirectoryUsecase
+}
+
+func NewLDAPRouterImpl(directoryUsecase usecase.DirectoryUsecase) LDAPRouter {
+	return &LDAPRouterImpl{
+		directoryUsecase: directoryUsecase,
+	}
+}
+
+func (t *LDAPRouterImpl) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go t.serveConn(conn)
+	}
+}
+
+func (t *LDAPRouterImpl) serveConn(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	bound := false
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(ldapIdleTimeout))
+
+		packet, err := readLDAPMessage(reader)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+
+		messageID, ok := packet.Children[0].Value.(int64)
+		request := packet.Children[1]
+		if !ok || request.ClassType != ber.ClassApplication {
+			return
+		}
+
+		var responses []*ber.Packet
+		switch request.Tag {
+		case ldap.ApplicationBindRequest:
+			authenticated, bindError := t.bind(request)
+			bound = authenticated
+			responses = append(responses, ldapResult(ldap.ApplicationBindResponse, bindError))
+		case ldap.ApplicationSearchRequest:
+			responses = t.search(request, bound)
+		case ldap.ApplicationUnbindRequest:
+			return
+		case ldap.ApplicationAbandonRequest:
+			continue
+		case ldap.ApplicationModifyRequest:
+			responses = append(responses, ldapReadOnly(ldap.ApplicationModifyResponse))
+		case ldap.ApplicationAddRequest:
+			responses = append(responses, ldapReadOnly(ldap.ApplicationAddResponse))
+		case ldap.ApplicationDelRequest:
+			responses = append(responses, ldapReadOnly(ldap.ApplicationDelResponse))
+		case ldap.ApplicationModifyDNRequest:
+			responses = append(responses, ldapReadOnly(ldap.ApplicationModifyDNResponse))
+		case ldap.ApplicationCompareRequest:
+			responses = append(responses, ldapReadOnly(ldap.ApplicationCompareResponse))
+		case ldap.ApplicationExtendedRequest:
+			responses = append(responses, ldapResult(ldap.ApplicationExtendedResponse, &helper.StandardError{Error: errors.New("unsupported extended operation"), ErrorCode: http.StatusBadRequest}))
+		default:
+			return
+		}
+
+		conn.SetWriteDeadline(time.Now().Add(ldapIdleTimeout))
+		for _, response := range responses {
+			if _, err := conn.Write(ldapMessage(messageID, response).Bytes()); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (t *LDAPRouterImpl) bind(request *ber.Packet) (bool, *helper.StandardError) {
+	if len(request.Children) < 3 || request.Children[2].ClassType != ber.ClassContext || request.Children[2].Tag != 0 {
+		return false, &helper.StandardError{Error: errors.New("only simple bind is supported"), ErrorCode: http.StatusUnauthorized}
+	}
+
+	dn := request.Children[1].Data.String()
+	password := request.Children[2].Data.String()
+	if dn == "" && password == "" {
+		return false, nil
+	}
+
+	err := t.directoryUsecase.Bind(dn, password)
+	return err == nil, err
+}
+
+func (t *LDAPRouterImpl) search(request *ber.Packet, bound bool) []*ber.Packet {
+	if len(request.Children) < 8 {
+		return []*ber.Packet{ldapResult(ldap.ApplicationSearchResultDone, &helper.StandardError{Error: errors.New("invalid search request"), ErrorCode: http.StatusBadRequest})}
+	}
+
+	search := repository.DirectorySearch{BaseDN: request.Children[0].Data.String()}
+	scope, _ := request.Children[1].Value.(int64)
+	search.Scope = int(scope)
+	sizeLimit, _ := request.Children[3].Value.(int64)
+
+	// The root DSE is readable without a bind so clients can discover the naming context.
+	if !bound && (search.BaseDN != "" || search.Scope != ldap.ScopeBaseObject) {
+		return []*ber.Packet{ldapResult(ldap.ApplicationSearchResultDone, &helper.StandardError{Error: errors.New("bind required"), ErrorCode: http.StatusForbidden})}
+	}
+
+	filter, err := ldap.DecompileFilter(request.Children[6])
+	if err != nil {
+		return []*ber.Packet{ldapResult(ldap.ApplicationSearchResultDone, &helper.StandardError{Error: errors.New("invalid filter"), ErrorCode: http.StatusBadRequest})}
+	}
+	search.Filter = filter
+
+	for _, attribute := range request.Children[7].Children {
+		search.Attributes = append(search.Attributes, attribute.Data.String())
+	}
+
+	entries, searchError := t.directoryUsecase.Search(search)
+	if searchError != nil {
+		return []*ber.Packet{ldapResult(ldap.ApplicationSearchResultDone, searchError)}
+	}
+
+	var responses []*ber.Packet
+	for i, entry := range entries {
+		if sizeLimit > 0 && int64(i) >= sizeLimit {
+			return append(responses, ldapResultCode(ldap.ApplicationSearchResultDone, ldap.LDAPResultSizeLimitExceeded, ""))
+		}
+		responses = append(responses, ldapEntry(entry))
+	}
+
+	return append(responses, ldapResult(ldap.ApplicationSearchResultDone, nil))
+}
+
+func readLDAPMessage(reader *bufio.Reader) (*ber.Packet, error) {
+	header, err := reader.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+
+	length := int(header[1])
+	if length&0x80 != 0 {
+		lengthBytes := length & 0x7f
+		if lengthBytes == 0 || lengthBytes > 3 {
+			return nil, errors.New("ldap message too large")
+		}
+
+		header, err = reader.Peek(2 + lengthBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		length = 0
+		for _, b := range header[2:] {
+			length = length<<8 | int(b)
+		}
+	}
+
+	if length > ldapMaxMessageSize {
+		return nil, errors.New("ldap message too large")
+	}
+
+	return ber.ReadPacket(reader)
+}
+
+func ldapMessage(messageID int64, operation *ber.Packet) *ber.Packet {
+	message := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	message.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, ""))
+	message.AppendChild(operation)
+	return message
+}
+
+func ldapResultCode(tag ber.Tag, code int, diagnosticMessage string) *ber.Packet {
+	result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "")
+	result.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), ""))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, diagnosticMessage, ""))
+	return result
+}
+
+func ldapResult(tag ber.Tag, err *helper.StandardError) *ber.Packet {
+	if err == nil || err.Error == nil {
+		return ldapResultCode(tag, ldap.LDAPResultSuccess, "")
+	}
+
+	code := ldap.LDAPResultOther
+	switch err.ErrorCode {
+	case http.StatusBadRequest:
+		code = ldap.LDAPResultProtocolError
+	case http.StatusUnauthorized:
+		code = ldap.LDAPResultInvalidCredentials
+	case http.StatusForbidden:
+		code = ldap.LDAPResultInsufficientAccessRights
+	case http.StatusNotFound:
+		code = ldap.LDAPResultNoSuchObject
+	}
+
+	return ldapResultCode(tag, code, err.Error.Error())
+}
+
+func ldapReadOnly(tag ber.Tag) *ber.Packet {
+	return ldapResultCode(tag, ldap.LDAPResultUnwillingToPerform, "directory is read-only")
+}
+
+func ldapEntry(entry repository.DirectoryEntry) *ber.Packet {
+	attributes := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	for name, values := range entry.Attributes {
+		attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+		attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, ""))
+
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+		for _, value := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, ""))
+		}
+		attribute.AppendChild(set)
+		attributes.AppendChild(attribute)
+	}
+
+	result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "")
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.DN, ""))
+	result.AppendChild(attributes)
+	return result
+}
@@ -0,0 +1,127 @@
+package router_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/router"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/go-playground/assert/v2"
+	"github.com/stretchr/testify/mock"
+)
+
+func serveLDAP(t *testing.T, mockDirectoryUsecase *mocks.DirectoryUsecaseMock) *ldap.Conn {
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	t.Cleanup(func() { listener.Close() })
+	go router.NewLDAPRouterImpl(mockDirectoryUsecase).Serve(listener)
+
+	conn, err := ldap.DialURL("ldap://" + listener.Addr().String())
+	assert.Equal(t, err, nil)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestLDAPBind(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockDirectoryUsecase := new(mocks.DirectoryUsecaseMock)
+		mockDirectoryUsecase.On("Bind", "uid=username,ou=people,dc=example,dc=com", "password").Return((*helper.StandardError)(nil))
+
+		conn := serveLDAP(t, mockDirectoryUsecase)
+
+		assert.Equal(t, conn.Bind("uid=username,ou=people,dc=example,dc=com", "password"), nil)
+	})
+
+	t.Run("Invalid credentials", func(t *testing.T) {
+		mockDirectoryUsecase := new(mocks.DirectoryUsecaseMock)
+		mockDirectoryUsecase.On("Bind", mock.Anything, mock.Anything).Return(&helper.StandardError{Error: errors.New("invalid credentials"), ErrorCode: http.StatusUnauthorized})
+
+		conn := serveLDAP(t, mockDirectoryUsecase)
+		err := conn.Bind("uid=username,ou=people,dc=example,dc=com", "wrong password")
+
+		assert.Equal(t, ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials), true)
+	})
+}
+
+func TestLDAPSearch(t *testing.T) {
+	entries := []repository.DirectoryEntry{
+		{DN: "uid=alice,ou=people,dc=example,dc=com", Attributes: map[string][]string{"uid": {"alice"}, "memberOf": {"cn=admin,ou=groups,dc=example,dc=com"}}},
+		{DN: "uid=bob,ou=people,dc=example,dc=com", Attributes: map[string][]string{"uid": {"bob"}}},
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockDirectoryUsecase := new(mocks.DirectoryUsecaseMock)
+		mockDirectoryUsecase.On("Bind", mock.Anything, mock.Anything).Return((*helper.StandardError)(nil))
+		mockDirectoryUsecase.On("Search", repository.DirectorySearch{
+			BaseDN:     "ou=people,dc=example,dc=com",
+			Scope:      ldap.ScopeWholeSubtree,
+			Filter:     "(&(objectClass=person)(uid=a*))",
+			Attributes: []string{"uid", "memberOf"},
+		}).Return(entries[:1], (*helper.StandardError)(nil))
+
+		conn := serveLDAP(t, mockDirectoryUsecase)
+		conn.Bind("uid=username,ou=people,dc=example,dc=com", "password")
+
+		result, err := conn.Search(ldap.NewSearchRequest("ou=people,dc=example,dc=com", ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false, "(&(objectClass=person)(uid=a*))", []string{"uid", "memberOf"}, nil))
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, len(result.Entries), 1)
+		assert.Equal(t, result.Entries[0].DN, "uid=alice,ou=people,dc=example,dc=com")
+		assert.Equal(t, result.Entries[0].GetAttributeValues("memberOf"), []string{"cn=admin,ou=groups,dc=example,dc=com"})
+	})
+
+	t.Run("Size limit", func(t *testing.T) {
+		mockDirectoryUsecase := new(mocks.DirectoryUsecaseMock)
+		mockDirectoryUsecase.On("Bind", mock.Anything, mock.Anything).Return((*helper.StandardError)(nil))
+		mockDirectoryUsecase.On("Search", mock.Anything).Return(entries, (*helper.StandardError)(nil))
+
+		conn := serveLDAP(t, mockDirectoryUsecase)
+		conn.Bind("uid=username,ou=people,dc=example,dc=com", "password")
+
+		result, err := conn.Search(ldap.NewSearchRequest("dc=example,dc=com", ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 1, 0, false, "(uid=*)", nil, nil))
+
+		assert.Equal(t, ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded), true)
+		assert.Equal(t, len(result.Entries), 1)
+	})
+
+	t.Run("Bind required", func(t *testing.T) {
+		mockDirectoryUsecase := new(mocks.DirectoryUsecaseMock)
+
+		conn := serveLDAP(t, mockDirectoryUsecase)
+		_, err := conn.Search(ldap.NewSearchRequest("dc=example,dc=com", ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false, "(uid=*)", nil, nil))
+
+		assert.Equal(t, ldap.IsErrorWithCode(err, ldap.LDAPResultInsufficientAccessRights), true)
+		mockDirectoryUsecase.AssertNotCalled(t, "Search", mock.Anything)
+	})
+
+	t.Run("No such object", func(t *testing.T) {
+		mockDirectoryUsecase := new(mocks.DirectoryUsecaseMock)
+		mockDirectoryUsecase.On("Bind", mock.Anything, mock.Anything).Return((*helper.StandardError)(nil))
+		mockDirectoryUsecase.On("Search", mock.Anything).Return([]repository.DirectoryEntry(nil), &helper.StandardError{Error: errors.New("no such object"), ErrorCode: http.StatusNotFound})
+
+		conn := serveLDAP(t, mockDirectoryUsecase)
+		conn.Bind("uid=username,ou=people,dc=example,dc=com", "password")
+
+		_, err := conn.Search(ldap.NewSearchRequest("ou=devices,dc=example,dc=com", ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false, "(objectClass=*)", nil, nil))
+
+		assert.Equal(t, ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject), true)
+	})
+}
+
+func TestLDAPReadOnly(t *testing.T) {
+	mockDirectoryUsecase := new(mocks.DirectoryUsecaseMock)
+	mockDirectoryUsecase.On("Bind", mock.Anything, mock.Anything).Return((*helper.StandardError)(nil))
+
+	conn := serveLDAP(t, mockDirectoryUsecase)
+	conn.Bind("uid=username,ou=people,dc=example,dc=com", "password")
+
+	modify := ldap.NewModifyRequest("uid=username,ou=people,dc=example,dc=com", nil)
+	modify.Replace("mail", []string{"new@example.com"})
+
+	assert.Equal(t, ldap.IsErrorWithCode(conn.Modify(modify), ldap.LDAPResultUnwillingToPerform), true)
+	assert.Equal(t, ldap.IsErrorWithCode(conn.Del(ldap.NewDelRequest("uid=username,ou=people,dc=example,dc=com", nil)), ldap.LDAPResultUnwillingToPerform), true)
+}
@@ -0,0 +1,75 @@
+package router
+
+import (
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	magicLinkCookie     = "magic_link_nonce"
+	magicLinkCookiePath = "/api/v1/login/magic"
+)
+
+type MagicLinkRouter interface {
+	RequestLink(c *gin.Context)
+	ConsumeLink(c *gin.Context)
+}
+
+type MagicLinkRouterImpl struct {
+	magicLinkUsecase usecase.MagicLinkUsecase
+}
+
+func NewMagicLinkRouterImpl(magicLinkUsecase usecase.MagicLinkUsecase) MagicLinkRouter {
+	return &MagicLinkRouterImpl{
+		magicLinkUsecase: magicLinkUsecase,
+	}
+}
+
+func isSecureRequest(c *gin.Context) bool {
+	return c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+}
+
+func (t *MagicLinkRouterImpl) RequestLink(c *gin.Context) {
+	var requestData repository.MagicLinkRequest
+
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	nonce, err := t.magicLinkUsecase.RequestLink(requestData, getCurrentOrganizationId(c))
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(magicLinkCookie, nonce, int(usecase.MagicLinkExpiry.Seconds()), magicLinkCookiePath, "", isSecureRequest(c), true)
+	c.JSON(http.StatusOK, gin.H{"message": "if the email belongs to an account, a login link has been sent"})
+}
+
+func (t *MagicLinkRouterImpl) ConsumeLink(c *gin.Context) {
+	var consumeData repository.MagicLinkConsume
+
+	if err := c.ShouldBindQuery(&consumeData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	nonce, _ := c.Cookie(magicLinkCookie)
+	token, err := t.magicLinkUsecase.ConsumeLink(consumeData.Token, nonce, requestLoginContext(c))
+
+	c.Header("Cache-Control", "no-store")
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(magicLinkCookie, "", -1, magicLinkCookiePath, "", isSecureRequest(c), true)
+	c.JSON(http.StatusOK, gin.H{"token": token, "message": "successfully login"})
+}
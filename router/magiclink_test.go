@@ -0,0 +1,95 @@
+package router_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/router"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/stretchr/testify/mock"
+)
+
+func magicLinkEngine(magicLinkRouter router.MagicLinkRouter) *gin.Engine {
+	engine := gin.Default()
+	engine.POST("/api/v1/login/magic", magicLinkRouter.RequestLink)
+	engine.GET("/api/v1/login/magic/consume", magicLinkRouter.ConsumeLink)
+	return engine
+}
+
+func TestMagicLinkRequestLink(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockMagicLinkUsecase := new(mocks.MagicLinkUsecaseMock)
+		mockMagicLinkUsecase.On("RequestLink", repository.MagicLinkRequest{Email: "test@mail.com"}).Return("nonce", (*helper.StandardError)(nil))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/v1/login/magic", strings.NewReader(`{"email":"test@mail.com"}`))
+		magicLinkEngine(router.NewMagicLinkRouterImpl(mockMagicLinkUsecase)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		cookie := w.Result().Cookies()[0]
+		assert.Equal(t, cookie.Name, "magic_link_nonce")
+		assert.Equal(t, cookie.Value, "nonce")
+		assert.Equal(t, cookie.Path, "/api/v1/login/magic")
+		assert.Equal(t, cookie.HttpOnly, true)
+		assert.Equal(t, cookie.SameSite, http.SameSiteLaxMode)
+	})
+
+	t.Run("Bind JSON Error", func(t *testing.T) {
+		mockMagicLinkUsecase := new(mocks.MagicLinkUsecaseMock)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/v1/login/magic", strings.NewReader(`{}`))
+		magicLinkEngine(router.NewMagicLinkRouterImpl(mockMagicLinkUsecase)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockMagicLinkUsecase.AssertNotCalled(t, "RequestLink", mock.Anything)
+	})
+}
+
+func TestMagicLinkConsumeLink(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockMagicLinkUsecase := new(mocks.MagicLinkUsecaseMock)
+		mockMagicLinkUsecase.On("ConsumeLink", "gum_ml_token", "nonce").Return("jwt-token", (*helper.StandardError)(nil))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/login/magic/consume?token=gum_ml_token", nil)
+		req.AddCookie(&http.Cookie{Name: "magic_link_nonce", Value: "nonce"})
+		magicLinkEngine(router.NewMagicLinkRouterImpl(mockMagicLinkUsecase)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), `"token":"jwt-token"`)
+		assert.Equal(t, w.Header().Get("Cache-Control"), "no-store")
+		assert.Equal(t, w.Result().Cookies()[0].MaxAge, -1)
+	})
+
+	t.Run("Without cookie", func(t *testing.T) {
+		mockMagicLinkUsecase := new(mocks.MagicLinkUsecaseMock)
+		mockMagicLinkUsecase.On("ConsumeLink", "gum_ml_token", "").Return("", &helper.StandardError{Error: errors.New("open the login link in the browser that requested it"), ErrorCode: http.StatusBadRequest})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/login/magic/consume?token=gum_ml_token", nil)
+		magicLinkEngine(router.NewMagicLinkRouterImpl(mockMagicLinkUsecase)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "browser that requested it")
+	})
+
+	t.Run("Missing token", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/login/magic/consume", nil)
+		magicLinkEngine(router.NewMagicLinkRouterImpl(nil)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,399 @@
+package router
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+type OAuthRouter interface {
+	Token(c *gin.Context)
+	CreateClient(c *gin.Context)
+	ListClients(c *gin.Context)
+	RotateClientSecret(c *gin.Context)
+	DisableClient(c *gin.Context)
+	Authorize(c *gin.Context)
+	AuthorizeDecision(c *gin.Context)
+	ListConsents(c *gin.Context)
+	RevokeConsent(c *gin.Context)
+	Introspect(c *gin.Context)
+	Revoke(c *gin.Context)
+	DeviceAuthorization(c *gin.Context)
+	DeviceVerification(c *gin.Context)
+	DeviceDecision(c *gin.Context)
+}
+
+type OAuthRouterImpl struct {
+	oauthUsecase usecase.OAuthUsecase
+}
+
+func NewOAuthRouterImpl(oauthUsecase usecase.OAuthUsecase) OAuthRouter {
+	return &OAuthRouterImpl{
+		oauthUsecase: oauthUsecase,
+	}
+}
+
+func (t *OAuthRouterImpl) Token(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	var tokenRequest repository.TokenRequest
+
+	if err := c.ShouldBindWith(&tokenRequest, binding.Form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": usecase.ErrInvalidRequest.Error()})
+		return
+	}
+
+	basicAuth, ok := bindBasicAuth(c, &tokenRequest.ClientID, &tokenRequest.ClientSecret)
+	if !ok {
+		return
+	}
+
+	token, tokenError := t.oauthUsecase.Token(tokenRequest)
+
+	if tokenError != nil && tokenError.Error != nil {
+		writeOAuthError(c, tokenError, basicAuth)
+		return
+	}
+
+	c.JSON(http.StatusOK, token)
+}
+
+func bindBasicAuth(c *gin.Context, clientId *string, clientSecret *string) (bool, bool) {
+	basicClientId, basicClientSecret, basicAuth := c.Request.BasicAuth()
+	if !basicAuth {
+		return false, true
+	}
+
+	decodedClientId, idErr := url.QueryUnescape(basicClientId)
+	decodedClientSecret, secretErr := url.QueryUnescape(basicClientSecret)
+	if idErr != nil || secretErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": usecase.ErrInvalidRequest.Error()})
+		return true, false
+	}
+
+	*clientId = decodedClientId
+	*clientSecret = decodedClientSecret
+	return true, true
+}
+
+func writeOAuthError(c *gin.Context, oauthError *helper.StandardError, basicAuth bool) {
+	if basicAuth && oauthError.ErrorCode == http.StatusUnauthorized {
+		c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+	c.JSON(int(oauthError.ErrorCode), gin.H{"error": oauthError.Error.Error()})
+}
+
+func (t *OAuthRouterImpl) Introspect(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+
+	var introspectRequest repository.TokenHintRequest
+
+	if err := c.ShouldBindWith(&introspectRequest, binding.Form); err != nil || introspectRequest.Token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": usecase.ErrInvalidRequest.Error()})
+		return
+	}
+
+	basicAuth, ok := bindBasicAuth(c, &introspectRequest.ClientID, &introspectRequest.ClientSecret)
+	if !ok {
+		return
+	}
+
+	introspection, introspectError := t.oauthUsecase.Introspect(introspectRequest)
+
+	if introspectError != nil && introspectError.Error != nil {
+		writeOAuthError(c, introspectError, basicAuth)
+		return
+	}
+
+	c.JSON(http.StatusOK, introspection)
+}
+
+func (t *OAuthRouterImpl) Revoke(c *gin.Context) {
+	var revokeRequest repository.TokenHintRequest
+
+	if err := c.ShouldBindWith(&revokeRequest, binding.Form); err != nil || revokeRequest.Token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": usecase.ErrInvalidRequest.Error()})
+		return
+	}
+
+	basicAuth, ok := bindBasicAuth(c, &revokeRequest.ClientID, &revokeRequest.ClientSecret)
+	if !ok {
+		return
+	}
+
+	revokeError := t.oauthUsecase.Revoke(revokeRequest)
+
+	if revokeError != nil && revokeError.Error != nil {
+		writeOAuthError(c, revokeError, basicAuth)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (t *OAuthRouterImpl) CreateClient(c *gin.Context) {
+	var createClientData repository.CreateClient
+
+	if err := c.ShouldBindJSON(&createClientData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	currentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	client, createClientError := t.oauthUsecase.CreateClient(getCurrentOrganizationId(c), createClientData, currentUserId)
+
+	if createClientError != nil && createClientError.Error != nil {
+		c.JSON(int(createClientError.ErrorCode), gin.H{"error": createClientError.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": client, "message": "successfully create client, the secret will not be shown again"})
+}
+
+func (t *OAuthRouterImpl) ListClients(c *gin.Context) {
+	clients, err := t.oauthUsecase.ListClients(getCurrentOrganizationId(c))
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": clients, "message": "successfully list clients"})
+}
+
+func (t *OAuthRouterImpl) RotateClientSecret(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to convert requested client ID"})
+		return
+	}
+
+	currentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	client, rotateError := t.oauthUsecase.RotateClientSecret(getCurrentOrganizationId(c), id, currentUserId)
+
+	if rotateError != nil && rotateError.Error != nil {
+		c.JSON(int(rotateError.ErrorCode), gin.H{"error": rotateError.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": client, "message": "successfully rotate client secret, the secret will not be shown again"})
+}
+
+func (t *OAuthRouterImpl) DisableClient(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to convert requested client ID"})
+		return
+	}
+
+	currentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	client, disableError := t.oauthUsecase.DisableClient(getCurrentOrganizationId(c), id, currentUserId)
+
+	if disableError != nil && disableError.Error != nil {
+		c.JSON(int(disableError.ErrorCode), gin.H{"error": disableError.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": client, "message": "successfully disable client"})
+}
+
+func authorizePageData(prompt *repository.AuthorizePrompt, authorizeRequest repository.AuthorizeRequest, errorMessage string) gin.H {
+	return gin.H{
+		"Client": prompt.Client,
+		"Scopes": prompt.Scopes,
+		"Error":  errorMessage,
+		"Action": "/oauth/authorize",
+		"Hidden": map[string]string{
+			"response_type":         authorizeRequest.ResponseType,
+			"client_id":             authorizeRequest.ClientID,
+			"redirect_uri":          authorizeRequest.RedirectURI,
+			"scope":                 authorizeRequest.Scope,
+			"state":                 authorizeRequest.State,
+			"code_challenge":        authorizeRequest.CodeChallenge,
+			"code_challenge_method": authorizeRequest.CodeChallengeMethod,
+			"nonce":                 authorizeRequest.Nonce,
+		},
+	}
+}
+
+func (t *OAuthRouterImpl) Authorize(c *gin.Context) {
+	var authorizeRequest repository.AuthorizeRequest
+
+	if err := c.ShouldBindQuery(&authorizeRequest); err != nil {
+		renderPage(c, http.StatusBadRequest, errorTemplate, err.Error())
+		return
+	}
+
+	prompt, authorizeError := t.oauthUsecase.Authorize(authorizeRequest)
+
+	if authorizeError != nil && authorizeError.Error != nil {
+		renderPage(c, int(authorizeError.ErrorCode), errorTemplate, authorizeError.Error.Error())
+		return
+	}
+
+	if prompt.RedirectTo != "" {
+		c.Redirect(http.StatusFound, prompt.RedirectTo)
+		return
+	}
+
+	renderPageWithFormTargets(c, http.StatusOK, authorizeTemplate, authorizePageData(prompt, authorizeRequest, ""), redirectSources(prompt.RedirectURI))
+}
+
+func (t *OAuthRouterImpl) AuthorizeDecision(c *gin.Context) {
+	var decision repository.AuthorizeDecision
+
+	if err := c.ShouldBindWith(&decision, binding.Form); err != nil {
+		renderPage(c, http.StatusBadRequest, errorTemplate, err.Error())
+		return
+	}
+
+	decision.LoginContext = requestLoginContext(c)
+	prompt, decisionError := t.oauthUsecase.Approve(decision)
+
+	if decisionError != nil && decisionError.Error != nil {
+		if prompt == nil {
+			renderPage(c, int(decisionError.ErrorCode), errorTemplate, decisionError.Error.Error())
+			return
+		}
+		renderPageWithFormTargets(c, int(decisionError.ErrorCode), authorizeTemplate, authorizePageData(prompt, decision.AuthorizeRequest, "invalid username or password"), redirectSources(prompt.RedirectURI))
+		return
+	}
+
+	c.Redirect(http.StatusFound, prompt.RedirectTo)
+}
+
+func (t *OAuthRouterImpl) ListConsents(c *gin.Context) {
+	currentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	consents, err := t.oauthUsecase.ListConsents(currentUserId)
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": consents, "message": "successfully list consents"})
+}
+
+func (t *OAuthRouterImpl) RevokeConsent(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to convert requested consent ID"})
+		return
+	}
+
+	currentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	consent, revokeError := t.oauthUsecase.RevokeConsent(currentUserId, id)
+
+	if revokeError != nil && revokeError.Error != nil {
+		c.JSON(int(revokeError.ErrorCode), gin.H{"error": revokeError.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": consent, "message": "successfully revoke consent"})
+}
+
+func (t *OAuthRouterImpl) DeviceAuthorization(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+
+	var deviceRequest repository.DeviceAuthorizationRequest
+
+	if err := c.ShouldBindWith(&deviceRequest, binding.Form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": usecase.ErrInvalidRequest.Error()})
+		return
+	}
+
+	basicAuth, ok := bindBasicAuth(c, &deviceRequest.ClientID, &deviceRequest.ClientSecret)
+	if !ok {
+		return
+	}
+
+	deviceAuthorization, deviceError := t.oauthUsecase.DeviceAuthorization(deviceRequest)
+
+	if deviceError != nil && deviceError.Error != nil {
+		writeOAuthError(c, deviceError, basicAuth)
+		return
+	}
+
+	c.JSON(http.StatusOK, deviceAuthorization)
+}
+
+// renderDevicePage skips the username and password fields for a browser that is already signed in; the form echoes
+// the session's CSRF token instead.
+func (t *OAuthRouterImpl) renderDevicePage(c *gin.Context, code int, data gin.H) {
+	if sessionToken, err := c.Cookie(usecase.SessionCookie); err == nil {
+		if user := t.oauthUsecase.SessionUser(sessionToken); user != nil {
+			data["SignedIn"] = user
+			data["CSRFToken"], _ = c.Cookie(usecase.CSRFCookie)
+		}
+	}
+	renderPage(c, code, deviceTemplate, data)
+}
+
+func (t *OAuthRouterImpl) DeviceVerification(c *gin.Context) {
+	userCode := c.Query("user_code")
+	if userCode == "" {
+		t.renderDevicePage(c, http.StatusOK, gin.H{})
+		return
+	}
+
+	prompt, promptError := t.oauthUsecase.DevicePrompt(userCode)
+
+	if promptError != nil && promptError.Error != nil {
+		t.renderDevicePage(c, int(promptError.ErrorCode), gin.H{"UserCode": userCode, "Error": promptError.Error.Error()})
+		return
+	}
+
+	t.renderDevicePage(c, http.StatusOK, gin.H{"UserCode": prompt.UserCode, "Prompt": prompt})
+}
+
+func (t *OAuthRouterImpl) DeviceDecision(c *gin.Context) {
+	var decision repository.DeviceDecision
+
+	if err := c.ShouldBindWith(&decision, binding.Form); err != nil {
+		renderPage(c, http.StatusBadRequest, errorTemplate, err.Error())
+		return
+	}
+
+	decision.SessionToken, _ = c.Cookie(usecase.SessionCookie)
+	decision.OrganizationID = getCurrentOrganizationId(c)
+	decision.LoginContext = requestLoginContext(c)
+	prompt, decisionError := t.oauthUsecase.ApproveDevice(decision)
+
+	if decisionError != nil && decisionError.Error != nil {
+		errorMessage := decisionError.Error.Error()
+		if prompt != nil {
+			errorMessage = "invalid username or password"
+		}
+		t.renderDevicePage(c, int(decisionError.ErrorCode), gin.H{"UserCode": decision.UserCode, "Prompt": prompt, "Error": errorMessage})
+		return
+	}
+
+	renderPage(c, http.StatusOK, deviceDoneTemplate, prompt)
+}
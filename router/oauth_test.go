@@ -0,0 +1,652 @@
+package router_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/router"
+	"andikawhy/go-user-management/usecase"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/stretchr/testify/mock"
+)
+
+var mockClient = repository.OAuthClient{
+	ID:       1,
+	ClientID: "gum_client_test",
+	Name:     "ci",
+	Scopes:   "users:read",
+}
+
+func TestToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success with basic auth", func(t *testing.T) {
+		mockOAuthUsecase := new(mocks.OAuthUsecaseMock)
+		oauthRouter := router.NewOAuthRouterImpl(mockOAuthUsecase)
+
+		mockError := &helper.StandardError{Error: nil, ErrorCode: http.StatusOK}
+		expectedRequest := repository.TokenRequest{GrantType: "client_credentials", ClientID: "gum_client_test", ClientSecret: "gum_cs_secret"}
+
+		mockOAuthUsecase.On("Token", expectedRequest).Return(&repository.TokenResponse{AccessToken: "token", TokenType: "Bearer", ExpiresIn: 3600}, mockError)
+
+		router := gin.Default()
+		router.POST("/oauth/token", oauthRouter.Token)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader("grant_type=client_credentials"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth("gum_client_test", "gum_cs_secret")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), `"access_token":"token"`)
+		assert.Equal(t, w.Header().Get("Cache-Control"), "no-store")
+	})
+
+	t.Run("Invalid client", func(t *testing.T) {
+		mockOAuthUsecase := new(mocks.OAuthUsecaseMock)
+		oauthRouter := router.NewOAuthRouterImpl(mockOAuthUsecase)
+
+		mockError := &helper.StandardError{Error: usecase.ErrInvalidClient, ErrorCode: http.StatusUnauthorized}
+
+		mockOAuthUsecase.On("Token", mock.Anything).Return(&repository.TokenResponse{}, mockError)
+
+		router := gin.Default()
+		router.POST("/oauth/token", oauthRouter.Token)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader("grant_type=client_credentials"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth("gum_client_test", "wrong")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "invalid_client")
+		assert.Equal(t, w.Header().Get("WWW-Authenticate"), `Basic realm="oauth"`)
+	})
+}
+
+func TestCreateClient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockOAuthUsecase := new(mocks.OAuthUsecaseMock)
+		oauthRouter := router.NewOAuthRouterImpl(mockOAuthUsecase)
+
+		mockError := &helper.StandardError{Error: nil, ErrorCode: http.StatusOK}
+
+		mockOAuthUsecase.On("CreateClient").Return(&repository.CreatedClientResponse{OAuthClient: mockClient, ClientSecret: "gum_cs_secret"}, mockError)
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.POST("/clients", oauthRouter.CreateClient)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/clients", strings.NewReader(`{"name": "ci", "scopes": ["users:read"]}`))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "gum_cs_secret")
+	})
+
+	t.Run("Bind JSON Error", func(t *testing.T) {
+		oauthRouter := router.NewOAuthRouterImpl(nil)
+
+		router := gin.Default()
+		router.POST("/clients", oauthRouter.CreateClient)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/clients", strings.NewReader(``))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestListClients(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockOAuthUsecase := new(mocks.OAuthUsecaseMock)
+		oauthRouter := router.NewOAuthRouterImpl(mockOAuthUsecase)
+
+		mockError := &helper.StandardError{Error: nil, ErrorCode: http.StatusOK}
+
+		mockOAuthUsecase.On("ListClients").Return(&[]repository.OAuthClient{mockClient}, mockError)
+
+		router := gin.Default()
+		router.GET("/clients", oauthRouter.ListClients)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/clients", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "successfully list clients")
+	})
+}
+
+func TestRotateClientSecret(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Error", func(t *testing.T) {
+		mockOAuthUsecase := new(mocks.OAuthUsecaseMock)
+		oauthRouter := router.NewOAuthRouterImpl(mockOAuthUsecase)
+
+		mockError := &helper.StandardError{Error: errors.New("client not found"), ErrorCode: http.StatusNotFound}
+
+		mockOAuthUsecase.On("RotateClientSecret").Return(&repository.CreatedClientResponse{}, mockError)
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.POST("/clients/:id/secret", oauthRouter.RotateClientSecret)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/clients/1/secret", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "client not found")
+	})
+}
+
+func TestDisableClient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockOAuthUsecase := new(mocks.OAuthUsecaseMock)
+		oauthRouter := router.NewOAuthRouterImpl(mockOAuthUsecase)
+
+		mockError := &helper.StandardError{Error: nil, ErrorCode: http.StatusOK}
+
+		mockOAuthUsecase.On("DisableClient").Return(&mockClient, mockError)
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.DELETE("/clients/:id", oauthRouter.DisableClient)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/clients/1", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "successfully disable client")
+	})
+
+	t.Run("Requested Client ID Conversion Fail", func(t *testing.T) {
+		oauthRouter := router.NewOAuthRouterImpl(nil)
+
+		router := gin.Default()
+		router.DELETE("/clients/:id", oauthRouter.DisableClient)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/clients/abc", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestAuthorize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Renders consent page", func(t *testing.T) {
+		mockOAuthUsecase := new(mocks.OAuthUsecaseMock)
+		oauthRouter := router.NewOAuthRouterImpl(mockOAuthUsecase)
+
+		mockError := &helper.StandardError{Error: nil, ErrorCode: http.StatusOK}
+
+		mockOAuthUsecase.On("Authorize").Return(&repository.AuthorizePrompt{Client: mockClient, Scopes: []string{"users:read"}, RedirectURI: "https://app.example.com/callback"}, mockError)
+
+		router := gin.Default()
+		router.GET("/oauth/authorize", oauthRouter.Authorize)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/oauth/authorize?response_type=code&client_id=gum_client_test&state=xyz", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "users:read")
+		assert.MatchRegex(t, w.Body.String(), `name="state" value="xyz"`)
+		assert.Equal(t, w.Header().Get("X-Frame-Options"), "DENY")
+		assert.Equal(t, w.Header().Get("Content-Security-Policy"), "default-src 'none'; form-action 'self' https://app.example.com; frame-ancestors 'none'")
+	})
+
+	t.Run("Consent page of a native app", func(t *testing.T) {
+		mockOAuthUsecase := new(mocks.OAuthUsecaseMock)
+		oauthRouter := router.NewOAuthRouterImpl(mockOAuthUsecase)
+
+		mockError := &helper.StandardError{Error: nil, ErrorCode: http.StatusOK}
+
+		mockOAuthUsecase.On("Authorize").Return(&repository.AuthorizePrompt{Client: mockClient, Scopes: []string{"users:read"}, RedirectURI: "com.example.app:/callback"}, mockError)
+
+		router := gin.Default()
+		router.GET("/oauth/authorize", oauthRouter.Authorize)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/oauth/authorize?response_type=code&client_id=gum_client_test", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, w.Header().Get("Content-Security-Policy"), "default-src 'none'; form-action 'self' com.example.app:; frame-ancestors 'none'")
+	})
+
+	t.Run("Redirects with error", func(t *testing.T) {
+		mockOAuthUsecase := new(mocks.OAuthUsecaseMock)
+		oauthRouter := router.NewOAuthRouterImpl(mockOAuthUsecase)
+
+		mockError := &helper.StandardError{Error: nil, ErrorCode: http.StatusOK}
+
+		mockOAuthUsecase.On("Authorize").Return(&repository.AuthorizePrompt{RedirectTo: "https://app.example.com/callback?error=invalid_request"}, mockError)
+
+		router := gin.Default()
+		router.GET("/oauth/authorize", oauthRouter.Authorize)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/oauth/authorize?client_id=gum_client_test", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusFound, w.Code)
+		assert.Equal(t, w.Header().Get("Location"), "https://app.example.com/callback?error=invalid_request")
+	})
+
+	t.Run("Invalid redirect uri is not redirected", func(t *testing.T) {
+		mockOAuthUsecase := new(mocks.OAuthUsecaseMock)
+		oauthRouter := router.NewOAuthRouterImpl(mockOAuthUsecase)
+
+		mockError := &helper.StandardError{Error: usecase.ErrInvalidRedirectURI, ErrorCode: http.StatusBadRequest}
+
+		mockOAuthUsecase.On("Authorize").Return((*repository.AuthorizePrompt)(nil), mockError)
+
+		router := gin.Default()
+		router.GET("/oauth/authorize", oauthRouter.Authorize)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/oauth/authorize?client_id=gum_client_test", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "invalid redirect_uri")
+	})
+}
+
+func TestAuthorizeDecision(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Redirects with code", func(t *testing.T) {
+		mockOAuthUsecase := new(mocks.OAuthUsecaseMock)
+		oauthRouter := router.NewOAuthRouterImpl(mockOAuthUsecase)
+
+		mockError := &helper.StandardError{Error: nil, ErrorCode: http.StatusOK}
+
+		mockOAuthUsecase.On("Approve").Return(&repository.AuthorizePrompt{RedirectTo: "https://app.example.com/callback?code=gum_ac_code"}, mockError)
+
+		router := gin.Default()
+		router.POST("/oauth/authorize", oauthRouter.AuthorizeDecision)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/oauth/authorize", strings.NewReader("client_id=gum_client_test&username=test&password=password&approve=true"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusFound, w.Code)
+		assert.Equal(t, w.Header().Get("Location"), "https://app.example.com/callback?code=gum_ac_code")
+	})
+
+	t.Run("Approved with the browser session", func(t *testing.T) {
+		mockOAuthUsecase := new(mocks.OAuthUsecaseMock)
+		oauthRouter := router.NewOAuthRouterImpl(mockOAuthUsecase)
+
+		mockOAuthUsecase.On("ApproveDevice", repository.DeviceDecision{UserCode: "BCDF-GHJK", Approve: true, CSRFToken: "csrf", SessionToken: "session", OrganizationID: 1, LoginContext: repository.LoginContext{OrganizationID: 1}}).Return(&repository.DevicePrompt{Client: mockClient, Approved: true}, (*helper.StandardError)(nil))
+
+		router := gin.Default()
+		router.POST("/oauth/device", oauthRouter.DeviceDecision)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/oauth/device", strings.NewReader("user_code=BCDF-GHJK&csrf_token=csrf&approve=true"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(&http.Cookie{Name: usecase.SessionCookie, Value: "session"})
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "Device connected")
+	})
+
+	t.Run("Wrong password re-renders the page", func(t *testing.T) {
+		mockOAuthUsecase := new(mocks.OAuthUsecaseMock)
+		oauthRouter := router.NewOAuthRouterImpl(mockOAuthUsecase)
+
+		mockError := &helper.StandardError{Error: errors.New("wrong password"), ErrorCode: http.StatusUnauthorized}
+
+		mockOAuthUsecase.On("Approve").Return(&repository.AuthorizePrompt{Client: mockClient, Scopes: []string{"users:read"}}, mockError)
+
+		router := gin.Default()
+		router.POST("/oauth/authorize", oauthRouter.AuthorizeDecision)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/oauth/authorize", strings.NewReader("client_id=gum_client_test&username=test&password=wrong&approve=true"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "invalid username or password")
+	})
+}
+
+func TestListConsents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockOAuthUsecase := new(mocks.OAuthUsecaseMock)
+		oauthRouter := router.NewOAuthRouterImpl(mockOAuthUsecase)
+
+		mockError := &helper.StandardError{Error: nil, ErrorCode: http.StatusOK}
+
+		mockOAuthUsecase.On("ListConsents").Return(&[]repository.Consent{{ID: 1, UserID: 1, ClientID: "gum_client_test", Scope: "users:read"}}, mockError)
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.GET("/me/consents", oauthRouter.ListConsents)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/me/consents", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "successfully list consents")
+	})
+}
+
+func TestRevokeConsent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Not found", func(t *testing.T) {
+		mockOAuthUsecase := new(mocks.OAuthUsecaseMock)
+		oauthRouter := router.NewOAuthRouterImpl(mockOAuthUsecase)
+
+		mockError := &helper.StandardError{Error: errors.New("consent not found"), ErrorCode: http.StatusNotFound}
+
+		mockOAuthUsecase.On("RevokeConsent").Return(&repository.Consent{}, mockError)
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.DELETE("/me/consents/:id", oauthRouter.RevokeConsent)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/me/consents/1", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "consent not found")
+	})
+}
+
+func TestIntrospect(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success with basic auth", func(t *testing.T) {
+		mockOAuthUsecase := new(mocks.OAuthUsecaseMock)
+		oauthRouter := router.NewOAuthRouterImpl(mockOAuthUsecase)
+
+		mockError := &helper.StandardError{Error: nil, ErrorCode: http.StatusOK}
+		expectedRequest := repository.TokenHintRequest{Token: "access", ClientID: "gum_client_test", ClientSecret: "gum_cs_secret"}
+
+		mockOAuthUsecase.On("Introspect", expectedRequest).Return(&repository.IntrospectionResponse{Active: true, Subject: "100"}, mockError)
+
+		router := gin.Default()
+		router.POST("/oauth/introspect", oauthRouter.Introspect)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/oauth/introspect", strings.NewReader("token=access"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth("gum_client_test", "gum_cs_secret")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, w.Body.String(), `{"active":true,"sub":"100"}`)
+	})
+
+	t.Run("Missing token", func(t *testing.T) {
+		mockOAuthUsecase := new(mocks.OAuthUsecaseMock)
+		oauthRouter := router.NewOAuthRouterImpl(mockOAuthUsecase)
+
+		router := gin.Default()
+		router.POST("/oauth/introspect", oauthRouter.Introspect)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/oauth/introspect", strings.NewReader(""))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "invalid_request")
+	})
+}
+
+func TestRevoke(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockOAuthUsecase := new(mocks.OAuthUsecaseMock)
+		oauthRouter := router.NewOAuthRouterImpl(mockOAuthUsecase)
+
+		mockOAuthUsecase.On("Revoke", mock.Anything).Return((*helper.StandardError)(nil))
+
+		router := gin.Default()
+		router.POST("/oauth/revoke", oauthRouter.Revoke)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/oauth/revoke", strings.NewReader("token=gum_rt_token&client_id=gum_client_app"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Invalid client", func(t *testing.T) {
+		mockOAuthUsecase := new(mocks.OAuthUsecaseMock)
+		oauthRouter := router.NewOAuthRouterImpl(mockOAuthUsecase)
+
+		mockOAuthUsecase.On("Revoke", mock.Anything).Return(&helper.StandardError{Error: usecase.ErrInvalidClient, ErrorCode: http.StatusUnauthorized})
+
+		router := gin.Default()
+		router.POST("/oauth/revoke", oauthRouter.Revoke)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/oauth/revoke", strings.NewReader("token=access"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth("gum_client_test", "wrong")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, w.Header().Get("WWW-Authenticate"), `Basic realm="oauth"`)
+	})
+}
+
+func TestDeviceAuthorization(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockOAuthUsecase := new(mocks.OAuthUsecaseMock)
+		oauthRouter := router.NewOAuthRouterImpl(mockOAuthUsecase)
+
+		mockOAuthUsecase.On("DeviceAuthorization", repository.DeviceAuthorizationRequest{ClientID: "gum_client_cli", Scope: "users:read"}).Return(&repository.DeviceAuthorizationResponse{
+			DeviceCode:      "gum_dc_code",
+			UserCode:        "BCDF-GHJK",
+			VerificationURI: "http://localhost:3000/oauth/device",
+			ExpiresIn:       600,
+			Interval:        5,
+		}, (*helper.StandardError)(nil))
+
+		router := gin.Default()
+		router.POST("/oauth/device/code", oauthRouter.DeviceAuthorization)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/oauth/device/code", strings.NewReader("client_id=gum_client_cli&scope=users:read"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), `"user_code":"BCDF-GHJK"`)
+		assert.MatchRegex(t, w.Body.String(), `"interval":5`)
+		assert.Equal(t, w.Header().Get("Cache-Control"), "no-store")
+	})
+
+	t.Run("Unauthorized client", func(t *testing.T) {
+		mockOAuthUsecase := new(mocks.OAuthUsecaseMock)
+		oauthRouter := router.NewOAuthRouterImpl(mockOAuthUsecase)
+
+		mockOAuthUsecase.On("DeviceAuthorization", mock.Anything).Return((*repository.DeviceAuthorizationResponse)(nil), &helper.StandardError{Error: usecase.ErrUnauthorizedClient, ErrorCode: http.StatusBadRequest})
+
+		router := gin.Default()
+		router.POST("/oauth/device/code", oauthRouter.DeviceAuthorization)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/oauth/device/code", strings.NewReader("client_id=gum_client_app"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "unauthorized_client")
+	})
+}
+
+func TestDeviceVerification(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Asks for the code", func(t *testing.T) {
+		oauthRouter := router.NewOAuthRouterImpl(new(mocks.OAuthUsecaseMock))
+
+		router := gin.Default()
+		router.GET("/oauth/device", oauthRouter.DeviceVerification)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/oauth/device", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "Enter the code shown on your device")
+	})
+
+	t.Run("Shows the requesting client", func(t *testing.T) {
+		mockOAuthUsecase := new(mocks.OAuthUsecaseMock)
+		oauthRouter := router.NewOAuthRouterImpl(mockOAuthUsecase)
+
+		mockOAuthUsecase.On("DevicePrompt", "BCDF-GHJK").Return(&repository.DevicePrompt{Client: mockClient, Scopes: []string{"users:read"}, UserCode: "BCDF-GHJK"}, (*helper.StandardError)(nil))
+
+		router := gin.Default()
+		router.GET("/oauth/device", oauthRouter.DeviceVerification)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/oauth/device?user_code=BCDF-GHJK", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "ci is requesting access to")
+		assert.MatchRegex(t, w.Body.String(), `value="BCDF-GHJK"`)
+	})
+
+	t.Run("Signed in browser skips the password", func(t *testing.T) {
+		mockOAuthUsecase := new(mocks.OAuthUsecaseMock)
+		oauthRouter := router.NewOAuthRouterImpl(mockOAuthUsecase)
+
+		mockOAuthUsecase.On("DevicePrompt", "BCDF-GHJK").Return(&repository.DevicePrompt{Client: mockClient, Scopes: []string{"users:read"}, UserCode: "BCDF-GHJK"}, (*helper.StandardError)(nil))
+		mockOAuthUsecase.On("SessionUser", "session").Return(&repository.User{ID: 100, Username: "test"})
+
+		router := gin.Default()
+		router.GET("/oauth/device", oauthRouter.DeviceVerification)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/oauth/device?user_code=BCDF-GHJK", nil)
+		req.AddCookie(&http.Cookie{Name: usecase.SessionCookie, Value: "session"})
+		req.AddCookie(&http.Cookie{Name: usecase.CSRFCookie, Value: "csrf"})
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "Signed in as test")
+		assert.MatchRegex(t, w.Body.String(), `name="csrf_token" value="csrf"`)
+		assert.Equal(t, strings.Contains(w.Body.String(), `name="password"`), false)
+	})
+
+	t.Run("Unknown code", func(t *testing.T) {
+		mockOAuthUsecase := new(mocks.OAuthUsecaseMock)
+		oauthRouter := router.NewOAuthRouterImpl(mockOAuthUsecase)
+
+		mockOAuthUsecase.On("DevicePrompt", "ZZZZ-ZZZZ").Return((*repository.DevicePrompt)(nil), &helper.StandardError{Error: errors.New("invalid or expired code"), ErrorCode: http.StatusBadRequest})
+
+		router := gin.Default()
+		router.GET("/oauth/device", oauthRouter.DeviceVerification)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/oauth/device?user_code=ZZZZ-ZZZZ", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "invalid or expired code")
+	})
+}
+
+func TestDeviceDecision(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Approved", func(t *testing.T) {
+		mockOAuthUsecase := new(mocks.OAuthUsecaseMock)
+		oauthRouter := router.NewOAuthRouterImpl(mockOAuthUsecase)
+
+		mockOAuthUsecase.On("ApproveDevice", repository.DeviceDecision{UserCode: "BCDF-GHJK", Username: "test", Password: "password", Approve: true, OrganizationID: 1, LoginContext: repository.LoginContext{OrganizationID: 1}}).Return(&repository.DevicePrompt{Client: mockClient, Approved: true}, (*helper.StandardError)(nil))
+
+		router := gin.Default()
+		router.POST("/oauth/device", oauthRouter.DeviceDecision)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/oauth/device", strings.NewReader("user_code=BCDF-GHJK&username=test&password=password&approve=true"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "Device connected")
+	})
+
+	t.Run("Approved with the browser session", func(t *testing.T) {
+		mockOAuthUsecase := new(mocks.OAuthUsecaseMock)
+		oauthRouter := router.NewOAuthRouterImpl(mockOAuthUsecase)
+
+		mockOAuthUsecase.On("ApproveDevice", repository.DeviceDecision{UserCode: "BCDF-GHJK", Approve: true, CSRFToken: "csrf", SessionToken: "session", OrganizationID: 1, LoginContext: repository.LoginContext{OrganizationID: 1}}).Return(&repository.DevicePrompt{Client: mockClient, Approved: true}, (*helper.StandardError)(nil))
+
+		router := gin.Default()
+		router.POST("/oauth/device", oauthRouter.DeviceDecision)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/oauth/device", strings.NewReader("user_code=BCDF-GHJK&csrf_token=csrf&approve=true"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(&http.Cookie{Name: usecase.SessionCookie, Value: "session"})
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "Device connected")
+	})
+
+	t.Run("Wrong password re-renders the page", func(t *testing.T) {
+		mockOAuthUsecase := new(mocks.OAuthUsecaseMock)
+		oauthRouter := router.NewOAuthRouterImpl(mockOAuthUsecase)
+
+		mockOAuthUsecase.On("ApproveDevice", mock.Anything).Return(&repository.DevicePrompt{Client: mockClient, UserCode: "BCDF-GHJK"}, &helper.StandardError{Error: errors.New("wrong password"), ErrorCode: http.StatusUnauthorized})
+
+		router := gin.Default()
+		router.POST("/oauth/device", oauthRouter.DeviceDecision)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/oauth/device", strings.NewReader("user_code=BCDF-GHJK&username=test&password=wrong&approve=true"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "invalid username or password")
+	})
+}
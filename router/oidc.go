@@ -0,0 +1,86 @@
+package router
+
+import (
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OIDCRouter interface {
+	Discovery(c *gin.Context)
+	JWKS(c *gin.Context)
+	UserInfo(c *gin.Context)
+	Logout(c *gin.Context)
+}
+
+type OIDCRouterImpl struct {
+	oidcUsecase usecase.OIDCUsecase
+}
+
+func NewOIDCRouterImpl(oidcUsecase usecase.OIDCUsecase) OIDCRouter {
+	return &OIDCRouterImpl{
+		oidcUsecase: oidcUsecase,
+	}
+}
+
+func (t *OIDCRouterImpl) Discovery(c *gin.Context) {
+	c.JSON(http.StatusOK, t.oidcUsecase.Discovery())
+}
+
+func (t *OIDCRouterImpl) JWKS(c *gin.Context) {
+	keySet, err := t.oidcUsecase.JWKS()
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, keySet)
+}
+
+func (t *OIDCRouterImpl) UserInfo(c *gin.Context) {
+	currentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	var scopes []string
+	if currentScopes, restricted := c.Get("currentScopes"); restricted {
+		scopes, _ = currentScopes.([]string)
+	}
+
+	userInfo, err := t.oidcUsecase.UserInfo(currentUserId, scopes)
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, userInfo)
+}
+
+func (t *OIDCRouterImpl) Logout(c *gin.Context) {
+	var logoutRequest repository.LogoutRequest
+
+	if err := c.ShouldBind(&logoutRequest); err != nil {
+		renderPage(c, http.StatusBadRequest, errorTemplate, err.Error())
+		return
+	}
+
+	result, logoutError := t.oidcUsecase.Logout(logoutRequest)
+
+	if logoutError != nil && logoutError.Error != nil {
+		renderPage(c, int(logoutError.ErrorCode), errorTemplate, logoutError.Error.Error())
+		return
+	}
+
+	if result.RedirectTo != "" {
+		c.Redirect(http.StatusFound, result.RedirectTo)
+		return
+	}
+
+	renderPage(c, http.StatusOK, logoutTemplate, nil)
+}
@@ -0,0 +1,169 @@
+package router_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/router"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDiscovery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockOIDCUsecase := new(mocks.OIDCUsecaseMock)
+		oidcRouter := router.NewOIDCRouterImpl(mockOIDCUsecase)
+
+		mockOIDCUsecase.On("Discovery").Return(&repository.DiscoveryDocument{Issuer: "http://localhost:3000"})
+
+		router := gin.Default()
+		router.GET("/.well-known/openid-configuration", oidcRouter.Discovery)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/.well-known/openid-configuration", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), `"issuer":"http://localhost:3000"`)
+	})
+}
+
+func TestJWKS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockOIDCUsecase := new(mocks.OIDCUsecaseMock)
+		oidcRouter := router.NewOIDCRouterImpl(mockOIDCUsecase)
+
+		mockError := &helper.StandardError{Error: nil, ErrorCode: http.StatusOK}
+
+		mockOIDCUsecase.On("JWKS").Return(&repository.JSONWebKeySet{Keys: []repository.JSONWebKey{{KeyType: "RSA", KeyID: "kid"}}}, mockError)
+
+		router := gin.Default()
+		router.GET("/.well-known/jwks.json", oidcRouter.JWKS)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), `"kid":"kid"`)
+	})
+}
+
+func TestUserInfo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Passes granted scopes", func(t *testing.T) {
+		mockOIDCUsecase := new(mocks.OIDCUsecaseMock)
+		oidcRouter := router.NewOIDCRouterImpl(mockOIDCUsecase)
+
+		mockError := &helper.StandardError{Error: nil, ErrorCode: http.StatusOK}
+
+		mockOIDCUsecase.On("UserInfo", []string{"openid", "profile"}).Return(&repository.UserInfo{Subject: "100", PreferredUsername: "test"}, mockError)
+
+		router := gin.Default()
+		router.Use(withCurrentUser, func(c *gin.Context) {
+			c.Set("currentScopes", []string{"openid", "profile"})
+			c.Next()
+		})
+		router.GET("/userinfo", oidcRouter.UserInfo)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/userinfo", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, w.Body.String(), `{"sub":"100","preferred_username":"test"}`)
+	})
+
+	t.Run("User not found", func(t *testing.T) {
+		mockOIDCUsecase := new(mocks.OIDCUsecaseMock)
+		oidcRouter := router.NewOIDCRouterImpl(mockOIDCUsecase)
+
+		mockError := &helper.StandardError{Error: errors.New("user not found"), ErrorCode: http.StatusNotFound}
+
+		mockOIDCUsecase.On("UserInfo", mock.Anything).Return(&repository.UserInfo{}, mockError)
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.GET("/userinfo", oidcRouter.UserInfo)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/userinfo", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "user not found")
+	})
+}
+
+func TestLogout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Redirects to post logout uri", func(t *testing.T) {
+		mockOIDCUsecase := new(mocks.OIDCUsecaseMock)
+		oidcRouter := router.NewOIDCRouterImpl(mockOIDCUsecase)
+
+		mockError := &helper.StandardError{Error: nil, ErrorCode: http.StatusOK}
+		expectedRequest := repository.LogoutRequest{IDTokenHint: "hint", PostLogoutRedirectURI: "https://spa.example.com/", State: "abc"}
+
+		mockOIDCUsecase.On("Logout", expectedRequest).Return(&repository.LogoutResult{RedirectTo: "https://spa.example.com/?state=abc"}, mockError)
+
+		router := gin.Default()
+		router.GET("/oauth/logout", oidcRouter.Logout)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/oauth/logout?id_token_hint=hint&post_logout_redirect_uri=https%3A%2F%2Fspa.example.com%2F&state=abc", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusFound, w.Code)
+		assert.Equal(t, w.Header().Get("Location"), "https://spa.example.com/?state=abc")
+	})
+
+	t.Run("Renders signed out page", func(t *testing.T) {
+		mockOIDCUsecase := new(mocks.OIDCUsecaseMock)
+		oidcRouter := router.NewOIDCRouterImpl(mockOIDCUsecase)
+
+		mockError := &helper.StandardError{Error: nil, ErrorCode: http.StatusOK}
+
+		mockOIDCUsecase.On("Logout", mock.Anything).Return(&repository.LogoutResult{}, mockError)
+
+		router := gin.Default()
+		router.GET("/oauth/logout", oidcRouter.Logout)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/oauth/logout", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "You have been signed out")
+	})
+
+	t.Run("Invalid post logout redirect uri", func(t *testing.T) {
+		mockOIDCUsecase := new(mocks.OIDCUsecaseMock)
+		oidcRouter := router.NewOIDCRouterImpl(mockOIDCUsecase)
+
+		mockError := &helper.StandardError{Error: errors.New("invalid post_logout_redirect_uri"), ErrorCode: http.StatusBadRequest}
+
+		mockOIDCUsecase.On("Logout", mock.Anything).Return((*repository.LogoutResult)(nil), mockError)
+
+		router := gin.Default()
+		router.GET("/oauth/logout", oidcRouter.Logout)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/oauth/logout?post_logout_redirect_uri=https%3A%2F%2Fevil.example.com%2F", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "invalid post_logout_redirect_uri")
+	})
+}
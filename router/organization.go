@@ -0,0 +1,184 @@
+package router
+
+import (
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OrganizationRouter interface {
+	CreateOrganization(c *gin.Context)
+	ListOrganizations(c *gin.Context)
+	ListMembers(c *gin.Context)
+	SetMember(c *gin.Context)
+	RemoveMember(c *gin.Context)
+	SwitchOrganization(c *gin.Context)
+}
+
+type OrganizationRouterImpl struct {
+	organizationUsecase usecase.OrganizationUsecase
+}
+
+func NewOrganizationRouterImpl(organizationUsecase usecase.OrganizationUsecase) OrganizationRouter {
+	return &OrganizationRouterImpl{
+		organizationUsecase: organizationUsecase,
+	}
+}
+
+func getCurrentOrganizationId(c *gin.Context) uint64 {
+	organizationId, _ := c.Get("currentOrganizationId")
+	currentOrganizationId, ok := organizationId.(uint64)
+	if !ok || currentOrganizationId == 0 {
+		return repository.DefaultOrganizationID
+	}
+	return currentOrganizationId
+}
+
+func (t *OrganizationRouterImpl) CreateOrganization(c *gin.Context) {
+	var createData repository.CreateOrganization
+
+	if err := c.ShouldBindJSON(&createData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	currentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	organization, err := t.organizationUsecase.CreateOrganization(currentUserId, createData)
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": organization, "message": "successfully create organization"})
+}
+
+func (t *OrganizationRouterImpl) ListOrganizations(c *gin.Context) {
+	currentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	organizations, err := t.organizationUsecase.ListOrganizations(currentUserId)
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": organizations, "message": "successfully list organizations"})
+}
+
+func (t *OrganizationRouterImpl) ListMembers(c *gin.Context) {
+	organizationId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to convert requested organization ID"})
+		return
+	}
+
+	currentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	members, listError := t.organizationUsecase.ListMembers(organizationId, currentUserId)
+
+	if listError != nil && listError.Error != nil {
+		c.JSON(int(listError.ErrorCode), gin.H{"error": listError.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": members, "message": "successfully list members"})
+}
+
+func (t *OrganizationRouterImpl) SetMember(c *gin.Context) {
+	organizationId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to convert requested organization ID"})
+		return
+	}
+
+	userId, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to convert requested user ID"})
+		return
+	}
+
+	var membershipData repository.SetMembership
+
+	if err := c.ShouldBindJSON(&membershipData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	currentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	membership, setError := t.organizationUsecase.SetMember(organizationId, userId, membershipData, currentUserId)
+
+	if setError != nil && setError.Error != nil {
+		c.JSON(int(setError.ErrorCode), gin.H{"error": setError.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": membership, "message": "successfully set member"})
+}
+
+func (t *OrganizationRouterImpl) RemoveMember(c *gin.Context) {
+	organizationId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to convert requested organization ID"})
+		return
+	}
+
+	userId, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to convert requested user ID"})
+		return
+	}
+
+	currentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	removeError := t.organizationUsecase.RemoveMember(organizationId, userId, currentUserId)
+
+	if removeError != nil && removeError.Error != nil {
+		c.JSON(int(removeError.ErrorCode), gin.H{"error": removeError.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "successfully remove member"})
+}
+
+func (t *OrganizationRouterImpl) SwitchOrganization(c *gin.Context) {
+	organizationId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to convert requested organization ID"})
+		return
+	}
+
+	currentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	token, switchError := t.organizationUsecase.SwitchOrganization(organizationId, currentUserId, requestLoginContext(c))
+
+	if switchError != nil && switchError.Error != nil {
+		c.JSON(int(switchError.ErrorCode), gin.H{"error": switchError.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": token, "message": "successfully switch organization"})
+}
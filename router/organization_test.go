@@ -0,0 +1,182 @@
+package router_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/router"
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestCreateOrganization(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockOrganizationUsecase := new(mocks.OrganizationUsecaseMock)
+		organizationRouter := router.NewOrganizationRouterImpl(mockOrganizationUsecase)
+
+		mockOrganizationUsecase.On("CreateOrganization", uint64(100), repository.CreateOrganization{Slug: "acme", Name: "Acme"}).Return(&repository.Organization{ID: 2, Slug: "acme", Name: "Acme"}, (*helper.StandardError)(nil))
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.POST("/organizations", organizationRouter.CreateOrganization)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/organizations", bytes.NewBufferString(`{"slug":"acme","name":"Acme"}`))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), `"slug":"acme"`)
+	})
+
+	t.Run("Invalid slug", func(t *testing.T) {
+		organizationRouter := router.NewOrganizationRouterImpl(nil)
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.POST("/organizations", organizationRouter.CreateOrganization)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/organizations", bytes.NewBufferString(`{"slug":"Acme Inc","name":"Acme"}`))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestListOrganizations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockOrganizationUsecase := new(mocks.OrganizationUsecaseMock)
+	organizationRouter := router.NewOrganizationRouterImpl(mockOrganizationUsecase)
+
+	organizations := []repository.OrganizationMembership{{Organization: repository.Organization{ID: 2, Slug: "acme"}, Role: repository.OrganizationRoleAdmin}}
+	mockOrganizationUsecase.On("ListOrganizations", uint64(100)).Return(&organizations, (*helper.StandardError)(nil))
+
+	router := gin.Default()
+	router.Use(withCurrentUser)
+	router.GET("/organizations", organizationRouter.ListOrganizations)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/organizations", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.MatchRegex(t, w.Body.String(), `"role":"admin"`)
+}
+
+func TestSetMember(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockOrganizationUsecase := new(mocks.OrganizationUsecaseMock)
+		organizationRouter := router.NewOrganizationRouterImpl(mockOrganizationUsecase)
+
+		mockOrganizationUsecase.On("SetMember", uint64(2), uint64(101), repository.SetMembership{Role: "member"}, uint64(100)).Return(&repository.Membership{ID: 1, OrganizationID: 2, UserID: 101, Role: "member"}, (*helper.StandardError)(nil))
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.PUT("/organizations/:id/members/:userId", organizationRouter.SetMember)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPut, "/organizations/2/members/101", bytes.NewBufferString(`{"role":"member"}`))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "successfully set member")
+	})
+
+	t.Run("Invalid role", func(t *testing.T) {
+		organizationRouter := router.NewOrganizationRouterImpl(nil)
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.PUT("/organizations/:id/members/:userId", organizationRouter.SetMember)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPut, "/organizations/2/members/101", bytes.NewBufferString(`{"role":"owner"}`))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Forbidden", func(t *testing.T) {
+		mockOrganizationUsecase := new(mocks.OrganizationUsecaseMock)
+		organizationRouter := router.NewOrganizationRouterImpl(mockOrganizationUsecase)
+
+		mockError := &helper.StandardError{Error: errors.New("organization admin role required"), ErrorCode: http.StatusForbidden}
+		mockOrganizationUsecase.On("SetMember", uint64(2), uint64(101), repository.SetMembership{Role: "admin"}, uint64(100)).Return((*repository.Membership)(nil), mockError)
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.PUT("/organizations/:id/members/:userId", organizationRouter.SetMember)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPut, "/organizations/2/members/101", bytes.NewBufferString(`{"role":"admin"}`))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+func TestRemoveMember(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockOrganizationUsecase := new(mocks.OrganizationUsecaseMock)
+		organizationRouter := router.NewOrganizationRouterImpl(mockOrganizationUsecase)
+
+		mockOrganizationUsecase.On("RemoveMember", uint64(2), uint64(101), uint64(100)).Return((*helper.StandardError)(nil))
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.DELETE("/organizations/:id/members/:userId", organizationRouter.RemoveMember)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/organizations/2/members/101", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Invalid ID", func(t *testing.T) {
+		organizationRouter := router.NewOrganizationRouterImpl(nil)
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.DELETE("/organizations/:id/members/:userId", organizationRouter.RemoveMember)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/organizations/acme/members/101", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestSwitchOrganization(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockOrganizationUsecase := new(mocks.OrganizationUsecaseMock)
+	organizationRouter := router.NewOrganizationRouterImpl(mockOrganizationUsecase)
+
+	mockOrganizationUsecase.On("SwitchOrganization", uint64(2), uint64(100)).Return("token", (*helper.StandardError)(nil))
+
+	router := gin.Default()
+	router.Use(withCurrentUser)
+	router.POST("/organizations/:id/switch", organizationRouter.SwitchOrganization)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/organizations/2/switch", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.MatchRegex(t, w.Body.String(), `"token":"token"`)
+}
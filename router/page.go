@@ -0,0 +1,133 @@
+package router
+
+import (
+	"html/template"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var authorizeTemplate = template.Must(template.New("authorize").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Sign in to {{.Client.Name}}</title>
+</head>
+<body>
+<h1>Sign in to {{.Client.Name}}</h1>
+{{if .Error}}<p role="alert">{{.Error}}</p>{{end}}
+<p>{{.Client.Name}} is requesting access to:</p>
+<ul>
+{{range .Scopes}}<li>{{.}}</li>
+{{end}}</ul>
+<form method="post" action="{{.Action}}">
+{{range $name, $value := .Hidden}}<input type="hidden" name="{{$name}}" value="{{$value}}">
+{{end}}<label>Username <input type="text" name="username" autocomplete="username" required></label>
+<label>Password <input type="password" name="password" autocomplete="current-password" required></label>
+<button type="submit" name="approve" value="true">Allow</button>
+<button type="submit" name="approve" value="false">Deny</button>
+</form>
+</body>
+</html>
+`))
+
+var deviceTemplate = template.Must(template.New("device").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Connect a device</title>
+</head>
+<body>
+<h1>Connect a device</h1>
+{{if .Error}}<p role="alert">{{.Error}}</p>{{end}}
+{{with .Prompt}}<p>{{.Client.Name}} is requesting access to:</p>
+<ul>
+{{range .Scopes}}<li>{{.}}</li>
+{{end}}</ul>
+<p>Only continue if the code matches the one shown on your device.</p>
+{{else}}<p>Enter the code shown on your device.</p>
+{{end}}<form method="post" action="/oauth/device">
+<label>Code <input type="text" name="user_code" value="{{.UserCode}}" autocomplete="off" required></label>
+{{with .SignedIn}}<p>Signed in as {{.Username}}.</p>
+<input type="hidden" name="csrf_token" value="{{$.CSRFToken}}">
+{{else}}<label>Username <input type="text" name="username" autocomplete="username" required></label>
+<label>Password <input type="password" name="password" autocomplete="current-password" required></label>
+{{end}}<button type="submit" name="approve" value="true">Allow</button>
+<button type="submit" name="approve" value="false">Deny</button>
+</form>
+</body>
+</html>
+`))
+
+var deviceDoneTemplate = template.Must(template.New("device_done").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Device {{if .Approved}}connected{{else}}denied{{end}}</title>
+</head>
+<body>
+<h1>Device {{if .Approved}}connected{{else}}denied{{end}}</h1>
+<p>{{if .Approved}}{{.Client.Name}} can now access your account. Return to your device to continue.{{else}}{{.Client.Name}} was not given access to your account.{{end}} You can close this window.</p>
+</body>
+</html>
+`))
+
+var errorTemplate = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Request rejected</title>
+</head>
+<body>
+<h1>Request rejected</h1>
+<p>{{.}}</p>
+</body>
+</html>
+`))
+
+var logoutTemplate = template.Must(template.New("logout").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Signed out</title>
+</head>
+<body>
+<h1>Signed out</h1>
+<p>You have been signed out. You can close this window.</p>
+</body>
+</html>
+`))
+
+func renderPage(c *gin.Context, status int, page *template.Template, data interface{}) {
+	renderPageWithFormTargets(c, status, page, data, nil)
+}
+
+// renderPageWithFormTargets also lets the page's forms end up at formTargets. Browsers check form-action against
+// every redirect that answers a submission, so a form the server redirects elsewhere needs its target listed.
+func renderPageWithFormTargets(c *gin.Context, status int, page *template.Template, data interface{}, formTargets []string) {
+	formAction := append([]string{"'self'"}, formTargets...)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Frame-Options", "DENY")
+	c.Header("Content-Security-Policy", "default-src 'none'; form-action "+strings.Join(formAction, " ")+"; frame-ancestors 'none'")
+	c.Status(status)
+	page.Execute(c.Writer, data)
+}
+
+// redirectSources returns the CSP source of a validated redirect URI: its origin, or only its scheme for the
+// private-use schemes of native apps, which have no host.
+func redirectSources(redirectURI string) []string {
+	parsed, err := url.Parse(redirectURI)
+	if err != nil || parsed.Scheme == "" {
+		return nil
+	}
+	source := parsed.Scheme + ":"
+	if parsed.Host != "" {
+		source = parsed.Scheme + "://" + parsed.Host
+	}
+	if strings.ContainsAny(source, " ;,'\"") {
+		return nil
+	}
+	return []string{source}
+}
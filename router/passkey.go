@@ -0,0 +1,166 @@
+package router
+
+import (
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PasskeyRouter interface {
+	BeginRegistration(c *gin.Context)
+	FinishRegistration(c *gin.Context)
+	ListPasskeys(c *gin.Context)
+	RemovePasskey(c *gin.Context)
+	UpdateSettings(c *gin.Context)
+	BeginLogin(c *gin.Context)
+	FinishLogin(c *gin.Context)
+}
+
+type PasskeyRouterImpl struct {
+	passkeyUsecase usecase.PasskeyUsecase
+}
+
+func NewPasskeyRouterImpl(passkeyUsecase usecase.PasskeyUsecase) PasskeyRouter {
+	return &PasskeyRouterImpl{
+		passkeyUsecase: passkeyUsecase,
+	}
+}
+
+func (t *PasskeyRouterImpl) BeginRegistration(c *gin.Context) {
+	currentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	ceremony, err := t.passkeyUsecase.BeginRegistration(currentUserId)
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": ceremony, "message": "successfully begin passkey registration"})
+}
+
+func (t *PasskeyRouterImpl) FinishRegistration(c *gin.Context) {
+	var registration repository.PasskeyRegistration
+
+	if err := c.ShouldBindJSON(&registration); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	currentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	passkey, err := t.passkeyUsecase.FinishRegistration(currentUserId, registration)
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": passkey, "message": "successfully register passkey"})
+}
+
+func (t *PasskeyRouterImpl) ListPasskeys(c *gin.Context) {
+	currentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	passkeys, err := t.passkeyUsecase.ListPasskeys(currentUserId)
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": passkeys, "message": "successfully list passkeys"})
+}
+
+func (t *PasskeyRouterImpl) RemovePasskey(c *gin.Context) {
+	passkeyId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to convert requested passkey ID"})
+		return
+	}
+
+	currentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	passkey, removeError := t.passkeyUsecase.RemovePasskey(currentUserId, passkeyId)
+
+	if removeError != nil && removeError.Error != nil {
+		c.JSON(int(removeError.ErrorCode), gin.H{"error": removeError.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": passkey, "message": "successfully remove passkey"})
+}
+
+func (t *PasskeyRouterImpl) UpdateSettings(c *gin.Context) {
+	var settings repository.PasskeySettings
+
+	if err := c.ShouldBindJSON(&settings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	currentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	updated, err := t.passkeyUsecase.UpdateSettings(currentUserId, settings)
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": updated, "message": "successfully update passkey settings"})
+}
+
+func (t *PasskeyRouterImpl) BeginLogin(c *gin.Context) {
+	var loginData repository.PasskeyLoginBegin
+
+	if err := c.ShouldBindJSON(&loginData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ceremony, err := t.passkeyUsecase.BeginLogin(loginData, getCurrentOrganizationId(c))
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": ceremony, "message": "successfully begin passkey login"})
+}
+
+func (t *PasskeyRouterImpl) FinishLogin(c *gin.Context) {
+	var loginData repository.PasskeyLoginFinish
+
+	if err := c.ShouldBindJSON(&loginData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, err := t.passkeyUsecase.FinishLogin(loginData, requestLoginContext(c))
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": token, "message": "successfully login"})
+}
@@ -0,0 +1,173 @@
+package router_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/router"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/stretchr/testify/mock"
+)
+
+func passkeyEngine(passkeyRouter router.PasskeyRouter) *gin.Engine {
+	engine := gin.Default()
+	engine.POST("/passkeys/login/begin", passkeyRouter.BeginLogin)
+	engine.POST("/passkeys/login/finish", passkeyRouter.FinishLogin)
+
+	me := engine.Group("/me", withCurrentUser)
+	me.GET("/passkeys", passkeyRouter.ListPasskeys)
+	me.POST("/passkeys/register/begin", passkeyRouter.BeginRegistration)
+	me.POST("/passkeys/register/finish", passkeyRouter.FinishRegistration)
+	me.PUT("/passkeys/settings", passkeyRouter.UpdateSettings)
+	me.DELETE("/passkeys/:id", passkeyRouter.RemovePasskey)
+	return engine
+}
+
+func TestPasskeyRegistration(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Begin", func(t *testing.T) {
+		mockPasskeyUsecase := new(mocks.PasskeyUsecaseMock)
+		mockPasskeyUsecase.On("BeginRegistration", uint64(100)).Return(&repository.PasskeyCeremony{SessionID: "session", Options: gin.H{"publicKey": gin.H{}}}, (*helper.StandardError)(nil))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/me/passkeys/register/begin", nil)
+		passkeyEngine(router.NewPasskeyRouterImpl(mockPasskeyUsecase)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), `"sessionid":"session"`)
+	})
+
+	t.Run("Finish", func(t *testing.T) {
+		mockPasskeyUsecase := new(mocks.PasskeyUsecaseMock)
+		registration := repository.PasskeyRegistration{SessionID: "session", Name: "laptop", Credential: json.RawMessage(`{"id":"abc"}`)}
+		mockPasskeyUsecase.On("FinishRegistration", uint64(100), registration).Return(&repository.PasskeyCredential{ID: 1, UserID: 100, Name: "laptop", PublicKey: []byte("key")}, (*helper.StandardError)(nil))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/me/passkeys/register/finish", strings.NewReader(`{"sessionid":"session","name":"laptop","credential":{"id":"abc"}}`))
+		passkeyEngine(router.NewPasskeyRouterImpl(mockPasskeyUsecase)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), `"name":"laptop"`)
+		assert.NotMatchRegex(t, w.Body.String(), `publickey`)
+	})
+
+	t.Run("Finish without credential", func(t *testing.T) {
+		mockPasskeyUsecase := new(mocks.PasskeyUsecaseMock)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/me/passkeys/register/finish", strings.NewReader(`{"sessionid":"session"}`))
+		passkeyEngine(router.NewPasskeyRouterImpl(mockPasskeyUsecase)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockPasskeyUsecase.AssertNotCalled(t, "FinishRegistration", mock.Anything, mock.Anything)
+	})
+}
+
+func TestPasskeyManagement(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("List", func(t *testing.T) {
+		mockPasskeyUsecase := new(mocks.PasskeyUsecaseMock)
+		mockPasskeyUsecase.On("ListPasskeys", uint64(100)).Return(&[]repository.PasskeyCredential{{ID: 1, Name: "laptop"}}, (*helper.StandardError)(nil))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/me/passkeys", nil)
+		passkeyEngine(router.NewPasskeyRouterImpl(mockPasskeyUsecase)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), `"name":"laptop"`)
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		mockPasskeyUsecase := new(mocks.PasskeyUsecaseMock)
+		mockPasskeyUsecase.On("RemovePasskey", uint64(100), uint64(1)).Return(&repository.PasskeyCredential{ID: 1}, (*helper.StandardError)(nil))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/me/passkeys/1", nil)
+		passkeyEngine(router.NewPasskeyRouterImpl(mockPasskeyUsecase)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Remove not found", func(t *testing.T) {
+		mockPasskeyUsecase := new(mocks.PasskeyUsecaseMock)
+		mockPasskeyUsecase.On("RemovePasskey", uint64(100), uint64(2)).Return((*repository.PasskeyCredential)(nil), &helper.StandardError{Error: errors.New("passkey not found"), ErrorCode: http.StatusNotFound})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/me/passkeys/2", nil)
+		passkeyEngine(router.NewPasskeyRouterImpl(mockPasskeyUsecase)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "passkey not found")
+	})
+
+	t.Run("Remove invalid id", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/me/passkeys/laptop", nil)
+		passkeyEngine(router.NewPasskeyRouterImpl(nil)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "Failed to convert requested passkey ID")
+	})
+
+	t.Run("Require passkeys", func(t *testing.T) {
+		mockPasskeyUsecase := new(mocks.PasskeyUsecaseMock)
+		mockPasskeyUsecase.On("UpdateSettings", uint64(100), repository.PasskeySettings{Required: true}).Return(&repository.PasskeySettings{Required: true}, (*helper.StandardError)(nil))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPut, "/me/passkeys/settings", strings.NewReader(`{"required":true}`))
+		passkeyEngine(router.NewPasskeyRouterImpl(mockPasskeyUsecase)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), `"required":true`)
+	})
+}
+
+func TestPasskeyLogin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Begin", func(t *testing.T) {
+		mockPasskeyUsecase := new(mocks.PasskeyUsecaseMock)
+		mockPasskeyUsecase.On("BeginLogin", repository.PasskeyLoginBegin{Username: "username", Password: "password"}).Return(&repository.PasskeyCeremony{SessionID: "session"}, (*helper.StandardError)(nil))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/passkeys/login/begin", strings.NewReader(`{"username":"username","password":"password"}`))
+		passkeyEngine(router.NewPasskeyRouterImpl(mockPasskeyUsecase)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), `"sessionid":"session"`)
+	})
+
+	t.Run("Finish", func(t *testing.T) {
+		mockPasskeyUsecase := new(mocks.PasskeyUsecaseMock)
+		mockPasskeyUsecase.On("FinishLogin", mock.Anything).Return("jwt-token", (*helper.StandardError)(nil))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/passkeys/login/finish", strings.NewReader(`{"sessionid":"session","credential":{"id":"abc"}}`))
+		passkeyEngine(router.NewPasskeyRouterImpl(mockPasskeyUsecase)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), `"token":"jwt-token"`)
+	})
+
+	t.Run("Finish verification failed", func(t *testing.T) {
+		mockPasskeyUsecase := new(mocks.PasskeyUsecaseMock)
+		mockPasskeyUsecase.On("FinishLogin", mock.Anything).Return("", &helper.StandardError{Error: errors.New("passkey verification failed"), ErrorCode: http.StatusUnauthorized})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/passkeys/login/finish", strings.NewReader(`{"sessionid":"session","credential":{"id":"abc"}}`))
+		passkeyEngine(router.NewPasskeyRouterImpl(mockPasskeyUsecase)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "passkey verification failed")
+	})
+}
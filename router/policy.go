@@ -0,0 +1,41 @@
+package router
+
+import (
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PolicyRouter interface {
+	Check(c *gin.Context)
+}
+
+type PolicyRouterImpl struct {
+	policyUsecase usecase.PolicyUsecase
+}
+
+func NewPolicyRouterImpl(policyUsecase usecase.PolicyUsecase) PolicyRouter {
+	return &PolicyRouterImpl{
+		policyUsecase: policyUsecase,
+	}
+}
+
+func (t *PolicyRouterImpl) Check(c *gin.Context) {
+	var request repository.AuthorizationRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	decision, err := t.policyUsecase.Check(getCurrentOrganizationId(c), request)
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": decision, "message": "successfully check authorization"})
+}
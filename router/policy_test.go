@@ -0,0 +1,54 @@
+package router_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/router"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestCheckAuthorization(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockPolicyUsecase := new(mocks.PolicyUsecaseMock)
+		policyRouter := router.NewPolicyRouterImpl(mockPolicyUsecase)
+
+		request := repository.AuthorizationRequest{
+			Subject:  map[string]interface{}{"id": float64(100)},
+			Action:   "users:delete",
+			Resource: map[string]interface{}{"type": "user", "id": float64(101)},
+		}
+		mockPolicyUsecase.On("Check", repository.DefaultOrganizationID, request).Return(&repository.AuthorizationDecision{Allowed: false, Rule: "admins-cannot-delete-admins"}, (*helper.StandardError)(nil))
+
+		router := gin.Default()
+		router.POST("/authz/check", policyRouter.Check)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/authz/check", bytes.NewBufferString(`{"subject":{"id":100},"action":"users:delete","resource":{"type":"user","id":101}}`))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), `"allowed":false,"rule":"admins-cannot-delete-admins"`)
+	})
+
+	t.Run("Missing action", func(t *testing.T) {
+		policyRouter := router.NewPolicyRouterImpl(nil)
+
+		router := gin.Default()
+		router.POST("/authz/check", policyRouter.Check)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/authz/check", bytes.NewBufferString(`{"resource":{"type":"user"}}`))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,101 @@
+package router
+
+import (
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PrivacyRouter interface {
+	ExportData(c *gin.Context)
+	RequestErasure(c *gin.Context)
+	GetErasure(c *gin.Context)
+	CancelErasure(c *gin.Context)
+}
+
+type PrivacyRouterImpl struct {
+	privacyUsecase usecase.PrivacyUsecase
+}
+
+func NewPrivacyRouterImpl(privacyUsecase usecase.PrivacyUsecase) PrivacyRouter {
+	return &PrivacyRouterImpl{
+		privacyUsecase: privacyUsecase,
+	}
+}
+
+func (t *PrivacyRouterImpl) ExportData(c *gin.Context) {
+	currentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	data, err := t.privacyUsecase.ExportData(currentUserId)
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="data-export.zip"`)
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/zip", data)
+}
+
+func (t *PrivacyRouterImpl) RequestErasure(c *gin.Context) {
+	currentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	// The body is optional; users who signed in recently confirm without a password.
+	var confirmation repository.ErasureConfirmation
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&confirmation); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	request, err := t.privacyUsecase.RequestErasure(currentUserId, getCurrentSessionId(c), confirmation)
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"data": request, "message": "successfully request erasure"})
+}
+
+func (t *PrivacyRouterImpl) GetErasure(c *gin.Context) {
+	currentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	request, err := t.privacyUsecase.GetErasure(currentUserId)
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": request, "message": "successfully get erasure"})
+}
+
+func (t *PrivacyRouterImpl) CancelErasure(c *gin.Context) {
+	currentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	request, err := t.privacyUsecase.CancelErasure(currentUserId)
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": request, "message": "successfully cancel erasure"})
+}
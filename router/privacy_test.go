@@ -0,0 +1,140 @@
+package router_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/router"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestExportData(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockPrivacyUsecase := new(mocks.PrivacyUsecaseMock)
+		privacyRouter := router.NewPrivacyRouterImpl(mockPrivacyUsecase)
+
+		mockPrivacyUsecase.On("ExportData", uint64(100)).Return([]byte("PK archive"), (*helper.StandardError)(nil))
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.GET("/me/data-export", privacyRouter.ExportData)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/me/data-export", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="data-export.zip"`, w.Header().Get("Content-Disposition"))
+		assert.Equal(t, "PK archive", w.Body.String())
+	})
+
+	t.Run("Erased user", func(t *testing.T) {
+		mockPrivacyUsecase := new(mocks.PrivacyUsecaseMock)
+		privacyRouter := router.NewPrivacyRouterImpl(mockPrivacyUsecase)
+
+		mockPrivacyUsecase.On("ExportData", uint64(100)).Return([]byte(nil), &helper.StandardError{Error: errors.New("user is erased"), ErrorCode: http.StatusGone})
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.GET("/me/data-export", privacyRouter.ExportData)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/me/data-export", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusGone, w.Code)
+		assert.Equal(t, "", w.Header().Get("Content-Disposition"))
+	})
+}
+
+func TestRequestErasure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockPrivacyUsecase := new(mocks.PrivacyUsecaseMock)
+		privacyRouter := router.NewPrivacyRouterImpl(mockPrivacyUsecase)
+
+		scheduledAt := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+		mockPrivacyUsecase.On("RequestErasure", uint64(100), repository.ErasureConfirmation{}).
+			Return(&repository.ErasureRequest{ID: 1, UserID: 100, Status: repository.ErasureStatusPending, ScheduledAt: scheduledAt}, (*helper.StandardError)(nil))
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.POST("/me/erasure", privacyRouter.RequestErasure)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/me/erasure", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		assert.MatchRegex(t, w.Body.String(), `"scheduledat":"2024-02-01T00:00:00Z"`)
+	})
+
+	t.Run("Already requested", func(t *testing.T) {
+		mockPrivacyUsecase := new(mocks.PrivacyUsecaseMock)
+		privacyRouter := router.NewPrivacyRouterImpl(mockPrivacyUsecase)
+
+		mockPrivacyUsecase.On("RequestErasure", uint64(100), repository.ErasureConfirmation{}).
+			Return((*repository.ErasureRequest)(nil), &helper.StandardError{Error: errors.New("erasure already requested"), ErrorCode: http.StatusConflict})
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.POST("/me/erasure", privacyRouter.RequestErasure)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/me/erasure", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Confirmed with password", func(t *testing.T) {
+		mockPrivacyUsecase := new(mocks.PrivacyUsecaseMock)
+		privacyRouter := router.NewPrivacyRouterImpl(mockPrivacyUsecase)
+
+		mockPrivacyUsecase.On("RequestErasure", uint64(100), repository.ErasureConfirmation{Password: "secret"}).
+			Return(&repository.ErasureRequest{ID: 1, UserID: 100, Status: repository.ErasureStatusPending}, (*helper.StandardError)(nil))
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.POST("/me/erasure", privacyRouter.RequestErasure)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/me/erasure", strings.NewReader(`{"password":"secret"}`))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+	})
+}
+
+func TestCancelErasure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockPrivacyUsecase := new(mocks.PrivacyUsecaseMock)
+	privacyRouter := router.NewPrivacyRouterImpl(mockPrivacyUsecase)
+
+	mockPrivacyUsecase.On("CancelErasure", uint64(100)).
+		Return(&repository.ErasureRequest{ID: 1, UserID: 100, Status: repository.ErasureStatusCancelled}, (*helper.StandardError)(nil))
+
+	router := gin.Default()
+	router.Use(withCurrentUser)
+	router.DELETE("/me/erasure", privacyRouter.CancelErasure)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodDelete, "/me/erasure", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.MatchRegex(t, w.Body.String(), `"status":"cancelled"`)
+}
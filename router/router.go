@@ -7,17 +7,135 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRouter(userRouter UserRouter, authRouter AuthRouter, authUsecase usecase.AuthUsecase) *gin.Engine {
+func SetupRouter(userRouter UserRouter, authRouter AuthRouter, auditRouter AuditRouter, tokenRouter TokenRouter, oauthRouter OAuthRouter, oidcRouter OIDCRouter, federationRouter FederationRouter, scimRouter SCIMRouter, passkeyRouter PasskeyRouter, magicLinkRouter MagicLinkRouter, sessionRouter SessionRouter, organizationRouter OrganizationRouter, groupRouter GroupRouter, policyRouter PolicyRouter, invitationRouter InvitationRouter, attributeRouter AttributeRouter, avatarRouter AvatarRouter, importRouter ImportRouter, privacyRouter PrivacyRouter, authUsecase usecase.AuthUsecase, organizationUsecase usecase.OrganizationUsecase, policyUsecase usecase.PolicyUsecase) *gin.Engine {
 	ginRouter := gin.Default()
+	ginRouter.Use(organizationUsecase.ResolveOrganization)
 
 	ginRouter.GET("/", func(ctx *gin.Context) {
 		ctx.JSON(http.StatusOK, "OK")
 	})
-	ginRouter.POST("/api/v1/register", authRouter.Register)
+	ginRouter.POST("/api/v1/register", organizationUsecase.RequireOpenRegistration, authRouter.Register)
 	ginRouter.POST("/api/v1/login", authRouter.Login)
-	ginRouter.GET("/api/v1/users", authUsecase.ValidateToken, userRouter.ListUsers)
-	ginRouter.POST("/api/v1/users", authUsecase.ValidateToken, userRouter.CreateUser)
-	ginRouter.DELETE("/api/v1/users/:id", authUsecase.ValidateToken, userRouter.RemoveUser)
+	ginRouter.POST("/api/v1/logout", authUsecase.ValidateToken, authRouter.Logout)
+	ginRouter.POST("/api/v1/login/magic", magicLinkRouter.RequestLink)
+	ginRouter.GET("/api/v1/login/magic/consume", magicLinkRouter.ConsumeLink)
+	ginRouter.GET("/api/v1/users", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeUsersRead), policyUsecase.Authorize("users:list", usecase.PolicyResourceUser), userRouter.ListUsers)
+	ginRouter.POST("/api/v1/users", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeUsersWrite), policyUsecase.Authorize("users:create", usecase.PolicyResourceUser), userRouter.CreateUser)
+	ginRouter.GET("/api/v1/users/export", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeUsersRead), policyUsecase.Authorize("users:list", usecase.PolicyResourceUser), userRouter.ExportUsers)
+	ginRouter.POST("/api/v1/users/import", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeUsersWrite), policyUsecase.Authorize("users:create", usecase.PolicyResourceUser), importRouter.ImportUsers)
+	ginRouter.GET("/api/v1/users/import", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeUsersWrite), policyUsecase.Authorize("users:create", usecase.PolicyResourceUser), importRouter.ListImports)
+	ginRouter.GET("/api/v1/users/import/:importId", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeUsersWrite), policyUsecase.Authorize("users:create", usecase.PolicyResourceUser), importRouter.GetImport)
+	ginRouter.DELETE("/api/v1/users/:id", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeUsersWrite), policyUsecase.Authorize("users:delete", usecase.PolicyResourceUser), userRouter.RemoveUser)
+	ginRouter.PUT("/api/v1/users/:id/attributes", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeUsersWrite), policyUsecase.Authorize("users:update", usecase.PolicyResourceUser), attributeRouter.SetUserAttributes)
+	ginRouter.GET("/api/v1/attributes", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeUsersRead), policyUsecase.Authorize("attributes:list", usecase.PolicyResourceAttribute), attributeRouter.ListDefinitions)
+	ginRouter.POST("/api/v1/attributes", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeUsersWrite), policyUsecase.Authorize("attributes:create", usecase.PolicyResourceAttribute), attributeRouter.CreateDefinition)
+	ginRouter.PUT("/api/v1/attributes/:id", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeUsersWrite), policyUsecase.Authorize("attributes:update", usecase.PolicyResourceAttribute), attributeRouter.UpdateDefinition)
+	ginRouter.DELETE("/api/v1/attributes/:id", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeUsersWrite), policyUsecase.Authorize("attributes:delete", usecase.PolicyResourceAttribute), attributeRouter.DeleteDefinition)
+	ginRouter.GET("/api/v1/invitations", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeUsersRead), policyUsecase.Authorize("invitations:list", usecase.PolicyResourceInvitation), invitationRouter.ListInvitations)
+	ginRouter.POST("/api/v1/invitations", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeUsersWrite), policyUsecase.Authorize("users:create", usecase.PolicyResourceUser), invitationRouter.CreateInvitation)
+	ginRouter.POST("/api/v1/invitations/:id/resend", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeUsersWrite), policyUsecase.Authorize("invitations:update", usecase.PolicyResourceInvitation), invitationRouter.ResendInvitation)
+	ginRouter.DELETE("/api/v1/invitations/:id", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeUsersWrite), policyUsecase.Authorize("invitations:delete", usecase.PolicyResourceInvitation), invitationRouter.RevokeInvitation)
+	ginRouter.POST("/api/v1/invitations/accept", invitationRouter.AcceptInvitation)
+	ginRouter.GET("/api/v1/users/:id/sessions", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeUsersRead), policyUsecase.Authorize("users:read", usecase.PolicyResourceUser), sessionRouter.ListUserSessions)
+	ginRouter.DELETE("/api/v1/users/:id/sessions/:sessionId", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeUsersWrite), policyUsecase.Authorize("users:update", usecase.PolicyResourceUser), sessionRouter.RevokeUserSession)
+	ginRouter.GET("/api/v1/organizations", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeAccount), organizationRouter.ListOrganizations)
+	ginRouter.POST("/api/v1/organizations", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeUsersWrite), policyUsecase.Authorize("organizations:create", usecase.PolicyResourceOrganization), organizationRouter.CreateOrganization)
+	ginRouter.POST("/api/v1/organizations/:id/switch", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeAccount), organizationRouter.SwitchOrganization)
+	ginRouter.GET("/api/v1/organizations/:id/members", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeUsersRead), policyUsecase.Authorize("organizations:read", usecase.PolicyResourceOrganization), organizationRouter.ListMembers)
+	ginRouter.PUT("/api/v1/organizations/:id/members/:userId", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeUsersWrite), policyUsecase.Authorize("organizations:update", usecase.PolicyResourceOrganization), organizationRouter.SetMember)
+	ginRouter.DELETE("/api/v1/organizations/:id/members/:userId", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeUsersWrite), policyUsecase.Authorize("organizations:update", usecase.PolicyResourceOrganization), organizationRouter.RemoveMember)
+	ginRouter.GET("/api/v1/groups", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeUsersRead), policyUsecase.Authorize("groups:list", usecase.PolicyResourceGroup), groupRouter.ListGroups)
+	ginRouter.POST("/api/v1/groups", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeUsersWrite), policyUsecase.Authorize("groups:create", usecase.PolicyResourceGroup), groupRouter.CreateGroup)
+	ginRouter.PUT("/api/v1/groups/:id", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeUsersWrite), policyUsecase.Authorize("groups:update", usecase.PolicyResourceGroup), groupRouter.RenameGroup)
+	ginRouter.DELETE("/api/v1/groups/:id", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeUsersWrite), policyUsecase.Authorize("groups:delete", usecase.PolicyResourceGroup), groupRouter.DeleteGroup)
+	ginRouter.GET("/api/v1/groups/:id/members", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeUsersRead), policyUsecase.Authorize("groups:read", usecase.PolicyResourceGroup), groupRouter.ListMembers)
+	ginRouter.PUT("/api/v1/groups/:id/members/users/:memberId", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeUsersWrite), policyUsecase.Authorize("groups:update", usecase.PolicyResourceGroup), groupRouter.AddUser)
+	ginRouter.DELETE("/api/v1/groups/:id/members/users/:memberId", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeUsersWrite), policyUsecase.Authorize("groups:update", usecase.PolicyResourceGroup), groupRouter.RemoveUser)
+	ginRouter.PUT("/api/v1/groups/:id/members/groups/:memberId", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeUsersWrite), policyUsecase.Authorize("groups:update", usecase.PolicyResourceGroup), groupRouter.AddGroup)
+	ginRouter.DELETE("/api/v1/groups/:id/members/groups/:memberId", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeUsersWrite), policyUsecase.Authorize("groups:update", usecase.PolicyResourceGroup), groupRouter.RemoveGroup)
+	ginRouter.POST("/api/v1/authz/check", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeAuthz), policyRouter.Check)
+	ginRouter.GET("/api/v1/audit/verify", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeAuditRead), policyUsecase.Authorize("audit:read", usecase.PolicyResourceAudit), auditRouter.VerifyAudit)
+	ginRouter.GET("/api/v1/me/tokens", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeTokens), tokenRouter.ListTokens)
+	ginRouter.POST("/api/v1/me/tokens", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeTokens), tokenRouter.CreateToken)
+	ginRouter.DELETE("/api/v1/me/tokens/:id", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeTokens), tokenRouter.RevokeToken)
+	ginRouter.GET("/api/v1/clients", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeClients), policyUsecase.Authorize("clients:list", usecase.PolicyResourceClient), organizationUsecase.RequireAdmin, oauthRouter.ListClients)
+	ginRouter.POST("/api/v1/clients", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeClients), policyUsecase.Authorize("clients:create", usecase.PolicyResourceClient), organizationUsecase.RequireAdmin, oauthRouter.CreateClient)
+	ginRouter.POST("/api/v1/clients/:id/secret", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeClients), policyUsecase.Authorize("clients:update", usecase.PolicyResourceClient), organizationUsecase.RequireAdmin, oauthRouter.RotateClientSecret)
+	ginRouter.DELETE("/api/v1/clients/:id", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeClients), policyUsecase.Authorize("clients:delete", usecase.PolicyResourceClient), organizationUsecase.RequireAdmin, oauthRouter.DisableClient)
+	ginRouter.PUT("/api/v1/me/avatar", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeAccount), avatarRouter.UploadAvatar)
+	ginRouter.DELETE("/api/v1/me/avatar", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeAccount), avatarRouter.RemoveAvatar)
+	ginRouter.GET("/blobs/*key", avatarRouter.GetAvatar)
+	ginRouter.GET("/api/v1/me/data-export", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeAccount), privacyRouter.ExportData)
+	ginRouter.GET("/api/v1/me/erasure", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeAccount), privacyRouter.GetErasure)
+	ginRouter.POST("/api/v1/me/erasure", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeAccount), privacyRouter.RequestErasure)
+	ginRouter.DELETE("/api/v1/me/erasure", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeAccount), privacyRouter.CancelErasure)
+	ginRouter.GET("/api/v1/me/sessions", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeTokens), sessionRouter.ListSessions)
+	ginRouter.DELETE("/api/v1/me/sessions/:id", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeTokens), sessionRouter.RevokeSession)
+	ginRouter.GET("/api/v1/me/consents", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeTokens), oauthRouter.ListConsents)
+	ginRouter.DELETE("/api/v1/me/consents/:id", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeTokens), oauthRouter.RevokeConsent)
+	ginRouter.OPTIONS("/oauth/token", allowAnyOrigin)
+	ginRouter.GET("/api/v1/me/identities", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeTokens), federationRouter.ListIdentities)
+	ginRouter.POST("/api/v1/me/identities/:provider", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeTokens), federationRouter.LinkIdentity)
+	ginRouter.DELETE("/api/v1/me/identities/:id", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeTokens), federationRouter.UnlinkIdentity)
+	ginRouter.GET("/api/v1/me/passkeys", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeTokens), passkeyRouter.ListPasskeys)
+	ginRouter.POST("/api/v1/me/passkeys/register/begin", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeTokens), passkeyRouter.BeginRegistration)
+	ginRouter.POST("/api/v1/me/passkeys/register/finish", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeTokens), passkeyRouter.FinishRegistration)
+	ginRouter.PUT("/api/v1/me/passkeys/settings", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeTokens), passkeyRouter.UpdateSettings)
+	ginRouter.DELETE("/api/v1/me/passkeys/:id", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeTokens), passkeyRouter.RemovePasskey)
+	ginRouter.POST("/api/v1/passkeys/login/begin", passkeyRouter.BeginLogin)
+	ginRouter.POST("/api/v1/passkeys/login/finish", passkeyRouter.FinishLogin)
+	ginRouter.GET("/api/v1/federation/:provider/login", federationRouter.Login)
+	ginRouter.GET("/api/v1/federation/:provider/callback", federationRouter.Callback)
+	ginRouter.GET("/scim/v2/Users", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeSCIM), policyUsecase.Authorize("users:list", usecase.PolicyResourceUser), scimRouter.ListUsers)
+	ginRouter.POST("/scim/v2/Users", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeSCIM), policyUsecase.Authorize("users:create", usecase.PolicyResourceUser), scimRouter.CreateUser)
+	ginRouter.GET("/scim/v2/Users/:id", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeSCIM), policyUsecase.Authorize("users:read", usecase.PolicyResourceUser), scimRouter.GetUser)
+	ginRouter.PUT("/scim/v2/Users/:id", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeSCIM), policyUsecase.Authorize("users:update", usecase.PolicyResourceUser), scimRouter.ReplaceUser)
+	ginRouter.PATCH("/scim/v2/Users/:id", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeSCIM), policyUsecase.Authorize("users:update", usecase.PolicyResourceUser), scimRouter.PatchUser)
+	ginRouter.DELETE("/scim/v2/Users/:id", authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeSCIM), policyUsecase.Authorize("users:delete", usecase.PolicyResourceUser), scimRouter.DeleteUser)
+	ginRouter.POST("/oauth/token", allowAnyOrigin, oauthRouter.Token)
+	ginRouter.POST("/oauth/introspect", oauthRouter.Introspect)
+	ginRouter.POST("/oauth/device/code", oauthRouter.DeviceAuthorization)
+	ginRouter.GET("/oauth/device", oauthRouter.DeviceVerification)
+	ginRouter.POST("/oauth/device", oauthRouter.DeviceDecision)
+	ginRouter.POST("/oauth/revoke", oauthRouter.Revoke)
+	ginRouter.GET("/oauth/authorize", oauthRouter.Authorize)
+	ginRouter.POST("/oauth/authorize", oauthRouter.AuthorizeDecision)
+	ginRouter.GET("/oauth/logout", oidcRouter.Logout)
+	ginRouter.POST("/oauth/logout", oidcRouter.Logout)
+	ginRouter.GET("/.well-known/openid-configuration", allowAnyOrigin, oidcRouter.Discovery)
+	ginRouter.GET("/.well-known/jwks.json", allowAnyOrigin, oidcRouter.JWKS)
+	ginRouter.OPTIONS("/userinfo", allowAnyOrigin)
+	ginRouter.GET("/userinfo", allowAnyOrigin, authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeOpenID), oidcRouter.UserInfo)
+	ginRouter.POST("/userinfo", allowAnyOrigin, authUsecase.ValidateToken, authUsecase.RequireScope(usecase.ScopeOpenID), oidcRouter.UserInfo)
 
 	return ginRouter
 }
+
+func allowAnyOrigin(c *gin.Context) {
+	c.Header("Access-Control-Allow-Origin", "*")
+	c.Header("Access-Control-Allow-Headers", "Authorization, Content-Type")
+	c.Header("Access-Control-Allow-Methods", "GET, POST")
+
+	if c.Request.Method == http.MethodOptions {
+		c.AbortWithStatus(http.StatusNoContent)
+		return
+	}
+
+	c.Next()
+}
+
+func getCurrentUserId(c *gin.Context) (uint64, bool) {
+	currentUserId, exists := c.Get("currentUserId")
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "current user not found"})
+		return 0, false
+	}
+
+	currentUserIdInt, ok := currentUserId.(uint64)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to convert current user ID"})
+		return 0, false
+	}
+
+	return currentUserIdInt, true
+}
@@ -18,16 +18,119 @@ func TestSetupRouter(t *testing.T) {
 
 	authRouterMock := new(mocks.AuthRouterMock)
 	userRouterMock := new(mocks.UserRouterMock)
+	auditRouterMock := new(mocks.AuditRouterMock)
+	tokenRouterMock := new(mocks.TokenRouterMock)
+	oauthRouterMock := new(mocks.OAuthRouterMock)
+	oidcRouterMock := new(mocks.OIDCRouterMock)
+	federationRouterMock := new(mocks.FederationRouterMock)
+	scimRouterMock := new(mocks.SCIMRouterMock)
+	passkeyRouterMock := new(mocks.PasskeyRouterMock)
+	magicLinkRouterMock := new(mocks.MagicLinkRouterMock)
+	sessionRouterMock := new(mocks.SessionRouterMock)
+	organizationRouterMock := new(mocks.OrganizationRouterMock)
+	groupRouterMock := new(mocks.GroupRouterMock)
+	policyRouterMock := new(mocks.PolicyRouterMock)
+	invitationRouterMock := new(mocks.InvitationRouterMock)
+	attributeRouterMock := new(mocks.AttributeRouterMock)
+	avatarRouterMock := new(mocks.AvatarRouterMock)
+	importRouterMock := new(mocks.ImportRouterMock)
+	privacyRouterMock := new(mocks.PrivacyRouterMock)
 	authUsecaseMock := new(mocks.AuthUsecaseMock)
+	organizationUsecaseMock := new(mocks.OrganizationUsecaseMock)
+	policyUsecaseMock := new(mocks.PolicyUsecaseMock)
 
 	authRouterMock.On("Register", mock.Anything)
 	authRouterMock.On("Login", mock.Anything)
+	authRouterMock.On("Logout", mock.Anything)
 	userRouterMock.On("ListUsers", mock.Anything)
 	userRouterMock.On("CreateUser", mock.Anything)
 	userRouterMock.On("RemoveUser", mock.Anything)
+	userRouterMock.On("ExportUsers", mock.Anything)
+	auditRouterMock.On("VerifyAudit", mock.Anything)
+	tokenRouterMock.On("CreateToken", mock.Anything)
+	tokenRouterMock.On("ListTokens", mock.Anything)
+	tokenRouterMock.On("RevokeToken", mock.Anything)
+	oauthRouterMock.On("Token", mock.Anything)
+	oauthRouterMock.On("CreateClient", mock.Anything)
+	oauthRouterMock.On("ListClients", mock.Anything)
+	oauthRouterMock.On("RotateClientSecret", mock.Anything)
+	oauthRouterMock.On("DisableClient", mock.Anything)
+	oauthRouterMock.On("Authorize", mock.Anything)
+	oauthRouterMock.On("AuthorizeDecision", mock.Anything)
+	oauthRouterMock.On("ListConsents", mock.Anything)
+	oauthRouterMock.On("RevokeConsent", mock.Anything)
+	oauthRouterMock.On("Introspect", mock.Anything)
+	oauthRouterMock.On("Revoke", mock.Anything)
+	oauthRouterMock.On("DeviceAuthorization", mock.Anything)
+	oauthRouterMock.On("DeviceVerification", mock.Anything)
+	oauthRouterMock.On("DeviceDecision", mock.Anything)
+	oidcRouterMock.On("Discovery", mock.Anything)
+	oidcRouterMock.On("JWKS", mock.Anything)
+	oidcRouterMock.On("UserInfo", mock.Anything)
+	oidcRouterMock.On("Logout", mock.Anything)
+	federationRouterMock.On("Login", mock.Anything)
+	federationRouterMock.On("Callback", mock.Anything)
+	federationRouterMock.On("LinkIdentity", mock.Anything)
+	federationRouterMock.On("ListIdentities", mock.Anything)
+	federationRouterMock.On("UnlinkIdentity", mock.Anything)
+	scimRouterMock.On("ListUsers", mock.Anything)
+	scimRouterMock.On("GetUser", mock.Anything)
+	scimRouterMock.On("CreateUser", mock.Anything)
+	scimRouterMock.On("ReplaceUser", mock.Anything)
+	scimRouterMock.On("PatchUser", mock.Anything)
+	scimRouterMock.On("DeleteUser", mock.Anything)
+	passkeyRouterMock.On("BeginRegistration", mock.Anything)
+	passkeyRouterMock.On("FinishRegistration", mock.Anything)
+	passkeyRouterMock.On("ListPasskeys", mock.Anything)
+	passkeyRouterMock.On("RemovePasskey", mock.Anything)
+	passkeyRouterMock.On("UpdateSettings", mock.Anything)
+	passkeyRouterMock.On("BeginLogin", mock.Anything)
+	passkeyRouterMock.On("FinishLogin", mock.Anything)
+	magicLinkRouterMock.On("RequestLink", mock.Anything)
+	magicLinkRouterMock.On("ConsumeLink", mock.Anything)
+	sessionRouterMock.On("ListSessions", mock.Anything)
+	sessionRouterMock.On("RevokeSession", mock.Anything)
+	sessionRouterMock.On("ListUserSessions", mock.Anything)
+	sessionRouterMock.On("RevokeUserSession", mock.Anything)
+	organizationRouterMock.On("CreateOrganization", mock.Anything)
+	organizationRouterMock.On("ListOrganizations", mock.Anything)
+	organizationRouterMock.On("ListMembers", mock.Anything)
+	organizationRouterMock.On("SetMember", mock.Anything)
+	organizationRouterMock.On("RemoveMember", mock.Anything)
+	organizationRouterMock.On("SwitchOrganization", mock.Anything)
+	groupRouterMock.On("CreateGroup", mock.Anything)
+	groupRouterMock.On("ListGroups", mock.Anything)
+	groupRouterMock.On("RenameGroup", mock.Anything)
+	groupRouterMock.On("DeleteGroup", mock.Anything)
+	groupRouterMock.On("ListMembers", mock.Anything)
+	groupRouterMock.On("AddUser", mock.Anything)
+	groupRouterMock.On("RemoveUser", mock.Anything)
+	groupRouterMock.On("AddGroup", mock.Anything)
+	groupRouterMock.On("RemoveGroup", mock.Anything)
+	policyRouterMock.On("Check", mock.Anything)
+	invitationRouterMock.On("CreateInvitation", mock.Anything)
+	invitationRouterMock.On("ListInvitations", mock.Anything)
+	invitationRouterMock.On("ResendInvitation", mock.Anything)
+	invitationRouterMock.On("RevokeInvitation", mock.Anything)
+	invitationRouterMock.On("AcceptInvitation", mock.Anything)
+	attributeRouterMock.On("CreateDefinition", mock.Anything)
+	attributeRouterMock.On("ListDefinitions", mock.Anything)
+	attributeRouterMock.On("UpdateDefinition", mock.Anything)
+	attributeRouterMock.On("DeleteDefinition", mock.Anything)
+	attributeRouterMock.On("SetUserAttributes", mock.Anything)
+	avatarRouterMock.On("UploadAvatar", mock.Anything)
+	avatarRouterMock.On("RemoveAvatar", mock.Anything)
+	avatarRouterMock.On("GetAvatar", mock.Anything)
+	importRouterMock.On("ImportUsers", mock.Anything)
+	importRouterMock.On("ListImports", mock.Anything)
+	importRouterMock.On("GetImport", mock.Anything)
+	privacyRouterMock.On("ExportData", mock.Anything)
+	privacyRouterMock.On("GetErasure", mock.Anything)
+	privacyRouterMock.On("RequestErasure", mock.Anything)
+	privacyRouterMock.On("CancelErasure", mock.Anything)
 	authUsecaseMock.On("ValidateToken", mock.Anything)
 
-	router := router.SetupRouter(userRouterMock, authRouterMock, authUsecaseMock)
+	router := router.SetupRouter(userRouterMock, authRouterMock, auditRouterMock, tokenRouterMock, oauthRouterMock, oidcRouterMock, federationRouterMock, scimRouterMock, passkeyRouterMock, magicLinkRouterMock, sessionRouterMock, organizationRouterMock, groupRouterMock, policyRouterMock, invitationRouterMock, attributeRouterMock, avatarRouterMock, importRouterMock, privacyRouterMock, authUsecaseMock, organizationUsecaseMock, policyUsecaseMock)
 
 	t.Run("GET /", func(t *testing.T) {
 		w := httptest.NewRecorder()
@@ -80,4 +183,700 @@ func TestSetupRouter(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, w.Code)
 	})
+
+	t.Run("GET /api/v1/invitations", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/invitations", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("POST /api/v1/invitations", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		body := bytes.NewBufferString(`{"username":"invitee","email":"invitee@example.com"}`)
+		req, _ := http.NewRequest("POST", "/api/v1/invitations", body)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("POST /api/v1/invitations/:id/resend", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/invitations/1/resend", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("DELETE /api/v1/invitations/:id", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/api/v1/invitations/1", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("POST /api/v1/invitations/accept", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		body := bytes.NewBufferString(`{"token":"gum_inv_token","password":"secret"}`)
+		req, _ := http.NewRequest("POST", "/api/v1/invitations/accept", body)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		invitationRouterMock.AssertCalled(t, "AcceptInvitation", mock.Anything)
+	})
+
+	t.Run("PUT /api/v1/users/:id/attributes", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		body := bytes.NewBufferString(`{"attributes":{"department":"eng"}}`)
+		req, _ := http.NewRequest("PUT", "/api/v1/users/123/attributes", body)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		attributeRouterMock.AssertCalled(t, "SetUserAttributes", mock.Anything)
+	})
+
+	t.Run("GET /api/v1/attributes", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/attributes", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("POST /api/v1/attributes", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		body := bytes.NewBufferString(`{"name":"department","type":"string"}`)
+		req, _ := http.NewRequest("POST", "/api/v1/attributes", body)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("PUT /api/v1/attributes/:id", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		body := bytes.NewBufferString(`{"name":"department","type":"string","required":true}`)
+		req, _ := http.NewRequest("PUT", "/api/v1/attributes/1", body)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("DELETE /api/v1/attributes/:id", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/api/v1/attributes/1", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("PUT /api/v1/me/avatar", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/api/v1/me/avatar", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("DELETE /api/v1/me/avatar", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/api/v1/me/avatar", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("GET /blobs/*key", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/blobs/avatars/100/key/256.png", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		avatarRouterMock.AssertCalled(t, "GetAvatar", mock.Anything)
+	})
+
+	t.Run("GET /api/v1/users/export", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/users/export?format=csv", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		userRouterMock.AssertCalled(t, "ExportUsers", mock.Anything)
+	})
+
+	t.Run("POST /api/v1/users/import", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/users/import?format=csv", bytes.NewBufferString("username,email\n"))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+	})
+
+	t.Run("GET /api/v1/users/import", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/users/import", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		importRouterMock.AssertCalled(t, "ListImports", mock.Anything)
+	})
+
+	t.Run("GET /api/v1/users/import/:importId", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/users/import/1", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("GET /api/v1/me/data-export", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/me/data-export", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		privacyRouterMock.AssertCalled(t, "ExportData", mock.Anything)
+	})
+
+	t.Run("GET /api/v1/me/erasure", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/me/erasure", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("POST /api/v1/me/erasure", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/me/erasure", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("DELETE /api/v1/me/erasure", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/api/v1/me/erasure", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("POST /api/v1/authz/check", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		body := bytes.NewBufferString(`{"subject":{"id":100},"action":"users:delete","resource":{"type":"user","id":101}}`)
+		req, _ := http.NewRequest("POST", "/api/v1/authz/check", body)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("GET /api/v1/audit/verify", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/audit/verify", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("GET /api/v1/me/tokens", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/me/tokens", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("POST /api/v1/me/tokens", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		body := bytes.NewBufferString(`{"name":"ci","scopes":["users:read"]}`)
+		req, _ := http.NewRequest("POST", "/api/v1/me/tokens", body)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("DELETE /api/v1/me/tokens/:id", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/api/v1/me/tokens/1", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("GET /api/v1/clients", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/clients", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("POST /api/v1/clients", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		body := bytes.NewBufferString(`{"name":"ci","scopes":["users:read"]}`)
+		req, _ := http.NewRequest("POST", "/api/v1/clients", body)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("POST /api/v1/clients/:id/secret", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/clients/1/secret", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("DELETE /api/v1/clients/:id", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/api/v1/clients/1", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("POST /oauth/token", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/oauth/token", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("GET /api/v1/me/consents", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/me/consents", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("DELETE /api/v1/me/consents/:id", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/api/v1/me/consents/1", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("GET /oauth/authorize", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/oauth/authorize", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("POST /oauth/authorize", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/oauth/authorize", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("GET /.well-known/openid-configuration", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/.well-known/openid-configuration", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, w.Header().Get("Access-Control-Allow-Origin"), "*")
+	})
+
+	t.Run("GET /.well-known/jwks.json", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/.well-known/jwks.json", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("GET /userinfo", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/userinfo", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("OPTIONS /userinfo", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("OPTIONS", "/userinfo", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, w.Header().Get("Access-Control-Allow-Headers"), "Authorization, Content-Type")
+	})
+
+	t.Run("GET /oauth/logout", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/oauth/logout", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("POST /oauth/introspect", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/oauth/introspect", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("POST /oauth/revoke", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/oauth/revoke", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("GET /api/v1/me/identities", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/me/identities", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("POST /api/v1/me/identities/:provider", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/me/identities/company", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("DELETE /api/v1/me/identities/:id", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/api/v1/me/identities/1", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("GET /api/v1/federation/:provider/login", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/federation/company/login", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("GET /api/v1/federation/:provider/callback", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/federation/company/callback?code=abc&state=xyz", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("GET /scim/v2/Users", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/scim/v2/Users?filter=userName%20eq%20%22alice%22", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("POST /scim/v2/Users", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/scim/v2/Users", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("GET /scim/v2/Users/:id", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/scim/v2/Users/1", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("PUT /scim/v2/Users/:id", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/scim/v2/Users/1", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("PATCH /scim/v2/Users/:id", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PATCH", "/scim/v2/Users/1", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("DELETE /scim/v2/Users/:id", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/scim/v2/Users/1", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("GET /api/v1/me/passkeys", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/me/passkeys", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("POST /api/v1/me/passkeys/register/begin", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/me/passkeys/register/begin", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("POST /api/v1/me/passkeys/register/finish", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/me/passkeys/register/finish", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("PUT /api/v1/me/passkeys/settings", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/api/v1/me/passkeys/settings", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("DELETE /api/v1/me/passkeys/:id", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/api/v1/me/passkeys/1", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("POST /api/v1/passkeys/login/begin", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/passkeys/login/begin", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("POST /api/v1/passkeys/login/finish", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/passkeys/login/finish", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("POST /api/v1/login/magic", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/login/magic", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("GET /api/v1/login/magic/consume", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/login/magic/consume?token=gum_ml_token", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("POST /oauth/device/code", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/oauth/device/code", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("GET /oauth/device", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/oauth/device", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("POST /oauth/device", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/oauth/device", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("POST /api/v1/logout", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/logout", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("GET /api/v1/me/sessions", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/me/sessions", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("DELETE /api/v1/me/sessions/1", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/api/v1/me/sessions/1", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("GET /api/v1/users/1/sessions", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/users/1/sessions", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("DELETE /api/v1/users/1/sessions/1", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/api/v1/users/1/sessions/1", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("GET /api/v1/organizations", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/organizations", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("POST /api/v1/organizations", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/organizations", bytes.NewBufferString("{}"))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("POST /api/v1/organizations/1/switch", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/organizations/1/switch", bytes.NewBufferString("{}"))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("GET /api/v1/organizations/1/members", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/organizations/1/members", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("PUT /api/v1/organizations/1/members/1", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/api/v1/organizations/1/members/1", bytes.NewBufferString("{}"))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("DELETE /api/v1/organizations/1/members/1", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/api/v1/organizations/1/members/1", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("GET /api/v1/groups", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/groups", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("POST /api/v1/groups", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/groups", bytes.NewBufferString("{}"))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("PUT /api/v1/groups/1", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/api/v1/groups/1", bytes.NewBufferString("{}"))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("DELETE /api/v1/groups/1", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/api/v1/groups/1", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("GET /api/v1/groups/1/members", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/groups/1/members", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("PUT /api/v1/groups/1/members/users/1", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/api/v1/groups/1/members/users/1", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("DELETE /api/v1/groups/1/members/users/1", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/api/v1/groups/1/members/users/1", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("PUT /api/v1/groups/1/members/groups/2", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/api/v1/groups/1/members/groups/2", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("DELETE /api/v1/groups/1/members/groups/2", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/api/v1/groups/1/members/groups/2", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
 }
@@ -0,0 +1,166 @@
+package router
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const scimContentType = "application/scim+json"
+
+var scimTypes = []error{usecase.ErrSCIMInvalidFilter, usecase.ErrSCIMInvalidSyntax, usecase.ErrSCIMInvalidValue, usecase.ErrSCIMNoTarget, usecase.ErrSCIMUniqueness}
+
+type SCIMRouter interface {
+	ListUsers(c *gin.Context)
+	GetUser(c *gin.Context)
+	CreateUser(c *gin.Context)
+	ReplaceUser(c *gin.Context)
+	PatchUser(c *gin.Context)
+	DeleteUser(c *gin.Context)
+}
+
+type SCIMRouterImpl struct {
+	scimUsecase usecase.SCIMUsecase
+}
+
+func NewSCIMRouterImpl(scimUsecase usecase.SCIMUsecase) SCIMRouter {
+	return &SCIMRouterImpl{
+		scimUsecase: scimUsecase,
+	}
+}
+
+func writeSCIM(c *gin.Context, status int, body interface{}) {
+	c.Header("Content-Type", scimContentType)
+	c.JSON(status, body)
+}
+
+func writeSCIMUser(c *gin.Context, status int, user *repository.SCIMUser) {
+	c.Header("ETag", user.Meta.Version)
+	writeSCIM(c, status, user)
+}
+
+func writeSCIMError(c *gin.Context, scimError *helper.StandardError) {
+	response := repository.SCIMError{
+		Schemas: []string{repository.SCIMErrorSchema},
+		Status:  strconv.Itoa(int(scimError.ErrorCode)),
+		Detail:  scimError.Error.Error(),
+	}
+	for _, scimType := range scimTypes {
+		if errors.Is(scimError.Error, scimType) {
+			response.ScimType = scimType.Error()
+		}
+	}
+
+	writeSCIM(c, int(scimError.ErrorCode), response)
+}
+
+func invalidSCIMRequest(c *gin.Context, err error) {
+	writeSCIMError(c, &helper.StandardError{Error: fmt.Errorf("%w: %s", usecase.ErrSCIMInvalidSyntax, err), ErrorCode: http.StatusBadRequest})
+}
+
+func (t *SCIMRouterImpl) ListUsers(c *gin.Context) {
+	var listRequest repository.SCIMListRequest
+
+	if err := c.ShouldBindQuery(&listRequest); err != nil {
+		invalidSCIMRequest(c, err)
+		return
+	}
+
+	users, err := t.scimUsecase.ListUsers(listRequest)
+
+	if err != nil && err.Error != nil {
+		writeSCIMError(c, err)
+		return
+	}
+
+	writeSCIM(c, http.StatusOK, users)
+}
+
+func (t *SCIMRouterImpl) GetUser(c *gin.Context) {
+	user, err := t.scimUsecase.GetUser(c.Param("id"))
+
+	if err != nil && err.Error != nil {
+		writeSCIMError(c, err)
+		return
+	}
+
+	if c.GetHeader("If-None-Match") == user.Meta.Version {
+		c.Header("ETag", user.Meta.Version)
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	writeSCIMUser(c, http.StatusOK, user)
+}
+
+func (t *SCIMRouterImpl) CreateUser(c *gin.Context) {
+	var resource repository.SCIMUser
+
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		invalidSCIMRequest(c, err)
+		return
+	}
+
+	user, err := t.scimUsecase.CreateUser(resource)
+
+	if err != nil && err.Error != nil {
+		writeSCIMError(c, err)
+		return
+	}
+
+	c.Header("Location", user.Meta.Location)
+	writeSCIMUser(c, http.StatusCreated, user)
+}
+
+func (t *SCIMRouterImpl) ReplaceUser(c *gin.Context) {
+	var resource repository.SCIMUser
+
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		invalidSCIMRequest(c, err)
+		return
+	}
+
+	user, err := t.scimUsecase.ReplaceUser(c.Param("id"), resource, c.GetHeader("If-Match"))
+
+	if err != nil && err.Error != nil {
+		writeSCIMError(c, err)
+		return
+	}
+
+	writeSCIMUser(c, http.StatusOK, user)
+}
+
+func (t *SCIMRouterImpl) PatchUser(c *gin.Context) {
+	var patchRequest repository.SCIMPatchRequest
+
+	if err := c.ShouldBindJSON(&patchRequest); err != nil {
+		invalidSCIMRequest(c, err)
+		return
+	}
+
+	user, err := t.scimUsecase.PatchUser(c.Param("id"), patchRequest, c.GetHeader("If-Match"))
+
+	if err != nil && err.Error != nil {
+		writeSCIMError(c, err)
+		return
+	}
+
+	writeSCIMUser(c, http.StatusOK, user)
+}
+
+func (t *SCIMRouterImpl) DeleteUser(c *gin.Context) {
+	err := t.scimUsecase.DeleteUser(c.Param("id"), c.GetHeader("If-Match"))
+
+	if err != nil && err.Error != nil {
+		writeSCIMError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,220 @@
+package router_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/router"
+	"andikawhy/go-user-management/usecase"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/stretchr/testify/mock"
+)
+
+func mockSCIMUser() *repository.SCIMUser {
+	active := true
+	return &repository.SCIMUser{
+		Schemas:  []string{repository.SCIMUserSchema},
+		ID:       "100",
+		UserName: "username",
+		Active:   &active,
+		Meta:     &repository.SCIMMeta{ResourceType: "User", Location: "http://localhost:3000/scim/v2/Users/100", Version: `W/"abc"`},
+	}
+}
+
+func scimEngine(scimRouter router.SCIMRouter) *gin.Engine {
+	engine := gin.Default()
+	engine.GET("/scim/v2/Users", scimRouter.ListUsers)
+	engine.POST("/scim/v2/Users", scimRouter.CreateUser)
+	engine.GET("/scim/v2/Users/:id", scimRouter.GetUser)
+	engine.PATCH("/scim/v2/Users/:id", scimRouter.PatchUser)
+	engine.DELETE("/scim/v2/Users/:id", scimRouter.DeleteUser)
+	return engine
+}
+
+func TestSCIMListUsers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockSCIMUsecase := new(mocks.SCIMUsecaseMock)
+		count := 10
+		mockSCIMUsecase.On("ListUsers", repository.SCIMListRequest{Filter: `userName eq "username"`, StartIndex: 1, Count: &count}).Return(&repository.SCIMListResponse{
+			Schemas:      []string{repository.SCIMListResponseSchema},
+			TotalResults: 1,
+			StartIndex:   1,
+			ItemsPerPage: 1,
+			Resources:    []repository.SCIMUser{*mockSCIMUser()},
+		}, (*helper.StandardError)(nil))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/scim/v2/Users?filter=userName+eq+%22username%22&startIndex=1&count=10", nil)
+		scimEngine(router.NewSCIMRouterImpl(mockSCIMUsecase)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, w.Header().Get("Content-Type"), "application/scim+json")
+		assert.MatchRegex(t, w.Body.String(), `"totalResults":1`)
+		assert.MatchRegex(t, w.Body.String(), `"Resources":\[\{`)
+	})
+
+	t.Run("Invalid filter", func(t *testing.T) {
+		mockSCIMUsecase := new(mocks.SCIMUsecaseMock)
+		mockSCIMUsecase.On("ListUsers", mock.Anything).Return((*repository.SCIMListResponse)(nil), &helper.StandardError{Error: fmt.Errorf("%w: unsupported operator zz", usecase.ErrSCIMInvalidFilter), ErrorCode: http.StatusBadRequest})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/scim/v2/Users?filter=userName+zz+%22username%22", nil)
+		scimEngine(router.NewSCIMRouterImpl(mockSCIMUsecase)).ServeHTTP(w, req)
+
+		var scimError repository.SCIMError
+		json.Unmarshal(w.Body.Bytes(), &scimError)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, scimError, repository.SCIMError{
+			Schemas:  []string{repository.SCIMErrorSchema},
+			Status:   "400",
+			ScimType: "invalidFilter",
+			Detail:   "invalidFilter: unsupported operator zz",
+		})
+	})
+
+	t.Run("Invalid count", func(t *testing.T) {
+		mockSCIMUsecase := new(mocks.SCIMUsecaseMock)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/scim/v2/Users?count=ten", nil)
+		scimEngine(router.NewSCIMRouterImpl(mockSCIMUsecase)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.MatchRegex(t, w.Body.String(), `"scimType":"invalidSyntax"`)
+		mockSCIMUsecase.AssertNotCalled(t, "ListUsers", mock.Anything)
+	})
+}
+
+func TestSCIMCreateUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockSCIMUsecase := new(mocks.SCIMUsecaseMock)
+		mockSCIMUsecase.On("CreateUser", repository.SCIMUser{Schemas: []string{repository.SCIMUserSchema}, UserName: "username"}).Return(mockSCIMUser(), (*helper.StandardError)(nil))
+
+		body := []byte(`{"schemas":["urn:ietf:params:scim:schemas:core:2.0:User"],"userName":"username"}`)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/scim/v2/Users", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/scim+json")
+		scimEngine(router.NewSCIMRouterImpl(mockSCIMUsecase)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, w.Header().Get("Location"), "http://localhost:3000/scim/v2/Users/100")
+		assert.Equal(t, w.Header().Get("ETag"), `W/"abc"`)
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		mockSCIMUsecase := new(mocks.SCIMUsecaseMock)
+		mockSCIMUsecase.On("CreateUser", mock.Anything).Return((*repository.SCIMUser)(nil), &helper.StandardError{Error: fmt.Errorf("%w: user username already exists", usecase.ErrSCIMUniqueness), ErrorCode: http.StatusConflict})
+
+		body := []byte(`{"userName":"username"}`)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/scim/v2/Users", bytes.NewBuffer(body))
+		scimEngine(router.NewSCIMRouterImpl(mockSCIMUsecase)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.MatchRegex(t, w.Body.String(), `"scimType":"uniqueness"`)
+	})
+}
+
+func TestSCIMGetUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockSCIMUsecase := new(mocks.SCIMUsecaseMock)
+		mockSCIMUsecase.On("GetUser", "100").Return(mockSCIMUser(), (*helper.StandardError)(nil))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/scim/v2/Users/100", nil)
+		scimEngine(router.NewSCIMRouterImpl(mockSCIMUsecase)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), `"userName":"username"`)
+	})
+
+	t.Run("Not modified", func(t *testing.T) {
+		mockSCIMUsecase := new(mocks.SCIMUsecaseMock)
+		mockSCIMUsecase.On("GetUser", "100").Return(mockSCIMUser(), (*helper.StandardError)(nil))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/scim/v2/Users/100", nil)
+		req.Header.Set("If-None-Match", `W/"abc"`)
+		scimEngine(router.NewSCIMRouterImpl(mockSCIMUsecase)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Equal(t, w.Body.String(), "")
+	})
+}
+
+func TestSCIMPatchUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockSCIMUsecase := new(mocks.SCIMUsecaseMock)
+		expectedPatch := repository.SCIMPatchRequest{
+			Schemas:    []string{repository.SCIMPatchOperationSchema},
+			Operations: []repository.SCIMPatchOperation{{Op: "replace", Path: "active", Value: false}},
+		}
+		mockSCIMUsecase.On("PatchUser", "100", expectedPatch, `W/"abc"`).Return(mockSCIMUser(), (*helper.StandardError)(nil))
+
+		body := []byte(`{"schemas":["urn:ietf:params:scim:api:messages:2.0:PatchOp"],"Operations":[{"op":"replace","path":"active","value":false}]}`)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPatch, "/scim/v2/Users/100", bytes.NewBuffer(body))
+		req.Header.Set("If-Match", `W/"abc"`)
+		scimEngine(router.NewSCIMRouterImpl(mockSCIMUsecase)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Precondition failed", func(t *testing.T) {
+		mockSCIMUsecase := new(mocks.SCIMUsecaseMock)
+		mockSCIMUsecase.On("PatchUser", mock.Anything, mock.Anything, mock.Anything).Return((*repository.SCIMUser)(nil), &helper.StandardError{Error: fmt.Errorf("resource version mismatch"), ErrorCode: http.StatusPreconditionFailed})
+
+		body := []byte(`{"Operations":[{"op":"replace","path":"active","value":false}]}`)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPatch, "/scim/v2/Users/100", bytes.NewBuffer(body))
+		req.Header.Set("If-Match", `W/"stale"`)
+		scimEngine(router.NewSCIMRouterImpl(mockSCIMUsecase)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		assert.MatchRegex(t, w.Body.String(), `"status":"412"`)
+	})
+}
+
+func TestSCIMDeleteUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockSCIMUsecase := new(mocks.SCIMUsecaseMock)
+		mockSCIMUsecase.On("DeleteUser", "100", "").Return((*helper.StandardError)(nil))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/scim/v2/Users/100", nil)
+		scimEngine(router.NewSCIMRouterImpl(mockSCIMUsecase)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		mockSCIMUsecase := new(mocks.SCIMUsecaseMock)
+		mockSCIMUsecase.On("DeleteUser", "999", "").Return(&helper.StandardError{Error: fmt.Errorf("user 999 not found"), ErrorCode: http.StatusNotFound})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/scim/v2/Users/999", nil)
+		scimEngine(router.NewSCIMRouterImpl(mockSCIMUsecase)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.MatchRegex(t, w.Body.String(), `"detail":"user 999 not found"`)
+	})
+}
@@ -0,0 +1,119 @@
+package router
+
+import (
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionRouter interface {
+	ListSessions(c *gin.Context)
+	RevokeSession(c *gin.Context)
+	ListUserSessions(c *gin.Context)
+	RevokeUserSession(c *gin.Context)
+}
+
+type SessionRouterImpl struct {
+	sessionUsecase usecase.SessionUsecase
+}
+
+func NewSessionRouterImpl(sessionUsecase usecase.SessionUsecase) SessionRouter {
+	return &SessionRouterImpl{
+		sessionUsecase: sessionUsecase,
+	}
+}
+
+func requestLoginContext(c *gin.Context) repository.LoginContext {
+	return repository.LoginContext{UserAgent: c.Request.UserAgent(), IPAddress: c.ClientIP(), OrganizationID: getCurrentOrganizationId(c)}
+}
+
+func getCurrentSessionId(c *gin.Context) uint64 {
+	sessionId, _ := c.Get("currentSessionId")
+	currentSessionId, _ := sessionId.(uint64)
+	return currentSessionId
+}
+
+func (t *SessionRouterImpl) ListSessions(c *gin.Context) {
+	currentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := t.sessionUsecase.ListSessions(currentUserId, getCurrentSessionId(c))
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": sessions, "message": "successfully list sessions"})
+}
+
+func (t *SessionRouterImpl) RevokeSession(c *gin.Context) {
+	sessionId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to convert requested session ID"})
+		return
+	}
+
+	currentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	revokeError := t.sessionUsecase.RevokeSession(currentUserId, sessionId, currentUserId)
+
+	if revokeError != nil && revokeError.Error != nil {
+		c.JSON(int(revokeError.ErrorCode), gin.H{"error": revokeError.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "successfully revoke session"})
+}
+
+func (t *SessionRouterImpl) ListUserSessions(c *gin.Context) {
+	userId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to convert requested user ID"})
+		return
+	}
+
+	sessions, listError := t.sessionUsecase.ListUserSessions(getCurrentOrganizationId(c), userId)
+
+	if listError != nil && listError.Error != nil {
+		c.JSON(int(listError.ErrorCode), gin.H{"error": listError.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": sessions, "message": "successfully list sessions"})
+}
+
+func (t *SessionRouterImpl) RevokeUserSession(c *gin.Context) {
+	userId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to convert requested user ID"})
+		return
+	}
+
+	sessionId, err := strconv.ParseUint(c.Param("sessionId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to convert requested session ID"})
+		return
+	}
+
+	// Admin requests may come from a client credentials token, which has no current user.
+	actorId, _ := c.Get("currentUserId")
+	currentUserId, _ := actorId.(uint64)
+
+	revokeError := t.sessionUsecase.RevokeUserSession(getCurrentOrganizationId(c), userId, sessionId, currentUserId)
+
+	if revokeError != nil && revokeError.Error != nil {
+		c.JSON(int(revokeError.ErrorCode), gin.H{"error": revokeError.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "successfully revoke session"})
+}
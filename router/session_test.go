@@ -0,0 +1,130 @@
+package router_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/router"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestListSessions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockSessionUsecase := new(mocks.SessionUsecaseMock)
+		sessionRouter := router.NewSessionRouterImpl(mockSessionUsecase)
+
+		mockError := &helper.StandardError{Error: nil, ErrorCode: http.StatusOK}
+		mockSessionUsecase.On("ListSessions", uint64(100), uint64(7)).Return(&[]repository.Session{{ID: 7, UserID: 100, Device: "Chrome on macOS", Current: true}}, mockError)
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.GET("/me/sessions", func(c *gin.Context) {
+			c.Set("currentSessionId", uint64(7))
+		}, sessionRouter.ListSessions)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/me/sessions", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), `"device":"Chrome on macOS"`)
+		assert.MatchRegex(t, w.Body.String(), `"current":true`)
+	})
+
+	t.Run("Admin", func(t *testing.T) {
+		mockSessionUsecase := new(mocks.SessionUsecaseMock)
+		sessionRouter := router.NewSessionRouterImpl(mockSessionUsecase)
+
+		mockError := &helper.StandardError{Error: nil, ErrorCode: http.StatusOK}
+		mockSessionUsecase.On("ListUserSessions", uint64(1), uint64(5)).Return(&[]repository.Session{}, mockError)
+
+		router := gin.Default()
+		router.GET("/users/:id/sessions", sessionRouter.ListUserSessions)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/users/5/sessions", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "successfully list sessions")
+	})
+}
+
+func TestRevokeSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockSessionUsecase := new(mocks.SessionUsecaseMock)
+		sessionRouter := router.NewSessionRouterImpl(mockSessionUsecase)
+
+		mockSessionUsecase.On("RevokeSession", uint64(100), uint64(3), uint64(100)).Return((*helper.StandardError)(nil))
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.DELETE("/me/sessions/:id", sessionRouter.RevokeSession)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/me/sessions/3", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "successfully revoke session")
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		mockSessionUsecase := new(mocks.SessionUsecaseMock)
+		sessionRouter := router.NewSessionRouterImpl(mockSessionUsecase)
+
+		mockError := &helper.StandardError{Error: errors.New("session not found"), ErrorCode: http.StatusNotFound}
+		mockSessionUsecase.On("RevokeSession", uint64(100), uint64(3), uint64(100)).Return(mockError)
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.DELETE("/me/sessions/:id", sessionRouter.RevokeSession)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/me/sessions/3", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Invalid ID", func(t *testing.T) {
+		sessionRouter := router.NewSessionRouterImpl(nil)
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.DELETE("/me/sessions/:id", sessionRouter.RevokeSession)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/me/sessions/abc", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Admin", func(t *testing.T) {
+		mockSessionUsecase := new(mocks.SessionUsecaseMock)
+		sessionRouter := router.NewSessionRouterImpl(mockSessionUsecase)
+
+		mockSessionUsecase.On("RevokeUserSession", uint64(1), uint64(5), uint64(3), uint64(100)).Return((*helper.StandardError)(nil))
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.DELETE("/users/:id/sessions/:sessionId", sessionRouter.RevokeUserSession)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/users/5/sessions/3", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
@@ -0,0 +1,96 @@
+package router
+
+import (
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TokenRouter interface {
+	CreateToken(c *gin.Context)
+	ListTokens(c *gin.Context)
+	RevokeToken(c *gin.Context)
+}
+
+type TokenRouterImpl struct {
+	tokenUsecase usecase.TokenUsecase
+}
+
+func NewTokenRouterImpl(tokenUsecase usecase.TokenUsecase) TokenRouter {
+	return &TokenRouterImpl{
+		tokenUsecase: tokenUsecase,
+	}
+}
+
+func (t *TokenRouterImpl) CreateToken(c *gin.Context) {
+	var createTokenData repository.CreateToken
+
+	if err := c.ShouldBindJSON(&createTokenData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	currentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	// Login tokens carry no scopes and may grant any; a scoped token is restricted to its own, even when it has none.
+	var callerScopes []string
+	if currentScopes, restricted := c.Get("currentScopes"); restricted {
+		callerScopes, _ = currentScopes.([]string)
+		if callerScopes == nil {
+			callerScopes = []string{}
+		}
+	}
+
+	token, createTokenError := t.tokenUsecase.CreateToken(currentUserId, callerScopes, createTokenData)
+
+	if createTokenError != nil && createTokenError.Error != nil {
+		c.JSON(int(createTokenError.ErrorCode), gin.H{"error": createTokenError.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": token, "message": "successfully create token, it will not be shown again"})
+}
+
+func (t *TokenRouterImpl) ListTokens(c *gin.Context) {
+	currentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	tokens, err := t.tokenUsecase.ListTokens(currentUserId)
+
+	if err != nil && err.Error != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": tokens, "message": "successfully list tokens"})
+}
+
+func (t *TokenRouterImpl) RevokeToken(c *gin.Context) {
+	tokenId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to convert requested token ID"})
+		return
+	}
+
+	currentUserId, ok := getCurrentUserId(c)
+	if !ok {
+		return
+	}
+
+	token, revokeTokenError := t.tokenUsecase.RevokeToken(currentUserId, tokenId)
+
+	if revokeTokenError != nil && revokeTokenError.Error != nil {
+		c.JSON(int(revokeTokenError.ErrorCode), gin.H{"error": revokeTokenError.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": token, "message": "successfully revoke token"})
+}
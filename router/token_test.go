@@ -0,0 +1,186 @@
+package router_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/router"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/stretchr/testify/mock"
+)
+
+var mockToken = repository.PersonalAccessToken{
+	ID:     1,
+	UserID: 100,
+	Name:   "ci",
+	Prefix: "gum_pat_abcd",
+	Scopes: "users:read",
+}
+
+func withCurrentUser(c *gin.Context) {
+	c.Set("currentUserId", uint64(100))
+	c.Next()
+}
+
+func TestCreateToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockTokenUsecase := new(mocks.TokenUsecaseMock)
+		tokenRouter := router.NewTokenRouterImpl(mockTokenUsecase)
+
+		mockError := &helper.StandardError{Error: nil, ErrorCode: http.StatusOK}
+
+		mockTokenUsecase.On("CreateToken", []string(nil)).Return(&repository.CreatedTokenResponse{PersonalAccessToken: mockToken, Token: "gum_pat_abcdefgh"}, mockError)
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.POST("/me/tokens", tokenRouter.CreateToken)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/me/tokens", strings.NewReader(`{"name": "ci", "scopes": ["users:read"]}`))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "gum_pat_abcdefgh")
+	})
+
+	t.Run("Scoped token passes its scopes", func(t *testing.T) {
+		mockTokenUsecase := new(mocks.TokenUsecaseMock)
+		tokenRouter := router.NewTokenRouterImpl(mockTokenUsecase)
+
+		mockError := &helper.StandardError{Error: nil, ErrorCode: http.StatusOK}
+
+		mockTokenUsecase.On("CreateToken", []string{"tokens"}).Return(&repository.CreatedTokenResponse{PersonalAccessToken: mockToken, Token: "gum_pat_abcdefgh"}, mockError)
+
+		router := gin.Default()
+		router.Use(withCurrentUser, func(c *gin.Context) {
+			c.Set("currentScopes", []string{"tokens"})
+			c.Next()
+		})
+		router.POST("/me/tokens", tokenRouter.CreateToken)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/me/tokens", strings.NewReader(`{"name": "ci", "scopes": ["tokens"]}`))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockTokenUsecase.AssertCalled(t, "CreateToken", []string{"tokens"})
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockTokenUsecase := new(mocks.TokenUsecaseMock)
+		tokenRouter := router.NewTokenRouterImpl(mockTokenUsecase)
+
+		mockError := &helper.StandardError{Error: errors.New("error message"), ErrorCode: http.StatusBadRequest}
+
+		mockTokenUsecase.On("CreateToken", mock.Anything).Return(&repository.CreatedTokenResponse{}, mockError)
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.POST("/me/tokens", tokenRouter.CreateToken)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/me/tokens", strings.NewReader(`{"name": "ci", "scopes": ["users:read"]}`))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "error message")
+	})
+
+	t.Run("Bind JSON Error", func(t *testing.T) {
+		tokenRouter := router.NewTokenRouterImpl(nil)
+
+		router := gin.Default()
+		router.POST("/me/tokens", tokenRouter.CreateToken)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/me/tokens", strings.NewReader(``))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestListTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockTokenUsecase := new(mocks.TokenUsecaseMock)
+		tokenRouter := router.NewTokenRouterImpl(mockTokenUsecase)
+
+		mockError := &helper.StandardError{Error: nil, ErrorCode: http.StatusOK}
+
+		mockTokenUsecase.On("ListTokens").Return(&[]repository.PersonalAccessToken{mockToken}, mockError)
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.GET("/me/tokens", tokenRouter.ListTokens)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/me/tokens", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "successfully list tokens")
+	})
+
+	t.Run("Current user id context not found", func(t *testing.T) {
+		tokenRouter := router.NewTokenRouterImpl(nil)
+
+		router := gin.Default()
+		router.GET("/me/tokens", tokenRouter.ListTokens)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/me/tokens", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "current user not found")
+	})
+}
+
+func TestRevokeToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockTokenUsecase := new(mocks.TokenUsecaseMock)
+		tokenRouter := router.NewTokenRouterImpl(mockTokenUsecase)
+
+		mockError := &helper.StandardError{Error: nil, ErrorCode: http.StatusOK}
+
+		mockTokenUsecase.On("RevokeToken").Return(&mockToken, mockError)
+
+		router := gin.Default()
+		router.Use(withCurrentUser)
+		router.DELETE("/me/tokens/:id", tokenRouter.RevokeToken)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/me/tokens/1", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "successfully revoke token")
+	})
+
+	t.Run("Requested Token ID Conversion Fail", func(t *testing.T) {
+		tokenRouter := router.NewTokenRouterImpl(nil)
+
+		router := gin.Default()
+		router.DELETE("/me/tokens/:id", tokenRouter.RevokeToken)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/me/tokens/abc", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "Failed to convert requested token ID")
+	})
+}
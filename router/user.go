@@ -3,6 +3,8 @@ package router
 import (
 	"andikawhy/go-user-management/repository"
 	"andikawhy/go-user-management/usecase"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 
@@ -13,6 +15,13 @@ type UserRouter interface {
 	CreateUser(c *gin.Context)
 	RemoveUser(c *gin.Context)
 	ListUsers(c *gin.Context)
+	ExportUsers(c *gin.Context)
+}
+
+var exportContentTypes = map[string]string{
+	repository.ExportFormatCSV:    "text/csv; charset=utf-8",
+	repository.ExportFormatNDJSON: "application/x-ndjson",
+	repository.ExportFormatXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
 }
 
 type UserRouterImpl struct {
@@ -35,7 +44,7 @@ func (t *UserRouterImpl) CreateUser(c *gin.Context) {
 		return
 	}
 
-	user, registerError := t.authUsecase.Register(createUserData)
+	user, registerError := t.authUsecase.Register(createUserData, getCurrentOrganizationId(c))
 
 	if registerError != nil && registerError.Error != nil {
 		c.JSON(int(registerError.ErrorCode), gin.H{"error": registerError.Error.Error()})
@@ -54,19 +63,12 @@ func (t *UserRouterImpl) RemoveUser(c *gin.Context) {
 		return
 	}
 
-	currentUserId, exists := c.Get("currentUserId")
-	if !exists {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "current user not found"})
-		return
-	}
-
-	currentUserIdInt, ok := currentUserId.(uint64)
+	currentUserIdInt, ok := getCurrentUserId(c)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to convert current user ID"})
 		return
 	}
 
-	user, removeUserError := t.userUsecase.RemoveUser(userIDInt, currentUserIdInt)
+	user, removeUserError := t.userUsecase.RemoveUser(getCurrentOrganizationId(c), userIDInt, currentUserIdInt)
 
 	if removeUserError != nil && removeUserError.Error != nil {
 		c.JSON(int(removeUserError.ErrorCode), gin.H{"error": removeUserError.Error.Error()})
@@ -77,7 +79,8 @@ func (t *UserRouterImpl) RemoveUser(c *gin.Context) {
 }
 
 func (t *UserRouterImpl) ListUsers(c *gin.Context) {
-	users, err := t.userUsecase.ListUsers()
+	// Attribute filters are passed as ?attributes[department]=engineering.
+	users, err := t.userUsecase.ListUsers(getCurrentOrganizationId(c), c.QueryMap("attributes"))
 
 	if err != nil && err.Error != nil {
 		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
@@ -86,3 +89,34 @@ func (t *UserRouterImpl) ListUsers(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"data": users, "message": "successfully list users"})
 }
+
+// ExportUsers streams the export as the response body, taking the same attribute filters as ListUsers.
+func (t *UserRouterImpl) ExportUsers(c *gin.Context) {
+	var exportData repository.UserExport
+
+	if err := c.ShouldBindQuery(&exportData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if exportData.Format == "" {
+		exportData.Format = repository.ExportFormatCSV
+	}
+
+	c.Header("Content-Type", exportContentTypes[exportData.Format])
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="users.%s"`, exportData.Format))
+	c.Status(http.StatusOK)
+
+	exportError := t.userUsecase.ExportUsers(getCurrentOrganizationId(c), exportData, c.QueryMap("attributes"), c.Writer, getActorId(c))
+
+	if exportError != nil && exportError.Error != nil {
+		// Once rows are sent the status can no longer change, so the download just ends early.
+		if c.Writer.Written() {
+			log.Printf("Failed to export users: %v", exportError.Error)
+			c.Abort()
+			return
+		}
+		c.Header("Content-Type", "")
+		c.Header("Content-Disposition", "")
+		c.JSON(int(exportError.ErrorCode), gin.H{"error": exportError.Error.Error()})
+	}
+}
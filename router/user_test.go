@@ -6,6 +6,7 @@ import (
 	"andikawhy/go-user-management/repository"
 	"andikawhy/go-user-management/router"
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/assert/v2"
+	"github.com/stretchr/testify/mock"
 )
 
 var mockUser = repository.UserResponse{
@@ -230,3 +232,64 @@ func TestCreateUser(t *testing.T) {
 	})
 
 }
+
+func TestExportUsers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Success", func(t *testing.T) {
+		mockUserUsecase := new(mocks.UserUsecaseMock)
+		userRouter := router.NewUserRouterImpl(mockUserUsecase, nil)
+
+		exportData := repository.UserExport{Format: repository.ExportFormatNDJSON, Columns: "id,username"}
+		mockUserUsecase.On("ExportUsers", uint64(1), exportData, map[string]string{"department": "eng"}, mock.Anything, uint64(0)).
+			Run(func(args mock.Arguments) {
+				io.WriteString(args.Get(3).(io.Writer), `{"id":1,"username":"alice"}`+"\n")
+			}).
+			Return((*helper.StandardError)(nil))
+
+		router := gin.Default()
+		router.GET("/users/export", userRouter.ExportUsers)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/users/export?format=ndjson&columns=id,username&attributes[department]=eng", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, w.Header().Get("Content-Type"), "application/x-ndjson")
+		assert.Equal(t, w.Header().Get("Content-Disposition"), `attachment; filename="users.ndjson"`)
+		assert.Equal(t, w.Body.String(), `{"id":1,"username":"alice"}`+"\n")
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockUserUsecase := new(mocks.UserUsecaseMock)
+		userRouter := router.NewUserRouterImpl(mockUserUsecase, nil)
+
+		mockUserUsecase.On("ExportUsers", uint64(1), repository.UserExport{Format: repository.ExportFormatCSV, Columns: "password"}, map[string]string{}, mock.Anything, uint64(0)).
+			Return(&helper.StandardError{Error: errors.New("unknown column password"), ErrorCode: http.StatusBadRequest})
+
+		router := gin.Default()
+		router.GET("/users/export", userRouter.ExportUsers)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/users/export?columns=password", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, w.Header().Get("Content-Disposition"), "")
+		assert.MatchRegex(t, w.Header().Get("Content-Type"), "application/json")
+		assert.MatchRegex(t, w.Body.String(), "unknown column password")
+	})
+
+	t.Run("Invalid format", func(t *testing.T) {
+		userRouter := router.NewUserRouterImpl(nil, nil)
+
+		router := gin.Default()
+		router.GET("/users/export", userRouter.ExportUsers)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/users/export?format=pdf", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
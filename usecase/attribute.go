@@ -0,0 +1,267 @@
+package usecase
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+)
+
+type AttributeUsecase interface {
+	CreateDefinition(organizationId uint64, definitionData repository.SaveAttributeDefinition, actorId uint64) (*repository.AttributeDefinition, *helper.StandardError)
+	ListDefinitions(organizationId uint64) (*[]repository.AttributeDefinition, *helper.StandardError)
+	UpdateDefinition(organizationId uint64, definitionId uint64, definitionData repository.SaveAttributeDefinition, actorId uint64) (*repository.AttributeDefinition, *helper.StandardError)
+	DeleteDefinition(organizationId uint64, definitionId uint64, actorId uint64) (*repository.AttributeDefinition, *helper.StandardError)
+	SetUserAttributes(organizationId uint64, userId uint64, attributes repository.UserAttributes, actorId uint64) (*repository.UserResponse, *helper.StandardError)
+}
+
+type AttributeUsecaseImpl struct {
+	AttributeRepository repository.AttributeRepository
+	UserRepository      repository.UserRepository
+	AuditUsecase        AuditUsecase
+}
+
+var (
+	errAttributeNotFound = &helper.StandardError{Error: errors.New("attribute not found"), ErrorCode: http.StatusNotFound}
+	errAttributeExists   = &helper.StandardError{Error: errors.New("attribute already exists"), ErrorCode: http.StatusConflict}
+	errAttributeRepeated = &helper.StandardError{Error: errors.New("stored values of the attribute are not unique"), ErrorCode: http.StatusConflict}
+
+	attributeNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+)
+
+func invalidAttribute(format string, args ...interface{}) *helper.StandardError {
+	return &helper.StandardError{Error: fmt.Errorf(format, args...), ErrorCode: http.StatusBadRequest}
+}
+
+func checkDefinition(definitionData repository.SaveAttributeDefinition) *helper.StandardError {
+	if !attributeNamePattern.MatchString(definitionData.Name) {
+		return invalidAttribute("attribute name must start with a lowercase letter and contain only lowercase letters, digits and underscores")
+	}
+	if definitionData.Type != repository.AttributeTypeString && (definitionData.Pattern != "" || len(definitionData.Enum) != 0) {
+		return invalidAttribute("pattern and enum only apply to string attributes")
+	}
+	if _, err := regexp.Compile(definitionData.Pattern); err != nil {
+		return invalidAttribute("invalid pattern: %v", err)
+	}
+	return nil
+}
+
+// attributeString is the form attribute values are compared in, both for uniqueness and for list filters.
+func attributeString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(value)
+}
+
+func checkAttributeValue(definition repository.AttributeDefinition, value interface{}) *helper.StandardError {
+	switch definition.Type {
+	case repository.AttributeTypeNumber:
+		if _, ok := value.(float64); !ok {
+			return invalidAttribute("attribute %s must be a number", definition.Name)
+		}
+	case repository.AttributeTypeBoolean:
+		if _, ok := value.(bool); !ok {
+			return invalidAttribute("attribute %s must be a boolean", definition.Name)
+		}
+	default:
+		text, ok := value.(string)
+		if !ok {
+			return invalidAttribute("attribute %s must be a string", definition.Name)
+		}
+		if definition.Pattern != "" {
+			if pattern, err := regexp.Compile(definition.Pattern); err != nil || !pattern.MatchString(text) {
+				return invalidAttribute("attribute %s does not match the pattern %s", definition.Name, definition.Pattern)
+			}
+		}
+		if len(definition.Enum) != 0 && !containsString(definition.Enum, text) {
+			return invalidAttribute("attribute %s must be one of %v", definition.Name, definition.Enum)
+		}
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// validateUserAttributes checks the complete attribute set of a user against the organization's definitions.
+// The user's own stored values are skipped by the uniqueness check; pass 0 for a user that does not exist yet.
+func validateUserAttributes(attributeRepository repository.AttributeRepository, userRepository repository.UserRepository, organizationId uint64, userId uint64, attributes repository.UserAttributes) *helper.StandardError {
+	definitions := attributeRepository.FindByOrganizationId(organizationOrDefault(organizationId))
+	defined := map[string]bool{}
+	for _, definition := range definitions {
+		defined[definition.Name] = true
+	}
+
+	for name := range attributes {
+		if !defined[name] {
+			return invalidAttribute("unknown attribute %s", name)
+		}
+	}
+
+	for _, definition := range definitions {
+		value, ok := attributes[definition.Name]
+		if !ok || value == nil {
+			if definition.Required {
+				return invalidAttribute("attribute %s is required", definition.Name)
+			}
+			continue
+		}
+		if err := checkAttributeValue(definition, value); err != nil {
+			return err
+		}
+		if !definition.Unique {
+			continue
+		}
+		// The unique index of the attribute still catches a concurrent write of the same value.
+		for _, user := range userRepository.ForOrganization(organizationId).WithAttributes(map[string]string{definition.Name: attributeString(value)}).FindAll() {
+			if user.ID != userId {
+				return &helper.StandardError{Error: fmt.Errorf("attribute %s is already used by another user", definition.Name), ErrorCode: http.StatusConflict}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (t *AttributeUsecaseImpl) CreateDefinition(organizationId uint64, definitionData repository.SaveAttributeDefinition, actorId uint64) (*repository.AttributeDefinition, *helper.StandardError) {
+	if err := checkDefinition(definitionData); err != nil {
+		return nil, err
+	}
+
+	if t.AttributeRepository.FindByName(organizationId, definitionData.Name).ID != 0 {
+		return nil, errAttributeExists
+	}
+
+	definition := t.AttributeRepository.Save(repository.AttributeDefinition{
+		OrganizationID: organizationId,
+		Name:           definitionData.Name,
+		Type:           definitionData.Type,
+		Required:       definitionData.Required,
+		Unique:         definitionData.Unique,
+		Pattern:        definitionData.Pattern,
+		Enum:           definitionData.Enum,
+	})
+	if definition.ID == 0 {
+		return nil, &helper.StandardError{Error: errors.New("failed to create attribute"), ErrorCode: http.StatusInternalServerError}
+	}
+
+	// Users may still carry values of an earlier attribute with the same name.
+	if err := t.AttributeRepository.IndexUsers(definition); err != nil {
+		log.Printf("Failed to index attribute %s: %v", definition.Name, err)
+		t.AttributeRepository.Delete(organizationId, definition.ID)
+		return nil, errAttributeRepeated
+	}
+
+	t.AuditUsecase.Record("attribute.create", actorId, 0, fmt.Sprintf("attribute_id=%d name=%q", definition.ID, definition.Name))
+
+	return &definition, nil
+}
+
+func (t *AttributeUsecaseImpl) ListDefinitions(organizationId uint64) (*[]repository.AttributeDefinition, *helper.StandardError) {
+	definitions := t.AttributeRepository.FindByOrganizationId(organizationId)
+	if definitions == nil {
+		definitions = []repository.AttributeDefinition{}
+	}
+	return &definitions, nil
+}
+
+// UpdateDefinition changes the rules of an attribute. Values already stored are not revalidated, except that an
+// attribute only becomes unique when its stored values are; the other rules apply the next time a user's
+// attributes are written.
+func (t *AttributeUsecaseImpl) UpdateDefinition(organizationId uint64, definitionId uint64, definitionData repository.SaveAttributeDefinition, actorId uint64) (*repository.AttributeDefinition, *helper.StandardError) {
+	definition := t.AttributeRepository.FindById(organizationId, definitionId)
+	if definition.ID == 0 {
+		return nil, errAttributeNotFound
+	}
+
+	// Values are keyed by name, so renaming would orphan them.
+	if definitionData.Name != definition.Name {
+		return nil, invalidAttribute("attribute name cannot be changed")
+	}
+
+	if err := checkDefinition(definitionData); err != nil {
+		return nil, err
+	}
+
+	previous := definition
+	definition.Type = definitionData.Type
+	definition.Required = definitionData.Required
+	definition.Unique = definitionData.Unique
+	definition.Pattern = definitionData.Pattern
+	definition.Enum = definitionData.Enum
+	definition = t.AttributeRepository.Update(definition)
+
+	if definition.Unique != previous.Unique {
+		if err := t.AttributeRepository.IndexUsers(definition); err != nil {
+			log.Printf("Failed to index attribute %s: %v", definition.Name, err)
+			t.AttributeRepository.Update(previous)
+			return nil, errAttributeRepeated
+		}
+	}
+
+	t.AuditUsecase.Record("attribute.update", actorId, 0, fmt.Sprintf("attribute_id=%d name=%q", definition.ID, definition.Name))
+
+	return &definition, nil
+}
+
+func (t *AttributeUsecaseImpl) DeleteDefinition(organizationId uint64, definitionId uint64, actorId uint64) (*repository.AttributeDefinition, *helper.StandardError) {
+	definition := t.AttributeRepository.FindById(organizationId, definitionId)
+	if definition.ID == 0 || !t.AttributeRepository.Delete(organizationId, definitionId) {
+		return nil, errAttributeNotFound
+	}
+
+	t.AuditUsecase.Record("attribute.delete", actorId, 0, fmt.Sprintf("attribute_id=%d name=%q", definition.ID, definition.Name))
+
+	return &definition, nil
+}
+
+// SetUserAttributes replaces all custom attributes of the user; attributes left out are removed.
+func (t *AttributeUsecaseImpl) SetUserAttributes(organizationId uint64, userId uint64, attributes repository.UserAttributes, actorId uint64) (*repository.UserResponse, *helper.StandardError) {
+	userRepository := t.UserRepository.ForOrganization(organizationId)
+	user := userRepository.FindById(userId)
+	if user.ID == 0 {
+		return nil, &helper.StandardError{Error: errors.New("user not found"), ErrorCode: http.StatusNotFound}
+	}
+
+	if err := validateUserAttributes(t.AttributeRepository, userRepository, organizationId, user.ID, attributes); err != nil {
+		return nil, err
+	}
+
+	user.Attributes = attributes
+	user = userRepository.Update(user)
+	t.AuditUsecase.Record("user.attributes", actorId, user.ID, "")
+
+	return &repository.UserResponse{
+		ID:             user.ID,
+		OrganizationID: user.OrganizationID,
+		Username:       user.Username,
+		Email:          user.Email,
+		Attributes:     user.Attributes,
+		AvatarURL:      user.AvatarURL,
+		CreatedAt:      user.CreatedAt,
+	}, nil
+}
+
+func NewAttributeUsecaseImpl(attributeRepository repository.AttributeRepository, userRepository repository.UserRepository, auditUsecase AuditUsecase) AttributeUsecase {
+	return &AttributeUsecaseImpl{
+		AttributeRepository: attributeRepository,
+		UserRepository:      userRepository,
+		AuditUsecase:        auditUsecase,
+	}
+}
@@ -0,0 +1,189 @@
+package usecase_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/stretchr/testify/mock"
+)
+
+func noAttributeDefinitions() *mocks.AttributeRepositoryMock {
+	attributeRepositoryMock := new(mocks.AttributeRepositoryMock)
+	attributeRepositoryMock.On("FindByOrganizationId", mock.Anything).Return([]repository.AttributeDefinition{})
+	return attributeRepositoryMock
+}
+
+func attributeDefinitions() *mocks.AttributeRepositoryMock {
+	attributeRepositoryMock := new(mocks.AttributeRepositoryMock)
+	attributeRepositoryMock.On("FindByOrganizationId", uint64(2)).Return([]repository.AttributeDefinition{
+		{ID: 1, OrganizationID: 2, Name: "department", Type: repository.AttributeTypeString, Required: true, Enum: []string{"eng", "sales"}},
+		{ID: 2, OrganizationID: 2, Name: "employee_id", Type: repository.AttributeTypeString, Unique: true, Pattern: `^E[0-9]{4}$`},
+		{ID: 3, OrganizationID: 2, Name: "level", Type: repository.AttributeTypeNumber},
+	})
+	return attributeRepositoryMock
+}
+
+func TestCreateAttributeDefinition(t *testing.T) {
+	t.Run("test create definition", func(t *testing.T) {
+		attributeRepositoryMock := new(mocks.AttributeRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		attributeRepositoryMock.On("FindByName", uint64(2), "department").Return(repository.AttributeDefinition{})
+		attributeRepositoryMock.On("Save", repository.AttributeDefinition{OrganizationID: 2, Name: "department", Type: "string", Required: true, Enum: []string{"eng"}}).
+			Return(repository.AttributeDefinition{ID: 1, OrganizationID: 2, Name: "department", Type: "string", Required: true, Enum: []string{"eng"}})
+		attributeRepositoryMock.On("IndexUsers", mock.Anything).Return(nil)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		attributeUsecase := usecase.NewAttributeUsecaseImpl(attributeRepositoryMock, nil, auditUsecaseMock)
+		definition, err := attributeUsecase.CreateDefinition(2, repository.SaveAttributeDefinition{Name: "department", Type: "string", Required: true, Enum: []string{"eng"}}, 100)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, definition.ID, uint64(1))
+	})
+
+	t.Run("invalid definitions", func(t *testing.T) {
+		attributeUsecase := usecase.NewAttributeUsecaseImpl(nil, nil, nil)
+
+		_, err := attributeUsecase.CreateDefinition(2, repository.SaveAttributeDefinition{Name: "Department", Type: "string"}, 100)
+		assert.Equal(t, int(err.ErrorCode), http.StatusBadRequest)
+
+		_, err = attributeUsecase.CreateDefinition(2, repository.SaveAttributeDefinition{Name: "level", Type: "number", Enum: []string{"1"}}, 100)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("pattern and enum only apply to string attributes"), ErrorCode: http.StatusBadRequest})
+
+		_, err = attributeUsecase.CreateDefinition(2, repository.SaveAttributeDefinition{Name: "code", Type: "string", Pattern: "["}, 100)
+		assert.Equal(t, int(err.ErrorCode), http.StatusBadRequest)
+	})
+
+	t.Run("stored values repeat", func(t *testing.T) {
+		attributeRepositoryMock := new(mocks.AttributeRepositoryMock)
+		attributeRepositoryMock.On("FindByName", uint64(2), "employee_id").Return(repository.AttributeDefinition{})
+		attributeRepositoryMock.On("Save", mock.Anything).Return(repository.AttributeDefinition{ID: 3, OrganizationID: 2, Name: "employee_id", Type: "string", Unique: true})
+		attributeRepositoryMock.On("IndexUsers", mock.Anything).Return(errors.New("duplicate key"))
+		attributeRepositoryMock.On("Delete", uint64(2), uint64(3)).Return(true)
+
+		attributeUsecase := usecase.NewAttributeUsecaseImpl(attributeRepositoryMock, nil, nil)
+		definition, err := attributeUsecase.CreateDefinition(2, repository.SaveAttributeDefinition{Name: "employee_id", Type: "string", Unique: true}, 100)
+
+		assert.Equal(t, definition, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("stored values of the attribute are not unique"), ErrorCode: http.StatusConflict})
+		attributeRepositoryMock.AssertCalled(t, "Delete", uint64(2), uint64(3))
+	})
+
+	t.Run("already exists", func(t *testing.T) {
+		attributeRepositoryMock := new(mocks.AttributeRepositoryMock)
+		attributeRepositoryMock.On("FindByName", uint64(2), "department").Return(repository.AttributeDefinition{ID: 1})
+
+		attributeUsecase := usecase.NewAttributeUsecaseImpl(attributeRepositoryMock, nil, nil)
+		definition, err := attributeUsecase.CreateDefinition(2, repository.SaveAttributeDefinition{Name: "department", Type: "string"}, 100)
+
+		assert.Equal(t, definition, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("attribute already exists"), ErrorCode: http.StatusConflict})
+	})
+}
+
+func TestUpdateAttributeDefinition(t *testing.T) {
+	t.Run("test update rules", func(t *testing.T) {
+		attributeRepositoryMock := new(mocks.AttributeRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		attributeRepositoryMock.On("FindById", uint64(2), uint64(1)).Return(repository.AttributeDefinition{ID: 1, OrganizationID: 2, Name: "department", Type: "string"})
+		attributeRepositoryMock.On("Update", mock.MatchedBy(func(definition repository.AttributeDefinition) bool {
+			return definition.Required && definition.Pattern == "^[a-z]+$"
+		})).Return(repository.AttributeDefinition{ID: 1, OrganizationID: 2, Name: "department", Type: "string", Required: true, Pattern: "^[a-z]+$"})
+		auditUsecaseMock.On("Record").Return(nil)
+
+		attributeUsecase := usecase.NewAttributeUsecaseImpl(attributeRepositoryMock, nil, auditUsecaseMock)
+		definition, err := attributeUsecase.UpdateDefinition(2, 1, repository.SaveAttributeDefinition{Name: "department", Type: "string", Required: true, Pattern: "^[a-z]+$"}, 100)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, definition.Required, true)
+	})
+
+	t.Run("making repeated values unique", func(t *testing.T) {
+		previous := repository.AttributeDefinition{ID: 2, OrganizationID: 2, Name: "employee_id", Type: "string"}
+		attributeRepositoryMock := new(mocks.AttributeRepositoryMock)
+		attributeRepositoryMock.On("FindById", uint64(2), uint64(2)).Return(previous)
+		attributeRepositoryMock.On("Update", mock.MatchedBy(func(definition repository.AttributeDefinition) bool { return definition.Unique })).
+			Return(repository.AttributeDefinition{ID: 2, OrganizationID: 2, Name: "employee_id", Type: "string", Unique: true})
+		attributeRepositoryMock.On("Update", previous).Return(previous)
+		attributeRepositoryMock.On("IndexUsers", mock.Anything).Return(errors.New("duplicate key"))
+
+		attributeUsecase := usecase.NewAttributeUsecaseImpl(attributeRepositoryMock, nil, nil)
+		definition, err := attributeUsecase.UpdateDefinition(2, 2, repository.SaveAttributeDefinition{Name: "employee_id", Type: "string", Unique: true}, 100)
+
+		assert.Equal(t, definition, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("stored values of the attribute are not unique"), ErrorCode: http.StatusConflict})
+		attributeRepositoryMock.AssertCalled(t, "Update", previous)
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		attributeRepositoryMock := new(mocks.AttributeRepositoryMock)
+		attributeRepositoryMock.On("FindById", uint64(2), uint64(1)).Return(repository.AttributeDefinition{ID: 1, OrganizationID: 2, Name: "department", Type: "string"})
+
+		attributeUsecase := usecase.NewAttributeUsecaseImpl(attributeRepositoryMock, nil, nil)
+		definition, err := attributeUsecase.UpdateDefinition(2, 1, repository.SaveAttributeDefinition{Name: "team", Type: "string"}, 100)
+
+		assert.Equal(t, definition, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("attribute name cannot be changed"), ErrorCode: http.StatusBadRequest})
+	})
+}
+
+func TestSetUserAttributes(t *testing.T) {
+	colleague := repository.User{ID: 102, OrganizationID: 2, Username: "colleague", Attributes: repository.UserAttributes{"department": "eng", "employee_id": "E0002"}}
+	member := repository.User{ID: 101, OrganizationID: 2, Username: "member", Attributes: repository.UserAttributes{"department": "eng", "employee_id": "E0001"}}
+
+	set := func(attributes repository.UserAttributes) (*repository.UserResponse, *helper.StandardError) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		userRepositoryMock.On("FindById").Return(member)
+		userRepositoryMock.On("FindAll").Return([]repository.User{member, colleague})
+		userRepositoryMock.On("Update", mock.Anything).Return(repository.User{ID: 101, OrganizationID: 2, Username: "member", Attributes: attributes})
+		auditUsecaseMock.On("Record").Return(nil)
+
+		attributeUsecase := usecase.NewAttributeUsecaseImpl(attributeDefinitions(), userRepositoryMock, auditUsecaseMock)
+		return attributeUsecase.SetUserAttributes(2, 101, attributes, 100)
+	}
+
+	t.Run("test set valid attributes", func(t *testing.T) {
+		attributes := repository.UserAttributes{"department": "sales", "employee_id": "E0001", "level": float64(4)}
+		user, err := set(attributes)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, user.Attributes, attributes)
+	})
+
+	t.Run("invalid attributes", func(t *testing.T) {
+		cases := []struct {
+			attributes repository.UserAttributes
+			err        helper.StandardError
+		}{
+			{repository.UserAttributes{"department": "eng", "shoe_size": float64(42)}, helper.StandardError{Error: errors.New("unknown attribute shoe_size"), ErrorCode: http.StatusBadRequest}},
+			{repository.UserAttributes{"employee_id": "E0001"}, helper.StandardError{Error: errors.New("attribute department is required"), ErrorCode: http.StatusBadRequest}},
+			{repository.UserAttributes{"department": "legal"}, helper.StandardError{Error: errors.New("attribute department must be one of [eng sales]"), ErrorCode: http.StatusBadRequest}},
+			{repository.UserAttributes{"department": "eng", "level": "4"}, helper.StandardError{Error: errors.New("attribute level must be a number"), ErrorCode: http.StatusBadRequest}},
+			{repository.UserAttributes{"department": "eng", "employee_id": "X1"}, helper.StandardError{Error: errors.New("attribute employee_id does not match the pattern ^E[0-9]{4}$"), ErrorCode: http.StatusBadRequest}},
+			{repository.UserAttributes{"department": "eng", "employee_id": "E0002"}, helper.StandardError{Error: errors.New("attribute employee_id is already used by another user"), ErrorCode: http.StatusConflict}},
+		}
+
+		for _, c := range cases {
+			user, err := set(c.attributes)
+			assert.Equal(t, user, nil)
+			assert.Equal(t, err, c.err)
+		}
+	})
+
+	t.Run("user of another organization", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		userRepositoryMock.On("FindById").Return(repository.User{})
+
+		attributeUsecase := usecase.NewAttributeUsecaseImpl(attributeDefinitions(), userRepositoryMock, nil)
+		user, err := attributeUsecase.SetUserAttributes(3, 101, repository.UserAttributes{"department": "eng"}, 100)
+
+		assert.Equal(t, user, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("user not found"), ErrorCode: http.StatusNotFound})
+	})
+}
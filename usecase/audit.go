@@ -0,0 +1,248 @@
+package usecase
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultAuditCheckpointInterval = 100
+	auditVerifyBatchSize           = 500
+	auditSaveRetries               = 3
+)
+
+type AuditUsecase interface {
+	Record(action string, actorID uint64, subjectID uint64, detail string) *helper.StandardError
+	Verify() (*repository.AuditVerification, *helper.StandardError)
+}
+
+type AuditUsecaseImpl struct {
+	AuditRepository repository.AuditRepository
+	mutex           sync.Mutex
+}
+
+type auditContent struct {
+	Action    string `json:"action"`
+	ActorID   uint64 `json:"actor_id"`
+	SubjectID uint64 `json:"subject_id"`
+	Detail    string `json:"detail"`
+	CreatedAt string `json:"created_at"`
+	PrevHash  string `json:"prev_hash"`
+}
+
+func hashAuditEvent(event repository.AuditEvent) string {
+	content, _ := json.Marshal(auditContent{
+		Action:    event.Action,
+		ActorID:   event.ActorID,
+		SubjectID: event.SubjectID,
+		Detail:    event.Detail,
+		CreatedAt: event.CreatedAt.UTC().Format(time.RFC3339Nano),
+		PrevHash:  event.PrevHash,
+	})
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func checkpointMessage(eventID uint64, hash string) []byte {
+	return []byte(fmt.Sprintf("%d:%s", eventID, hash))
+}
+
+func auditSigningKey() (ed25519.PrivateKey, error) {
+	encoded := os.Getenv("AUDIT_SIGNING_KEY")
+	if encoded == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.New("invalid audit signing key encoding")
+	}
+
+	switch len(raw) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(raw), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(raw), nil
+	}
+
+	return nil, errors.New("invalid audit signing key length")
+}
+
+// auditVerifyKey returns the Ed25519 public key in AUDIT_VERIFY_KEY, so a verifier does not need the signing key.
+// Without it, the public half of AUDIT_SIGNING_KEY is used. Both unset returns nil.
+func auditVerifyKey() (ed25519.PublicKey, error) {
+	encoded := os.Getenv("AUDIT_VERIFY_KEY")
+	if encoded == "" {
+		signingKey, err := auditSigningKey()
+		if err != nil || signingKey == nil {
+			return nil, err
+		}
+		return signingKey.Public().(ed25519.PublicKey), nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.New("invalid audit verify key encoding")
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid audit verify key length")
+	}
+
+	return ed25519.PublicKey(raw), nil
+}
+
+func auditCheckpointInterval() uint64 {
+	interval, err := strconv.ParseUint(os.Getenv("AUDIT_CHECKPOINT_INTERVAL"), 10, 64)
+	if err != nil || interval == 0 {
+		return defaultAuditCheckpointInterval
+	}
+	return interval
+}
+
+// Record appends an event to the audit chain. Callers carry on when it fails, so failures are logged here.
+func (t *AuditUsecaseImpl) Record(action string, actorID uint64, subjectID uint64, detail string) *helper.StandardError {
+	err := t.record(action, actorID, subjectID, detail)
+	if err != nil {
+		log.Printf("Failed to record audit event %s for subject %d: %v", action, subjectID, err.Error)
+	}
+	return err
+}
+
+func (t *AuditUsecaseImpl) record(action string, actorID uint64, subjectID uint64, detail string) *helper.StandardError {
+	signingKey, err := auditSigningKey()
+	if err != nil {
+		return &helper.StandardError{Error: err, ErrorCode: http.StatusInternalServerError}
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	// prev_hash is unique, so a concurrent writer makes the insert fail and we retry on the new head
+	var saved repository.AuditEvent
+	for attempt := 0; attempt < auditSaveRetries && saved.ID == 0; attempt++ {
+		event := repository.AuditEvent{
+			Action:    action,
+			ActorID:   actorID,
+			SubjectID: subjectID,
+			Detail:    detail,
+			CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+			PrevHash:  t.AuditRepository.FindLast().Hash,
+		}
+		event.Hash = hashAuditEvent(event)
+		saved = t.AuditRepository.Save(event)
+	}
+
+	if saved.ID == 0 {
+		return &helper.StandardError{Error: errors.New("failed to record audit event"), ErrorCode: http.StatusInternalServerError}
+	}
+
+	// ids can have gaps, e.g. from failed inserts, so the interval counts from the last checkpoint
+	if signingKey != nil && t.AuditRepository.FindLastCheckpoint().EventID+auditCheckpointInterval() <= saved.ID {
+		t.AuditRepository.SaveCheckpoint(repository.AuditCheckpoint{
+			EventID:   saved.ID,
+			Hash:      saved.Hash,
+			Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(signingKey, checkpointMessage(saved.ID, saved.Hash))),
+		})
+	}
+
+	return nil
+}
+
+// Verify walks the chain and checks every checkpoint signature. Deleting a checkpoint must not go unnoticed, so
+// with a key configured, a chain that runs the checkpoint interval past its last checkpoint is broken. Without a
+// key, an intact chain is reported as "signatures unchecked" rather than valid.
+func (t *AuditUsecaseImpl) Verify() (*repository.AuditVerification, *helper.StandardError) {
+	verifyKey, err := auditVerifyKey()
+	if err != nil {
+		return nil, &helper.StandardError{Error: err, ErrorCode: http.StatusInternalServerError}
+	}
+
+	checkpoints := map[uint64]repository.AuditCheckpoint{}
+	for _, checkpoint := range t.AuditRepository.FindCheckpoints() {
+		checkpoints[checkpoint.EventID] = checkpoint
+	}
+
+	result := repository.AuditVerification{Valid: true, SignaturesChecked: verifyKey != nil}
+	broken := func(eventID uint64, reason string) (*repository.AuditVerification, *helper.StandardError) {
+		result.Valid = false
+		result.BrokenEventID = eventID
+		result.Reason = reason
+		return &result, nil
+	}
+
+	interval := auditCheckpointInterval()
+	var lastID, lastCheckpointID uint64
+	previousHash := ""
+	for {
+		events := t.AuditRepository.FindAfter(lastID, auditVerifyBatchSize)
+		if len(events) == 0 {
+			break
+		}
+
+		for _, event := range events {
+			if event.PrevHash != previousHash {
+				return broken(event.ID, "previous hash does not match preceding event")
+			}
+			if hashAuditEvent(event) != event.Hash {
+				return broken(event.ID, "event content does not match its hash")
+			}
+
+			if checkpoint, ok := checkpoints[event.ID]; ok {
+				if checkpoint.Hash != event.Hash {
+					return broken(event.ID, "checkpoint hash does not match event")
+				}
+				if verifyKey != nil {
+					signature, err := base64.StdEncoding.DecodeString(checkpoint.Signature)
+					if err != nil || !ed25519.Verify(verifyKey, checkpointMessage(event.ID, event.Hash), signature) {
+						return broken(event.ID, "checkpoint signature is invalid")
+					}
+				}
+				delete(checkpoints, event.ID)
+				lastCheckpointID = event.ID
+				result.CheckedCheckpoints++
+			} else if verifyKey != nil && lastCheckpointID+interval <= event.ID {
+				return broken(event.ID, "checkpoint is missing")
+			}
+
+			previousHash = event.Hash
+			lastID = event.ID
+			result.CheckedEvents++
+		}
+	}
+
+	if len(checkpoints) > 0 {
+		var firstMissing uint64
+		for eventID := range checkpoints {
+			if firstMissing == 0 || eventID < firstMissing {
+				firstMissing = eventID
+			}
+		}
+		return broken(firstMissing, "checkpoint refers to a missing event")
+	}
+
+	if verifyKey == nil {
+		result.Valid = false
+		result.Reason = "signatures unchecked"
+	}
+
+	return &result, nil
+}
+
+func NewAuditUsecaseImpl(auditRepository repository.AuditRepository) AuditUsecase {
+	return &AuditUsecaseImpl{
+		AuditRepository: auditRepository,
+	}
+}
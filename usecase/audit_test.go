@@ -0,0 +1,206 @@
+package usecase_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newAuditDB(t *testing.T) *gorm.DB {
+	db, _ := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	err := db.AutoMigrate(&repository.AuditEvent{}, &repository.AuditCheckpoint{})
+	if err != nil {
+		t.Fatalf("Error migrating database: %v", err)
+	}
+	return db
+}
+
+func TestAuditRecordAndVerify(t *testing.T) {
+	os.Setenv("AUDIT_SIGNING_KEY", base64.StdEncoding.EncodeToString(make([]byte, ed25519.SeedSize)))
+	os.Setenv("AUDIT_CHECKPOINT_INTERVAL", "2")
+	defer os.Unsetenv("AUDIT_SIGNING_KEY")
+	defer os.Unsetenv("AUDIT_CHECKPOINT_INTERVAL")
+
+	t.Run("intact chain", func(t *testing.T) {
+		db := newAuditDB(t)
+		auditUsecase := usecase.NewAuditUsecaseImpl(repository.NewAuditRepositoryImpl(db))
+
+		for i := uint64(1); i <= 5; i++ {
+			assert.Equal(t, auditUsecase.Record("user.login", i, i, ""), nil)
+		}
+
+		verification, err := auditUsecase.Verify()
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, verification.Valid, true)
+		assert.Equal(t, verification.CheckedEvents, uint64(5))
+		assert.Equal(t, verification.CheckedCheckpoints, uint64(2))
+		assert.Equal(t, verification.SignaturesChecked, true)
+	})
+
+	t.Run("verify key without the signing key", func(t *testing.T) {
+		db := newAuditDB(t)
+		auditUsecase := usecase.NewAuditUsecaseImpl(repository.NewAuditRepositoryImpl(db))
+
+		for i := uint64(1); i <= 5; i++ {
+			auditUsecase.Record("user.login", i, i, "")
+		}
+
+		publicKey := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)).Public().(ed25519.PublicKey)
+		t.Setenv("AUDIT_SIGNING_KEY", "")
+		t.Setenv("AUDIT_VERIFY_KEY", base64.StdEncoding.EncodeToString(publicKey))
+
+		verification, err := auditUsecase.Verify()
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, verification.Valid, true)
+		assert.Equal(t, verification.CheckedCheckpoints, uint64(2))
+	})
+
+	t.Run("deleted checkpoint", func(t *testing.T) {
+		db := newAuditDB(t)
+		auditUsecase := usecase.NewAuditUsecaseImpl(repository.NewAuditRepositoryImpl(db))
+
+		for i := uint64(1); i <= 5; i++ {
+			auditUsecase.Record("user.login", i, i, "")
+		}
+		db.Where("event_id=?", 4).Delete(&repository.AuditCheckpoint{})
+
+		verification, _ := auditUsecase.Verify()
+
+		assert.Equal(t, verification.Valid, false)
+		assert.Equal(t, verification.BrokenEventID, uint64(4))
+		assert.Equal(t, verification.Reason, "checkpoint is missing")
+	})
+
+	t.Run("no key configured", func(t *testing.T) {
+		db := newAuditDB(t)
+		auditUsecase := usecase.NewAuditUsecaseImpl(repository.NewAuditRepositoryImpl(db))
+
+		for i := uint64(1); i <= 3; i++ {
+			auditUsecase.Record("user.login", i, i, "")
+		}
+		t.Setenv("AUDIT_SIGNING_KEY", "")
+
+		verification, err := auditUsecase.Verify()
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, verification.Valid, false)
+		assert.Equal(t, verification.BrokenEventID, uint64(0))
+		assert.Equal(t, verification.CheckedEvents, uint64(3))
+		assert.Equal(t, verification.SignaturesChecked, false)
+		assert.Equal(t, verification.Reason, "signatures unchecked")
+	})
+
+	t.Run("checkpoints survive gaps in event ids", func(t *testing.T) {
+		db := newAuditDB(t)
+		auditUsecase := usecase.NewAuditUsecaseImpl(repository.NewAuditRepositoryImpl(db))
+
+		auditUsecase.Record("user.login", 1, 1, "")
+		// Skip id 2, which would have been the first checkpoint.
+		db.Exec("INSERT INTO sqlite_sequence (name, seq) SELECT 'audit_events', 2 WHERE NOT EXISTS (SELECT 1 FROM sqlite_sequence WHERE name='audit_events')")
+		db.Exec("UPDATE sqlite_sequence SET seq=2 WHERE name='audit_events'")
+		for i := uint64(2); i <= 5; i++ {
+			auditUsecase.Record("user.login", i, i, "")
+		}
+
+		var eventIds []uint64
+		db.Model(&repository.AuditCheckpoint{}).Order("event_id asc").Pluck("event_id", &eventIds)
+		assert.Equal(t, eventIds, []uint64{3, 5})
+	})
+
+	t.Run("edited event", func(t *testing.T) {
+		db := newAuditDB(t)
+		auditUsecase := usecase.NewAuditUsecaseImpl(repository.NewAuditRepositoryImpl(db))
+
+		for i := uint64(1); i <= 5; i++ {
+			auditUsecase.Record("user.login", i, i, "")
+		}
+		db.Model(&repository.AuditEvent{}).Where("id=?", 3).Update("actor_id", 99)
+
+		verification, err := auditUsecase.Verify()
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, verification.Valid, false)
+		assert.Equal(t, verification.BrokenEventID, uint64(3))
+		assert.Equal(t, verification.Reason, "event content does not match its hash")
+	})
+
+	t.Run("deleted event", func(t *testing.T) {
+		db := newAuditDB(t)
+		auditUsecase := usecase.NewAuditUsecaseImpl(repository.NewAuditRepositoryImpl(db))
+
+		for i := uint64(1); i <= 5; i++ {
+			auditUsecase.Record("user.login", i, i, "")
+		}
+		db.Where("id=?", 2).Delete(&repository.AuditEvent{})
+
+		verification, _ := auditUsecase.Verify()
+
+		assert.Equal(t, verification.Valid, false)
+		assert.Equal(t, verification.BrokenEventID, uint64(3))
+		assert.Equal(t, verification.Reason, "previous hash does not match preceding event")
+	})
+
+	t.Run("forged checkpoint signature", func(t *testing.T) {
+		db := newAuditDB(t)
+		auditUsecase := usecase.NewAuditUsecaseImpl(repository.NewAuditRepositoryImpl(db))
+
+		for i := uint64(1); i <= 3; i++ {
+			auditUsecase.Record("user.login", i, i, "")
+		}
+		db.Model(&repository.AuditCheckpoint{}).Where("event_id=?", 2).Update("signature", base64.StdEncoding.EncodeToString(make([]byte, ed25519.SignatureSize)))
+
+		verification, _ := auditUsecase.Verify()
+
+		assert.Equal(t, verification.Valid, false)
+		assert.Equal(t, verification.BrokenEventID, uint64(2))
+		assert.Equal(t, verification.Reason, "checkpoint signature is invalid")
+	})
+}
+
+func TestAuditRecordNegative(t *testing.T) {
+	t.Run("save failure", func(t *testing.T) {
+		auditRepositoryMock := new(mocks.AuditRepositoryMock)
+
+		auditRepositoryMock.On("FindLast").Return(repository.AuditEvent{})
+		auditRepositoryMock.On("Save").Return(repository.AuditEvent{})
+
+		auditUsecase := usecase.NewAuditUsecaseImpl(auditRepositoryMock)
+		err := auditUsecase.Record("user.login", 1, 1, "")
+
+		assert.Equal(t, err, &helper.StandardError{Error: errors.New("failed to record audit event"), ErrorCode: http.StatusInternalServerError})
+	})
+
+	t.Run("invalid signing key", func(t *testing.T) {
+		os.Setenv("AUDIT_SIGNING_KEY", "not-a-key")
+		defer os.Unsetenv("AUDIT_SIGNING_KEY")
+
+		auditUsecase := usecase.NewAuditUsecaseImpl(new(mocks.AuditRepositoryMock))
+		verification, err := auditUsecase.Verify()
+
+		assert.Equal(t, verification, nil)
+		assert.Equal(t, err, &helper.StandardError{Error: errors.New("invalid audit signing key encoding"), ErrorCode: http.StatusInternalServerError})
+	})
+
+	t.Run("invalid verify key", func(t *testing.T) {
+		t.Setenv("AUDIT_VERIFY_KEY", base64.StdEncoding.EncodeToString(make([]byte, ed25519.SeedSize+1)))
+
+		auditUsecase := usecase.NewAuditUsecaseImpl(new(mocks.AuditRepositoryMock))
+		verification, err := auditUsecase.Verify()
+
+		assert.Equal(t, verification, nil)
+		assert.Equal(t, err, &helper.StandardError{Error: errors.New("invalid audit verify key length"), ErrorCode: http.StatusInternalServerError})
+	})
+}
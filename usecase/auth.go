@@ -16,67 +16,103 @@ import (
 )
 
 type AuthUsecase interface {
-	Login(loginData repository.Login) (string, *helper.StandardError)
-	Register(registerData repository.Register) (*repository.UserResponse, *helper.StandardError)
+	Authenticate(loginData repository.Login) (*repository.User, *helper.StandardError)
+	Login(loginData repository.Login, loginContext repository.LoginContext) (string, *helper.StandardError)
+	Register(registerData repository.Register, organizationId uint64) (*repository.UserResponse, *helper.StandardError)
 	ValidateToken(c *gin.Context)
+	ParseToken(tokenString string) (*repository.TokenInfo, *helper.StandardError)
+	RequireScope(scope string) gin.HandlerFunc
+	CreateSession(loginData repository.Login, loginContext repository.LoginContext) (*repository.CreatedSession, *helper.StandardError)
+	FindSession(sessionToken string) (*repository.User, *repository.Session)
+	Logout(sessionId uint64) *helper.StandardError
 }
 
 type AuthUsecaseImpl struct {
-	UserRepository repository.UserRepository
+	UserRepository      repository.UserRepository
+	AuditUsecase        AuditUsecase
+	TokenRepository     repository.PersonalAccessTokenRepository
+	ClientRepository    repository.OAuthClientRepository
+	OAuthRepository     repository.OAuthRepository
+	Authenticator       Authenticator
+	SessionRepository   repository.SessionRepository
+	GroupRepository     repository.GroupRepository
+	AttributeRepository repository.AttributeRepository
 }
 
-func (t *AuthUsecaseImpl) Register(registerData repository.Register) (*repository.UserResponse, *helper.StandardError) {
-	userFound := t.UserRepository.FindByUsername(registerData.Username)
+func (t *AuthUsecaseImpl) Register(registerData repository.Register, organizationId uint64) (*repository.UserResponse, *helper.StandardError) {
+	userRepository := t.UserRepository.ForOrganization(organizationId)
+	userFound := userRepository.FindByUsername(registerData.Username)
 
 	if userFound.ID != 0 {
 		return nil, &helper.StandardError{Error: errors.New("user already exist"), ErrorCode: http.StatusBadRequest}
 	}
 
+	if err := validateUserAttributes(t.AttributeRepository, userRepository, organizationId, 0, registerData.Attributes); err != nil {
+		return nil, err
+	}
+
 	passwordHash, err := bcrypt.GenerateFromPassword([]byte(registerData.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, &helper.StandardError{Error: err, ErrorCode: http.StatusInternalServerError}
 	}
 
 	user := repository.User{
-		Username: registerData.Username,
-		Email:    registerData.Email,
-		Password: string(passwordHash),
+		Username:   registerData.Username,
+		Email:      registerData.Email,
+		Password:   string(passwordHash),
+		Attributes: registerData.Attributes,
 	}
 
-	createdUser := t.UserRepository.Save(user)
+	createdUser := userRepository.Save(user)
+	t.AuditUsecase.Record("user.register", createdUser.ID, createdUser.ID, "")
 
 	userResponse := repository.UserResponse{
-		ID:        createdUser.ID,
-		Email:     createdUser.Email,
-		Username:  createdUser.Username,
-		CreatedAt: createdUser.CreatedAt,
+		ID:             createdUser.ID,
+		OrganizationID: createdUser.OrganizationID,
+		Email:          createdUser.Email,
+		Username:       createdUser.Username,
+		Attributes:     createdUser.Attributes,
+		AvatarURL:      createdUser.AvatarURL,
+		CreatedAt:      createdUser.CreatedAt,
 	}
 
 	return &userResponse, nil
 }
 
-func (t *AuthUsecaseImpl) Login(loginData repository.Login) (string, *helper.StandardError) {
-	userFound := t.UserRepository.FindByUsername(loginData.Username)
+func (t *AuthUsecaseImpl) Authenticate(loginData repository.Login) (*repository.User, *helper.StandardError) {
+	user, authError := t.Authenticator.Authenticate(loginData)
+	if authError != nil {
+		return nil, authError
+	}
 
-	if userFound.ID == 0 {
-		return "", &helper.StandardError{Error: errors.New("user not found"), ErrorCode: http.StatusBadRequest}
+	if user.PasskeyRequired {
+		return nil, errPasskeyRequired
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(userFound.Password), []byte(loginData.Password)); err != nil {
-		return "", &helper.StandardError{Error: errors.New("wrong password"), ErrorCode: http.StatusUnauthorized}
+	return user, nil
+}
+
+func (t *AuthUsecaseImpl) Login(loginData repository.Login, loginContext repository.LoginContext) (string, *helper.StandardError) {
+	loginData.OrganizationID = loginContext.OrganizationID
+	userFound, authError := t.Authenticate(loginData)
+	if authError != nil {
+		return "", authError
 	}
 
-	generateToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"id":       userFound.ID,
-		"username": userFound.Username,
-		"exp":      time.Now().Add(time.Hour * 24).Unix(),
-	})
+	// Downstream services authorize on group membership, so password logins carry the user's effective groups.
+	organizationId := organizationOrDefault(userFound.OrganizationID)
+	var claims jwt.MapClaims
+	if groups := effectiveGroups(t.GroupRepository, userFound.ID, organizationId); len(groups) != 0 {
+		claims = jwt.MapClaims{"groups": groups}
+	}
 
-	token, err := generateToken.SignedString([]byte(os.Getenv("SECRET")))
-	if err != nil {
-		return "", &helper.StandardError{Error: errors.New("failed to generate token"), ErrorCode: http.StatusInternalServerError}
+	token, session, loginError := startOrganizationSession(t.SessionRepository, *userFound, organizationId, SessionMethodPassword, loginContext, claims)
+	if loginError != nil {
+		return "", loginError
 	}
 
+	t.AuditUsecase.Record("user.login", userFound.ID, userFound.ID, fmt.Sprintf("method=password session_id=%d", session.ID))
+
 	return token, nil
 }
 
@@ -84,6 +120,11 @@ func (t *AuthUsecaseImpl) ValidateToken(c *gin.Context) {
 	authHeader := c.GetHeader("Authorization")
 
 	if authHeader == "" {
+		if sessionToken, err := c.Cookie(SessionCookie); err == nil && sessionToken != "" {
+			t.validateSession(c, sessionToken)
+			return
+		}
+
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header is missing"})
 		c.AbortWithStatus(http.StatusUnauthorized)
 		return
@@ -96,7 +137,70 @@ func (t *AuthUsecaseImpl) ValidateToken(c *gin.Context) {
 		return
 	}
 
-	tokenString := authToken[1]
+	tokenInfo, accessToken, session, parseError := t.parseToken(authToken[1])
+	if parseError != nil {
+		c.JSON(int(parseError.ErrorCode), gin.H{"error": parseError.Error.Error()})
+		c.AbortWithStatus(int(parseError.ErrorCode))
+		return
+	}
+
+	if accessToken != nil {
+		now := time.Now()
+		if accessToken.LastUsedAt == nil || now.Sub(*accessToken.LastUsedAt) > tokenLastUsedResolution || accessToken.LastUsedIP != c.ClientIP() {
+			t.TokenRepository.UpdateLastUsed(accessToken.ID, now, c.ClientIP())
+		}
+	}
+
+	if !setCurrentOrganization(c, tokenInfo.OrganizationID) {
+		return
+	}
+
+	if session != nil {
+		t.touchSession(c, *session)
+		c.Set("currentSessionId", session.ID)
+	}
+
+	if tokenInfo.UserID != 0 {
+		c.Set("currentUserId", tokenInfo.UserID)
+	} else {
+		c.Set("currentClientId", tokenInfo.ClientID)
+	}
+	if tokenInfo.Scopes != nil {
+		c.Set("currentScopes", tokenInfo.Scopes)
+	}
+
+	c.Next()
+}
+
+func (t *AuthUsecaseImpl) ParseToken(tokenString string) (*repository.TokenInfo, *helper.StandardError) {
+	tokenInfo, _, _, err := t.parseToken(tokenString)
+	return tokenInfo, err
+}
+
+func (t *AuthUsecaseImpl) parseToken(tokenString string) (*repository.TokenInfo, *repository.PersonalAccessToken, *repository.Session, *helper.StandardError) {
+	invalidToken := &helper.StandardError{Error: errors.New("invalid or expired token"), ErrorCode: http.StatusUnauthorized}
+
+	if isPersonalAccessToken(tokenString) {
+		accessToken := t.TokenRepository.FindByHash(hashToken(tokenString))
+		if accessToken.ID == 0 || accessToken.RevokedAt != nil || time.Now().After(accessToken.ExpiresAt) {
+			return nil, nil, nil, invalidToken
+		}
+
+		user := t.UserRepository.FindById(accessToken.UserID)
+		if user.ID == 0 || user.DisabledAt != nil {
+			return nil, nil, nil, invalidToken
+		}
+
+		return &repository.TokenInfo{
+			UserID:         user.ID,
+			Username:       user.Username,
+			OrganizationID: organizationOrDefault(user.OrganizationID),
+			Scopes:         strings.Fields(accessToken.Scopes),
+			IssuedAt:       accessToken.CreatedAt.Unix(),
+			ExpiresAt:      accessToken.ExpiresAt.Unix(),
+		}, &accessToken, nil, nil
+	}
+
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -105,32 +209,126 @@ func (t *AuthUsecaseImpl) ValidateToken(c *gin.Context) {
 	})
 
 	if err != nil || !token.Valid {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
-		c.AbortWithStatus(http.StatusUnauthorized)
-		return
+		return nil, nil, nil, invalidToken
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-		c.Abort()
-		return
+		return nil, nil, nil, &helper.StandardError{Error: errors.New("invalid token"), ErrorCode: http.StatusUnauthorized}
 	}
 
-	user := t.UserRepository.FindByUsername(claims["username"].(string))
+	tokenInfo := repository.TokenInfo{}
+	tokenInfo.JTI, _ = claims["jti"].(string)
+	if tokenInfo.JTI != "" && t.OAuthRepository.IsTokenRevoked(tokenInfo.JTI) {
+		return nil, nil, nil, invalidToken
+	}
 
-	if user == (repository.User{}) {
-		c.AbortWithStatus(http.StatusUnauthorized)
-		return
+	if issuedAt, ok := claims["iat"].(float64); ok {
+		tokenInfo.IssuedAt = int64(issuedAt)
+	}
+	if expiresAt, ok := claims["exp"].(float64); ok {
+		tokenInfo.ExpiresAt = int64(expiresAt)
+	}
+	if scope, ok := claims["scope"].(string); ok {
+		tokenInfo.Scopes = strings.Fields(scope)
 	}
+	tokenInfo.ClientID, _ = claims["client_id"].(string)
 
-	c.Set("currentUserId", user.ID)
+	if tokenInfo.ClientID != "" && claims["username"] == nil {
+		client := t.ClientRepository.FindByClientId(tokenInfo.ClientID)
+		if client.ID == 0 || client.DisabledAt != nil {
+			return nil, nil, nil, &helper.StandardError{Error: errors.New("client is disabled or does not exist"), ErrorCode: http.StatusUnauthorized}
+		}
+		if tokenInfo.Scopes == nil {
+			tokenInfo.Scopes = []string{}
+		}
+		tokenInfo.OrganizationID = organizationOrDefault(client.OrganizationID)
+		return &tokenInfo, nil, nil, nil
+	}
 
-	c.Next()
+	// Usernames are only unique within an organization, so tokens are resolved by their id claim. User tokens also
+	// reference the session they were issued for, so terminating the session revokes the token; tokens without
+	// one could never be revoked and are refused.
+	username, hasUsername := claims["username"].(string)
+	userId, hasUserId := claims["id"].(float64)
+	sessionId, hasSession := claims["sid"].(float64)
+	if !hasUsername || !hasUserId || !hasSession {
+		return nil, nil, nil, &helper.StandardError{Error: errors.New("invalid token"), ErrorCode: http.StatusUnauthorized}
+	}
+
+	user := t.UserRepository.FindById(uint64(userId))
+	if user.ID == 0 || user.Username != username || user.DisabledAt != nil {
+		return nil, nil, nil, &helper.StandardError{Error: errors.New("invalid token"), ErrorCode: http.StatusUnauthorized}
+	}
+
+	tokenInfo.UserID = user.ID
+	tokenInfo.Username = user.Username
+	tokenInfo.OrganizationID = organizationOrDefault(user.OrganizationID)
+
+	session := t.SessionRepository.FindById(uint64(sessionId))
+	if session.ID == 0 || session.UserID != user.ID || time.Now().After(session.ExpiresAt) {
+		return nil, nil, nil, invalidToken
+	}
+	if session.OrganizationID != 0 {
+		tokenInfo.OrganizationID = session.OrganizationID
+	}
+
+	return &tokenInfo, nil, &session, nil
+}
+
+func signLoginToken(user repository.User, session repository.Session, claims jwt.MapClaims) (string, error) {
+	if claims == nil {
+		claims = jwt.MapClaims{}
+	}
+	claims["id"] = user.ID
+	claims["username"] = user.Username
+	claims["sid"] = session.ID
+	claims["org"] = organizationOrDefault(session.OrganizationID)
+	claims["exp"] = session.ExpiresAt.Unix()
+	if user.Roles != "" {
+		claims["roles"] = strings.Fields(user.Roles)
+	}
+	return signToken(claims)
+}
+
+func signToken(claims jwt.MapClaims) (string, error) {
+	tokenId, err := generateRandomToken("")
+	if err != nil {
+		return "", err
+	}
+	claims["jti"] = tokenId
+	claims["iat"] = time.Now().Unix()
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(os.Getenv("SECRET")))
+}
+
+func (t *AuthUsecaseImpl) RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scopes, restricted := c.Get("currentScopes")
+
+		if restricted {
+			grantedScopes, ok := scopes.([]string)
+			if !ok || !hasScope(grantedScopes, scope) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "token is missing required scope: " + scope})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
 }
 
-func NewAuthUsecaseImpl(userRepository repository.UserRepository) AuthUsecase {
+func NewAuthUsecaseImpl(userRepository repository.UserRepository, auditUsecase AuditUsecase, tokenRepository repository.PersonalAccessTokenRepository, clientRepository repository.OAuthClientRepository, oauthRepository repository.OAuthRepository, authenticator Authenticator, sessionRepository repository.SessionRepository, groupRepository repository.GroupRepository, attributeRepository repository.AttributeRepository) AuthUsecase {
 	return &AuthUsecaseImpl{
-		UserRepository: userRepository,
+		UserRepository:      userRepository,
+		AuditUsecase:        auditUsecase,
+		TokenRepository:     tokenRepository,
+		ClientRepository:    clientRepository,
+		OAuthRepository:     oauthRepository,
+		Authenticator:       authenticator,
+		SessionRepository:   sessionRepository,
+		GroupRepository:     groupRepository,
+		AttributeRepository: attributeRepository,
 	}
 }
@@ -15,6 +15,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/assert/v2"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestLogin(t *testing.T) {
@@ -22,25 +23,42 @@ func TestLogin(t *testing.T) {
 		findByUsernameResponse := mockUser
 
 		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
 
 		userRepositoryMock.On("FindByUsername").Return(findByUsernameResponse)
-
-		authUsecase := usecase.NewAuthUsecaseImpl(userRepositoryMock)
-		loginResult, err := authUsecase.Login(repository.Login{Username: "username", Password: "password"})
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		sessionRepositoryMock.On("Save", mock.MatchedBy(func(session repository.Session) bool {
+			return session.UserID == 100 && session.Kind == repository.SessionKindToken && session.Method == usecase.SessionMethodPassword && session.Device == "Firefox on Linux"
+		})).Return(loginSession)
+		groupRepositoryMock := new(mocks.GroupRepositoryMock)
+		groupRepositoryMock.On("FindParents", uint64(100), []uint64(nil)).Return([]repository.GroupMember{{GroupID: 1, UserID: 100}})
+		groupRepositoryMock.On("FindParents", uint64(0), []uint64{1}).Return([]repository.GroupMember{{GroupID: 2, MemberGroupID: 1}})
+		groupRepositoryMock.On("FindParents", uint64(0), []uint64{2}).Return([]repository.GroupMember{})
+		groupRepositoryMock.On("FindByIds", []uint64{1, 2}).Return([]repository.Group{{ID: 1, OrganizationID: 1, Name: "backend"}, {ID: 2, OrganizationID: 1, Name: "engineering"}, {ID: 3, OrganizationID: 2, Name: "other"}})
+
+		authUsecase := usecase.NewAuthUsecaseImpl(userRepositoryMock, auditUsecaseMock, nil, nil, nil, usecase.NewLocalAuthenticator(userRepositoryMock, auditUsecaseMock), sessionRepositoryMock, groupRepositoryMock, nil)
+		loginResult, err := authUsecase.Login(repository.Login{Username: "username", Password: "password"}, repository.LoginContext{UserAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0"})
 
 		assert.Equal(t, len(loginResult) > 0, true)
 		assert.Equal(t, err, nil)
+
+		claims := jwt.MapClaims{}
+		jwt.ParseWithClaims(loginResult, claims, func(token *jwt.Token) (interface{}, error) { return []byte(os.Getenv("SECRET")), nil })
+		assert.Equal(t, claims["groups"], []interface{}{"backend", "engineering"})
 	})
 
 	t.Run("test user not found login", func(t *testing.T) {
 		findByUsernameResponse := repository.User{}
 
 		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
 
 		userRepositoryMock.On("FindByUsername").Return(findByUsernameResponse)
 
-		authUsecase := usecase.NewAuthUsecaseImpl(userRepositoryMock)
-		loginResult, err := authUsecase.Login(repository.Login{Username: "username", Password: "password"})
+		authUsecase := usecase.NewAuthUsecaseImpl(userRepositoryMock, auditUsecaseMock, nil, nil, nil, usecase.NewLocalAuthenticator(userRepositoryMock, auditUsecaseMock), nil, nil, nil)
+		loginResult, err := authUsecase.Login(repository.Login{Username: "username", Password: "password"}, repository.LoginContext{})
 
 		assert.Equal(t, len(loginResult) > 0, false)
 		assert.Equal(t, err, helper.StandardError{Error: errors.New("user not found"), ErrorCode: 400})
@@ -50,11 +68,13 @@ func TestLogin(t *testing.T) {
 		findByUsernameResponse := mockUser
 
 		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
 
 		userRepositoryMock.On("FindByUsername").Return(findByUsernameResponse)
 
-		authUsecase := usecase.NewAuthUsecaseImpl(userRepositoryMock)
-		loginResult, err := authUsecase.Login(repository.Login{Username: "username", Password: "wrong password"})
+		authUsecase := usecase.NewAuthUsecaseImpl(userRepositoryMock, auditUsecaseMock, nil, nil, nil, usecase.NewLocalAuthenticator(userRepositoryMock, auditUsecaseMock), nil, nil, nil)
+		loginResult, err := authUsecase.Login(repository.Login{Username: "username", Password: "wrong password"}, repository.LoginContext{})
 
 		assert.Equal(t, len(loginResult) > 0, false)
 		assert.Equal(t, err, helper.StandardError{Error: errors.New("wrong password"), ErrorCode: 401})
@@ -66,12 +86,14 @@ func TestRegister(t *testing.T) {
 		expectedResponse := mockUserResponse
 
 		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
 
 		userRepositoryMock.On("FindByUsername").Return(repository.User{})
 		userRepositoryMock.On("Save").Return(mockUser)
 
-		authUsecase := usecase.NewAuthUsecaseImpl(userRepositoryMock)
-		registerResult, err := authUsecase.Register(repository.Register{Username: "username", Password: "password", Email: "test@mail.com"})
+		authUsecase := usecase.NewAuthUsecaseImpl(userRepositoryMock, auditUsecaseMock, nil, nil, nil, nil, nil, nil, noAttributeDefinitions())
+		registerResult, err := authUsecase.Register(repository.Register{Username: "username", Password: "password", Email: "test@mail.com"}, repository.DefaultOrganizationID)
 
 		assert.Equal(t, err, nil)
 		assert.Equal(t, registerResult, expectedResponse)
@@ -79,12 +101,14 @@ func TestRegister(t *testing.T) {
 
 	t.Run("user already exist", func(t *testing.T) {
 		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
 
 		userRepositoryMock.On("FindByUsername").Return(mockUser)
 		userRepositoryMock.On("Save").Return(mockUser)
 
-		authUsecase := usecase.NewAuthUsecaseImpl(userRepositoryMock)
-		registerResult, err := authUsecase.Register(repository.Register{Username: "username", Password: "password", Email: "test@mail.com"})
+		authUsecase := usecase.NewAuthUsecaseImpl(userRepositoryMock, auditUsecaseMock, nil, nil, nil, nil, nil, nil, noAttributeDefinitions())
+		registerResult, err := authUsecase.Register(repository.Register{Username: "username", Password: "password", Email: "test@mail.com"}, repository.DefaultOrganizationID)
 
 		assert.Equal(t, err, helper.StandardError{Error: errors.New("user already exist"), ErrorCode: http.StatusBadRequest})
 		assert.Equal(t, registerResult, nil)
@@ -92,12 +116,14 @@ func TestRegister(t *testing.T) {
 
 	t.Run("error hash password", func(t *testing.T) {
 		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
 
 		userRepositoryMock.On("FindByUsername").Return(repository.User{})
 		userRepositoryMock.On("Save").Return(mockUser)
 
-		authUsecase := usecase.NewAuthUsecaseImpl(userRepositoryMock)
-		registerResult, err := authUsecase.Register(repository.Register{Username: "username", Password: "superlongpasswordtextthatcanbehashedbylibrarysuperlongpasswordtextthatcanbehashedbylibrary", Email: "test@mail.com"})
+		authUsecase := usecase.NewAuthUsecaseImpl(userRepositoryMock, auditUsecaseMock, nil, nil, nil, nil, nil, nil, noAttributeDefinitions())
+		registerResult, err := authUsecase.Register(repository.Register{Username: "username", Password: "superlongpasswordtextthatcanbehashedbylibrarysuperlongpasswordtextthatcanbehashedbylibrary", Email: "test@mail.com"}, repository.DefaultOrganizationID)
 
 		assert.Equal(t, err, helper.StandardError{Error: errors.New("bcrypt: password length exceeds 72 bytes"), ErrorCode: http.StatusInternalServerError})
 		assert.Equal(t, registerResult, nil)
@@ -107,7 +133,9 @@ func TestValidateToken(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.Default()
 	userRepositoryMock := new(mocks.UserRepositoryMock)
-	authUsecase := usecase.NewAuthUsecaseImpl(userRepositoryMock)
+	auditUsecaseMock := new(mocks.AuditUsecaseMock)
+	sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+	authUsecase := usecase.NewAuthUsecaseImpl(userRepositoryMock, auditUsecaseMock, nil, nil, nil, nil, sessionRepositoryMock, nil, nil)
 	router.Use(authUsecase.ValidateToken)
 
 	router.GET("/test", func(c *gin.Context) {
@@ -117,9 +145,12 @@ func TestValidateToken(t *testing.T) {
 	os.Setenv("SECRET", "testkey")
 
 	t.Run("Valid token and user exists", func(t *testing.T) {
-		userRepositoryMock.On("FindByUsername").Return(mockUser)
+		userRepositoryMock.On("FindById").Return(mockUser)
+		sessionRepositoryMock.On("FindById").Return(repository.Session{ID: 5, UserID: mockUser.ID, LastSeenAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)})
 		claims := jwt.MapClaims{
-			"username": "validUser",
+			"id":       mockUser.ID,
+			"username": mockUser.Username,
+			"sid":      5,
 			"exp":      float64(time.Now().Add(time.Hour).Unix()),
 		}
 		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -138,7 +169,8 @@ func TestValidateTokenNegative(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.Default()
 	userRepositoryMock := new(mocks.UserRepositoryMock)
-	authUsecase := usecase.NewAuthUsecaseImpl(userRepositoryMock)
+	auditUsecaseMock := new(mocks.AuditUsecaseMock)
+	authUsecase := usecase.NewAuthUsecaseImpl(userRepositoryMock, auditUsecaseMock, nil, nil, nil, nil, nil, nil, nil)
 	router.Use(authUsecase.ValidateToken)
 
 	router.GET("/test", func(c *gin.Context) {
@@ -180,9 +212,28 @@ func TestValidateTokenNegative(t *testing.T) {
 	})
 
 	t.Run("Valid token and user not exists", func(t *testing.T) {
-		userRepositoryMock.On("FindByUsername").Return(repository.User{})
+		userRepositoryMock.On("FindById").Return(repository.User{})
 		claims := jwt.MapClaims{
+			"id":       100,
 			"username": "validUser",
+			"sid":      5,
+			"exp":      float64(time.Now().Add(time.Hour).Unix()),
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		tokenString, _ := token.SignedString([]byte(os.Getenv("SECRET")))
+
+		req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Add("Authorization", "Bearer "+tokenString)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("User token without a session", func(t *testing.T) {
+		claims := jwt.MapClaims{
+			"id":       100,
+			"username": "username",
 			"exp":      float64(time.Now().Add(time.Hour).Unix()),
 		}
 		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -194,5 +245,232 @@ func TestValidateTokenNegative(t *testing.T) {
 		router.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "invalid token")
+	})
+}
+
+func TestValidatePersonalAccessToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(tokenRepositoryMock *mocks.PersonalAccessTokenRepositoryMock, userRepositoryMock *mocks.UserRepositoryMock, scope string) *gin.Engine {
+		authUsecase := usecase.NewAuthUsecaseImpl(userRepositoryMock, new(mocks.AuditUsecaseMock), tokenRepositoryMock, nil, nil, nil, nil, nil, nil)
+		router := gin.Default()
+		router.GET("/test", authUsecase.ValidateToken, authUsecase.RequireScope(scope), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		return router
+	}
+
+	validToken := repository.PersonalAccessToken{
+		ID:        1,
+		UserID:    100,
+		Scopes:    "users:read",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	t.Run("Valid token with scope", func(t *testing.T) {
+		tokenRepositoryMock := new(mocks.PersonalAccessTokenRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+
+		tokenRepositoryMock.On("FindByHash").Return(validToken)
+		tokenRepositoryMock.On("UpdateLastUsed", uint64(1), mock.Anything).Return()
+		userRepositoryMock.On("FindById").Return(mockUser)
+
+		req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Add("Authorization", "Bearer gum_pat_secret")
+		w := httptest.NewRecorder()
+		newRouter(tokenRepositoryMock, userRepositoryMock, usecase.ScopeUsersRead).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		tokenRepositoryMock.AssertCalled(t, "UpdateLastUsed", uint64(1), mock.Anything)
+	})
+
+	t.Run("Valid token missing scope", func(t *testing.T) {
+		tokenRepositoryMock := new(mocks.PersonalAccessTokenRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+
+		tokenRepositoryMock.On("FindByHash").Return(validToken)
+		tokenRepositoryMock.On("UpdateLastUsed", uint64(1), mock.Anything).Return()
+		userRepositoryMock.On("FindById").Return(mockUser)
+
+		req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Add("Authorization", "Bearer gum_pat_secret")
+		w := httptest.NewRecorder()
+		newRouter(tokenRepositoryMock, userRepositoryMock, usecase.ScopeUsersWrite).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "token is missing required scope: users:write")
+	})
+
+	t.Run("Revoked token", func(t *testing.T) {
+		revokedAt := time.Now()
+		revokedToken := validToken
+		revokedToken.RevokedAt = &revokedAt
+
+		tokenRepositoryMock := new(mocks.PersonalAccessTokenRepositoryMock)
+		tokenRepositoryMock.On("FindByHash").Return(revokedToken)
+
+		req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Add("Authorization", "Bearer gum_pat_secret")
+		w := httptest.NewRecorder()
+		newRouter(tokenRepositoryMock, new(mocks.UserRepositoryMock), usecase.ScopeUsersRead).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "invalid or expired token")
+	})
+
+	t.Run("Expired token", func(t *testing.T) {
+		expiredToken := validToken
+		expiredToken.ExpiresAt = time.Now().Add(-time.Minute)
+
+		tokenRepositoryMock := new(mocks.PersonalAccessTokenRepositoryMock)
+		tokenRepositoryMock.On("FindByHash").Return(expiredToken)
+
+		req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Add("Authorization", "Bearer gum_pat_secret")
+		w := httptest.NewRecorder()
+		newRouter(tokenRepositoryMock, new(mocks.UserRepositoryMock), usecase.ScopeUsersRead).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestValidateClientToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	os.Setenv("SECRET", "testkey")
+
+	newRouter := func(clientRepositoryMock *mocks.OAuthClientRepositoryMock, scope string) *gin.Engine {
+		authUsecase := usecase.NewAuthUsecaseImpl(new(mocks.UserRepositoryMock), new(mocks.AuditUsecaseMock), nil, clientRepositoryMock, nil, nil, nil, nil, nil)
+		router := gin.Default()
+		router.GET("/test", authUsecase.ValidateToken, authUsecase.RequireScope(scope), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		return router
+	}
+
+	clientToken := func() string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"client_id": "gum_client_test",
+			"scope":     "users:read",
+			"exp":       float64(time.Now().Add(time.Hour).Unix()),
+		})
+		tokenString, _ := token.SignedString([]byte(os.Getenv("SECRET")))
+		return tokenString
+	}
+
+	t.Run("Valid client token", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		clientRepositoryMock.On("FindByClientId").Return(repository.OAuthClient{ID: 1, ClientID: "gum_client_test"})
+
+		req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Add("Authorization", "Bearer "+clientToken())
+		w := httptest.NewRecorder()
+		newRouter(clientRepositoryMock, usecase.ScopeUsersRead).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Client token acts in the client's organization", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		clientRepositoryMock.On("FindByClientId").Return(repository.OAuthClient{ID: 1, ClientID: "gum_client_test", OrganizationID: 2})
+		authUsecase := usecase.NewAuthUsecaseImpl(new(mocks.UserRepositoryMock), new(mocks.AuditUsecaseMock), nil, clientRepositoryMock, nil, nil, nil, nil, nil)
+
+		var organizationId interface{}
+		router := gin.Default()
+		router.GET("/test", authUsecase.ValidateToken, func(c *gin.Context) {
+			organizationId, _ = c.Get("currentOrganizationId")
+			c.Status(http.StatusOK)
+		})
+
+		req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Add("Authorization", "Bearer "+clientToken())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, uint64(2), organizationId)
+	})
+
+	t.Run("Disabled client", func(t *testing.T) {
+		disabledAt := time.Now()
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		clientRepositoryMock.On("FindByClientId").Return(repository.OAuthClient{ID: 1, ClientID: "gum_client_test", DisabledAt: &disabledAt})
+
+		req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Add("Authorization", "Bearer "+clientToken())
+		w := httptest.NewRecorder()
+		newRouter(clientRepositoryMock, usecase.ScopeUsersRead).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "client is disabled or does not exist")
+	})
+}
+
+func TestParseToken(t *testing.T) {
+	os.Setenv("SECRET", "testkey")
+
+	loginToken := func() string {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+		userRepositoryMock.On("FindByUsername").Return(mockUser)
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		sessionRepositoryMock.On("Save", mock.Anything).Return(loginSession)
+		groupRepositoryMock := new(mocks.GroupRepositoryMock)
+		groupRepositoryMock.On("FindParents", mock.Anything, mock.Anything).Return([]repository.GroupMember{})
+		groupRepositoryMock.On("FindByIds", mock.Anything).Return([]repository.Group{})
+
+		token, _ := usecase.NewAuthUsecaseImpl(userRepositoryMock, auditUsecaseMock, nil, nil, nil, usecase.NewLocalAuthenticator(userRepositoryMock, auditUsecaseMock), sessionRepositoryMock, groupRepositoryMock, nil).Login(repository.Login{Username: "username", Password: "password"}, repository.LoginContext{})
+		return token
+	}
+
+	t.Run("test normal parse of login token", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+
+		userRepositoryMock.On("FindById").Return(mockUser)
+		oauthRepositoryMock.On("IsTokenRevoked").Return(false)
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		sessionRepositoryMock.On("FindById").Return(loginSession)
+
+		authUsecase := usecase.NewAuthUsecaseImpl(userRepositoryMock, nil, nil, nil, oauthRepositoryMock, nil, sessionRepositoryMock, nil, nil)
+		tokenInfo, err := authUsecase.ParseToken(loginToken())
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, tokenInfo.UserID, mockUser.ID)
+		assert.Equal(t, tokenInfo.OrganizationID, repository.DefaultOrganizationID)
+		assert.Equal(t, tokenInfo.Scopes, nil)
+		assert.NotEqual(t, tokenInfo.JTI, "")
+		assert.Equal(t, tokenInfo.ExpiresAt > tokenInfo.IssuedAt, true)
+	})
+
+	t.Run("terminated session", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+
+		userRepositoryMock.On("FindById").Return(mockUser)
+		oauthRepositoryMock.On("IsTokenRevoked").Return(false)
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		sessionRepositoryMock.On("FindById").Return(repository.Session{})
+
+		authUsecase := usecase.NewAuthUsecaseImpl(userRepositoryMock, nil, nil, nil, oauthRepositoryMock, nil, sessionRepositoryMock, nil, nil)
+		tokenInfo, err := authUsecase.ParseToken(loginToken())
+
+		assert.Equal(t, tokenInfo, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("invalid or expired token"), ErrorCode: http.StatusUnauthorized})
+	})
+
+	t.Run("revoked token", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+
+		userRepositoryMock.On("FindById").Return(mockUser)
+		oauthRepositoryMock.On("IsTokenRevoked").Return(true)
+
+		authUsecase := usecase.NewAuthUsecaseImpl(userRepositoryMock, nil, nil, nil, oauthRepositoryMock, nil, nil, nil, nil)
+		tokenInfo, err := authUsecase.ParseToken(loginToken())
+
+		assert.Equal(t, tokenInfo, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("invalid or expired token"), ErrorCode: http.StatusUnauthorized})
 	})
 }
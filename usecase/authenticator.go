@@ -0,0 +1,127 @@
+package usecase
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrUserNotFound = errors.New("user not found")
+
+var errUserDisabled = &helper.StandardError{Error: errors.New("user is disabled"), ErrorCode: http.StatusForbidden}
+
+type Authenticator interface {
+	Authenticate(loginData repository.Login) (*repository.User, *helper.StandardError)
+}
+
+type LocalAuthenticator struct {
+	UserRepository repository.UserRepository
+	AuditUsecase   AuditUsecase
+}
+
+func (t *LocalAuthenticator) Authenticate(loginData repository.Login) (*repository.User, *helper.StandardError) {
+	userFound := t.UserRepository.ForOrganization(loginData.OrganizationID).FindByUsername(loginData.Username)
+
+	if userFound.ID == 0 {
+		return nil, &helper.StandardError{Error: ErrUserNotFound, ErrorCode: http.StatusBadRequest}
+	}
+
+	ok, legacy := verifyPassword(userFound.Password, loginData.Password)
+	if !ok {
+		t.AuditUsecase.Record("user.login_failed", 0, userFound.ID, "wrong password")
+		return nil, &helper.StandardError{Error: errors.New("wrong password"), ErrorCode: http.StatusUnauthorized}
+	}
+
+	if userFound.DisabledAt != nil {
+		return nil, errUserDisabled
+	}
+
+	if legacy {
+		t.rehashPassword(&userFound, loginData.Password)
+	}
+
+	return &userFound, nil
+}
+
+// rehashPassword replaces a hash imported from another system with bcrypt. Failing to do so does not fail the
+// login; the imported hash keeps working and is replaced on a later login.
+func (t *LocalAuthenticator) rehashPassword(user *repository.User, password string) {
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Printf("Failed to rehash password of user %d: %v", user.ID, err)
+		return
+	}
+
+	if !t.UserRepository.ForOrganization(user.OrganizationID).ReplacePassword(user.ID, user.Password, string(passwordHash)) {
+		log.Printf("Failed to rehash password of user %d", user.ID)
+		return
+	}
+	user.Password = string(passwordHash)
+	t.AuditUsecase.Record("user.password_rehash", user.ID, user.ID, "")
+}
+
+type ChainAuthenticator struct {
+	Authenticators []Authenticator
+}
+
+func (t *ChainAuthenticator) Authenticate(loginData repository.Login) (*repository.User, *helper.StandardError) {
+	var firstError *helper.StandardError
+
+	for _, authenticator := range t.Authenticators {
+		user, err := authenticator.Authenticate(loginData)
+		if err == nil {
+			return user, nil
+		}
+		if firstError == nil || errors.Is(firstError.Error, ErrUserNotFound) {
+			firstError = err
+		}
+	}
+
+	if firstError == nil {
+		return nil, &helper.StandardError{Error: ErrUserNotFound, ErrorCode: http.StatusBadRequest}
+	}
+	return nil, firstError
+}
+
+func NewLocalAuthenticator(userRepository repository.UserRepository, auditUsecase AuditUsecase) Authenticator {
+	return &LocalAuthenticator{
+		UserRepository: userRepository,
+		AuditUsecase:   auditUsecase,
+	}
+}
+
+func NewChainAuthenticator(authenticators ...Authenticator) Authenticator {
+	return &ChainAuthenticator{
+		Authenticators: authenticators,
+	}
+}
+
+func NewAuthenticator(userRepository repository.UserRepository, federationRepository repository.FederationRepository, auditUsecase AuditUsecase) Authenticator {
+	backends := os.Getenv("AUTHENTICATORS")
+	if backends == "" {
+		backends = "local"
+	}
+
+	var authenticators []Authenticator
+	for _, backend := range strings.Split(backends, ",") {
+		switch strings.TrimSpace(backend) {
+		case "local":
+			authenticators = append(authenticators, NewLocalAuthenticator(userRepository, auditUsecase))
+		case "ldap":
+			authenticators = append(authenticators, NewLDAPAuthenticator(userRepository, federationRepository, auditUsecase))
+		default:
+			log.Fatal("Unknown authenticator: ", backend)
+		}
+	}
+
+	if len(authenticators) == 1 {
+		return authenticators[0]
+	}
+	return NewChainAuthenticator(authenticators...)
+}
@@ -0,0 +1,147 @@
+package usecase_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestChainAuthenticator(t *testing.T) {
+	userNotFound := &helper.StandardError{Error: usecase.ErrUserNotFound, ErrorCode: http.StatusBadRequest}
+	wrongPassword := &helper.StandardError{Error: errors.New("wrong password"), ErrorCode: http.StatusUnauthorized}
+
+	t.Run("test falls through to the next authenticator", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		userRepositoryMock.On("FindByUsername").Return(repository.User{})
+		localAuthenticator := usecase.NewLocalAuthenticator(userRepositoryMock, nil)
+
+		ldapAuthenticatorMock := new(mocks.AuthenticatorMock)
+		ldapAuthenticatorMock.On("Authenticate").Return(&mockUser, (*helper.StandardError)(nil))
+
+		user, err := usecase.NewChainAuthenticator(localAuthenticator, ldapAuthenticatorMock).Authenticate(repository.Login{Username: "username", Password: "password"})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, user.ID, mockUser.ID)
+	})
+
+	t.Run("first authenticator wins", func(t *testing.T) {
+		localAuthenticatorMock := new(mocks.AuthenticatorMock)
+		localAuthenticatorMock.On("Authenticate").Return(&mockUser, (*helper.StandardError)(nil))
+		ldapAuthenticatorMock := new(mocks.AuthenticatorMock)
+
+		user, err := usecase.NewChainAuthenticator(localAuthenticatorMock, ldapAuthenticatorMock).Authenticate(repository.Login{Username: "username", Password: "password"})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, user.ID, mockUser.ID)
+		ldapAuthenticatorMock.AssertNotCalled(t, "Authenticate")
+	})
+
+	t.Run("reports the error of the authenticator that knows the user", func(t *testing.T) {
+		localAuthenticatorMock := new(mocks.AuthenticatorMock)
+		localAuthenticatorMock.On("Authenticate").Return((*repository.User)(nil), userNotFound)
+		ldapAuthenticatorMock := new(mocks.AuthenticatorMock)
+		ldapAuthenticatorMock.On("Authenticate").Return((*repository.User)(nil), wrongPassword)
+
+		user, err := usecase.NewChainAuthenticator(localAuthenticatorMock, ldapAuthenticatorMock).Authenticate(repository.Login{Username: "username", Password: "password"})
+
+		assert.Equal(t, user, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("wrong password"), ErrorCode: http.StatusUnauthorized})
+	})
+}
+
+func TestLocalAuthenticator(t *testing.T) {
+	t.Run("disabled user", func(t *testing.T) {
+		disabledUser := mockUser
+		disabledUser.DisabledAt = &disabledAt
+
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		userRepositoryMock.On("FindByUsername").Return(disabledUser)
+
+		user, err := usecase.NewLocalAuthenticator(userRepositoryMock, nil).Authenticate(repository.Login{Username: "username", Password: "password"})
+
+		assert.Equal(t, user, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("user is disabled"), ErrorCode: http.StatusForbidden})
+	})
+	t.Run("legacy password hashes are rehashed", func(t *testing.T) {
+		legacyHashes := map[string]string{
+			"django pbkdf2":            "pbkdf2_sha256$1000$seasalt$mQnueSakb748zqBAC1tmWVZsZbi2zPGZarEzTGdfmso=",
+			"django scrypt":            "scrypt$seasalt$1024$8$1$b9vqOe0B5HZh2f10Q9RPN9OFNPtmb+ezp5YOlnZsJQ0vQ5I3eq8Zt7GFMuqQ58xCxTIvVL1QCL/PTXEWr+RNHA==",
+			"md5-crypt":                "$1$saltsalt$NuzA7WTAelpl95xgBGWN60",
+			"sha512-crypt":             "$6$saltsaltsaltsalt$GkzgkzVbauGAKXpOTbypQEKy/9yJWVjcvXvDw7CxoJjnJ1.w.g1rV8bhCVTpHrRrO/h6b3DAwPN3y5qmHXZ1R1",
+			"sha512-crypt with rounds": "$6$rounds=1000$short$0e8c4VH2.9WECWJ4hEU7wwl83rldSLVuQftBmF/xkj96Wh4.yNfDVKXLBTXD0R1YGFzxp1NCeSFbNup.13awx1",
+		}
+		for name, passwordHash := range legacyHashes {
+			t.Run(name, func(t *testing.T) {
+				legacyUser := mockUser
+				legacyUser.Password = passwordHash
+
+				userRepositoryMock := new(mocks.UserRepositoryMock)
+				auditUsecaseMock := new(mocks.AuditUsecaseMock)
+				userRepositoryMock.On("FindByUsername").Return(legacyUser)
+				userRepositoryMock.On("ReplacePassword", mock.MatchedBy(func(passwordHash string) bool {
+					return bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte("correct horse")) == nil
+				})).Return(true)
+				auditUsecaseMock.On("Record").Return(nil)
+				authenticator := usecase.NewLocalAuthenticator(userRepositoryMock, auditUsecaseMock)
+
+				_, err := authenticator.Authenticate(repository.Login{Username: "username", Password: "wrong horse"})
+				assert.Equal(t, err, helper.StandardError{Error: errors.New("wrong password"), ErrorCode: http.StatusUnauthorized})
+				userRepositoryMock.AssertNotCalled(t, "ReplacePassword", mock.Anything)
+
+				user, err := authenticator.Authenticate(repository.Login{Username: "username", Password: "correct horse"})
+				assert.Equal(t, err, nil)
+				assert.MatchRegex(t, user.Password, `^\$2a\$10\$`)
+				userRepositoryMock.AssertNumberOfCalls(t, "ReplacePassword", 1)
+			})
+		}
+	})
+
+	t.Run("phpass", func(t *testing.T) {
+		legacyUser := mockUser
+		legacyUser.Password = "$P$9IQRaTwmfeRo7ud9Fh4E2PdI0S3r.L0"
+
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		userRepositoryMock.On("FindByUsername").Return(legacyUser)
+		userRepositoryMock.On("ReplacePassword", mock.Anything).Return(true)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		user, err := usecase.NewLocalAuthenticator(userRepositoryMock, auditUsecaseMock).Authenticate(repository.Login{Username: "username", Password: "test12345"})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("test12345")), nil)
+	})
+
+	t.Run("password changed during the login", func(t *testing.T) {
+		legacyUser := mockUser
+		legacyUser.Password = "$P$9IQRaTwmfeRo7ud9Fh4E2PdI0S3r.L0"
+
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		userRepositoryMock.On("FindByUsername").Return(legacyUser)
+		userRepositoryMock.On("ReplacePassword", mock.Anything).Return(false)
+
+		user, err := usecase.NewLocalAuthenticator(userRepositoryMock, nil).Authenticate(repository.Login{Username: "username", Password: "test12345"})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, user.Password, legacyUser.Password)
+	})
+
+	t.Run("bcrypt hashes are kept", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		userRepositoryMock.On("FindByUsername").Return(mockUser)
+
+		user, err := usecase.NewLocalAuthenticator(userRepositoryMock, nil).Authenticate(repository.Login{Username: "username", Password: "password"})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, user.Password, mockUser.Password)
+		userRepositoryMock.AssertNotCalled(t, "ReplacePassword", mock.Anything)
+	})
+}
@@ -0,0 +1,224 @@
+package usecase
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	avatarKeyPrefix       = "avatars/"
+	defaultAvatarMaxBytes = 5 << 20
+	// maxAvatarDimension bounds the decoded size, so a small file cannot expand into a huge bitmap.
+	maxAvatarDimension = 4096
+)
+
+// AvatarSizes are the square thumbnails stored for every avatar; the largest one is the user's avatar URL.
+var AvatarSizes = []int{32, 64, 128, 256}
+
+var avatarContentTypes = map[string]string{
+	"image/png":  "png",
+	"image/jpeg": "jpeg",
+	"image/gif":  "gif",
+	"image/webp": "webp",
+}
+
+var errInvalidImage = &helper.StandardError{Error: errors.New("invalid image"), ErrorCode: http.StatusBadRequest}
+
+type AvatarUsecase interface {
+	SetAvatar(userId uint64, data []byte) (*repository.UserResponse, *helper.StandardError)
+	RemoveAvatar(userId uint64) (*repository.UserResponse, *helper.StandardError)
+	GetAvatar(key string) ([]byte, *helper.StandardError)
+}
+
+type AvatarUsecaseImpl struct {
+	UserRepository repository.UserRepository
+	BlobStore      BlobStore
+	AuditUsecase   AuditUsecase
+}
+
+// AvatarMaxBytes is the largest accepted upload, set with AVATAR_MAX_BYTES.
+func AvatarMaxBytes() int64 {
+	if maxBytes, err := strconv.ParseInt(os.Getenv("AVATAR_MAX_BYTES"), 10, 64); err == nil && maxBytes > 0 {
+		return maxBytes
+	}
+	return defaultAvatarMaxBytes
+}
+
+func avatarThumbnailKey(avatarKey string, size int) string {
+	return fmt.Sprintf("%s/%d.png", avatarKey, size)
+}
+
+// decodeAvatar sniffs the uploaded bytes instead of trusting the declared content type and fully decodes them,
+// so only well-formed images of a supported format are processed.
+func decodeAvatar(data []byte) (image.Image, *helper.StandardError) {
+	format, ok := avatarContentTypes[http.DetectContentType(data)]
+	if !ok {
+		return nil, &helper.StandardError{Error: errors.New("avatar must be a PNG, JPEG, GIF or WebP image"), ErrorCode: http.StatusUnsupportedMediaType}
+	}
+
+	config, decodedFormat, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || decodedFormat != format || config.Width == 0 || config.Height == 0 {
+		return nil, errInvalidImage
+	}
+	if config.Width > maxAvatarDimension || config.Height > maxAvatarDimension {
+		return nil, &helper.StandardError{Error: fmt.Errorf("avatar must be at most %dx%d pixels", maxAvatarDimension, maxAvatarDimension), ErrorCode: http.StatusBadRequest}
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errInvalidImage
+	}
+	return img, nil
+}
+
+// thumbnail crops the centered square of the image and scales it to size x size pixels.
+func thumbnail(img image.Image, size int) ([]byte, error) {
+	bounds := img.Bounds()
+	side := bounds.Dx()
+	if bounds.Dy() < side {
+		side = bounds.Dy()
+	}
+	x := bounds.Min.X + (bounds.Dx()-side)/2
+	y := bounds.Min.Y + (bounds.Dy()-side)/2
+
+	scaled := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, image.Rect(x, y, x+side, y+side), draw.Src, nil)
+
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, scaled); err != nil {
+		return nil, err
+	}
+	return encoded.Bytes(), nil
+}
+
+func deleteAvatar(blobStore BlobStore, avatarKey string) {
+	for _, size := range AvatarSizes {
+		if err := blobStore.Delete(avatarThumbnailKey(avatarKey, size)); err != nil {
+			log.Printf("Failed to delete avatar %s: %v", avatarKey, err)
+		}
+	}
+}
+
+func avatarUserResponse(user repository.User) *repository.UserResponse {
+	return &repository.UserResponse{
+		ID:             user.ID,
+		OrganizationID: user.OrganizationID,
+		Username:       user.Username,
+		Email:          user.Email,
+		Attributes:     user.Attributes,
+		AvatarURL:      user.AvatarURL,
+		CreatedAt:      user.CreatedAt,
+	}
+}
+
+// SetAvatar stores the thumbnails under a new random key, so cached copies of a previous avatar are never served
+// for the new one, and deletes the previous avatar afterwards.
+func (t *AvatarUsecaseImpl) SetAvatar(userId uint64, data []byte) (*repository.UserResponse, *helper.StandardError) {
+	if int64(len(data)) > AvatarMaxBytes() {
+		return nil, &helper.StandardError{Error: fmt.Errorf("avatar must be at most %d bytes", AvatarMaxBytes()), ErrorCode: http.StatusRequestEntityTooLarge}
+	}
+
+	user := t.UserRepository.FindById(userId)
+	if user.ID == 0 {
+		return nil, &helper.StandardError{Error: errors.New("user not found"), ErrorCode: http.StatusNotFound}
+	}
+
+	img, decodeError := decodeAvatar(data)
+	if decodeError != nil {
+		return nil, decodeError
+	}
+
+	token, err := generateRandomToken("")
+	if err != nil {
+		return nil, &helper.StandardError{Error: errors.New("failed to store avatar"), ErrorCode: http.StatusInternalServerError}
+	}
+	avatarKey := fmt.Sprintf("%s%d/%s", avatarKeyPrefix, user.ID, token)
+
+	for _, size := range AvatarSizes {
+		encoded, err := thumbnail(img, size)
+		if err == nil {
+			err = t.BlobStore.Put(avatarThumbnailKey(avatarKey, size), "image/png", encoded)
+		}
+		if err != nil {
+			log.Printf("Failed to store avatar: %v", err)
+			deleteAvatar(t.BlobStore, avatarKey)
+			return nil, &helper.StandardError{Error: errors.New("failed to store avatar"), ErrorCode: http.StatusBadGateway}
+		}
+	}
+
+	previousKey := user.AvatarKey
+	user.AvatarKey = avatarKey
+	user.AvatarURL = t.BlobStore.URL(avatarThumbnailKey(avatarKey, AvatarSizes[len(AvatarSizes)-1]))
+	if !t.UserRepository.UpdateAvatar(user.ID, user.AvatarKey, user.AvatarURL) {
+		deleteAvatar(t.BlobStore, avatarKey)
+		return nil, &helper.StandardError{Error: errors.New("user not found"), ErrorCode: http.StatusNotFound}
+	}
+	if previousKey != "" {
+		deleteAvatar(t.BlobStore, previousKey)
+	}
+
+	t.AuditUsecase.Record("user.avatar", user.ID, user.ID, "")
+
+	return avatarUserResponse(user), nil
+}
+
+func (t *AvatarUsecaseImpl) RemoveAvatar(userId uint64) (*repository.UserResponse, *helper.StandardError) {
+	user := t.UserRepository.FindById(userId)
+	if user.ID == 0 {
+		return nil, &helper.StandardError{Error: errors.New("user not found"), ErrorCode: http.StatusNotFound}
+	}
+
+	if user.AvatarKey != "" {
+		avatarKey := user.AvatarKey
+		user.AvatarKey = ""
+		user.AvatarURL = ""
+		if !t.UserRepository.UpdateAvatar(user.ID, "", "") {
+			return nil, &helper.StandardError{Error: errors.New("user not found"), ErrorCode: http.StatusNotFound}
+		}
+		deleteAvatar(t.BlobStore, avatarKey)
+		t.AuditUsecase.Record("user.avatar.remove", user.ID, user.ID, "")
+	}
+
+	return avatarUserResponse(user), nil
+}
+
+// GetAvatar serves thumbnails from a local blob store; only avatar keys can be read.
+func (t *AvatarUsecaseImpl) GetAvatar(key string) ([]byte, *helper.StandardError) {
+	notFound := &helper.StandardError{Error: errors.New("avatar not found"), ErrorCode: http.StatusNotFound}
+	if !strings.HasPrefix(key, avatarKeyPrefix) || strings.Contains(key, "..") {
+		return nil, notFound
+	}
+
+	data, err := t.BlobStore.Get(key)
+	if errors.Is(err, ErrBlobNotFound) {
+		return nil, notFound
+	}
+	if err != nil {
+		log.Printf("Failed to read avatar: %v", err)
+		return nil, &helper.StandardError{Error: errors.New("failed to read avatar"), ErrorCode: http.StatusBadGateway}
+	}
+	return data, nil
+}
+
+func NewAvatarUsecaseImpl(userRepository repository.UserRepository, blobStore BlobStore, auditUsecase AuditUsecase) AvatarUsecase {
+	return &AvatarUsecaseImpl{
+		UserRepository: userRepository,
+		BlobStore:      blobStore,
+		AuditUsecase:   auditUsecase,
+	}
+}
@@ -0,0 +1,129 @@
+package usecase_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/usecase"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/stretchr/testify/mock"
+)
+
+func encodedPNG(t *testing.T, width int, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, 0, color.RGBA{R: 255, A: 255})
+	}
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, img); err != nil {
+		t.Fatal(err)
+	}
+	return encoded.Bytes()
+}
+
+func TestSetAvatar(t *testing.T) {
+	t.Run("test store thumbnails and replace previous avatar", func(t *testing.T) {
+		blobStore := &usecase.LocalBlobStore{Dir: t.TempDir(), BaseURL: "http://localhost:3000/blobs"}
+		if err := blobStore.Put("avatars/100/previous/256.png", "image/png", []byte("previous")); err != nil {
+			t.Fatal(err)
+		}
+
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		previous := mockUser
+		previous.AvatarKey = "avatars/100/previous"
+		userRepositoryMock.On("FindById").Return(previous)
+		userRepositoryMock.On("UpdateAvatar", mock.MatchedBy(func(avatarKey string) bool {
+			return strings.HasPrefix(avatarKey, "avatars/100/")
+		}), mock.Anything).Return(true)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		avatarUsecase := usecase.NewAvatarUsecaseImpl(userRepositoryMock, blobStore, auditUsecaseMock)
+		user, err := avatarUsecase.SetAvatar(100, encodedPNG(t, 300, 200))
+
+		assert.Equal(t, err, nil)
+		assert.MatchRegex(t, user.AvatarURL, `^http://localhost:3000/blobs/avatars/100/[\w-]+/256\.png$`)
+		userRepositoryMock.AssertCalled(t, "UpdateAvatar", mock.Anything, user.AvatarURL)
+
+		key := strings.TrimPrefix(user.AvatarURL, "http://localhost:3000/blobs/")
+		for _, size := range usecase.AvatarSizes {
+			data, getError := avatarUsecase.GetAvatar(fmt.Sprintf("%s%d.png", strings.TrimSuffix(key, "256.png"), size))
+			assert.Equal(t, getError, nil)
+			config, decodeError := png.DecodeConfig(bytes.NewReader(data))
+			assert.Equal(t, decodeError, nil)
+			assert.Equal(t, config.Width, size)
+			assert.Equal(t, config.Height, size)
+		}
+
+		_, statError := os.Stat(filepath.Join(blobStore.Dir, "avatars/100/previous/256.png"))
+		assert.Equal(t, os.IsNotExist(statError), true)
+	})
+
+	t.Run("user deleted during the upload", func(t *testing.T) {
+		blobStore := &usecase.LocalBlobStore{Dir: t.TempDir(), BaseURL: "http://localhost:3000/blobs"}
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		userRepositoryMock.On("FindById").Return(mockUser)
+		userRepositoryMock.On("UpdateAvatar", mock.Anything, mock.Anything).Return(false)
+
+		_, err := usecase.NewAvatarUsecaseImpl(userRepositoryMock, blobStore, nil).SetAvatar(100, encodedPNG(t, 64, 64))
+
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("user not found"), ErrorCode: http.StatusNotFound})
+		entries, _ := os.ReadDir(filepath.Join(blobStore.Dir, "avatars/100"))
+		for _, entry := range entries {
+			thumbnails, _ := os.ReadDir(filepath.Join(blobStore.Dir, "avatars/100", entry.Name()))
+			assert.Equal(t, len(thumbnails), 0)
+		}
+	})
+
+	t.Run("rejected uploads", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		userRepositoryMock.On("FindById").Return(mockUser)
+		avatarUsecase := usecase.NewAvatarUsecaseImpl(userRepositoryMock, &usecase.LocalBlobStore{Dir: t.TempDir()}, nil)
+
+		_, err := avatarUsecase.SetAvatar(100, []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"))
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("avatar must be a PNG, JPEG, GIF or WebP image"), ErrorCode: http.StatusUnsupportedMediaType})
+
+		truncated := encodedPNG(t, 64, 64)
+		_, err = avatarUsecase.SetAvatar(100, truncated[:len(truncated)/2])
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("invalid image"), ErrorCode: http.StatusBadRequest})
+
+		_, err = avatarUsecase.SetAvatar(100, encodedPNG(t, 5000, 1))
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("avatar must be at most 4096x4096 pixels"), ErrorCode: http.StatusBadRequest})
+
+		t.Setenv("AVATAR_MAX_BYTES", "100")
+		_, err = avatarUsecase.SetAvatar(100, encodedPNG(t, 64, 64))
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("avatar must be at most 100 bytes"), ErrorCode: http.StatusRequestEntityTooLarge})
+	})
+}
+
+func TestGetAvatar(t *testing.T) {
+	blobStore := &usecase.LocalBlobStore{Dir: t.TempDir()}
+	if err := blobStore.Put("avatars/100/key/32.png", "image/png", []byte("thumbnail")); err != nil {
+		t.Fatal(err)
+	}
+	if err := blobStore.Put("private/secret", "text/plain", []byte("secret")); err != nil {
+		t.Fatal(err)
+	}
+	avatarUsecase := usecase.NewAvatarUsecaseImpl(nil, blobStore, nil)
+
+	data, err := avatarUsecase.GetAvatar("avatars/100/key/32.png")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, string(data), "thumbnail")
+
+	notFound := helper.StandardError{Error: errors.New("avatar not found"), ErrorCode: http.StatusNotFound}
+	for _, key := range []string{"avatars/100/key/64.png", "private/secret", "avatars/../private/secret"} {
+		_, err = avatarUsecase.GetAvatar(key)
+		assert.Equal(t, err, notFound)
+	}
+}
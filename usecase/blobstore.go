@@ -0,0 +1,217 @@
+package usecase
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore keeps uploaded files such as avatars. Keys are slash separated paths like "avatars/100/abc/64.png".
+type BlobStore interface {
+	Put(key string, contentType string, data []byte) error
+	Get(key string) ([]byte, error)
+	Delete(key string) error
+	URL(key string) string
+}
+
+// LocalBlobStore writes blobs below Dir; they are served by the application under BaseURL.
+type LocalBlobStore struct {
+	Dir     string
+	BaseURL string
+}
+
+// path rejects keys that would escape Dir.
+func (t *LocalBlobStore) path(key string) (string, error) {
+	cleaned := filepath.Clean("/" + key)
+	if key == "" || cleaned != "/"+key {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(t.Dir, filepath.FromSlash(cleaned)), nil
+}
+
+func (t *LocalBlobStore) Put(key string, contentType string, data []byte) error {
+	path, err := t.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func (t *LocalBlobStore) Get(key string) ([]byte, error) {
+	path, err := t.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+	return data, err
+}
+
+func (t *LocalBlobStore) Delete(key string) error {
+	path, err := t.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (t *LocalBlobStore) URL(key string) string {
+	return strings.TrimSuffix(t.BaseURL, "/") + "/" + key
+}
+
+// S3BlobStore stores blobs in a bucket of any S3 compatible service, addressed path style and signed with
+// AWS signature version 4. Objects are expected to be publicly readable at PublicURL.
+type S3BlobStore struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	PublicURL       string
+	Client          *http.Client
+}
+
+func (t *S3BlobStore) objectURL(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.TrimSuffix(t.Endpoint, "/") + "/" + url.PathEscape(t.Bucket) + "/" + strings.Join(segments, "/")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// sign adds the signature version 4 headers to the request.
+func (t *S3BlobStore) sign(request *http.Request, payload []byte, now time.Time) {
+	payloadHash := sha256.Sum256(payload)
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	request.Header.Set("x-amz-content-sha256", hex.EncodeToString(payloadHash[:]))
+	request.Header.Set("x-amz-date", amzDate)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + request.URL.Host + "\n" +
+		"x-amz-content-sha256:" + hex.EncodeToString(payloadHash[:]) + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		"",
+		canonicalHeaders,
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := date + "/" + t.Region + "/s3/aws4_request"
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+t.SecretAccessKey), date)
+	key = hmacSHA256(key, t.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+
+	request.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		t.AccessKeyID, scope, signedHeaders, hex.EncodeToString(hmacSHA256(key, stringToSign))))
+}
+
+func (t *S3BlobStore) do(method string, key string, contentType string, payload []byte) ([]byte, error) {
+	request, err := http.NewRequest(method, t.objectURL(key), bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	t.sign(request, payload, time.Now())
+
+	client := t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode == http.StatusNotFound {
+		return nil, ErrBlobNotFound
+	}
+	if response.StatusCode >= 300 {
+		return nil, fmt.Errorf("blob store responded with %s", response.Status)
+	}
+	return body, nil
+}
+
+func (t *S3BlobStore) Put(key string, contentType string, data []byte) error {
+	_, err := t.do(http.MethodPut, key, contentType, data)
+	return err
+}
+
+func (t *S3BlobStore) Get(key string) ([]byte, error) {
+	return t.do(http.MethodGet, key, "", nil)
+}
+
+func (t *S3BlobStore) Delete(key string) error {
+	_, err := t.do(http.MethodDelete, key, "", nil)
+	if errors.Is(err, ErrBlobNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (t *S3BlobStore) URL(key string) string {
+	if t.PublicURL == "" {
+		return t.objectURL(key)
+	}
+	return strings.TrimSuffix(t.PublicURL, "/") + "/" + key
+}
+
+// NewBlobStore uses an S3 compatible bucket when S3_BUCKET is set and the local filesystem otherwise.
+func NewBlobStore() BlobStore {
+	if bucket := os.Getenv("S3_BUCKET"); bucket != "" {
+		return &S3BlobStore{
+			Endpoint:        envOrDefault("S3_ENDPOINT", "https://s3.amazonaws.com"),
+			Region:          envOrDefault("S3_REGION", "us-east-1"),
+			Bucket:          bucket,
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			PublicURL:       os.Getenv("S3_PUBLIC_URL"),
+		}
+	}
+
+	return &LocalBlobStore{
+		Dir:     envOrDefault("BLOB_DIR", "uploads"),
+		BaseURL: oidcIssuer() + "/blobs",
+	}
+}
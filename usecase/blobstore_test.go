@@ -0,0 +1,74 @@
+package usecase_test
+
+import (
+	"andikawhy/go-user-management/usecase"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestLocalBlobStore(t *testing.T) {
+	blobStore := &usecase.LocalBlobStore{Dir: t.TempDir(), BaseURL: "http://localhost:3000/blobs/"}
+
+	assert.Equal(t, blobStore.Put("avatars/1/a.png", "image/png", []byte("png")), nil)
+	data, err := blobStore.Get("avatars/1/a.png")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, string(data), "png")
+	assert.Equal(t, blobStore.URL("avatars/1/a.png"), "http://localhost:3000/blobs/avatars/1/a.png")
+
+	assert.Equal(t, blobStore.Delete("avatars/1/a.png"), nil)
+	assert.Equal(t, blobStore.Delete("avatars/1/a.png"), nil)
+	_, err = blobStore.Get("avatars/1/a.png")
+	assert.Equal(t, err, usecase.ErrBlobNotFound)
+
+	assert.NotEqual(t, blobStore.Put("../escape", "text/plain", []byte("x")), nil)
+	assert.NotEqual(t, blobStore.Put("/absolute", "text/plain", []byte("x")), nil)
+}
+
+func TestS3BlobStore(t *testing.T) {
+	objects := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization := regexp.MustCompile(`^AWS4-HMAC-SHA256 Credential=access/\d{8}/eu-central-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=[0-9a-f]{64}$`)
+		if !authorization.MatchString(r.Header.Get("Authorization")) || r.Header.Get("x-amz-date") == "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			objects[r.URL.Path] = r.Header.Get("Content-Type") + ":" + string(body)
+		case http.MethodGet:
+			object, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			io.WriteString(w, object)
+		case http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	blobStore := &usecase.S3BlobStore{Endpoint: server.URL, Region: "eu-central-1", Bucket: "avatars", AccessKeyID: "access", SecretAccessKey: "secret"}
+
+	assert.Equal(t, blobStore.Put("avatars/1/a b.png", "image/png", []byte("png")), nil)
+	assert.Equal(t, objects["/avatars/avatars/1/a b.png"], "image/png:png")
+
+	data, err := blobStore.Get("avatars/1/a b.png")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, string(data), "image/png:png")
+
+	assert.Equal(t, blobStore.Delete("avatars/1/a b.png"), nil)
+	_, err = blobStore.Get("avatars/1/a b.png")
+	assert.Equal(t, err, usecase.ErrBlobNotFound)
+
+	assert.Equal(t, blobStore.URL("avatars/1/a.png"), server.URL+"/avatars/avatars/1/a.png")
+	blobStore.PublicURL = "https://cdn.example.com/"
+	assert.Equal(t, blobStore.URL("avatars/1/a.png"), "https://cdn.example.com/avatars/1/a.png")
+}
@@ -0,0 +1,241 @@
+package usecase
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	deviceCodePrefix    = "gum_dc_"
+	deviceCodeExpiry    = 10 * time.Minute
+	deviceCodeInterval  = 5
+	slowDownIncrement   = 5
+	userCodeLength      = 8
+	userCodeAlphabet    = "BCDFGHJKLMNPQRSTVWXZ"
+	deviceVerifyPath    = "/oauth/device"
+	userCodeGroupLength = 4
+)
+
+func generateUserCode() (string, error) {
+	code := make([]byte, userCodeLength)
+	for i := range code {
+		index, err := rand.Int(rand.Reader, big.NewInt(int64(len(userCodeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		code[i] = userCodeAlphabet[index.Int64()]
+	}
+	return string(code), nil
+}
+
+// normalizeUserCode accepts user codes typed in lower case or with dashes and spaces.
+func normalizeUserCode(userCode string) string {
+	return strings.Map(func(r rune) rune {
+		r = unicode.ToUpper(r)
+		if strings.ContainsRune(userCodeAlphabet, r) {
+			return r
+		}
+		return -1
+	}, userCode)
+}
+
+func formatUserCode(userCode string) string {
+	if len(userCode) != userCodeLength {
+		return userCode
+	}
+	return userCode[:userCodeGroupLength] + "-" + userCode[userCodeGroupLength:]
+}
+
+func (t *OAuthUsecaseImpl) DeviceAuthorization(deviceRequest repository.DeviceAuthorizationRequest) (*repository.DeviceAuthorizationResponse, *helper.StandardError) {
+	client, authError := t.authenticateClient(deviceRequest.ClientID, deviceRequest.ClientSecret)
+	if authError != nil {
+		return nil, authError
+	}
+
+	if !allowsGrant(client, GrantDeviceCode) {
+		return nil, &helper.StandardError{Error: ErrUnauthorizedClient, ErrorCode: http.StatusBadRequest}
+	}
+
+	scopes := strings.Fields(client.Scopes)
+	if deviceRequest.Scope != "" {
+		scopes = strings.Fields(deviceRequest.Scope)
+		if validateScopes(scopes, append(strings.Fields(client.Scopes), oidcScopes...)) != nil {
+			return nil, &helper.StandardError{Error: ErrInvalidScope, ErrorCode: http.StatusBadRequest}
+		}
+	}
+
+	rawDeviceCode, err := generateRandomToken(deviceCodePrefix)
+	if err != nil {
+		return nil, &helper.StandardError{Error: errors.New("failed to generate device code"), ErrorCode: http.StatusInternalServerError}
+	}
+
+	userCode, err := generateUserCode()
+	if err != nil {
+		return nil, &helper.StandardError{Error: errors.New("failed to generate device code"), ErrorCode: http.StatusInternalServerError}
+	}
+
+	deviceCode := t.OAuthRepository.SaveDeviceCode(repository.DeviceCode{
+		DeviceCodeHash: hashToken(rawDeviceCode),
+		UserCode:       userCode,
+		ClientID:       client.ClientID,
+		Scope:          strings.Join(scopes, " "),
+		Status:         repository.DeviceCodePending,
+		Interval:       deviceCodeInterval,
+		ExpiresAt:      time.Now().Add(deviceCodeExpiry),
+	})
+	if deviceCode.ID == 0 {
+		return nil, &helper.StandardError{Error: errors.New("failed to generate device code"), ErrorCode: http.StatusInternalServerError}
+	}
+
+	t.AuditUsecase.Record("oauth.device_authorization", 0, 0, fmt.Sprintf("client_id=%s", client.ClientID))
+
+	verificationURI := oidcIssuer() + deviceVerifyPath
+	return &repository.DeviceAuthorizationResponse{
+		DeviceCode:              rawDeviceCode,
+		UserCode:                formatUserCode(userCode),
+		VerificationURI:         verificationURI,
+		VerificationURIComplete: withQuery(verificationURI, url.Values{"user_code": {formatUserCode(userCode)}}),
+		ExpiresIn:               int64(deviceCodeExpiry.Seconds()),
+		Interval:                deviceCodeInterval,
+	}, nil
+}
+
+func (t *OAuthUsecaseImpl) findPendingDeviceCode(userCode string) (repository.DeviceCode, *repository.DevicePrompt, *helper.StandardError) {
+	deviceCode := t.OAuthRepository.FindDeviceCodeByUserCode(normalizeUserCode(userCode))
+	if deviceCode.ID == 0 || deviceCode.Status != repository.DeviceCodePending || time.Now().After(deviceCode.ExpiresAt) {
+		return deviceCode, nil, &helper.StandardError{Error: errors.New("invalid or expired code"), ErrorCode: http.StatusBadRequest}
+	}
+
+	client := t.ClientRepository.FindByClientId(deviceCode.ClientID)
+	if client.ID == 0 || client.DisabledAt != nil {
+		return deviceCode, nil, &helper.StandardError{Error: ErrInvalidClient, ErrorCode: http.StatusBadRequest}
+	}
+
+	return deviceCode, &repository.DevicePrompt{Client: client, Scopes: strings.Fields(deviceCode.Scope), UserCode: formatUserCode(deviceCode.UserCode)}, nil
+}
+
+func (t *OAuthUsecaseImpl) DevicePrompt(userCode string) (*repository.DevicePrompt, *helper.StandardError) {
+	_, prompt, promptError := t.findPendingDeviceCode(userCode)
+	return prompt, promptError
+}
+
+// SessionUser returns the user signed in with the browser's cookie session, so the device page does not ask for a password.
+func (t *OAuthUsecaseImpl) SessionUser(sessionToken string) *repository.User {
+	if sessionToken == "" {
+		return nil
+	}
+	user, _ := t.AuthUsecase.FindSession(sessionToken)
+	return user
+}
+
+func (t *OAuthUsecaseImpl) ApproveDevice(decision repository.DeviceDecision) (*repository.DevicePrompt, *helper.StandardError) {
+	deviceCode, prompt, promptError := t.findPendingDeviceCode(decision.UserCode)
+	if promptError != nil {
+		return nil, promptError
+	}
+
+	var user *repository.User
+	if decision.SessionToken != "" {
+		var session *repository.Session
+		if user, session = t.AuthUsecase.FindSession(decision.SessionToken); user != nil && !validCSRFToken(*session, decision.CSRFToken) {
+			return nil, &helper.StandardError{Error: errors.New("invalid csrf token"), ErrorCode: http.StatusForbidden}
+		}
+	}
+	if user == nil {
+		var authError *helper.StandardError
+		user, authError = t.AuthUsecase.Authenticate(repository.Login{Username: decision.Username, Password: decision.Password, OrganizationID: decision.OrganizationID})
+		if authError != nil {
+			return prompt, authError
+		}
+	}
+
+	// Like an authorization, an approval is listed with the user's sessions and ending it revokes the device's tokens.
+	status := repository.DeviceCodeDenied
+	var session repository.Session
+	if decision.Approve {
+		status = repository.DeviceCodeApproved
+		var ok bool
+		session, ok = saveTokenSession(t.SessionRepository, *user, organizationOrDefault(user.OrganizationID), SessionMethodDevice, decision.LoginContext, refreshTokenExpiry)
+		if !ok {
+			return nil, &helper.StandardError{Error: errors.New("failed to approve device"), ErrorCode: http.StatusInternalServerError}
+		}
+	}
+
+	if !t.OAuthRepository.DecideDeviceCode(deviceCode.ID, user.ID, status, session.ID) {
+		if session.ID != 0 {
+			t.SessionRepository.Delete(session.ID)
+		}
+		return nil, &helper.StandardError{Error: errors.New("invalid or expired code"), ErrorCode: http.StatusBadRequest}
+	}
+
+	if !decision.Approve {
+		t.AuditUsecase.Record("oauth.device_deny", user.ID, user.ID, fmt.Sprintf("client_id=%s", deviceCode.ClientID))
+		return prompt, nil
+	}
+
+	t.grantConsent(user.ID, deviceCode.ClientID, prompt.Scopes)
+	t.AuditUsecase.Record("oauth.device_approve", user.ID, user.ID, fmt.Sprintf("client_id=%s session_id=%d", deviceCode.ClientID, session.ID))
+
+	prompt.Approved = true
+	return prompt, nil
+}
+
+func (t *OAuthUsecaseImpl) deviceCode(tokenRequest repository.TokenRequest) (*repository.TokenResponse, *helper.StandardError) {
+	client, authError := t.authenticateClient(tokenRequest.ClientID, tokenRequest.ClientSecret)
+	if authError != nil {
+		return nil, authError
+	}
+
+	if !allowsGrant(client, GrantDeviceCode) {
+		return nil, &helper.StandardError{Error: ErrUnauthorizedClient, ErrorCode: http.StatusBadRequest}
+	}
+
+	invalidGrant := &helper.StandardError{Error: ErrInvalidGrant, ErrorCode: http.StatusBadRequest}
+
+	deviceCode := t.OAuthRepository.FindDeviceCodeByHash(hashToken(tokenRequest.DeviceCode))
+	if deviceCode.ID == 0 || deviceCode.ClientID != client.ClientID || deviceCode.Status == repository.DeviceCodeUsed {
+		return nil, invalidGrant
+	}
+
+	if time.Now().After(deviceCode.ExpiresAt) {
+		return nil, &helper.StandardError{Error: ErrExpiredToken, ErrorCode: http.StatusBadRequest}
+	}
+
+	if deviceCode.Status == repository.DeviceCodeDenied {
+		return nil, &helper.StandardError{Error: ErrAccessDenied, ErrorCode: http.StatusBadRequest}
+	}
+
+	// Clients polling faster than the interval are told to slow down, and the interval grows for every violation.
+	now := time.Now()
+	interval := deviceCode.Interval
+	if deviceCode.LastPolledAt != nil && now.Sub(*deviceCode.LastPolledAt) < time.Duration(interval)*time.Second {
+		interval += slowDownIncrement
+		t.OAuthRepository.UpdateDeviceCodePoll(deviceCode.ID, now, interval)
+		return nil, &helper.StandardError{Error: ErrSlowDown, ErrorCode: http.StatusBadRequest}
+	}
+	t.OAuthRepository.UpdateDeviceCodePoll(deviceCode.ID, now, interval)
+
+	if deviceCode.Status == repository.DeviceCodePending {
+		return nil, &helper.StandardError{Error: ErrAuthorizationPending, ErrorCode: http.StatusBadRequest}
+	}
+
+	if !t.activeSession(deviceCode.SessionID) || !t.OAuthRepository.MarkDeviceCodeUsed(deviceCode.ID) {
+		return nil, invalidGrant
+	}
+
+	user := t.UserRepository.FindById(deviceCode.UserID)
+	if user.ID == 0 || user.DisabledAt != nil {
+		return nil, invalidGrant
+	}
+
+	return t.issueUserTokens(client, user, deviceCode.Scope, "", deviceCode.SessionID)
+}
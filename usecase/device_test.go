@@ -0,0 +1,350 @@
+package usecase_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/stretchr/testify/mock"
+)
+
+var mockCLIClient = repository.OAuthClient{
+	ID:         3,
+	ClientID:   "gum_client_cli",
+	Name:       "cli",
+	Scopes:     "users:read users:write",
+	GrantTypes: "urn:ietf:params:oauth:grant-type:device_code refresh_token",
+	Public:     true,
+}
+
+func mockDeviceCode(status string) repository.DeviceCode {
+	return repository.DeviceCode{
+		ID:        1,
+		UserCode:  "BCDFGHJK",
+		ClientID:  "gum_client_cli",
+		UserID:    100,
+		Scope:     "users:read",
+		SessionID: 7,
+		Status:    status,
+		Interval:  5,
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+}
+
+func TestDeviceAuthorization(t *testing.T) {
+	os.Setenv("OIDC_ISSUER", "http://localhost:3000")
+
+	t.Run("test normal device authorization", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockCLIClient)
+		oauthRepositoryMock.On("SaveDeviceCode", mock.MatchedBy(func(deviceCode repository.DeviceCode) bool {
+			return deviceCode.ClientID == "gum_client_cli" && deviceCode.Scope == "users:read" && deviceCode.Status == repository.DeviceCodePending && deviceCode.DeviceCodeHash != ""
+		})).Return(repository.DeviceCode{ID: 1})
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, oauthRepositoryMock, nil, nil, nil, auditUsecaseMock)
+		deviceAuthorization, err := oauthUsecase.DeviceAuthorization(repository.DeviceAuthorizationRequest{ClientID: "gum_client_cli", Scope: "users:read"})
+
+		assert.Equal(t, err, nil)
+		assert.MatchRegex(t, deviceAuthorization.DeviceCode, `^gum_dc_[A-Za-z0-9_-]+$`)
+		assert.MatchRegex(t, deviceAuthorization.UserCode, `^[BCDFGHJKLMNPQRSTVWXZ]{4}-[BCDFGHJKLMNPQRSTVWXZ]{4}$`)
+		assert.Equal(t, deviceAuthorization.VerificationURI, "http://localhost:3000/oauth/device")
+		assert.Equal(t, deviceAuthorization.VerificationURIComplete, "http://localhost:3000/oauth/device?user_code="+deviceAuthorization.UserCode)
+		assert.Equal(t, deviceAuthorization.ExpiresIn, int64(600))
+		assert.Equal(t, deviceAuthorization.Interval, 5)
+	})
+
+	t.Run("client without device grant", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		clientRepositoryMock.On("FindByClientId").Return(mockAppClient)
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, nil, nil, nil, nil, nil)
+		deviceAuthorization, err := oauthUsecase.DeviceAuthorization(repository.DeviceAuthorizationRequest{ClientID: "gum_client_app"})
+
+		assert.Equal(t, deviceAuthorization, nil)
+		assert.Equal(t, err, helper.StandardError{Error: usecase.ErrUnauthorizedClient, ErrorCode: http.StatusBadRequest})
+	})
+
+	t.Run("scope not allowed", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		clientRepositoryMock.On("FindByClientId").Return(mockCLIClient)
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, nil, nil, nil, nil, nil)
+		deviceAuthorization, err := oauthUsecase.DeviceAuthorization(repository.DeviceAuthorizationRequest{ClientID: "gum_client_cli", Scope: "audit:read"})
+
+		assert.Equal(t, deviceAuthorization, nil)
+		assert.Equal(t, err, helper.StandardError{Error: usecase.ErrInvalidScope, ErrorCode: http.StatusBadRequest})
+	})
+}
+
+func TestApproveDevice(t *testing.T) {
+	t.Run("test prompt accepts a typed code", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockCLIClient)
+		oauthRepositoryMock.On("FindDeviceCodeByUserCode", "BCDFGHJK").Return(mockDeviceCode(repository.DeviceCodePending))
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, oauthRepositoryMock, nil, nil, nil, nil)
+		prompt, err := oauthUsecase.DevicePrompt("bcdf ghjk")
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, prompt.UserCode, "BCDF-GHJK")
+		assert.Equal(t, prompt.Client.Name, "cli")
+		assert.Equal(t, prompt.Scopes, []string{"users:read"})
+	})
+
+	t.Run("test normal approve", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+		authUsecaseMock := new(mocks.AuthUsecaseMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockCLIClient)
+		oauthRepositoryMock.On("FindDeviceCodeByUserCode", "BCDFGHJK").Return(mockDeviceCode(repository.DeviceCodePending))
+		authUsecaseMock.On("Authenticate").Return(&mockUser, (*helper.StandardError)(nil))
+		oauthRepositoryMock.On("DecideDeviceCode", uint64(100), repository.DeviceCodeApproved).Return(true)
+		oauthRepositoryMock.On("FindConsent").Return(repository.Consent{})
+		oauthRepositoryMock.On("SaveConsent").Return(repository.Consent{ID: 1})
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		sessionRepositoryMock.On("Save", mock.MatchedBy(func(session repository.Session) bool {
+			return session.UserID == mockUser.ID && session.Kind == repository.SessionKindToken && session.Method == usecase.SessionMethodDevice && session.UserAgent == "browser"
+		})).Return(repository.Session{ID: 7, UserID: mockUser.ID})
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, oauthRepositoryMock, nil, sessionRepositoryMock, authUsecaseMock, auditUsecaseMock)
+		prompt, err := oauthUsecase.ApproveDevice(repository.DeviceDecision{UserCode: "BCDF-GHJK", Username: "username", Password: "password", Approve: true, OrganizationID: 2, LoginContext: repository.LoginContext{UserAgent: "browser"}})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, prompt.Approved, true)
+		assert.Equal(t, authUsecaseMock.OrganizationID, uint64(2))
+		oauthRepositoryMock.AssertCalled(t, "SaveConsent")
+		oauthRepositoryMock.AssertCalled(t, "DecideDeviceCode", uint64(100), repository.DeviceCodeApproved)
+		assert.Equal(t, sessionRepositoryMock.Calls[0].Arguments.Get(0).(repository.Session).Method, usecase.SessionMethodDevice)
+	})
+
+	t.Run("approve with the browser session", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+		authUsecaseMock := new(mocks.AuthUsecaseMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockCLIClient)
+		oauthRepositoryMock.On("FindDeviceCodeByUserCode", "BCDFGHJK").Return(mockDeviceCode(repository.DeviceCodePending))
+		authUsecaseMock.On("FindSession", "session").Return(&mockUser, &repository.Session{ID: 1, UserID: 100, CSRFTokenHash: sha256Hex("csrf")})
+		oauthRepositoryMock.On("DecideDeviceCode", uint64(100), repository.DeviceCodeApproved).Return(true)
+		oauthRepositoryMock.On("FindConsent").Return(repository.Consent{})
+		oauthRepositoryMock.On("SaveConsent").Return(repository.Consent{ID: 1})
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		sessionRepositoryMock.On("Save", mock.Anything).Return(repository.Session{ID: 7, UserID: mockUser.ID})
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, oauthRepositoryMock, nil, sessionRepositoryMock, authUsecaseMock, auditUsecaseMock)
+		prompt, err := oauthUsecase.ApproveDevice(repository.DeviceDecision{UserCode: "BCDF-GHJK", SessionToken: "session", CSRFToken: "csrf", Approve: true})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, prompt.Approved, true)
+		authUsecaseMock.AssertNotCalled(t, "Authenticate")
+	})
+
+	t.Run("browser session with a wrong csrf token", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+		authUsecaseMock := new(mocks.AuthUsecaseMock)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockCLIClient)
+		oauthRepositoryMock.On("FindDeviceCodeByUserCode", "BCDFGHJK").Return(mockDeviceCode(repository.DeviceCodePending))
+		authUsecaseMock.On("FindSession", "session").Return(&mockUser, &repository.Session{ID: 1, UserID: 100, CSRFTokenHash: sha256Hex("csrf")})
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, oauthRepositoryMock, nil, nil, authUsecaseMock, nil)
+		prompt, err := oauthUsecase.ApproveDevice(repository.DeviceDecision{UserCode: "BCDF-GHJK", SessionToken: "session", CSRFToken: "forged", Approve: true})
+
+		assert.Equal(t, prompt, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("invalid csrf token"), ErrorCode: http.StatusForbidden})
+		oauthRepositoryMock.AssertNotCalled(t, "DecideDeviceCode", mock.Anything, mock.Anything)
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+		authUsecaseMock := new(mocks.AuthUsecaseMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockCLIClient)
+		oauthRepositoryMock.On("FindDeviceCodeByUserCode", "BCDFGHJK").Return(mockDeviceCode(repository.DeviceCodePending))
+		authUsecaseMock.On("Authenticate").Return(&mockUser, (*helper.StandardError)(nil))
+		oauthRepositoryMock.On("DecideDeviceCode", uint64(100), repository.DeviceCodeDenied).Return(true)
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, oauthRepositoryMock, nil, nil, authUsecaseMock, auditUsecaseMock)
+		prompt, err := oauthUsecase.ApproveDevice(repository.DeviceDecision{UserCode: "BCDF-GHJK", Username: "username", Password: "password", Approve: false})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, prompt.Approved, false)
+		oauthRepositoryMock.AssertNotCalled(t, "SaveConsent")
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+		authUsecaseMock := new(mocks.AuthUsecaseMock)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockCLIClient)
+		oauthRepositoryMock.On("FindDeviceCodeByUserCode", "BCDFGHJK").Return(mockDeviceCode(repository.DeviceCodePending))
+		authUsecaseMock.On("Authenticate").Return((*repository.User)(nil), &helper.StandardError{Error: errors.New("wrong password"), ErrorCode: http.StatusUnauthorized})
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, oauthRepositoryMock, nil, nil, authUsecaseMock, nil)
+		prompt, err := oauthUsecase.ApproveDevice(repository.DeviceDecision{UserCode: "BCDF-GHJK", Username: "username", Password: "wrong", Approve: true})
+
+		assert.Equal(t, prompt.UserCode, "BCDF-GHJK")
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("wrong password"), ErrorCode: http.StatusUnauthorized})
+		oauthRepositoryMock.AssertNotCalled(t, "DecideDeviceCode", mock.Anything, mock.Anything)
+	})
+
+	t.Run("code already decided", func(t *testing.T) {
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+		oauthRepositoryMock.On("FindDeviceCodeByUserCode", "BCDFGHJK").Return(mockDeviceCode(repository.DeviceCodeApproved))
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(nil, oauthRepositoryMock, nil, nil, nil, nil)
+		prompt, err := oauthUsecase.ApproveDevice(repository.DeviceDecision{UserCode: "BCDF-GHJK", Username: "username", Password: "password", Approve: true})
+
+		assert.Equal(t, prompt, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("invalid or expired code"), ErrorCode: http.StatusBadRequest})
+	})
+}
+
+func TestDeviceCodeGrant(t *testing.T) {
+	os.Setenv("SECRET", "testkey")
+
+	tokenRequest := repository.TokenRequest{GrantType: usecase.GrantDeviceCode, ClientID: "gum_client_cli", DeviceCode: "gum_dc_code"}
+
+	t.Run("test token after approval", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockCLIClient)
+		oauthRepositoryMock.On("FindDeviceCodeByHash").Return(mockDeviceCode(repository.DeviceCodeApproved))
+		oauthRepositoryMock.On("UpdateDeviceCodePoll", 5).Return()
+		oauthRepositoryMock.On("MarkDeviceCodeUsed").Return(true)
+		oauthRepositoryMock.On("SaveRefreshToken").Return(repository.RefreshToken{ID: 1})
+		userRepositoryMock.On("FindById").Return(mockUser)
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		sessionRepositoryMock.On("FindById").Return(repository.Session{ID: 7, ExpiresAt: time.Now().Add(time.Hour)})
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, oauthRepositoryMock, userRepositoryMock, sessionRepositoryMock, nil, auditUsecaseMock)
+		token, err := oauthUsecase.Token(tokenRequest)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, token.Scope, "users:read")
+		assert.NotEqual(t, token.RefreshToken, "")
+
+		assert.Equal(t, accessTokenClaims(token.AccessToken)["sid"], float64(7))
+	})
+
+	t.Run("approval session ended", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockCLIClient)
+		oauthRepositoryMock.On("FindDeviceCodeByHash").Return(mockDeviceCode(repository.DeviceCodeApproved))
+		oauthRepositoryMock.On("UpdateDeviceCodePoll", 5).Return()
+		sessionRepositoryMock.On("FindById").Return(repository.Session{})
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, oauthRepositoryMock, nil, sessionRepositoryMock, nil, nil)
+		_, err := oauthUsecase.Token(tokenRequest)
+
+		assert.Equal(t, err, helper.StandardError{Error: usecase.ErrInvalidGrant, ErrorCode: http.StatusBadRequest})
+		oauthRepositoryMock.AssertNotCalled(t, "MarkDeviceCodeUsed")
+	})
+
+	t.Run("authorization pending", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockCLIClient)
+		oauthRepositoryMock.On("FindDeviceCodeByHash").Return(mockDeviceCode(repository.DeviceCodePending))
+		oauthRepositoryMock.On("UpdateDeviceCodePoll", 5).Return()
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, oauthRepositoryMock, nil, nil, nil, nil)
+		token, err := oauthUsecase.Token(tokenRequest)
+
+		assert.Equal(t, token, nil)
+		assert.Equal(t, err, helper.StandardError{Error: usecase.ErrAuthorizationPending, ErrorCode: http.StatusBadRequest})
+	})
+
+	t.Run("polling too fast", func(t *testing.T) {
+		lastPolledAt := time.Now().Add(-time.Second)
+		deviceCode := mockDeviceCode(repository.DeviceCodePending)
+		deviceCode.LastPolledAt = &lastPolledAt
+
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockCLIClient)
+		oauthRepositoryMock.On("FindDeviceCodeByHash").Return(deviceCode)
+		oauthRepositoryMock.On("UpdateDeviceCodePoll", 10).Return()
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, oauthRepositoryMock, nil, nil, nil, nil)
+		token, err := oauthUsecase.Token(tokenRequest)
+
+		assert.Equal(t, token, nil)
+		assert.Equal(t, err, helper.StandardError{Error: usecase.ErrSlowDown, ErrorCode: http.StatusBadRequest})
+		oauthRepositoryMock.AssertCalled(t, "UpdateDeviceCodePoll", 10)
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockCLIClient)
+		oauthRepositoryMock.On("FindDeviceCodeByHash").Return(mockDeviceCode(repository.DeviceCodeDenied))
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, oauthRepositoryMock, nil, nil, nil, nil)
+		_, err := oauthUsecase.Token(tokenRequest)
+
+		assert.Equal(t, err, helper.StandardError{Error: usecase.ErrAccessDenied, ErrorCode: http.StatusBadRequest})
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		deviceCode := mockDeviceCode(repository.DeviceCodePending)
+		deviceCode.ExpiresAt = time.Now().Add(-time.Minute)
+
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockCLIClient)
+		oauthRepositoryMock.On("FindDeviceCodeByHash").Return(deviceCode)
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, oauthRepositoryMock, nil, nil, nil, nil)
+		_, err := oauthUsecase.Token(tokenRequest)
+
+		assert.Equal(t, err, helper.StandardError{Error: usecase.ErrExpiredToken, ErrorCode: http.StatusBadRequest})
+	})
+
+	t.Run("already used", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockCLIClient)
+		oauthRepositoryMock.On("FindDeviceCodeByHash").Return(mockDeviceCode(repository.DeviceCodeUsed))
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, oauthRepositoryMock, nil, nil, nil, nil)
+		_, err := oauthUsecase.Token(tokenRequest)
+
+		assert.Equal(t, err, helper.StandardError{Error: usecase.ErrInvalidGrant, ErrorCode: http.StatusBadRequest})
+	})
+}
@@ -0,0 +1,260 @@
+package usecase
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+const defaultDirectoryBaseDN = "dc=example,dc=com"
+
+var personObjectClasses = []string{"top", "person", "organizationalPerson", "inetOrgPerson"}
+
+type DirectoryUsecase interface {
+	Bind(dn string, password string) *helper.StandardError
+	Search(search repository.DirectorySearch) ([]repository.DirectoryEntry, *helper.StandardError)
+}
+
+type DirectoryUsecaseImpl struct {
+	UserRepository repository.UserRepository
+	AuditUsecase   AuditUsecase
+}
+
+func directoryBaseDN() (*ldap.DN, error) {
+	baseDN, err := ldap.ParseDN(envOrDefault("LDAP_SERVER_BASE_DN", defaultDirectoryBaseDN))
+	if err != nil || len(baseDN.RDNs) == 0 {
+		return nil, errors.New("invalid LDAP_SERVER_BASE_DN")
+	}
+	return baseDN, nil
+}
+
+func childDN(attribute string, value string, parent *ldap.DN) *ldap.DN {
+	rdn := &ldap.RelativeDN{Attributes: []*ldap.AttributeTypeAndValue{{Type: attribute, Value: value}}}
+	return &ldap.DN{RDNs: append([]*ldap.RelativeDN{rdn}, parent.RDNs...)}
+}
+
+func directoryEntries(baseDN *ldap.DN, users []repository.User) []repository.DirectoryEntry {
+	peopleDN := childDN("ou", "people", baseDN)
+	groupsDN := childDN("ou", "groups", baseDN)
+
+	baseRDN := baseDN.RDNs[0].Attributes[0]
+	baseObjectClass := "organization"
+	if strings.EqualFold(baseRDN.Type, "dc") {
+		baseObjectClass = "domain"
+	}
+
+	entries := []repository.DirectoryEntry{
+		{DN: baseDN.String(), Attributes: map[string][]string{"objectClass": {"top", baseObjectClass}, baseRDN.Type: {baseRDN.Value}}},
+		{DN: peopleDN.String(), Attributes: map[string][]string{"objectClass": {"top", "organizationalUnit"}, "ou": {"people"}}},
+		{DN: groupsDN.String(), Attributes: map[string][]string{"objectClass": {"top", "organizationalUnit"}, "ou": {"groups"}}},
+	}
+
+	members := map[string][]string{}
+	for _, user := range users {
+		userDN := childDN("uid", user.Username, peopleDN).String()
+		attributes := map[string][]string{
+			"objectClass": personObjectClasses,
+			"uid":         {user.Username},
+			"cn":          {user.Username},
+			"sn":          {user.Username},
+		}
+		if user.Email != "" {
+			attributes["mail"] = []string{user.Email}
+		}
+		for _, role := range strings.Fields(user.Roles) {
+			attributes["memberOf"] = append(attributes["memberOf"], childDN("cn", role, groupsDN).String())
+			members[role] = append(members[role], userDN)
+		}
+		entries = append(entries, repository.DirectoryEntry{DN: userDN, Attributes: attributes})
+	}
+
+	roles := make([]string, 0, len(members))
+	for role := range members {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+
+	for _, role := range roles {
+		entries = append(entries, repository.DirectoryEntry{
+			DN:         childDN("cn", role, groupsDN).String(),
+			Attributes: map[string][]string{"objectClass": {"top", "groupOfNames"}, "cn": {role}, "member": members[role]},
+		})
+	}
+
+	return entries
+}
+
+func attributeValues(attributes map[string][]string, name string) []string {
+	for attribute, values := range attributes {
+		if strings.EqualFold(attribute, name) {
+			return values
+		}
+	}
+	return nil
+}
+
+func matchesSubstrings(value string, parts []*ber.Packet) bool {
+	value = strings.ToLower(value)
+	for _, part := range parts {
+		substring := strings.ToLower(part.Data.String())
+		switch part.Tag {
+		case ldap.FilterSubstringsInitial:
+			if !strings.HasPrefix(value, substring) {
+				return false
+			}
+			value = value[len(substring):]
+		case ldap.FilterSubstringsAny:
+			index := strings.Index(value, substring)
+			if index < 0 {
+				return false
+			}
+			value = value[index+len(substring):]
+		case ldap.FilterSubstringsFinal:
+			return strings.HasSuffix(value, substring)
+		}
+	}
+	return true
+}
+
+func matchesFilter(attributes map[string][]string, filter *ber.Packet) bool {
+	switch filter.Tag {
+	case ldap.FilterAnd:
+		for _, child := range filter.Children {
+			if !matchesFilter(attributes, child) {
+				return false
+			}
+		}
+		return true
+	case ldap.FilterOr:
+		for _, child := range filter.Children {
+			if matchesFilter(attributes, child) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterNot:
+		return !matchesFilter(attributes, filter.Children[0])
+	case ldap.FilterPresent:
+		return len(attributeValues(attributes, filter.Data.String())) > 0
+	case ldap.FilterEqualityMatch, ldap.FilterApproxMatch:
+		for _, value := range attributeValues(attributes, filter.Children[0].Data.String()) {
+			if strings.EqualFold(value, filter.Children[1].Data.String()) {
+				return true
+			}
+		}
+	case ldap.FilterSubstrings:
+		for _, value := range attributeValues(attributes, filter.Children[0].Data.String()) {
+			if matchesSubstrings(value, filter.Children[1].Children) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func inScope(dn *ldap.DN, searchBase *ldap.DN, scope int) bool {
+	switch scope {
+	case ldap.ScopeBaseObject:
+		return searchBase.EqualFold(dn)
+	case ldap.ScopeSingleLevel:
+		return len(dn.RDNs) == len(searchBase.RDNs)+1 && searchBase.AncestorOfFold(dn)
+	case ldap.ScopeWholeSubtree:
+		return searchBase.EqualFold(dn) || searchBase.AncestorOfFold(dn)
+	}
+	return false
+}
+
+func selectAttributes(attributes map[string][]string, requested []string) map[string][]string {
+	if len(requested) == 0 {
+		return attributes
+	}
+
+	selected := map[string][]string{}
+	for name, values := range attributes {
+		for _, attribute := range requested {
+			if attribute == "*" || strings.EqualFold(attribute, name) {
+				selected[name] = values
+			}
+		}
+	}
+	return selected
+}
+
+func (t *DirectoryUsecaseImpl) Bind(dn string, password string) *helper.StandardError {
+	invalidCredentials := &helper.StandardError{Error: errors.New("invalid credentials"), ErrorCode: http.StatusUnauthorized}
+
+	baseDN, err := directoryBaseDN()
+	if err != nil {
+		return &helper.StandardError{Error: err, ErrorCode: http.StatusInternalServerError}
+	}
+
+	bindDN, err := ldap.ParseDN(dn)
+	peopleDN := childDN("ou", "people", baseDN)
+	if err != nil || password == "" || len(bindDN.RDNs) != len(peopleDN.RDNs)+1 || !peopleDN.AncestorOfFold(bindDN) {
+		return invalidCredentials
+	}
+
+	rdn := bindDN.RDNs[0].Attributes
+	if len(rdn) != 1 || !strings.EqualFold(rdn[0].Type, "uid") {
+		return invalidCredentials
+	}
+
+	user, authError := NewLocalAuthenticator(t.UserRepository, t.AuditUsecase).Authenticate(repository.Login{Username: rdn[0].Value, Password: password, OrganizationID: repository.DefaultOrganizationID})
+	if authError != nil || user.PasskeyRequired {
+		return invalidCredentials
+	}
+
+	return nil
+}
+
+func (t *DirectoryUsecaseImpl) Search(search repository.DirectorySearch) ([]repository.DirectoryEntry, *helper.StandardError) {
+	baseDN, err := directoryBaseDN()
+	if err != nil {
+		return nil, &helper.StandardError{Error: err, ErrorCode: http.StatusInternalServerError}
+	}
+
+	searchBase, err := ldap.ParseDN(search.BaseDN)
+	if err != nil {
+		return nil, &helper.StandardError{Error: errors.New("invalid base dn"), ErrorCode: http.StatusBadRequest}
+	}
+
+	filter, err := ldap.CompileFilter(search.Filter)
+	if err != nil {
+		return nil, &helper.StandardError{Error: errors.New("invalid filter"), ErrorCode: http.StatusBadRequest}
+	}
+
+	entries := directoryEntries(baseDN, t.UserRepository.ForOrganization(repository.DefaultOrganizationID).FindAll())
+	if search.BaseDN == "" && search.Scope == ldap.ScopeBaseObject {
+		entries = []repository.DirectoryEntry{{Attributes: map[string][]string{"objectClass": {"top"}, "namingContexts": {baseDN.String()}, "supportedLDAPVersion": {"3"}}}}
+	}
+
+	found := false
+	results := []repository.DirectoryEntry{}
+	for _, entry := range entries {
+		dn, _ := ldap.ParseDN(entry.DN)
+		found = found || searchBase.EqualFold(dn)
+
+		if inScope(dn, searchBase, search.Scope) && matchesFilter(entry.Attributes, filter) {
+			results = append(results, repository.DirectoryEntry{DN: entry.DN, Attributes: selectAttributes(entry.Attributes, search.Attributes)})
+		}
+	}
+
+	if !found {
+		return nil, &helper.StandardError{Error: errors.New("no such object"), ErrorCode: http.StatusNotFound}
+	}
+
+	return results, nil
+}
+
+func NewDirectoryUsecaseImpl(userRepository repository.UserRepository, auditUsecase AuditUsecase) DirectoryUsecase {
+	return &DirectoryUsecaseImpl{
+		UserRepository: userRepository,
+		AuditUsecase:   auditUsecase,
+	}
+}
@@ -0,0 +1,119 @@
+package usecase_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/go-playground/assert/v2"
+)
+
+var mockDirectoryUsers = []repository.User{
+	mockUser,
+	{ID: 101, Username: "alice", Email: "alice@example.com", Roles: "admin staff"},
+}
+
+func directoryDNs(entries []repository.DirectoryEntry) []string {
+	dns := []string{}
+	for _, entry := range entries {
+		dns = append(dns, entry.DN)
+	}
+	return dns
+}
+
+func TestDirectoryBind(t *testing.T) {
+	t.Run("test normal bind", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		userRepositoryMock.On("FindByUsername").Return(mockUser)
+
+		directoryUsecase := usecase.NewDirectoryUsecaseImpl(userRepositoryMock, nil)
+		err := directoryUsecase.Bind("UID=username,ou=People,dc=example,dc=com", "password")
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, userRepositoryMock.OrganizationID, repository.DefaultOrganizationID)
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+		userRepositoryMock.On("FindByUsername").Return(mockUser)
+
+		directoryUsecase := usecase.NewDirectoryUsecaseImpl(userRepositoryMock, auditUsecaseMock)
+		err := directoryUsecase.Bind("uid=username,ou=people,dc=example,dc=com", "wrong password")
+
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("invalid credentials"), ErrorCode: http.StatusUnauthorized})
+	})
+
+	t.Run("dn outside the people branch", func(t *testing.T) {
+		directoryUsecase := usecase.NewDirectoryUsecaseImpl(nil, nil)
+		err := directoryUsecase.Bind("uid=username,ou=groups,dc=example,dc=com", "password")
+
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("invalid credentials"), ErrorCode: http.StatusUnauthorized})
+	})
+}
+
+func TestDirectorySearch(t *testing.T) {
+	userRepositoryMock := new(mocks.UserRepositoryMock)
+	userRepositoryMock.On("FindAll").Return(mockDirectoryUsers)
+	directoryUsecase := usecase.NewDirectoryUsecaseImpl(userRepositoryMock, nil)
+
+	t.Run("test equality filter", func(t *testing.T) {
+		entries, err := directoryUsecase.Search(repository.DirectorySearch{BaseDN: "dc=example,dc=com", Scope: ldap.ScopeWholeSubtree, Filter: "(&(objectClass=inetOrgPerson)(UID=Alice))"})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, directoryDNs(entries), []string{"uid=alice,ou=people,dc=example,dc=com"})
+		assert.Equal(t, entries[0].Attributes["mail"], []string{"alice@example.com"})
+		assert.Equal(t, entries[0].Attributes["memberOf"], []string{"cn=admin,ou=groups,dc=example,dc=com", "cn=staff,ou=groups,dc=example,dc=com"})
+		assert.Equal(t, entries[0].Attributes["userPassword"], nil)
+		assert.Equal(t, userRepositoryMock.OrganizationID, repository.DefaultOrganizationID)
+	})
+
+	t.Run("test substring, or and not filters", func(t *testing.T) {
+		entries, err := directoryUsecase.Search(repository.DirectorySearch{BaseDN: "ou=people,dc=example,dc=com", Scope: ldap.ScopeSingleLevel, Filter: "(|(mail=*@example.com)(&(cn=user*)(!(uid=alice))))"})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, directoryDNs(entries), []string{"uid=username,ou=people,dc=example,dc=com", "uid=alice,ou=people,dc=example,dc=com"})
+	})
+
+	t.Run("test groups from roles", func(t *testing.T) {
+		entries, err := directoryUsecase.Search(repository.DirectorySearch{BaseDN: "ou=groups,dc=example,dc=com", Scope: ldap.ScopeSingleLevel, Filter: "(member=uid=alice,ou=people,dc=example,dc=com)", Attributes: []string{"cn"}})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, directoryDNs(entries), []string{"cn=admin,ou=groups,dc=example,dc=com", "cn=staff,ou=groups,dc=example,dc=com"})
+		assert.Equal(t, entries[0].Attributes, map[string][]string{"cn": {"admin"}})
+	})
+
+	t.Run("test base scope", func(t *testing.T) {
+		entries, err := directoryUsecase.Search(repository.DirectorySearch{BaseDN: "dc=example,dc=com", Scope: ldap.ScopeBaseObject, Filter: "(objectClass=*)"})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, directoryDNs(entries), []string{"dc=example,dc=com"})
+	})
+
+	t.Run("test root dse", func(t *testing.T) {
+		entries, err := directoryUsecase.Search(repository.DirectorySearch{Scope: ldap.ScopeBaseObject, Filter: "(objectClass=*)", Attributes: []string{"namingContexts"}})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, entries[0].Attributes, map[string][]string{"namingContexts": {"dc=example,dc=com"}})
+	})
+
+	t.Run("unknown base dn", func(t *testing.T) {
+		entries, err := directoryUsecase.Search(repository.DirectorySearch{BaseDN: "ou=devices,dc=example,dc=com", Scope: ldap.ScopeWholeSubtree, Filter: "(objectClass=*)"})
+
+		assert.Equal(t, entries, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("no such object"), ErrorCode: http.StatusNotFound})
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		entries, err := directoryUsecase.Search(repository.DirectorySearch{BaseDN: "dc=example,dc=com", Scope: ldap.ScopeWholeSubtree, Filter: "(uid=alice"})
+
+		assert.Equal(t, entries, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("invalid filter"), ErrorCode: http.StatusBadRequest})
+	})
+}
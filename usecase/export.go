@@ -0,0 +1,272 @@
+package usecase
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	defaultExportColumns = []string{"id", "organizationid", "username", "email", "emailverified", "roles", "disabledat", "createdat", "updatedat"}
+
+	// exportColumns lists every column an export can select. Password hashes and avatar keys are left out on purpose.
+	exportColumns = map[string]func(user repository.User) interface{}{
+		"id":              func(user repository.User) interface{} { return user.ID },
+		"organizationid":  func(user repository.User) interface{} { return user.OrganizationID },
+		"username":        func(user repository.User) interface{} { return user.Username },
+		"email":           func(user repository.User) interface{} { return user.Email },
+		"emailverified":   func(user repository.User) interface{} { return user.EmailVerified },
+		"roles":           func(user repository.User) interface{} { return user.Roles },
+		"externalid":      func(user repository.User) interface{} { return user.ExternalID },
+		"passkeyrequired": func(user repository.User) interface{} { return user.PasskeyRequired },
+		"disabledat":      func(user repository.User) interface{} { return exportTime(user.DisabledAt) },
+		"avatarurl":       func(user repository.User) interface{} { return user.AvatarURL },
+		"attributes":      func(user repository.User) interface{} { return user.Attributes },
+		"createdat":       func(user repository.User) interface{} { return exportTime(&user.CreatedAt) },
+		"updatedat":       func(user repository.User) interface{} { return exportTime(&user.UpdatedAt) },
+	}
+)
+
+func exportTime(value *time.Time) interface{} {
+	if value == nil {
+		return nil
+	}
+	return value.UTC().Format(time.RFC3339)
+}
+
+// parseExportColumns validates the requested columns. Besides the columns above, "attributes.<name>" selects a single custom attribute.
+func parseExportColumns(columns string) ([]string, *helper.StandardError) {
+	if strings.TrimSpace(columns) == "" {
+		return defaultExportColumns, nil
+	}
+
+	var selected []string
+	seen := map[string]bool{}
+	for _, column := range strings.Split(columns, ",") {
+		column = strings.TrimSpace(column)
+		name, isAttribute := strings.CutPrefix(column, "attributes.")
+		if _, ok := exportColumns[column]; !ok && !(isAttribute && attributeNamePattern.MatchString(name)) {
+			return nil, &helper.StandardError{Error: fmt.Errorf("unknown column %s", column), ErrorCode: http.StatusBadRequest}
+		}
+		if !seen[column] {
+			seen[column] = true
+			selected = append(selected, column)
+		}
+	}
+	return selected, nil
+}
+
+func exportValue(user repository.User, column string) interface{} {
+	if name, ok := strings.CutPrefix(column, "attributes."); ok {
+		return user.Attributes[name]
+	}
+	return exportColumns[column](user)
+}
+
+// exportString formats a value for the text based cells of CSV.
+func exportString(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case repository.UserAttributes, map[string]interface{}, []interface{}:
+		encoded, _ := json.Marshal(value)
+		return string(encoded)
+	}
+	return fmt.Sprint(value)
+}
+
+type userExportWriter interface {
+	WriteRow(values []interface{}) error
+	Close() error
+}
+
+func newUserExportWriter(format string, writer io.Writer, columns []string) (userExportWriter, error) {
+	switch format {
+	case repository.ExportFormatNDJSON:
+		return &ndjsonExportWriter{encoder: json.NewEncoder(writer), columns: columns}, nil
+	case repository.ExportFormatXLSX:
+		return newXLSXExportWriter(writer, columns)
+	}
+	return newCSVExportWriter(writer, columns)
+}
+
+type csvExportWriter struct {
+	writer *csv.Writer
+}
+
+func newCSVExportWriter(writer io.Writer, columns []string) (*csvExportWriter, error) {
+	exportWriter := &csvExportWriter{writer: csv.NewWriter(writer)}
+	return exportWriter, exportWriter.writer.Write(columns)
+}
+
+func (t *csvExportWriter) WriteRow(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		record[i] = exportString(value)
+		// Spreadsheets evaluate cells starting with these characters as formulas.
+		if _, isString := value.(string); isString && record[i] != "" && strings.ContainsRune("=+-@\t\r", rune(record[i][0])) {
+			record[i] = "'" + record[i]
+		}
+	}
+	return t.writer.Write(record)
+}
+
+func (t *csvExportWriter) Close() error {
+	t.writer.Flush()
+	return t.writer.Error()
+}
+
+type ndjsonExportWriter struct {
+	encoder *json.Encoder
+	columns []string
+}
+
+func (t *ndjsonExportWriter) WriteRow(values []interface{}) error {
+	object := make(map[string]interface{}, len(values))
+	for i, value := range values {
+		object[t.columns[i]] = value
+	}
+	return t.encoder.Encode(object)
+}
+
+func (t *ndjsonExportWriter) Close() error {
+	return nil
+}
+
+// xlsxParts are the fixed parts of a workbook with a single sheet. The sheet itself is written row by row,
+// which works because zip entries are written one after another.
+var xlsxParts = []struct {
+	Name    string
+	Content string
+}{
+	{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`},
+	{"_rels/.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/workbook.xml", `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Users" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+}
+
+type xlsxExportWriter struct {
+	archive *zip.Writer
+	sheet   io.Writer
+	row     int
+}
+
+func newXLSXExportWriter(writer io.Writer, columns []string) (*xlsxExportWriter, error) {
+	archive := zip.NewWriter(writer)
+	for _, part := range xlsxParts {
+		partWriter, err := archive.Create(part.Name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(partWriter, xml.Header+part.Content); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(sheet, xml.Header+`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`); err != nil {
+		return nil, err
+	}
+
+	exportWriter := &xlsxExportWriter{archive: archive, sheet: sheet}
+	header := make([]interface{}, len(columns))
+	for i, column := range columns {
+		header[i] = column
+	}
+	return exportWriter, exportWriter.WriteRow(header)
+}
+
+// xlsxColumnName turns a zero based index into a column name: A to Z, then AA, AB and so on.
+func xlsxColumnName(index int) string {
+	name := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
+
+func (t *xlsxExportWriter) WriteRow(values []interface{}) error {
+	t.row++
+	var row strings.Builder
+	fmt.Fprintf(&row, `<row r="%d">`, t.row)
+	for i, value := range values {
+		reference := xlsxColumnName(i) + strconv.Itoa(t.row)
+		switch value := value.(type) {
+		case nil:
+			continue
+		case uint64, float64:
+			fmt.Fprintf(&row, `<c r="%s"><v>%s</v></c>`, reference, exportString(value))
+		case bool:
+			cell := "0"
+			if value {
+				cell = "1"
+			}
+			fmt.Fprintf(&row, `<c r="%s" t="b"><v>%s</v></c>`, reference, cell)
+		default:
+			fmt.Fprintf(&row, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, reference)
+			xml.EscapeText(&row, []byte(exportString(value)))
+			row.WriteString(`</t></is></c>`)
+		}
+	}
+	row.WriteString(`</row>`)
+
+	_, err := io.WriteString(t.sheet, row.String())
+	return err
+}
+
+func (t *xlsxExportWriter) Close() error {
+	if _, err := io.WriteString(t.sheet, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	return t.archive.Close()
+}
+
+// ExportUsers streams the organization's users matching attributeFilters to writer. Nothing is written when
+// the columns are invalid, so the caller can still answer with an error.
+func (t *UserUsecaseImpl) ExportUsers(organizationId uint64, exportData repository.UserExport, attributeFilters map[string]string, writer io.Writer, actorId uint64) *helper.StandardError {
+	columns, err := parseExportColumns(exportData.Columns)
+	if err != nil {
+		return err
+	}
+	if exportData.Format == "" {
+		exportData.Format = repository.ExportFormatCSV
+	}
+
+	exportWriter, writeError := newUserExportWriter(exportData.Format, writer, columns)
+	count := 0
+	if writeError == nil {
+		writeError = t.UserRepository.ForOrganization(organizationId).WithAttributes(attributeFilters).Each(func(user repository.User) error {
+			count++
+			values := make([]interface{}, len(columns))
+			for i, column := range columns {
+				values[i] = exportValue(user, column)
+			}
+			return exportWriter.WriteRow(values)
+		})
+	}
+	if writeError == nil {
+		writeError = exportWriter.Close()
+	}
+	if writeError != nil {
+		return &helper.StandardError{Error: writeError, ErrorCode: http.StatusInternalServerError}
+	}
+
+	t.AuditUsecase.Record("user.export", actorId, 0, fmt.Sprintf("format=%s columns=%s users=%d", exportData.Format, strings.Join(columns, ","), count))
+	return nil
+}
@@ -0,0 +1,95 @@
+package usecase_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+var exportedUsers = []repository.User{
+	{ID: 1, OrganizationID: 2, Username: "alice", Email: "alice@example.com", Password: "$2a$10$hash", Roles: "admin", EmailVerified: true, Attributes: repository.UserAttributes{"department": "eng", "level": float64(3)}, CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+	{ID: 2, OrganizationID: 2, Username: "=cmd|' /C calc'!A0", Email: "bob@example.com", Password: "$2a$10$hash", Attributes: repository.UserAttributes{"department": "sales"}},
+}
+
+func exportUsers(t *testing.T, exportData repository.UserExport, filters map[string]string) string {
+	userRepositoryMock := new(mocks.UserRepositoryMock)
+	auditUsecaseMock := new(mocks.AuditUsecaseMock)
+	userRepositoryMock.On("Each").Return(exportedUsers, nil)
+	auditUsecaseMock.On("Record").Return(nil)
+
+	var output bytes.Buffer
+	userUsecase := usecase.NewUserUsecaseImpl(userRepositoryMock, auditUsecaseMock)
+	err := userUsecase.ExportUsers(2, exportData, filters, &output, 100)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, userRepositoryMock.OrganizationID, uint64(2))
+	auditUsecaseMock.AssertNumberOfCalls(t, "Record", 1)
+	return output.String()
+}
+
+func TestExportUsers(t *testing.T) {
+	t.Run("test CSV with default columns", func(t *testing.T) {
+		output := exportUsers(t, repository.UserExport{}, nil)
+
+		assert.Equal(t, output, "id,organizationid,username,email,emailverified,roles,disabledat,createdat,updatedat\n"+
+			"1,2,alice,alice@example.com,true,admin,,2024-01-02T03:04:05Z,0001-01-01T00:00:00Z\n"+
+			"2,2,'=cmd|' /C calc'!A0,bob@example.com,false,,,0001-01-01T00:00:00Z,0001-01-01T00:00:00Z\n")
+		assert.Equal(t, strings.Contains(output, "$2a$"), false)
+	})
+
+	t.Run("test NDJSON with selected columns and filters", func(t *testing.T) {
+		output := exportUsers(t, repository.UserExport{Format: repository.ExportFormatNDJSON, Columns: "username, attributes.level,attributes"}, map[string]string{"department": "eng"})
+
+		assert.Equal(t, output, `{"attributes":{"department":"eng","level":3},"attributes.level":3,"username":"alice"}`+"\n")
+	})
+
+	t.Run("test XLSX", func(t *testing.T) {
+		output := exportUsers(t, repository.UserExport{Format: repository.ExportFormatXLSX, Columns: "id,username,emailverified,attributes.level"}, nil)
+
+		archive, err := zip.NewReader(strings.NewReader(output), int64(len(output)))
+		assert.Equal(t, err, nil)
+		files := map[string]string{}
+		for _, file := range archive.File {
+			reader, _ := file.Open()
+			content, _ := io.ReadAll(reader)
+			files[file.Name] = string(content)
+		}
+		assert.Equal(t, len(files), 5)
+		assert.MatchRegex(t, files["[Content_Types].xml"], `spreadsheetml\.worksheet\+xml`)
+		assert.MatchRegex(t, files["xl/worksheets/sheet1.xml"], `<row r="1"><c r="A1" t="inlineStr"><is><t xml:space="preserve">id</t></is></c>`)
+		assert.MatchRegex(t, files["xl/worksheets/sheet1.xml"], `<row r="2"><c r="A2"><v>1</v></c><c r="B2" t="inlineStr"><is><t xml:space="preserve">alice</t></is></c><c r="C2" t="b"><v>1</v></c><c r="D2"><v>3</v></c></row>`)
+		assert.MatchRegex(t, files["xl/worksheets/sheet1.xml"], `<row r="3"><c r="A3"><v>2</v></c><c r="B3" t="inlineStr"><is><t xml:space="preserve">=cmd\|&#39; /C calc&#39;!A0</t></is></c><c r="C3" t="b"><v>0</v></c></row></sheetData></worksheet>$`)
+	})
+
+	t.Run("test invalid columns", func(t *testing.T) {
+		userUsecase := usecase.NewUserUsecaseImpl(nil, nil)
+
+		for _, column := range []string{"password", "avatarkey", "attributes.Bad-Name"} {
+			var output bytes.Buffer
+			err := userUsecase.ExportUsers(2, repository.UserExport{Columns: "id," + column}, nil, &output, 100)
+			assert.Equal(t, err, helper.StandardError{Error: errors.New("unknown column " + column), ErrorCode: http.StatusBadRequest})
+			assert.Equal(t, output.Len(), 0)
+		}
+	})
+
+	t.Run("test cursor error", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		userRepositoryMock.On("Each").Return([]repository.User{}, errors.New("connection reset"))
+
+		var output bytes.Buffer
+		userUsecase := usecase.NewUserUsecaseImpl(userRepositoryMock, nil)
+		err := userUsecase.ExportUsers(2, repository.UserExport{}, nil, &output, 100)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("connection reset"), ErrorCode: http.StatusInternalServerError})
+	})
+}
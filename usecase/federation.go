@@ -0,0 +1,409 @@
+package usecase
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	FederationStateExpiry = 10 * time.Minute
+	federationHTTPTimeout = 10 * time.Second
+)
+
+var (
+	federationHTTPClient      = &http.Client{Timeout: federationHTTPTimeout}
+	defaultFederationScopes   = []string{ScopeOpenID, ScopeProfile, ScopeEmail}
+	defaultFederationClaims   = map[string]string{"subject": "sub", "username": "preferred_username", "email": "email"}
+	errIdentityProviderFailed = &helper.StandardError{Error: errors.New("identity provider request failed"), ErrorCode: http.StatusBadGateway}
+)
+
+type FederationUsecase interface {
+	BeginLogin(providerName string, linkUserId uint64) (*repository.FederationRedirect, *helper.StandardError)
+	Callback(providerName string, callback repository.FederationCallback, loginContext repository.LoginContext) (*repository.FederationResult, *helper.StandardError)
+	ListIdentities(userId uint64) (*[]repository.Identity, *helper.StandardError)
+	UnlinkIdentity(userId uint64, identityId uint64) (*repository.Identity, *helper.StandardError)
+}
+
+type FederationUsecaseImpl struct {
+	FederationRepository repository.FederationRepository
+	UserRepository       repository.UserRepository
+	PasskeyRepository    repository.PasskeyRepository
+	SessionRepository    repository.SessionRepository
+	AuditUsecase         AuditUsecase
+}
+
+// federationRedirectURL is the frontend page the browser lands on once the callback has signed it in or linked an identity.
+func federationRedirectURL() string {
+	return envOrDefault("FEDERATION_REDIRECT_URL", oidcIssuer()+"/")
+}
+
+func federationProvider(name string) (repository.FederationProvider, *helper.StandardError) {
+	var providers []repository.FederationProvider
+	if encoded := os.Getenv("FEDERATION_PROVIDERS"); encoded != "" {
+		if err := json.Unmarshal([]byte(encoded), &providers); err != nil {
+			return repository.FederationProvider{}, &helper.StandardError{Error: errors.New("invalid federation provider configuration"), ErrorCode: http.StatusInternalServerError}
+		}
+	}
+
+	for _, provider := range providers {
+		if provider.Name == name {
+			if len(provider.Scopes) == 0 {
+				provider.Scopes = defaultFederationScopes
+			}
+			return provider, nil
+		}
+	}
+
+	return repository.FederationProvider{}, &helper.StandardError{Error: errors.New("identity provider not found"), ErrorCode: http.StatusNotFound}
+}
+
+func federationRedirectURI(provider repository.FederationProvider) string {
+	return oidcIssuer() + "/api/v1/federation/" + url.PathEscape(provider.Name) + "/callback"
+}
+
+func claimName(provider repository.FederationProvider, claim string) string {
+	if name, ok := provider.Claims[claim]; ok {
+		return name
+	}
+	return defaultFederationClaims[claim]
+}
+
+func claimString(claims map[string]interface{}, name string) string {
+	switch value := claims[name].(type) {
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	}
+	return ""
+}
+
+func fetchJSON(request *http.Request, target interface{}) error {
+	request.Header.Set("Accept", "application/json")
+
+	response, err := federationHTTPClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", response.StatusCode, request.URL.Host)
+	}
+
+	decoder := json.NewDecoder(response.Body)
+	decoder.UseNumber()
+	return decoder.Decode(target)
+}
+
+func resolveEndpoints(provider repository.FederationProvider) (repository.FederationProvider, error) {
+	if provider.Issuer == "" || (provider.AuthorizationEndpoint != "" && provider.TokenEndpoint != "") {
+		return provider, nil
+	}
+
+	request, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(provider.Issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return provider, err
+	}
+
+	var discovery repository.DiscoveryDocument
+	if err := fetchJSON(request, &discovery); err != nil {
+		return provider, err
+	}
+
+	if discovery.Issuer != provider.Issuer {
+		return provider, errors.New("discovery issuer does not match configured issuer")
+	}
+
+	if provider.AuthorizationEndpoint == "" {
+		provider.AuthorizationEndpoint = discovery.AuthorizationEndpoint
+	}
+	if provider.TokenEndpoint == "" {
+		provider.TokenEndpoint = discovery.TokenEndpoint
+	}
+	if provider.UserInfoEndpoint == "" {
+		provider.UserInfoEndpoint = discovery.UserInfoEndpoint
+	}
+	return provider, nil
+}
+
+// the ID token comes straight from the token endpoint over TLS, so the claims are checked without verifying the signature (OIDC Core 3.1.3.7)
+func idTokenUpstreamClaims(provider repository.FederationProvider, idToken string, nonce string) (map[string]interface{}, error) {
+	claims := jwt.MapClaims{}
+	parser := jwt.NewParser(jwt.WithJSONNumber())
+	if _, _, err := parser.ParseUnverified(idToken, claims); err != nil {
+		return nil, err
+	}
+
+	if provider.Issuer != "" && !claims.VerifyIssuer(provider.Issuer, true) {
+		return nil, errors.New("id token issuer mismatch")
+	}
+	if !claims.VerifyAudience(provider.ClientID, true) {
+		return nil, errors.New("id token audience mismatch")
+	}
+	expiresAt, _ := claims["exp"].(json.Number)
+	if expiry, err := expiresAt.Int64(); err != nil || time.Now().Unix() > expiry {
+		return nil, errors.New("id token expired")
+	}
+	if claims["nonce"] != nonce {
+		return nil, errors.New("id token nonce mismatch")
+	}
+
+	return claims, nil
+}
+
+func (t *FederationUsecaseImpl) upstreamClaims(provider repository.FederationProvider, code string, state repository.FederationState) (map[string]interface{}, error) {
+	form := url.Values{
+		"grant_type":    {GrantAuthorizationCode},
+		"code":          {code},
+		"redirect_uri":  {federationRedirectURI(provider)},
+		"code_verifier": {state.CodeVerifier},
+		"client_id":     {provider.ClientID},
+		"client_secret": {provider.ClientSecret},
+	}
+	request, err := http.NewRequest(http.MethodPost, provider.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var tokenResponse struct {
+		AccessToken string `json:"access_token"`
+		IDToken     string `json:"id_token"`
+	}
+	if err := fetchJSON(request, &tokenResponse); err != nil {
+		return nil, err
+	}
+
+	claims := map[string]interface{}{}
+	if tokenResponse.IDToken != "" {
+		if claims, err = idTokenUpstreamClaims(provider, tokenResponse.IDToken, state.Nonce); err != nil {
+			return nil, err
+		}
+	}
+
+	if provider.UserInfoEndpoint != "" && tokenResponse.AccessToken != "" {
+		request, err := http.NewRequest(http.MethodGet, provider.UserInfoEndpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+		request.Header.Set("Authorization", "Bearer "+tokenResponse.AccessToken)
+
+		userInfo := map[string]interface{}{}
+		if err := fetchJSON(request, &userInfo); err != nil {
+			return nil, err
+		}
+
+		subjectClaim := claimName(provider, "subject")
+		if claims[subjectClaim] != nil && claimString(userInfo, subjectClaim) != claimString(claims, subjectClaim) {
+			return nil, errors.New("userinfo subject does not match id token")
+		}
+		for name, value := range userInfo {
+			claims[name] = value
+		}
+	}
+
+	return claims, nil
+}
+
+func (t *FederationUsecaseImpl) BeginLogin(providerName string, linkUserId uint64) (*repository.FederationRedirect, *helper.StandardError) {
+	provider, providerError := federationProvider(providerName)
+	if providerError != nil {
+		return nil, providerError
+	}
+
+	provider, err := resolveEndpoints(provider)
+	if err != nil {
+		return nil, errIdentityProviderFailed
+	}
+
+	state, stateErr := generateRandomToken("")
+	nonce, nonceErr := generateRandomToken("")
+	codeVerifier, verifierErr := generateRandomToken("")
+	if stateErr != nil || nonceErr != nil || verifierErr != nil {
+		return nil, &helper.StandardError{Error: errors.New("failed to generate state"), ErrorCode: http.StatusInternalServerError}
+	}
+
+	t.FederationRepository.SaveState(repository.FederationState{
+		StateHash:    hashToken(state),
+		Provider:     provider.Name,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		LinkUserID:   linkUserId,
+		ExpiresAt:    time.Now().Add(FederationStateExpiry),
+	})
+
+	challenge := sha256.Sum256([]byte(codeVerifier))
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {provider.ClientID},
+		"redirect_uri":          {federationRedirectURI(provider)},
+		"scope":                 {strings.Join(provider.Scopes, " ")},
+		"state":                 {state},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	if hasScope(provider.Scopes, ScopeOpenID) {
+		params.Set("nonce", nonce)
+	}
+
+	return &repository.FederationRedirect{RedirectTo: withQuery(provider.AuthorizationEndpoint, params), State: state}, nil
+}
+
+func (t *FederationUsecaseImpl) Callback(providerName string, callback repository.FederationCallback, loginContext repository.LoginContext) (*repository.FederationResult, *helper.StandardError) {
+	provider, providerError := federationProvider(providerName)
+	if providerError != nil {
+		return nil, providerError
+	}
+
+	invalidState := &helper.StandardError{Error: errors.New("invalid or expired state"), ErrorCode: http.StatusBadRequest}
+
+	state := t.FederationRepository.FindStateByHash(hashToken(callback.State))
+	if state.ID == 0 || state.Provider != provider.Name || time.Now().After(state.ExpiresAt) || !t.FederationRepository.DeleteState(state.ID) {
+		return nil, invalidState
+	}
+
+	if callback.Error != "" {
+		return nil, &helper.StandardError{Error: fmt.Errorf("identity provider returned %s", callback.Error), ErrorCode: http.StatusBadRequest}
+	}
+
+	provider, err := resolveEndpoints(provider)
+	if err != nil {
+		return nil, errIdentityProviderFailed
+	}
+
+	claims, err := t.upstreamClaims(provider, callback.Code, state)
+	if err != nil {
+		return nil, errIdentityProviderFailed
+	}
+
+	subject := claimString(claims, claimName(provider, "subject"))
+	if subject == "" {
+		return nil, &helper.StandardError{Error: errors.New("identity provider did not return a subject"), ErrorCode: http.StatusBadGateway}
+	}
+
+	identity := t.FederationRepository.FindIdentity(provider.Name, subject)
+	if identity.ID != 0 && t.UserRepository.FindById(identity.UserID).ID == 0 {
+		t.FederationRepository.DeleteIdentity(identity.ID)
+		identity = repository.Identity{}
+	}
+
+	email := claimString(claims, claimName(provider, "email"))
+
+	if state.LinkUserID != 0 {
+		if identity.ID != 0 && identity.UserID != state.LinkUserID {
+			return nil, &helper.StandardError{Error: errors.New("identity is already linked to another user"), ErrorCode: http.StatusConflict}
+		}
+		if identity.ID == 0 {
+			identity = t.FederationRepository.SaveIdentity(repository.Identity{UserID: state.LinkUserID, Provider: provider.Name, Subject: subject, Email: email})
+			t.AuditUsecase.Record("identity.link", state.LinkUserID, state.LinkUserID, fmt.Sprintf("provider=%s identity_id=%d", provider.Name, identity.ID))
+		}
+		return &repository.FederationResult{Identity: identity, RedirectTo: federationRedirectURL()}, nil
+	}
+
+	var user repository.User
+	if identity.ID != 0 {
+		user = t.UserRepository.FindById(identity.UserID)
+	} else {
+		username := claimString(claims, claimName(provider, "username"))
+		if username == "" {
+			username = provider.Name + "_" + subject
+		}
+
+		// New accounts join the organization the login was started from.
+		userRepository := t.UserRepository.ForOrganization(loginContext.OrganizationID)
+		if userRepository.FindByUsername(username).ID != 0 {
+			return nil, &helper.StandardError{Error: fmt.Errorf("user %s already exists, sign in and link the identity from your account", username), ErrorCode: http.StatusConflict}
+		}
+
+		emailVerified, _ := claims["email_verified"].(bool)
+		user = userRepository.Save(repository.User{Username: username, Email: email, EmailVerified: emailVerified && email != ""})
+		t.AuditUsecase.Record("user.register", user.ID, user.ID, fmt.Sprintf("provider=%s", provider.Name))
+
+		identity = t.FederationRepository.SaveIdentity(repository.Identity{UserID: user.ID, Provider: provider.Name, Subject: subject, Email: email})
+	}
+
+	if user.DisabledAt != nil {
+		return nil, errUserDisabled
+	}
+	if user.PasskeyRequired {
+		return nil, errPasskeyRequired
+	}
+
+	now := time.Now()
+	identity.LastLoginAt = &now
+	identity = t.FederationRepository.UpdateIdentity(identity)
+
+	// The callback is a browser navigation, so the login continues as a cookie session rather than a bearer token in the page.
+	session, loginError := startCookieSession(t.SessionRepository, user, SessionMethodFederation, loginContext)
+	if loginError != nil {
+		return nil, loginError
+	}
+
+	t.AuditUsecase.Record("user.login", user.ID, user.ID, fmt.Sprintf("provider=%s session_id=%d", provider.Name, session.ID))
+
+	return &repository.FederationResult{Session: session, Identity: identity, RedirectTo: federationRedirectURL()}, nil
+}
+
+func (t *FederationUsecaseImpl) ListIdentities(userId uint64) (*[]repository.Identity, *helper.StandardError) {
+	identities := t.FederationRepository.FindIdentitiesByUserId(userId)
+	if identities == nil {
+		identities = []repository.Identity{}
+	}
+	return &identities, nil
+}
+
+// hasOtherSignInMethod reports whether the user can still sign in without their identities: with a password, a passkey
+// or a magic link sent to their email.
+func (t *FederationUsecaseImpl) hasOtherSignInMethod(user repository.User) bool {
+	if user.Password != "" || (user.Email != "" && !user.PasskeyRequired) {
+		return true
+	}
+	return len(t.PasskeyRepository.FindCredentialsByUserId(user.ID)) > 0
+}
+
+func (t *FederationUsecaseImpl) UnlinkIdentity(userId uint64, identityId uint64) (*repository.Identity, *helper.StandardError) {
+	identities := t.FederationRepository.FindIdentitiesByUserId(userId)
+
+	var identity repository.Identity
+	for _, userIdentity := range identities {
+		if userIdentity.ID == identityId {
+			identity = userIdentity
+		}
+	}
+
+	if identity.ID == 0 {
+		return nil, &helper.StandardError{Error: errors.New("identity not found"), ErrorCode: http.StatusNotFound}
+	}
+
+	if len(identities) == 1 && !t.hasOtherSignInMethod(t.UserRepository.FindById(userId)) {
+		return nil, &helper.StandardError{Error: errors.New("cannot unlink the only sign-in method of the account"), ErrorCode: http.StatusConflict}
+	}
+
+	t.FederationRepository.DeleteIdentity(identity.ID)
+	t.AuditUsecase.Record("identity.unlink", userId, userId, fmt.Sprintf("provider=%s identity_id=%d", identity.Provider, identity.ID))
+
+	return &identity, nil
+}
+
+func NewFederationUsecaseImpl(federationRepository repository.FederationRepository, userRepository repository.UserRepository, passkeyRepository repository.PasskeyRepository, sessionRepository repository.SessionRepository, auditUsecase AuditUsecase) FederationUsecase {
+	return &FederationUsecaseImpl{
+		FederationRepository: federationRepository,
+		UserRepository:       userRepository,
+		PasskeyRepository:    passkeyRepository,
+		SessionRepository:    sessionRepository,
+		AuditUsecase:         auditUsecase,
+	}
+}
@@ -0,0 +1,341 @@
+package usecase_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/mock"
+)
+
+type fakeIdentityProvider struct {
+	server        *httptest.Server
+	nonce         string
+	codeChallenge string
+	userInfo      map[string]interface{}
+}
+
+func newFakeIdentityProvider() *fakeIdentityProvider {
+	provider := &fakeIdentityProvider{
+		userInfo: map[string]interface{}{
+			"sub":                "upstream-sub",
+			"preferred_username": "alice",
+			"email":              "alice@example.com",
+			"email_verified":     true,
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 provider.server.URL,
+			"authorization_endpoint": provider.server.URL + "/authorize",
+			"token_endpoint":         provider.server.URL + "/token",
+			"userinfo_endpoint":      provider.server.URL + "/userinfo",
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if r.PostForm.Get("code") != "upstream-code" || r.PostForm.Get("client_secret") != "upstream-secret" || base64.RawURLEncoding.EncodeToString(verifier[:]) != provider.codeChallenge {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		idToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"iss":   provider.server.URL,
+			"sub":   "upstream-sub",
+			"aud":   "upstream-client",
+			"exp":   time.Now().Add(time.Minute).Unix(),
+			"nonce": provider.nonce,
+		}).SignedString([]byte("upstream-key"))
+
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "upstream-access", "token_type": "Bearer", "id_token": idToken})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer upstream-access" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(provider.userInfo)
+	})
+
+	provider.server = httptest.NewServer(mux)
+	os.Setenv("FEDERATION_PROVIDERS", fmt.Sprintf(`[{"name":"company","issuer":"%s","clientid":"upstream-client","clientsecret":"upstream-secret"}]`, provider.server.URL))
+
+	return provider
+}
+
+func (p *fakeIdentityProvider) beginLogin(t *testing.T, federationRepositoryMock *mocks.FederationRepositoryMock, linkUserId uint64) string {
+	federationRepositoryMock.On("SaveState", mock.Anything).Return(repository.FederationState{ID: 1})
+
+	federationUsecase := usecase.NewFederationUsecaseImpl(federationRepositoryMock, nil, nil, nil, nil)
+	redirect, err := federationUsecase.BeginLogin("company", linkUserId)
+	assert.Equal(t, err, nil)
+
+	redirectTo, _ := url.Parse(redirect.RedirectTo)
+	assert.Equal(t, strings.HasPrefix(redirect.RedirectTo, p.server.URL+"/authorize?"), true)
+	assert.Equal(t, redirectTo.Query().Get("redirect_uri"), "http://localhost:3000/api/v1/federation/company/callback")
+
+	assert.Equal(t, redirect.State, redirectTo.Query().Get("state"))
+	p.nonce = redirectTo.Query().Get("nonce")
+	p.codeChallenge = redirectTo.Query().Get("code_challenge")
+
+	savedState := federationRepositoryMock.Calls[0].Arguments.Get(0).(repository.FederationState)
+	savedState.ID = 1
+	federationRepositoryMock.On("FindStateByHash").Return(savedState)
+	federationRepositoryMock.On("DeleteState").Return(true)
+
+	return redirectTo.Query().Get("state")
+}
+
+func TestFederationLogin(t *testing.T) {
+	os.Setenv("SECRET", "testkey")
+	provider := newFakeIdentityProvider()
+	defer provider.server.Close()
+	defer os.Unsetenv("FEDERATION_PROVIDERS")
+
+	t.Run("test just in time provisioning", func(t *testing.T) {
+		federationRepositoryMock := new(mocks.FederationRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		state := provider.beginLogin(t, federationRepositoryMock, 0)
+
+		federationRepositoryMock.On("FindIdentity", "company", "upstream-sub").Return(repository.Identity{})
+		userRepositoryMock.On("FindByUsername").Return(repository.User{})
+		userRepositoryMock.On("Save").Return(repository.User{ID: 7, Username: "alice"})
+		federationRepositoryMock.On("SaveIdentity", mock.Anything).Return(repository.Identity{ID: 1, UserID: 7, Provider: "company", Subject: "upstream-sub"})
+		federationRepositoryMock.On("UpdateIdentity").Return(repository.Identity{ID: 1, UserID: 7, Provider: "company", Subject: "upstream-sub"})
+
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		sessionRepositoryMock.On("Save", mock.MatchedBy(func(session repository.Session) bool {
+			return session.Method == usecase.SessionMethodFederation && session.Kind == repository.SessionKindCookie
+		})).Return(loginSession)
+
+		federationUsecase := usecase.NewFederationUsecaseImpl(federationRepositoryMock, userRepositoryMock, nil, sessionRepositoryMock, auditUsecaseMock)
+		result, err := federationUsecase.Callback("company", repository.FederationCallback{Code: "upstream-code", State: state}, repository.LoginContext{})
+
+		assert.Equal(t, err, nil)
+		assert.NotEqual(t, result.Session.SessionToken, "")
+		assert.Equal(t, result.RedirectTo, "http://localhost:3000/")
+		federationRepositoryMock.AssertCalled(t, "SaveIdentity", repository.Identity{UserID: 7, Provider: "company", Subject: "upstream-sub", Email: "alice@example.com"})
+	})
+
+	t.Run("test login with linked identity", func(t *testing.T) {
+		federationRepositoryMock := new(mocks.FederationRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		state := provider.beginLogin(t, federationRepositoryMock, 0)
+
+		federationRepositoryMock.On("FindIdentity", "company", "upstream-sub").Return(repository.Identity{ID: 1, UserID: 100})
+		federationRepositoryMock.On("UpdateIdentity").Return(repository.Identity{ID: 1, UserID: 100})
+		userRepositoryMock.On("FindById").Return(mockUser)
+
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		sessionRepositoryMock.On("Save", mock.MatchedBy(func(session repository.Session) bool {
+			return session.Method == usecase.SessionMethodFederation && session.Kind == repository.SessionKindCookie
+		})).Return(loginSession)
+
+		federationUsecase := usecase.NewFederationUsecaseImpl(federationRepositoryMock, userRepositoryMock, nil, sessionRepositoryMock, auditUsecaseMock)
+		result, err := federationUsecase.Callback("company", repository.FederationCallback{Code: "upstream-code", State: state}, repository.LoginContext{})
+
+		assert.Equal(t, err, nil)
+		assert.NotEqual(t, result.Session.CSRFToken, "")
+		userRepositoryMock.AssertNotCalled(t, "Save")
+	})
+
+	t.Run("users requiring a passkey are refused", func(t *testing.T) {
+		federationRepositoryMock := new(mocks.FederationRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+
+		state := provider.beginLogin(t, federationRepositoryMock, 0)
+
+		passkeyUser := mockUser
+		passkeyUser.PasskeyRequired = true
+		federationRepositoryMock.On("FindIdentity", "company", "upstream-sub").Return(repository.Identity{ID: 1, UserID: 100})
+		userRepositoryMock.On("FindById").Return(passkeyUser)
+
+		federationUsecase := usecase.NewFederationUsecaseImpl(federationRepositoryMock, userRepositoryMock, nil, nil, nil)
+		result, err := federationUsecase.Callback("company", repository.FederationCallback{Code: "upstream-code", State: state}, repository.LoginContext{})
+
+		assert.Equal(t, result, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("passkey required, sign in with a passkey"), ErrorCode: http.StatusForbidden})
+		federationRepositoryMock.AssertNotCalled(t, "UpdateIdentity")
+	})
+
+	t.Run("existing username is not taken over", func(t *testing.T) {
+		federationRepositoryMock := new(mocks.FederationRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+
+		state := provider.beginLogin(t, federationRepositoryMock, 0)
+
+		federationRepositoryMock.On("FindIdentity", "company", "upstream-sub").Return(repository.Identity{})
+		userRepositoryMock.On("FindByUsername").Return(mockUser)
+
+		federationUsecase := usecase.NewFederationUsecaseImpl(federationRepositoryMock, userRepositoryMock, nil, nil, nil)
+		result, err := federationUsecase.Callback("company", repository.FederationCallback{Code: "upstream-code", State: state}, repository.LoginContext{})
+
+		assert.Equal(t, result, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("user alice already exists, sign in and link the identity from your account"), ErrorCode: http.StatusConflict})
+	})
+
+	t.Run("nonce mismatch", func(t *testing.T) {
+		federationRepositoryMock := new(mocks.FederationRepositoryMock)
+
+		state := provider.beginLogin(t, federationRepositoryMock, 0)
+		provider.nonce = "replayed"
+
+		federationUsecase := usecase.NewFederationUsecaseImpl(federationRepositoryMock, nil, nil, nil, nil)
+		result, err := federationUsecase.Callback("company", repository.FederationCallback{Code: "upstream-code", State: state}, repository.LoginContext{})
+
+		assert.Equal(t, result, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("identity provider request failed"), ErrorCode: http.StatusBadGateway})
+	})
+
+	t.Run("unknown state", func(t *testing.T) {
+		federationRepositoryMock := new(mocks.FederationRepositoryMock)
+		federationRepositoryMock.On("FindStateByHash").Return(repository.FederationState{})
+
+		federationUsecase := usecase.NewFederationUsecaseImpl(federationRepositoryMock, nil, nil, nil, nil)
+		result, err := federationUsecase.Callback("company", repository.FederationCallback{Code: "upstream-code", State: "forged"}, repository.LoginContext{})
+
+		assert.Equal(t, result, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("invalid or expired state"), ErrorCode: http.StatusBadRequest})
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		federationUsecase := usecase.NewFederationUsecaseImpl(nil, nil, nil, nil, nil)
+		redirect, err := federationUsecase.BeginLogin("github", 0)
+
+		assert.Equal(t, redirect, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("identity provider not found"), ErrorCode: http.StatusNotFound})
+	})
+}
+
+func TestFederationLink(t *testing.T) {
+	os.Setenv("SECRET", "testkey")
+	provider := newFakeIdentityProvider()
+	defer provider.server.Close()
+	defer os.Unsetenv("FEDERATION_PROVIDERS")
+
+	t.Run("test normal link", func(t *testing.T) {
+		federationRepositoryMock := new(mocks.FederationRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		state := provider.beginLogin(t, federationRepositoryMock, 100)
+
+		federationRepositoryMock.On("FindIdentity", "company", "upstream-sub").Return(repository.Identity{})
+		federationRepositoryMock.On("SaveIdentity", mock.Anything).Return(repository.Identity{ID: 2, UserID: 100, Provider: "company", Subject: "upstream-sub"})
+
+		federationUsecase := usecase.NewFederationUsecaseImpl(federationRepositoryMock, userRepositoryMock, nil, nil, auditUsecaseMock)
+		result, err := federationUsecase.Callback("company", repository.FederationCallback{Code: "upstream-code", State: state}, repository.LoginContext{})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, result.Session, nil)
+		assert.Equal(t, result.Identity.UserID, uint64(100))
+	})
+
+	t.Run("identity linked to another user", func(t *testing.T) {
+		federationRepositoryMock := new(mocks.FederationRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+
+		state := provider.beginLogin(t, federationRepositoryMock, 100)
+
+		federationRepositoryMock.On("FindIdentity", "company", "upstream-sub").Return(repository.Identity{ID: 2, UserID: 7})
+		userRepositoryMock.On("FindById").Return(repository.User{ID: 7})
+
+		federationUsecase := usecase.NewFederationUsecaseImpl(federationRepositoryMock, userRepositoryMock, nil, nil, nil)
+		result, err := federationUsecase.Callback("company", repository.FederationCallback{Code: "upstream-code", State: state}, repository.LoginContext{})
+
+		assert.Equal(t, result, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("identity is already linked to another user"), ErrorCode: http.StatusConflict})
+	})
+}
+
+func TestUnlinkIdentity(t *testing.T) {
+	t.Run("test normal unlink", func(t *testing.T) {
+		federationRepositoryMock := new(mocks.FederationRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		federationRepositoryMock.On("FindIdentitiesByUserId").Return([]repository.Identity{{ID: 2, UserID: 100, Provider: "company"}})
+		federationRepositoryMock.On("DeleteIdentity").Return()
+		userRepositoryMock.On("FindById").Return(mockUser)
+
+		federationUsecase := usecase.NewFederationUsecaseImpl(federationRepositoryMock, userRepositoryMock, nil, nil, auditUsecaseMock)
+		identity, err := federationUsecase.UnlinkIdentity(100, 2)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, identity.Provider, "company")
+	})
+
+	t.Run("passkey or magic link remains", func(t *testing.T) {
+		for _, user := range []repository.User{{ID: 7, Username: "alice"}, {ID: 7, Username: "alice", Email: "alice@example.com"}} {
+			federationRepositoryMock := new(mocks.FederationRepositoryMock)
+			userRepositoryMock := new(mocks.UserRepositoryMock)
+			passkeyRepositoryMock := new(mocks.PasskeyRepositoryMock)
+			auditUsecaseMock := new(mocks.AuditUsecaseMock)
+			auditUsecaseMock.On("Record").Return(nil)
+
+			federationRepositoryMock.On("FindIdentitiesByUserId").Return([]repository.Identity{{ID: 2, UserID: 7, Provider: "company"}})
+			federationRepositoryMock.On("DeleteIdentity").Return()
+			userRepositoryMock.On("FindById").Return(user)
+			passkeyRepositoryMock.On("FindCredentialsByUserId").Return([]repository.PasskeyCredential{{ID: 1, UserID: 7}})
+
+			federationUsecase := usecase.NewFederationUsecaseImpl(federationRepositoryMock, userRepositoryMock, passkeyRepositoryMock, nil, auditUsecaseMock)
+			identity, err := federationUsecase.UnlinkIdentity(7, 2)
+
+			assert.Equal(t, err, nil)
+			assert.Equal(t, identity.ID, uint64(2))
+		}
+	})
+
+	t.Run("only sign-in method", func(t *testing.T) {
+		federationRepositoryMock := new(mocks.FederationRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		passkeyRepositoryMock := new(mocks.PasskeyRepositoryMock)
+
+		federationRepositoryMock.On("FindIdentitiesByUserId").Return([]repository.Identity{{ID: 2, UserID: 7, Provider: "company"}})
+		userRepositoryMock.On("FindById").Return(repository.User{ID: 7, Username: "alice"})
+		passkeyRepositoryMock.On("FindCredentialsByUserId").Return([]repository.PasskeyCredential{})
+
+		federationUsecase := usecase.NewFederationUsecaseImpl(federationRepositoryMock, userRepositoryMock, passkeyRepositoryMock, nil, nil)
+		identity, err := federationUsecase.UnlinkIdentity(7, 2)
+
+		assert.Equal(t, identity, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("cannot unlink the only sign-in method of the account"), ErrorCode: http.StatusConflict})
+	})
+
+	t.Run("negative: identity not found", func(t *testing.T) {
+		federationRepositoryMock := new(mocks.FederationRepositoryMock)
+		federationRepositoryMock.On("FindIdentitiesByUserId").Return([]repository.Identity{})
+
+		federationUsecase := usecase.NewFederationUsecaseImpl(federationRepositoryMock, nil, nil, nil, nil)
+		identity, err := federationUsecase.UnlinkIdentity(100, 2)
+
+		assert.Equal(t, identity, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("identity not found"), ErrorCode: http.StatusNotFound})
+	})
+}
@@ -0,0 +1,207 @@
+package usecase
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+type GroupUsecase interface {
+	CreateGroup(organizationId uint64, groupData repository.SaveGroup, actorId uint64) (*repository.Group, *helper.StandardError)
+	ListGroups(organizationId uint64) (*[]repository.Group, *helper.StandardError)
+	RenameGroup(organizationId uint64, groupId uint64, groupData repository.SaveGroup, actorId uint64) (*repository.Group, *helper.StandardError)
+	DeleteGroup(organizationId uint64, groupId uint64, actorId uint64) (*repository.Group, *helper.StandardError)
+	ListMembers(organizationId uint64, groupId uint64) (*repository.GroupMembers, *helper.StandardError)
+	AddMember(organizationId uint64, groupId uint64, member repository.GroupMember, actorId uint64) (*repository.GroupMember, *helper.StandardError)
+	RemoveMember(organizationId uint64, groupId uint64, member repository.GroupMember, actorId uint64) *helper.StandardError
+}
+
+type GroupUsecaseImpl struct {
+	GroupRepository repository.GroupRepository
+	UserRepository  repository.UserRepository
+	AuditUsecase    AuditUsecase
+}
+
+var (
+	errGroupNotFound = &helper.StandardError{Error: errors.New("group not found"), ErrorCode: http.StatusNotFound}
+	errGroupExists   = &helper.StandardError{Error: errors.New("group already exists"), ErrorCode: http.StatusConflict}
+)
+
+// descendantGroups walks down from the given groups and returns every group and user reachable through nesting.
+// Visited groups are skipped, so the walk also ends if the stored memberships already contain a cycle.
+func descendantGroups(groupRepository repository.GroupRepository, groupIds []uint64) (map[uint64]bool, map[uint64]bool) {
+	groups, users := map[uint64]bool{}, map[uint64]bool{}
+	for _, groupId := range groupIds {
+		groups[groupId] = true
+	}
+
+	for frontier := groupIds; len(frontier) != 0; {
+		var next []uint64
+		for _, member := range groupRepository.FindMembers(frontier) {
+			if member.UserID != 0 {
+				users[member.UserID] = true
+			} else if !groups[member.MemberGroupID] {
+				groups[member.MemberGroupID] = true
+				next = append(next, member.MemberGroupID)
+			}
+		}
+		frontier = next
+	}
+
+	return groups, users
+}
+
+// effectiveGroups returns the groups the user belongs to directly or through nested groups, sorted by name.
+func effectiveGroups(groupRepository repository.GroupRepository, userId uint64, organizationId uint64) []string {
+	visited := map[uint64]bool{}
+	var groupIds []uint64
+
+	parents := groupRepository.FindParents(userId, nil)
+	for len(parents) != 0 {
+		var frontier []uint64
+		for _, parent := range parents {
+			if !visited[parent.GroupID] {
+				visited[parent.GroupID] = true
+				frontier = append(frontier, parent.GroupID)
+			}
+		}
+		if len(frontier) == 0 {
+			break
+		}
+		groupIds = append(groupIds, frontier...)
+		parents = groupRepository.FindParents(0, frontier)
+	}
+
+	names := []string{}
+	for _, group := range groupRepository.FindByIds(groupIds) {
+		if group.OrganizationID == organizationId {
+			names = append(names, group.Name)
+		}
+	}
+	return names
+}
+
+func (t *GroupUsecaseImpl) CreateGroup(organizationId uint64, groupData repository.SaveGroup, actorId uint64) (*repository.Group, *helper.StandardError) {
+	if t.GroupRepository.FindByName(organizationId, groupData.Name).ID != 0 {
+		return nil, errGroupExists
+	}
+
+	group := t.GroupRepository.Save(repository.Group{OrganizationID: organizationId, Name: groupData.Name})
+	if group.ID == 0 {
+		return nil, &helper.StandardError{Error: errors.New("failed to create group"), ErrorCode: http.StatusInternalServerError}
+	}
+
+	t.AuditUsecase.Record("group.create", actorId, 0, fmt.Sprintf("group_id=%d name=%q", group.ID, group.Name))
+
+	return &group, nil
+}
+
+func (t *GroupUsecaseImpl) ListGroups(organizationId uint64) (*[]repository.Group, *helper.StandardError) {
+	groups := t.GroupRepository.FindByOrganizationId(organizationId)
+	if groups == nil {
+		groups = []repository.Group{}
+	}
+	return &groups, nil
+}
+
+func (t *GroupUsecaseImpl) RenameGroup(organizationId uint64, groupId uint64, groupData repository.SaveGroup, actorId uint64) (*repository.Group, *helper.StandardError) {
+	group := t.GroupRepository.FindById(organizationId, groupId)
+	if group.ID == 0 {
+		return nil, errGroupNotFound
+	}
+
+	if existing := t.GroupRepository.FindByName(organizationId, groupData.Name); existing.ID != 0 && existing.ID != group.ID {
+		return nil, errGroupExists
+	}
+
+	previousName := group.Name
+	group.Name = groupData.Name
+	group = t.GroupRepository.Update(group)
+	t.AuditUsecase.Record("group.rename", actorId, 0, fmt.Sprintf("group_id=%d from=%q to=%q", group.ID, previousName, group.Name))
+
+	return &group, nil
+}
+
+func (t *GroupUsecaseImpl) DeleteGroup(organizationId uint64, groupId uint64, actorId uint64) (*repository.Group, *helper.StandardError) {
+	group := t.GroupRepository.FindById(organizationId, groupId)
+	if group.ID == 0 || !t.GroupRepository.Delete(organizationId, groupId) {
+		return nil, errGroupNotFound
+	}
+
+	t.AuditUsecase.Record("group.delete", actorId, 0, fmt.Sprintf("group_id=%d name=%q", group.ID, group.Name))
+
+	return &group, nil
+}
+
+func (t *GroupUsecaseImpl) ListMembers(organizationId uint64, groupId uint64) (*repository.GroupMembers, *helper.StandardError) {
+	if t.GroupRepository.FindById(organizationId, groupId).ID == 0 {
+		return nil, errGroupNotFound
+	}
+
+	members := t.GroupRepository.FindMembers([]uint64{groupId})
+	if members == nil {
+		members = []repository.GroupMember{}
+	}
+
+	_, users := descendantGroups(t.GroupRepository, []uint64{groupId})
+	effectiveUsers := make([]uint64, 0, len(users))
+	for userId := range users {
+		effectiveUsers = append(effectiveUsers, userId)
+	}
+	sort.Slice(effectiveUsers, func(i, j int) bool { return effectiveUsers[i] < effectiveUsers[j] })
+
+	return &repository.GroupMembers{Members: members, EffectiveUsers: effectiveUsers}, nil
+}
+
+// AddMember puts a user of the organization, or another of its groups, into the group.
+// A group may not end up inside itself, directly or through any of its descendants.
+func (t *GroupUsecaseImpl) AddMember(organizationId uint64, groupId uint64, member repository.GroupMember, actorId uint64) (*repository.GroupMember, *helper.StandardError) {
+	if t.GroupRepository.FindById(organizationId, groupId).ID == 0 {
+		return nil, errGroupNotFound
+	}
+
+	member.GroupID = groupId
+	if member.UserID != 0 {
+		if t.UserRepository.ForOrganization(organizationId).FindById(member.UserID).ID == 0 {
+			return nil, &helper.StandardError{Error: errors.New("user not found"), ErrorCode: http.StatusNotFound}
+		}
+	} else {
+		if t.GroupRepository.FindById(organizationId, member.MemberGroupID).ID == 0 {
+			return nil, errGroupNotFound
+		}
+		if descendants, _ := descendantGroups(t.GroupRepository, []uint64{member.MemberGroupID}); descendants[groupId] {
+			return nil, &helper.StandardError{Error: errors.New("group membership would create a cycle"), ErrorCode: http.StatusConflict}
+		}
+	}
+
+	member = t.GroupRepository.SaveMember(member)
+	t.AuditUsecase.Record("group.member_add", actorId, member.UserID, fmt.Sprintf("group_id=%d member_group_id=%d", groupId, member.MemberGroupID))
+
+	return &member, nil
+}
+
+func (t *GroupUsecaseImpl) RemoveMember(organizationId uint64, groupId uint64, member repository.GroupMember, actorId uint64) *helper.StandardError {
+	if t.GroupRepository.FindById(organizationId, groupId).ID == 0 {
+		return errGroupNotFound
+	}
+
+	member.GroupID = groupId
+	if !t.GroupRepository.DeleteMember(member) {
+		return &helper.StandardError{Error: errors.New("group member not found"), ErrorCode: http.StatusNotFound}
+	}
+
+	t.AuditUsecase.Record("group.member_remove", actorId, member.UserID, fmt.Sprintf("group_id=%d member_group_id=%d", groupId, member.MemberGroupID))
+
+	return nil
+}
+
+func NewGroupUsecaseImpl(groupRepository repository.GroupRepository, userRepository repository.UserRepository, auditUsecase AuditUsecase) GroupUsecase {
+	return &GroupUsecaseImpl{
+		GroupRepository: groupRepository,
+		UserRepository:  userRepository,
+		AuditUsecase:    auditUsecase,
+	}
+}
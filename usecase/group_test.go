@@ -0,0 +1,204 @@
+package usecase_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+var (
+	engineeringGroup = repository.Group{ID: 1, OrganizationID: 2, Name: "engineering"}
+	backendGroup     = repository.Group{ID: 2, OrganizationID: 2, Name: "backend"}
+)
+
+func TestCreateGroup(t *testing.T) {
+	t.Run("test create group", func(t *testing.T) {
+		groupRepositoryMock := new(mocks.GroupRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		groupRepositoryMock.On("FindByName", uint64(2), "engineering").Return(repository.Group{})
+		groupRepositoryMock.On("Save", repository.Group{OrganizationID: 2, Name: "engineering"}).Return(engineeringGroup)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		groupUsecase := usecase.NewGroupUsecaseImpl(groupRepositoryMock, nil, auditUsecaseMock)
+		group, err := groupUsecase.CreateGroup(2, repository.SaveGroup{Name: "engineering"}, 100)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, group, &engineeringGroup)
+	})
+
+	t.Run("name taken", func(t *testing.T) {
+		groupRepositoryMock := new(mocks.GroupRepositoryMock)
+		groupRepositoryMock.On("FindByName", uint64(2), "engineering").Return(engineeringGroup)
+
+		groupUsecase := usecase.NewGroupUsecaseImpl(groupRepositoryMock, nil, nil)
+		group, err := groupUsecase.CreateGroup(2, repository.SaveGroup{Name: "engineering"}, 100)
+
+		assert.Equal(t, group, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("group already exists"), ErrorCode: http.StatusConflict})
+	})
+}
+
+func TestRenameGroup(t *testing.T) {
+	t.Run("test rename group", func(t *testing.T) {
+		renamed := engineeringGroup
+		renamed.Name = "platform"
+
+		groupRepositoryMock := new(mocks.GroupRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		groupRepositoryMock.On("FindById", uint64(2), uint64(1)).Return(engineeringGroup)
+		groupRepositoryMock.On("FindByName", uint64(2), "platform").Return(repository.Group{})
+		groupRepositoryMock.On("Update", renamed).Return(renamed)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		groupUsecase := usecase.NewGroupUsecaseImpl(groupRepositoryMock, nil, auditUsecaseMock)
+		group, err := groupUsecase.RenameGroup(2, 1, repository.SaveGroup{Name: "platform"}, 100)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, group.Name, "platform")
+	})
+
+	t.Run("group of another organization", func(t *testing.T) {
+		groupRepositoryMock := new(mocks.GroupRepositoryMock)
+		groupRepositoryMock.On("FindById", uint64(3), uint64(1)).Return(repository.Group{})
+
+		groupUsecase := usecase.NewGroupUsecaseImpl(groupRepositoryMock, nil, nil)
+		group, err := groupUsecase.RenameGroup(3, 1, repository.SaveGroup{Name: "platform"}, 100)
+
+		assert.Equal(t, group, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("group not found"), ErrorCode: http.StatusNotFound})
+	})
+}
+
+func TestDeleteGroup(t *testing.T) {
+	groupRepositoryMock := new(mocks.GroupRepositoryMock)
+	auditUsecaseMock := new(mocks.AuditUsecaseMock)
+	groupRepositoryMock.On("FindById", uint64(2), uint64(1)).Return(engineeringGroup)
+	groupRepositoryMock.On("Delete", uint64(2), uint64(1)).Return(true)
+	auditUsecaseMock.On("Record").Return(nil)
+
+	groupUsecase := usecase.NewGroupUsecaseImpl(groupRepositoryMock, nil, auditUsecaseMock)
+	group, err := groupUsecase.DeleteGroup(2, 1, 100)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, group, &engineeringGroup)
+}
+
+func TestListGroupMembers(t *testing.T) {
+	groupRepositoryMock := new(mocks.GroupRepositoryMock)
+	groupRepositoryMock.On("FindById", uint64(2), uint64(1)).Return(engineeringGroup)
+	direct := []repository.GroupMember{{ID: 1, GroupID: 1, UserID: 101}, {ID: 2, GroupID: 1, MemberGroupID: 2}}
+	groupRepositoryMock.On("FindMembers", []uint64{1}).Return(direct)
+	groupRepositoryMock.On("FindMembers", []uint64{2}).Return([]repository.GroupMember{{ID: 3, GroupID: 2, UserID: 100}, {ID: 4, GroupID: 2, UserID: 101}, {ID: 5, GroupID: 2, MemberGroupID: 1}})
+
+	groupUsecase := usecase.NewGroupUsecaseImpl(groupRepositoryMock, nil, nil)
+	members, err := groupUsecase.ListMembers(2, 1)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, members.Members, direct)
+	assert.Equal(t, members.EffectiveUsers, []uint64{100, 101})
+}
+
+func TestAddGroupMember(t *testing.T) {
+	t.Run("test add user", func(t *testing.T) {
+		groupRepositoryMock := new(mocks.GroupRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		groupRepositoryMock.On("FindById", uint64(2), uint64(1)).Return(engineeringGroup)
+		groupRepositoryMock.On("SaveMember", repository.GroupMember{GroupID: 1, UserID: 100}).Return(repository.GroupMember{ID: 1, GroupID: 1, UserID: 100})
+		userRepositoryMock.On("FindById").Return(mockUser)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		groupUsecase := usecase.NewGroupUsecaseImpl(groupRepositoryMock, userRepositoryMock, auditUsecaseMock)
+		member, err := groupUsecase.AddMember(2, 1, repository.GroupMember{UserID: 100}, 100)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, member.ID, uint64(1))
+		assert.Equal(t, userRepositoryMock.OrganizationID, uint64(2))
+	})
+
+	t.Run("test nest group", func(t *testing.T) {
+		groupRepositoryMock := new(mocks.GroupRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		groupRepositoryMock.On("FindById", uint64(2), uint64(1)).Return(engineeringGroup)
+		groupRepositoryMock.On("FindById", uint64(2), uint64(2)).Return(backendGroup)
+		groupRepositoryMock.On("FindMembers", []uint64{2}).Return([]repository.GroupMember{{GroupID: 2, UserID: 100}})
+		groupRepositoryMock.On("SaveMember", repository.GroupMember{GroupID: 1, MemberGroupID: 2}).Return(repository.GroupMember{ID: 2, GroupID: 1, MemberGroupID: 2})
+		auditUsecaseMock.On("Record").Return(nil)
+
+		groupUsecase := usecase.NewGroupUsecaseImpl(groupRepositoryMock, nil, auditUsecaseMock)
+		member, err := groupUsecase.AddMember(2, 1, repository.GroupMember{MemberGroupID: 2}, 100)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, member.MemberGroupID, uint64(2))
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		// engineering already contains backend, which contains platform; platform may not contain engineering.
+		groupRepositoryMock := new(mocks.GroupRepositoryMock)
+		groupRepositoryMock.On("FindById", uint64(2), uint64(3)).Return(repository.Group{ID: 3, OrganizationID: 2, Name: "platform"})
+		groupRepositoryMock.On("FindById", uint64(2), uint64(1)).Return(engineeringGroup)
+		groupRepositoryMock.On("FindMembers", []uint64{1}).Return([]repository.GroupMember{{GroupID: 1, MemberGroupID: 2}})
+		groupRepositoryMock.On("FindMembers", []uint64{2}).Return([]repository.GroupMember{{GroupID: 2, MemberGroupID: 3}})
+		groupRepositoryMock.On("FindMembers", []uint64{3}).Return([]repository.GroupMember{})
+
+		groupUsecase := usecase.NewGroupUsecaseImpl(groupRepositoryMock, nil, nil)
+		member, err := groupUsecase.AddMember(2, 3, repository.GroupMember{MemberGroupID: 1}, 100)
+
+		assert.Equal(t, member, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("group membership would create a cycle"), ErrorCode: http.StatusConflict})
+		groupRepositoryMock.AssertNotCalled(t, "SaveMember")
+	})
+
+	t.Run("self", func(t *testing.T) {
+		groupRepositoryMock := new(mocks.GroupRepositoryMock)
+		groupRepositoryMock.On("FindById", uint64(2), uint64(1)).Return(engineeringGroup)
+		groupRepositoryMock.On("FindMembers", []uint64{1}).Return([]repository.GroupMember{})
+
+		groupUsecase := usecase.NewGroupUsecaseImpl(groupRepositoryMock, nil, nil)
+		_, err := groupUsecase.AddMember(2, 1, repository.GroupMember{MemberGroupID: 1}, 100)
+
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("group membership would create a cycle"), ErrorCode: http.StatusConflict})
+	})
+
+	t.Run("user of another organization", func(t *testing.T) {
+		groupRepositoryMock := new(mocks.GroupRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		groupRepositoryMock.On("FindById", uint64(2), uint64(1)).Return(engineeringGroup)
+		userRepositoryMock.On("FindById").Return(repository.User{})
+
+		groupUsecase := usecase.NewGroupUsecaseImpl(groupRepositoryMock, userRepositoryMock, nil)
+		_, err := groupUsecase.AddMember(2, 1, repository.GroupMember{UserID: 100}, 100)
+
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("user not found"), ErrorCode: http.StatusNotFound})
+	})
+}
+
+func TestRemoveGroupMember(t *testing.T) {
+	t.Run("test remove user", func(t *testing.T) {
+		groupRepositoryMock := new(mocks.GroupRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		groupRepositoryMock.On("FindById", uint64(2), uint64(1)).Return(engineeringGroup)
+		groupRepositoryMock.On("DeleteMember", repository.GroupMember{GroupID: 1, UserID: 100}).Return(true)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		groupUsecase := usecase.NewGroupUsecaseImpl(groupRepositoryMock, nil, auditUsecaseMock)
+
+		assert.Equal(t, groupUsecase.RemoveMember(2, 1, repository.GroupMember{UserID: 100}, 100), nil)
+	})
+
+	t.Run("not a member", func(t *testing.T) {
+		groupRepositoryMock := new(mocks.GroupRepositoryMock)
+		groupRepositoryMock.On("FindById", uint64(2), uint64(1)).Return(engineeringGroup)
+		groupRepositoryMock.On("DeleteMember", repository.GroupMember{GroupID: 1, MemberGroupID: 2}).Return(false)
+
+		groupUsecase := usecase.NewGroupUsecaseImpl(groupRepositoryMock, nil, nil)
+
+		assert.Equal(t, groupUsecase.RemoveMember(2, 1, repository.GroupMember{MemberGroupID: 2}, 100), helper.StandardError{Error: errors.New("group member not found"), ErrorCode: http.StatusNotFound})
+	})
+}
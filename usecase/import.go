@@ -0,0 +1,545 @@
+package usecase
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/mail"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type ImportUsecase interface {
+	StartImport(organizationId uint64, options repository.ImportOptions, data []byte, actorId uint64) (*repository.ImportJob, *helper.StandardError)
+	RunImport(organizationId uint64, options repository.ImportOptions, data []byte, actorId uint64) (*repository.ImportJob, *helper.StandardError)
+	GetImport(organizationId uint64, jobId uint64) (*repository.ImportJob, *helper.StandardError)
+	ListImports(organizationId uint64) (*[]repository.ImportJob, *helper.StandardError)
+	FailInterruptedImports() int64
+}
+
+type ImportUsecaseImpl struct {
+	ImportRepository    repository.ImportRepository
+	UserRepository      repository.UserRepository
+	AttributeRepository repository.AttributeRepository
+	PolicyUsecase       PolicyUsecase
+	AuditUsecase        AuditUsecase
+}
+
+const (
+	defaultImportMaxBytes  = 10 << 20
+	defaultImportBatchSize = 100
+	// maxImportErrors bounds the errors kept on a job; Failed still counts every rejected row.
+	maxImportErrors = 1000
+)
+
+var (
+	errImportNotFound = &helper.StandardError{Error: errors.New("import not found"), ErrorCode: http.StatusNotFound}
+
+	importColumns = []string{"username", "email", "password", "password_hash", "roles"}
+)
+
+// importRow is a parsed row of an import file; Line is where it starts in the file, for error reporting.
+type importRow struct {
+	Line   int
+	Record repository.ImportRecord
+	Error  error
+}
+
+func ImportMaxBytes() int64 {
+	if maxBytes, err := strconv.ParseInt(os.Getenv("IMPORT_MAX_BYTES"), 10, 64); err == nil && maxBytes > 0 {
+		return maxBytes
+	}
+	return defaultImportMaxBytes
+}
+
+func importBatchSize() int {
+	if batchSize, err := strconv.Atoi(os.Getenv("IMPORT_BATCH_SIZE")); err == nil && batchSize > 0 {
+		return batchSize
+	}
+	return defaultImportBatchSize
+}
+
+func invalidImport(format string, args ...interface{}) *helper.StandardError {
+	return &helper.StandardError{Error: fmt.Errorf(format, args...), ErrorCode: http.StatusBadRequest}
+}
+
+// parseCSVImport reads a CSV file with a header row. Attribute columns are named "attributes.<name>" and
+// their cells are converted to the type of the attribute's definition; empty cells are left out.
+func parseCSVImport(data []byte, definitions []repository.AttributeDefinition) ([]importRow, *helper.StandardError) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, invalidImport("import file is empty")
+	}
+	if err != nil {
+		return nil, invalidImport("invalid CSV: %v", err)
+	}
+
+	types := map[string]string{}
+	for _, definition := range definitions {
+		types[definition.Name] = definition.Type
+	}
+	seen := map[string]bool{}
+	for i, column := range header {
+		column = strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))
+		header[i] = column
+		if seen[column] {
+			return nil, invalidImport("duplicate column %s", column)
+		}
+		seen[column] = true
+		if name, ok := strings.CutPrefix(column, "attributes."); ok {
+			if _, defined := types[name]; !defined {
+				return nil, invalidImport("unknown attribute %s", name)
+			}
+		} else if !containsString(importColumns, column) {
+			return nil, invalidImport("unknown column %s", column)
+		}
+	}
+	if !seen["username"] || !seen["email"] {
+		return nil, invalidImport("CSV header must contain username and email columns")
+	}
+
+	var rows []importRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+			return nil, invalidImport("invalid CSV: %v", err)
+		}
+
+		row := importRow{Line: line}
+		if err != nil {
+			row.Error = fmt.Errorf("expected %d fields, got %d", len(header), len(record))
+			rows = append(rows, row)
+			continue
+		}
+		for i, column := range header {
+			value := strings.TrimSpace(record[i])
+			switch column {
+			case "username":
+				row.Record.Username = value
+			case "email":
+				row.Record.Email = value
+			case "password":
+				row.Record.Password = record[i]
+			case "password_hash":
+				row.Record.PasswordHash = value
+			case "roles":
+				row.Record.Roles = value
+			default:
+				if value == "" || row.Error != nil {
+					continue
+				}
+				name := strings.TrimPrefix(column, "attributes.")
+				converted, convertError := convertAttribute(types[name], value)
+				if convertError != nil {
+					row.Error = fmt.Errorf("attribute %s %s", name, convertError.Error())
+					continue
+				}
+				if row.Record.Attributes == nil {
+					row.Record.Attributes = repository.UserAttributes{}
+				}
+				row.Record.Attributes[name] = converted
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func convertAttribute(attributeType string, value string) (interface{}, error) {
+	switch attributeType {
+	case repository.AttributeTypeNumber:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, errors.New("must be a number")
+		}
+		return number, nil
+	case repository.AttributeTypeBoolean:
+		boolean, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, errors.New("must be a boolean")
+		}
+		return boolean, nil
+	}
+	return value, nil
+}
+
+// parseNDJSONImport reads one JSON object per line, skipping blank lines. A malformed line only fails its own row.
+func parseNDJSONImport(data []byte) ([]importRow, *helper.StandardError) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64<<10), len(data)+1)
+
+	var rows []importRow
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		row := importRow{Line: line}
+		decoder := json.NewDecoder(bytes.NewReader(text))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&row.Record); err != nil {
+			row.Error = fmt.Errorf("invalid JSON: %v", err)
+		} else if decoder.More() {
+			row.Error = errors.New("invalid JSON: expected one object per line")
+		}
+		row.Record.Username = strings.TrimSpace(row.Record.Username)
+		row.Record.Email = strings.TrimSpace(row.Record.Email)
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, invalidImport("invalid NDJSON: %v", err)
+	}
+	return rows, nil
+}
+
+func (t *ImportUsecaseImpl) parse(organizationId uint64, format string, data []byte) ([]importRow, *helper.StandardError) {
+	if int64(len(data)) > ImportMaxBytes() {
+		return nil, &helper.StandardError{Error: fmt.Errorf("import file must be at most %d bytes", ImportMaxBytes()), ErrorCode: http.StatusRequestEntityTooLarge}
+	}
+
+	var rows []importRow
+	var err *helper.StandardError
+	switch format {
+	case repository.ImportFormatCSV:
+		rows, err = parseCSVImport(data, t.AttributeRepository.FindByOrganizationId(organizationId))
+	case repository.ImportFormatNDJSON:
+		rows, err = parseNDJSONImport(data)
+	default:
+		return nil, invalidImport("format must be csv or ndjson")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, invalidImport("import file contains no users")
+	}
+	return rows, nil
+}
+
+// importState carries what a job learns while processing rows, so later rows can be checked against earlier ones.
+type importState struct {
+	subject        map[string]interface{}
+	userRepository repository.UserRepository
+	definitions    []repository.AttributeDefinition
+	usernames      map[string]bool
+	uniqueValues   map[string]string
+}
+
+// prepareUser validates a row and returns the user it creates or, in upsert mode, the columns of the existing user it
+// updates. The username and unique attribute values of the row are only claimed once the row is valid.
+func (t *ImportUsecaseImpl) prepareUser(job repository.ImportJob, state *importState, row importRow) (repository.User, error) {
+	if row.Error != nil {
+		return repository.User{}, row.Error
+	}
+	record := row.Record
+
+	if record.Username == "" {
+		return repository.User{}, errors.New("username is required")
+	}
+	if state.usernames[record.Username] {
+		return repository.User{}, errors.New("duplicate username in import")
+	}
+
+	if address, err := mail.ParseAddress(record.Email); err != nil || address.Address != record.Email {
+		return repository.User{}, errors.New("invalid email")
+	}
+	if record.Password != "" && record.PasswordHash != "" {
+		return repository.User{}, errors.New("set either password or password_hash, not both")
+	}
+	if record.PasswordHash != "" && !isSupportedPasswordHash(record.PasswordHash) {
+		return repository.User{}, errors.New("unsupported password_hash")
+	}
+
+	user := state.userRepository.FindByUsername(record.Username)
+	if user.ID != 0 && !job.Upsert {
+		return repository.User{}, errors.New("user already exist")
+	}
+	if user.ID == 0 {
+		if record.Password == "" && record.PasswordHash == "" {
+			return repository.User{}, errors.New("password or password_hash is required")
+		}
+		user = repository.User{OrganizationID: job.OrganizationID, Username: record.Username}
+	} else {
+		if !t.authorizeUser(job, state, "users:update", user.ID) {
+			return repository.User{}, errors.New("access denied by policy")
+		}
+		if (record.Password != "" || record.PasswordHash != "") && !job.UpdatePasswords {
+			return repository.User{}, errors.New("passwords of existing users are only replaced with update_passwords")
+		}
+	}
+	roles := strings.Join(strings.Fields(strings.ReplaceAll(record.Roles, ",", " ")), " ")
+	if roles != "" && roles != user.Roles && !t.mayAssignRoles(job, state, user.ID) {
+		return repository.User{}, errors.New("not allowed to assign roles")
+	}
+
+	// Upserts keep attributes the row does not mention.
+	attributes := repository.UserAttributes{}
+	for name, value := range user.Attributes {
+		attributes[name] = value
+	}
+	for name, value := range record.Attributes {
+		attributes[name] = value
+	}
+	if err := validateUserAttributes(t.AttributeRepository, state.userRepository, job.OrganizationID, user.ID, attributes); err != nil {
+		return repository.User{}, err.Error
+	}
+	uniqueKeys := []string{}
+	for _, definition := range state.definitions {
+		value, ok := attributes[definition.Name]
+		if !definition.Unique || !ok || value == nil {
+			continue
+		}
+		key := definition.Name + "=" + attributeString(value)
+		if other, used := state.uniqueValues[key]; used && other != user.Username {
+			return repository.User{}, fmt.Errorf("attribute %s is already used by another user", definition.Name)
+		}
+		uniqueKeys = append(uniqueKeys, key)
+	}
+
+	// Columns the row does not import stay empty, so SaveUsers leaves them alone on existing users.
+	imported := repository.User{ID: user.ID, OrganizationID: job.OrganizationID, Username: record.Username, Email: record.Email, Roles: roles}
+	if len(record.Attributes) != 0 {
+		imported.Attributes = attributes
+	}
+	if record.PasswordHash != "" {
+		imported.Password = record.PasswordHash
+	}
+	if record.Password != "" {
+		passwordHash, err := bcrypt.GenerateFromPassword([]byte(record.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return repository.User{}, err
+		}
+		imported.Password = string(passwordHash)
+	}
+
+	state.usernames[record.Username] = true
+	for _, key := range uniqueKeys {
+		state.uniqueValues[key] = record.Username
+	}
+	return imported, nil
+}
+
+// authorizeUser checks the policy for an existing user the import changes. Imports from the command line are
+// not checked.
+func (t *ImportUsecaseImpl) authorizeUser(job repository.ImportJob, state *importState, action string, userId uint64) bool {
+	if state.subject == nil {
+		return true
+	}
+	return t.PolicyUsecase.Enforce(job.OrganizationID, state.subject, action, map[string]interface{}{"type": PolicyResourceUser, "id": userId}) == nil
+}
+
+// mayAssignRoles reports whether the caller may set roles. Roles grant privileges, so they need the separate
+// users:assign_roles action; without a policy to grant it, only imports from the command line can set them.
+func (t *ImportUsecaseImpl) mayAssignRoles(job repository.ImportJob, state *importState, userId uint64) bool {
+	if state.subject == nil {
+		return true
+	}
+	if !policyConfigured() {
+		return false
+	}
+	resource := map[string]interface{}{"type": PolicyResourceUser, "organization_id": job.OrganizationID}
+	if userId != 0 {
+		resource = map[string]interface{}{"type": PolicyResourceUser, "id": userId}
+	}
+	return t.PolicyUsecase.Enforce(job.OrganizationID, state.subject, "users:assign_roles", resource) == nil
+}
+
+func addImportError(job *repository.ImportJob, row int, username string, err error) {
+	job.Failed++
+	if len(job.Errors) < maxImportErrors {
+		job.Errors = append(job.Errors, repository.ImportRowError{Row: row, Username: username, Error: err.Error()})
+	}
+}
+
+// process validates every row and writes the valid ones in batches of IMPORT_BATCH_SIZE, each in its own
+// transaction. A batch that fails to write marks all of its rows as failed; earlier batches stay written.
+func (t *ImportUsecaseImpl) process(job repository.ImportJob, subject map[string]interface{}, rows []importRow) repository.ImportJob {
+	state := &importState{
+		subject:        subject,
+		userRepository: t.UserRepository.ForOrganization(job.OrganizationID),
+		definitions:    t.AttributeRepository.FindByOrganizationId(job.OrganizationID),
+		usernames:      map[string]bool{},
+		uniqueValues:   map[string]string{},
+	}
+	batchSize := importBatchSize()
+
+	var batch []repository.User
+	var batchRows []int
+	batchEnd := 0
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		created := make([]bool, len(batch))
+		for i, user := range batch {
+			created[i] = user.ID == 0
+		}
+		var written []bool
+		var err error
+		if !job.DryRun {
+			written, err = t.ImportRepository.SaveUsers(batch)
+		}
+		for i, user := range batch {
+			switch {
+			case err != nil:
+				addImportError(&job, batchRows[i], user.Username, fmt.Errorf("failed to save batch: %v", err))
+			case written != nil && !written[i]:
+				addImportError(&job, batchRows[i], user.Username, errors.New("user was deleted or moved during the import"))
+			case created[i]:
+				job.Created++
+			default:
+				job.Updated++
+			}
+		}
+		batch, batchRows = nil, nil
+		job.Processed = batchEnd
+		job = t.ImportRepository.UpdateJob(job)
+	}
+
+	for i, row := range rows {
+		batchEnd = i + 1
+		user, err := t.prepareUser(job, state, row)
+		if err != nil {
+			addImportError(&job, row.Line, row.Record.Username, err)
+			continue
+		}
+		batch = append(batch, user)
+		batchRows = append(batchRows, row.Line)
+		if len(batch) == batchSize {
+			flush()
+		}
+	}
+	flush()
+
+	completedAt := time.Now()
+	job.Status = repository.ImportStatusCompleted
+	job.Processed = len(rows)
+	job.CompletedAt = &completedAt
+	job = t.ImportRepository.UpdateJob(job)
+
+	if !job.DryRun {
+		t.AuditUsecase.Record("user.import", job.CreatedBy, 0, fmt.Sprintf("job=%d created=%d updated=%d failed=%d", job.ID, job.Created, job.Updated, job.Failed))
+	}
+	return job
+}
+
+func (t *ImportUsecaseImpl) createJob(organizationId uint64, options repository.ImportOptions, data []byte, actorId uint64) (repository.ImportJob, []importRow, *helper.StandardError) {
+	organizationId = organizationOrDefault(organizationId)
+	if options.Upsert && options.Subject != nil {
+		collection := map[string]interface{}{"type": PolicyResourceUser, "organization_id": organizationId}
+		if err := t.PolicyUsecase.Enforce(organizationId, options.Subject, "users:update", collection); err != nil {
+			return repository.ImportJob{}, nil, err
+		}
+	}
+	rows, err := t.parse(organizationId, options.Format, data)
+	if err != nil {
+		return repository.ImportJob{}, nil, err
+	}
+
+	job := t.ImportRepository.SaveJob(repository.ImportJob{
+		OrganizationID:  organizationId,
+		CreatedBy:       actorId,
+		Format:          options.Format,
+		DryRun:          options.DryRun,
+		Upsert:          options.Upsert,
+		UpdatePasswords: options.UpdatePasswords,
+		Status:          repository.ImportStatusRunning,
+		Total:           len(rows),
+		Errors:          []repository.ImportRowError{},
+	})
+	return job, rows, nil
+}
+
+// StartImport parses the file and processes its rows in the background; poll GetImport for the outcome.
+func (t *ImportUsecaseImpl) StartImport(organizationId uint64, options repository.ImportOptions, data []byte, actorId uint64) (*repository.ImportJob, *helper.StandardError) {
+	job, rows, err := t.createJob(organizationId, options, data, actorId)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		// A panic here would take the whole server down; fail the job instead.
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				log.Printf("Failed to run import %d: %v", job.ID, recovered)
+				failed := t.ImportRepository.FindJobById(job.OrganizationID, job.ID)
+				completedAt := time.Now()
+				failed.Status = repository.ImportStatusFailed
+				failed.Error = "internal error"
+				failed.CompletedAt = &completedAt
+				t.ImportRepository.UpdateJob(failed)
+			}
+		}()
+		t.process(job, options.Subject, rows)
+	}()
+
+	return &job, nil
+}
+
+// RunImport is StartImport waiting for the job to complete, for the command line.
+func (t *ImportUsecaseImpl) RunImport(organizationId uint64, options repository.ImportOptions, data []byte, actorId uint64) (*repository.ImportJob, *helper.StandardError) {
+	job, rows, err := t.createJob(organizationId, options, data, actorId)
+	if err != nil {
+		return nil, err
+	}
+
+	job = t.process(job, options.Subject, rows)
+
+	return &job, nil
+}
+
+func (t *ImportUsecaseImpl) GetImport(organizationId uint64, jobId uint64) (*repository.ImportJob, *helper.StandardError) {
+	job := t.ImportRepository.FindJobById(organizationOrDefault(organizationId), jobId)
+	if job.ID == 0 {
+		return nil, errImportNotFound
+	}
+
+	return &job, nil
+}
+
+func (t *ImportUsecaseImpl) ListImports(organizationId uint64) (*[]repository.ImportJob, *helper.StandardError) {
+	jobs := t.ImportRepository.FindJobsByOrganizationId(organizationOrDefault(organizationId))
+	if jobs == nil {
+		jobs = []repository.ImportJob{}
+	}
+
+	return &jobs, nil
+}
+
+// FailInterruptedImports fails the jobs a previous run of the server left running. Call it at startup,
+// before any import can start.
+func (t *ImportUsecaseImpl) FailInterruptedImports() int64 {
+	return t.ImportRepository.FailRunningJobs("interrupted by a restart")
+}
+
+func NewImportUsecaseImpl(importRepository repository.ImportRepository, userRepository repository.UserRepository, attributeRepository repository.AttributeRepository, policyUsecase PolicyUsecase, auditUsecase AuditUsecase) ImportUsecase {
+	return &ImportUsecaseImpl{
+		ImportRepository:    importRepository,
+		UserRepository:      userRepository,
+		AttributeRepository: attributeRepository,
+		PolicyUsecase:       policyUsecase,
+		AuditUsecase:        auditUsecase,
+	}
+}
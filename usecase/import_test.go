@@ -0,0 +1,319 @@
+package usecase_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func importRepository() *mocks.ImportRepositoryMock {
+	importRepositoryMock := new(mocks.ImportRepositoryMock)
+	importRepositoryMock.On("SaveJob", mock.Anything).Return(func(job repository.ImportJob) repository.ImportJob {
+		job.ID = 1
+		return job
+	})
+	importRepositoryMock.On("UpdateJob", mock.Anything).Return(func(job repository.ImportJob) repository.ImportJob { return job })
+	return importRepositoryMock
+}
+
+func TestRunImport(t *testing.T) {
+	t.Run("test CSV import reports every failed row", func(t *testing.T) {
+		t.Setenv("IMPORT_BATCH_SIZE", "2")
+		passwordHash, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+		data := fmt.Sprintf(`username,email,password,password_hash,roles,attributes.department,attributes.employee_id,attributes.level
+alice,alice@example.com,secret,,"admin, auditor",eng,E0001,3
+bob,bob@example.com,,%s,,sales,E0002,
+alice,alice2@example.com,secret,,,eng,E0003,
+carol,not-an-email,secret,,,eng,E0004,
+dave,dave@example.com,secret,,,eng,E0001,
+erin,erin@example.com,secret,,,eng,,senior
+frank,frank@example.com,,,,eng,,
+grace,grace@example.com,secret,md5hash,,eng,,
+`, passwordHash)
+
+		importRepositoryMock := importRepository()
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		userRepositoryMock.On("FindByUsername").Return(repository.User{})
+		userRepositoryMock.On("FindAll").Return([]repository.User{})
+		importRepositoryMock.On("SaveUsers", mock.MatchedBy(func(users []repository.User) bool {
+			return len(users) == 2 && users[0].Username == "alice" && users[0].Roles == "admin auditor" && users[0].Attributes["level"] == float64(3) &&
+				bcrypt.CompareHashAndPassword([]byte(users[0].Password), []byte("secret")) == nil &&
+				users[1].Username == "bob" && users[1].Password == string(passwordHash) && users[1].OrganizationID == 2
+		})).Return(nil, nil)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		importUsecase := usecase.NewImportUsecaseImpl(importRepositoryMock, userRepositoryMock, attributeDefinitions(), nil, auditUsecaseMock)
+		job, err := importUsecase.RunImport(2, repository.ImportOptions{Format: repository.ImportFormatCSV}, []byte(data), 100)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, job.Status, repository.ImportStatusCompleted)
+		assert.Equal(t, job.Total, 8)
+		assert.Equal(t, job.Processed, 8)
+		assert.Equal(t, job.Created, 2)
+		assert.Equal(t, job.Failed, 6)
+		assert.Equal(t, job.Errors, []repository.ImportRowError{
+			{Row: 4, Username: "alice", Error: "duplicate username in import"},
+			{Row: 5, Username: "carol", Error: "invalid email"},
+			{Row: 6, Username: "dave", Error: "attribute employee_id is already used by another user"},
+			{Row: 7, Username: "erin", Error: "attribute level must be a number"},
+			{Row: 8, Username: "frank", Error: "password or password_hash is required"},
+			{Row: 9, Username: "grace", Error: "set either password or password_hash, not both"},
+		})
+		importRepositoryMock.AssertNumberOfCalls(t, "SaveUsers", 1)
+		auditUsecaseMock.AssertNumberOfCalls(t, "Record", 1)
+	})
+
+	t.Run("test upsert and dry run", func(t *testing.T) {
+		data := []byte(`{"username":"username","email":"new@mail.com","roles":"admin"}` + "\n\n" + `{"username":"other","email":"other@mail.com","unknown":true}` + "\n")
+
+		importRepositoryMock := importRepository()
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		userRepositoryMock.On("FindByUsername").Return(mockUser)
+		importRepositoryMock.On("SaveUsers", mock.MatchedBy(func(users []repository.User) bool {
+			return len(users) == 1 && users[0].ID == 100 && users[0].Email == "new@mail.com" && users[0].Password == "" && users[0].Roles == "admin"
+		})).Return(nil, nil)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		importUsecase := usecase.NewImportUsecaseImpl(importRepositoryMock, userRepositoryMock, noAttributeDefinitions(), nil, auditUsecaseMock)
+
+		job, err := importUsecase.RunImport(0, repository.ImportOptions{Format: repository.ImportFormatNDJSON}, data, 100)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, job.OrganizationID, repository.DefaultOrganizationID)
+		assert.Equal(t, job.Failed, 2)
+		assert.Equal(t, job.Errors[0], repository.ImportRowError{Row: 1, Username: "username", Error: "user already exist"})
+		assert.Equal(t, job.Errors[1].Row, 3)
+
+		job, err = importUsecase.RunImport(0, repository.ImportOptions{Format: repository.ImportFormatNDJSON, Upsert: true, DryRun: true}, data, 100)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, job.Updated, 1)
+		importRepositoryMock.AssertNotCalled(t, "SaveUsers", mock.Anything)
+
+		job, err = importUsecase.RunImport(0, repository.ImportOptions{Format: repository.ImportFormatNDJSON, Upsert: true}, data, 100)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, job.Updated, 1)
+		importRepositoryMock.AssertNumberOfCalls(t, "SaveUsers", 1)
+		auditUsecaseMock.AssertNumberOfCalls(t, "Record", 2)
+	})
+
+	t.Run("test upsert over HTTP checks the policy, passwords and roles", func(t *testing.T) {
+		data := []byte(`{"username":"alice","email":"alice@mail.com","password":"secret"}` + "\n" +
+			`{"username":"bob","email":"bob@mail.com","roles":"admin"}` + "\n" +
+			`{"username":"carol","email":"carol@mail.com"}` + "\n" +
+			`{"username":"dave","email":"dave@mail.com","roles":"member"}` + "\n")
+		subject := map[string]interface{}{"id": uint64(1)}
+
+		importRepositoryMock := importRepository()
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		policyUsecaseMock := new(mocks.PolicyUsecaseMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		for i, username := range []string{"alice", "bob", "carol", "dave"} {
+			userRepositoryMock.On("FindByUsername").Return(repository.User{ID: uint64(100 + i), Username: username, Password: "hash", Roles: "member"}).Once()
+		}
+		policyUsecaseMock.On("Enforce", uint64(1), subject, "users:update", map[string]interface{}{"type": "user", "id": uint64(102)}).
+			Return(&helper.StandardError{Error: errors.New("access denied by policy"), ErrorCode: http.StatusForbidden})
+		policyUsecaseMock.On("Enforce", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return((*helper.StandardError)(nil))
+		importRepositoryMock.On("SaveUsers", mock.MatchedBy(func(users []repository.User) bool {
+			return len(users) == 1 && users[0].ID == 103 && users[0].Password == "" && users[0].Roles == "member"
+		})).Return(nil, nil)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		importUsecase := usecase.NewImportUsecaseImpl(importRepositoryMock, userRepositoryMock, noAttributeDefinitions(), policyUsecaseMock, auditUsecaseMock)
+		job, err := importUsecase.RunImport(1, repository.ImportOptions{Format: repository.ImportFormatNDJSON, Upsert: true, Subject: subject}, data, 1)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, job.Updated, 1)
+		assert.Equal(t, job.Errors, []repository.ImportRowError{
+			{Row: 1, Username: "alice", Error: "passwords of existing users are only replaced with update_passwords"},
+			{Row: 2, Username: "bob", Error: "not allowed to assign roles"},
+			{Row: 3, Username: "carol", Error: "access denied by policy"},
+		})
+		policyUsecaseMock.AssertCalled(t, "Enforce", uint64(1), subject, "users:update", map[string]interface{}{"type": "user", "organization_id": uint64(1)})
+	})
+
+	t.Run("test failed batch", func(t *testing.T) {
+		importRepositoryMock := importRepository()
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		userRepositoryMock.On("FindByUsername").Return(repository.User{})
+		importRepositoryMock.On("SaveUsers", mock.Anything).Return(nil, errors.New("database is locked"))
+		auditUsecaseMock.On("Record").Return(nil)
+
+		importUsecase := usecase.NewImportUsecaseImpl(importRepositoryMock, userRepositoryMock, noAttributeDefinitions(), nil, auditUsecaseMock)
+		job, err := importUsecase.RunImport(1, repository.ImportOptions{Format: repository.ImportFormatCSV}, []byte("username,email,password_hash\nalice,alice@example.com,$1$saltsalt$NuzA7WTAelpl95xgBGWN60\n"), 100)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, job.Created, 0)
+		assert.Equal(t, job.Errors, []repository.ImportRowError{{Row: 2, Username: "alice", Error: "failed to save batch: database is locked"}})
+	})
+
+	t.Run("test invalid row does not claim its username", func(t *testing.T) {
+		importRepositoryMock := importRepository()
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		userRepositoryMock.On("FindByUsername").Return(repository.User{})
+		importRepositoryMock.On("SaveUsers", mock.MatchedBy(func(users []repository.User) bool {
+			return len(users) == 1 && users[0].Email == "alice@example.com"
+		})).Return(nil, nil)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		importUsecase := usecase.NewImportUsecaseImpl(importRepositoryMock, userRepositoryMock, noAttributeDefinitions(), nil, auditUsecaseMock)
+		job, err := importUsecase.RunImport(1, repository.ImportOptions{Format: repository.ImportFormatCSV}, []byte("username,email,password\nalice,not-an-email,secret\nalice,alice@example.com,secret\n"), 100)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, job.Created, 1)
+		assert.Equal(t, job.Errors, []repository.ImportRowError{{Row: 2, Username: "alice", Error: "invalid email"}})
+	})
+
+	t.Run("test user deleted during the import", func(t *testing.T) {
+		importRepositoryMock := importRepository()
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		userRepositoryMock.On("FindByUsername").Return(mockUser)
+		importRepositoryMock.On("SaveUsers", mock.Anything).Return([]bool{false}, nil)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		importUsecase := usecase.NewImportUsecaseImpl(importRepositoryMock, userRepositoryMock, noAttributeDefinitions(), nil, auditUsecaseMock)
+		job, err := importUsecase.RunImport(1, repository.ImportOptions{Format: repository.ImportFormatNDJSON, Upsert: true}, []byte(`{"username":"username","email":"new@mail.com"}`), 100)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, job.Updated, 0)
+		assert.Equal(t, job.Errors, []repository.ImportRowError{{Row: 1, Username: "username", Error: "user was deleted or moved during the import"}})
+	})
+
+	t.Run("test password hashes above realistic costs", func(t *testing.T) {
+		importRepositoryMock := importRepository()
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		userRepositoryMock.On("FindByUsername").Return(repository.User{})
+		auditUsecaseMock.On("Record").Return(nil)
+
+		data := `username,email,password_hash
+alice,alice@example.com,$2a$31$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy
+bob,bob@example.com,pbkdf2_sha256$999999999$seasalt$mQnueSakb748zqBAC1tmWVZsZbi2zPGZarEzTGdfmso=
+carol,carol@example.com,scrypt$seasalt$1073741824$8$1$b9vqOe0B5HZh2f10Q9RPN9OFNPtmb+ezp5YOlnZsJQ0vQ5I3eq8Zt7GFMuqQ58xCxTIvVL1QCL/PTXEWr+RNHA==
+dave,dave@example.com,scrypt$seasalt$1024$8$1024$b9vqOe0B5HZh2f10Q9RPN9OFNPtmb+ezp5YOlnZsJQ0vQ5I3eq8Zt7GFMuqQ58xCxTIvVL1QCL/PTXEWr+RNHA==
+erin,erin@example.com,$6$rounds=999999999$short$0e8c4VH2.9WECWJ4hEU7wwl83rldSLVuQftBmF/xkj96Wh4.yNfDVKXLBTXD0R1YGFzxp1NCeSFbNup.13awx1
+frank,frank@example.com,$P$SIQRaTwmfeRo7ud9Fh4E2PdI0S3r.L0
+`
+		importUsecase := usecase.NewImportUsecaseImpl(importRepositoryMock, userRepositoryMock, noAttributeDefinitions(), nil, auditUsecaseMock)
+		job, err := importUsecase.RunImport(1, repository.ImportOptions{Format: repository.ImportFormatCSV}, []byte(data), 100)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, job.Created, 0)
+		assert.Equal(t, job.Failed, 6)
+		for _, rowError := range job.Errors {
+			assert.Equal(t, rowError.Error, "unsupported password_hash")
+		}
+		importRepositoryMock.AssertNotCalled(t, "SaveUsers", mock.Anything)
+	})
+
+	t.Run("test invalid files", func(t *testing.T) {
+		importUsecase := usecase.NewImportUsecaseImpl(nil, nil, noAttributeDefinitions(), nil, nil)
+
+		_, err := importUsecase.RunImport(1, repository.ImportOptions{Format: repository.ImportFormatCSV}, []byte("username,email,admin\n"), 100)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("unknown column admin"), ErrorCode: http.StatusBadRequest})
+
+		_, err = importUsecase.RunImport(1, repository.ImportOptions{Format: repository.ImportFormatCSV}, []byte("username,email\n"), 100)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("import file contains no users"), ErrorCode: http.StatusBadRequest})
+
+		_, err = importUsecase.RunImport(1, repository.ImportOptions{}, []byte("username,email\n"), 100)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("format must be csv or ndjson"), ErrorCode: http.StatusBadRequest})
+
+		t.Setenv("IMPORT_MAX_BYTES", "10")
+		_, err = importUsecase.RunImport(1, repository.ImportOptions{Format: repository.ImportFormatCSV}, []byte("username,email\n"), 100)
+		assert.Equal(t, int(err.ErrorCode), http.StatusRequestEntityTooLarge)
+	})
+}
+
+func TestStartImport(t *testing.T) {
+	completed := make(chan repository.ImportJob, 1)
+	importRepositoryMock := new(mocks.ImportRepositoryMock)
+	userRepositoryMock := new(mocks.UserRepositoryMock)
+	auditUsecaseMock := new(mocks.AuditUsecaseMock)
+	importRepositoryMock.On("SaveJob", mock.Anything).Return(func(job repository.ImportJob) repository.ImportJob {
+		job.ID = 1
+		return job
+	})
+	importRepositoryMock.On("UpdateJob", mock.Anything).Return(func(job repository.ImportJob) repository.ImportJob {
+		if job.Status == repository.ImportStatusCompleted {
+			completed <- job
+		}
+		return job
+	})
+	importRepositoryMock.On("SaveUsers", mock.Anything).Return(nil, nil)
+	userRepositoryMock.On("FindByUsername").Return(repository.User{})
+	auditUsecaseMock.On("Record").Return(nil)
+
+	importUsecase := usecase.NewImportUsecaseImpl(importRepositoryMock, userRepositoryMock, noAttributeDefinitions(), nil, auditUsecaseMock)
+	job, err := importUsecase.StartImport(1, repository.ImportOptions{Format: repository.ImportFormatNDJSON}, []byte(`{"username":"alice","email":"alice@example.com","password":"secret"}`), 100)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, job.ID, uint64(1))
+	assert.Equal(t, job.Status, repository.ImportStatusRunning)
+	assert.Equal(t, job.Total, 1)
+
+	finished := <-completed
+	assert.Equal(t, finished.Created, 1)
+}
+
+func TestStartImportFailsOnPanic(t *testing.T) {
+	failed := make(chan repository.ImportJob, 1)
+	importRepositoryMock := new(mocks.ImportRepositoryMock)
+	userRepositoryMock := new(mocks.UserRepositoryMock)
+	auditUsecaseMock := new(mocks.AuditUsecaseMock)
+	importRepositoryMock.On("SaveJob", mock.Anything).Return(func(job repository.ImportJob) repository.ImportJob {
+		job.ID = 1
+		return job
+	})
+	importRepositoryMock.On("UpdateJob", mock.Anything).Return(func(job repository.ImportJob) repository.ImportJob {
+		if job.Status == repository.ImportStatusFailed {
+			failed <- job
+		}
+		return job
+	})
+	importRepositoryMock.On("FindJobById", uint64(1), uint64(1)).Return(repository.ImportJob{ID: 1, OrganizationID: 1, Status: repository.ImportStatusRunning})
+	importRepositoryMock.On("SaveUsers", mock.Anything).Run(func(mock.Arguments) { panic("database gone") })
+	userRepositoryMock.On("FindByUsername").Return(repository.User{})
+	auditUsecaseMock.On("Record").Return(nil)
+
+	importUsecase := usecase.NewImportUsecaseImpl(importRepositoryMock, userRepositoryMock, noAttributeDefinitions(), nil, auditUsecaseMock)
+	_, err := importUsecase.StartImport(1, repository.ImportOptions{Format: repository.ImportFormatNDJSON}, []byte(`{"username":"alice","email":"alice@example.com","password":"secret"}`), 100)
+	assert.Equal(t, err, nil)
+
+	job := <-failed
+	assert.Equal(t, job.Error, "internal error")
+	assert.NotEqual(t, job.CompletedAt, nil)
+}
+
+func TestFailInterruptedImports(t *testing.T) {
+	importRepositoryMock := new(mocks.ImportRepositoryMock)
+	importRepositoryMock.On("FailRunningJobs", "interrupted by a restart").Return(int64(2))
+	importUsecase := usecase.NewImportUsecaseImpl(importRepositoryMock, nil, nil, nil, nil)
+
+	assert.Equal(t, importUsecase.FailInterruptedImports(), int64(2))
+}
+
+func TestGetImport(t *testing.T) {
+	importRepositoryMock := new(mocks.ImportRepositoryMock)
+	importRepositoryMock.On("FindJobById", uint64(2), uint64(1)).Return(repository.ImportJob{ID: 1, OrganizationID: 2})
+	importRepositoryMock.On("FindJobById", uint64(2), uint64(3)).Return(repository.ImportJob{})
+	importUsecase := usecase.NewImportUsecaseImpl(importRepositoryMock, nil, nil, nil, nil)
+
+	job, err := importUsecase.GetImport(2, 1)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, job.ID, uint64(1))
+
+	_, err = importUsecase.GetImport(2, 3)
+	assert.Equal(t, err, helper.StandardError{Error: errors.New("import not found"), ErrorCode: http.StatusNotFound})
+}
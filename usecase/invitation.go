@@ -0,0 +1,218 @@
+package usecase
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	defaultInvitationExpiry = 72 * time.Hour
+	invitationTokenPrefix   = "gum_inv_"
+)
+
+var (
+	errInvitationNotFound = &helper.StandardError{Error: errors.New("invitation not found"), ErrorCode: http.StatusNotFound}
+	errInvitationNotSent  = &helper.StandardError{Error: errors.New("failed to send invitation"), ErrorCode: http.StatusBadGateway}
+)
+
+type InvitationUsecase interface {
+	CreateInvitation(organizationId uint64, invitationData repository.CreateInvitation, actorId uint64) (*repository.PendingInvitation, *helper.StandardError)
+	ListInvitations(organizationId uint64) (*[]repository.PendingInvitation, *helper.StandardError)
+	ResendInvitation(organizationId uint64, invitationId uint64, actorId uint64) (*repository.PendingInvitation, *helper.StandardError)
+	RevokeInvitation(organizationId uint64, invitationId uint64, actorId uint64) (*repository.PendingInvitation, *helper.StandardError)
+	AcceptInvitation(acceptData repository.AcceptInvitation) (*repository.UserResponse, *helper.StandardError)
+}
+
+type InvitationUsecaseImpl struct {
+	InvitationRepository repository.InvitationRepository
+	UserRepository       repository.UserRepository
+	AttributeRepository  repository.AttributeRepository
+	Mailer               Mailer
+	AuditUsecase         AuditUsecase
+}
+
+func invitationExpiry() time.Duration {
+	return sessionDuration("INVITATION_EXPIRY", defaultInvitationExpiry)
+}
+
+// invitationURL is the page that lets invitees choose a password; it posts the token to the accept endpoint.
+func invitationURL(token string) string {
+	return envOrDefault("INVITATION_URL", oidcIssuer()+"/invitations/accept") + "?token=" + url.QueryEscape(token)
+}
+
+func (t *InvitationUsecaseImpl) sendInvitation(user repository.User, token string) error {
+	return t.Mailer.Send(MailMessage{
+		To:      user.Email,
+		Subject: "You have been invited",
+		Body: fmt.Sprintf("An account with the username %s has been created for you. Choose your password to activate it:\n\n%s\n\nThe invitation expires in %d hours and can only be used once. If you did not expect it, ignore this email.\n",
+			user.Username, invitationURL(token), int(invitationExpiry().Hours())),
+	})
+}
+
+// CreateInvitation creates the user as pending and emails the invitee a link to choose a password.
+// Pending users are disabled, so no sign-in method accepts them before the invitation is accepted.
+func (t *InvitationUsecaseImpl) CreateInvitation(organizationId uint64, invitationData repository.CreateInvitation, actorId uint64) (*repository.PendingInvitation, *helper.StandardError) {
+	userRepository := t.UserRepository.ForOrganization(organizationId)
+	if userRepository.FindByUsername(invitationData.Username).ID != 0 {
+		return nil, &helper.StandardError{Error: errors.New("user already exist"), ErrorCode: http.StatusBadRequest}
+	}
+
+	if err := validateUserAttributes(t.AttributeRepository, userRepository, organizationId, 0, invitationData.Attributes); err != nil {
+		return nil, err
+	}
+
+	token, err := generateRandomToken(invitationTokenPrefix)
+	if err != nil {
+		return nil, &helper.StandardError{Error: errors.New("failed to generate invitation"), ErrorCode: http.StatusInternalServerError}
+	}
+
+	now := time.Now()
+	user := userRepository.Save(repository.User{
+		Username:   invitationData.Username,
+		Email:      strings.TrimSpace(invitationData.Email),
+		DisabledAt: &now,
+		Attributes: invitationData.Attributes,
+	})
+	if user.ID == 0 {
+		return nil, &helper.StandardError{Error: errors.New("failed to create user"), ErrorCode: http.StatusInternalServerError}
+	}
+
+	invitation := t.InvitationRepository.Save(repository.Invitation{
+		TokenHash:      hashToken(token),
+		OrganizationID: user.OrganizationID,
+		UserID:         user.ID,
+		InvitedBy:      actorId,
+		ExpiresAt:      now.Add(invitationExpiry()),
+	})
+
+	if err := t.sendInvitation(user, token); err != nil {
+		log.Printf("Failed to send invitation: %v", err)
+		t.InvitationRepository.Delete(invitation.ID)
+		userRepository.Delete(user.ID)
+		return nil, errInvitationNotSent
+	}
+
+	t.AuditUsecase.Record("invitation.create", actorId, user.ID, fmt.Sprintf("invitation_id=%d", invitation.ID))
+
+	return &repository.PendingInvitation{Invitation: invitation, Username: user.Username, Email: user.Email}, nil
+}
+
+func (t *InvitationUsecaseImpl) ListInvitations(organizationId uint64) (*[]repository.PendingInvitation, *helper.StandardError) {
+	invitations := t.InvitationRepository.FindByOrganizationId(organizationId)
+	if invitations == nil {
+		invitations = []repository.PendingInvitation{}
+	}
+	return &invitations, nil
+}
+
+// ResendInvitation emails a new link with a fresh expiry; links sent before stop working.
+func (t *InvitationUsecaseImpl) ResendInvitation(organizationId uint64, invitationId uint64, actorId uint64) (*repository.PendingInvitation, *helper.StandardError) {
+	invitation := t.InvitationRepository.FindById(organizationId, invitationId)
+	if invitation.ID == 0 {
+		return nil, errInvitationNotFound
+	}
+
+	user := t.UserRepository.ForOrganization(organizationId).FindById(invitation.UserID)
+	if user.ID == 0 {
+		return nil, errInvitationNotFound
+	}
+
+	token, err := generateRandomToken(invitationTokenPrefix)
+	if err != nil {
+		return nil, &helper.StandardError{Error: errors.New("failed to generate invitation"), ErrorCode: http.StatusInternalServerError}
+	}
+
+	invitation.TokenHash = hashToken(token)
+	invitation.ExpiresAt = time.Now().Add(invitationExpiry())
+	invitation = t.InvitationRepository.Update(invitation)
+
+	if err := t.sendInvitation(user, token); err != nil {
+		log.Printf("Failed to send invitation: %v", err)
+		return nil, errInvitationNotSent
+	}
+
+	t.AuditUsecase.Record("invitation.resend", actorId, user.ID, fmt.Sprintf("invitation_id=%d", invitation.ID))
+
+	return &repository.PendingInvitation{Invitation: invitation, Username: user.Username, Email: user.Email}, nil
+}
+
+// RevokeInvitation also deletes the pending user, which never had a password and cannot have signed in.
+func (t *InvitationUsecaseImpl) RevokeInvitation(organizationId uint64, invitationId uint64, actorId uint64) (*repository.PendingInvitation, *helper.StandardError) {
+	invitation := t.InvitationRepository.FindById(organizationId, invitationId)
+	if invitation.ID == 0 || !t.InvitationRepository.Delete(invitation.ID) {
+		return nil, errInvitationNotFound
+	}
+
+	userRepository := t.UserRepository.ForOrganization(organizationId)
+	user := userRepository.FindById(invitation.UserID)
+	if user.ID != 0 {
+		userRepository.Delete(user.ID)
+	}
+
+	t.AuditUsecase.Record("invitation.revoke", actorId, invitation.UserID, fmt.Sprintf("invitation_id=%d", invitation.ID))
+
+	return &repository.PendingInvitation{Invitation: invitation, Username: user.Username, Email: user.Email}, nil
+}
+
+// AcceptInvitation sets the invitee's password and activates the user. Receiving the invitation proves
+// control of the email address, so it is marked verified.
+func (t *InvitationUsecaseImpl) AcceptInvitation(acceptData repository.AcceptInvitation) (*repository.UserResponse, *helper.StandardError) {
+	invalidInvitation := &helper.StandardError{Error: errors.New("invalid or expired invitation"), ErrorCode: http.StatusBadRequest}
+
+	invitation := t.InvitationRepository.FindByTokenHash(hashToken(acceptData.Token))
+	if invitation.ID == 0 || time.Now().After(invitation.ExpiresAt) {
+		return nil, invalidInvitation
+	}
+
+	userRepository := t.UserRepository.ForOrganization(invitation.OrganizationID)
+	user := userRepository.FindById(invitation.UserID)
+	if user.ID == 0 {
+		return nil, invalidInvitation
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(acceptData.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, &helper.StandardError{Error: err, ErrorCode: http.StatusInternalServerError}
+	}
+
+	user.Password = string(passwordHash)
+	user.EmailVerified = true
+	user.DisabledAt = nil
+	if err := t.InvitationRepository.Accept(invitation.ID, user); err != nil {
+		if errors.Is(err, repository.ErrInvitationNotFound) {
+			return nil, invalidInvitation
+		}
+		log.Printf("Failed to accept invitation %d: %v", invitation.ID, err)
+		return nil, &helper.StandardError{Error: errors.New("failed to accept invitation"), ErrorCode: http.StatusInternalServerError}
+	}
+	t.AuditUsecase.Record("invitation.accept", user.ID, user.ID, fmt.Sprintf("invitation_id=%d", invitation.ID))
+
+	return &repository.UserResponse{
+		ID:             user.ID,
+		OrganizationID: user.OrganizationID,
+		Username:       user.Username,
+		Email:          user.Email,
+		Attributes:     user.Attributes,
+		AvatarURL:      user.AvatarURL,
+		CreatedAt:      user.CreatedAt,
+	}, nil
+}
+
+func NewInvitationUsecaseImpl(invitationRepository repository.InvitationRepository, userRepository repository.UserRepository, attributeRepository repository.AttributeRepository, mailer Mailer, auditUsecase AuditUsecase) InvitationUsecase {
+	return &InvitationUsecaseImpl{
+		InvitationRepository: invitationRepository,
+		UserRepository:       userRepository,
+		AttributeRepository:  attributeRepository,
+		Mailer:               mailer,
+		AuditUsecase:         auditUsecase,
+	}
+}
@@ -0,0 +1,182 @@
+package usecase_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const invitationPattern = `https://app.example.com/invite\?token=gum_inv_\S+`
+
+func pendingUser() repository.User {
+	invitedAt := time.Now()
+	return repository.User{ID: 101, OrganizationID: 2, Username: "invitee", Email: "invitee@example.com", DisabledAt: &invitedAt}
+}
+
+func TestCreateInvitation(t *testing.T) {
+	t.Setenv("INVITATION_URL", "https://app.example.com/invite")
+
+	t.Run("test create pending user and send invitation", func(t *testing.T) {
+		invitationRepositoryMock := new(mocks.InvitationRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		outbox := &usecase.OutboxMailer{}
+		userRepositoryMock.On("FindByUsername").Return(repository.User{})
+		userRepositoryMock.On("Save").Return(pendingUser())
+		invitationRepositoryMock.On("Save", mock.MatchedBy(func(invitation repository.Invitation) bool {
+			return invitation.UserID == 101 && invitation.OrganizationID == 2 && invitation.InvitedBy == 100 && invitation.TokenHash != "" &&
+				invitation.ExpiresAt.After(time.Now().Add(71*time.Hour))
+		})).Return(repository.Invitation{ID: 1, OrganizationID: 2, UserID: 101, InvitedBy: 100})
+		auditUsecaseMock.On("Record").Return(nil)
+
+		invitationUsecase := usecase.NewInvitationUsecaseImpl(invitationRepositoryMock, userRepositoryMock, noAttributeDefinitions(), outbox, auditUsecaseMock)
+		invitation, err := invitationUsecase.CreateInvitation(2, repository.CreateInvitation{Username: "invitee", Email: "invitee@example.com"}, 100)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, invitation.ID, uint64(1))
+		assert.Equal(t, invitation.Username, "invitee")
+		assert.Equal(t, userRepositoryMock.OrganizationID, uint64(2))
+		assert.Equal(t, len(outbox.Messages()), 1)
+		assert.Equal(t, outbox.Messages()[0].To, "invitee@example.com")
+		assert.MatchRegex(t, outbox.Messages()[0].Body, invitationPattern)
+	})
+
+	t.Run("username taken", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		userRepositoryMock.On("FindByUsername").Return(mockUser)
+
+		invitationUsecase := usecase.NewInvitationUsecaseImpl(nil, userRepositoryMock, nil, &usecase.OutboxMailer{}, nil)
+		invitation, err := invitationUsecase.CreateInvitation(2, repository.CreateInvitation{Username: "username", Email: "test@mail.com"}, 100)
+
+		assert.Equal(t, invitation, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("user already exist"), ErrorCode: http.StatusBadRequest})
+	})
+}
+
+func TestResendInvitation(t *testing.T) {
+	t.Setenv("INVITATION_URL", "https://app.example.com/invite")
+
+	t.Run("test send a new link", func(t *testing.T) {
+		invitationRepositoryMock := new(mocks.InvitationRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		outbox := &usecase.OutboxMailer{}
+		invitationRepositoryMock.On("FindById", uint64(2), uint64(1)).Return(repository.Invitation{ID: 1, OrganizationID: 2, UserID: 101, TokenHash: "previous", ExpiresAt: time.Now().Add(-time.Hour)})
+		invitationRepositoryMock.On("Update", mock.MatchedBy(func(invitation repository.Invitation) bool {
+			return invitation.TokenHash != "previous" && invitation.ExpiresAt.After(time.Now())
+		})).Return(repository.Invitation{ID: 1, OrganizationID: 2, UserID: 101})
+		userRepositoryMock.On("FindById").Return(pendingUser())
+		auditUsecaseMock.On("Record").Return(nil)
+
+		invitationUsecase := usecase.NewInvitationUsecaseImpl(invitationRepositoryMock, userRepositoryMock, nil, outbox, auditUsecaseMock)
+		invitation, err := invitationUsecase.ResendInvitation(2, 1, 100)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, invitation.Email, "invitee@example.com")
+		assert.Equal(t, userRepositoryMock.OrganizationID, uint64(2))
+		assert.Equal(t, len(outbox.Messages()), 1)
+		assert.MatchRegex(t, outbox.Messages()[0].Body, invitationPattern)
+	})
+
+	t.Run("invitation of another organization", func(t *testing.T) {
+		invitationRepositoryMock := new(mocks.InvitationRepositoryMock)
+		invitationRepositoryMock.On("FindById", uint64(3), uint64(1)).Return(repository.Invitation{})
+
+		invitationUsecase := usecase.NewInvitationUsecaseImpl(invitationRepositoryMock, nil, nil, &usecase.OutboxMailer{}, nil)
+		invitation, err := invitationUsecase.ResendInvitation(3, 1, 100)
+
+		assert.Equal(t, invitation, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("invitation not found"), ErrorCode: http.StatusNotFound})
+	})
+}
+
+func TestRevokeInvitation(t *testing.T) {
+	invitationRepositoryMock := new(mocks.InvitationRepositoryMock)
+	userRepositoryMock := new(mocks.UserRepositoryMock)
+	auditUsecaseMock := new(mocks.AuditUsecaseMock)
+	invitationRepositoryMock.On("FindById", uint64(2), uint64(1)).Return(repository.Invitation{ID: 1, OrganizationID: 2, UserID: 101})
+	invitationRepositoryMock.On("Delete", uint64(1)).Return(true)
+	userRepositoryMock.On("FindById").Return(pendingUser())
+	userRepositoryMock.On("Delete").Return(pendingUser())
+	auditUsecaseMock.On("Record").Return(nil)
+
+	invitationUsecase := usecase.NewInvitationUsecaseImpl(invitationRepositoryMock, userRepositoryMock, nil, nil, auditUsecaseMock)
+	invitation, err := invitationUsecase.RevokeInvitation(2, 1, 100)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, invitation.Username, "invitee")
+	assert.Equal(t, userRepositoryMock.OrganizationID, uint64(2))
+	userRepositoryMock.AssertCalled(t, "Delete")
+}
+
+func TestAcceptInvitation(t *testing.T) {
+	t.Run("test set password and activate user", func(t *testing.T) {
+		invitationRepositoryMock := new(mocks.InvitationRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		invitationRepositoryMock.On("FindByTokenHash", mock.Anything).Return(repository.Invitation{ID: 1, OrganizationID: 2, UserID: 101, ExpiresAt: time.Now().Add(time.Hour)})
+		userRepositoryMock.On("FindById").Return(pendingUser())
+		invitationRepositoryMock.On("Accept", uint64(1), mock.MatchedBy(func(user repository.User) bool {
+			return user.DisabledAt == nil && user.EmailVerified && bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("chosen password")) == nil
+		})).Return(nil)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		invitationUsecase := usecase.NewInvitationUsecaseImpl(invitationRepositoryMock, userRepositoryMock, nil, nil, auditUsecaseMock)
+		user, err := invitationUsecase.AcceptInvitation(repository.AcceptInvitation{Token: "gum_inv_token", Password: "chosen password"})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, user.Username, "invitee")
+		assert.Equal(t, userRepositoryMock.OrganizationID, uint64(2))
+	})
+
+	t.Run("invitation used concurrently", func(t *testing.T) {
+		invitationRepositoryMock := new(mocks.InvitationRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		invitationRepositoryMock.On("FindByTokenHash", mock.Anything).Return(repository.Invitation{ID: 1, OrganizationID: 2, UserID: 101, ExpiresAt: time.Now().Add(time.Hour)})
+		invitationRepositoryMock.On("Accept", uint64(1), mock.Anything).Return(repository.ErrInvitationNotFound)
+		userRepositoryMock.On("FindById").Return(pendingUser())
+
+		invitationUsecase := usecase.NewInvitationUsecaseImpl(invitationRepositoryMock, userRepositoryMock, nil, nil, auditUsecaseMock)
+		user, err := invitationUsecase.AcceptInvitation(repository.AcceptInvitation{Token: "gum_inv_token", Password: "chosen password"})
+
+		assert.Equal(t, user, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("invalid or expired invitation"), ErrorCode: http.StatusBadRequest})
+		auditUsecaseMock.AssertNotCalled(t, "Record")
+	})
+
+	t.Run("failed to save user", func(t *testing.T) {
+		invitationRepositoryMock := new(mocks.InvitationRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		invitationRepositoryMock.On("FindByTokenHash", mock.Anything).Return(repository.Invitation{ID: 1, OrganizationID: 2, UserID: 101, ExpiresAt: time.Now().Add(time.Hour)})
+		invitationRepositoryMock.On("Accept", uint64(1), mock.Anything).Return(errors.New("database is locked"))
+		userRepositoryMock.On("FindById").Return(pendingUser())
+
+		invitationUsecase := usecase.NewInvitationUsecaseImpl(invitationRepositoryMock, userRepositoryMock, nil, nil, nil)
+		user, err := invitationUsecase.AcceptInvitation(repository.AcceptInvitation{Token: "gum_inv_token", Password: "chosen password"})
+
+		assert.Equal(t, user, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("failed to accept invitation"), ErrorCode: http.StatusInternalServerError})
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		invitationRepositoryMock := new(mocks.InvitationRepositoryMock)
+		invitationRepositoryMock.On("FindByTokenHash", mock.Anything).Return(repository.Invitation{ID: 1, UserID: 101, ExpiresAt: time.Now().Add(-time.Minute)})
+
+		invitationUsecase := usecase.NewInvitationUsecaseImpl(invitationRepositoryMock, nil, nil, nil, nil)
+		user, err := invitationUsecase.AcceptInvitation(repository.AcceptInvitation{Token: "gum_inv_token", Password: "chosen password"})
+
+		assert.Equal(t, user, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("invalid or expired invitation"), ErrorCode: http.StatusBadRequest})
+		invitationRepositoryMock.AssertNotCalled(t, "Accept", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,233 @@
+package usecase
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+const (
+	ldapProvider = "ldap"
+	ldapTimeout  = 10 * time.Second
+)
+
+var errLDAPFailed = &helper.StandardError{Error: errors.New("ldap server request failed"), ErrorCode: http.StatusBadGateway}
+
+type LDAPAuthenticator struct {
+	UserRepository       repository.UserRepository
+	FederationRepository repository.FederationRepository
+	AuditUsecase         AuditUsecase
+}
+
+type ldapConfig struct {
+	URL               string
+	StartTLS          bool
+	CAFile            string
+	BindDN            string
+	BindPassword      string
+	BaseDN            string
+	UserFilter        string
+	UsernameAttribute string
+	EmailAttribute    string
+	GroupAttribute    string
+	GroupRoles        map[string]string
+}
+
+func envOrDefault(name string, defaultValue string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func loadLDAPConfig() (ldapConfig, error) {
+	config := ldapConfig{
+		URL:               os.Getenv("LDAP_URL"),
+		StartTLS:          os.Getenv("LDAP_START_TLS") == "true",
+		CAFile:            os.Getenv("LDAP_CA_FILE"),
+		BindDN:            os.Getenv("LDAP_BIND_DN"),
+		BindPassword:      os.Getenv("LDAP_BIND_PASSWORD"),
+		BaseDN:            os.Getenv("LDAP_BASE_DN"),
+		UserFilter:        envOrDefault("LDAP_USER_FILTER", "(uid=%s)"),
+		UsernameAttribute: envOrDefault("LDAP_USERNAME_ATTRIBUTE", "uid"),
+		EmailAttribute:    envOrDefault("LDAP_EMAIL_ATTRIBUTE", "mail"),
+		GroupAttribute:    envOrDefault("LDAP_GROUP_ATTRIBUTE", "memberOf"),
+	}
+
+	if config.URL == "" || config.BaseDN == "" {
+		return config, errors.New("LDAP_URL and LDAP_BASE_DN are required")
+	}
+
+	if encoded := os.Getenv("LDAP_GROUP_ROLES"); encoded != "" {
+		if err := json.Unmarshal([]byte(encoded), &config.GroupRoles); err != nil {
+			return config, err
+		}
+	}
+
+	return config, nil
+}
+
+func (config ldapConfig) dial() (*ldap.Conn, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if config.CAFile != "" {
+		pem, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificates found in LDAP_CA_FILE")
+		}
+	}
+
+	conn, err := ldap.DialURL(config.URL, ldap.DialWithDialer(&net.Dialer{Timeout: ldapTimeout}), ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(ldapTimeout)
+
+	if config.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return conn, nil
+}
+
+func (config ldapConfig) roles(groups []string) string {
+	roleSet := map[string]bool{}
+	for _, group := range groups {
+		for groupDN, role := range config.GroupRoles {
+			if strings.EqualFold(group, groupDN) {
+				roleSet[role] = true
+			}
+		}
+	}
+
+	roles := make([]string, 0, len(roleSet))
+	for role := range roleSet {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return strings.Join(roles, " ")
+}
+
+func (t *LDAPAuthenticator) Authenticate(loginData repository.Login) (*repository.User, *helper.StandardError) {
+	config, err := loadLDAPConfig()
+	if err != nil {
+		return nil, &helper.StandardError{Error: errors.New("invalid ldap configuration"), ErrorCode: http.StatusInternalServerError}
+	}
+
+	conn, err := config.dial()
+	if err != nil {
+		return nil, errLDAPFailed
+	}
+	defer conn.Close()
+
+	if config.BindDN != "" {
+		if err := conn.Bind(config.BindDN, config.BindPassword); err != nil {
+			return nil, errLDAPFailed
+		}
+	}
+
+	result, err := conn.Search(ldap.NewSearchRequest(
+		config.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, int(ldapTimeout.Seconds()), false,
+		strings.ReplaceAll(config.UserFilter, "%s", ldap.EscapeFilter(loginData.Username)),
+		[]string{config.UsernameAttribute, config.EmailAttribute, config.GroupAttribute},
+		nil,
+	))
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return nil, errLDAPFailed
+	}
+	if result == nil || len(result.Entries) != 1 {
+		return nil, &helper.StandardError{Error: ErrUserNotFound, ErrorCode: http.StatusBadRequest}
+	}
+
+	entry := result.Entries[0]
+	username := entry.GetAttributeValue(config.UsernameAttribute)
+	if username == "" {
+		username = loginData.Username
+	}
+
+	identity := t.FederationRepository.FindIdentity(ldapProvider, username)
+
+	// An empty password would be an unauthenticated bind, which servers accept for any DN.
+	if loginData.Password == "" {
+		return nil, t.wrongPassword(identity)
+	}
+	if err := conn.Bind(entry.DN, loginData.Password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, t.wrongPassword(identity)
+		}
+		return nil, errLDAPFailed
+	}
+
+	email := entry.GetAttributeValue(config.EmailAttribute)
+	roles := config.roles(entry.GetAttributeValues(config.GroupAttribute))
+
+	var user repository.User
+	if identity.ID != 0 {
+		user = t.UserRepository.FindById(identity.UserID)
+		if user.ID == 0 {
+			t.FederationRepository.DeleteIdentity(identity.ID)
+			identity = repository.Identity{}
+		}
+	}
+
+	// Directory accounts are provisioned into the organization they first sign in to.
+	if identity.ID == 0 {
+		userRepository := t.UserRepository.ForOrganization(loginData.OrganizationID)
+		if userRepository.FindByUsername(username).ID != 0 {
+			return nil, &helper.StandardError{Error: fmt.Errorf("user %s already exists as a local account", username), ErrorCode: http.StatusConflict}
+		}
+
+		user = userRepository.Save(repository.User{Username: username, Email: email, Roles: roles})
+		t.AuditUsecase.Record("user.register", user.ID, user.ID, fmt.Sprintf("provider=%s", ldapProvider))
+
+		identity = t.FederationRepository.SaveIdentity(repository.Identity{UserID: user.ID, Provider: ldapProvider, Subject: username, Email: email})
+	} else if user.Email != email || user.Roles != roles {
+		user.Email = email
+		user.Roles = roles
+		user = t.UserRepository.Update(user)
+	}
+
+	if user.DisabledAt != nil {
+		return nil, errUserDisabled
+	}
+
+	now := time.Now()
+	identity.Email = email
+	identity.LastLoginAt = &now
+	t.FederationRepository.UpdateIdentity(identity)
+
+	return &user, nil
+}
+
+func (t *LDAPAuthenticator) wrongPassword(identity repository.Identity) *helper.StandardError {
+	if identity.ID != 0 {
+		t.AuditUsecase.Record("user.login_failed", 0, identity.UserID, "wrong password")
+	}
+	return &helper.StandardError{Error: errors.New("wrong password"), ErrorCode: http.StatusUnauthorized}
+}
+
+func NewLDAPAuthenticator(userRepository repository.UserRepository, federationRepository repository.FederationRepository, auditUsecase AuditUsecase) Authenticator {
+	return &LDAPAuthenticator{
+		UserRepository:       userRepository,
+		FederationRepository: federationRepository,
+		AuditUsecase:         auditUsecase,
+	}
+}
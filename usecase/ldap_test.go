@@ -0,0 +1,268 @@
+package usecase_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/go-playground/assert/v2"
+	"github.com/stretchr/testify/mock"
+)
+
+type ldapStubEntry struct {
+	DN         string
+	Password   string
+	Attributes map[string][]string
+}
+
+type ldapStub struct {
+	listener net.Listener
+	entries  []ldapStubEntry
+}
+
+func newLDAPStub(entries []ldapStubEntry) *ldapStub {
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	stub := &ldapStub{listener: listener, entries: entries}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go stub.serve(conn)
+		}
+	}()
+
+	os.Setenv("LDAP_URL", "ldap://"+listener.Addr().String())
+	os.Setenv("LDAP_BASE_DN", "dc=example,dc=com")
+	os.Setenv("LDAP_BIND_DN", "cn=service,dc=example,dc=com")
+	os.Setenv("LDAP_BIND_PASSWORD", "service")
+	os.Setenv("LDAP_GROUP_ROLES", `{"cn=admins,ou=groups,dc=example,dc=com":"admin"}`)
+
+	return stub
+}
+
+func (s *ldapStub) Close() {
+	s.listener.Close()
+	for _, name := range []string{"LDAP_URL", "LDAP_BASE_DN", "LDAP_BIND_DN", "LDAP_BIND_PASSWORD", "LDAP_GROUP_ROLES"} {
+		os.Unsetenv(name)
+	}
+}
+
+func (s *ldapStub) serve(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+
+		messageID := packet.Children[0].Value.(int64)
+		request := packet.Children[1]
+
+		switch request.Tag {
+		case ldap.ApplicationBindRequest:
+			name := request.Children[1].Data.String()
+			password := request.Children[2].Data.String()
+
+			code := ldap.LDAPResultInvalidCredentials
+			for _, entry := range s.entries {
+				if entry.DN == name && entry.Password == password {
+					code = ldap.LDAPResultSuccess
+				}
+			}
+			conn.Write(ldapStubResult(messageID, ldap.ApplicationBindResponse, code).Bytes())
+		case ldap.ApplicationSearchRequest:
+			for _, entry := range s.entries {
+				if entry.matches(request.Children[6]) {
+					conn.Write(entry.packet(messageID).Bytes())
+				}
+			}
+			conn.Write(ldapStubResult(messageID, ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess).Bytes())
+		default:
+			return
+		}
+	}
+}
+
+func (e ldapStubEntry) matches(filter *ber.Packet) bool {
+	switch filter.Tag {
+	case ldap.FilterAnd:
+		for _, child := range filter.Children {
+			if !e.matches(child) {
+				return false
+			}
+		}
+		return true
+	case ldap.FilterEqualityMatch:
+		for _, value := range e.Attributes[filter.Children[0].Data.String()] {
+			if strings.EqualFold(value, filter.Children[1].Data.String()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (e ldapStubEntry) packet(messageID int64) *ber.Packet {
+	attributes := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	for name, values := range e.Attributes {
+		attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+		attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, ""))
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+		for _, value := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, ""))
+		}
+		attribute.AppendChild(set)
+		attributes.AppendChild(attribute)
+	}
+
+	entry := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "")
+	entry.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, e.DN, ""))
+	entry.AppendChild(attributes)
+
+	return ldapStubMessage(messageID, entry)
+}
+
+func ldapStubResult(messageID int64, tag ber.Tag, code int) *ber.Packet {
+	result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "")
+	result.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), ""))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+
+	return ldapStubMessage(messageID, result)
+}
+
+func ldapStubMessage(messageID int64, operation *ber.Packet) *ber.Packet {
+	message := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	message.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, ""))
+	message.AppendChild(operation)
+	return message
+}
+
+var ldapStubEntries = []ldapStubEntry{
+	{DN: "cn=service,dc=example,dc=com", Password: "service"},
+	{
+		DN:       "uid=alice,ou=people,dc=example,dc=com",
+		Password: "secret",
+		Attributes: map[string][]string{
+			"uid":      {"alice"},
+			"mail":     {"alice@example.com"},
+			"memberOf": {"CN=Admins,OU=Groups,DC=example,DC=com", "cn=staff,ou=groups,dc=example,dc=com"},
+		},
+	},
+}
+
+func TestLDAPAuthenticator(t *testing.T) {
+	stub := newLDAPStub(ldapStubEntries)
+	defer stub.Close()
+
+	t.Run("test first login provisions the user", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		federationRepositoryMock := new(mocks.FederationRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		federationRepositoryMock.On("FindIdentity", "ldap", "alice").Return(repository.Identity{})
+		userRepositoryMock.On("FindByUsername").Return(repository.User{})
+		userRepositoryMock.On("Save").Return(repository.User{ID: 7, Username: "alice", Email: "alice@example.com", Roles: "admin"})
+		federationRepositoryMock.On("SaveIdentity", mock.Anything).Return(repository.Identity{ID: 1, UserID: 7, Provider: "ldap", Subject: "alice"})
+		federationRepositoryMock.On("UpdateIdentity").Return(repository.Identity{ID: 1, UserID: 7, Provider: "ldap", Subject: "alice"})
+
+		authenticator := usecase.NewLDAPAuthenticator(userRepositoryMock, federationRepositoryMock, auditUsecaseMock)
+		user, err := authenticator.Authenticate(repository.Login{Username: "alice", Password: "secret"})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, user.ID, uint64(7))
+		federationRepositoryMock.AssertCalled(t, "SaveIdentity", repository.Identity{UserID: 7, Provider: "ldap", Subject: "alice", Email: "alice@example.com"})
+	})
+
+	t.Run("test roles are synced from groups", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		federationRepositoryMock := new(mocks.FederationRepositoryMock)
+
+		syncedUser := repository.User{ID: 7, Username: "alice", Email: "alice@example.com", Roles: "admin"}
+
+		federationRepositoryMock.On("FindIdentity", "ldap", "alice").Return(repository.Identity{ID: 1, UserID: 7, Provider: "ldap", Subject: "alice"})
+		federationRepositoryMock.On("UpdateIdentity").Return(repository.Identity{ID: 1, UserID: 7})
+		userRepositoryMock.On("FindById").Return(repository.User{ID: 7, Username: "alice"})
+		userRepositoryMock.On("Update", syncedUser).Return(syncedUser)
+
+		authenticator := usecase.NewLDAPAuthenticator(userRepositoryMock, federationRepositoryMock, nil)
+		user, err := authenticator.Authenticate(repository.Login{Username: "alice", Password: "secret"})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, user.Roles, "admin")
+		userRepositoryMock.AssertCalled(t, "Update", syncedUser)
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		federationRepositoryMock := new(mocks.FederationRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		federationRepositoryMock.On("FindIdentity", "ldap", "alice").Return(repository.Identity{ID: 1, UserID: 7})
+
+		authenticator := usecase.NewLDAPAuthenticator(nil, federationRepositoryMock, auditUsecaseMock)
+		user, err := authenticator.Authenticate(repository.Login{Username: "alice", Password: "wrong"})
+
+		assert.Equal(t, user, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("wrong password"), ErrorCode: http.StatusUnauthorized})
+		auditUsecaseMock.AssertCalled(t, "Record")
+	})
+
+	t.Run("empty password is not an anonymous bind", func(t *testing.T) {
+		federationRepositoryMock := new(mocks.FederationRepositoryMock)
+		federationRepositoryMock.On("FindIdentity", "ldap", "alice").Return(repository.Identity{})
+
+		authenticator := usecase.NewLDAPAuthenticator(nil, federationRepositoryMock, nil)
+		user, err := authenticator.Authenticate(repository.Login{Username: "alice", Password: ""})
+
+		assert.Equal(t, user, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("wrong password"), ErrorCode: http.StatusUnauthorized})
+	})
+
+	t.Run("user not in directory", func(t *testing.T) {
+		authenticator := usecase.NewLDAPAuthenticator(nil, nil, nil)
+		user, err := authenticator.Authenticate(repository.Login{Username: "bob*", Password: "secret"})
+
+		assert.Equal(t, user, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("user not found"), ErrorCode: http.StatusBadRequest})
+	})
+
+	t.Run("local account with the same username", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		federationRepositoryMock := new(mocks.FederationRepositoryMock)
+
+		federationRepositoryMock.On("FindIdentity", "ldap", "alice").Return(repository.Identity{})
+		userRepositoryMock.On("FindByUsername").Return(repository.User{ID: 3, Username: "alice"})
+
+		authenticator := usecase.NewLDAPAuthenticator(userRepositoryMock, federationRepositoryMock, nil)
+		user, err := authenticator.Authenticate(repository.Login{Username: "alice", Password: "secret"})
+
+		assert.Equal(t, user, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("user alice already exists as a local account"), ErrorCode: http.StatusConflict})
+	})
+
+	t.Run("server unavailable", func(t *testing.T) {
+		os.Setenv("LDAP_URL", "ldap://127.0.0.1:1")
+		defer os.Setenv("LDAP_URL", "ldap://"+stub.listener.Addr().String())
+
+		authenticator := usecase.NewLDAPAuthenticator(nil, nil, nil)
+		user, err := authenticator.Authenticate(repository.Login{Username: "alice", Password: "secret"})
+
+		assert.Equal(t, user, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("ldap server request failed"), ErrorCode: http.StatusBadGateway})
+	})
+}
@@ -0,0 +1,127 @@
+package usecase
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	MagicLinkExpiry       = 15 * time.Minute
+	magicLinkTokenPrefix  = "gum_ml_"
+	maxMagicLinksInExpiry = 3
+)
+
+type MagicLinkUsecase interface {
+	RequestLink(requestData repository.MagicLinkRequest, organizationId uint64) (string, *helper.StandardError)
+	ConsumeLink(token string, nonce string, loginContext repository.LoginContext) (string, *helper.StandardError)
+}
+
+type MagicLinkUsecaseImpl struct {
+	MagicLinkRepository repository.MagicLinkRepository
+	UserRepository      repository.UserRepository
+	SessionRepository   repository.SessionRepository
+	Mailer              Mailer
+	AuditUsecase        AuditUsecase
+}
+
+// RequestLink emails a login link and returns the nonce the requesting browser must present to use it.
+// The outcome is the same whether or not the email belongs to an account, so accounts cannot be enumerated.
+func (t *MagicLinkUsecaseImpl) RequestLink(requestData repository.MagicLinkRequest, organizationId uint64) (string, *helper.StandardError) {
+	nonce, err := generateRandomToken("")
+	if err != nil {
+		return "", &helper.StandardError{Error: errors.New("failed to generate login link"), ErrorCode: http.StatusInternalServerError}
+	}
+
+	user := t.UserRepository.ForOrganization(organizationId).FindByEmail(strings.TrimSpace(requestData.Email))
+	if user.ID == 0 || user.DisabledAt != nil || user.PasskeyRequired {
+		return nonce, nil
+	}
+
+	if t.MagicLinkRepository.CountByUserIdSince(user.ID, time.Now().Add(-MagicLinkExpiry)) >= maxMagicLinksInExpiry {
+		return nonce, nil
+	}
+
+	token, err := generateRandomToken(magicLinkTokenPrefix)
+	if err != nil {
+		return "", &helper.StandardError{Error: errors.New("failed to generate login link"), ErrorCode: http.StatusInternalServerError}
+	}
+
+	link := t.MagicLinkRepository.Save(repository.MagicLink{
+		TokenHash: hashToken(token),
+		NonceHash: hashToken(nonce),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(MagicLinkExpiry),
+	})
+
+	err = t.Mailer.Send(MailMessage{
+		To:      user.Email,
+		Subject: "Your login link",
+		Body: fmt.Sprintf("Open this link in the browser you requested it from to sign in as %s:\n\n%s/api/v1/login/magic/consume?token=%s\n\nThe link expires in %d minutes and can only be used once. If you did not request it, ignore this email.\n",
+			user.Username, oidcIssuer(), url.QueryEscape(token), int(MagicLinkExpiry.Minutes())),
+	})
+	if err != nil {
+		log.Printf("Failed to send login link: %v", err)
+		t.MagicLinkRepository.Delete(link.ID)
+		return nonce, nil
+	}
+
+	t.AuditUsecase.Record("magic_link.request", user.ID, user.ID, fmt.Sprintf("magic_link_id=%d", link.ID))
+
+	return nonce, nil
+}
+
+func (t *MagicLinkUsecaseImpl) ConsumeLink(token string, nonce string, loginContext repository.LoginContext) (string, *helper.StandardError) {
+	invalidLink := &helper.StandardError{Error: errors.New("invalid or expired login link"), ErrorCode: http.StatusBadRequest}
+
+	link := t.MagicLinkRepository.FindByTokenHash(hashToken(token))
+	if link.ID == 0 || time.Now().After(link.ExpiresAt) {
+		return "", invalidLink
+	}
+
+	// A link opened elsewhere, e.g. by a mail scanner, is refused without being used up.
+	if subtle.ConstantTimeCompare([]byte(hashToken(nonce)), []byte(link.NonceHash)) != 1 {
+		return "", &helper.StandardError{Error: errors.New("open the login link in the browser that requested it"), ErrorCode: http.StatusBadRequest}
+	}
+
+	if !t.MagicLinkRepository.Delete(link.ID) {
+		return "", invalidLink
+	}
+
+	user := t.UserRepository.FindById(link.UserID)
+	if user.ID == 0 {
+		return "", invalidLink
+	}
+	if user.DisabledAt != nil {
+		return "", errUserDisabled
+	}
+	if user.PasskeyRequired {
+		return "", errPasskeyRequired
+	}
+
+	loginToken, session, loginError := startTokenSession(t.SessionRepository, user, SessionMethodMagicLink, loginContext)
+	if loginError != nil {
+		return "", loginError
+	}
+
+	t.AuditUsecase.Record("user.login", user.ID, user.ID, fmt.Sprintf("method=magic_link magic_link_id=%d session_id=%d", link.ID, session.ID))
+
+	return loginToken, nil
+}
+
+func NewMagicLinkUsecaseImpl(magicLinkRepository repository.MagicLinkRepository, userRepository repository.UserRepository, sessionRepository repository.SessionRepository, mailer Mailer, auditUsecase AuditUsecase) MagicLinkUsecase {
+	return &MagicLinkUsecaseImpl{
+		MagicLinkRepository: magicLinkRepository,
+		UserRepository:      userRepository,
+		SessionRepository:   sessionRepository,
+		Mailer:              mailer,
+		AuditUsecase:        auditUsecase,
+	}
+}
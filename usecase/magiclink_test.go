@@ -0,0 +1,195 @@
+package usecase_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/stretchr/testify/mock"
+)
+
+var magicLinkPattern = regexp.MustCompile(`http://localhost:3000/api/v1/login/magic/consume\?token=(\S+)`)
+
+// savedMagicLink returns the link stored by the last RequestLink call, as the database would return it.
+func savedMagicLink(magicLinkRepositoryMock *mocks.MagicLinkRepositoryMock) repository.MagicLink {
+	var saved repository.MagicLink
+	for _, call := range magicLinkRepositoryMock.Calls {
+		if call.Method == "Save" {
+			saved = call.Arguments.Get(0).(repository.MagicLink)
+		}
+	}
+	saved.ID = 1
+	return saved
+}
+
+func TestMagicLinkRequestLink(t *testing.T) {
+	t.Setenv("OIDC_ISSUER", "http://localhost:3000")
+
+	t.Run("test send link to the outbox", func(t *testing.T) {
+		magicLinkRepositoryMock := new(mocks.MagicLinkRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		outbox := &usecase.OutboxMailer{}
+		userRepositoryMock.On("FindByEmail").Return(mockUser)
+		magicLinkRepositoryMock.On("CountByUserIdSince").Return(int64(0))
+		magicLinkRepositoryMock.On("Save", mock.MatchedBy(func(link repository.MagicLink) bool {
+			return link.UserID == 100 && link.TokenHash != "" && link.NonceHash != "" && link.ExpiresAt.After(time.Now())
+		})).Return(repository.MagicLink{ID: 1})
+		auditUsecaseMock.On("Record").Return(nil)
+
+		magicLinkUsecase := usecase.NewMagicLinkUsecaseImpl(magicLinkRepositoryMock, userRepositoryMock, nil, outbox, auditUsecaseMock)
+		nonce, err := magicLinkUsecase.RequestLink(repository.MagicLinkRequest{Email: "test@mail.com"}, repository.DefaultOrganizationID)
+
+		assert.Equal(t, err, nil)
+		assert.NotEqual(t, nonce, "")
+		assert.Equal(t, len(outbox.Messages()), 1)
+		assert.Equal(t, outbox.Messages()[0].To, "test@mail.com")
+		assert.MatchRegex(t, outbox.Messages()[0].Body, magicLinkPattern.String())
+	})
+
+	t.Run("unknown email", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		outbox := &usecase.OutboxMailer{}
+		userRepositoryMock.On("FindByEmail").Return(repository.User{})
+
+		magicLinkUsecase := usecase.NewMagicLinkUsecaseImpl(nil, userRepositoryMock, nil, outbox, nil)
+		nonce, err := magicLinkUsecase.RequestLink(repository.MagicLinkRequest{Email: "unknown@mail.com"}, repository.DefaultOrganizationID)
+
+		assert.Equal(t, err, nil)
+		assert.NotEqual(t, nonce, "")
+		assert.Equal(t, len(outbox.Messages()), 0)
+	})
+
+	t.Run("too many links", func(t *testing.T) {
+		magicLinkRepositoryMock := new(mocks.MagicLinkRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		outbox := &usecase.OutboxMailer{}
+		userRepositoryMock.On("FindByEmail").Return(mockUser)
+		magicLinkRepositoryMock.On("CountByUserIdSince").Return(int64(3))
+
+		magicLinkUsecase := usecase.NewMagicLinkUsecaseImpl(magicLinkRepositoryMock, userRepositoryMock, nil, outbox, nil)
+		_, err := magicLinkUsecase.RequestLink(repository.MagicLinkRequest{Email: "test@mail.com"}, repository.DefaultOrganizationID)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, len(outbox.Messages()), 0)
+		magicLinkRepositoryMock.AssertNotCalled(t, "Save", mock.Anything)
+	})
+
+	t.Run("passkey required", func(t *testing.T) {
+		passkeyUser := mockUser
+		passkeyUser.PasskeyRequired = true
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		outbox := &usecase.OutboxMailer{}
+		userRepositoryMock.On("FindByEmail").Return(passkeyUser)
+
+		magicLinkUsecase := usecase.NewMagicLinkUsecaseImpl(nil, userRepositoryMock, nil, outbox, nil)
+		_, err := magicLinkUsecase.RequestLink(repository.MagicLinkRequest{Email: "test@mail.com"}, repository.DefaultOrganizationID)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, len(outbox.Messages()), 0)
+	})
+}
+
+func TestMagicLinkConsumeLink(t *testing.T) {
+	t.Setenv("OIDC_ISSUER", "http://localhost:3000")
+	t.Setenv("SECRET", "testkey")
+
+	requestLink := func(magicLinkRepositoryMock *mocks.MagicLinkRepositoryMock, userRepositoryMock *mocks.UserRepositoryMock, auditUsecaseMock *mocks.AuditUsecaseMock) (usecase.MagicLinkUsecase, string, string) {
+		outbox := &usecase.OutboxMailer{}
+		userRepositoryMock.On("FindByEmail").Return(mockUser)
+		magicLinkRepositoryMock.On("CountByUserIdSince").Return(int64(0))
+		magicLinkRepositoryMock.On("Save", mock.Anything).Return(repository.MagicLink{ID: 1})
+		auditUsecaseMock.On("Record").Return(nil)
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		sessionRepositoryMock.On("Save", mock.Anything).Return(loginSession)
+
+		magicLinkUsecase := usecase.NewMagicLinkUsecaseImpl(magicLinkRepositoryMock, userRepositoryMock, sessionRepositoryMock, outbox, auditUsecaseMock)
+		nonce, _ := magicLinkUsecase.RequestLink(repository.MagicLinkRequest{Email: "test@mail.com"}, repository.DefaultOrganizationID)
+		token, _ := url.QueryUnescape(magicLinkPattern.FindStringSubmatch(outbox.Messages()[0].Body)[1])
+		return magicLinkUsecase, token, nonce
+	}
+
+	t.Run("test login with the emailed link", func(t *testing.T) {
+		magicLinkRepositoryMock := new(mocks.MagicLinkRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		magicLinkUsecase, token, nonce := requestLink(magicLinkRepositoryMock, userRepositoryMock, auditUsecaseMock)
+		magicLinkRepositoryMock.On("FindByTokenHash").Return(savedMagicLink(magicLinkRepositoryMock))
+		magicLinkRepositoryMock.On("Delete").Return(true)
+		userRepositoryMock.On("FindById").Return(mockUser)
+
+		loginToken, err := magicLinkUsecase.ConsumeLink(token, nonce, repository.LoginContext{})
+
+		assert.Equal(t, err, nil)
+		assert.NotEqual(t, loginToken, "")
+		magicLinkRepositoryMock.AssertCalled(t, "Delete")
+	})
+
+	t.Run("another browser", func(t *testing.T) {
+		magicLinkRepositoryMock := new(mocks.MagicLinkRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		magicLinkUsecase, token, _ := requestLink(magicLinkRepositoryMock, userRepositoryMock, auditUsecaseMock)
+		magicLinkRepositoryMock.On("FindByTokenHash").Return(savedMagicLink(magicLinkRepositoryMock))
+
+		loginToken, err := magicLinkUsecase.ConsumeLink(token, "", repository.LoginContext{})
+
+		assert.Equal(t, loginToken, "")
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("open the login link in the browser that requested it"), ErrorCode: http.StatusBadRequest})
+		magicLinkRepositoryMock.AssertNotCalled(t, "Delete")
+	})
+
+	t.Run("link already used", func(t *testing.T) {
+		magicLinkRepositoryMock := new(mocks.MagicLinkRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		magicLinkUsecase, token, nonce := requestLink(magicLinkRepositoryMock, userRepositoryMock, auditUsecaseMock)
+		magicLinkRepositoryMock.On("FindByTokenHash").Return(savedMagicLink(magicLinkRepositoryMock))
+		magicLinkRepositoryMock.On("Delete").Return(false)
+
+		loginToken, err := magicLinkUsecase.ConsumeLink(token, nonce, repository.LoginContext{})
+
+		assert.Equal(t, loginToken, "")
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("invalid or expired login link"), ErrorCode: http.StatusBadRequest})
+	})
+
+	t.Run("expired link", func(t *testing.T) {
+		magicLinkRepositoryMock := new(mocks.MagicLinkRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		magicLinkUsecase, token, nonce := requestLink(magicLinkRepositoryMock, userRepositoryMock, auditUsecaseMock)
+		expired := savedMagicLink(magicLinkRepositoryMock)
+		expired.ExpiresAt = time.Now().Add(-time.Minute)
+		magicLinkRepositoryMock.On("FindByTokenHash").Return(expired)
+
+		loginToken, err := magicLinkUsecase.ConsumeLink(token, nonce, repository.LoginContext{})
+
+		assert.Equal(t, loginToken, "")
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("invalid or expired login link"), ErrorCode: http.StatusBadRequest})
+	})
+
+	t.Run("disabled user", func(t *testing.T) {
+		disabledUser := mockUser
+		disabledUser.DisabledAt = &disabledAt
+		magicLinkRepositoryMock := new(mocks.MagicLinkRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		magicLinkUsecase, token, nonce := requestLink(magicLinkRepositoryMock, userRepositoryMock, auditUsecaseMock)
+		magicLinkRepositoryMock.On("FindByTokenHash").Return(savedMagicLink(magicLinkRepositoryMock))
+		magicLinkRepositoryMock.On("Delete").Return(true)
+		userRepositoryMock.On("FindById").Return(disabledUser)
+
+		loginToken, err := magicLinkUsecase.ConsumeLink(token, nonce, repository.LoginContext{})
+
+		assert.Equal(t, loginToken, "")
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("user is disabled"), ErrorCode: http.StatusForbidden})
+	})
+}
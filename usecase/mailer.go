@@ -0,0 +1,110 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+)
+
+type MailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(message MailMessage) error
+}
+
+type SMTPMailer struct {
+	Address  string
+	Username string
+	Password string
+	From     string
+}
+
+func (t *SMTPMailer) Send(message MailMessage) error {
+	from, err := mail.ParseAddress(t.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+	to, err := mail.ParseAddress(message.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address: %w", err)
+	}
+	if strings.ContainsAny(message.Subject, "\r\n") {
+		return fmt.Errorf("invalid subject")
+	}
+
+	var auth smtp.Auth
+	if t.Username != "" {
+		host, _, _ := net.SplitHostPort(t.Address)
+		auth = smtp.PlainAuth("", t.Username, t.Password, host)
+	}
+
+	body := strings.Join([]string{
+		"From: " + from.String(),
+		"To: " + to.String(),
+		"Subject: " + message.Subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+		"",
+		message.Body,
+	}, "\r\n")
+
+	return smtp.SendMail(t.Address, auth, from.Address, []string{to.Address}, []byte(body))
+}
+
+// outboxLimit bounds the outbox; older messages are dropped first.
+const outboxLimit = 100
+
+// OutboxMailer keeps the last outboxLimit messages in memory instead of delivering them, for development and tests.
+type OutboxMailer struct {
+	mutex    sync.Mutex
+	messages []MailMessage
+}
+
+func (t *OutboxMailer) Send(message MailMessage) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if len(t.messages) >= outboxLimit {
+		t.messages = t.messages[len(t.messages)-outboxLimit+1:]
+	}
+	t.messages = append(t.messages, message)
+	log.Printf("SMTP_HOST is not set, keeping mail %q to %s in the outbox", message.Subject, message.To)
+	return nil
+}
+
+func (t *OutboxMailer) Messages() []MailMessage {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return append([]MailMessage{}, t.messages...)
+}
+
+// NewMailer runs at startup. Without SMTP_HOST no login link or invitation would ever arrive, so the outbox is
+// only accepted in development mode.
+func NewMailer() (Mailer, error) {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		if !developmentMode() {
+			return nil, errors.New("SMTP_HOST is not set, set DEV_MODE=true to keep mail in an in-memory outbox")
+		}
+		log.Printf("WARNING: SMTP_HOST is not set, mail is not delivered and only the last %d messages are kept in memory", outboxLimit)
+		return &OutboxMailer{}, nil
+	}
+
+	return &SMTPMailer{
+		Address:  net.JoinHostPort(host, envOrDefault("SMTP_PORT", "587")),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     envOrDefault("MAIL_FROM", "no-reply@localhost"),
+	}, nil
+}
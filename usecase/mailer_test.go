@@ -0,0 +1,56 @@
+package usecase_test
+
+import (
+	"andikawhy/go-user-management/usecase"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestNewMailer(t *testing.T) {
+	t.Run("missing SMTP host outside development mode", func(t *testing.T) {
+		os.Unsetenv("SMTP_HOST")
+		os.Unsetenv("DEV_MODE")
+
+		mailer, err := usecase.NewMailer()
+
+		assert.Equal(t, mailer, nil)
+		assert.Equal(t, err, errors.New("SMTP_HOST is not set, set DEV_MODE=true to keep mail in an in-memory outbox"))
+	})
+
+	t.Run("outbox in development mode", func(t *testing.T) {
+		os.Unsetenv("SMTP_HOST")
+		os.Setenv("DEV_MODE", "true")
+		defer os.Unsetenv("DEV_MODE")
+
+		mailer, err := usecase.NewMailer()
+
+		assert.Equal(t, err, nil)
+		_, ok := mailer.(*usecase.OutboxMailer)
+		assert.Equal(t, ok, true)
+	})
+
+	t.Run("SMTP host", func(t *testing.T) {
+		t.Setenv("SMTP_HOST", "smtp.example.com")
+
+		mailer, err := usecase.NewMailer()
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, mailer.(*usecase.SMTPMailer).Address, "smtp.example.com:587")
+	})
+}
+
+func TestOutboxMailer(t *testing.T) {
+	outbox := &usecase.OutboxMailer{}
+	for i := 0; i < 150; i++ {
+		outbox.Send(usecase.MailMessage{To: "test@mail.com", Subject: fmt.Sprintf("message %d", i)})
+	}
+
+	messages := outbox.Messages()
+	assert.Equal(t, len(messages), 100)
+	assert.Equal(t, messages[0].Subject, "message 50")
+	assert.Equal(t, messages[99].Subject, "message 149")
+}
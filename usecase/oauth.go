@@ -0,0 +1,723 @@
+package usecase
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	clientIdPrefix          = "gum_client_"
+	clientSecretPrefix      = "gum_cs_"
+	authorizationCodePrefix = "gum_ac_"
+	refreshTokenPrefix      = "gum_rt_"
+	clientTokenExpiry       = time.Hour
+	userAccessTokenExpiry   = time.Hour
+	authorizationCodeExpiry = 5 * time.Minute
+	refreshTokenExpiry      = 30 * 24 * time.Hour
+)
+
+const (
+	GrantClientCredentials = "client_credentials"
+	GrantAuthorizationCode = "authorization_code"
+	GrantRefreshToken      = "refresh_token"
+	GrantDeviceCode        = "urn:ietf:params:oauth:grant-type:device_code"
+)
+
+var (
+	ErrInvalidRequest          = errors.New("invalid_request")
+	ErrInvalidClient           = errors.New("invalid_client")
+	ErrInvalidGrant            = errors.New("invalid_grant")
+	ErrInvalidScope            = errors.New("invalid_scope")
+	ErrUnauthorizedClient      = errors.New("unauthorized_client")
+	ErrUnsupportedGrantType    = errors.New("unsupported_grant_type")
+	ErrUnsupportedResponseType = errors.New("unsupported_response_type")
+	ErrAccessDenied            = errors.New("access_denied")
+	ErrUnsupportedTokenType    = errors.New("unsupported_token_type")
+	ErrInvalidRedirectURI      = errors.New("invalid redirect_uri")
+	ErrAuthorizationPending    = errors.New("authorization_pending")
+	ErrSlowDown                = errors.New("slow_down")
+	ErrExpiredToken            = errors.New("expired_token")
+)
+
+var (
+	codeChallengePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{43}$`)
+	codeVerifierPattern  = regexp.MustCompile(`^[A-Za-z0-9._~-]{43,128}$`)
+	// privateUseSchemePattern matches the reverse domain name schemes of native apps, e.g. com.example.app (RFC 8252 section 7.1).
+	privateUseSchemePattern = regexp.MustCompile(`^[a-z][a-z0-9+-]*(\.[a-z0-9+-]+)+$`)
+	supportedGrantTypes     = []string{GrantClientCredentials, GrantAuthorizationCode, GrantRefreshToken, GrantDeviceCode}
+	defaultGrantTypes       = []string{GrantClientCredentials}
+	clientScopes            = []string{ScopeUsersRead, ScopeUsersWrite, ScopeAuditRead, ScopeSCIM, ScopeAuthz}
+)
+
+type OAuthUsecase interface {
+	CreateClient(organizationId uint64, createClientData repository.CreateClient, currentUserId uint64) (*repository.CreatedClientResponse, *helper.StandardError)
+	ListClients(organizationId uint64) (*[]repository.OAuthClient, *helper.StandardError)
+	RotateClientSecret(organizationId uint64, id uint64, currentUserId uint64) (*repository.CreatedClientResponse, *helper.StandardError)
+	DisableClient(organizationId uint64, id uint64, currentUserId uint64) (*repository.OAuthClient, *helper.StandardError)
+	Token(tokenRequest repository.TokenRequest) (*repository.TokenResponse, *helper.StandardError)
+	Authorize(authorizeRequest repository.AuthorizeRequest) (*repository.AuthorizePrompt, *helper.StandardError)
+	Approve(decision repository.AuthorizeDecision) (*repository.AuthorizePrompt, *helper.StandardError)
+	ListConsents(userId uint64) (*[]repository.Consent, *helper.StandardError)
+	RevokeConsent(userId uint64, consentId uint64) (*repository.Consent, *helper.StandardError)
+	Introspect(introspectRequest repository.TokenHintRequest) (*repository.IntrospectionResponse, *helper.StandardError)
+	Revoke(revokeRequest repository.TokenHintRequest) *helper.StandardError
+	DeviceAuthorization(deviceRequest repository.DeviceAuthorizationRequest) (*repository.DeviceAuthorizationResponse, *helper.StandardError)
+	DevicePrompt(userCode string) (*repository.DevicePrompt, *helper.StandardError)
+	SessionUser(sessionToken string) *repository.User
+	ApproveDevice(decision repository.DeviceDecision) (*repository.DevicePrompt, *helper.StandardError)
+}
+
+type OAuthUsecaseImpl struct {
+	ClientRepository  repository.OAuthClientRepository
+	OAuthRepository   repository.OAuthRepository
+	UserRepository    repository.UserRepository
+	SessionRepository repository.SessionRepository
+	AuthUsecase       AuthUsecase
+	AuditUsecase      AuditUsecase
+}
+
+// validateRedirectURI allows https, http on a loopback address and private-use schemes of native apps (RFC 8252).
+// Any other scheme, such as javascript: or data:, would run in the browser instead of reaching the client.
+func validateRedirectURI(redirectURI string) error {
+	parsed, err := url.Parse(redirectURI)
+	if err != nil || parsed.Scheme == "" || parsed.Opaque != "" || parsed.Fragment != "" {
+		return fmt.Errorf("invalid redirect uri: %s", redirectURI)
+	}
+
+	switch {
+	case parsed.Scheme == "https":
+		if parsed.Host == "" {
+			return fmt.Errorf("invalid redirect uri: %s", redirectURI)
+		}
+	case parsed.Scheme == "http":
+		host := parsed.Hostname()
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return fmt.Errorf("redirect uri must use https: %s", redirectURI)
+		}
+	case !privateUseSchemePattern.MatchString(parsed.Scheme):
+		return fmt.Errorf("redirect uri must use https, loopback http or a private-use scheme: %s", redirectURI)
+	}
+
+	return nil
+}
+
+func allowsGrant(client repository.OAuthClient, grantType string) bool {
+	return hasScope(strings.Fields(client.GrantTypes), grantType)
+}
+
+func withQuery(redirectURI string, params url.Values) string {
+	parsed, _ := url.Parse(redirectURI)
+	query := parsed.Query()
+	for key, values := range params {
+		for _, value := range values {
+			if value != "" {
+				query.Add(key, value)
+			}
+		}
+	}
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
+
+func verifyCodeChallenge(codeVerifier string, codeChallenge string) bool {
+	if !codeVerifierPattern.MatchString(codeVerifier) {
+		return false
+	}
+	sum := sha256.Sum256([]byte(codeVerifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(codeChallenge)) == 1
+}
+
+// CreateClient registers a client of the organization; its client credentials tokens act in that organization only.
+func (t *OAuthUsecaseImpl) CreateClient(organizationId uint64, createClientData repository.CreateClient, currentUserId uint64) (*repository.CreatedClientResponse, *helper.StandardError) {
+	if len(createClientData.Scopes) == 0 {
+		return nil, &helper.StandardError{Error: errors.New("at least one scope is required"), ErrorCode: http.StatusBadRequest}
+	}
+
+	if err := validateScopes(createClientData.Scopes, clientScopes); err != nil {
+		return nil, &helper.StandardError{Error: err, ErrorCode: http.StatusBadRequest}
+	}
+
+	grantTypes := createClientData.GrantTypes
+	if len(grantTypes) == 0 {
+		grantTypes = defaultGrantTypes
+	}
+
+	if err := validateScopes(grantTypes, supportedGrantTypes); err != nil {
+		return nil, &helper.StandardError{Error: fmt.Errorf("unsupported grant type in: %s", strings.Join(grantTypes, ", ")), ErrorCode: http.StatusBadRequest}
+	}
+
+	if createClientData.Public && hasScope(grantTypes, GrantClientCredentials) {
+		return nil, &helper.StandardError{Error: errors.New("public clients cannot use the client_credentials grant"), ErrorCode: http.StatusBadRequest}
+	}
+
+	if hasScope(grantTypes, GrantAuthorizationCode) && len(createClientData.RedirectURIs) == 0 {
+		return nil, &helper.StandardError{Error: errors.New("at least one redirect uri is required for the authorization_code grant"), ErrorCode: http.StatusBadRequest}
+	}
+
+	for _, redirectURI := range append(append([]string{}, createClientData.RedirectURIs...), createClientData.PostLogoutRedirectURIs...) {
+		if err := validateRedirectURI(redirectURI); err != nil {
+			return nil, &helper.StandardError{Error: err, ErrorCode: http.StatusBadRequest}
+		}
+	}
+
+	clientId, err := generateRandomToken(clientIdPrefix)
+	if err != nil {
+		return nil, &helper.StandardError{Error: errors.New("failed to generate client id"), ErrorCode: http.StatusInternalServerError}
+	}
+
+	clientSecret, err := generateRandomToken(clientSecretPrefix)
+	if err != nil {
+		return nil, &helper.StandardError{Error: errors.New("failed to generate client secret"), ErrorCode: http.StatusInternalServerError}
+	}
+
+	client := repository.OAuthClient{
+		ClientID:               clientId,
+		OrganizationID:         organizationId,
+		Name:                   createClientData.Name,
+		Scopes:                 strings.Join(createClientData.Scopes, " "),
+		GrantTypes:             strings.Join(grantTypes, " "),
+		RedirectURIs:           strings.Join(createClientData.RedirectURIs, " "),
+		PostLogoutRedirectURIs: strings.Join(createClientData.PostLogoutRedirectURIs, " "),
+		Public:                 createClientData.Public,
+	}
+	if client.Public {
+		clientSecret = ""
+	} else {
+		client.SecretHash = hashToken(clientSecret)
+	}
+
+	client = t.ClientRepository.Save(client)
+
+	t.AuditUsecase.Record("client.create", currentUserId, 0, fmt.Sprintf("client_id=%s", client.ClientID))
+
+	return &repository.CreatedClientResponse{OAuthClient: client, ClientSecret: clientSecret}, nil
+}
+
+func (t *OAuthUsecaseImpl) ListClients(organizationId uint64) (*[]repository.OAuthClient, *helper.StandardError) {
+	clients := t.ClientRepository.FindByOrganizationId(organizationId)
+	if clients == nil {
+		clients = []repository.OAuthClient{}
+	}
+	return &clients, nil
+}
+
+func (t *OAuthUsecaseImpl) RotateClientSecret(organizationId uint64, id uint64, currentUserId uint64) (*repository.CreatedClientResponse, *helper.StandardError) {
+	client := t.ClientRepository.FindById(organizationId, id)
+
+	if client.ID == 0 {
+		return nil, &helper.StandardError{Error: errors.New("client not found"), ErrorCode: http.StatusNotFound}
+	}
+
+	if client.Public {
+		return nil, &helper.StandardError{Error: errors.New("public clients have no secret"), ErrorCode: http.StatusBadRequest}
+	}
+
+	clientSecret, err := generateRandomToken(clientSecretPrefix)
+	if err != nil {
+		return nil, &helper.StandardError{Error: errors.New("failed to generate client secret"), ErrorCode: http.StatusInternalServerError}
+	}
+
+	client.SecretHash = hashToken(clientSecret)
+	client = t.ClientRepository.Update(client)
+
+	t.AuditUsecase.Record("client.rotate_secret", currentUserId, 0, fmt.Sprintf("client_id=%s", client.ClientID))
+
+	return &repository.CreatedClientResponse{OAuthClient: client, ClientSecret: clientSecret}, nil
+}
+
+func (t *OAuthUsecaseImpl) DisableClient(organizationId uint64, id uint64, currentUserId uint64) (*repository.OAuthClient, *helper.StandardError) {
+	client := t.ClientRepository.FindById(organizationId, id)
+
+	if client.ID == 0 {
+		return nil, &helper.StandardError{Error: errors.New("client not found"), ErrorCode: http.StatusNotFound}
+	}
+
+	if client.DisabledAt == nil {
+		now := time.Now()
+		client.DisabledAt = &now
+		client = t.ClientRepository.Update(client)
+		t.AuditUsecase.Record("client.disable", currentUserId, 0, fmt.Sprintf("client_id=%s", client.ClientID))
+	}
+
+	return &client, nil
+}
+
+func (t *OAuthUsecaseImpl) authenticateClient(clientId string, clientSecret string) (repository.OAuthClient, *helper.StandardError) {
+	if clientId == "" {
+		return repository.OAuthClient{}, &helper.StandardError{Error: ErrInvalidClient, ErrorCode: http.StatusUnauthorized}
+	}
+
+	client := t.ClientRepository.FindByClientId(clientId)
+
+	if client.ID == 0 || client.DisabledAt != nil {
+		return repository.OAuthClient{}, &helper.StandardError{Error: ErrInvalidClient, ErrorCode: http.StatusUnauthorized}
+	}
+
+	if !client.Public && subtle.ConstantTimeCompare([]byte(client.SecretHash), []byte(hashToken(clientSecret))) != 1 {
+		return repository.OAuthClient{}, &helper.StandardError{Error: ErrInvalidClient, ErrorCode: http.StatusUnauthorized}
+	}
+
+	return client, nil
+}
+
+func (t *OAuthUsecaseImpl) Token(tokenRequest repository.TokenRequest) (*repository.TokenResponse, *helper.StandardError) {
+	switch tokenRequest.GrantType {
+	case GrantClientCredentials:
+		return t.clientCredentials(tokenRequest)
+	case GrantAuthorizationCode:
+		return t.authorizationCode(tokenRequest)
+	case GrantRefreshToken:
+		return t.refreshToken(tokenRequest)
+	case GrantDeviceCode:
+		return t.deviceCode(tokenRequest)
+	case "":
+		return nil, &helper.StandardError{Error: ErrInvalidRequest, ErrorCode: http.StatusBadRequest}
+	}
+
+	return nil, &helper.StandardError{Error: ErrUnsupportedGrantType, ErrorCode: http.StatusBadRequest}
+}
+
+func (t *OAuthUsecaseImpl) clientCredentials(tokenRequest repository.TokenRequest) (*repository.TokenResponse, *helper.StandardError) {
+	client, authError := t.authenticateClient(tokenRequest.ClientID, tokenRequest.ClientSecret)
+	if authError != nil {
+		return nil, authError
+	}
+
+	if !allowsGrant(client, GrantClientCredentials) {
+		return nil, &helper.StandardError{Error: ErrUnauthorizedClient, ErrorCode: http.StatusBadRequest}
+	}
+
+	scopes := strings.Fields(client.Scopes)
+	if tokenRequest.Scope != "" {
+		requested := strings.Fields(tokenRequest.Scope)
+		if validateScopes(requested, scopes) != nil {
+			return nil, &helper.StandardError{Error: ErrInvalidScope, ErrorCode: http.StatusBadRequest}
+		}
+		scopes = requested
+	}
+
+	scope := strings.Join(scopes, " ")
+	expiresAt := time.Now().Add(clientTokenExpiry)
+
+	accessToken, err := signToken(jwt.MapClaims{
+		"sub":       client.ClientID,
+		"client_id": client.ClientID,
+		"scope":     scope,
+		"exp":       expiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, &helper.StandardError{Error: errors.New("failed to generate token"), ErrorCode: http.StatusInternalServerError}
+	}
+
+	t.AuditUsecase.Record("client.token", 0, 0, fmt.Sprintf("client_id=%s", client.ClientID))
+
+	return &repository.TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(clientTokenExpiry.Seconds()),
+		Scope:       scope,
+	}, nil
+}
+
+func (t *OAuthUsecaseImpl) authorizationCode(tokenRequest repository.TokenRequest) (*repository.TokenResponse, *helper.StandardError) {
+	client, authError := t.authenticateClient(tokenRequest.ClientID, tokenRequest.ClientSecret)
+	if authError != nil {
+		return nil, authError
+	}
+
+	if !allowsGrant(client, GrantAuthorizationCode) {
+		return nil, &helper.StandardError{Error: ErrUnauthorizedClient, ErrorCode: http.StatusBadRequest}
+	}
+
+	invalidGrant := &helper.StandardError{Error: ErrInvalidGrant, ErrorCode: http.StatusBadRequest}
+
+	code := t.OAuthRepository.FindAuthorizationCodeByHash(hashToken(tokenRequest.Code))
+	if code.ID == 0 || code.ClientID != client.ClientID || time.Now().After(code.ExpiresAt) {
+		return nil, invalidGrant
+	}
+
+	if code.UsedAt != nil || !t.OAuthRepository.MarkAuthorizationCodeUsed(code.ID) {
+		t.OAuthRepository.RevokeRefreshTokens(code.UserID, code.ClientID)
+		t.AuditUsecase.Record("oauth.code_reused", 0, code.UserID, fmt.Sprintf("client_id=%s", code.ClientID))
+		return nil, invalidGrant
+	}
+
+	if code.RedirectURI != tokenRequest.RedirectURI || !verifyCodeChallenge(tokenRequest.CodeVerifier, code.CodeChallenge) || !t.activeSession(code.SessionID) {
+		return nil, invalidGrant
+	}
+
+	user := t.UserRepository.FindById(code.UserID)
+	if user.ID == 0 || user.DisabledAt != nil {
+		return nil, invalidGrant
+	}
+
+	return t.issueUserTokens(client, user, code.Scope, code.Nonce, code.SessionID)
+}
+
+// activeSession reports whether the sign-in a code or refresh token belongs to has not been ended. Codes and refresh
+// tokens issued before grants were tied to a session carry none; issueUserTokens gives them one.
+func (t *OAuthUsecaseImpl) activeSession(sessionId uint64) bool {
+	if sessionId == 0 {
+		return true
+	}
+	session := t.SessionRepository.FindById(sessionId)
+	return session.ID != 0 && time.Now().Before(session.ExpiresAt)
+}
+
+func (t *OAuthUsecaseImpl) refreshToken(tokenRequest repository.TokenRequest) (*repository.TokenResponse, *helper.StandardError) {
+	client, authError := t.authenticateClient(tokenRequest.ClientID, tokenRequest.ClientSecret)
+	if authError != nil {
+		return nil, authError
+	}
+
+	if !allowsGrant(client, GrantRefreshToken) {
+		return nil, &helper.StandardError{Error: ErrUnauthorizedClient, ErrorCode: http.StatusBadRequest}
+	}
+
+	invalidGrant := &helper.StandardError{Error: ErrInvalidGrant, ErrorCode: http.StatusBadRequest}
+
+	refreshToken := t.OAuthRepository.FindRefreshTokenByHash(hashToken(tokenRequest.RefreshToken))
+	if refreshToken.ID == 0 || refreshToken.ClientID != client.ClientID || time.Now().After(refreshToken.ExpiresAt) || !t.activeSession(refreshToken.SessionID) {
+		return nil, invalidGrant
+	}
+
+	if refreshToken.RevokedAt != nil || !t.OAuthRepository.RevokeRefreshToken(refreshToken.ID) {
+		t.OAuthRepository.RevokeRefreshTokens(refreshToken.UserID, refreshToken.ClientID)
+		t.AuditUsecase.Record("oauth.refresh_token_reused", 0, refreshToken.UserID, fmt.Sprintf("client_id=%s", refreshToken.ClientID))
+		return nil, invalidGrant
+	}
+
+	scope := refreshToken.Scope
+	if tokenRequest.Scope != "" {
+		if validateScopes(strings.Fields(tokenRequest.Scope), strings.Fields(refreshToken.Scope)) != nil {
+			return nil, &helper.StandardError{Error: ErrInvalidScope, ErrorCode: http.StatusBadRequest}
+		}
+		scope = tokenRequest.Scope
+	}
+
+	user := t.UserRepository.FindById(refreshToken.UserID)
+	if user.ID == 0 || user.DisabledAt != nil {
+		return nil, invalidGrant
+	}
+
+	return t.issueUserTokens(client, user, scope, "", refreshToken.SessionID)
+}
+
+// issueUserTokens binds the access token to the grant's session through its sid claim, so ending the session
+// revokes the token like a login token.
+func (t *OAuthUsecaseImpl) issueUserTokens(client repository.OAuthClient, user repository.User, scope string, nonce string, sessionId uint64) (*repository.TokenResponse, *helper.StandardError) {
+	if sessionId == 0 {
+		session, ok := saveTokenSession(t.SessionRepository, user, organizationOrDefault(user.OrganizationID), SessionMethodOAuth, repository.LoginContext{}, refreshTokenExpiry)
+		if !ok {
+			return nil, &helper.StandardError{Error: errors.New("failed to generate token"), ErrorCode: http.StatusInternalServerError}
+		}
+		sessionId = session.ID
+	}
+
+	accessToken, err := signToken(jwt.MapClaims{
+		"sub":       strconv.FormatUint(user.ID, 10),
+		"id":        user.ID,
+		"username":  user.Username,
+		"sid":       sessionId,
+		"client_id": client.ClientID,
+		"scope":     scope,
+		"exp":       time.Now().Add(userAccessTokenExpiry).Unix(),
+	})
+	if err != nil {
+		return nil, &helper.StandardError{Error: errors.New("failed to generate token"), ErrorCode: http.StatusInternalServerError}
+	}
+
+	tokenResponse := repository.TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(userAccessTokenExpiry.Seconds()),
+		Scope:       scope,
+	}
+
+	if scopes := strings.Fields(scope); hasScope(scopes, ScopeOpenID) {
+		idToken, err := signIDToken(idTokenClaims(client, user, scopes, nonce, sessionId))
+		if err != nil {
+			return nil, &helper.StandardError{Error: errors.New("failed to generate token"), ErrorCode: http.StatusInternalServerError}
+		}
+		tokenResponse.IDToken = idToken
+	}
+
+	if allowsGrant(client, GrantRefreshToken) {
+		rawRefreshToken, err := generateRandomToken(refreshTokenPrefix)
+		if err != nil {
+			return nil, &helper.StandardError{Error: errors.New("failed to generate token"), ErrorCode: http.StatusInternalServerError}
+		}
+
+		t.OAuthRepository.SaveRefreshToken(repository.RefreshToken{
+			TokenHash: hashToken(rawRefreshToken),
+			ClientID:  client.ClientID,
+			UserID:    user.ID,
+			Scope:     scope,
+			SessionID: sessionId,
+			ExpiresAt: time.Now().Add(refreshTokenExpiry),
+		})
+		tokenResponse.RefreshToken = rawRefreshToken
+	}
+
+	t.AuditUsecase.Record("oauth.token", user.ID, user.ID, fmt.Sprintf("client_id=%s", client.ClientID))
+
+	return &tokenResponse, nil
+}
+
+func (t *OAuthUsecaseImpl) Authorize(authorizeRequest repository.AuthorizeRequest) (*repository.AuthorizePrompt, *helper.StandardError) {
+	client := t.ClientRepository.FindByClientId(authorizeRequest.ClientID)
+	if client.ID == 0 || client.DisabledAt != nil {
+		return nil, &helper.StandardError{Error: ErrInvalidClient, ErrorCode: http.StatusBadRequest}
+	}
+
+	redirectURIs := strings.Fields(client.RedirectURIs)
+	redirectURI := authorizeRequest.RedirectURI
+	if redirectURI == "" && len(redirectURIs) == 1 {
+		redirectURI = redirectURIs[0]
+	}
+	// Clients registered before the scheme rules were tightened may still hold an unsafe redirect uri.
+	if !hasScope(redirectURIs, redirectURI) || validateRedirectURI(redirectURI) != nil {
+		return nil, &helper.StandardError{Error: ErrInvalidRedirectURI, ErrorCode: http.StatusBadRequest}
+	}
+
+	prompt := repository.AuthorizePrompt{Client: client, RedirectURI: redirectURI}
+	redirectError := func(err error) (*repository.AuthorizePrompt, *helper.StandardError) {
+		prompt.RedirectTo = withQuery(redirectURI, url.Values{"error": {err.Error()}, "state": {authorizeRequest.State}})
+		return &prompt, nil
+	}
+
+	if authorizeRequest.ResponseType != "code" {
+		return redirectError(ErrUnsupportedResponseType)
+	}
+
+	if !allowsGrant(client, GrantAuthorizationCode) {
+		return redirectError(ErrUnauthorizedClient)
+	}
+
+	if authorizeRequest.CodeChallengeMethod != "S256" || !codeChallengePattern.MatchString(authorizeRequest.CodeChallenge) {
+		return redirectError(ErrInvalidRequest)
+	}
+
+	prompt.Scopes = strings.Fields(client.Scopes)
+	if authorizeRequest.Scope != "" {
+		prompt.Scopes = strings.Fields(authorizeRequest.Scope)
+		if validateScopes(prompt.Scopes, append(strings.Fields(client.Scopes), oidcScopes...)) != nil {
+			return redirectError(ErrInvalidScope)
+		}
+	}
+
+	return &prompt, nil
+}
+
+func (t *OAuthUsecaseImpl) grantConsent(userId uint64, clientId string, scopes []string) {
+	consent := t.OAuthRepository.FindConsent(userId, clientId)
+	consentedScopes := strings.Fields(consent.Scope)
+	for _, requestedScope := range scopes {
+		if !hasScope(consentedScopes, requestedScope) {
+			consentedScopes = append(consentedScopes, requestedScope)
+		}
+	}
+	consent.UserID = userId
+	consent.ClientID = clientId
+	consent.Scope = strings.Join(consentedScopes, " ")
+	t.OAuthRepository.SaveConsent(consent)
+}
+
+func (t *OAuthUsecaseImpl) Approve(decision repository.AuthorizeDecision) (*repository.AuthorizePrompt, *helper.StandardError) {
+	prompt, promptError := t.Authorize(decision.AuthorizeRequest)
+	if promptError != nil || prompt.RedirectTo != "" {
+		return prompt, promptError
+	}
+
+	user, authError := t.AuthUsecase.Authenticate(repository.Login{Username: decision.Username, Password: decision.Password, OrganizationID: decision.LoginContext.OrganizationID})
+	if authError != nil {
+		return prompt, authError
+	}
+
+	if !decision.Approve {
+		prompt.RedirectTo = withQuery(prompt.RedirectURI, url.Values{"error": {ErrAccessDenied.Error()}, "state": {decision.State}})
+		return prompt, nil
+	}
+
+	scope := strings.Join(prompt.Scopes, " ")
+	t.grantConsent(user.ID, prompt.Client.ClientID, prompt.Scopes)
+
+	failed := &helper.StandardError{Error: errors.New("failed to generate authorization code"), ErrorCode: http.StatusInternalServerError}
+	rawCode, err := generateRandomToken(authorizationCodePrefix)
+	if err != nil {
+		return nil, failed
+	}
+
+	// The sign-in is listed with the user's sessions; ending it, or RP-initiated logout through the id token's sid,
+	// stops the client's refresh tokens.
+	session, ok := saveTokenSession(t.SessionRepository, *user, organizationOrDefault(user.OrganizationID), SessionMethodOAuth, decision.LoginContext, refreshTokenExpiry)
+	if !ok {
+		return nil, failed
+	}
+
+	t.OAuthRepository.SaveAuthorizationCode(repository.AuthorizationCode{
+		CodeHash:      hashToken(rawCode),
+		ClientID:      prompt.Client.ClientID,
+		UserID:        user.ID,
+		RedirectURI:   decision.RedirectURI,
+		Scope:         scope,
+		CodeChallenge: decision.CodeChallenge,
+		Nonce:         decision.Nonce,
+		SessionID:     session.ID,
+		ExpiresAt:     time.Now().Add(authorizationCodeExpiry),
+	})
+
+	t.AuditUsecase.Record("oauth.authorize", user.ID, user.ID, fmt.Sprintf("client_id=%s session_id=%d", prompt.Client.ClientID, session.ID))
+
+	prompt.RedirectTo = withQuery(prompt.RedirectURI, url.Values{"code": {rawCode}, "state": {decision.State}})
+	return prompt, nil
+}
+
+func (t *OAuthUsecaseImpl) ListConsents(userId uint64) (*[]repository.Consent, *helper.StandardError) {
+	consents := t.OAuthRepository.FindConsentsByUserId(userId)
+	if consents == nil {
+		consents = []repository.Consent{}
+	}
+	return &consents, nil
+}
+
+func (t *OAuthUsecaseImpl) RevokeConsent(userId uint64, consentId uint64) (*repository.Consent, *helper.StandardError) {
+	var consent repository.Consent
+	for _, userConsent := range t.OAuthRepository.FindConsentsByUserId(userId) {
+		if userConsent.ID == consentId {
+			consent = userConsent
+		}
+	}
+
+	if consent.ID == 0 {
+		return nil, &helper.StandardError{Error: errors.New("consent not found"), ErrorCode: http.StatusNotFound}
+	}
+
+	t.OAuthRepository.DeleteConsent(consent.ID)
+	t.OAuthRepository.RevokeRefreshTokens(userId, consent.ClientID)
+	t.AuditUsecase.Record("oauth.consent_revoke", userId, userId, fmt.Sprintf("client_id=%s", consent.ClientID))
+
+	return &consent, nil
+}
+
+func isRefreshToken(token string) bool {
+	return strings.HasPrefix(token, refreshTokenPrefix)
+}
+
+func (t *OAuthUsecaseImpl) Introspect(introspectRequest repository.TokenHintRequest) (*repository.IntrospectionResponse, *helper.StandardError) {
+	client, authError := t.authenticateClient(introspectRequest.ClientID, introspectRequest.ClientSecret)
+	if authError != nil {
+		return nil, authError
+	}
+
+	if client.Public {
+		return nil, &helper.StandardError{Error: ErrUnauthorizedClient, ErrorCode: http.StatusBadRequest}
+	}
+
+	inactive := &repository.IntrospectionResponse{Active: false}
+
+	if isRefreshToken(introspectRequest.Token) {
+		refreshToken := t.OAuthRepository.FindRefreshTokenByHash(hashToken(introspectRequest.Token))
+		if refreshToken.ID == 0 || refreshToken.ClientID != client.ClientID || refreshToken.RevokedAt != nil || time.Now().After(refreshToken.ExpiresAt) {
+			return inactive, nil
+		}
+
+		return &repository.IntrospectionResponse{
+			Active:    true,
+			Scope:     refreshToken.Scope,
+			ClientID:  refreshToken.ClientID,
+			ExpiresAt: refreshToken.ExpiresAt.Unix(),
+			IssuedAt:  refreshToken.CreatedAt.Unix(),
+			Subject:   strconv.FormatUint(refreshToken.UserID, 10),
+		}, nil
+	}
+
+	tokenInfo, parseError := t.AuthUsecase.ParseToken(introspectRequest.Token)
+	if parseError != nil {
+		return inactive, nil
+	}
+
+	subject := tokenInfo.ClientID
+	if tokenInfo.UserID != 0 {
+		subject = strconv.FormatUint(tokenInfo.UserID, 10)
+	}
+
+	return &repository.IntrospectionResponse{
+		Active:    true,
+		Scope:     strings.Join(tokenInfo.Scopes, " "),
+		ClientID:  tokenInfo.ClientID,
+		Username:  tokenInfo.Username,
+		TokenType: "Bearer",
+		ExpiresAt: tokenInfo.ExpiresAt,
+		IssuedAt:  tokenInfo.IssuedAt,
+		Subject:   subject,
+		JTI:       tokenInfo.JTI,
+	}, nil
+}
+
+func (t *OAuthUsecaseImpl) Revoke(revokeRequest repository.TokenHintRequest) *helper.StandardError {
+	client, authError := t.authenticateClient(revokeRequest.ClientID, revokeRequest.ClientSecret)
+	if authError != nil {
+		return authError
+	}
+
+	if isRefreshToken(revokeRequest.Token) {
+		refreshToken := t.OAuthRepository.FindRefreshTokenByHash(hashToken(revokeRequest.Token))
+		if refreshToken.ID == 0 {
+			return nil
+		}
+		if refreshToken.ClientID != client.ClientID {
+			return &helper.StandardError{Error: ErrUnauthorizedClient, ErrorCode: http.StatusBadRequest}
+		}
+
+		if t.OAuthRepository.RevokeRefreshToken(refreshToken.ID) {
+			t.AuditUsecase.Record("oauth.token_revoke", 0, refreshToken.UserID, fmt.Sprintf("client_id=%s", client.ClientID))
+		}
+		return nil
+	}
+
+	// invalid tokens need no action, RFC 7009 answers them like a successful revocation
+	tokenInfo, parseError := t.AuthUsecase.ParseToken(revokeRequest.Token)
+	if parseError != nil {
+		return nil
+	}
+
+	if tokenInfo.ClientID != client.ClientID {
+		return &helper.StandardError{Error: ErrUnauthorizedClient, ErrorCode: http.StatusBadRequest}
+	}
+
+	if tokenInfo.JTI == "" {
+		return &helper.StandardError{Error: ErrUnsupportedTokenType, ErrorCode: http.StatusBadRequest}
+	}
+
+	t.OAuthRepository.SaveRevokedToken(repository.RevokedToken{JTI: tokenInfo.JTI, ExpiresAt: time.Unix(tokenInfo.ExpiresAt, 0)})
+	t.AuditUsecase.Record("oauth.token_revoke", 0, tokenInfo.UserID, fmt.Sprintf("client_id=%s", client.ClientID))
+
+	return nil
+}
+
+func NewOAuthUsecaseImpl(clientRepository repository.OAuthClientRepository, oauthRepository repository.OAuthRepository, userRepository repository.UserRepository, sessionRepository repository.SessionRepository, authUsecase AuthUsecase, auditUsecase AuditUsecase) OAuthUsecase {
+	return &OAuthUsecaseImpl{
+		ClientRepository:  clientRepository,
+		OAuthRepository:   oauthRepository,
+		UserRepository:    userRepository,
+		SessionRepository: sessionRepository,
+		AuthUsecase:       authUsecase,
+		AuditUsecase:      auditUsecase,
+	}
+}
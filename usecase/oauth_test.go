@@ -0,0 +1,752 @@
+package usecase_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/mock"
+)
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+var mockClient = repository.OAuthClient{
+	ID:         1,
+	ClientID:   "gum_client_test",
+	SecretHash: hashSecret("gum_cs_secret"),
+	Name:       "ci",
+	Scopes:     "users:read users:write",
+	GrantTypes: "client_credentials",
+}
+
+func TestCreateClient(t *testing.T) {
+	t.Run("test normal create client", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		clientRepositoryMock.On("Save").Return(mockClient)
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, nil, nil, nil, nil, auditUsecaseMock)
+		created, err := oauthUsecase.CreateClient(1, repository.CreateClient{Name: "ci", Scopes: []string{"users:read"}}, 100)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, strings.HasPrefix(created.ClientSecret, "gum_cs_"), true)
+	})
+
+	t.Run("scope not allowed for clients", func(t *testing.T) {
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(new(mocks.OAuthClientRepositoryMock), nil, nil, nil, nil, nil)
+		created, err := oauthUsecase.CreateClient(1, repository.CreateClient{Name: "ci", Scopes: []string{"tokens"}}, 100)
+
+		assert.Equal(t, created, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("unsupported scope: tokens"), ErrorCode: http.StatusBadRequest})
+	})
+}
+
+func TestRotateClientSecret(t *testing.T) {
+	t.Run("test normal rotate", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		clientRepositoryMock.On("FindById").Return(mockClient)
+		clientRepositoryMock.On("Update").Return(mockClient)
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, nil, nil, nil, nil, auditUsecaseMock)
+		rotated, err := oauthUsecase.RotateClientSecret(1, 1, 100)
+
+		assert.Equal(t, err, nil)
+		assert.NotEqual(t, rotated.ClientSecret, "gum_cs_secret")
+	})
+
+	t.Run("negative: client not found", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+
+		clientRepositoryMock.On("FindById").Return(repository.OAuthClient{})
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, nil, nil, nil, nil, nil)
+		rotated, err := oauthUsecase.RotateClientSecret(1, 1, 100)
+
+		assert.Equal(t, rotated, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("client not found"), ErrorCode: http.StatusNotFound})
+	})
+}
+
+func TestDisableClient(t *testing.T) {
+	t.Run("test normal disable", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		clientRepositoryMock.On("FindById").Return(mockClient)
+		clientRepositoryMock.On("Update").Return(mockClient)
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, nil, nil, nil, nil, auditUsecaseMock)
+		_, err := oauthUsecase.DisableClient(1, 1, 100)
+
+		assert.Equal(t, err, nil)
+		clientRepositoryMock.AssertCalled(t, "Update")
+	})
+}
+
+func TestClientCredentialsGrant(t *testing.T) {
+	os.Setenv("SECRET", "testkey")
+
+	t.Run("test normal client credentials", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockClient)
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, nil, nil, nil, nil, auditUsecaseMock)
+		token, err := oauthUsecase.Token(repository.TokenRequest{GrantType: "client_credentials", ClientID: "gum_client_test", ClientSecret: "gum_cs_secret", Scope: "users:read"})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, token.Scope, "users:read")
+		assert.Equal(t, token.TokenType, "Bearer")
+
+		claims := jwt.MapClaims{}
+		_, parseErr := jwt.ParseWithClaims(token.AccessToken, claims, func(token *jwt.Token) (interface{}, error) {
+			return []byte("testkey"), nil
+		})
+		assert.Equal(t, parseErr, nil)
+		assert.Equal(t, claims["client_id"], "gum_client_test")
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockClient)
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, nil, nil, nil, nil, nil)
+		token, err := oauthUsecase.Token(repository.TokenRequest{GrantType: "client_credentials", ClientID: "gum_client_test", ClientSecret: "wrong"})
+
+		assert.Equal(t, token, nil)
+		assert.Equal(t, err, helper.StandardError{Error: usecase.ErrInvalidClient, ErrorCode: http.StatusUnauthorized})
+	})
+
+	t.Run("disabled client", func(t *testing.T) {
+		disabledAt := time.Now()
+		disabledClient := mockClient
+		disabledClient.DisabledAt = &disabledAt
+
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		clientRepositoryMock.On("FindByClientId").Return(disabledClient)
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, nil, nil, nil, nil, nil)
+		_, err := oauthUsecase.Token(repository.TokenRequest{GrantType: "client_credentials", ClientID: "gum_client_test", ClientSecret: "gum_cs_secret"})
+
+		assert.Equal(t, err, helper.StandardError{Error: usecase.ErrInvalidClient, ErrorCode: http.StatusUnauthorized})
+	})
+
+	t.Run("scope outside of client scopes", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		clientRepositoryMock.On("FindByClientId").Return(mockClient)
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, nil, nil, nil, nil, nil)
+		_, err := oauthUsecase.Token(repository.TokenRequest{GrantType: "client_credentials", ClientID: "gum_client_test", ClientSecret: "gum_cs_secret", Scope: "audit:read"})
+
+		assert.Equal(t, err, helper.StandardError{Error: usecase.ErrInvalidScope, ErrorCode: http.StatusBadRequest})
+	})
+
+	t.Run("unsupported grant type", func(t *testing.T) {
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(nil, nil, nil, nil, nil, nil)
+		_, err := oauthUsecase.Token(repository.TokenRequest{GrantType: "password"})
+
+		assert.Equal(t, err, helper.StandardError{Error: usecase.ErrUnsupportedGrantType, ErrorCode: http.StatusBadRequest})
+	})
+}
+
+var mockAppClient = repository.OAuthClient{
+	ID:           2,
+	ClientID:     "gum_client_app",
+	Name:         "app",
+	Scopes:       "users:read users:write",
+	GrantTypes:   "authorization_code refresh_token",
+	RedirectURIs: "https://app.example.com/callback",
+	Public:       true,
+}
+
+const (
+	mockCodeVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	mockCodeChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+)
+
+func mockAuthorizeRequest() repository.AuthorizeRequest {
+	return repository.AuthorizeRequest{
+		ResponseType:        "code",
+		ClientID:            "gum_client_app",
+		RedirectURI:         "https://app.example.com/callback",
+		Scope:               "users:read",
+		State:               "xyz",
+		CodeChallenge:       mockCodeChallenge,
+		CodeChallengeMethod: "S256",
+	}
+}
+
+func TestCreateClientWithAuthorizationCode(t *testing.T) {
+	t.Run("redirect uri required", func(t *testing.T) {
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(nil, nil, nil, nil, nil, nil)
+		_, err := oauthUsecase.CreateClient(1, repository.CreateClient{Name: "app", Scopes: []string{"users:read"}, GrantTypes: []string{"authorization_code"}}, 100)
+
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("at least one redirect uri is required for the authorization_code grant"), ErrorCode: http.StatusBadRequest})
+	})
+
+	t.Run("plain http redirect uri rejected", func(t *testing.T) {
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(nil, nil, nil, nil, nil, nil)
+		_, err := oauthUsecase.CreateClient(1, repository.CreateClient{Name: "app", Scopes: []string{"users:read"}, GrantTypes: []string{"authorization_code"}, RedirectURIs: []string{"http://app.example.com/cb"}}, 100)
+
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("redirect uri must use https: http://app.example.com/cb"), ErrorCode: http.StatusBadRequest})
+	})
+
+	t.Run("script and data redirect uris rejected", func(t *testing.T) {
+		for _, redirectURI := range []string{"javascript://app.example.com/%0Aalert(1)", "data:text/html,<script>alert(1)</script>", "vbscript:msgbox(1)", "ftp://app.example.com/cb"} {
+			oauthUsecase := usecase.NewOAuthUsecaseImpl(nil, nil, nil, nil, nil, nil)
+			_, err := oauthUsecase.CreateClient(1, repository.CreateClient{Name: "app", Scopes: []string{"users:read"}, GrantTypes: []string{"authorization_code"}, PostLogoutRedirectURIs: []string{redirectURI}, RedirectURIs: []string{"https://app.example.com/cb"}}, 100)
+
+			assert.NotEqual(t, err, nil)
+			assert.Equal(t, int(err.ErrorCode), http.StatusBadRequest)
+		}
+	})
+
+	t.Run("private-use scheme of a native app", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		clientRepositoryMock.On("Save").Return(mockAppClient)
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, nil, nil, nil, nil, auditUsecaseMock)
+		_, err := oauthUsecase.CreateClient(1, repository.CreateClient{Name: "app", Scopes: []string{"users:read"}, GrantTypes: []string{"authorization_code"}, RedirectURIs: []string{"com.example.app:/oauth2redirect"}, Public: true}, 100)
+
+		assert.Equal(t, err, nil)
+	})
+
+	t.Run("public client without secret", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		clientRepositoryMock.On("Save").Return(mockAppClient)
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, nil, nil, nil, nil, auditUsecaseMock)
+		created, err := oauthUsecase.CreateClient(1, repository.CreateClient{Name: "app", Scopes: []string{"users:read"}, GrantTypes: []string{"authorization_code", "refresh_token"}, RedirectURIs: []string{"http://127.0.0.1:8080/cb"}, Public: true}, 100)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, created.ClientSecret, "")
+	})
+}
+
+func TestAuthorize(t *testing.T) {
+	t.Run("test normal authorize", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		clientRepositoryMock.On("FindByClientId").Return(mockAppClient)
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, nil, nil, nil, nil, nil)
+		prompt, err := oauthUsecase.Authorize(mockAuthorizeRequest())
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, prompt.RedirectTo, "")
+		assert.Equal(t, prompt.Scopes, []string{"users:read"})
+	})
+
+	t.Run("unregistered redirect uri", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		clientRepositoryMock.On("FindByClientId").Return(mockAppClient)
+
+		request := mockAuthorizeRequest()
+		request.RedirectURI = "https://evil.example.com/callback"
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, nil, nil, nil, nil, nil)
+		prompt, err := oauthUsecase.Authorize(request)
+
+		assert.Equal(t, prompt, nil)
+		assert.Equal(t, err, helper.StandardError{Error: usecase.ErrInvalidRedirectURI, ErrorCode: http.StatusBadRequest})
+	})
+
+	t.Run("registered script redirect uri", func(t *testing.T) {
+		scriptClient := mockAppClient
+		scriptClient.RedirectURIs = "javascript:alert(1)"
+
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		clientRepositoryMock.On("FindByClientId").Return(scriptClient)
+
+		request := mockAuthorizeRequest()
+		request.RedirectURI = "javascript:alert(1)"
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, nil, nil, nil, nil, nil)
+		prompt, err := oauthUsecase.Authorize(request)
+
+		assert.Equal(t, prompt, nil)
+		assert.Equal(t, err, helper.StandardError{Error: usecase.ErrInvalidRedirectURI, ErrorCode: http.StatusBadRequest})
+	})
+
+	t.Run("missing code challenge", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		clientRepositoryMock.On("FindByClientId").Return(mockAppClient)
+
+		request := mockAuthorizeRequest()
+		request.CodeChallenge = ""
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, nil, nil, nil, nil, nil)
+		prompt, err := oauthUsecase.Authorize(request)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, prompt.RedirectTo, "https://app.example.com/callback?error=invalid_request&state=xyz")
+	})
+
+	t.Run("plain code challenge method", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		clientRepositoryMock.On("FindByClientId").Return(mockAppClient)
+
+		request := mockAuthorizeRequest()
+		request.CodeChallengeMethod = "plain"
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, nil, nil, nil, nil, nil)
+		prompt, _ := oauthUsecase.Authorize(request)
+
+		assert.Equal(t, prompt.RedirectTo, "https://app.example.com/callback?error=invalid_request&state=xyz")
+	})
+}
+
+func TestApprove(t *testing.T) {
+	t.Run("test normal approve", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+		authUsecaseMock := new(mocks.AuthUsecaseMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockAppClient)
+		authUsecaseMock.On("Authenticate").Return(&mockUser, (*helper.StandardError)(nil))
+		oauthRepositoryMock.On("FindConsent").Return(repository.Consent{})
+		oauthRepositoryMock.On("SaveConsent").Return(repository.Consent{ID: 1})
+		oauthRepositoryMock.On("SaveAuthorizationCode", mock.Anything).Return(repository.AuthorizationCode{ID: 1})
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		sessionRepositoryMock.On("Save", mock.MatchedBy(func(session repository.Session) bool {
+			return session.UserID == mockUser.ID && session.Kind == repository.SessionKindToken && session.Method == usecase.SessionMethodOAuth && session.UserAgent == "browser"
+		})).Return(repository.Session{ID: 7, UserID: mockUser.ID})
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, oauthRepositoryMock, nil, sessionRepositoryMock, authUsecaseMock, auditUsecaseMock)
+		prompt, err := oauthUsecase.Approve(repository.AuthorizeDecision{AuthorizeRequest: mockAuthorizeRequest(), Username: "username", Password: "password", Approve: true, LoginContext: repository.LoginContext{UserAgent: "browser", OrganizationID: 2}})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, authUsecaseMock.OrganizationID, uint64(2))
+		assert.MatchRegex(t, prompt.RedirectTo, `^https://app\.example\.com/callback\?code=gum_ac_[A-Za-z0-9_-]+&state=xyz$`)
+
+		savedCode := oauthRepositoryMock.Calls[2].Arguments.Get(0).(repository.AuthorizationCode)
+		assert.Equal(t, savedCode.CodeChallenge, mockCodeChallenge)
+		assert.Equal(t, savedCode.UserID, mockUser.ID)
+		assert.Equal(t, savedCode.SessionID, uint64(7))
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		authUsecaseMock := new(mocks.AuthUsecaseMock)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockAppClient)
+		authUsecaseMock.On("Authenticate").Return(&mockUser, (*helper.StandardError)(nil))
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, nil, nil, nil, authUsecaseMock, nil)
+		prompt, err := oauthUsecase.Approve(repository.AuthorizeDecision{AuthorizeRequest: mockAuthorizeRequest(), Username: "username", Password: "password", Approve: false})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, prompt.RedirectTo, "https://app.example.com/callback?error=access_denied&state=xyz")
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		authUsecaseMock := new(mocks.AuthUsecaseMock)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockAppClient)
+		authUsecaseMock.On("Authenticate").Return((*repository.User)(nil), &helper.StandardError{Error: errors.New("wrong password"), ErrorCode: http.StatusUnauthorized})
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, nil, nil, nil, authUsecaseMock, nil)
+		prompt, err := oauthUsecase.Approve(repository.AuthorizeDecision{AuthorizeRequest: mockAuthorizeRequest(), Username: "username", Password: "wrong", Approve: true})
+
+		assert.Equal(t, prompt.RedirectTo, "")
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("wrong password"), ErrorCode: http.StatusUnauthorized})
+	})
+}
+
+func TestAuthorizationCodeGrant(t *testing.T) {
+	os.Setenv("SECRET", "testkey")
+
+	mockCode := repository.AuthorizationCode{
+		ID:            1,
+		ClientID:      "gum_client_app",
+		UserID:        100,
+		RedirectURI:   "https://app.example.com/callback",
+		Scope:         "users:read",
+		CodeChallenge: mockCodeChallenge,
+		SessionID:     7,
+		ExpiresAt:     time.Now().Add(time.Minute),
+	}
+	tokenRequest := repository.TokenRequest{
+		GrantType:    "authorization_code",
+		ClientID:     "gum_client_app",
+		Code:         "gum_ac_code",
+		RedirectURI:  "https://app.example.com/callback",
+		CodeVerifier: mockCodeVerifier,
+	}
+
+	t.Run("test normal exchange", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockAppClient)
+		oauthRepositoryMock.On("FindAuthorizationCodeByHash").Return(mockCode)
+		oauthRepositoryMock.On("MarkAuthorizationCodeUsed").Return(true)
+		oauthRepositoryMock.On("SaveRefreshToken").Return(repository.RefreshToken{ID: 1})
+		userRepositoryMock.On("FindById").Return(mockUser)
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		sessionRepositoryMock.On("FindById").Return(repository.Session{ID: 7, ExpiresAt: time.Now().Add(time.Hour)})
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, oauthRepositoryMock, userRepositoryMock, sessionRepositoryMock, nil, auditUsecaseMock)
+		token, err := oauthUsecase.Token(tokenRequest)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, token.Scope, "users:read")
+		assert.Equal(t, strings.HasPrefix(token.RefreshToken, "gum_rt_"), true)
+
+		assert.Equal(t, accessTokenClaims(token.AccessToken)["sid"], float64(7))
+	})
+
+	t.Run("wrong code verifier", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockAppClient)
+		oauthRepositoryMock.On("FindAuthorizationCodeByHash").Return(mockCode)
+		oauthRepositoryMock.On("MarkAuthorizationCodeUsed").Return(true)
+
+		request := tokenRequest
+		request.CodeVerifier = strings.Repeat("a", 43)
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, oauthRepositoryMock, nil, nil, nil, nil)
+		_, err := oauthUsecase.Token(request)
+
+		assert.Equal(t, err, helper.StandardError{Error: usecase.ErrInvalidGrant, ErrorCode: http.StatusBadRequest})
+	})
+
+	t.Run("reused code revokes issued tokens", func(t *testing.T) {
+		usedAt := time.Now()
+		usedCode := mockCode
+		usedCode.UsedAt = &usedAt
+
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockAppClient)
+		oauthRepositoryMock.On("FindAuthorizationCodeByHash").Return(usedCode)
+		oauthRepositoryMock.On("RevokeRefreshTokens").Return()
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, oauthRepositoryMock, nil, nil, nil, auditUsecaseMock)
+		_, err := oauthUsecase.Token(tokenRequest)
+
+		assert.Equal(t, err, helper.StandardError{Error: usecase.ErrInvalidGrant, ErrorCode: http.StatusBadRequest})
+		oauthRepositoryMock.AssertCalled(t, "RevokeRefreshTokens")
+	})
+}
+
+func accessTokenClaims(accessToken string) jwt.MapClaims {
+	claims := jwt.MapClaims{}
+	jwt.ParseWithClaims(accessToken, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(os.Getenv("SECRET")), nil
+	})
+	return claims
+}
+
+func TestRefreshTokenGrant(t *testing.T) {
+	os.Setenv("SECRET", "testkey")
+
+	mockRefreshToken := repository.RefreshToken{
+		ID:        1,
+		ClientID:  "gum_client_app",
+		UserID:    100,
+		Scope:     "users:read users:write",
+		SessionID: 7,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	tokenRequest := repository.TokenRequest{GrantType: "refresh_token", ClientID: "gum_client_app", RefreshToken: "gum_rt_token", Scope: "users:read"}
+
+	t.Run("test normal refresh with rotation", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockAppClient)
+		oauthRepositoryMock.On("FindRefreshTokenByHash").Return(mockRefreshToken)
+		oauthRepositoryMock.On("RevokeRefreshToken").Return(true)
+		oauthRepositoryMock.On("SaveRefreshToken").Return(repository.RefreshToken{ID: 2})
+		userRepositoryMock.On("FindById").Return(mockUser)
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		sessionRepositoryMock.On("FindById").Return(repository.Session{ID: 7, ExpiresAt: time.Now().Add(time.Hour)})
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, oauthRepositoryMock, userRepositoryMock, sessionRepositoryMock, nil, auditUsecaseMock)
+		token, err := oauthUsecase.Token(tokenRequest)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, token.Scope, "users:read")
+		assert.NotEqual(t, token.RefreshToken, "")
+		assert.Equal(t, accessTokenClaims(token.AccessToken)["sid"], float64(7))
+	})
+
+	t.Run("refresh token issued without a session", func(t *testing.T) {
+		legacy := mockRefreshToken
+		legacy.SessionID = 0
+
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockAppClient)
+		oauthRepositoryMock.On("FindRefreshTokenByHash").Return(legacy)
+		oauthRepositoryMock.On("RevokeRefreshToken").Return(true)
+		oauthRepositoryMock.On("SaveRefreshToken").Return(repository.RefreshToken{ID: 2})
+		userRepositoryMock.On("FindById").Return(mockUser)
+		sessionRepositoryMock.On("Save", mock.MatchedBy(func(session repository.Session) bool {
+			return session.UserID == mockUser.ID && session.Method == usecase.SessionMethodOAuth
+		})).Return(repository.Session{ID: 8, UserID: mockUser.ID})
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, oauthRepositoryMock, userRepositoryMock, sessionRepositoryMock, nil, auditUsecaseMock)
+		token, err := oauthUsecase.Token(tokenRequest)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, accessTokenClaims(token.AccessToken)["sid"], float64(8))
+	})
+
+	t.Run("refresh token of an ended session", func(t *testing.T) {
+		ended := mockRefreshToken
+		ended.SessionID = 7
+
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockAppClient)
+		oauthRepositoryMock.On("FindRefreshTokenByHash").Return(ended)
+		sessionRepositoryMock.On("FindById").Return(repository.Session{})
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, oauthRepositoryMock, nil, sessionRepositoryMock, nil, nil)
+		_, err := oauthUsecase.Token(tokenRequest)
+
+		assert.Equal(t, err, helper.StandardError{Error: usecase.ErrInvalidGrant, ErrorCode: http.StatusBadRequest})
+		oauthRepositoryMock.AssertNotCalled(t, "RevokeRefreshToken")
+	})
+
+	t.Run("revoked refresh token is treated as reuse", func(t *testing.T) {
+		revokedAt := time.Now()
+		revoked := mockRefreshToken
+		revoked.RevokedAt = &revokedAt
+
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockAppClient)
+		oauthRepositoryMock.On("FindRefreshTokenByHash").Return(revoked)
+		oauthRepositoryMock.On("RevokeRefreshTokens").Return()
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		sessionRepositoryMock.On("FindById").Return(repository.Session{ID: 7, ExpiresAt: time.Now().Add(time.Hour)})
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, oauthRepositoryMock, nil, sessionRepositoryMock, nil, auditUsecaseMock)
+		_, err := oauthUsecase.Token(tokenRequest)
+
+		assert.Equal(t, err, helper.StandardError{Error: usecase.ErrInvalidGrant, ErrorCode: http.StatusBadRequest})
+		oauthRepositoryMock.AssertCalled(t, "RevokeRefreshTokens")
+	})
+}
+
+func TestRevokeConsent(t *testing.T) {
+	t.Run("test normal revoke consent", func(t *testing.T) {
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		oauthRepositoryMock.On("FindConsentsByUserId").Return([]repository.Consent{{ID: 3, UserID: 100, ClientID: "gum_client_app"}})
+		oauthRepositoryMock.On("DeleteConsent").Return()
+		oauthRepositoryMock.On("RevokeRefreshTokens").Return()
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(nil, oauthRepositoryMock, nil, nil, nil, auditUsecaseMock)
+		consent, err := oauthUsecase.RevokeConsent(100, 3)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, consent.ClientID, "gum_client_app")
+		oauthRepositoryMock.AssertCalled(t, "RevokeRefreshTokens")
+	})
+
+	t.Run("negative: consent not found", func(t *testing.T) {
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+		oauthRepositoryMock.On("FindConsentsByUserId").Return([]repository.Consent{})
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(nil, oauthRepositoryMock, nil, nil, nil, nil)
+		consent, err := oauthUsecase.RevokeConsent(100, 3)
+
+		assert.Equal(t, consent, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("consent not found"), ErrorCode: http.StatusNotFound})
+	})
+}
+
+func TestIntrospect(t *testing.T) {
+	introspectRequest := repository.TokenHintRequest{Token: "access", ClientID: "gum_client_test", ClientSecret: "gum_cs_secret"}
+
+	t.Run("test normal introspect", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		authUsecaseMock := new(mocks.AuthUsecaseMock)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockClient)
+		authUsecaseMock.On("ParseToken", "access").Return(&repository.TokenInfo{UserID: 100, Username: "username", ClientID: "gum_client_app", Scopes: []string{"openid", "users:read"}, JTI: "jti", IssuedAt: 1, ExpiresAt: 2}, (*helper.StandardError)(nil))
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, nil, nil, nil, authUsecaseMock, nil)
+		introspection, err := oauthUsecase.Introspect(introspectRequest)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, *introspection, repository.IntrospectionResponse{
+			Active:    true,
+			Scope:     "openid users:read",
+			ClientID:  "gum_client_app",
+			Username:  "username",
+			TokenType: "Bearer",
+			ExpiresAt: 2,
+			IssuedAt:  1,
+			Subject:   "100",
+			JTI:       "jti",
+		})
+	})
+
+	t.Run("invalid token is inactive", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		authUsecaseMock := new(mocks.AuthUsecaseMock)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockClient)
+		authUsecaseMock.On("ParseToken", "access").Return((*repository.TokenInfo)(nil), &helper.StandardError{Error: errors.New("invalid or expired token"), ErrorCode: http.StatusUnauthorized})
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, nil, nil, nil, authUsecaseMock, nil)
+		introspection, err := oauthUsecase.Introspect(introspectRequest)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, *introspection, repository.IntrospectionResponse{Active: false})
+	})
+
+	t.Run("refresh token of another client is inactive", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockClient)
+		oauthRepositoryMock.On("FindRefreshTokenByHash").Return(repository.RefreshToken{ID: 1, ClientID: "gum_client_app", UserID: 100, ExpiresAt: time.Now().Add(time.Hour)})
+
+		request := introspectRequest
+		request.Token = "gum_rt_token"
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, oauthRepositoryMock, nil, nil, nil, nil)
+		introspection, _ := oauthUsecase.Introspect(request)
+
+		assert.Equal(t, introspection.Active, false)
+	})
+
+	t.Run("public clients cannot introspect", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		clientRepositoryMock.On("FindByClientId").Return(mockAppClient)
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, nil, nil, nil, nil, nil)
+		introspection, err := oauthUsecase.Introspect(repository.TokenHintRequest{Token: "access", ClientID: "gum_client_app"})
+
+		assert.Equal(t, introspection, nil)
+		assert.Equal(t, err, helper.StandardError{Error: usecase.ErrUnauthorizedClient, ErrorCode: http.StatusBadRequest})
+	})
+}
+
+func TestRevoke(t *testing.T) {
+	revokeRequest := repository.TokenHintRequest{Token: "access", ClientID: "gum_client_test", ClientSecret: "gum_cs_secret"}
+
+	t.Run("test normal revoke of access token", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+		authUsecaseMock := new(mocks.AuthUsecaseMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockClient)
+		authUsecaseMock.On("ParseToken", "access").Return(&repository.TokenInfo{ClientID: "gum_client_test", JTI: "jti", ExpiresAt: 1700000000}, (*helper.StandardError)(nil))
+		oauthRepositoryMock.On("SaveRevokedToken", repository.RevokedToken{JTI: "jti", ExpiresAt: time.Unix(1700000000, 0)}).Return()
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, oauthRepositoryMock, nil, nil, authUsecaseMock, auditUsecaseMock)
+		err := oauthUsecase.Revoke(revokeRequest)
+
+		assert.Equal(t, err, nil)
+		oauthRepositoryMock.AssertCalled(t, "SaveRevokedToken", repository.RevokedToken{JTI: "jti", ExpiresAt: time.Unix(1700000000, 0)})
+	})
+
+	t.Run("token issued to another client", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		authUsecaseMock := new(mocks.AuthUsecaseMock)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockClient)
+		authUsecaseMock.On("ParseToken", "access").Return(&repository.TokenInfo{ClientID: "gum_client_app", JTI: "jti"}, (*helper.StandardError)(nil))
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, nil, nil, nil, authUsecaseMock, nil)
+		err := oauthUsecase.Revoke(revokeRequest)
+
+		assert.Equal(t, err, helper.StandardError{Error: usecase.ErrUnauthorizedClient, ErrorCode: http.StatusBadRequest})
+	})
+
+	t.Run("invalid token is ignored", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		authUsecaseMock := new(mocks.AuthUsecaseMock)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockClient)
+		authUsecaseMock.On("ParseToken", "access").Return((*repository.TokenInfo)(nil), &helper.StandardError{Error: errors.New("invalid or expired token"), ErrorCode: http.StatusUnauthorized})
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, nil, nil, nil, authUsecaseMock, nil)
+		err := oauthUsecase.Revoke(revokeRequest)
+
+		assert.Equal(t, err, nil)
+	})
+
+	t.Run("test normal revoke of refresh token", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockClient)
+		oauthRepositoryMock.On("FindRefreshTokenByHash").Return(repository.RefreshToken{ID: 1, ClientID: "gum_client_test", UserID: 100})
+		oauthRepositoryMock.On("RevokeRefreshToken").Return(true)
+
+		request := revokeRequest
+		request.Token = "gum_rt_token"
+
+		oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, oauthRepositoryMock, nil, nil, nil, auditUsecaseMock)
+		err := oauthUsecase.Revoke(request)
+
+		assert.Equal(t, err, nil)
+		oauthRepositoryMock.AssertCalled(t, "RevokeRefreshToken")
+	})
+}
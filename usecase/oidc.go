@@ -0,0 +1,312 @@
+package usecase
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	defaultOIDCIssuer = "http://localhost:3000"
+	idTokenExpiry     = time.Hour
+	oidcKeyBits       = 2048
+)
+
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+)
+
+var oidcScopes = []string{ScopeOpenID, ScopeProfile, ScopeEmail}
+
+var (
+	oidcKeyOnce  sync.Once
+	oidcKey      *rsa.PrivateKey
+	oidcKeyError error
+)
+
+type OIDCUsecase interface {
+	Discovery() *repository.DiscoveryDocument
+	JWKS() (*repository.JSONWebKeySet, *helper.StandardError)
+	UserInfo(userId uint64, scopes []string) (*repository.UserInfo, *helper.StandardError)
+	Logout(logoutRequest repository.LogoutRequest) (*repository.LogoutResult, *helper.StandardError)
+}
+
+type OIDCUsecaseImpl struct {
+	UserRepository    repository.UserRepository
+	ClientRepository  repository.OAuthClientRepository
+	OAuthRepository   repository.OAuthRepository
+	SessionRepository repository.SessionRepository
+	AuditUsecase      AuditUsecase
+}
+
+func oidcIssuer() string {
+	issuer := os.Getenv("OIDC_ISSUER")
+	if issuer == "" {
+		return defaultOIDCIssuer
+	}
+	return strings.TrimSuffix(issuer, "/")
+}
+
+// developmentMode relaxes startup checks that would otherwise refuse throwaway configuration.
+func developmentMode() bool {
+	return os.Getenv("DEV_MODE") == "true"
+}
+
+// CheckOIDCSigningKey runs at startup. Without OIDC_SIGNING_KEY every restart signs with a new key and invalidates
+// the id tokens relying parties hold, so the ephemeral key is only accepted in development mode.
+func CheckOIDCSigningKey() error {
+	if os.Getenv("OIDC_SIGNING_KEY") == "" {
+		if !developmentMode() {
+			return errors.New("OIDC_SIGNING_KEY is not set, set DEV_MODE=true to sign with an ephemeral key")
+		}
+		log.Printf("WARNING: OIDC_SIGNING_KEY is not set, id tokens are signed with an ephemeral key that changes on every restart")
+	}
+
+	_, err := oidcSigningKey()
+	return err
+}
+
+func oidcSigningKey() (*rsa.PrivateKey, error) {
+	oidcKeyOnce.Do(func() {
+		encoded := os.Getenv("OIDC_SIGNING_KEY")
+		if encoded == "" {
+			oidcKey, oidcKeyError = rsa.GenerateKey(rand.Reader, oidcKeyBits)
+			return
+		}
+
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			oidcKeyError = errors.New("invalid oidc signing key encoding")
+			return
+		}
+
+		parsed, err := x509.ParsePKCS8PrivateKey(raw)
+		if err != nil {
+			oidcKey, oidcKeyError = x509.ParsePKCS1PrivateKey(raw)
+			return
+		}
+
+		rsaKey, ok := parsed.(*rsa.PrivateKey)
+		if !ok {
+			oidcKeyError = errors.New("oidc signing key must be an rsa key")
+			return
+		}
+		oidcKey = rsaKey
+	})
+	return oidcKey, oidcKeyError
+}
+
+func jsonWebKey(publicKey *rsa.PublicKey) repository.JSONWebKey {
+	modulus := base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+	exponent := base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+
+	// RFC 7638 thumbprint: members in lexicographic order, no whitespace
+	thumbprint := sha256.Sum256([]byte(fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, exponent, modulus)))
+
+	return repository.JSONWebKey{
+		KeyType:   "RSA",
+		Use:       "sig",
+		Algorithm: "RS256",
+		KeyID:     base64.RawURLEncoding.EncodeToString(thumbprint[:]),
+		Modulus:   modulus,
+		Exponent:  exponent,
+	}
+}
+
+func signIDToken(claims jwt.MapClaims) (string, error) {
+	signingKey, err := oidcSigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = jsonWebKey(&signingKey.PublicKey).KeyID
+	return token.SignedString(signingKey)
+}
+
+func parseIDToken(idToken string) (jwt.MapClaims, error) {
+	signingKey, err := oidcSigningKey()
+	if err != nil {
+		return nil, err
+	}
+
+	// an id_token_hint may already be expired, so only the signature and issuer are checked
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}), jwt.WithoutClaimsValidation())
+	claims := jwt.MapClaims{}
+	if _, err := parser.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		return &signingKey.PublicKey, nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if !claims.VerifyIssuer(oidcIssuer(), true) {
+		return nil, errors.New("unexpected issuer")
+	}
+
+	return claims, nil
+}
+
+func userInfoClaims(user repository.User, scopes []string) repository.UserInfo {
+	userInfo := repository.UserInfo{Subject: strconv.FormatUint(user.ID, 10)}
+
+	if scopes == nil || hasScope(scopes, ScopeProfile) {
+		userInfo.PreferredUsername = user.Username
+	}
+
+	if scopes == nil || hasScope(scopes, ScopeEmail) {
+		emailVerified := user.EmailVerified
+		userInfo.Email = user.Email
+		userInfo.EmailVerified = &emailVerified
+	}
+
+	return userInfo
+}
+
+func idTokenClaims(client repository.OAuthClient, user repository.User, scopes []string, nonce string, sessionId uint64) jwt.MapClaims {
+	now := time.Now()
+	userInfo := userInfoClaims(user, scopes)
+
+	claims := jwt.MapClaims{
+		"iss": oidcIssuer(),
+		"sub": userInfo.Subject,
+		"aud": client.ClientID,
+		"iat": now.Unix(),
+		"exp": now.Add(idTokenExpiry).Unix(),
+	}
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+	if sessionId != 0 {
+		claims["sid"] = strconv.FormatUint(sessionId, 10)
+	}
+	if userInfo.PreferredUsername != "" {
+		claims["preferred_username"] = userInfo.PreferredUsername
+	}
+	if userInfo.EmailVerified != nil {
+		claims["email"] = userInfo.Email
+		claims["email_verified"] = *userInfo.EmailVerified
+	}
+
+	return claims
+}
+
+func (t *OIDCUsecaseImpl) Discovery() *repository.DiscoveryDocument {
+	issuer := oidcIssuer()
+
+	return &repository.DiscoveryDocument{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             issuer + "/oauth/authorize",
+		TokenEndpoint:                     issuer + "/oauth/token",
+		UserInfoEndpoint:                  issuer + "/userinfo",
+		JWKSURI:                           issuer + "/.well-known/jwks.json",
+		EndSessionEndpoint:                issuer + "/oauth/logout",
+		IntrospectionEndpoint:             issuer + "/oauth/introspect",
+		RevocationEndpoint:                issuer + "/oauth/revoke",
+		DeviceAuthorizationEndpoint:       issuer + "/oauth/device/code",
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               supportedGrantTypes,
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{jwt.SigningMethodRS256.Alg()},
+		ScopesSupported:                   append(append([]string{}, oidcScopes...), clientScopes...),
+		ClaimsSupported:                   []string{"iss", "sub", "aud", "exp", "iat", "nonce", "sid", "preferred_username", "email", "email_verified"},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+	}
+}
+
+func (t *OIDCUsecaseImpl) JWKS() (*repository.JSONWebKeySet, *helper.StandardError) {
+	signingKey, err := oidcSigningKey()
+	if err != nil {
+		return nil, &helper.StandardError{Error: err, ErrorCode: http.StatusInternalServerError}
+	}
+
+	return &repository.JSONWebKeySet{Keys: []repository.JSONWebKey{jsonWebKey(&signingKey.PublicKey)}}, nil
+}
+
+func (t *OIDCUsecaseImpl) UserInfo(userId uint64, scopes []string) (*repository.UserInfo, *helper.StandardError) {
+	user := t.UserRepository.FindById(userId)
+
+	if user.ID == 0 {
+		return nil, &helper.StandardError{Error: errors.New("user not found"), ErrorCode: http.StatusNotFound}
+	}
+
+	userInfo := userInfoClaims(user, scopes)
+	return &userInfo, nil
+}
+
+func (t *OIDCUsecaseImpl) Logout(logoutRequest repository.LogoutRequest) (*repository.LogoutResult, *helper.StandardError) {
+	clientId := logoutRequest.ClientID
+	var userId, sessionId uint64
+
+	if logoutRequest.IDTokenHint != "" {
+		claims, err := parseIDToken(logoutRequest.IDTokenHint)
+		if err != nil {
+			return nil, &helper.StandardError{Error: errors.New("invalid id_token_hint"), ErrorCode: http.StatusBadRequest}
+		}
+
+		audience, _ := claims["aud"].(string)
+		if clientId != "" && clientId != audience {
+			return nil, &helper.StandardError{Error: errors.New("client_id does not match id_token_hint"), ErrorCode: http.StatusBadRequest}
+		}
+		clientId = audience
+
+		subject, _ := claims["sub"].(string)
+		userId, _ = strconv.ParseUint(subject, 10, 64)
+		sid, _ := claims["sid"].(string)
+		sessionId, _ = strconv.ParseUint(sid, 10, 64)
+	}
+
+	var client repository.OAuthClient
+	if clientId != "" {
+		client = t.ClientRepository.FindByClientId(clientId)
+	}
+
+	result := repository.LogoutResult{}
+	if logoutRequest.PostLogoutRedirectURI != "" {
+		if client.ID == 0 || !hasScope(strings.Fields(client.PostLogoutRedirectURIs), logoutRequest.PostLogoutRedirectURI) {
+			return nil, &helper.StandardError{Error: errors.New("invalid post_logout_redirect_uri"), ErrorCode: http.StatusBadRequest}
+		}
+		result.RedirectTo = withQuery(logoutRequest.PostLogoutRedirectURI, url.Values{"state": {logoutRequest.State}})
+	}
+
+	if userId != 0 && client.ID != 0 {
+		t.OAuthRepository.RevokeRefreshTokens(userId, client.ClientID)
+
+		// The sign-in behind the id token ends too, so other clients it approved lose their refresh tokens.
+		if sessionId != 0 && t.SessionRepository.FindById(sessionId).UserID == userId {
+			t.SessionRepository.Delete(sessionId)
+		}
+		t.AuditUsecase.Record("oauth.logout", userId, userId, fmt.Sprintf("client_id=%s session_id=%d", client.ClientID, sessionId))
+	}
+
+	return &result, nil
+}
+
+func NewOIDCUsecaseImpl(userRepository repository.UserRepository, clientRepository repository.OAuthClientRepository, oauthRepository repository.OAuthRepository, sessionRepository repository.SessionRepository, auditUsecase AuditUsecase) OIDCUsecase {
+	return &OIDCUsecaseImpl{
+		UserRepository:    userRepository,
+		ClientRepository:  clientRepository,
+		OAuthRepository:   oauthRepository,
+		SessionRepository: sessionRepository,
+		AuditUsecase:      auditUsecase,
+	}
+}
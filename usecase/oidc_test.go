@@ -0,0 +1,266 @@
+package usecase_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var mockOIDCClient = repository.OAuthClient{
+	ID:                     3,
+	ClientID:               "gum_client_spa",
+	Name:                   "spa",
+	Scopes:                 "users:read",
+	GrantTypes:             "authorization_code",
+	RedirectURIs:           "https://spa.example.com/callback",
+	PostLogoutRedirectURIs: "https://spa.example.com/",
+	Public:                 true,
+}
+
+func issueIDToken(t *testing.T, scope string, nonce string, sessionId uint64) string {
+	clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+	oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+	userRepositoryMock := new(mocks.UserRepositoryMock)
+	auditUsecaseMock := new(mocks.AuditUsecaseMock)
+	auditUsecaseMock.On("Record").Return(nil)
+
+	clientRepositoryMock.On("FindByClientId").Return(mockOIDCClient)
+	oauthRepositoryMock.On("FindAuthorizationCodeByHash").Return(repository.AuthorizationCode{
+		ID:            1,
+		ClientID:      mockOIDCClient.ClientID,
+		UserID:        mockUser.ID,
+		RedirectURI:   "https://spa.example.com/callback",
+		Scope:         scope,
+		CodeChallenge: mockCodeChallenge,
+		Nonce:         nonce,
+		SessionID:     sessionId,
+		ExpiresAt:     time.Now().Add(time.Minute),
+	})
+	oauthRepositoryMock.On("MarkAuthorizationCodeUsed").Return(true)
+	userRepositoryMock.On("FindById").Return(mockUser)
+	sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+	sessionRepositoryMock.On("FindById").Return(repository.Session{ID: sessionId, UserID: mockUser.ID, ExpiresAt: time.Now().Add(time.Hour)})
+
+	oauthUsecase := usecase.NewOAuthUsecaseImpl(clientRepositoryMock, oauthRepositoryMock, userRepositoryMock, sessionRepositoryMock, nil, auditUsecaseMock)
+	token, err := oauthUsecase.Token(repository.TokenRequest{
+		GrantType:    "authorization_code",
+		ClientID:     mockOIDCClient.ClientID,
+		Code:         "gum_ac_code",
+		RedirectURI:  "https://spa.example.com/callback",
+		CodeVerifier: mockCodeVerifier,
+	})
+
+	assert.Equal(t, err, nil)
+	return token.IDToken
+}
+
+func parseWithKeySet(t *testing.T, idToken string) jwt.MapClaims {
+	keySet, err := usecase.NewOIDCUsecaseImpl(nil, nil, nil, nil, nil).JWKS()
+	assert.Equal(t, err, nil)
+
+	key := keySet.Keys[0]
+	modulus, _ := base64.RawURLEncoding.DecodeString(key.Modulus)
+	exponent, _ := base64.RawURLEncoding.DecodeString(key.Exponent)
+	publicKey := &rsa.PublicKey{N: new(big.Int).SetBytes(modulus), E: int(new(big.Int).SetBytes(exponent).Int64())}
+
+	claims := jwt.MapClaims{}
+	token, parseError := jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		assert.Equal(t, token.Header["kid"], key.KeyID)
+		return publicKey, nil
+	})
+
+	assert.Equal(t, parseError, nil)
+	assert.Equal(t, token.Valid, true)
+	return claims
+}
+
+func TestDiscovery(t *testing.T) {
+	os.Setenv("OIDC_ISSUER", "https://id.example.com/")
+	defer os.Unsetenv("OIDC_ISSUER")
+
+	document := usecase.NewOIDCUsecaseImpl(nil, nil, nil, nil, nil).Discovery()
+
+	assert.Equal(t, document.Issuer, "https://id.example.com")
+	assert.Equal(t, document.JWKSURI, "https://id.example.com/.well-known/jwks.json")
+	assert.Equal(t, document.UserInfoEndpoint, "https://id.example.com/userinfo")
+	assert.Equal(t, document.IDTokenSigningAlgValuesSupported, []string{"RS256"})
+	assert.Equal(t, document.CodeChallengeMethodsSupported, []string{"S256"})
+}
+
+func TestCheckOIDCSigningKey(t *testing.T) {
+	t.Run("missing key outside development mode", func(t *testing.T) {
+		os.Unsetenv("DEV_MODE")
+
+		assert.Equal(t, usecase.CheckOIDCSigningKey(), errors.New("OIDC_SIGNING_KEY is not set, set DEV_MODE=true to sign with an ephemeral key"))
+	})
+
+	t.Run("ephemeral key in development mode", func(t *testing.T) {
+		os.Setenv("DEV_MODE", "true")
+		defer os.Unsetenv("DEV_MODE")
+
+		assert.Equal(t, usecase.CheckOIDCSigningKey(), nil)
+	})
+}
+
+func TestIDToken(t *testing.T) {
+	os.Setenv("SECRET", "testkey")
+
+	t.Run("test id token with standard claims and nonce", func(t *testing.T) {
+		claims := parseWithKeySet(t, issueIDToken(t, "openid profile email", "n-0S6_WzA2Mj", 7))
+
+		assert.Equal(t, claims["iss"], "http://localhost:3000")
+		assert.Equal(t, claims["sub"], "100")
+		assert.Equal(t, claims["aud"], "gum_client_spa")
+		assert.Equal(t, claims["nonce"], "n-0S6_WzA2Mj")
+		assert.Equal(t, claims["sid"], "7")
+		assert.Equal(t, claims["preferred_username"], "username")
+		assert.Equal(t, claims["email"], "test@mail.com")
+		assert.Equal(t, claims["email_verified"], false)
+	})
+
+	t.Run("claims follow the granted scopes", func(t *testing.T) {
+		claims := parseWithKeySet(t, issueIDToken(t, "openid", "", 7))
+
+		assert.Equal(t, claims["sub"], "100")
+		assert.Equal(t, claims["nonce"], nil)
+		assert.Equal(t, claims["preferred_username"], nil)
+		assert.Equal(t, claims["email"], nil)
+	})
+
+	t.Run("no id token without openid scope", func(t *testing.T) {
+		assert.Equal(t, issueIDToken(t, "users:read", "nonce", 7), "")
+	})
+}
+
+func TestUserInfo(t *testing.T) {
+	t.Run("test normal user info", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		userRepositoryMock.On("FindById").Return(mockUser)
+
+		oidcUsecase := usecase.NewOIDCUsecaseImpl(userRepositoryMock, nil, nil, nil, nil)
+		userInfo, err := oidcUsecase.UserInfo(100, []string{"openid", "email"})
+
+		emailVerified := false
+		assert.Equal(t, err, nil)
+		assert.Equal(t, *userInfo, repository.UserInfo{Subject: "100", Email: "test@mail.com", EmailVerified: &emailVerified})
+	})
+
+	t.Run("negative: user not found", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		userRepositoryMock.On("FindById").Return(repository.User{})
+
+		oidcUsecase := usecase.NewOIDCUsecaseImpl(userRepositoryMock, nil, nil, nil, nil)
+		userInfo, err := oidcUsecase.UserInfo(100, []string{"openid"})
+
+		assert.Equal(t, userInfo, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("user not found"), ErrorCode: http.StatusNotFound})
+	})
+}
+
+func TestLogout(t *testing.T) {
+	os.Setenv("SECRET", "testkey")
+
+	t.Run("test normal logout with redirect", func(t *testing.T) {
+		idToken := issueIDToken(t, "openid", "", 7)
+
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockOIDCClient)
+		oauthRepositoryMock.On("RevokeRefreshTokens").Return()
+		sessionRepositoryMock.On("FindById").Return(repository.Session{ID: 7, UserID: mockUser.ID})
+		sessionRepositoryMock.On("Delete").Return(true)
+
+		oidcUsecase := usecase.NewOIDCUsecaseImpl(nil, clientRepositoryMock, oauthRepositoryMock, sessionRepositoryMock, auditUsecaseMock)
+		result, err := oidcUsecase.Logout(repository.LogoutRequest{IDTokenHint: idToken, PostLogoutRedirectURI: "https://spa.example.com/", State: "abc"})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, result.RedirectTo, "https://spa.example.com/?state=abc")
+		oauthRepositoryMock.AssertCalled(t, "RevokeRefreshTokens")
+	})
+
+	t.Run("logout ends the session of the id token", func(t *testing.T) {
+		idToken := issueIDToken(t, "openid", "", 7)
+
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockOIDCClient)
+		oauthRepositoryMock.On("RevokeRefreshTokens").Return()
+		sessionRepositoryMock.On("FindById").Return(repository.Session{ID: 7, UserID: mockUser.ID})
+		sessionRepositoryMock.On("Delete").Return(true)
+
+		oidcUsecase := usecase.NewOIDCUsecaseImpl(nil, clientRepositoryMock, oauthRepositoryMock, sessionRepositoryMock, auditUsecaseMock)
+		_, err := oidcUsecase.Logout(repository.LogoutRequest{IDTokenHint: idToken})
+
+		assert.Equal(t, err, nil)
+		sessionRepositoryMock.AssertCalled(t, "Delete")
+	})
+
+	t.Run("session of another user is kept", func(t *testing.T) {
+		idToken := issueIDToken(t, "openid", "", 7)
+
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		oauthRepositoryMock := new(mocks.OAuthRepositoryMock)
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		clientRepositoryMock.On("FindByClientId").Return(mockOIDCClient)
+		oauthRepositoryMock.On("RevokeRefreshTokens").Return()
+		sessionRepositoryMock.On("FindById").Return(repository.Session{ID: 7, UserID: 200})
+
+		oidcUsecase := usecase.NewOIDCUsecaseImpl(nil, clientRepositoryMock, oauthRepositoryMock, sessionRepositoryMock, auditUsecaseMock)
+		_, err := oidcUsecase.Logout(repository.LogoutRequest{IDTokenHint: idToken})
+
+		assert.Equal(t, err, nil)
+		sessionRepositoryMock.AssertNotCalled(t, "Delete")
+	})
+
+	t.Run("unregistered post logout redirect uri", func(t *testing.T) {
+		clientRepositoryMock := new(mocks.OAuthClientRepositoryMock)
+		clientRepositoryMock.On("FindByClientId").Return(mockOIDCClient)
+
+		oidcUsecase := usecase.NewOIDCUsecaseImpl(nil, clientRepositoryMock, nil, nil, nil)
+		result, err := oidcUsecase.Logout(repository.LogoutRequest{ClientID: "gum_client_spa", PostLogoutRedirectURI: "https://evil.example.com/"})
+
+		assert.Equal(t, result, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("invalid post_logout_redirect_uri"), ErrorCode: http.StatusBadRequest})
+	})
+
+	t.Run("id token hint signed with another key", func(t *testing.T) {
+		forged, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"iss": "http://localhost:3000", "sub": "100", "aud": "gum_client_spa"}).SignedString([]byte("testkey"))
+
+		oidcUsecase := usecase.NewOIDCUsecaseImpl(nil, nil, nil, nil, nil)
+		result, err := oidcUsecase.Logout(repository.LogoutRequest{IDTokenHint: forged})
+
+		assert.Equal(t, result, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("invalid id_token_hint"), ErrorCode: http.StatusBadRequest})
+	})
+
+	t.Run("logout without hint shows signed out page", func(t *testing.T) {
+		oidcUsecase := usecase.NewOIDCUsecaseImpl(nil, nil, nil, nil, nil)
+		result, err := oidcUsecase.Logout(repository.LogoutRequest{})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, result.RedirectTo, "")
+	})
+}
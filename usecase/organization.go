@@ -0,0 +1,272 @@
+package usecase
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	OrganizationHeader        = "X-Organization"
+	SessionMethodOrganization = "organization_switch"
+)
+
+type OrganizationUsecase interface {
+	ResolveOrganization(c *gin.Context)
+	RequireAdmin(c *gin.Context)
+	RequireOpenRegistration(c *gin.Context)
+	CreateOrganization(userId uint64, createData repository.CreateOrganization) (*repository.Organization, *helper.StandardError)
+	ListOrganizations(userId uint64) (*[]repository.OrganizationMembership, *helper.StandardError)
+	ListMembers(organizationId uint64, actorId uint64) (*[]repository.Membership, *helper.StandardError)
+	SetMember(organizationId uint64, userId uint64, membershipData repository.SetMembership, actorId uint64) (*repository.Membership, *helper.StandardError)
+	RemoveMember(organizationId uint64, userId uint64, actorId uint64) *helper.StandardError
+	SwitchOrganization(organizationId uint64, userId uint64, loginContext repository.LoginContext) (string, *helper.StandardError)
+}
+
+type OrganizationUsecaseImpl struct {
+	OrganizationRepository repository.OrganizationRepository
+	UserRepository         repository.UserRepository
+	SessionRepository      repository.SessionRepository
+	AuditUsecase           AuditUsecase
+}
+
+var (
+	errOrganizationNotFound = &helper.StandardError{Error: errors.New("organization not found"), ErrorCode: http.StatusNotFound}
+	errNotOrganizationAdmin = &helper.StandardError{Error: errors.New("organization admin role required"), ErrorCode: http.StatusForbidden}
+)
+
+// organizationOrDefault maps users and sessions stored before organizations existed to the default organization.
+func organizationOrDefault(organizationId uint64) uint64 {
+	if organizationId == 0 {
+		return repository.DefaultOrganizationID
+	}
+	return organizationId
+}
+
+// setCurrentOrganization binds the request to the organization of its credentials, rejecting
+// requests that explicitly asked for a different one.
+func setCurrentOrganization(c *gin.Context, organizationId uint64) bool {
+	if requested, ok := c.Get("requestedOrganizationId"); ok && requested != organizationId {
+		c.JSON(http.StatusForbidden, gin.H{"error": "credentials are not valid for this organization"})
+		c.AbortWithStatus(http.StatusForbidden)
+		return false
+	}
+
+	c.Set("currentOrganizationId", organizationId)
+	return true
+}
+
+// subdomainOrganization returns "acme" for acme.example.com when ORGANIZATION_DOMAIN is example.com.
+func subdomainOrganization(host string) string {
+	domain := strings.ToLower(strings.Trim(envOrDefault("ORGANIZATION_DOMAIN", ""), "."))
+	if domain == "" {
+		return ""
+	}
+
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+
+	label := strings.TrimSuffix(strings.ToLower(host), "."+domain)
+	if label == strings.ToLower(host) || label == "" || strings.Contains(label, ".") {
+		return ""
+	}
+	return label
+}
+
+// ResolveOrganization selects the tenant of a request from the X-Organization header or the subdomain.
+// Requests naming neither use the default organization; authenticated requests are later bound to the
+// organization their credentials were issued for.
+func (t *OrganizationUsecaseImpl) ResolveOrganization(c *gin.Context) {
+	slug := strings.ToLower(strings.TrimSpace(c.GetHeader(OrganizationHeader)))
+	if slug == "" {
+		slug = subdomainOrganization(c.Request.Host)
+	}
+
+	if slug == "" {
+		c.Set("currentOrganizationId", repository.DefaultOrganizationID)
+		c.Next()
+		return
+	}
+
+	organization := t.OrganizationRepository.FindBySlug(slug)
+	if organization.ID == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "organization not found"})
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	c.Set("currentOrganizationId", organization.ID)
+	c.Set("requestedOrganizationId", organization.ID)
+	c.Next()
+}
+
+// RequireOpenRegistration lets anyone register in the default organization, but in other organizations only
+// when they allow it; everyone else joins them through an invitation.
+func (t *OrganizationUsecaseImpl) RequireOpenRegistration(c *gin.Context) {
+	organizationId, _ := c.Value("currentOrganizationId").(uint64)
+	organizationId = organizationOrDefault(organizationId)
+	if organizationId != repository.DefaultOrganizationID && !t.OrganizationRepository.FindById(organizationId).AllowRegistration {
+		c.JSON(http.StatusForbidden, gin.H{"error": "registration is closed for this organization, ask an admin for an invitation"})
+		c.Abort()
+		return
+	}
+
+	c.Next()
+}
+
+func (t *OrganizationUsecaseImpl) CreateOrganization(userId uint64, createData repository.CreateOrganization) (*repository.Organization, *helper.StandardError) {
+	if t.OrganizationRepository.FindBySlug(createData.Slug).ID != 0 {
+		return nil, &helper.StandardError{Error: errors.New("organization already exists"), ErrorCode: http.StatusConflict}
+	}
+
+	organization := t.OrganizationRepository.Save(repository.Organization{Slug: createData.Slug, Name: createData.Name, AllowRegistration: createData.AllowRegistration})
+	if organization.ID == 0 {
+		return nil, &helper.StandardError{Error: errors.New("failed to create organization"), ErrorCode: http.StatusInternalServerError}
+	}
+
+	t.OrganizationRepository.SaveMembership(repository.Membership{OrganizationID: organization.ID, UserID: userId, Role: repository.OrganizationRoleAdmin})
+	t.AuditUsecase.Record("organization.create", userId, userId, fmt.Sprintf("organization_id=%d slug=%s", organization.ID, organization.Slug))
+
+	return &organization, nil
+}
+
+func (t *OrganizationUsecaseImpl) ListOrganizations(userId uint64) (*[]repository.OrganizationMembership, *helper.StandardError) {
+	organizations := t.OrganizationRepository.FindByUserId(userId)
+	if organizations == nil {
+		organizations = []repository.OrganizationMembership{}
+	}
+	return &organizations, nil
+}
+
+// requireAdmin accepts admin members of the organization and its own users holding the admin role, which is how
+// the default organization has admins before anyone was made a member of it.
+func (t *OrganizationUsecaseImpl) requireAdmin(organizationId uint64, actorId uint64) *helper.StandardError {
+	if t.OrganizationRepository.FindById(organizationId).ID == 0 {
+		return errOrganizationNotFound
+	}
+	if t.OrganizationRepository.FindMembership(organizationId, actorId).Role == repository.OrganizationRoleAdmin {
+		return nil
+	}
+	if user := t.UserRepository.ForOrganization(organizationId).FindById(actorId); user.ID != 0 && hasScope(strings.Fields(user.Roles), repository.OrganizationRoleAdmin) {
+		return nil
+	}
+	return errNotOrganizationAdmin
+}
+
+// RequireAdmin guards routes that manage resources of the whole current organization, such as OAuth clients,
+// so they stay limited to its admins even without POLICY_FILE.
+func (t *OrganizationUsecaseImpl) RequireAdmin(c *gin.Context) {
+	currentUserId, _ := c.Value("currentUserId").(uint64)
+	organizationId, _ := c.Value("currentOrganizationId").(uint64)
+	if err := t.requireAdmin(organizationOrDefault(organizationId), currentUserId); err != nil {
+		c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+		c.Abort()
+		return
+	}
+
+	c.Next()
+}
+
+func (t *OrganizationUsecaseImpl) ListMembers(organizationId uint64, actorId uint64) (*[]repository.Membership, *helper.StandardError) {
+	if err := t.requireAdmin(organizationId, actorId); err != nil {
+		return nil, err
+	}
+
+	members := t.OrganizationRepository.FindMemberships(organizationId)
+	if members == nil {
+		members = []repository.Membership{}
+	}
+	return &members, nil
+}
+
+func (t *OrganizationUsecaseImpl) SetMember(organizationId uint64, userId uint64, membershipData repository.SetMembership, actorId uint64) (*repository.Membership, *helper.StandardError) {
+	if err := t.requireAdmin(organizationId, actorId); err != nil {
+		return nil, err
+	}
+
+	// Admins cannot demote themselves, so an organization always keeps the admin that created it.
+	if userId == actorId {
+		return nil, &helper.StandardError{Error: errors.New("cannot change own membership"), ErrorCode: http.StatusBadRequest}
+	}
+
+	if !t.canEnroll(organizationId, userId, actorId) {
+		return nil, &helper.StandardError{Error: errors.New("user not found"), ErrorCode: http.StatusNotFound}
+	}
+
+	membership := t.OrganizationRepository.SaveMembership(repository.Membership{OrganizationID: organizationId, UserID: userId, Role: membershipData.Role})
+	t.AuditUsecase.Record("organization.member_set", actorId, userId, fmt.Sprintf("organization_id=%d role=%s", organizationId, membership.Role))
+
+	return &membership, nil
+}
+
+// canEnroll reports whether the admin may give the user a role in the organization: users of the organization and
+// existing members can always be changed, users of other organizations only by an admin of their organization too.
+func (t *OrganizationUsecaseImpl) canEnroll(organizationId uint64, userId uint64, actorId uint64) bool {
+	if t.UserRepository.ForOrganization(organizationId).FindById(userId).ID != 0 || t.OrganizationRepository.FindMembership(organizationId, userId).ID != 0 {
+		return true
+	}
+
+	user := t.UserRepository.FindById(userId)
+	return user.ID != 0 && t.requireAdmin(organizationOrDefault(user.OrganizationID), actorId) == nil
+}
+
+// RemoveMember also ends the user's sessions in the organization, so access stops immediately.
+func (t *OrganizationUsecaseImpl) RemoveMember(organizationId uint64, userId uint64, actorId uint64) *helper.StandardError {
+	if err := t.requireAdmin(organizationId, actorId); err != nil {
+		return err
+	}
+
+	if userId == actorId {
+		return &helper.StandardError{Error: errors.New("cannot change own membership"), ErrorCode: http.StatusBadRequest}
+	}
+
+	if !t.OrganizationRepository.DeleteMembership(organizationId, userId) {
+		return &helper.StandardError{Error: errors.New("membership not found"), ErrorCode: http.StatusNotFound}
+	}
+
+	revoked := t.SessionRepository.DeleteByUserIdAndOrganizationId(userId, organizationId)
+	t.AuditUsecase.Record("organization.member_remove", actorId, userId, fmt.Sprintf("organization_id=%d sessions_revoked=%d", organizationId, revoked))
+
+	return nil
+}
+
+// SwitchOrganization issues a login token bound to another organization the user belongs to.
+func (t *OrganizationUsecaseImpl) SwitchOrganization(organizationId uint64, userId uint64, loginContext repository.LoginContext) (string, *helper.StandardError) {
+	if t.OrganizationRepository.FindById(organizationId).ID == 0 {
+		return "", errOrganizationNotFound
+	}
+
+	user := t.UserRepository.FindById(userId)
+	if user.ID == 0 || user.DisabledAt != nil {
+		return "", &helper.StandardError{Error: errors.New("user not found"), ErrorCode: http.StatusNotFound}
+	}
+
+	if organizationOrDefault(user.OrganizationID) != organizationId && t.OrganizationRepository.FindMembership(organizationId, userId).ID == 0 {
+		return "", &helper.StandardError{Error: errors.New("not a member of this organization"), ErrorCode: http.StatusForbidden}
+	}
+
+	token, session, loginError := startOrganizationSession(t.SessionRepository, user, organizationId, SessionMethodOrganization, loginContext, nil)
+	if loginError != nil {
+		return "", loginError
+	}
+
+	t.AuditUsecase.Record("organization.switch", userId, userId, fmt.Sprintf("organization_id=%d session_id=%d", organizationId, session.ID))
+
+	return token, nil
+}
+
+func NewOrganizationUsecaseImpl(organizationRepository repository.OrganizationRepository, userRepository repository.UserRepository, sessionRepository repository.SessionRepository, auditUsecase AuditUsecase) OrganizationUsecase {
+	return &OrganizationUsecaseImpl{
+		OrganizationRepository: organizationRepository,
+		UserRepository:         userRepository,
+		SessionRepository:      sessionRepository,
+		AuditUsecase:           auditUsecase,
+	}
+}
@@ -0,0 +1,378 @@
+package usecase_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/mock"
+)
+
+var acmeOrganization = repository.Organization{ID: 2, Slug: "acme", Name: "Acme"}
+
+func TestResolveOrganization(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	organizationRepositoryMock := new(mocks.OrganizationRepositoryMock)
+	organizationRepositoryMock.On("FindBySlug", "acme").Return(acmeOrganization)
+	organizationRepositoryMock.On("FindBySlug", "unknown").Return(repository.Organization{})
+
+	organizationUsecase := usecase.NewOrganizationUsecaseImpl(organizationRepositoryMock, nil, nil, nil)
+	router := gin.Default()
+	router.Use(organizationUsecase.ResolveOrganization)
+	router.GET("/test", func(c *gin.Context) {
+		_, requested := c.Get("requestedOrganizationId")
+		c.JSON(http.StatusOK, gin.H{"organization": c.MustGet("currentOrganizationId"), "requested": requested})
+	})
+
+	resolve := func(host string, header string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "http://"+host+"/test", nil)
+		if header != "" {
+			req.Header.Set(usecase.OrganizationHeader, header)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("default organization", func(t *testing.T) {
+		w := resolve("example.com", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, w.Body.String(), `{"organization":1,"requested":false}`)
+	})
+
+	t.Run("header", func(t *testing.T) {
+		w := resolve("example.com", "Acme")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, w.Body.String(), `{"organization":2,"requested":true}`)
+	})
+
+	t.Run("subdomain", func(t *testing.T) {
+		os.Setenv("ORGANIZATION_DOMAIN", "example.com")
+		defer os.Unsetenv("ORGANIZATION_DOMAIN")
+
+		assert.Equal(t, resolve("acme.example.com:8080", "").Body.String(), `{"organization":2,"requested":true}`)
+		assert.Equal(t, resolve("example.com", "").Body.String(), `{"organization":1,"requested":false}`)
+		assert.Equal(t, resolve("a.b.example.com", "").Body.String(), `{"organization":1,"requested":false}`)
+	})
+
+	t.Run("unknown organization", func(t *testing.T) {
+		w := resolve("example.com", "unknown")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "organization not found")
+	})
+}
+
+func TestValidateTokenOrganization(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	os.Setenv("SECRET", "testkey")
+
+	userRepositoryMock := new(mocks.UserRepositoryMock)
+	userRepositoryMock.On("FindById").Return(mockUser)
+	sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+	sessionRepositoryMock.On("FindById").Return(repository.Session{ID: 5, UserID: mockUser.ID, LastSeenAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)})
+	authUsecase := usecase.NewAuthUsecaseImpl(userRepositoryMock, nil, nil, nil, nil, nil, sessionRepositoryMock, nil, nil)
+
+	tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"id":       mockUser.ID,
+		"username": "username",
+		"sid":      5,
+		"exp":      float64(time.Now().Add(time.Hour).Unix()),
+	}).SignedString([]byte("testkey"))
+
+	request := func(requestedOrganizationId uint64) *httptest.ResponseRecorder {
+		router := gin.Default()
+		router.GET("/test", func(c *gin.Context) {
+			if requestedOrganizationId != 0 {
+				c.Set("requestedOrganizationId", requestedOrganizationId)
+			}
+		}, authUsecase.ValidateToken, func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"organization": c.MustGet("currentOrganizationId")})
+		})
+
+		req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Add("Authorization", "Bearer "+tokenString)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("token organization", func(t *testing.T) {
+		w := request(0)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, w.Body.String(), `{"organization":1}`)
+	})
+
+	t.Run("another organization", func(t *testing.T) {
+		w := request(2)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.MatchRegex(t, w.Body.String(), "credentials are not valid for this organization")
+	})
+}
+
+func TestCreateOrganization(t *testing.T) {
+	t.Run("test creator becomes admin", func(t *testing.T) {
+		organizationRepositoryMock := new(mocks.OrganizationRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		organizationRepositoryMock.On("FindBySlug", "acme").Return(repository.Organization{})
+		organizationRepositoryMock.On("Save", repository.Organization{Slug: "acme", Name: "Acme"}).Return(acmeOrganization)
+		organizationRepositoryMock.On("SaveMembership", repository.Membership{OrganizationID: 2, UserID: 100, Role: repository.OrganizationRoleAdmin}).Return(repository.Membership{ID: 1})
+		auditUsecaseMock.On("Record").Return(nil)
+
+		organizationUsecase := usecase.NewOrganizationUsecaseImpl(organizationRepositoryMock, nil, nil, auditUsecaseMock)
+		organization, err := organizationUsecase.CreateOrganization(100, repository.CreateOrganization{Slug: "acme", Name: "Acme"})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, organization, &acmeOrganization)
+		organizationRepositoryMock.AssertExpectations(t)
+	})
+
+	t.Run("slug taken", func(t *testing.T) {
+		organizationRepositoryMock := new(mocks.OrganizationRepositoryMock)
+		organizationRepositoryMock.On("FindBySlug", "acme").Return(acmeOrganization)
+
+		organizationUsecase := usecase.NewOrganizationUsecaseImpl(organizationRepositoryMock, nil, nil, nil)
+		organization, err := organizationUsecase.CreateOrganization(100, repository.CreateOrganization{Slug: "acme", Name: "Acme"})
+
+		assert.Equal(t, organization, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("organization already exists"), ErrorCode: http.StatusConflict})
+	})
+}
+
+func TestSetMember(t *testing.T) {
+	t.Run("test admin adds a member", func(t *testing.T) {
+		organizationRepositoryMock := new(mocks.OrganizationRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		organizationRepositoryMock.On("FindById", uint64(2)).Return(acmeOrganization)
+		organizationRepositoryMock.On("FindMembership", uint64(2), uint64(100)).Return(repository.Membership{ID: 1, Role: repository.OrganizationRoleAdmin})
+		organizationRepositoryMock.On("SaveMembership", repository.Membership{OrganizationID: 2, UserID: 101, Role: repository.OrganizationRoleMember}).Return(repository.Membership{ID: 2, OrganizationID: 2, UserID: 101, Role: repository.OrganizationRoleMember})
+		userRepositoryMock.On("FindById").Return(repository.User{ID: 101})
+		auditUsecaseMock.On("Record").Return(nil)
+
+		organizationUsecase := usecase.NewOrganizationUsecaseImpl(organizationRepositoryMock, userRepositoryMock, nil, auditUsecaseMock)
+		membership, err := organizationUsecase.SetMember(2, 101, repository.SetMembership{Role: repository.OrganizationRoleMember}, 100)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, membership.ID, uint64(2))
+	})
+
+	t.Run("not an admin", func(t *testing.T) {
+		organizationRepositoryMock := new(mocks.OrganizationRepositoryMock)
+		organizationRepositoryMock.On("FindById", uint64(2)).Return(acmeOrganization)
+		organizationRepositoryMock.On("FindMembership", uint64(2), uint64(100)).Return(repository.Membership{ID: 1, Role: repository.OrganizationRoleMember})
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		userRepositoryMock.On("FindById").Return(repository.User{ID: 100, Roles: "auditor"})
+
+		organizationUsecase := usecase.NewOrganizationUsecaseImpl(organizationRepositoryMock, userRepositoryMock, nil, nil)
+		membership, err := organizationUsecase.SetMember(2, 101, repository.SetMembership{Role: repository.OrganizationRoleAdmin}, 100)
+
+		assert.Equal(t, membership, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("organization admin role required"), ErrorCode: http.StatusForbidden})
+	})
+
+	t.Run("user of another organization", func(t *testing.T) {
+		organizationRepositoryMock := new(mocks.OrganizationRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		organizationRepositoryMock.On("FindById", uint64(2)).Return(acmeOrganization)
+		organizationRepositoryMock.On("FindById", uint64(3)).Return(repository.Organization{ID: 3, Slug: "globex"})
+		organizationRepositoryMock.On("FindMembership", uint64(2), uint64(100)).Return(repository.Membership{ID: 1, Role: repository.OrganizationRoleAdmin})
+		organizationRepositoryMock.On("FindMembership", uint64(2), uint64(101)).Return(repository.Membership{})
+		organizationRepositoryMock.On("FindMembership", uint64(3), uint64(100)).Return(repository.Membership{})
+		userRepositoryMock.On("FindById").Return(repository.User{}).Once()
+		userRepositoryMock.On("FindById").Return(repository.User{ID: 101, OrganizationID: 3})
+
+		organizationUsecase := usecase.NewOrganizationUsecaseImpl(organizationRepositoryMock, userRepositoryMock, nil, nil)
+		membership, err := organizationUsecase.SetMember(2, 101, repository.SetMembership{Role: repository.OrganizationRoleMember}, 100)
+
+		assert.Equal(t, membership, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("user not found"), ErrorCode: http.StatusNotFound})
+		organizationRepositoryMock.AssertNotCalled(t, "SaveMembership", mock.Anything)
+	})
+
+	t.Run("test admin of both organizations adds a user of the other", func(t *testing.T) {
+		organizationRepositoryMock := new(mocks.OrganizationRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		organizationRepositoryMock.On("FindById", uint64(2)).Return(acmeOrganization)
+		organizationRepositoryMock.On("FindById", uint64(3)).Return(repository.Organization{ID: 3, Slug: "globex"})
+		organizationRepositoryMock.On("FindMembership", uint64(2), uint64(100)).Return(repository.Membership{ID: 1, Role: repository.OrganizationRoleAdmin})
+		organizationRepositoryMock.On("FindMembership", uint64(2), uint64(101)).Return(repository.Membership{})
+		organizationRepositoryMock.On("FindMembership", uint64(3), uint64(100)).Return(repository.Membership{ID: 2, Role: repository.OrganizationRoleAdmin})
+		organizationRepositoryMock.On("SaveMembership", repository.Membership{OrganizationID: 2, UserID: 101, Role: repository.OrganizationRoleMember}).Return(repository.Membership{ID: 3, OrganizationID: 2, UserID: 101, Role: repository.OrganizationRoleMember})
+		userRepositoryMock.On("FindById").Return(repository.User{}).Once()
+		userRepositoryMock.On("FindById").Return(repository.User{ID: 101, OrganizationID: 3})
+		auditUsecaseMock.On("Record").Return(nil)
+
+		organizationUsecase := usecase.NewOrganizationUsecaseImpl(organizationRepositoryMock, userRepositoryMock, nil, auditUsecaseMock)
+		membership, err := organizationUsecase.SetMember(2, 101, repository.SetMembership{Role: repository.OrganizationRoleMember}, 100)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, membership.ID, uint64(3))
+	})
+
+	t.Run("own membership", func(t *testing.T) {
+		organizationRepositoryMock := new(mocks.OrganizationRepositoryMock)
+		organizationRepositoryMock.On("FindById", uint64(2)).Return(acmeOrganization)
+		organizationRepositoryMock.On("FindMembership", uint64(2), uint64(100)).Return(repository.Membership{ID: 1, Role: repository.OrganizationRoleAdmin})
+
+		organizationUsecase := usecase.NewOrganizationUsecaseImpl(organizationRepositoryMock, nil, nil, nil)
+		_, err := organizationUsecase.SetMember(2, 100, repository.SetMembership{Role: repository.OrganizationRoleMember}, 100)
+
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("cannot change own membership"), ErrorCode: http.StatusBadRequest})
+	})
+}
+
+func TestRequireAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(organizationUsecase usecase.OrganizationUsecase) *gin.Engine {
+		router := gin.New()
+		router.Use(func(c *gin.Context) {
+			c.Set("currentOrganizationId", repository.DefaultOrganizationID)
+			c.Set("currentUserId", uint64(100))
+			c.Next()
+		})
+		router.GET("/test", organizationUsecase.RequireAdmin, func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		return router
+	}
+
+	t.Run("user of the organization with the admin role", func(t *testing.T) {
+		organizationRepositoryMock := new(mocks.OrganizationRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		organizationRepositoryMock.On("FindById", repository.DefaultOrganizationID).Return(repository.Organization{ID: 1, Slug: "default"})
+		organizationRepositoryMock.On("FindMembership", repository.DefaultOrganizationID, uint64(100)).Return(repository.Membership{})
+		userRepositoryMock.On("FindById").Return(repository.User{ID: 100, Roles: "admin"})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+		newRouter(usecase.NewOrganizationUsecaseImpl(organizationRepositoryMock, userRepositoryMock, nil, nil)).ServeHTTP(w, req)
+
+		assert.Equal(t, w.Code, http.StatusOK)
+		assert.Equal(t, userRepositoryMock.OrganizationID, repository.DefaultOrganizationID)
+	})
+
+	t.Run("plain member", func(t *testing.T) {
+		organizationRepositoryMock := new(mocks.OrganizationRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		organizationRepositoryMock.On("FindById", repository.DefaultOrganizationID).Return(repository.Organization{ID: 1, Slug: "default"})
+		organizationRepositoryMock.On("FindMembership", repository.DefaultOrganizationID, uint64(100)).Return(repository.Membership{})
+		userRepositoryMock.On("FindById").Return(repository.User{ID: 100})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+		newRouter(usecase.NewOrganizationUsecaseImpl(organizationRepositoryMock, userRepositoryMock, nil, nil)).ServeHTTP(w, req)
+
+		assert.Equal(t, w.Code, http.StatusForbidden)
+	})
+}
+
+func TestRequireOpenRegistration(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	organizationRepositoryMock := new(mocks.OrganizationRepositoryMock)
+	organizationRepositoryMock.On("FindById", uint64(2)).Return(acmeOrganization)
+	organizationRepositoryMock.On("FindById", uint64(3)).Return(repository.Organization{ID: 3, Slug: "globex", AllowRegistration: true})
+	organizationUsecase := usecase.NewOrganizationUsecaseImpl(organizationRepositoryMock, nil, nil, nil)
+
+	for organizationId, code := range map[uint64]int{repository.DefaultOrganizationID: http.StatusOK, 2: http.StatusForbidden, 3: http.StatusOK} {
+		router := gin.New()
+		router.Use(func(c *gin.Context) {
+			c.Set("currentOrganizationId", organizationId)
+			c.Next()
+		})
+		router.POST("/register", organizationUsecase.RequireOpenRegistration, func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/register", nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, w.Code, code)
+	}
+}
+
+func TestRemoveMember(t *testing.T) {
+	t.Run("test remove member and end their sessions", func(t *testing.T) {
+		organizationRepositoryMock := new(mocks.OrganizationRepositoryMock)
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		organizationRepositoryMock.On("FindById", uint64(2)).Return(acmeOrganization)
+		organizationRepositoryMock.On("FindMembership", uint64(2), uint64(100)).Return(repository.Membership{ID: 1, Role: repository.OrganizationRoleAdmin})
+		organizationRepositoryMock.On("DeleteMembership", uint64(2), uint64(101)).Return(true)
+		sessionRepositoryMock.On("DeleteByUserIdAndOrganizationId", uint64(101), uint64(2)).Return(int64(1))
+		auditUsecaseMock.On("Record").Return(nil)
+
+		organizationUsecase := usecase.NewOrganizationUsecaseImpl(organizationRepositoryMock, nil, sessionRepositoryMock, auditUsecaseMock)
+
+		assert.Equal(t, organizationUsecase.RemoveMember(2, 101, 100), nil)
+		sessionRepositoryMock.AssertExpectations(t)
+	})
+
+	t.Run("organization not found", func(t *testing.T) {
+		organizationRepositoryMock := new(mocks.OrganizationRepositoryMock)
+		organizationRepositoryMock.On("FindById", uint64(3)).Return(repository.Organization{})
+
+		organizationUsecase := usecase.NewOrganizationUsecaseImpl(organizationRepositoryMock, nil, nil, nil)
+
+		assert.Equal(t, organizationUsecase.RemoveMember(3, 101, 100), helper.StandardError{Error: errors.New("organization not found"), ErrorCode: http.StatusNotFound})
+	})
+}
+
+func TestSwitchOrganization(t *testing.T) {
+	os.Setenv("SECRET", "testkey")
+
+	t.Run("test switch to a member organization", func(t *testing.T) {
+		organizationRepositoryMock := new(mocks.OrganizationRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		organizationRepositoryMock.On("FindById", uint64(2)).Return(acmeOrganization)
+		organizationRepositoryMock.On("FindMembership", uint64(2), uint64(100)).Return(repository.Membership{ID: 1, Role: repository.OrganizationRoleMember})
+		userRepositoryMock.On("FindById").Return(mockUser)
+		sessionRepositoryMock.On("Save", mock.MatchedBy(func(session repository.Session) bool {
+			return session.UserID == 100 && session.OrganizationID == 2 && session.Method == usecase.SessionMethodOrganization
+		})).Return(repository.Session{ID: 5, UserID: 100, OrganizationID: 2, ExpiresAt: time.Now().Add(time.Hour)})
+		auditUsecaseMock.On("Record").Return(nil)
+
+		organizationUsecase := usecase.NewOrganizationUsecaseImpl(organizationRepositoryMock, userRepositoryMock, sessionRepositoryMock, auditUsecaseMock)
+		token, err := organizationUsecase.SwitchOrganization(2, 100, repository.LoginContext{})
+
+		assert.Equal(t, err, nil)
+		claims := jwt.MapClaims{}
+		jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) { return []byte("testkey"), nil })
+		assert.Equal(t, claims["org"], float64(2))
+		assert.Equal(t, claims["sid"], float64(5))
+	})
+
+	t.Run("not a member", func(t *testing.T) {
+		organizationRepositoryMock := new(mocks.OrganizationRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		organizationRepositoryMock.On("FindById", uint64(2)).Return(acmeOrganization)
+		organizationRepositoryMock.On("FindMembership", uint64(2), uint64(100)).Return(repository.Membership{})
+		userRepositoryMock.On("FindById").Return(mockUser)
+
+		organizationUsecase := usecase.NewOrganizationUsecaseImpl(organizationRepositoryMock, userRepositoryMock, nil, nil)
+		token, err := organizationUsecase.SwitchOrganization(2, 100, repository.LoginContext{})
+
+		assert.Equal(t, token, "")
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("not a member of this organization"), ErrorCode: http.StatusForbidden})
+	})
+}
@@ -0,0 +1,451 @@
+package usecase
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+const (
+	passkeySessionExpiry       = 5 * time.Minute
+	passkeyPurposeRegistration = "registration"
+	passkeyPurposeLogin        = "login"
+	defaultPasskeyName         = "passkey"
+	defaultPasskeyRPName       = "go-user-management"
+)
+
+var errPasskeyRequired = &helper.StandardError{Error: errors.New("passkey required, sign in with a passkey"), ErrorCode: http.StatusForbidden}
+
+type PasskeyUsecase interface {
+	BeginRegistration(userId uint64) (*repository.PasskeyCeremony, *helper.StandardError)
+	FinishRegistration(userId uint64, registration repository.PasskeyRegistration) (*repository.PasskeyCredential, *helper.StandardError)
+	BeginLogin(loginData repository.PasskeyLoginBegin, organizationId uint64) (*repository.PasskeyCeremony, *helper.StandardError)
+	FinishLogin(loginData repository.PasskeyLoginFinish, loginContext repository.LoginContext) (string, *helper.StandardError)
+	ListPasskeys(userId uint64) (*[]repository.PasskeyCredential, *helper.StandardError)
+	RemovePasskey(userId uint64, passkeyId uint64) (*repository.PasskeyCredential, *helper.StandardError)
+	UpdateSettings(userId uint64, settings repository.PasskeySettings) (*repository.PasskeySettings, *helper.StandardError)
+}
+
+type PasskeyUsecaseImpl struct {
+	PasskeyRepository repository.PasskeyRepository
+	UserRepository    repository.UserRepository
+	Authenticator     Authenticator
+	SessionRepository repository.SessionRepository
+	AuditUsecase      AuditUsecase
+}
+
+// passkeyUser adapts a user and its stored credentials to the webauthn library.
+type passkeyUser struct {
+	user        repository.User
+	credentials []repository.PasskeyCredential
+}
+
+func (t *passkeyUser) WebAuthnID() []byte {
+	return []byte(strconv.FormatUint(t.user.ID, 10))
+}
+
+func (t *passkeyUser) WebAuthnName() string {
+	return t.user.Username
+}
+
+func (t *passkeyUser) WebAuthnDisplayName() string {
+	return t.user.Username
+}
+
+func (t *passkeyUser) WebAuthnIcon() string {
+	return ""
+}
+
+func (t *passkeyUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(t.credentials))
+	for _, credential := range t.credentials {
+		id, _ := base64.RawURLEncoding.DecodeString(credential.CredentialID)
+
+		var transports []protocol.AuthenticatorTransport
+		for _, transport := range strings.Fields(credential.Transports) {
+			transports = append(transports, protocol.AuthenticatorTransport(transport))
+		}
+
+		credentials = append(credentials, webauthn.Credential{
+			ID:              id,
+			PublicKey:       credential.PublicKey,
+			AttestationType: credential.AttestationType,
+			Transport:       transports,
+			Flags:           webauthn.CredentialFlags{BackupEligible: credential.BackupEligible, BackupState: credential.BackupState},
+			Authenticator:   webauthn.Authenticator{AAGUID: credential.AAGUID, SignCount: credential.SignCount},
+		})
+	}
+	return credentials
+}
+
+func (t *passkeyUser) descriptors() []protocol.CredentialDescriptor {
+	var descriptors []protocol.CredentialDescriptor
+	for _, credential := range t.WebAuthnCredentials() {
+		descriptors = append(descriptors, credential.Descriptor())
+	}
+	return descriptors
+}
+
+// newWebAuthn configures the relying party from the environment. The RP ID defaults to the host
+// of OIDC_ISSUER and the allowed origin to the issuer itself.
+func newWebAuthn() (*webauthn.WebAuthn, error) {
+	issuer := oidcIssuer()
+
+	rpID := os.Getenv("WEBAUTHN_RP_ID")
+	if rpID == "" {
+		issuerURL, err := url.Parse(issuer)
+		if err != nil {
+			return nil, err
+		}
+		rpID = issuerURL.Hostname()
+	}
+
+	origins := strings.Fields(strings.ReplaceAll(os.Getenv("WEBAUTHN_RP_ORIGINS"), ",", " "))
+	if len(origins) == 0 {
+		origins = []string{issuer}
+	}
+
+	return webauthn.New(&webauthn.Config{
+		RPID:          rpID,
+		RPDisplayName: envOrDefault("WEBAUTHN_RP_NAME", defaultPasskeyRPName),
+		RPOrigins:     origins,
+	})
+}
+
+func (t *PasskeyUsecaseImpl) loadUser(user repository.User) *passkeyUser {
+	return &passkeyUser{user: user, credentials: t.PasskeyRepository.FindCredentialsByUserId(user.ID)}
+}
+
+func (t *PasskeyUsecaseImpl) saveSession(userId uint64, purpose string, sessionData *webauthn.SessionData) (string, *helper.StandardError) {
+	sessionId, err := generateRandomToken("")
+	if err != nil {
+		return "", &helper.StandardError{Error: errors.New("failed to generate passkey session"), ErrorCode: http.StatusInternalServerError}
+	}
+
+	data, _ := json.Marshal(sessionData)
+	t.PasskeyRepository.SaveSession(repository.PasskeySession{
+		SessionHash: hashToken(sessionId),
+		UserID:      userId,
+		Purpose:     purpose,
+		Data:        string(data),
+		ExpiresAt:   time.Now().Add(passkeySessionExpiry),
+	})
+
+	return sessionId, nil
+}
+
+// consumeSession loads and deletes a ceremony session, so every challenge can only be answered once.
+func (t *PasskeyUsecaseImpl) consumeSession(sessionId string, purpose string) (repository.PasskeySession, webauthn.SessionData, *helper.StandardError) {
+	invalidSession := &helper.StandardError{Error: errors.New("invalid or expired passkey session"), ErrorCode: http.StatusBadRequest}
+
+	var sessionData webauthn.SessionData
+	session := t.PasskeyRepository.FindSessionByHash(hashToken(sessionId))
+	if session.ID == 0 || session.Purpose != purpose || time.Now().After(session.ExpiresAt) || !t.PasskeyRepository.DeleteSession(session.ID) {
+		return session, sessionData, invalidSession
+	}
+
+	if err := json.Unmarshal([]byte(session.Data), &sessionData); err != nil {
+		return session, sessionData, invalidSession
+	}
+
+	return session, sessionData, nil
+}
+
+func (t *PasskeyUsecaseImpl) BeginRegistration(userId uint64) (*repository.PasskeyCeremony, *helper.StandardError) {
+	user := t.UserRepository.FindById(userId)
+	if user.ID == 0 {
+		return nil, &helper.StandardError{Error: errors.New("user not found"), ErrorCode: http.StatusNotFound}
+	}
+
+	webAuthn, err := newWebAuthn()
+	if err != nil {
+		return nil, &helper.StandardError{Error: errors.New("invalid webauthn configuration"), ErrorCode: http.StatusInternalServerError}
+	}
+
+	owner := t.loadUser(user)
+	options, sessionData, err := webAuthn.BeginRegistration(owner,
+		webauthn.WithExclusions(owner.descriptors()),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementPreferred),
+	)
+	if err != nil {
+		return nil, &helper.StandardError{Error: errors.New("failed to begin passkey registration"), ErrorCode: http.StatusInternalServerError}
+	}
+
+	sessionId, sessionError := t.saveSession(user.ID, passkeyPurposeRegistration, sessionData)
+	if sessionError != nil {
+		return nil, sessionError
+	}
+
+	return &repository.PasskeyCeremony{SessionID: sessionId, Options: options}, nil
+}
+
+func (t *PasskeyUsecaseImpl) FinishRegistration(userId uint64, registration repository.PasskeyRegistration) (*repository.PasskeyCredential, *helper.StandardError) {
+	session, sessionData, sessionError := t.consumeSession(registration.SessionID, passkeyPurposeRegistration)
+	if sessionError != nil {
+		return nil, sessionError
+	}
+	if session.UserID != userId {
+		return nil, &helper.StandardError{Error: errors.New("invalid or expired passkey session"), ErrorCode: http.StatusBadRequest}
+	}
+
+	user := t.UserRepository.FindById(userId)
+	if user.ID == 0 {
+		return nil, &helper.StandardError{Error: errors.New("user not found"), ErrorCode: http.StatusNotFound}
+	}
+
+	webAuthn, err := newWebAuthn()
+	if err != nil {
+		return nil, &helper.StandardError{Error: errors.New("invalid webauthn configuration"), ErrorCode: http.StatusInternalServerError}
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBody(bytes.NewReader(registration.Credential))
+	if err != nil {
+		return nil, &helper.StandardError{Error: errors.New("invalid passkey response"), ErrorCode: http.StatusBadRequest}
+	}
+
+	credential, err := webAuthn.CreateCredential(t.loadUser(user), sessionData, parsed)
+	if err != nil {
+		return nil, &helper.StandardError{Error: errors.New("passkey verification failed"), ErrorCode: http.StatusBadRequest}
+	}
+
+	credentialId := base64.RawURLEncoding.EncodeToString(credential.ID)
+	if t.PasskeyRepository.FindCredentialByCredentialId(credentialId).ID != 0 {
+		return nil, &helper.StandardError{Error: errors.New("passkey is already registered"), ErrorCode: http.StatusConflict}
+	}
+
+	var transports []string
+	for _, transport := range credential.Transport {
+		transports = append(transports, string(transport))
+	}
+
+	name := strings.TrimSpace(registration.Name)
+	if name == "" {
+		name = defaultPasskeyName
+	}
+
+	saved := t.PasskeyRepository.SaveCredential(repository.PasskeyCredential{
+		UserID:          user.ID,
+		Name:            name,
+		CredentialID:    credentialId,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		Transports:      strings.Join(transports, " "),
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+	})
+	t.AuditUsecase.Record("passkey.register", user.ID, user.ID, fmt.Sprintf("passkey_id=%d", saved.ID))
+
+	return &saved, nil
+}
+
+// BeginLogin starts an assertion ceremony. Without a username any discoverable passkey may answer;
+// with a username only that user's passkeys are allowed, and a password makes the passkey the second
+// factor of a password login, for which user verification is not required.
+func (t *PasskeyUsecaseImpl) BeginLogin(loginData repository.PasskeyLoginBegin, organizationId uint64) (*repository.PasskeyCeremony, *helper.StandardError) {
+	webAuthn, err := newWebAuthn()
+	if err != nil {
+		return nil, &helper.StandardError{Error: errors.New("invalid webauthn configuration"), ErrorCode: http.StatusInternalServerError}
+	}
+
+	if loginData.Username == "" {
+		options, sessionData, err := webAuthn.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+		if err != nil {
+			return nil, &helper.StandardError{Error: errors.New("failed to begin passkey login"), ErrorCode: http.StatusInternalServerError}
+		}
+
+		sessionId, sessionError := t.saveSession(0, passkeyPurposeLogin, sessionData)
+		if sessionError != nil {
+			return nil, sessionError
+		}
+		return &repository.PasskeyCeremony{SessionID: sessionId, Options: options}, nil
+	}
+
+	var user repository.User
+	userVerification := protocol.VerificationRequired
+	if loginData.Password != "" {
+		authenticated, authError := t.Authenticator.Authenticate(repository.Login{Username: loginData.Username, Password: loginData.Password, OrganizationID: organizationId})
+		if authError != nil {
+			return nil, authError
+		}
+		user = *authenticated
+		userVerification = protocol.VerificationPreferred
+	} else {
+		user = t.UserRepository.ForOrganization(organizationId).FindByUsername(loginData.Username)
+	}
+
+	owner := t.loadUser(user)
+	if user.ID == 0 || len(owner.credentials) == 0 {
+		return nil, &helper.StandardError{Error: errors.New("no passkeys registered"), ErrorCode: http.StatusBadRequest}
+	}
+	if user.DisabledAt != nil {
+		return nil, errUserDisabled
+	}
+
+	options, sessionData, err := webAuthn.BeginLogin(owner, webauthn.WithUserVerification(userVerification))
+	if err != nil {
+		return nil, &helper.StandardError{Error: errors.New("failed to begin passkey login"), ErrorCode: http.StatusInternalServerError}
+	}
+
+	sessionId, sessionError := t.saveSession(user.ID, passkeyPurposeLogin, sessionData)
+	if sessionError != nil {
+		return nil, sessionError
+	}
+
+	return &repository.PasskeyCeremony{SessionID: sessionId, Options: options}, nil
+}
+
+func (t *PasskeyUsecaseImpl) FinishLogin(loginData repository.PasskeyLoginFinish, loginContext repository.LoginContext) (string, *helper.StandardError) {
+	verificationFailed := &helper.StandardError{Error: errors.New("passkey verification failed"), ErrorCode: http.StatusUnauthorized}
+
+	session, sessionData, sessionError := t.consumeSession(loginData.SessionID, passkeyPurposeLogin)
+	if sessionError != nil {
+		return "", sessionError
+	}
+
+	webAuthn, err := newWebAuthn()
+	if err != nil {
+		return "", &helper.StandardError{Error: errors.New("invalid webauthn configuration"), ErrorCode: http.StatusInternalServerError}
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(loginData.Credential))
+	if err != nil {
+		return "", &helper.StandardError{Error: errors.New("invalid passkey response"), ErrorCode: http.StatusBadRequest}
+	}
+
+	var owner *passkeyUser
+	var credential *webauthn.Credential
+	if session.UserID != 0 {
+		owner = t.loadUser(t.UserRepository.FindById(session.UserID))
+		credential, err = webAuthn.ValidateLogin(owner, sessionData, parsed)
+	} else {
+		credential, err = webAuthn.ValidateDiscoverableLogin(func(rawID, userHandle []byte) (webauthn.User, error) {
+			userId, err := strconv.ParseUint(string(userHandle), 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			owner = t.loadUser(t.UserRepository.FindById(userId))
+			if owner.user.ID == 0 {
+				return nil, ErrUserNotFound
+			}
+			return owner, nil
+		}, sessionData, parsed)
+	}
+
+	if err != nil {
+		if owner != nil && owner.user.ID != 0 {
+			t.AuditUsecase.Record("user.login_failed", 0, owner.user.ID, "passkey verification failed")
+		}
+		return "", verificationFailed
+	}
+
+	user := owner.user
+	if user.DisabledAt != nil {
+		return "", errUserDisabled
+	}
+
+	credentialId := base64.RawURLEncoding.EncodeToString(credential.ID)
+	var stored repository.PasskeyCredential
+	for _, candidate := range owner.credentials {
+		if candidate.CredentialID == credentialId {
+			stored = candidate
+		}
+	}
+
+	// A counter that does not increase means the private key may have been copied to another authenticator.
+	if credential.Authenticator.CloneWarning {
+		t.AuditUsecase.Record("passkey.clone_warning", 0, user.ID, fmt.Sprintf("passkey_id=%d", stored.ID))
+		return "", verificationFailed
+	}
+
+	now := time.Now()
+	stored.SignCount = credential.Authenticator.SignCount
+	stored.BackupState = credential.Flags.BackupState
+	stored.LastUsedAt = &now
+	t.PasskeyRepository.UpdateCredential(stored)
+
+	token, loginSession, loginError := startTokenSession(t.SessionRepository, user, SessionMethodPasskey, loginContext)
+	if loginError != nil {
+		return "", loginError
+	}
+
+	t.AuditUsecase.Record("user.login", user.ID, user.ID, fmt.Sprintf("method=passkey passkey_id=%d session_id=%d", stored.ID, loginSession.ID))
+
+	return token, nil
+}
+
+func (t *PasskeyUsecaseImpl) ListPasskeys(userId uint64) (*[]repository.PasskeyCredential, *helper.StandardError) {
+	credentials := t.PasskeyRepository.FindCredentialsByUserId(userId)
+	if credentials == nil {
+		credentials = []repository.PasskeyCredential{}
+	}
+	return &credentials, nil
+}
+
+func (t *PasskeyUsecaseImpl) RemovePasskey(userId uint64, passkeyId uint64) (*repository.PasskeyCredential, *helper.StandardError) {
+	credentials := t.PasskeyRepository.FindCredentialsByUserId(userId)
+
+	for _, credential := range credentials {
+		if credential.ID != passkeyId {
+			continue
+		}
+
+		t.PasskeyRepository.DeleteCredential(credential.ID)
+		t.AuditUsecase.Record("passkey.remove", userId, userId, fmt.Sprintf("passkey_id=%d", credential.ID))
+
+		// Without any passkey left the user could not sign in at all, so the requirement is dropped.
+		if len(credentials) == 1 {
+			if user := t.UserRepository.FindById(userId); user.PasskeyRequired {
+				user.PasskeyRequired = false
+				t.UserRepository.Update(user)
+			}
+		}
+
+		return &credential, nil
+	}
+
+	return nil, &helper.StandardError{Error: errors.New("passkey not found"), ErrorCode: http.StatusNotFound}
+}
+
+func (t *PasskeyUsecaseImpl) UpdateSettings(userId uint64, settings repository.PasskeySettings) (*repository.PasskeySettings, *helper.StandardError) {
+	user := t.UserRepository.FindById(userId)
+	if user.ID == 0 {
+		return nil, &helper.StandardError{Error: errors.New("user not found"), ErrorCode: http.StatusNotFound}
+	}
+
+	if settings.Required && len(t.PasskeyRepository.FindCredentialsByUserId(userId)) == 0 {
+		return nil, &helper.StandardError{Error: errors.New("register a passkey before requiring it"), ErrorCode: http.StatusBadRequest}
+	}
+
+	if user.PasskeyRequired != settings.Required {
+		user.PasskeyRequired = settings.Required
+		t.UserRepository.Update(user)
+		t.AuditUsecase.Record("passkey.settings", userId, userId, fmt.Sprintf("required=%t", settings.Required))
+	}
+
+	return &repository.PasskeySettings{Required: user.PasskeyRequired}, nil
+}
+
+func NewPasskeyUsecaseImpl(passkeyRepository repository.PasskeyRepository, userRepository repository.UserRepository, authenticator Authenticator, sessionRepository repository.SessionRepository, auditUsecase AuditUsecase) PasskeyUsecase {
+	return &PasskeyUsecaseImpl{
+		PasskeyRepository: passkeyRepository,
+		UserRepository:    userRepository,
+		Authenticator:     authenticator,
+		SessionRepository: sessionRepository,
+		AuditUsecase:      auditUsecase,
+	}
+}
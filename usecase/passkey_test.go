@@ -0,0 +1,419 @@
+package usecase_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/go-playground/assert/v2"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/stretchr/testify/mock"
+)
+
+// softwareAuthenticator answers WebAuthn ceremonies the way a platform authenticator would,
+// with an ES256 key and "none" attestation.
+type softwareAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	counter      uint32
+}
+
+func newSoftwareAuthenticator() *softwareAuthenticator {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	credentialID := make([]byte, 16)
+	rand.Read(credentialID)
+	return &softwareAuthenticator{key: key, credentialID: credentialID}
+}
+
+func (a *softwareAuthenticator) id() string {
+	return base64.RawURLEncoding.EncodeToString(a.credentialID)
+}
+
+func (a *softwareAuthenticator) publicKey() []byte {
+	publicKey, _ := cbor.Marshal(map[int]interface{}{
+		1:  2,
+		3:  -7,
+		-1: 1,
+		-2: a.key.PublicKey.X.FillBytes(make([]byte, 32)),
+		-3: a.key.PublicKey.Y.FillBytes(make([]byte, 32)),
+	})
+	return publicKey
+}
+
+func (a *softwareAuthenticator) authenticatorData(flags byte, attested bool) []byte {
+	rpIdHash := sha256.Sum256([]byte("localhost"))
+	data := append(rpIdHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, a.counter)
+	if attested {
+		data = append(data, make([]byte, 16)...)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+		data = append(data, a.credentialID...)
+		data = append(data, a.publicKey()...)
+	}
+	return data
+}
+
+func passkeyClientData(ceremony string, challenge string) []byte {
+	clientData, _ := json.Marshal(map[string]string{"type": ceremony, "challenge": challenge, "origin": "http://localhost:3000"})
+	return clientData
+}
+
+func (a *softwareAuthenticator) create(challenge string) json.RawMessage {
+	encode := base64.RawURLEncoding.EncodeToString
+	attestationObject, _ := cbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": a.authenticatorData(0x45, true),
+	})
+
+	response, _ := json.Marshal(map[string]interface{}{
+		"id":    a.id(),
+		"rawId": a.id(),
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    encode(passkeyClientData("webauthn.create", challenge)),
+			"attestationObject": encode(attestationObject),
+		},
+	})
+	return response
+}
+
+func (a *softwareAuthenticator) get(challenge string, userHandle string) json.RawMessage {
+	encode := base64.RawURLEncoding.EncodeToString
+	a.counter++
+
+	authenticatorData := a.authenticatorData(0x05, false)
+	clientData := passkeyClientData("webauthn.get", challenge)
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authenticatorData...), clientDataHash[:]...))
+	signature, _ := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+
+	response, _ := json.Marshal(map[string]interface{}{
+		"id":    a.id(),
+		"rawId": a.id(),
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    encode(clientData),
+			"authenticatorData": encode(authenticatorData),
+			"signature":         encode(signature),
+			"userHandle":        encode([]byte(userHandle)),
+		},
+	})
+	return response
+}
+
+func (a *softwareAuthenticator) stored(userId uint64, signCount uint32) repository.PasskeyCredential {
+	return repository.PasskeyCredential{ID: 1, UserID: userId, Name: "laptop", CredentialID: a.id(), PublicKey: a.publicKey(), AttestationType: "none", SignCount: signCount}
+}
+
+// answerSession makes the repository mock return the session saved by the last begin call.
+func answerSession(passkeyRepositoryMock *mocks.PasskeyRepositoryMock) {
+	var saved repository.PasskeySession
+	for _, call := range passkeyRepositoryMock.Calls {
+		if call.Method == "SaveSession" {
+			saved = call.Arguments.Get(0).(repository.PasskeySession)
+		}
+	}
+	saved.ID = 1
+	passkeyRepositoryMock.On("FindSessionByHash").Return(saved)
+	passkeyRepositoryMock.On("DeleteSession").Return(true)
+}
+
+func TestPasskeyRegistration(t *testing.T) {
+	t.Setenv("OIDC_ISSUER", "http://localhost:3000")
+
+	t.Run("test register with a software authenticator", func(t *testing.T) {
+		authenticator := newSoftwareAuthenticator()
+		passkeyRepositoryMock := new(mocks.PasskeyRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		userRepositoryMock.On("FindById").Return(mockUser)
+		passkeyRepositoryMock.On("FindCredentialsByUserId").Return([]repository.PasskeyCredential{})
+		passkeyRepositoryMock.On("FindCredentialByCredentialId").Return(repository.PasskeyCredential{})
+		passkeyRepositoryMock.On("SaveSession", mock.Anything).Return(repository.PasskeySession{})
+		passkeyRepositoryMock.On("SaveCredential", mock.MatchedBy(func(credential repository.PasskeyCredential) bool {
+			return credential.UserID == 100 && credential.CredentialID == authenticator.id() && credential.Name == "laptop" && credential.AttestationType == "none"
+		})).Return(authenticator.stored(100, 0))
+		auditUsecaseMock.On("Record").Return(nil)
+
+		passkeyUsecase := usecase.NewPasskeyUsecaseImpl(passkeyRepositoryMock, userRepositoryMock, nil, nil, auditUsecaseMock)
+		ceremony, err := passkeyUsecase.BeginRegistration(100)
+		assert.Equal(t, err, nil)
+
+		options := ceremony.Options.(*protocol.CredentialCreation)
+		assert.Equal(t, options.Response.RelyingParty.ID, "localhost")
+		assert.Equal(t, options.Response.User.Name, "username")
+
+		answerSession(passkeyRepositoryMock)
+		credential, err := passkeyUsecase.FinishRegistration(100, repository.PasskeyRegistration{
+			SessionID:  ceremony.SessionID,
+			Name:       "laptop",
+			Credential: authenticator.create(options.Response.Challenge.String()),
+		})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, credential.CredentialID, authenticator.id())
+	})
+
+	t.Run("wrong challenge", func(t *testing.T) {
+		authenticator := newSoftwareAuthenticator()
+		passkeyRepositoryMock := new(mocks.PasskeyRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		userRepositoryMock.On("FindById").Return(mockUser)
+		passkeyRepositoryMock.On("FindCredentialsByUserId").Return([]repository.PasskeyCredential{})
+		passkeyRepositoryMock.On("SaveSession", mock.Anything).Return(repository.PasskeySession{})
+
+		passkeyUsecase := usecase.NewPasskeyUsecaseImpl(passkeyRepositoryMock, userRepositoryMock, nil, nil, nil)
+		ceremony, _ := passkeyUsecase.BeginRegistration(100)
+
+		answerSession(passkeyRepositoryMock)
+		credential, err := passkeyUsecase.FinishRegistration(100, repository.PasskeyRegistration{
+			SessionID:  ceremony.SessionID,
+			Credential: authenticator.create(base64.RawURLEncoding.EncodeToString([]byte("another challenge"))),
+		})
+
+		assert.Equal(t, credential, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("passkey verification failed"), ErrorCode: http.StatusBadRequest})
+	})
+
+	t.Run("session of another user", func(t *testing.T) {
+		passkeyRepositoryMock := new(mocks.PasskeyRepositoryMock)
+		passkeyRepositoryMock.On("FindSessionByHash").Return(repository.PasskeySession{ID: 1, UserID: 101, Purpose: "registration", Data: "{}", ExpiresAt: disabledAt.AddDate(100, 0, 0)})
+		passkeyRepositoryMock.On("DeleteSession").Return(true)
+
+		passkeyUsecase := usecase.NewPasskeyUsecaseImpl(passkeyRepositoryMock, nil, nil, nil, nil)
+		credential, err := passkeyUsecase.FinishRegistration(100, repository.PasskeyRegistration{SessionID: "session", Credential: json.RawMessage(`{}`)})
+
+		assert.Equal(t, credential, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("invalid or expired passkey session"), ErrorCode: http.StatusBadRequest})
+	})
+}
+
+func TestPasskeyLogin(t *testing.T) {
+	t.Setenv("OIDC_ISSUER", "http://localhost:3000")
+	t.Setenv("SECRET", "testkey")
+
+	t.Run("test passwordless login with a discoverable passkey", func(t *testing.T) {
+		authenticator := newSoftwareAuthenticator()
+		passkeyRepositoryMock := new(mocks.PasskeyRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		userRepositoryMock.On("FindById").Return(mockUser)
+		passkeyRepositoryMock.On("FindCredentialsByUserId").Return([]repository.PasskeyCredential{authenticator.stored(100, 0)})
+		passkeyRepositoryMock.On("SaveSession", mock.Anything).Return(repository.PasskeySession{})
+		passkeyRepositoryMock.On("UpdateCredential", mock.MatchedBy(func(credential repository.PasskeyCredential) bool {
+			return credential.ID == 1 && credential.SignCount == 1 && credential.LastUsedAt != nil
+		})).Return(repository.PasskeyCredential{})
+		auditUsecaseMock.On("Record").Return(nil)
+
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		sessionRepositoryMock.On("Save", mock.MatchedBy(func(session repository.Session) bool {
+			return session.Method == usecase.SessionMethodPasskey
+		})).Return(loginSession)
+
+		passkeyUsecase := usecase.NewPasskeyUsecaseImpl(passkeyRepositoryMock, userRepositoryMock, nil, sessionRepositoryMock, auditUsecaseMock)
+		ceremony, err := passkeyUsecase.BeginLogin(repository.PasskeyLoginBegin{}, repository.DefaultOrganizationID)
+		assert.Equal(t, err, nil)
+
+		options := ceremony.Options.(*protocol.CredentialAssertion)
+		assert.Equal(t, len(options.Response.AllowedCredentials), 0)
+		assert.Equal(t, options.Response.UserVerification, protocol.VerificationRequired)
+
+		answerSession(passkeyRepositoryMock)
+		token, err := passkeyUsecase.FinishLogin(repository.PasskeyLoginFinish{
+			SessionID:  ceremony.SessionID,
+			Credential: authenticator.get(options.Response.Challenge.String(), "100"),
+		}, repository.LoginContext{})
+
+		assert.Equal(t, err, nil)
+		assert.NotEqual(t, token, "")
+		passkeyRepositoryMock.AssertCalled(t, "UpdateCredential", mock.Anything)
+	})
+
+	t.Run("test passkey as second factor", func(t *testing.T) {
+		authenticator := newSoftwareAuthenticator()
+		passkeyRepositoryMock := new(mocks.PasskeyRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		userRepositoryMock.On("FindByUsername").Return(mockUser)
+		userRepositoryMock.On("FindById").Return(mockUser)
+		passkeyRepositoryMock.On("FindCredentialsByUserId").Return([]repository.PasskeyCredential{authenticator.stored(100, 0)})
+		passkeyRepositoryMock.On("SaveSession", mock.Anything).Return(repository.PasskeySession{})
+		passkeyRepositoryMock.On("UpdateCredential", mock.Anything).Return(repository.PasskeyCredential{})
+		auditUsecaseMock.On("Record").Return(nil)
+
+		passwordAuthenticator := usecase.NewLocalAuthenticator(userRepositoryMock, auditUsecaseMock)
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		sessionRepositoryMock.On("Save", mock.MatchedBy(func(session repository.Session) bool {
+			return session.Method == usecase.SessionMethodPasskey
+		})).Return(loginSession)
+
+		passkeyUsecase := usecase.NewPasskeyUsecaseImpl(passkeyRepositoryMock, userRepositoryMock, passwordAuthenticator, sessionRepositoryMock, auditUsecaseMock)
+		ceremony, err := passkeyUsecase.BeginLogin(repository.PasskeyLoginBegin{Username: "username", Password: "password"}, repository.DefaultOrganizationID)
+		assert.Equal(t, err, nil)
+
+		options := ceremony.Options.(*protocol.CredentialAssertion)
+		assert.Equal(t, len(options.Response.AllowedCredentials), 1)
+		assert.Equal(t, options.Response.UserVerification, protocol.VerificationPreferred)
+
+		answerSession(passkeyRepositoryMock)
+		token, err := passkeyUsecase.FinishLogin(repository.PasskeyLoginFinish{
+			SessionID:  ceremony.SessionID,
+			Credential: authenticator.get(options.Response.Challenge.String(), "100"),
+		}, repository.LoginContext{})
+
+		assert.Equal(t, err, nil)
+		assert.NotEqual(t, token, "")
+	})
+
+	t.Run("wrong password before second factor", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		userRepositoryMock.On("FindByUsername").Return(mockUser)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		passwordAuthenticator := usecase.NewLocalAuthenticator(userRepositoryMock, auditUsecaseMock)
+		passkeyUsecase := usecase.NewPasskeyUsecaseImpl(nil, userRepositoryMock, passwordAuthenticator, nil, auditUsecaseMock)
+		ceremony, err := passkeyUsecase.BeginLogin(repository.PasskeyLoginBegin{Username: "username", Password: "wrong password"}, repository.DefaultOrganizationID)
+
+		assert.Equal(t, ceremony, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("wrong password"), ErrorCode: http.StatusUnauthorized})
+	})
+
+	t.Run("no passkeys registered", func(t *testing.T) {
+		passkeyRepositoryMock := new(mocks.PasskeyRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		userRepositoryMock.On("FindByUsername").Return(mockUser)
+		passkeyRepositoryMock.On("FindCredentialsByUserId").Return([]repository.PasskeyCredential{})
+
+		passkeyUsecase := usecase.NewPasskeyUsecaseImpl(passkeyRepositoryMock, userRepositoryMock, nil, nil, nil)
+		ceremony, err := passkeyUsecase.BeginLogin(repository.PasskeyLoginBegin{Username: "username"}, repository.DefaultOrganizationID)
+
+		assert.Equal(t, ceremony, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("no passkeys registered"), ErrorCode: http.StatusBadRequest})
+	})
+
+	t.Run("cloned authenticator", func(t *testing.T) {
+		authenticator := newSoftwareAuthenticator()
+		passkeyRepositoryMock := new(mocks.PasskeyRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		userRepositoryMock.On("FindById").Return(mockUser)
+		passkeyRepositoryMock.On("FindCredentialsByUserId").Return([]repository.PasskeyCredential{authenticator.stored(100, 5)})
+		passkeyRepositoryMock.On("SaveSession", mock.Anything).Return(repository.PasskeySession{})
+		auditUsecaseMock.On("Record").Return(nil)
+
+		passkeyUsecase := usecase.NewPasskeyUsecaseImpl(passkeyRepositoryMock, userRepositoryMock, nil, nil, auditUsecaseMock)
+		ceremony, _ := passkeyUsecase.BeginLogin(repository.PasskeyLoginBegin{}, repository.DefaultOrganizationID)
+		options := ceremony.Options.(*protocol.CredentialAssertion)
+
+		answerSession(passkeyRepositoryMock)
+		token, err := passkeyUsecase.FinishLogin(repository.PasskeyLoginFinish{
+			SessionID:  ceremony.SessionID,
+			Credential: authenticator.get(options.Response.Challenge.String(), "100"),
+		}, repository.LoginContext{})
+
+		assert.Equal(t, token, "")
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("passkey verification failed"), ErrorCode: http.StatusUnauthorized})
+		passkeyRepositoryMock.AssertNotCalled(t, "UpdateCredential", mock.Anything)
+	})
+
+	t.Run("signature from another key", func(t *testing.T) {
+		authenticator := newSoftwareAuthenticator()
+		impostor := newSoftwareAuthenticator()
+		impostor.credentialID = authenticator.credentialID
+		passkeyRepositoryMock := new(mocks.PasskeyRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		userRepositoryMock.On("FindById").Return(mockUser)
+		passkeyRepositoryMock.On("FindCredentialsByUserId").Return([]repository.PasskeyCredential{authenticator.stored(100, 0)})
+		passkeyRepositoryMock.On("SaveSession", mock.Anything).Return(repository.PasskeySession{})
+		auditUsecaseMock.On("Record").Return(nil)
+
+		passkeyUsecase := usecase.NewPasskeyUsecaseImpl(passkeyRepositoryMock, userRepositoryMock, nil, nil, auditUsecaseMock)
+		ceremony, _ := passkeyUsecase.BeginLogin(repository.PasskeyLoginBegin{}, repository.DefaultOrganizationID)
+		options := ceremony.Options.(*protocol.CredentialAssertion)
+
+		answerSession(passkeyRepositoryMock)
+		token, err := passkeyUsecase.FinishLogin(repository.PasskeyLoginFinish{
+			SessionID:  ceremony.SessionID,
+			Credential: impostor.get(options.Response.Challenge.String(), "100"),
+		}, repository.LoginContext{})
+
+		assert.Equal(t, token, "")
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("passkey verification failed"), ErrorCode: http.StatusUnauthorized})
+	})
+}
+
+func TestPasskeyRequired(t *testing.T) {
+	t.Run("password login is refused", func(t *testing.T) {
+		passkeyUser := mockUser
+		passkeyUser.PasskeyRequired = true
+
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		userRepositoryMock.On("FindByUsername").Return(passkeyUser)
+
+		authUsecase := usecase.NewAuthUsecaseImpl(userRepositoryMock, nil, nil, nil, nil, usecase.NewLocalAuthenticator(userRepositoryMock, nil), nil, nil, nil)
+		token, err := authUsecase.Login(repository.Login{Username: "username", Password: "password"}, repository.LoginContext{})
+
+		assert.Equal(t, token, "")
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("passkey required, sign in with a passkey"), ErrorCode: http.StatusForbidden})
+	})
+
+	t.Run("requiring needs a registered passkey", func(t *testing.T) {
+		passkeyRepositoryMock := new(mocks.PasskeyRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		userRepositoryMock.On("FindById").Return(mockUser)
+		passkeyRepositoryMock.On("FindCredentialsByUserId").Return([]repository.PasskeyCredential{})
+
+		passkeyUsecase := usecase.NewPasskeyUsecaseImpl(passkeyRepositoryMock, userRepositoryMock, nil, nil, nil)
+		settings, err := passkeyUsecase.UpdateSettings(100, repository.PasskeySettings{Required: true})
+
+		assert.Equal(t, settings, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("register a passkey before requiring it"), ErrorCode: http.StatusBadRequest})
+	})
+
+	t.Run("removing the last passkey drops the requirement", func(t *testing.T) {
+		passkeyUser := mockUser
+		passkeyUser.PasskeyRequired = true
+
+		passkeyRepositoryMock := new(mocks.PasskeyRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		userRepositoryMock.On("FindById").Return(passkeyUser)
+		userRepositoryMock.On("Update", mock.MatchedBy(func(user repository.User) bool { return !user.PasskeyRequired })).Return(mockUser)
+		passkeyRepositoryMock.On("FindCredentialsByUserId").Return([]repository.PasskeyCredential{newSoftwareAuthenticator().stored(100, 0)})
+		passkeyRepositoryMock.On("DeleteCredential").Return()
+		auditUsecaseMock.On("Record").Return(nil)
+
+		passkeyUsecase := usecase.NewPasskeyUsecaseImpl(passkeyRepositoryMock, userRepositoryMock, nil, nil, auditUsecaseMock)
+		passkey, err := passkeyUsecase.RemovePasskey(100, 1)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, passkey.ID, uint64(1))
+		userRepositoryMock.AssertCalled(t, "Update", mock.Anything)
+	})
+
+	t.Run("passkey of another user", func(t *testing.T) {
+		passkeyRepositoryMock := new(mocks.PasskeyRepositoryMock)
+		passkeyRepositoryMock.On("FindCredentialsByUserId").Return([]repository.PasskeyCredential{})
+
+		passkeyUsecase := usecase.NewPasskeyUsecaseImpl(passkeyRepositoryMock, nil, nil, nil, nil)
+		passkey, err := passkeyUsecase.RemovePasskey(100, 1)
+
+		assert.Equal(t, passkey, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("passkey not found"), ErrorCode: http.StatusNotFound})
+	})
+}
@@ -0,0 +1,331 @@
+package usecase
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// cryptAlphabet is the base64 alphabet of crypt(3) and phpass.
+const cryptAlphabet = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// Imported hashes are verified on every login attempt, so their cost parameters are capped at what the
+// exporting systems use in practice; a hash above them would let one login request burn minutes of CPU or
+// gigabytes of memory.
+const (
+	maxImportedBcryptCost = 16
+	maxPBKDF2Iterations   = 5000000
+	maxScryptN            = 1 << 20
+	maxScryptRP           = 32
+	maxScryptMemory       = 256 << 20 // 128·N·r bytes
+	maxSHA512CryptRounds  = 1000000
+	maxPHPassCountLog2    = 16
+)
+
+var errUnsupportedPasswordHash = errors.New("unsupported password hash")
+
+// verifyPassword checks password against a stored hash. Hashes imported from other systems report legacy,
+// so callers can replace them with bcrypt once the password is known to be right.
+func verifyPassword(passwordHash string, password string) (ok bool, legacy bool) {
+	if verify, err := parseLegacyPasswordHash(passwordHash); err == nil {
+		return verify(password), true
+	}
+	return bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password)) == nil, false
+}
+
+// isSupportedPasswordHash reports whether a pre-hashed password can be verified on login.
+func isSupportedPasswordHash(passwordHash string) bool {
+	if cost, err := bcrypt.Cost([]byte(passwordHash)); err == nil {
+		return cost <= maxImportedBcryptCost
+	}
+	_, err := parseLegacyPasswordHash(passwordHash)
+	return err == nil
+}
+
+// parseLegacyPasswordHash recognizes the hash formats of Django (PBKDF2-SHA256 and scrypt), crypt(3)
+// (md5-crypt and sha512-crypt) and phpass, and returns a function that verifies passwords against it.
+func parseLegacyPasswordHash(passwordHash string) (func(password string) bool, error) {
+	switch {
+	case strings.HasPrefix(passwordHash, "pbkdf2_sha256$"):
+		return parseDjangoPBKDF2(passwordHash)
+	case strings.HasPrefix(passwordHash, "scrypt$"):
+		return parseDjangoScrypt(passwordHash)
+	case strings.HasPrefix(passwordHash, "$1$"):
+		return parseMD5Crypt(passwordHash)
+	case strings.HasPrefix(passwordHash, "$6$"):
+		return parseSHA512Crypt(passwordHash)
+	case strings.HasPrefix(passwordHash, "$P$"), strings.HasPrefix(passwordHash, "$H$"):
+		return parsePHPass(passwordHash)
+	}
+	return nil, errUnsupportedPasswordHash
+}
+
+func equalHash(computed []byte, expected []byte) bool {
+	return subtle.ConstantTimeCompare(computed, expected) == 1
+}
+
+// parseDjangoPBKDF2 reads pbkdf2_sha256$<iterations>$<salt>$<base64 key>.
+func parseDjangoPBKDF2(passwordHash string) (func(password string) bool, error) {
+	parts := strings.Split(passwordHash, "$")
+	if len(parts) != 4 {
+		return nil, errUnsupportedPasswordHash
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations < 1 || iterations > maxPBKDF2Iterations {
+		return nil, errUnsupportedPasswordHash
+	}
+	expected, err := base64.StdEncoding.DecodeString(parts[3])
+	if err != nil || len(expected) == 0 {
+		return nil, errUnsupportedPasswordHash
+	}
+
+	return func(password string) bool {
+		return equalHash(pbkdf2.Key([]byte(password), []byte(parts[2]), iterations, len(expected), sha256.New), expected)
+	}, nil
+}
+
+// parseDjangoScrypt reads scrypt$<salt>$<N>$<r>$<p>$<base64 key>.
+func parseDjangoScrypt(passwordHash string) (func(password string) bool, error) {
+	parts := strings.Split(passwordHash, "$")
+	if len(parts) != 6 {
+		return nil, errUnsupportedPasswordHash
+	}
+	var params [3]int
+	for i, part := range parts[2:5] {
+		value, err := strconv.Atoi(part)
+		if err != nil || value < 1 {
+			return nil, errUnsupportedPasswordHash
+		}
+		params[i] = value
+	}
+	if params[0] < 2 || params[0]&(params[0]-1) != 0 || params[0] > maxScryptN || params[1] > maxScryptRP || params[2] > maxScryptRP ||
+		params[1]*params[2] > maxScryptRP || 128*params[0]*params[1] > maxScryptMemory {
+		return nil, errUnsupportedPasswordHash
+	}
+	expected, err := base64.StdEncoding.DecodeString(parts[5])
+	if err != nil || len(expected) == 0 {
+		return nil, errUnsupportedPasswordHash
+	}
+
+	return func(password string) bool {
+		key, err := scrypt.Key([]byte(password), []byte(parts[1]), params[0], params[1], params[2], len(expected))
+		return err == nil && equalHash(key, expected)
+	}, nil
+}
+
+// cryptEncode writes bytes as crypt(3) base64, taking them in the given groups of three, least significant
+// bits first. A shorter last group is padded with zeros in its high bytes.
+func cryptEncode(sum []byte, groups [][]int) string {
+	var encoded strings.Builder
+	for _, group := range groups {
+		value := 0
+		for _, index := range group {
+			value = value<<8 | int(sum[index])
+		}
+		for i := 0; i <= len(group); i++ {
+			encoded.WriteByte(cryptAlphabet[value&0x3f])
+			value >>= 6
+		}
+	}
+	return encoded.String()
+}
+
+// splitCryptHash separates "<salt>$<checksum>" after a crypt(3) prefix.
+func splitCryptHash(rest string, checksumLength int) (string, string, error) {
+	salt, checksum, found := strings.Cut(rest, "$")
+	if !found || len(checksum) != checksumLength || strings.Trim(checksum, cryptAlphabet) != "" {
+		return "", "", errUnsupportedPasswordHash
+	}
+	return salt, checksum, nil
+}
+
+var md5CryptGroups = [][]int{{0, 6, 12}, {1, 7, 13}, {2, 8, 14}, {3, 9, 15}, {4, 10, 5}, {11}}
+
+// md5Crypt computes the checksum of FreeBSD's md5-crypt ($1$).
+func md5Crypt(password []byte, salt []byte) string {
+	alternate := md5.New()
+	alternate.Write(password)
+	alternate.Write(salt)
+	alternate.Write(password)
+	alternateSum := alternate.Sum(nil)
+
+	digest := md5.New()
+	digest.Write(password)
+	digest.Write([]byte("$1$"))
+	digest.Write(salt)
+	for i := len(password); i > 0; i -= 16 {
+		digest.Write(alternateSum[:min(i, 16)])
+	}
+	for i := len(password); i > 0; i >>= 1 {
+		if i&1 != 0 {
+			digest.Write([]byte{0})
+		} else {
+			digest.Write(password[:1])
+		}
+	}
+	sum := digest.Sum(nil)
+
+	for i := 0; i < 1000; i++ {
+		round := md5.New()
+		if i&1 != 0 {
+			round.Write(password)
+		} else {
+			round.Write(sum)
+		}
+		if i%3 != 0 {
+			round.Write(salt)
+		}
+		if i%7 != 0 {
+			round.Write(password)
+		}
+		if i&1 != 0 {
+			round.Write(sum)
+		} else {
+			round.Write(password)
+		}
+		sum = round.Sum(nil)
+	}
+
+	return cryptEncode(sum, md5CryptGroups)
+}
+
+func parseMD5Crypt(passwordHash string) (func(password string) bool, error) {
+	salt, checksum, err := splitCryptHash(strings.TrimPrefix(passwordHash, "$1$"), 22)
+	if err != nil || len(salt) > 8 {
+		return nil, errUnsupportedPasswordHash
+	}
+
+	return func(password string) bool {
+		return equalHash([]byte(md5Crypt([]byte(password), []byte(salt))), []byte(checksum))
+	}, nil
+}
+
+var sha512CryptGroups = [][]int{
+	{0, 21, 42}, {22, 43, 1}, {44, 2, 23}, {3, 24, 45}, {25, 46, 4}, {47, 5, 26}, {6, 27, 48},
+	{28, 49, 7}, {50, 8, 29}, {9, 30, 51}, {31, 52, 10}, {53, 11, 32}, {12, 33, 54}, {34, 55, 13},
+	{56, 14, 35}, {15, 36, 57}, {37, 58, 16}, {59, 17, 38}, {18, 39, 60}, {40, 61, 19}, {62, 20, 41}, {63},
+}
+
+// repeatSum repeats sum until it is length bytes long.
+func repeatSum(sum []byte, length int) []byte {
+	repeated := make([]byte, 0, length)
+	for len(repeated) < length {
+		repeated = append(repeated, sum[:min(len(sum), length-len(repeated))]...)
+	}
+	return repeated
+}
+
+// sha512Crypt computes the checksum of Ulrich Drepper's sha512-crypt ($6$).
+func sha512Crypt(password []byte, salt []byte, rounds int) string {
+	alternate := sha512.New()
+	alternate.Write(password)
+	alternate.Write(salt)
+	alternate.Write(password)
+	alternateSum := alternate.Sum(nil)
+
+	digest := sha512.New()
+	digest.Write(password)
+	digest.Write(salt)
+	digest.Write(repeatSum(alternateSum, len(password)))
+	for i := len(password); i > 0; i >>= 1 {
+		if i&1 != 0 {
+			digest.Write(alternateSum)
+		} else {
+			digest.Write(password)
+		}
+	}
+	sum := digest.Sum(nil)
+
+	passwordDigest := sha512.New()
+	for range password {
+		passwordDigest.Write(password)
+	}
+	passwordSequence := repeatSum(passwordDigest.Sum(nil), len(password))
+
+	saltDigest := sha512.New()
+	for i := 0; i < 16+int(sum[0]); i++ {
+		saltDigest.Write(salt)
+	}
+	saltSequence := repeatSum(saltDigest.Sum(nil), len(salt))
+
+	for i := 0; i < rounds; i++ {
+		round := sha512.New()
+		if i&1 != 0 {
+			round.Write(passwordSequence)
+		} else {
+			round.Write(sum)
+		}
+		if i%3 != 0 {
+			round.Write(saltSequence)
+		}
+		if i%7 != 0 {
+			round.Write(passwordSequence)
+		}
+		if i&1 != 0 {
+			round.Write(sum)
+		} else {
+			round.Write(passwordSequence)
+		}
+		sum = round.Sum(nil)
+	}
+
+	return cryptEncode(sum, sha512CryptGroups)
+}
+
+// parseSHA512Crypt reads $6$[rounds=<n>$]<salt>$<checksum>. Low rounds are raised and salts truncated as crypt(3)
+// does; rounds above maxSHA512CryptRounds are refused.
+func parseSHA512Crypt(passwordHash string) (func(password string) bool, error) {
+	rest := strings.TrimPrefix(passwordHash, "$6$")
+	rounds := 5000
+	if value, found := strings.CutPrefix(rest, "rounds="); found {
+		roundsText, remainder, ok := strings.Cut(value, "$")
+		parsed, err := strconv.Atoi(roundsText)
+		if !ok || err != nil || parsed > maxSHA512CryptRounds {
+			return nil, errUnsupportedPasswordHash
+		}
+		rounds = max(1000, parsed)
+		rest = remainder
+	}
+
+	salt, checksum, err := splitCryptHash(rest, 86)
+	if err != nil {
+		return nil, err
+	}
+	salt = salt[:min(len(salt), 16)]
+
+	return func(password string) bool {
+		return equalHash([]byte(sha512Crypt([]byte(password), []byte(salt), rounds)), []byte(checksum))
+	}, nil
+}
+
+var phpassGroups = [][]int{{2, 1, 0}, {5, 4, 3}, {8, 7, 6}, {11, 10, 9}, {14, 13, 12}, {15}}
+
+// parsePHPass reads the portable hashes of phpass, as used by WordPress ($P$) and phpBB ($H$).
+func parsePHPass(passwordHash string) (func(password string) bool, error) {
+	if len(passwordHash) != 34 {
+		return nil, errUnsupportedPasswordHash
+	}
+	countLog2 := strings.IndexByte(cryptAlphabet, passwordHash[3])
+	if countLog2 < 7 || countLog2 > maxPHPassCountLog2 {
+		return nil, errUnsupportedPasswordHash
+	}
+	salt := passwordHash[4:12]
+	checksum := passwordHash[12:]
+
+	return func(password string) bool {
+		sum := md5.Sum([]byte(salt + password))
+		for i := 0; i < 1<<countLog2; i++ {
+			sum = md5.Sum(append(sum[:], password...))
+		}
+		return equalHash([]byte(cryptEncode(sum[:], phpassGroups)), []byte(checksum))
+	}, nil
+}
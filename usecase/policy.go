@@ -0,0 +1,484 @@
+package usecase
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	PolicySubjectUser          = "user"
+	PolicySubjectClient        = "client"
+	PolicyResourceUser         = "user"
+	PolicyResourceGroup        = "group"
+	PolicyResourceAttribute    = "attribute"
+	PolicyResourceInvitation   = "invitation"
+	PolicyResourceOrganization = "organization"
+	PolicyResourceClient       = "client"
+	PolicyResourceAudit        = "audit"
+)
+
+var policyOperators = map[string]bool{"eq": true, "ne": true, "in": true, "not_in": true, "contains": true, "exists": true, "gt": true, "gte": true, "lt": true, "lte": true}
+
+type PolicyUsecase interface {
+	Authorize(action string, resourceType string) gin.HandlerFunc
+	Check(organizationId uint64, request repository.AuthorizationRequest) (*repository.AuthorizationDecision, *helper.StandardError)
+	Enforce(organizationId uint64, subject map[string]interface{}, action string, resource map[string]interface{}) *helper.StandardError
+}
+
+type PolicyUsecaseImpl struct {
+	UserRepository         repository.UserRepository
+	OrganizationRepository repository.OrganizationRepository
+	GroupRepository        repository.GroupRepository
+
+	mutex      sync.Mutex
+	policy     *repository.Policy
+	policyPath string
+	modifiedAt time.Time
+}
+
+func parsePolicy(data []byte) (*repository.Policy, error) {
+	var policy repository.Policy
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&policy); err != nil {
+		return nil, err
+	}
+
+	for index, rule := range policy.Rules {
+		if rule.Effect != repository.PolicyEffectAllow && rule.Effect != repository.PolicyEffectDeny {
+			return nil, fmt.Errorf("rule %d: effect must be %q or %q", index, repository.PolicyEffectAllow, repository.PolicyEffectDeny)
+		}
+		if len(rule.Actions) == 0 {
+			return nil, fmt.Errorf("rule %d: at least one action is required", index)
+		}
+		for _, condition := range rule.Conditions {
+			if !policyOperators[condition.Operator] {
+				return nil, fmt.Errorf("rule %d: unsupported operator %q", index, condition.Operator)
+			}
+			if !validPolicyAttribute(condition.Attribute) || (condition.Reference != "" && !validPolicyAttribute(condition.Reference)) {
+				return nil, fmt.Errorf("rule %d: attributes must start with subject., resource. or environment.", index)
+			}
+		}
+	}
+
+	return &policy, nil
+}
+
+func validPolicyAttribute(name string) bool {
+	return name == "action" || strings.HasPrefix(name, "subject.") || strings.HasPrefix(name, "resource.") || strings.HasPrefix(name, "environment.")
+}
+
+// currentPolicy returns the rules in POLICY_FILE, reading the file again whenever it changes on disk.
+// A file that fails to load after a good one keeps the previous rules in force, so a broken edit does not
+// lock everyone out. It returns nil when no policy file is configured.
+func (t *PolicyUsecaseImpl) currentPolicy() (*repository.Policy, error) {
+	path := os.Getenv("POLICY_FILE")
+	if path == "" {
+		return nil, nil
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	info, err := os.Stat(path)
+	if err == nil && t.policy != nil && path == t.policyPath && info.ModTime().Equal(t.modifiedAt) {
+		return t.policy, nil
+	}
+
+	var policy *repository.Policy
+	if err == nil {
+		var data []byte
+		if data, err = os.ReadFile(path); err == nil {
+			policy, err = parsePolicy(data)
+		}
+	}
+
+	if err != nil {
+		if t.policy == nil || path != t.policyPath {
+			return nil, err
+		}
+		log.Printf("Failed to reload policy file %s, keeping the previous rules: %v", path, err)
+		if info != nil {
+			t.modifiedAt = info.ModTime()
+		}
+		return t.policy, nil
+	}
+
+	log.Printf("Loaded %d policy rules from %s", len(policy.Rules), path)
+	t.policy, t.policyPath, t.modifiedAt = policy, path, info.ModTime()
+	return policy, nil
+}
+
+// normalizePolicyValue lets attributes built from Go values compare equal to the same values decoded from JSON.
+func normalizePolicyValue(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case int:
+		return float64(typed)
+	case int64:
+		return float64(typed)
+	case uint:
+		return float64(typed)
+	case uint64:
+		return float64(typed)
+	case json.Number:
+		number, _ := typed.Float64()
+		return number
+	case []string:
+		values := make([]interface{}, len(typed))
+		for index, item := range typed {
+			values[index] = item
+		}
+		return values
+	}
+	return value
+}
+
+func policyValuesEqual(actual interface{}, expected interface{}) bool {
+	return reflect.DeepEqual(normalizePolicyValue(actual), normalizePolicyValue(expected))
+}
+
+func policyListContains(list interface{}, item interface{}) bool {
+	values, ok := normalizePolicyValue(list).([]interface{})
+	if !ok {
+		return false
+	}
+	for _, value := range values {
+		if policyValuesEqual(value, item) {
+			return true
+		}
+	}
+	return false
+}
+
+type policyAttributes struct {
+	subject     map[string]interface{}
+	action      string
+	resource    map[string]interface{}
+	environment map[string]interface{}
+}
+
+func (attributes policyAttributes) lookup(name string) (interface{}, bool) {
+	if name == "action" {
+		return attributes.action, true
+	}
+
+	source, key, _ := strings.Cut(name, ".")
+	var value interface{}
+	var found bool
+	switch source {
+	case "subject":
+		value, found = attributes.subject[key]
+	case "resource":
+		value, found = attributes.resource[key]
+	case "environment":
+		value, found = attributes.environment[key]
+	}
+	return value, found && value != nil
+}
+
+// conditionHolds never holds for a missing attribute except through "exists", so rules fail closed.
+func conditionHolds(condition repository.PolicyCondition, attributes policyAttributes) bool {
+	actual, found := attributes.lookup(condition.Attribute)
+	if condition.Operator == "exists" {
+		return found
+	}
+	if !found {
+		return false
+	}
+
+	expected := condition.Value
+	if condition.Reference != "" {
+		if expected, found = attributes.lookup(condition.Reference); !found {
+			return false
+		}
+	}
+
+	switch condition.Operator {
+	case "eq":
+		return policyValuesEqual(actual, expected)
+	case "ne":
+		return !policyValuesEqual(actual, expected)
+	case "in":
+		return policyListContains(expected, actual)
+	case "not_in":
+		return !policyListContains(expected, actual)
+	case "contains":
+		return policyListContains(actual, expected)
+	}
+
+	actualNumber, actualOk := normalizePolicyValue(actual).(float64)
+	expectedNumber, expectedOk := normalizePolicyValue(expected).(float64)
+	if !actualOk || !expectedOk {
+		return false
+	}
+	switch condition.Operator {
+	case "gt":
+		return actualNumber > expectedNumber
+	case "gte":
+		return actualNumber >= expectedNumber
+	case "lt":
+		return actualNumber < expectedNumber
+	case "lte":
+		return actualNumber <= expectedNumber
+	}
+	return false
+}
+
+func ruleMatches(rule repository.PolicyRule, attributes policyAttributes) bool {
+	actionMatches := false
+	for _, action := range rule.Actions {
+		if action == attributes.action || (strings.HasSuffix(action, "*") && strings.HasPrefix(attributes.action, strings.TrimSuffix(action, "*"))) {
+			actionMatches = true
+			break
+		}
+	}
+	if !actionMatches {
+		return false
+	}
+
+	if len(rule.Resources) != 0 && !policyListContains(rule.Resources, attributes.resource["type"]) {
+		return false
+	}
+
+	for _, condition := range rule.Conditions {
+		if !conditionHolds(condition, attributes) {
+			return false
+		}
+	}
+	return true
+}
+
+// evaluatePolicy denies unless an allow rule matches, and any matching deny rule overrides the allow rules.
+func evaluatePolicy(policy *repository.Policy, attributes policyAttributes) repository.AuthorizationDecision {
+	decision := repository.AuthorizationDecision{}
+	for _, rule := range policy.Rules {
+		if !ruleMatches(rule, attributes) {
+			continue
+		}
+		if rule.Effect == repository.PolicyEffectDeny {
+			return repository.AuthorizationDecision{Allowed: false, Rule: rule.Name}
+		}
+		if !decision.Allowed {
+			decision = repository.AuthorizationDecision{Allowed: true, Rule: rule.Name}
+		}
+	}
+	return decision
+}
+
+func policyUserId(value interface{}) uint64 {
+	switch typed := normalizePolicyValue(value).(type) {
+	case float64:
+		if typed > 0 {
+			return uint64(typed)
+		}
+	case string:
+		userId, _ := strconv.ParseUint(typed, 10, 64)
+		return userId
+	}
+	return 0
+}
+
+// organizationUser describes a user as seen from an organization: its home organization and role there.
+// Users that neither live in nor are members of the organization are not described at all.
+func (t *PolicyUsecaseImpl) organizationUser(organizationId uint64, userId uint64) map[string]interface{} {
+	user := t.UserRepository.FindById(userId)
+	if user.ID == 0 {
+		return nil
+	}
+
+	role := ""
+	if membership := t.OrganizationRepository.FindMembership(organizationId, user.ID); membership.ID != 0 {
+		role = membership.Role
+	} else if organizationOrDefault(user.OrganizationID) == organizationId {
+		role = repository.OrganizationRoleMember
+	} else {
+		return nil
+	}
+
+	return map[string]interface{}{
+		"id":                user.ID,
+		"username":          user.Username,
+		"organization_id":   organizationOrDefault(user.OrganizationID),
+		"organization_role": role,
+		"roles":             strings.Fields(user.Roles),
+		"groups":            effectiveGroups(t.GroupRepository, user.ID, organizationId),
+		"disabled":          user.DisabledAt != nil,
+	}
+}
+
+// resolveAttributes overrides the attributes a caller sent with the ones stored for the user it names.
+func resolveAttributes(sent map[string]interface{}, stored map[string]interface{}) map[string]interface{} {
+	attributes := map[string]interface{}{}
+	for name, value := range sent {
+		attributes[name] = value
+	}
+	for name, value := range stored {
+		attributes[name] = value
+	}
+	return attributes
+}
+
+// Check decides whether the subject may perform the action on the resource. Subjects and user resources
+// given by id are filled in with their stored attributes; the subject always acts in the given organization.
+func (t *PolicyUsecaseImpl) Check(organizationId uint64, request repository.AuthorizationRequest) (*repository.AuthorizationDecision, *helper.StandardError) {
+	policy, err := t.currentPolicy()
+	if err != nil {
+		log.Printf("Failed to load policy file: %v", err)
+		return nil, &helper.StandardError{Error: errors.New("authorization policy could not be loaded"), ErrorCode: http.StatusInternalServerError}
+	}
+	if policy == nil {
+		return nil, &helper.StandardError{Error: errors.New("authorization policy is not configured"), ErrorCode: http.StatusServiceUnavailable}
+	}
+
+	var storedSubject map[string]interface{}
+	if request.Subject["type"] == nil || request.Subject["type"] == PolicySubjectUser {
+		if userId := policyUserId(request.Subject["id"]); userId != 0 {
+			storedSubject = t.organizationUser(organizationId, userId)
+		}
+	}
+	subject := resolveAttributes(request.Subject, storedSubject)
+	if subject["type"] == nil {
+		subject["type"] = PolicySubjectUser
+	}
+	subject["organization_id"] = organizationId
+
+	var storedResource map[string]interface{}
+	if request.Resource["type"] == PolicyResourceUser {
+		if userId := policyUserId(request.Resource["id"]); userId != 0 {
+			storedResource = t.organizationUser(organizationId, userId)
+		}
+	}
+	resource := resolveAttributes(request.Resource, storedResource)
+
+	now := time.Now().UTC()
+	environment := resolveAttributes(map[string]interface{}{
+		"time":    now.Format(time.RFC3339),
+		"hour":    now.Hour(),
+		"weekday": strings.ToLower(now.Weekday().String()),
+	}, request.Environment)
+	environment["organization_id"] = organizationId
+
+	decision := evaluatePolicy(policy, policyAttributes{subject: subject, action: request.Action, resource: resource, environment: environment})
+	return &decision, nil
+}
+
+func policyConfigured() bool {
+	return os.Getenv("POLICY_FILE") != ""
+}
+
+var errPolicyDenied = &helper.StandardError{Error: errors.New("access denied by policy"), ErrorCode: http.StatusForbidden}
+
+// PolicySubject describes the caller of a request: the current user, or the OAuth client of a client credentials token.
+func PolicySubject(c *gin.Context) map[string]interface{} {
+	subject := map[string]interface{}{}
+	if currentUserId, ok := c.Get("currentUserId"); ok {
+		subject["id"] = currentUserId
+	} else if currentClientId, ok := c.Get("currentClientId"); ok {
+		subject["type"] = PolicySubjectClient
+		subject["client_id"] = currentClientId
+	}
+	if scopes, ok := c.Get("currentScopes"); ok {
+		subject["scopes"] = scopes
+	}
+	return subject
+}
+
+// Enforce lets usecases check the policy for each resource they touch, e.g. every user an import updates.
+// Without POLICY_FILE, and for a nil subject (the command line), it allows everything.
+func (t *PolicyUsecaseImpl) Enforce(organizationId uint64, subject map[string]interface{}, action string, resource map[string]interface{}) *helper.StandardError {
+	if !policyConfigured() || subject == nil {
+		return nil
+	}
+
+	decision, err := t.Check(organizationId, repository.AuthorizationRequest{Subject: subject, Action: action, Resource: resource})
+	if err != nil {
+		return err
+	}
+	if !decision.Allowed {
+		return errPolicyDenied
+	}
+	return nil
+}
+
+// globalPolicyResources are shared by all organizations, so they carry no organization_id and rules
+// comparing it never match them.
+var globalPolicyResources = map[string]bool{PolicyResourceAudit: true}
+
+// policyResource describes the resource named by the ":id" path parameter, or the organization's collection
+// of that type when the route has none. Users are looked up by Check; other resources are already limited to
+// the current organization by their usecases, except organizations, which are their own organization.
+func policyResource(c *gin.Context, organizationId uint64, resourceType string) map[string]interface{} {
+	resource := map[string]interface{}{"type": resourceType}
+	resourceId := c.Param("id")
+	if resourceId != "" {
+		resource["id"] = resourceId
+	}
+
+	switch {
+	case resourceType == PolicyResourceUser && resourceId != "":
+	case globalPolicyResources[resourceType]:
+	case resourceType == PolicyResourceOrganization && resourceId != "":
+		resource["organization_id"], _ = strconv.ParseUint(resourceId, 10, 64)
+	default:
+		resource["organization_id"] = organizationId
+	}
+	return resource
+}
+
+// Authorize guards a route with the policy. Without POLICY_FILE it lets every request through, leaving
+// access to the scope checks.
+func (t *PolicyUsecaseImpl) Authorize(action string, resourceType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !policyConfigured() {
+			c.Next()
+			return
+		}
+
+		organizationId := repository.DefaultOrganizationID
+		if currentOrganizationId, ok := c.Value("currentOrganizationId").(uint64); ok && currentOrganizationId != 0 {
+			organizationId = currentOrganizationId
+		}
+
+		decision, err := t.Check(organizationId, repository.AuthorizationRequest{
+			Subject:     PolicySubject(c),
+			Action:      action,
+			Resource:    policyResource(c, organizationId, resourceType),
+			Environment: map[string]interface{}{"ip": c.ClientIP()},
+		})
+		if err != nil {
+			c.JSON(int(err.ErrorCode), gin.H{"error": err.Error.Error()})
+			c.Abort()
+			return
+		}
+
+		if !decision.Allowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": errPolicyDenied.Error.Error()})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func NewPolicyUsecaseImpl(userRepository repository.UserRepository, organizationRepository repository.OrganizationRepository, groupRepository repository.GroupRepository) PolicyUsecase {
+	return &PolicyUsecaseImpl{
+		UserRepository:         userRepository,
+		OrganizationRepository: organizationRepository,
+		GroupRepository:        groupRepository,
+	}
+}
@@ -0,0 +1,263 @@
+package usecase_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/stretchr/testify/mock"
+)
+
+func policyGroupRepository() *mocks.GroupRepositoryMock {
+	groupRepositoryMock := new(mocks.GroupRepositoryMock)
+	groupRepositoryMock.On("FindParents", mock.Anything, mock.Anything).Return([]repository.GroupMember{})
+	groupRepositoryMock.On("FindByIds", mock.Anything).Return([]repository.Group{})
+	return groupRepositoryMock
+}
+
+func writePolicy(t *testing.T, path string, policy string, modifiedAt time.Time) {
+	if err := os.WriteFile(path, []byte(policy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modifiedAt, modifiedAt); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPolicyCheck(t *testing.T) {
+	admin := repository.User{ID: 100, Username: "admin", OrganizationID: 3}
+	member := repository.User{ID: 101, Username: "member", OrganizationID: 2}
+	deleteMember := repository.AuthorizationRequest{
+		Subject:  map[string]interface{}{"id": float64(100)},
+		Action:   "users:delete",
+		Resource: map[string]interface{}{"type": "user", "id": float64(101)},
+	}
+
+	t.Run("org admin deletes a member of their org", func(t *testing.T) {
+		t.Setenv("POLICY_FILE", "../policy.example.json")
+
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		organizationRepositoryMock := new(mocks.OrganizationRepositoryMock)
+		userRepositoryMock.On("FindById").Return(admin).Once()
+		userRepositoryMock.On("FindById").Return(member).Once()
+		organizationRepositoryMock.On("FindMembership", uint64(2), uint64(100)).Return(repository.Membership{ID: 1, OrganizationID: 2, UserID: 100, Role: repository.OrganizationRoleAdmin})
+		organizationRepositoryMock.On("FindMembership", uint64(2), uint64(101)).Return(repository.Membership{})
+
+		policyUsecase := usecase.NewPolicyUsecaseImpl(userRepositoryMock, organizationRepositoryMock, policyGroupRepository())
+		decision, err := policyUsecase.Check(2, deleteMember)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, decision, &repository.AuthorizationDecision{Allowed: true, Rule: "org-admins-manage-users"})
+	})
+
+	t.Run("org admin deletes another admin", func(t *testing.T) {
+		t.Setenv("POLICY_FILE", "../policy.example.json")
+
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		organizationRepositoryMock := new(mocks.OrganizationRepositoryMock)
+		userRepositoryMock.On("FindById").Return(admin).Once()
+		userRepositoryMock.On("FindById").Return(member).Once()
+		organizationRepositoryMock.On("FindMembership", uint64(2), mock.Anything).Return(repository.Membership{ID: 1, OrganizationID: 2, Role: repository.OrganizationRoleAdmin})
+
+		policyUsecase := usecase.NewPolicyUsecaseImpl(userRepositoryMock, organizationRepositoryMock, policyGroupRepository())
+		decision, err := policyUsecase.Check(2, deleteMember)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, decision, &repository.AuthorizationDecision{Allowed: false, Rule: "admins-cannot-delete-admins"})
+	})
+
+	t.Run("member of another organization", func(t *testing.T) {
+		t.Setenv("POLICY_FILE", "../policy.example.json")
+
+		outsider := repository.User{ID: 101, Username: "outsider", OrganizationID: 4}
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		organizationRepositoryMock := new(mocks.OrganizationRepositoryMock)
+		userRepositoryMock.On("FindById").Return(admin).Once()
+		userRepositoryMock.On("FindById").Return(outsider).Once()
+		organizationRepositoryMock.On("FindMembership", uint64(2), uint64(100)).Return(repository.Membership{ID: 1, OrganizationID: 2, UserID: 100, Role: repository.OrganizationRoleAdmin})
+		organizationRepositoryMock.On("FindMembership", uint64(2), uint64(101)).Return(repository.Membership{})
+
+		policyUsecase := usecase.NewPolicyUsecaseImpl(userRepositoryMock, organizationRepositoryMock, policyGroupRepository())
+		decision, err := policyUsecase.Check(2, deleteMember)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, decision, &repository.AuthorizationDecision{Allowed: false})
+	})
+
+	t.Run("service account attributes are taken as sent", func(t *testing.T) {
+		t.Setenv("POLICY_FILE", "../policy.example.json")
+
+		policyUsecase := usecase.NewPolicyUsecaseImpl(nil, nil, nil)
+		decision, err := policyUsecase.Check(2, repository.AuthorizationRequest{
+			Subject:  map[string]interface{}{"type": "client", "client_id": "ci", "scopes": []interface{}{"users:read", "users:write"}},
+			Action:   "users:create",
+			Resource: map[string]interface{}{"type": "user"},
+		})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, decision.Allowed, true)
+	})
+
+	t.Run("policy not configured", func(t *testing.T) {
+		t.Setenv("POLICY_FILE", "")
+
+		policyUsecase := usecase.NewPolicyUsecaseImpl(nil, nil, nil)
+		decision, err := policyUsecase.Check(2, deleteMember)
+
+		assert.Equal(t, decision, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("authorization policy is not configured"), ErrorCode: http.StatusServiceUnavailable})
+	})
+}
+
+func TestPolicyConditions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	t.Setenv("POLICY_FILE", path)
+	writePolicy(t, path, `{"rules":[{"name":"office","effect":"allow","actions":["reports:*"],"resources":["report"],"conditions":[
+		{"attribute":"subject.department","operator":"in","value":["finance","audit"]},
+		{"attribute":"resource.owner","operator":"ne","reference":"subject.client_id"},
+		{"attribute":"resource.classification","operator":"not_in","value":["secret"]},
+		{"attribute":"environment.hour","operator":"gte","value":8},
+		{"attribute":"environment.hour","operator":"lt","value":18},
+		{"attribute":"environment.ip","operator":"exists"}]}]}`, time.Now())
+
+	policyUsecase := usecase.NewPolicyUsecaseImpl(nil, nil, nil)
+	check := func(department string, hour int, environment map[string]interface{}) bool {
+		environment["hour"] = hour
+		decision, err := policyUsecase.Check(2, repository.AuthorizationRequest{
+			Subject:     map[string]interface{}{"type": "client", "client_id": "reporting", "department": department},
+			Action:      "reports:read",
+			Resource:    map[string]interface{}{"type": "report", "owner": "billing", "classification": "internal"},
+			Environment: environment,
+		})
+		assert.Equal(t, err, nil)
+		return decision.Allowed
+	}
+
+	assert.Equal(t, check("finance", 9, map[string]interface{}{"ip": "10.0.0.1"}), true)
+	assert.Equal(t, check("sales", 9, map[string]interface{}{"ip": "10.0.0.1"}), false)
+	assert.Equal(t, check("audit", 18, map[string]interface{}{"ip": "10.0.0.1"}), false)
+	assert.Equal(t, check("audit", 9, map[string]interface{}{}), false)
+}
+
+func TestPolicyReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	t.Setenv("POLICY_FILE", path)
+	request := repository.AuthorizationRequest{Subject: map[string]interface{}{"type": "client"}, Action: "users:list", Resource: map[string]interface{}{"type": "user"}}
+	policyUsecase := usecase.NewPolicyUsecaseImpl(nil, nil, nil)
+
+	writePolicy(t, path, `{"rules":[{"name":"everyone","effect":"allow","actions":["*"]}]}`, time.Now().Add(-time.Hour))
+	decision, _ := policyUsecase.Check(2, request)
+	assert.Equal(t, decision.Allowed, true)
+
+	writePolicy(t, path, `{"rules":[{"name":"nobody","effect":"deny","actions":["*"]}]}`, time.Now().Add(-time.Minute))
+	decision, _ = policyUsecase.Check(2, request)
+	assert.Equal(t, decision, &repository.AuthorizationDecision{Allowed: false, Rule: "nobody"})
+
+	writePolicy(t, path, `{"rules":[{"name":"broken","effect":"maybe","actions":["*"]}]}`, time.Now())
+	decision, err := policyUsecase.Check(2, request)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, decision.Rule, "nobody")
+
+	invalidUsecase := usecase.NewPolicyUsecaseImpl(nil, nil, nil)
+	_, err = invalidUsecase.Check(2, request)
+	assert.Equal(t, err, helper.StandardError{Error: errors.New("authorization policy could not be loaded"), ErrorCode: http.StatusInternalServerError})
+}
+
+func TestPolicyAuthorize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	serve := func(policyUsecase usecase.PolicyUsecase) int {
+		router := gin.New()
+		router.Use(func(c *gin.Context) {
+			c.Set("currentOrganizationId", uint64(2))
+			c.Set("currentUserId", uint64(100))
+			c.Next()
+		})
+		router.DELETE("/users/:id", policyUsecase.Authorize("users:delete", usecase.PolicyResourceUser), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/users/101", nil)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("denied", func(t *testing.T) {
+		t.Setenv("POLICY_FILE", "../policy.example.json")
+
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		organizationRepositoryMock := new(mocks.OrganizationRepositoryMock)
+		userRepositoryMock.On("FindById").Return(repository.User{ID: 100, OrganizationID: 2}).Once()
+		userRepositoryMock.On("FindById").Return(repository.User{ID: 101, OrganizationID: 2}).Once()
+		organizationRepositoryMock.On("FindMembership", uint64(2), mock.Anything).Return(repository.Membership{})
+
+		assert.Equal(t, serve(usecase.NewPolicyUsecaseImpl(userRepositoryMock, organizationRepositoryMock, policyGroupRepository())), http.StatusForbidden)
+	})
+
+	t.Run("policy not configured", func(t *testing.T) {
+		t.Setenv("POLICY_FILE", "")
+
+		assert.Equal(t, serve(usecase.NewPolicyUsecaseImpl(nil, nil, nil)), http.StatusOK)
+	})
+
+	t.Run("resources other than users belong to the current organization", func(t *testing.T) {
+		t.Setenv("POLICY_FILE", "../policy.example.json")
+
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		organizationRepositoryMock := new(mocks.OrganizationRepositoryMock)
+		userRepositoryMock.On("FindById").Return(repository.User{ID: 100, OrganizationID: 2})
+		organizationRepositoryMock.On("FindMembership", uint64(2), uint64(100)).Return(repository.Membership{ID: 1, Role: repository.OrganizationRoleAdmin})
+		policyUsecase := usecase.NewPolicyUsecaseImpl(userRepositoryMock, organizationRepositoryMock, policyGroupRepository())
+
+		router := gin.New()
+		router.Use(func(c *gin.Context) {
+			c.Set("currentOrganizationId", uint64(2))
+			c.Set("currentUserId", uint64(100))
+			c.Next()
+		})
+		ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+		router.PUT("/groups/:id", policyUsecase.Authorize("groups:update", usecase.PolicyResourceGroup), ok)
+		router.PUT("/organizations/:id/members/:userId", policyUsecase.Authorize("organizations:update", usecase.PolicyResourceOrganization), ok)
+		router.DELETE("/clients/:id", policyUsecase.Authorize("clients:delete", usecase.PolicyResourceClient), ok)
+
+		for path, code := range map[string]int{"/groups/7": http.StatusOK, "/organizations/2/members/5": http.StatusOK, "/organizations/3/members/5": http.StatusForbidden} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPut, path, nil)
+			router.ServeHTTP(w, req)
+			assert.Equal(t, w.Code, code)
+		}
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/clients/ci", nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, w.Code, http.StatusOK)
+	})
+}
+
+func TestPolicyEnforce(t *testing.T) {
+	userRepositoryMock := new(mocks.UserRepositoryMock)
+	organizationRepositoryMock := new(mocks.OrganizationRepositoryMock)
+	userRepositoryMock.On("FindById").Return(repository.User{ID: 100, OrganizationID: 2})
+	organizationRepositoryMock.On("FindMembership", uint64(2), mock.Anything).Return(repository.Membership{ID: 1, Role: repository.OrganizationRoleAdmin})
+	policyUsecase := usecase.NewPolicyUsecaseImpl(userRepositoryMock, organizationRepositoryMock, policyGroupRepository())
+	otherAdmin := map[string]interface{}{"type": usecase.PolicyResourceUser, "id": uint64(101)}
+
+	t.Setenv("POLICY_FILE", "../policy.example.json")
+	assert.Equal(t, policyUsecase.Enforce(2, map[string]interface{}{"id": uint64(100)}, "users:update", otherAdmin), nil)
+	assert.Equal(t, policyUsecase.Enforce(2, map[string]interface{}{"id": uint64(100)}, "users:delete", otherAdmin), helper.StandardError{Error: errors.New("access denied by policy"), ErrorCode: http.StatusForbidden})
+	assert.Equal(t, policyUsecase.Enforce(2, nil, "users:delete", otherAdmin), nil)
+
+	t.Setenv("POLICY_FILE", "")
+	assert.Equal(t, policyUsecase.Enforce(2, map[string]interface{}{}, "users:delete", otherAdmin), nil)
+}
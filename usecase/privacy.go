@@ -0,0 +1,256 @@
+package usecase
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+type PrivacyUsecase interface {
+	ExportData(userId uint64) ([]byte, *helper.StandardError)
+	RequestErasure(userId uint64, sessionId uint64, confirmation repository.ErasureConfirmation) (*repository.ErasureRequest, *helper.StandardError)
+	GetErasure(userId uint64) (*repository.ErasureRequest, *helper.StandardError)
+	CancelErasure(userId uint64) (*repository.ErasureRequest, *helper.StandardError)
+	ProcessErasures() (int, *helper.StandardError)
+}
+
+type PrivacyUsecaseImpl struct {
+	PrivacyRepository repository.PrivacyRepository
+	UserRepository    repository.UserRepository
+	SessionRepository repository.SessionRepository
+	BlobStore         BlobStore
+	AuditUsecase      AuditUsecase
+}
+
+const (
+	defaultErasureGracePeriod   = 30 * 24 * time.Hour
+	defaultErasureCheckInterval = time.Hour
+	// erasureReauthWindow is how recently a passwordless user must have signed in to request an erasure.
+	erasureReauthWindow = 10 * time.Minute
+)
+
+var (
+	errErasureNotFound  = &helper.StandardError{Error: errors.New("erasure not found"), ErrorCode: http.StatusNotFound}
+	errErasureRequested = &helper.StandardError{Error: errors.New("erasure already requested"), ErrorCode: http.StatusConflict}
+	errUserErased       = &helper.StandardError{Error: errors.New("user is erased"), ErrorCode: http.StatusGone}
+	errErasureReauth    = &helper.StandardError{Error: errors.New("confirm the erasure with your password or sign in again"), ErrorCode: http.StatusForbidden}
+)
+
+// erasureGracePeriod is how long a user can cancel an erasure before it runs.
+func erasureGracePeriod() time.Duration {
+	return sessionDuration("ERASURE_GRACE_PERIOD", defaultErasureGracePeriod)
+}
+
+// ErasureCheckInterval is how often the server looks for erasures whose grace period is over.
+func ErasureCheckInterval() time.Duration {
+	return sessionDuration("ERASURE_CHECK_INTERVAL", defaultErasureCheckInterval)
+}
+
+func (t *PrivacyUsecaseImpl) findUser(userId uint64) (repository.User, *helper.StandardError) {
+	user := t.UserRepository.FindById(userId)
+	if user.ID == 0 {
+		return user, &helper.StandardError{Error: errors.New("user not found"), ErrorCode: http.StatusNotFound}
+	}
+	if user.ErasedAt != nil {
+		return user, errUserErased
+	}
+	return user, nil
+}
+
+// ExportData returns a ZIP archive with one JSON file per kind of data stored about the user.
+func (t *PrivacyUsecaseImpl) ExportData(userId uint64) ([]byte, *helper.StandardError) {
+	user, err := t.findUser(userId)
+	if err != nil {
+		return nil, err
+	}
+	data := t.PrivacyRepository.FindPersonalData(userId)
+
+	files := []struct {
+		Name    string
+		Content interface{}
+	}{
+		{"profile.json", repository.PersonalProfile{
+			ID:              user.ID,
+			OrganizationID:  user.OrganizationID,
+			Username:        user.Username,
+			Email:           user.Email,
+			EmailVerified:   user.EmailVerified,
+			Roles:           user.Roles,
+			ExternalID:      user.ExternalID,
+			PasskeyRequired: user.PasskeyRequired,
+			DisabledAt:      user.DisabledAt,
+			Attributes:      user.Attributes,
+			AvatarURL:       user.AvatarURL,
+			CreatedAt:       user.CreatedAt,
+			UpdatedAt:       user.UpdatedAt,
+		}},
+		{"sessions.json", data.Sessions},
+		{"audit.json", data.AuditEvents},
+		{"consents.json", data.Consents},
+		{"identities.json", data.Identities},
+		{"tokens.json", data.Tokens},
+		{"passkeys.json", data.Passkeys},
+		{"memberships.json", data.Memberships},
+	}
+
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+	for _, file := range files {
+		content, marshalError := json.MarshalIndent(file.Content, "", "  ")
+		if marshalError != nil {
+			return nil, &helper.StandardError{Error: marshalError, ErrorCode: http.StatusInternalServerError}
+		}
+		// A nil slice marshals as null; an export says "none" with an empty list instead.
+		if string(content) == "null" {
+			content = []byte("[]")
+		}
+		fileWriter, createError := writer.Create(file.Name)
+		if createError == nil {
+			_, createError = fileWriter.Write(content)
+		}
+		if createError != nil {
+			return nil, &helper.StandardError{Error: createError, ErrorCode: http.StatusInternalServerError}
+		}
+	}
+	if closeError := writer.Close(); closeError != nil {
+		return nil, &helper.StandardError{Error: closeError, ErrorCode: http.StatusInternalServerError}
+	}
+
+	t.AuditUsecase.Record("user.data_export", userId, userId, "")
+
+	return archive.Bytes(), nil
+}
+
+// RequestErasure schedules the user's erasure after ERASURE_GRACE_PERIOD. The account keeps working until then,
+// so the user can still sign in and cancel.
+func (t *PrivacyUsecaseImpl) RequestErasure(userId uint64, sessionId uint64, confirmation repository.ErasureConfirmation) (*repository.ErasureRequest, *helper.StandardError) {
+	user, err := t.findUser(userId)
+	if err != nil {
+		return nil, err
+	}
+	if !t.recentlyAuthenticated(user, sessionId, confirmation) {
+		return nil, errErasureReauth
+	}
+	if t.PrivacyRepository.FindLatestErasure(userId).Status == repository.ErasureStatusPending {
+		return nil, errErasureRequested
+	}
+
+	request := t.PrivacyRepository.SaveErasure(repository.ErasureRequest{
+		UserID:      userId,
+		RequestedBy: userId,
+		Status:      repository.ErasureStatusPending,
+		ScheduledAt: time.Now().Add(erasureGracePeriod()),
+	})
+	t.AuditUsecase.Record("user.erasure_request", userId, userId, fmt.Sprintf("erasure_id=%d scheduled_at=%s", request.ID, request.ScheduledAt.UTC().Format(time.RFC3339)))
+
+	return &request, nil
+}
+
+// recentlyAuthenticated keeps a stolen token or an unattended browser from erasing the account: the request must
+// carry the user's password, or come from a session signed in within erasureReauthWindow. Personal access tokens
+// have no session, so they can only request an erasure with the password.
+func (t *PrivacyUsecaseImpl) recentlyAuthenticated(user repository.User, sessionId uint64, confirmation repository.ErasureConfirmation) bool {
+	if confirmation.Password != "" {
+		ok, _ := verifyPassword(user.Password, confirmation.Password)
+		return user.Password != "" && ok
+	}
+	if sessionId == 0 {
+		return false
+	}
+
+	session := t.SessionRepository.FindById(sessionId)
+	return session.ID == sessionId && session.UserID == user.ID && time.Since(session.CreatedAt) <= erasureReauthWindow
+}
+
+func (t *PrivacyUsecaseImpl) GetErasure(userId uint64) (*repository.ErasureRequest, *helper.StandardError) {
+	request := t.PrivacyRepository.FindLatestErasure(userId)
+	if request.ID == 0 {
+		return nil, errErasureNotFound
+	}
+
+	return &request, nil
+}
+
+func (t *PrivacyUsecaseImpl) CancelErasure(userId uint64) (*repository.ErasureRequest, *helper.StandardError) {
+	request := t.PrivacyRepository.FindLatestErasure(userId)
+	if request.Status != repository.ErasureStatusPending {
+		return nil, errErasureNotFound
+	}
+
+	cancelledAt := time.Now()
+	request.Status = repository.ErasureStatusCancelled
+	request.CancelledAt = &cancelledAt
+	request = t.PrivacyRepository.UpdateErasure(request)
+	t.AuditUsecase.Record("user.erasure_cancel", userId, userId, fmt.Sprintf("erasure_id=%d", request.ID))
+
+	return &request, nil
+}
+
+// anonymizedUser keeps only what is needed for the audit trail to stay meaningful: the ID, organization and
+// timestamps. Without a password or identities, the user can no longer sign in.
+func anonymizedUser(user repository.User, erasedAt time.Time) repository.User {
+	disabledAt := erasedAt
+	if user.DisabledAt != nil {
+		disabledAt = *user.DisabledAt
+	}
+	return repository.User{
+		ID:             user.ID,
+		OrganizationID: user.OrganizationID,
+		Username:       fmt.Sprintf("erased-%d", user.ID),
+		DisabledAt:     &disabledAt,
+		ErasedAt:       &erasedAt,
+		CreatedAt:      user.CreatedAt,
+	}
+}
+
+// ProcessErasures runs the erasures whose grace period is over and returns how many were completed.
+// A failing erasure stays pending and is retried on the next run.
+func (t *PrivacyUsecaseImpl) ProcessErasures() (int, *helper.StandardError) {
+	now := time.Now()
+	completed := 0
+	var firstError error
+
+	for _, request := range t.PrivacyRepository.FindDueErasures(now) {
+		user := t.UserRepository.FindById(request.UserID)
+		if user.ID != 0 && user.ErasedAt == nil {
+			if err := t.PrivacyRepository.EraseUser(anonymizedUser(user, now)); err != nil {
+				log.Printf("Failed to erase user %d: %v", request.UserID, err)
+				if firstError == nil {
+					firstError = err
+				}
+				continue
+			}
+			if user.AvatarKey != "" {
+				deleteAvatar(t.BlobStore, user.AvatarKey)
+			}
+		}
+
+		request.Status = repository.ErasureStatusCompleted
+		request.CompletedAt = &now
+		t.PrivacyRepository.UpdateErasure(request)
+		t.AuditUsecase.Record("user.erase", request.RequestedBy, request.UserID, fmt.Sprintf("erasure_id=%d", request.ID))
+		completed++
+	}
+
+	if firstError != nil {
+		return completed, &helper.StandardError{Error: firstError, ErrorCode: http.StatusInternalServerError}
+	}
+	return completed, nil
+}
+
+func NewPrivacyUsecaseImpl(privacyRepository repository.PrivacyRepository, userRepository repository.UserRepository, sessionRepository repository.SessionRepository, blobStore BlobStore, auditUsecase AuditUsecase) PrivacyUsecase {
+	return &PrivacyUsecaseImpl{
+		PrivacyRepository: privacyRepository,
+		UserRepository:    userRepository,
+		SessionRepository: sessionRepository,
+		BlobStore:         blobStore,
+		AuditUsecase:      auditUsecase,
+	}
+}
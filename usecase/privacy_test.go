@@ -0,0 +1,215 @@
+package usecase_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestExportData(t *testing.T) {
+	t.Run("test archive contents", func(t *testing.T) {
+		privacyRepositoryMock := new(mocks.PrivacyRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		user := mockUser
+		user.Email = "user@example.com"
+		userRepositoryMock.On("FindById").Return(user)
+		privacyRepositoryMock.On("FindPersonalData", uint64(100)).Return(repository.PersonalData{
+			Sessions:    []repository.Session{{ID: 1, UserID: 100, SessionHash: "secret-session-hash", Device: "Firefox on Linux"}},
+			AuditEvents: []repository.AuditEvent{{ID: 1, Action: "user.login", ActorID: 100, SubjectID: 100}},
+		})
+		auditUsecaseMock.On("Record").Return(nil)
+
+		privacyUsecase := usecase.NewPrivacyUsecaseImpl(privacyRepositoryMock, userRepositoryMock, nil, nil, auditUsecaseMock)
+		data, err := privacyUsecase.ExportData(100)
+		assert.Equal(t, err, nil)
+
+		archive, zipError := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		assert.Equal(t, zipError, nil)
+		files := map[string]string{}
+		for _, file := range archive.File {
+			reader, _ := file.Open()
+			content, _ := io.ReadAll(reader)
+			files[file.Name] = string(content)
+		}
+		assert.Equal(t, len(files), 8)
+		assert.MatchRegex(t, files["profile.json"], `"email": "user@example.com"`)
+		assert.Equal(t, strings.Contains(files["profile.json"], "$2a$"), false)
+		assert.MatchRegex(t, files["sessions.json"], `"device": "Firefox on Linux"`)
+		assert.Equal(t, strings.Contains(files["sessions.json"], "secret-session-hash"), false)
+		assert.MatchRegex(t, files["audit.json"], `"action": "user.login"`)
+		assert.Equal(t, files["consents.json"], "[]")
+		auditUsecaseMock.AssertNumberOfCalls(t, "Record", 1)
+	})
+
+	t.Run("test erased user", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		erasedAt := time.Now()
+		userRepositoryMock.On("FindById").Return(repository.User{ID: 100, Username: "erased-100", ErasedAt: &erasedAt})
+
+		privacyUsecase := usecase.NewPrivacyUsecaseImpl(nil, userRepositoryMock, nil, nil, nil)
+		_, err := privacyUsecase.ExportData(100)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("user is erased"), ErrorCode: http.StatusGone})
+	})
+}
+
+func TestRequestErasure(t *testing.T) {
+	t.Run("test schedules after grace period", func(t *testing.T) {
+		t.Setenv("ERASURE_GRACE_PERIOD", "48h")
+		privacyRepositoryMock := new(mocks.PrivacyRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		userRepositoryMock.On("FindById").Return(mockUser)
+		privacyRepositoryMock.On("FindLatestErasure", uint64(100)).Return(repository.ErasureRequest{ID: 1, Status: repository.ErasureStatusCancelled})
+		privacyRepositoryMock.On("SaveErasure", mock.Anything).Return(func(request repository.ErasureRequest) repository.ErasureRequest {
+			request.ID = 2
+			return request
+		})
+		auditUsecaseMock.On("Record").Return(nil)
+
+		privacyUsecase := usecase.NewPrivacyUsecaseImpl(privacyRepositoryMock, userRepositoryMock, nil, nil, auditUsecaseMock)
+		request, err := privacyUsecase.RequestErasure(100, 0, repository.ErasureConfirmation{Password: "password"})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, request.Status, repository.ErasureStatusPending)
+		assert.Equal(t, request.UserID, uint64(100))
+		assert.Equal(t, time.Until(request.ScheduledAt) > 47*time.Hour && time.Until(request.ScheduledAt) <= 48*time.Hour, true)
+		auditUsecaseMock.AssertNumberOfCalls(t, "Record", 1)
+	})
+
+	t.Run("test already requested", func(t *testing.T) {
+		privacyRepositoryMock := new(mocks.PrivacyRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		userRepositoryMock.On("FindById").Return(mockUser)
+		privacyRepositoryMock.On("FindLatestErasure", uint64(100)).Return(repository.ErasureRequest{ID: 1, Status: repository.ErasureStatusPending})
+
+		privacyUsecase := usecase.NewPrivacyUsecaseImpl(privacyRepositoryMock, userRepositoryMock, nil, nil, nil)
+		_, err := privacyUsecase.RequestErasure(100, 0, repository.ErasureConfirmation{Password: "password"})
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("erasure already requested"), ErrorCode: http.StatusConflict})
+	})
+
+	t.Run("test wrong password", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		userRepositoryMock.On("FindById").Return(mockUser)
+
+		privacyUsecase := usecase.NewPrivacyUsecaseImpl(nil, userRepositoryMock, nil, nil, nil)
+		_, err := privacyUsecase.RequestErasure(100, 0, repository.ErasureConfirmation{Password: "wrong password"})
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("confirm the erasure with your password or sign in again"), ErrorCode: http.StatusForbidden})
+	})
+
+	t.Run("test session signed in recently", func(t *testing.T) {
+		privacyRepositoryMock := new(mocks.PrivacyRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		userRepositoryMock.On("FindById").Return(repository.User{ID: 100, Username: "passwordless"})
+		sessionRepositoryMock.On("FindById").Return(repository.Session{ID: 7, UserID: 100, CreatedAt: time.Now().Add(-time.Minute)})
+		privacyRepositoryMock.On("FindLatestErasure", uint64(100)).Return(repository.ErasureRequest{})
+		privacyRepositoryMock.On("SaveErasure", mock.Anything).Return(repository.ErasureRequest{ID: 1, UserID: 100, Status: repository.ErasureStatusPending})
+		auditUsecaseMock.On("Record").Return(nil)
+
+		privacyUsecase := usecase.NewPrivacyUsecaseImpl(privacyRepositoryMock, userRepositoryMock, sessionRepositoryMock, nil, auditUsecaseMock)
+		request, err := privacyUsecase.RequestErasure(100, 7, repository.ErasureConfirmation{})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, request.Status, repository.ErasureStatusPending)
+	})
+
+	t.Run("test stale session or token without session", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		userRepositoryMock.On("FindById").Return(mockUser)
+		sessionRepositoryMock.On("FindById").Return(repository.Session{ID: 7, UserID: 100, CreatedAt: time.Now().Add(-time.Hour)})
+
+		privacyUsecase := usecase.NewPrivacyUsecaseImpl(nil, userRepositoryMock, sessionRepositoryMock, nil, nil)
+		for _, sessionId := range []uint64{7, 0} {
+			_, err := privacyUsecase.RequestErasure(100, sessionId, repository.ErasureConfirmation{})
+			assert.Equal(t, err, helper.StandardError{Error: errors.New("confirm the erasure with your password or sign in again"), ErrorCode: http.StatusForbidden})
+		}
+	})
+}
+
+func TestCancelErasure(t *testing.T) {
+	privacyRepositoryMock := new(mocks.PrivacyRepositoryMock)
+	auditUsecaseMock := new(mocks.AuditUsecaseMock)
+	privacyRepositoryMock.On("FindLatestErasure", uint64(100)).Return(repository.ErasureRequest{ID: 1, UserID: 100, Status: repository.ErasureStatusPending})
+	privacyRepositoryMock.On("FindLatestErasure", uint64(101)).Return(repository.ErasureRequest{ID: 2, UserID: 101, Status: repository.ErasureStatusCompleted})
+	privacyRepositoryMock.On("UpdateErasure", mock.Anything).Return(func(request repository.ErasureRequest) repository.ErasureRequest { return request })
+	auditUsecaseMock.On("Record").Return(nil)
+
+	privacyUsecase := usecase.NewPrivacyUsecaseImpl(privacyRepositoryMock, nil, nil, nil, auditUsecaseMock)
+
+	request, err := privacyUsecase.CancelErasure(100)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, request.Status, repository.ErasureStatusCancelled)
+	assert.NotEqual(t, request.CancelledAt, nil)
+
+	_, err = privacyUsecase.CancelErasure(101)
+	assert.Equal(t, err, helper.StandardError{Error: errors.New("erasure not found"), ErrorCode: http.StatusNotFound})
+}
+
+func TestProcessErasures(t *testing.T) {
+	t.Run("test anonymizes user", func(t *testing.T) {
+		blobStore := &usecase.LocalBlobStore{Dir: t.TempDir()}
+		if err := blobStore.Put("avatars/100/key/256.png", "image/png", []byte("avatar")); err != nil {
+			t.Fatal(err)
+		}
+		privacyRepositoryMock := new(mocks.PrivacyRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		user := mockUser
+		user.OrganizationID = 2
+		user.Email = "user@example.com"
+		user.AvatarKey = "avatars/100/key"
+		user.Attributes = repository.UserAttributes{"department": "eng"}
+		userRepositoryMock.On("FindById").Return(user)
+		privacyRepositoryMock.On("FindDueErasures", mock.Anything).Return([]repository.ErasureRequest{{ID: 1, UserID: 100, RequestedBy: 100, Status: repository.ErasureStatusPending}})
+		privacyRepositoryMock.On("EraseUser", mock.MatchedBy(func(anonymized repository.User) bool {
+			return anonymized.ID == 100 && anonymized.OrganizationID == 2 && anonymized.Username == "erased-100" && anonymized.Email == "" &&
+				anonymized.Password == "" && anonymized.AvatarKey == "" && anonymized.Attributes == nil && anonymized.DisabledAt != nil && anonymized.ErasedAt != nil
+		})).Return(nil)
+		privacyRepositoryMock.On("UpdateErasure", mock.MatchedBy(func(request repository.ErasureRequest) bool {
+			return request.Status == repository.ErasureStatusCompleted && request.CompletedAt != nil
+		})).Return(repository.ErasureRequest{})
+		auditUsecaseMock.On("Record").Return(nil)
+
+		privacyUsecase := usecase.NewPrivacyUsecaseImpl(privacyRepositoryMock, userRepositoryMock, nil, blobStore, auditUsecaseMock)
+		completed, err := privacyUsecase.ProcessErasures()
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, completed, 1)
+		_, statError := os.Stat(filepath.Join(blobStore.Dir, "avatars/100/key/256.png"))
+		assert.Equal(t, os.IsNotExist(statError), true)
+		privacyRepositoryMock.AssertNumberOfCalls(t, "UpdateErasure", 1)
+		auditUsecaseMock.AssertNumberOfCalls(t, "Record", 1)
+	})
+
+	t.Run("test failed erasure stays pending", func(t *testing.T) {
+		privacyRepositoryMock := new(mocks.PrivacyRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		userRepositoryMock.On("FindById").Return(mockUser)
+		privacyRepositoryMock.On("FindDueErasures", mock.Anything).Return([]repository.ErasureRequest{{ID: 1, UserID: 100, Status: repository.ErasureStatusPending}})
+		privacyRepositoryMock.On("EraseUser", mock.Anything).Return(errors.New("database is locked"))
+
+		privacyUsecase := usecase.NewPrivacyUsecaseImpl(privacyRepositoryMock, userRepositoryMock, nil, nil, nil)
+		completed, err := privacyUsecase.ProcessErasures()
+
+		assert.Equal(t, completed, 0)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("database is locked"), ErrorCode: http.StatusInternalServerError})
+		privacyRepositoryMock.AssertNotCalled(t, "UpdateErasure", mock.Anything)
+	})
+}
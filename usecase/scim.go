@@ -0,0 +1,442 @@
+package usecase
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	scimUserSchemaPrefix = repository.SCIMUserSchema + ":"
+	scimProvider         = "scim"
+	defaultSCIMCount     = 100
+	maxSCIMCount         = 1000
+)
+
+// Errors wrapping these carry the SCIM scimType of the failure.
+var (
+	ErrSCIMInvalidFilter = errors.New("invalidFilter")
+	ErrSCIMInvalidSyntax = errors.New("invalidSyntax")
+	ErrSCIMInvalidValue  = errors.New("invalidValue")
+	ErrSCIMNoTarget      = errors.New("noTarget")
+	ErrSCIMUniqueness    = errors.New("uniqueness")
+)
+
+type SCIMUsecase interface {
+	ListUsers(listRequest repository.SCIMListRequest) (*repository.SCIMListResponse, *helper.StandardError)
+	GetUser(id string) (*repository.SCIMUser, *helper.StandardError)
+	CreateUser(resource repository.SCIMUser) (*repository.SCIMUser, *helper.StandardError)
+	ReplaceUser(id string, resource repository.SCIMUser, ifMatch string) (*repository.SCIMUser, *helper.StandardError)
+	PatchUser(id string, patchRequest repository.SCIMPatchRequest, ifMatch string) (*repository.SCIMUser, *helper.StandardError)
+	DeleteUser(id string, ifMatch string) *helper.StandardError
+}
+
+type SCIMUsecaseImpl struct {
+	UserRepository      repository.UserRepository
+	AttributeRepository repository.AttributeRepository
+	AuditUsecase        AuditUsecase
+}
+
+func scimVersion(user repository.User) string {
+	disabled := user.DisabledAt != nil
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d\x00%s\x00%s\x00%s\x00%t\x00%s", user.ID, user.Username, user.Email, user.ExternalID, disabled, user.Password)))
+	return fmt.Sprintf(`W/"%x"`, sum[:8])
+}
+
+func toSCIMUser(user repository.User) repository.SCIMUser {
+	id := strconv.FormatUint(user.ID, 10)
+	active := user.DisabledAt == nil
+
+	resource := repository.SCIMUser{
+		Schemas:    []string{repository.SCIMUserSchema},
+		ID:         id,
+		ExternalID: user.ExternalID,
+		UserName:   user.Username,
+		Active:     &active,
+		Meta: &repository.SCIMMeta{
+			ResourceType: "User",
+			Created:      user.CreatedAt,
+			LastModified: user.UpdatedAt,
+			Location:     oidcIssuer() + "/scim/v2/Users/" + id,
+			Version:      scimVersion(user),
+		},
+	}
+	if user.Email != "" {
+		resource.Emails = []repository.SCIMEmail{{Value: user.Email, Type: "work", Primary: true}}
+	}
+
+	return resource
+}
+
+func scimAttributes(resource repository.SCIMUser) map[string][]string {
+	attributes := map[string][]string{
+		"id":                {resource.ID},
+		"externalid":        {resource.ExternalID},
+		"username":          {resource.UserName},
+		"active":            {strconv.FormatBool(resource.Active == nil || *resource.Active)},
+		"meta.resourcetype": {resource.Meta.ResourceType},
+		"meta.created":      {resource.Meta.Created.UTC().Format(time.RFC3339)},
+		"meta.lastmodified": {resource.Meta.LastModified.UTC().Format(time.RFC3339)},
+	}
+	for _, email := range resource.Emails {
+		attributes["emails"] = append(attributes["emails"], email.Value)
+		attributes["emails.value"] = append(attributes["emails.value"], email.Value)
+		attributes["emails.type"] = append(attributes["emails.type"], email.Type)
+	}
+	return attributes
+}
+
+func primaryEmail(emails []repository.SCIMEmail) string {
+	for _, email := range emails {
+		if email.Primary {
+			return email.Value
+		}
+	}
+	if len(emails) > 0 {
+		return emails[0].Value
+	}
+	return ""
+}
+
+func matchesVersion(ifMatch string, version string) bool {
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(version, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+func scimBool(value interface{}) (bool, bool) {
+	switch typed := value.(type) {
+	case bool:
+		return typed, true
+	case string:
+		parsed, err := strconv.ParseBool(typed)
+		return parsed, err == nil
+	}
+	return false, false
+}
+
+func scimEmails(value interface{}) ([]repository.SCIMEmail, bool) {
+	values, ok := value.([]interface{})
+	if !ok {
+		values = []interface{}{value}
+	}
+
+	var emails []repository.SCIMEmail
+	for _, item := range values {
+		object, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		email := repository.SCIMEmail{}
+		email.Value, _ = object["value"].(string)
+		email.Type, _ = object["type"].(string)
+		email.Primary, _ = object["primary"].(bool)
+		emails = append(emails, email)
+	}
+	return emails, true
+}
+
+// applySCIMPatchValue sets or removes a single attribute of resource. Attributes the user model
+// has no place for are ignored, so provisioning clients can send their full mapping.
+func applySCIMPatchValue(resource *repository.SCIMUser, path string, value interface{}, remove bool) error {
+	attribute := scimAttributeName(path)
+
+	switch {
+	case attribute == "username":
+		userName, ok := value.(string)
+		if remove || !ok || userName == "" {
+			return fmt.Errorf("%w: userName must be a non-empty string", ErrSCIMInvalidValue)
+		}
+		resource.UserName = userName
+	case attribute == "externalid":
+		externalID, ok := value.(string)
+		if !remove && !ok {
+			return fmt.Errorf("%w: externalId must be a string", ErrSCIMInvalidValue)
+		}
+		resource.ExternalID = externalID
+	case attribute == "active":
+		active, ok := scimBool(value)
+		if remove || !ok {
+			return fmt.Errorf("%w: active must be a boolean", ErrSCIMInvalidValue)
+		}
+		resource.Active = &active
+	case attribute == "password":
+		password, ok := value.(string)
+		if remove || !ok || password == "" {
+			return fmt.Errorf("%w: password must be a non-empty string", ErrSCIMInvalidValue)
+		}
+		resource.Password = password
+	case attribute == "emails":
+		if remove {
+			resource.Emails = nil
+			return nil
+		}
+		emails, ok := scimEmails(value)
+		if !ok {
+			return fmt.Errorf("%w: emails must be a list of email objects", ErrSCIMInvalidValue)
+		}
+		resource.Emails = emails
+	case strings.HasPrefix(attribute, "emails[") || attribute == "emails.value":
+		// Filtered paths such as emails[type eq "work"].value address the single stored email.
+		if remove {
+			resource.Emails = nil
+			return nil
+		}
+		email, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%w: %s must be a string", ErrSCIMInvalidValue, path)
+		}
+		resource.Emails = []repository.SCIMEmail{{Value: email, Type: "work", Primary: true}}
+	}
+
+	return nil
+}
+
+func applySCIMPatch(resource *repository.SCIMUser, operations []repository.SCIMPatchOperation) error {
+	for _, operation := range operations {
+		op := strings.ToLower(operation.Op)
+		if op != "add" && op != "replace" && op != "remove" {
+			return fmt.Errorf("%w: unsupported patch operation %s", ErrSCIMInvalidSyntax, operation.Op)
+		}
+
+		if operation.Path != "" {
+			if err := applySCIMPatchValue(resource, operation.Path, operation.Value, op == "remove"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if op == "remove" {
+			return fmt.Errorf("%w: remove requires a path", ErrSCIMNoTarget)
+		}
+
+		values, ok := operation.Value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%w: a patch without a path needs an object value", ErrSCIMInvalidValue)
+		}
+		for path, value := range values {
+			if err := applySCIMPatchValue(resource, path, value, false); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (t *SCIMUsecaseImpl) findUser(id string) (repository.User, *helper.StandardError) {
+	userId, err := strconv.ParseUint(id, 10, 64)
+	if err == nil {
+		if user := t.UserRepository.FindById(userId); user.ID != 0 {
+			return user, nil
+		}
+	}
+	return repository.User{}, &helper.StandardError{Error: fmt.Errorf("user %s not found", id), ErrorCode: http.StatusNotFound}
+}
+
+// applyResource copies the attributes of a full SCIM resource onto user, leaving the stored
+// password and activation alone when the resource does not mention them. The result must still satisfy the
+// organization's attribute definitions.
+func (t *SCIMUsecaseImpl) applyResource(user *repository.User, resource repository.SCIMUser) *helper.StandardError {
+	if resource.UserName == "" {
+		return &helper.StandardError{Error: fmt.Errorf("%w: userName is required", ErrSCIMInvalidValue), ErrorCode: http.StatusBadRequest}
+	}
+
+	if resource.UserName != user.Username {
+		if existing := t.UserRepository.FindByUsername(resource.UserName); existing.ID != 0 && existing.ID != user.ID {
+			return &helper.StandardError{Error: fmt.Errorf("%w: user %s already exists", ErrSCIMUniqueness, resource.UserName), ErrorCode: http.StatusConflict}
+		}
+	}
+
+	if resource.Password != "" {
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(resource.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return &helper.StandardError{Error: fmt.Errorf("%w: invalid password", ErrSCIMInvalidValue), ErrorCode: http.StatusBadRequest}
+		}
+		user.Password = string(hashedPassword)
+	}
+
+	email := primaryEmail(resource.Emails)
+	if email != user.Email {
+		user.Email = email
+		user.EmailVerified = false
+	}
+
+	if resource.Active != nil {
+		if *resource.Active {
+			user.DisabledAt = nil
+		} else if user.DisabledAt == nil {
+			now := time.Now()
+			user.DisabledAt = &now
+		}
+	}
+
+	user.Username = resource.UserName
+	user.ExternalID = resource.ExternalID
+
+	if err := validateUserAttributes(t.AttributeRepository, t.UserRepository, repository.DefaultOrganizationID, user.ID, user.Attributes); err != nil {
+		scimType := ErrSCIMInvalidValue
+		if err.ErrorCode == http.StatusConflict {
+			scimType = ErrSCIMUniqueness
+		}
+		return &helper.StandardError{Error: fmt.Errorf("%w: %s", scimType, err.Error), ErrorCode: err.ErrorCode}
+	}
+	return nil
+}
+
+func (t *SCIMUsecaseImpl) updateUser(user repository.User, resource repository.SCIMUser) (*repository.SCIMUser, *helper.StandardError) {
+	if err := t.applyResource(&user, resource); err != nil {
+		return nil, err
+	}
+
+	user = t.UserRepository.Update(user)
+	t.AuditUsecase.Record("user.update", 0, user.ID, fmt.Sprintf("provider=%s active=%t", scimProvider, user.DisabledAt == nil))
+
+	updated := toSCIMUser(user)
+	return &updated, nil
+}
+
+func (t *SCIMUsecaseImpl) ListUsers(listRequest repository.SCIMListRequest) (*repository.SCIMListResponse, *helper.StandardError) {
+	filter := func(map[string][]string) bool { return true }
+	if strings.TrimSpace(listRequest.Filter) != "" {
+		parsed, err := parseSCIMFilter(listRequest.Filter)
+		if err != nil {
+			return nil, &helper.StandardError{Error: err, ErrorCode: http.StatusBadRequest}
+		}
+		filter = parsed
+	}
+
+	startIndex := listRequest.StartIndex
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	count := defaultSCIMCount
+	if listRequest.Count != nil {
+		count = *listRequest.Count
+	}
+	if count < 0 {
+		count = 0
+	}
+	if count > maxSCIMCount {
+		count = maxSCIMCount
+	}
+
+	users := t.UserRepository.FindAll()
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+
+	matched := []repository.SCIMUser{}
+	for _, user := range users {
+		resource := toSCIMUser(user)
+		if filter(scimAttributes(resource)) {
+			matched = append(matched, resource)
+		}
+	}
+
+	resources := []repository.SCIMUser{}
+	if startIndex <= len(matched) {
+		end := startIndex - 1 + count
+		if end > len(matched) {
+			end = len(matched)
+		}
+		resources = matched[startIndex-1 : end]
+	}
+
+	return &repository.SCIMListResponse{
+		Schemas:      []string{repository.SCIMListResponseSchema},
+		TotalResults: len(matched),
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}, nil
+}
+
+func (t *SCIMUsecaseImpl) GetUser(id string) (*repository.SCIMUser, *helper.StandardError) {
+	user, err := t.findUser(id)
+	if err != nil {
+		return nil, err
+	}
+
+	resource := toSCIMUser(user)
+	return &resource, nil
+}
+
+func (t *SCIMUsecaseImpl) CreateUser(resource repository.SCIMUser) (*repository.SCIMUser, *helper.StandardError) {
+	user := repository.User{}
+	if err := t.applyResource(&user, resource); err != nil {
+		return nil, err
+	}
+
+	user = t.UserRepository.Save(user)
+	t.AuditUsecase.Record("user.register", 0, user.ID, fmt.Sprintf("provider=%s", scimProvider))
+
+	created := toSCIMUser(user)
+	return &created, nil
+}
+
+func (t *SCIMUsecaseImpl) ReplaceUser(id string, resource repository.SCIMUser, ifMatch string) (*repository.SCIMUser, *helper.StandardError) {
+	user, err := t.findUser(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if ifMatch != "" && !matchesVersion(ifMatch, scimVersion(user)) {
+		return nil, &helper.StandardError{Error: errors.New("resource version mismatch"), ErrorCode: http.StatusPreconditionFailed}
+	}
+
+	return t.updateUser(user, resource)
+}
+
+func (t *SCIMUsecaseImpl) PatchUser(id string, patchRequest repository.SCIMPatchRequest, ifMatch string) (*repository.SCIMUser, *helper.StandardError) {
+	user, err := t.findUser(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if ifMatch != "" && !matchesVersion(ifMatch, scimVersion(user)) {
+		return nil, &helper.StandardError{Error: errors.New("resource version mismatch"), ErrorCode: http.StatusPreconditionFailed}
+	}
+
+	resource := toSCIMUser(user)
+	if err := applySCIMPatch(&resource, patchRequest.Operations); err != nil {
+		return nil, &helper.StandardError{Error: err, ErrorCode: http.StatusBadRequest}
+	}
+
+	return t.updateUser(user, resource)
+}
+
+func (t *SCIMUsecaseImpl) DeleteUser(id string, ifMatch string) *helper.StandardError {
+	user, err := t.findUser(id)
+	if err != nil {
+		return err
+	}
+
+	if ifMatch != "" && !matchesVersion(ifMatch, scimVersion(user)) {
+		return &helper.StandardError{Error: errors.New("resource version mismatch"), ErrorCode: http.StatusPreconditionFailed}
+	}
+
+	t.UserRepository.Delete(user.ID)
+	t.AuditUsecase.Record("user.remove", 0, user.ID, fmt.Sprintf("provider=%s", scimProvider))
+
+	return nil
+}
+
+func NewSCIMUsecaseImpl(userRepository repository.UserRepository, attributeRepository repository.AttributeRepository, auditUsecase AuditUsecase) SCIMUsecase {
+	return &SCIMUsecaseImpl{
+		UserRepository:      userRepository,
+		AttributeRepository: attributeRepository,
+		AuditUsecase:        auditUsecase,
+	}
+}
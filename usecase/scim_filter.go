@@ -0,0 +1,275 @@
+package usecase
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// scimFilter evaluates a parsed SCIM filter against the attributes of a resource. Attribute
+// names are lower case and multi-valued attributes keep every value.
+type scimFilter func(attributes map[string][]string) bool
+
+type scimFilterParser struct {
+	tokens   []string
+	position int
+}
+
+var scimCaseExactAttributes = map[string]bool{"id": true, "externalid": true}
+
+func parseSCIMFilter(filter string) (scimFilter, error) {
+	tokens, err := tokenizeSCIMFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &scimFilterParser{tokens: tokens}
+	expression, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if parser.position != len(tokens) {
+		return nil, fmt.Errorf("%w: unexpected %s", ErrSCIMInvalidFilter, tokens[parser.position])
+	}
+
+	return expression, nil
+}
+
+func tokenizeSCIMFilter(filter string) ([]string, error) {
+	var tokens []string
+	runes := []rune(filter)
+
+	for i := 0; i < len(runes); {
+		switch {
+		case unicode.IsSpace(runes[i]):
+			i++
+		case runes[i] == '(' || runes[i] == ')':
+			tokens = append(tokens, string(runes[i]))
+			i++
+		case runes[i] == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				if runes[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("%w: unterminated string", ErrSCIMInvalidFilter)
+			}
+			tokens = append(tokens, string(runes[i:end+1]))
+			i = end + 1
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && runes[end] != '(' && runes[end] != ')' {
+				end++
+			}
+			token := string(runes[i:end])
+			if strings.ContainsAny(token, "[]") {
+				return nil, fmt.Errorf("%w: complex attribute filters are not supported", ErrSCIMInvalidFilter)
+			}
+			tokens = append(tokens, token)
+			i = end
+		}
+	}
+
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("%w: empty filter", ErrSCIMInvalidFilter)
+	}
+	return tokens, nil
+}
+
+func (t *scimFilterParser) peek() string {
+	if t.position < len(t.tokens) {
+		return t.tokens[t.position]
+	}
+	return ""
+}
+
+func (t *scimFilterParser) next() (string, error) {
+	if t.position >= len(t.tokens) {
+		return "", fmt.Errorf("%w: unexpected end of filter", ErrSCIMInvalidFilter)
+	}
+	t.position++
+	return t.tokens[t.position-1], nil
+}
+
+func (t *scimFilterParser) parseOr() (scimFilter, error) {
+	left, err := t.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for strings.EqualFold(t.peek(), "or") {
+		t.position++
+		right, err := t.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		first, second := left, right
+		left = func(attributes map[string][]string) bool { return first(attributes) || second(attributes) }
+	}
+
+	return left, nil
+}
+
+func (t *scimFilterParser) parseAnd() (scimFilter, error) {
+	left, err := t.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+
+	for strings.EqualFold(t.peek(), "and") {
+		t.position++
+		right, err := t.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		first, second := left, right
+		left = func(attributes map[string][]string) bool { return first(attributes) && second(attributes) }
+	}
+
+	return left, nil
+}
+
+func (t *scimFilterParser) parseFactor() (scimFilter, error) {
+	token, err := t.next()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case strings.EqualFold(token, "not"):
+		if t.peek() != "(" {
+			return nil, fmt.Errorf("%w: not must be followed by a parenthesized filter", ErrSCIMInvalidFilter)
+		}
+		expression, err := t.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return func(attributes map[string][]string) bool { return !expression(attributes) }, nil
+	case token == "(":
+		expression, err := t.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing, _ := t.next(); closing != ")" {
+			return nil, fmt.Errorf("%w: missing closing parenthesis", ErrSCIMInvalidFilter)
+		}
+		return expression, nil
+	case token == ")" || strings.HasPrefix(token, `"`):
+		return nil, fmt.Errorf("%w: expected an attribute, got %s", ErrSCIMInvalidFilter, token)
+	}
+
+	return t.parseComparison(scimAttributeName(token))
+}
+
+func (t *scimFilterParser) parseComparison(attribute string) (scimFilter, error) {
+	operatorToken, err := t.next()
+	if err != nil {
+		return nil, err
+	}
+	operator := strings.ToLower(operatorToken)
+
+	if operator == "pr" {
+		return func(attributes map[string][]string) bool {
+			for _, value := range attributes[attribute] {
+				if value != "" {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+
+	valueToken, err := t.next()
+	if err != nil {
+		return nil, err
+	}
+	expected, err := parseSCIMFilterValue(valueToken)
+	if err != nil {
+		return nil, err
+	}
+
+	caseExact := scimCaseExactAttributes[attribute]
+	if !caseExact {
+		expected = strings.ToLower(expected)
+	}
+
+	var compare func(value string) bool
+	switch operator {
+	case "eq":
+		compare = func(value string) bool { return value == expected }
+	case "ne":
+		return func(attributes map[string][]string) bool {
+			for _, value := range attributes[attribute] {
+				if !caseExact {
+					value = strings.ToLower(value)
+				}
+				if value == expected {
+					return false
+				}
+			}
+			return true
+		}, nil
+	case "co":
+		compare = func(value string) bool { return strings.Contains(value, expected) }
+	case "sw":
+		compare = func(value string) bool { return strings.HasPrefix(value, expected) }
+	case "ew":
+		compare = func(value string) bool { return strings.HasSuffix(value, expected) }
+	case "gt":
+		compare = func(value string) bool { return value > expected }
+	case "ge":
+		compare = func(value string) bool { return value >= expected }
+	case "lt":
+		compare = func(value string) bool { return value < expected }
+	case "le":
+		compare = func(value string) bool { return value <= expected }
+	default:
+		return nil, fmt.Errorf("%w: unsupported operator %s", ErrSCIMInvalidFilter, operatorToken)
+	}
+
+	return func(attributes map[string][]string) bool {
+		for _, value := range attributes[attribute] {
+			if !caseExact {
+				value = strings.ToLower(value)
+			}
+			if compare(value) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+func parseSCIMFilterValue(token string) (string, error) {
+	if strings.HasPrefix(token, `"`) {
+		var value string
+		if err := json.Unmarshal([]byte(token), &value); err != nil {
+			return "", fmt.Errorf("%w: invalid string %s", ErrSCIMInvalidFilter, token)
+		}
+		return value, nil
+	}
+
+	switch lower := strings.ToLower(token); lower {
+	case "true", "false":
+		return lower, nil
+	case "(", ")", "and", "or", "not", "null":
+		return "", fmt.Errorf("%w: unsupported comparison value %s", ErrSCIMInvalidFilter, token)
+	}
+
+	var number json.Number
+	if err := json.Unmarshal([]byte(token), &number); err != nil {
+		return "", fmt.Errorf("%w: unsupported comparison value %s", ErrSCIMInvalidFilter, token)
+	}
+	return number.String(), nil
+}
+
+// scimAttributeName strips the core schema URN from fully qualified attribute names and
+// lowers the case, since SCIM attribute names are case-insensitive.
+func scimAttributeName(name string) string {
+	name = strings.ToLower(name)
+	return strings.TrimPrefix(name, strings.ToLower(scimUserSchemaPrefix))
+}
@@ -0,0 +1,286 @@
+package usecase_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/stretchr/testify/mock"
+)
+
+var disabledAt = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var mockSCIMUsers = []repository.User{
+	{ID: 102, Username: "bob", Email: "bob@example.com", DisabledAt: &disabledAt},
+	mockUser,
+	{ID: 101, Username: "alice", Email: "alice@example.com", ExternalID: "00u1"},
+}
+
+func scimUserNames(response *repository.SCIMListResponse) []string {
+	names := []string{}
+	for _, resource := range response.Resources {
+		names = append(names, resource.UserName)
+	}
+	return names
+}
+
+func TestSCIMListUsers(t *testing.T) {
+	userRepositoryMock := new(mocks.UserRepositoryMock)
+	userRepositoryMock.On("FindAll").Return(mockSCIMUsers)
+	scimUsecase := usecase.NewSCIMUsecaseImpl(userRepositoryMock, nil, nil)
+
+	t.Run("test equality filter", func(t *testing.T) {
+		response, err := scimUsecase.ListUsers(repository.SCIMListRequest{Filter: `userName eq "ALICE"`})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, response.TotalResults, 1)
+		assert.Equal(t, response.Resources[0].ID, "101")
+		assert.Equal(t, response.Resources[0].ExternalID, "00u1")
+		assert.Equal(t, response.Resources[0].Emails, []repository.SCIMEmail{{Value: "alice@example.com", Type: "work", Primary: true}})
+		assert.Equal(t, response.Resources[0].Meta.Version != "", true)
+	})
+
+	t.Run("test logical filter", func(t *testing.T) {
+		response, err := scimUsecase.ListUsers(repository.SCIMListRequest{Filter: `emails.value ew "@example.com" and not (active eq false) or externalId pr`})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, scimUserNames(response), []string{"alice"})
+	})
+
+	t.Run("test pagination", func(t *testing.T) {
+		count := 1
+		response, err := scimUsecase.ListUsers(repository.SCIMListRequest{StartIndex: 2, Count: &count})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, response.TotalResults, 3)
+		assert.Equal(t, response.StartIndex, 2)
+		assert.Equal(t, response.ItemsPerPage, 1)
+		assert.Equal(t, scimUserNames(response), []string{"alice"})
+	})
+
+	t.Run("test start index past the end", func(t *testing.T) {
+		response, err := scimUsecase.ListUsers(repository.SCIMListRequest{StartIndex: 10})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, response.TotalResults, 3)
+		assert.Equal(t, response.Resources, []repository.SCIMUser{})
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		response, err := scimUsecase.ListUsers(repository.SCIMListRequest{Filter: `userName zz "alice"`})
+
+		assert.Equal(t, response, nil)
+		assert.Equal(t, int(err.ErrorCode), http.StatusBadRequest)
+		assert.Equal(t, errors.Is(err.Error, usecase.ErrSCIMInvalidFilter), true)
+	})
+}
+
+func TestSCIMCreateUser(t *testing.T) {
+	t.Run("test normal create", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		userRepositoryMock.On("FindByUsername").Return(repository.User{})
+		userRepositoryMock.On("Save").Return(repository.User{ID: 103, Username: "carol", Email: "carol@example.com", ExternalID: "00u2"})
+		auditUsecaseMock.On("Record").Return(nil)
+
+		scimUsecase := usecase.NewSCIMUsecaseImpl(userRepositoryMock, noAttributeDefinitions(), auditUsecaseMock)
+		user, err := scimUsecase.CreateUser(repository.SCIMUser{
+			UserName:   "carol",
+			ExternalID: "00u2",
+			Emails:     []repository.SCIMEmail{{Value: "carol@example.com", Primary: true}},
+		})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, user.ID, "103")
+		assert.Equal(t, *user.Active, true)
+		assert.Equal(t, user.Meta.Location, "http://localhost:3000/scim/v2/Users/103")
+	})
+
+	t.Run("username already exists", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		userRepositoryMock.On("FindByUsername").Return(mockUser)
+
+		scimUsecase := usecase.NewSCIMUsecaseImpl(userRepositoryMock, nil, nil)
+		user, err := scimUsecase.CreateUser(repository.SCIMUser{UserName: "username"})
+
+		assert.Equal(t, user, nil)
+		assert.Equal(t, int(err.ErrorCode), http.StatusConflict)
+		assert.Equal(t, errors.Is(err.Error, usecase.ErrSCIMUniqueness), true)
+	})
+
+	t.Run("required attribute is missing", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		attributeRepositoryMock := new(mocks.AttributeRepositoryMock)
+		userRepositoryMock.On("FindByUsername").Return(repository.User{})
+		attributeRepositoryMock.On("FindByOrganizationId", repository.DefaultOrganizationID).Return([]repository.AttributeDefinition{
+			{ID: 1, OrganizationID: 1, Name: "department", Type: repository.AttributeTypeString, Required: true},
+		})
+
+		scimUsecase := usecase.NewSCIMUsecaseImpl(userRepositoryMock, attributeRepositoryMock, nil)
+		user, err := scimUsecase.CreateUser(repository.SCIMUser{UserName: "carol"})
+
+		assert.Equal(t, user, nil)
+		assert.Equal(t, int(err.ErrorCode), http.StatusBadRequest)
+		assert.Equal(t, errors.Is(err.Error, usecase.ErrSCIMInvalidValue), true)
+		userRepositoryMock.AssertNotCalled(t, "Save")
+	})
+
+	t.Run("username is required", func(t *testing.T) {
+		scimUsecase := usecase.NewSCIMUsecaseImpl(nil, nil, nil)
+		user, err := scimUsecase.CreateUser(repository.SCIMUser{})
+
+		assert.Equal(t, user, nil)
+		assert.Equal(t, int(err.ErrorCode), http.StatusBadRequest)
+	})
+}
+
+func TestSCIMReplaceUser(t *testing.T) {
+	t.Run("test normal replace", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		userRepositoryMock.On("FindById").Return(mockUser)
+		userRepositoryMock.On("Update", mock.MatchedBy(func(user repository.User) bool {
+			return user.Email == "new@mail.com" && user.ExternalID == "00u3" && user.Password == mockUser.Password
+		})).Return(repository.User{ID: 100, Username: "username", Email: "new@mail.com", ExternalID: "00u3"})
+		auditUsecaseMock.On("Record").Return(nil)
+
+		scimUsecase := usecase.NewSCIMUsecaseImpl(userRepositoryMock, noAttributeDefinitions(), auditUsecaseMock)
+		user, err := scimUsecase.ReplaceUser("100", repository.SCIMUser{
+			UserName:   "username",
+			ExternalID: "00u3",
+			Emails:     []repository.SCIMEmail{{Value: "new@mail.com"}},
+		}, "")
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, user.Emails[0].Value, "new@mail.com")
+	})
+
+	t.Run("rename to an existing username", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		userRepositoryMock.On("FindById").Return(mockUser)
+		userRepositoryMock.On("FindByUsername").Return(mockSCIMUsers[2])
+
+		scimUsecase := usecase.NewSCIMUsecaseImpl(userRepositoryMock, nil, nil)
+		user, err := scimUsecase.ReplaceUser("100", repository.SCIMUser{UserName: "alice"}, "")
+
+		assert.Equal(t, user, nil)
+		assert.Equal(t, int(err.ErrorCode), http.StatusConflict)
+	})
+
+	t.Run("version mismatch", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		userRepositoryMock.On("FindById").Return(mockUser)
+
+		scimUsecase := usecase.NewSCIMUsecaseImpl(userRepositoryMock, nil, nil)
+		user, err := scimUsecase.ReplaceUser("100", repository.SCIMUser{UserName: "username"}, `W/"stale"`)
+
+		assert.Equal(t, user, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("resource version mismatch"), ErrorCode: http.StatusPreconditionFailed})
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		userRepositoryMock.On("FindById").Return(repository.User{})
+
+		scimUsecase := usecase.NewSCIMUsecaseImpl(userRepositoryMock, nil, nil)
+		user, err := scimUsecase.ReplaceUser("999", repository.SCIMUser{UserName: "username"}, "")
+
+		assert.Equal(t, user, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("user 999 not found"), ErrorCode: http.StatusNotFound})
+	})
+}
+
+func TestSCIMPatchUser(t *testing.T) {
+	t.Run("test deactivate with string boolean", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		userRepositoryMock.On("FindById").Return(mockUser)
+		userRepositoryMock.On("Update", mock.MatchedBy(func(user repository.User) bool {
+			return user.DisabledAt != nil && user.Email == mockUser.Email
+		})).Return(repository.User{ID: 100, Username: "username", Email: "test@mail.com", DisabledAt: &disabledAt})
+		auditUsecaseMock.On("Record").Return(nil)
+
+		current, _ := usecase.NewSCIMUsecaseImpl(userRepositoryMock, nil, nil).GetUser("100")
+
+		scimUsecase := usecase.NewSCIMUsecaseImpl(userRepositoryMock, noAttributeDefinitions(), auditUsecaseMock)
+		user, err := scimUsecase.PatchUser("100", repository.SCIMPatchRequest{Operations: []repository.SCIMPatchOperation{
+			{Op: "Replace", Path: "active", Value: "False"},
+		}}, current.Meta.Version)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, *user.Active, false)
+		assert.NotEqual(t, user.Meta.Version, current.Meta.Version)
+	})
+
+	t.Run("test replace without path and filtered email path", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		userRepositoryMock.On("FindById").Return(mockUser)
+		userRepositoryMock.On("Update", mock.MatchedBy(func(user repository.User) bool {
+			return user.ExternalID == "00u4" && user.Email == "work@mail.com" && user.DisabledAt == nil
+		})).Return(repository.User{ID: 100, Username: "username", Email: "work@mail.com", ExternalID: "00u4"})
+		auditUsecaseMock.On("Record").Return(nil)
+
+		scimUsecase := usecase.NewSCIMUsecaseImpl(userRepositoryMock, noAttributeDefinitions(), auditUsecaseMock)
+		user, err := scimUsecase.PatchUser("100", repository.SCIMPatchRequest{Operations: []repository.SCIMPatchOperation{
+			{Op: "replace", Value: map[string]interface{}{"externalId": "00u4", "name.givenName": "Test"}},
+			{Op: "add", Path: `emails[type eq "work"].value`, Value: "work@mail.com"},
+		}}, "")
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, user.ExternalID, "00u4")
+	})
+
+	t.Run("unsupported operation", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		userRepositoryMock.On("FindById").Return(mockUser)
+
+		scimUsecase := usecase.NewSCIMUsecaseImpl(userRepositoryMock, nil, nil)
+		user, err := scimUsecase.PatchUser("100", repository.SCIMPatchRequest{Operations: []repository.SCIMPatchOperation{
+			{Op: "move", Path: "userName", Value: "other"},
+		}}, "")
+
+		assert.Equal(t, user, nil)
+		assert.Equal(t, int(err.ErrorCode), http.StatusBadRequest)
+		assert.Equal(t, errors.Is(err.Error, usecase.ErrSCIMInvalidSyntax), true)
+	})
+
+	t.Run("remove without path", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		userRepositoryMock.On("FindById").Return(mockUser)
+
+		scimUsecase := usecase.NewSCIMUsecaseImpl(userRepositoryMock, nil, nil)
+		_, err := scimUsecase.PatchUser("100", repository.SCIMPatchRequest{Operations: []repository.SCIMPatchOperation{{Op: "remove"}}}, "")
+
+		assert.Equal(t, errors.Is(err.Error, usecase.ErrSCIMNoTarget), true)
+	})
+}
+
+func TestSCIMDeleteUser(t *testing.T) {
+	t.Run("test normal delete", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		userRepositoryMock.On("FindById").Return(mockUser)
+		userRepositoryMock.On("Delete").Return(mockUser)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		scimUsecase := usecase.NewSCIMUsecaseImpl(userRepositoryMock, noAttributeDefinitions(), auditUsecaseMock)
+		err := scimUsecase.DeleteUser("100", "*")
+
+		assert.Equal(t, err, nil)
+		userRepositoryMock.AssertCalled(t, "Delete")
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		scimUsecase := usecase.NewSCIMUsecaseImpl(nil, nil, nil)
+		err := scimUsecase.DeleteUser("abc", "")
+
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("user abc not found"), ErrorCode: http.StatusNotFound})
+	})
+}
@@ -0,0 +1,353 @@
+package usecase
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	SessionCookie             = "gum_session"
+	CSRFCookie                = "gum_csrf"
+	CSRFHeader                = "X-CSRF-Token"
+	sessionTokenPrefix        = "gum_sess_"
+	loginTokenExpiry          = 24 * time.Hour
+	defaultSessionIdleTimeout = 30 * time.Minute
+	defaultSessionMaxAge      = 7 * 24 * time.Hour
+	defaultSessionCleanup     = time.Hour
+	sessionTouchResolution    = time.Minute
+)
+
+const (
+	SessionMethodPassword   = "password"
+	SessionMethodPasskey    = "passkey"
+	SessionMethodMagicLink  = "magic_link"
+	SessionMethodFederation = "federation"
+	SessionMethodOAuth      = "oauth"
+	SessionMethodDevice     = "device"
+)
+
+type SessionUsecase interface {
+	ListSessions(userId uint64, currentSessionId uint64) (*[]repository.Session, *helper.StandardError)
+	RevokeSession(userId uint64, sessionId uint64, actorId uint64) *helper.StandardError
+	ListUserSessions(organizationId uint64, userId uint64) (*[]repository.Session, *helper.StandardError)
+	RevokeUserSession(organizationId uint64, userId uint64, sessionId uint64, actorId uint64) *helper.StandardError
+	DeleteExpiredSessions() int64
+}
+
+type SessionUsecaseImpl struct {
+	SessionRepository repository.SessionRepository
+	UserRepository    repository.UserRepository
+	AuditUsecase      AuditUsecase
+}
+
+func sessionDuration(name string, defaultValue time.Duration) time.Duration {
+	duration, err := time.ParseDuration(envOrDefault(name, defaultValue.String()))
+	if err != nil || duration <= 0 {
+		return defaultValue
+	}
+	return duration
+}
+
+func sessionIdleTimeout() time.Duration {
+	return sessionDuration("SESSION_IDLE_TIMEOUT", defaultSessionIdleTimeout)
+}
+
+func sessionMaxAge() time.Duration {
+	return sessionDuration("SESSION_MAX_AGE", defaultSessionMaxAge)
+}
+
+// SessionCleanupInterval is how often the server deletes expired sessions.
+func SessionCleanupInterval() time.Duration {
+	return sessionDuration("SESSION_CLEANUP_INTERVAL", defaultSessionCleanup)
+}
+
+var (
+	browserSignatures = [][2]string{{"Edg/", "Edge"}, {"OPR/", "Opera"}, {"Firefox/", "Firefox"}, {"Chrome/", "Chrome"}, {"Safari/", "Safari"}, {"curl/", "curl"}}
+	systemSignatures  = [][2]string{{"Windows", "Windows"}, {"Android", "Android"}, {"iPhone", "iOS"}, {"iPad", "iPadOS"}, {"Mac OS X", "macOS"}, {"CrOS", "ChromeOS"}, {"Linux", "Linux"}}
+)
+
+// describeDevice turns a User-Agent header into a short label such as "Chrome on macOS".
+// Signatures are checked in order because browsers also advertise the engines they are based on.
+func describeDevice(userAgent string) string {
+	match := func(signatures [][2]string) string {
+		for _, signature := range signatures {
+			if strings.Contains(userAgent, signature[0]) {
+				return signature[1]
+			}
+		}
+		return ""
+	}
+
+	browser, system := match(browserSignatures), match(systemSignatures)
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return "Unknown browser on " + system
+	}
+	return "Unknown device"
+}
+
+func newSession(kind string, method string, userId uint64, loginContext repository.LoginContext) repository.Session {
+	now := time.Now()
+	return repository.Session{
+		UserID:     userId,
+		Kind:       kind,
+		Method:     method,
+		Device:     describeDevice(loginContext.UserAgent),
+		UserAgent:  loginContext.UserAgent,
+		IPAddress:  loginContext.IPAddress,
+		LastSeenAt: now,
+	}
+}
+
+// startTokenSession records a login to the user's own organization and signs a login token bound to it.
+func startTokenSession(sessionRepository repository.SessionRepository, user repository.User, method string, loginContext repository.LoginContext) (string, *repository.Session, *helper.StandardError) {
+	return startOrganizationSession(sessionRepository, user, organizationOrDefault(user.OrganizationID), method, loginContext, nil)
+}
+
+// saveTokenSession records a session that tokens reference through their sid claim.
+func saveTokenSession(sessionRepository repository.SessionRepository, user repository.User, organizationId uint64, method string, loginContext repository.LoginContext, expiry time.Duration) (repository.Session, bool) {
+	// The random hash only keeps the column unique.
+	sessionHash, err := generateRandomToken("")
+	if err != nil {
+		return repository.Session{}, false
+	}
+
+	session := newSession(repository.SessionKindToken, method, user.ID, loginContext)
+	session.OrganizationID = organizationId
+	session.SessionHash = hashToken(sessionHash)
+	session.ExpiresAt = session.LastSeenAt.Add(expiry)
+	session.AbsoluteExpiresAt = session.ExpiresAt
+	session = sessionRepository.Save(session)
+	return session, session.ID != 0
+}
+
+func startOrganizationSession(sessionRepository repository.SessionRepository, user repository.User, organizationId uint64, method string, loginContext repository.LoginContext, claims jwt.MapClaims) (string, *repository.Session, *helper.StandardError) {
+	failed := &helper.StandardError{Error: errors.New("failed to generate token"), ErrorCode: http.StatusInternalServerError}
+
+	session, ok := saveTokenSession(sessionRepository, user, organizationId, method, loginContext, loginTokenExpiry)
+	if !ok {
+		return "", nil, failed
+	}
+
+	token, err := signLoginToken(user, session, claims)
+	if err != nil {
+		sessionRepository.Delete(session.ID)
+		return "", nil, failed
+	}
+
+	return token, &session, nil
+}
+
+// CreateSession logs the user in with a server-side session instead of a bearer token.
+// The raw session and CSRF tokens are only returned here; the store keeps their hashes.
+func (t *AuthUsecaseImpl) CreateSession(loginData repository.Login, loginContext repository.LoginContext) (*repository.CreatedSession, *helper.StandardError) {
+	loginData.OrganizationID = loginContext.OrganizationID
+	userFound, authError := t.Authenticate(loginData)
+	if authError != nil {
+		return nil, authError
+	}
+
+	created, sessionError := startCookieSession(t.SessionRepository, *userFound, SessionMethodPassword, loginContext)
+	if sessionError != nil {
+		return nil, sessionError
+	}
+
+	t.AuditUsecase.Record("user.login", userFound.ID, userFound.ID, fmt.Sprintf("method=password session_id=%d", created.ID))
+
+	return created, nil
+}
+
+// startCookieSession records a cookie session in the user's own organization and returns its raw session and CSRF tokens.
+func startCookieSession(sessionRepository repository.SessionRepository, user repository.User, method string, loginContext repository.LoginContext) (*repository.CreatedSession, *helper.StandardError) {
+	failed := &helper.StandardError{Error: errors.New("failed to create session"), ErrorCode: http.StatusInternalServerError}
+
+	sessionToken, err := generateRandomToken(sessionTokenPrefix)
+	if err != nil {
+		return nil, failed
+	}
+
+	csrfToken, err := generateRandomToken("")
+	if err != nil {
+		return nil, failed
+	}
+
+	session := newSession(repository.SessionKindCookie, method, user.ID, loginContext)
+	session.OrganizationID = organizationOrDefault(user.OrganizationID)
+	session.SessionHash = hashToken(sessionToken)
+	session.CSRFTokenHash = hashToken(csrfToken)
+	session.AbsoluteExpiresAt = session.LastSeenAt.Add(sessionMaxAge())
+	session.ExpiresAt = slideExpiry(session.LastSeenAt, session.AbsoluteExpiresAt)
+	session = sessionRepository.Save(session)
+	if session.ID == 0 {
+		return nil, failed
+	}
+
+	return &repository.CreatedSession{Session: session, SessionToken: sessionToken, CSRFToken: csrfToken}, nil
+}
+
+func (t *AuthUsecaseImpl) Logout(sessionId uint64) *helper.StandardError {
+	if sessionId == 0 {
+		return &helper.StandardError{Error: errors.New("request is not authenticated with a session"), ErrorCode: http.StatusBadRequest}
+	}
+
+	if !t.SessionRepository.Delete(sessionId) {
+		return &helper.StandardError{Error: errors.New("session not found"), ErrorCode: http.StatusNotFound}
+	}
+
+	return nil
+}
+
+func slideExpiry(now time.Time, absoluteExpiresAt time.Time) time.Time {
+	expiresAt := now.Add(sessionIdleTimeout())
+	if expiresAt.After(absoluteExpiresAt) {
+		return absoluteExpiresAt
+	}
+	return expiresAt
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// touchSession records activity at most once per resolution; cookie sessions also slide their expiry.
+func (t *AuthUsecaseImpl) touchSession(c *gin.Context, session repository.Session) {
+	now := time.Now()
+	if now.Sub(session.LastSeenAt) <= sessionTouchResolution && session.IPAddress == c.ClientIP() {
+		return
+	}
+
+	expiresAt := session.ExpiresAt
+	if session.Kind == repository.SessionKindCookie {
+		expiresAt = slideExpiry(now, session.AbsoluteExpiresAt)
+	}
+	t.SessionRepository.Touch(session.ID, now, c.ClientIP(), expiresAt)
+}
+
+// FindSession returns the user and the cookie session of a session token, or nil when the session is unknown,
+// expired or belongs to a disabled user.
+func (t *AuthUsecaseImpl) FindSession(sessionToken string) (*repository.User, *repository.Session) {
+	session := t.SessionRepository.FindByHash(hashToken(sessionToken))
+	if session.ID == 0 || session.Kind != repository.SessionKindCookie || time.Now().After(session.ExpiresAt) {
+		return nil, nil
+	}
+
+	user := t.UserRepository.FindById(session.UserID)
+	if user.ID == 0 || user.DisabledAt != nil {
+		return nil, nil
+	}
+
+	return &user, &session
+}
+
+// validCSRFToken reports whether a request echoes the CSRF token issued with the session.
+func validCSRFToken(session repository.Session, csrfToken string) bool {
+	return subtle.ConstantTimeCompare([]byte(hashToken(csrfToken)), []byte(session.CSRFTokenHash)) == 1
+}
+
+func (t *AuthUsecaseImpl) validateSession(c *gin.Context, sessionToken string) {
+	foundUser, foundSession := t.FindSession(sessionToken)
+	if foundSession == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired session"})
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	user, session := *foundUser, *foundSession
+
+	// Browsers attach the cookie to cross-site requests too, so state changes must echo the CSRF token.
+	if !isSafeMethod(c.Request.Method) && !validCSRFToken(session, c.GetHeader(CSRFHeader)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "invalid csrf token"})
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	organizationId := session.OrganizationID
+	if organizationId == 0 {
+		organizationId = organizationOrDefault(user.OrganizationID)
+	}
+	if !setCurrentOrganization(c, organizationId) {
+		return
+	}
+
+	t.touchSession(c, session)
+
+	c.Set("currentUserId", user.ID)
+	c.Set("currentSessionId", session.ID)
+
+	c.Next()
+}
+
+func (t *SessionUsecaseImpl) ListSessions(userId uint64, currentSessionId uint64) (*[]repository.Session, *helper.StandardError) {
+	sessions := t.SessionRepository.FindByUserId(userId)
+	if sessions == nil {
+		sessions = []repository.Session{}
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionId
+	}
+
+	return &sessions, nil
+}
+
+func (t *SessionUsecaseImpl) RevokeSession(userId uint64, sessionId uint64, actorId uint64) *helper.StandardError {
+	session := t.SessionRepository.FindById(sessionId)
+	if session.ID == 0 || session.UserID != userId {
+		return &helper.StandardError{Error: errors.New("session not found"), ErrorCode: http.StatusNotFound}
+	}
+
+	if !t.SessionRepository.Delete(session.ID) {
+		return &helper.StandardError{Error: errors.New("session not found"), ErrorCode: http.StatusNotFound}
+	}
+
+	t.AuditUsecase.Record("session.revoke", actorId, userId, fmt.Sprintf("session_id=%d device=%q", session.ID, session.Device))
+
+	return nil
+}
+
+// organizationUserExists keeps admins from reaching the sessions of users outside their organization.
+func (t *SessionUsecaseImpl) organizationUserExists(organizationId uint64, userId uint64) *helper.StandardError {
+	if t.UserRepository.ForOrganization(organizationId).FindById(userId).ID == 0 {
+		return &helper.StandardError{Error: errors.New("user not found"), ErrorCode: http.StatusNotFound}
+	}
+	return nil
+}
+
+func (t *SessionUsecaseImpl) ListUserSessions(organizationId uint64, userId uint64) (*[]repository.Session, *helper.StandardError) {
+	if err := t.organizationUserExists(organizationId, userId); err != nil {
+		return nil, err
+	}
+	return t.ListSessions(userId, 0)
+}
+
+func (t *SessionUsecaseImpl) RevokeUserSession(organizationId uint64, userId uint64, sessionId uint64, actorId uint64) *helper.StandardError {
+	if err := t.organizationUserExists(organizationId, userId); err != nil {
+		return err
+	}
+	return t.RevokeSession(userId, sessionId, actorId)
+}
+
+// DeleteExpiredSessions removes the sessions that can no longer be used and returns how many were deleted.
+func (t *SessionUsecaseImpl) DeleteExpiredSessions() int64 {
+	return t.SessionRepository.DeleteExpired()
+}
+
+func NewSessionUsecaseImpl(sessionRepository repository.SessionRepository, userRepository repository.UserRepository, auditUsecase AuditUsecase) SessionUsecase {
+	return &SessionUsecaseImpl{
+		SessionRepository: sessionRepository,
+		UserRepository:    userRepository,
+		AuditUsecase:      auditUsecase,
+	}
+}
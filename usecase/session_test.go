@@ -0,0 +1,296 @@
+package usecase_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/stretchr/testify/mock"
+)
+
+var loginSession = repository.Session{ID: 1, UserID: 100, Kind: repository.SessionKindToken, LastSeenAt: time.Now(), ExpiresAt: time.Now().Add(24 * time.Hour)}
+
+func sha256Hex(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestCreateSession(t *testing.T) {
+	t.Run("test login with a session", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		userRepositoryMock.On("FindByUsername").Return(mockUser)
+		sessionRepositoryMock.On("Save", mock.MatchedBy(func(session repository.Session) bool {
+			return session.UserID == 100 && session.SessionHash != "" && session.CSRFTokenHash != "" && session.UserAgent == "browser" && session.Kind == repository.SessionKindCookie &&
+				session.ExpiresAt.Before(session.AbsoluteExpiresAt) && session.ExpiresAt.After(time.Now())
+		})).Return(repository.Session{ID: 1, UserID: 100})
+		auditUsecaseMock.On("Record").Return(nil)
+
+		authUsecase := usecase.NewAuthUsecaseImpl(userRepositoryMock, auditUsecaseMock, nil, nil, nil, usecase.NewLocalAuthenticator(userRepositoryMock, auditUsecaseMock), sessionRepositoryMock, nil, nil)
+		session, err := authUsecase.CreateSession(repository.Login{Username: "username", Password: "password", Session: true}, repository.LoginContext{UserAgent: "browser", IPAddress: "10.0.0.1"})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, session.ID, uint64(1))
+		assert.NotEqual(t, session.SessionToken, "")
+		assert.NotEqual(t, session.CSRFToken, "")
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		userRepositoryMock.On("FindByUsername").Return(mockUser)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		authUsecase := usecase.NewAuthUsecaseImpl(userRepositoryMock, auditUsecaseMock, nil, nil, nil, usecase.NewLocalAuthenticator(userRepositoryMock, auditUsecaseMock), sessionRepositoryMock, nil, nil)
+		session, err := authUsecase.CreateSession(repository.Login{Username: "username", Password: "wrong", Session: true}, repository.LoginContext{UserAgent: "browser", IPAddress: "10.0.0.1"})
+
+		assert.Equal(t, session, nil)
+		assert.NotEqual(t, err, nil)
+		sessionRepositoryMock.AssertNotCalled(t, "Save", mock.Anything)
+	})
+}
+
+func TestLogoutSession(t *testing.T) {
+	t.Run("test end the session", func(t *testing.T) {
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		sessionRepositoryMock.On("Delete").Return(true)
+
+		authUsecase := usecase.NewAuthUsecaseImpl(nil, nil, nil, nil, nil, nil, sessionRepositoryMock, nil, nil)
+
+		assert.Equal(t, authUsecase.Logout(1), nil)
+	})
+
+	t.Run("bearer token", func(t *testing.T) {
+		authUsecase := usecase.NewAuthUsecaseImpl(nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+		assert.Equal(t, authUsecase.Logout(0), helper.StandardError{Error: errors.New("request is not authenticated with a session"), ErrorCode: http.StatusBadRequest})
+	})
+}
+
+func TestValidateSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	activeSession := func() repository.Session {
+		return repository.Session{
+			ID:                1,
+			SessionHash:       sha256Hex("gum_sess_token"),
+			CSRFTokenHash:     sha256Hex("csrf"),
+			UserID:            100,
+			Kind:              repository.SessionKindCookie,
+			IPAddress:         "192.0.2.1",
+			LastSeenAt:        time.Now(),
+			ExpiresAt:         time.Now().Add(time.Minute),
+			AbsoluteExpiresAt: time.Now().Add(time.Hour),
+		}
+	}
+
+	serve := func(sessionRepositoryMock *mocks.SessionRepositoryMock, userRepositoryMock *mocks.UserRepositoryMock, method string, csrfToken string) *httptest.ResponseRecorder {
+		router := gin.New()
+		authUsecase := usecase.NewAuthUsecaseImpl(userRepositoryMock, nil, nil, nil, nil, nil, sessionRepositoryMock, nil, nil)
+		router.Handle(method, "/test", authUsecase.ValidateToken, func(c *gin.Context) {
+			sessionId, _ := c.Get("currentSessionId")
+			c.JSON(http.StatusOK, gin.H{"session": sessionId})
+		})
+
+		req, _ := http.NewRequest(method, "/test", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.AddCookie(&http.Cookie{Name: usecase.SessionCookie, Value: "gum_sess_token"})
+		if csrfToken != "" {
+			req.Header.Set(usecase.CSRFHeader, csrfToken)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Valid session cookie", func(t *testing.T) {
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		sessionRepositoryMock.On("FindByHash").Return(activeSession())
+		userRepositoryMock.On("FindById").Return(mockUser)
+
+		w := serve(sessionRepositoryMock, userRepositoryMock, http.MethodGet, "")
+
+		assert.Equal(t, w.Code, http.StatusOK)
+		assert.Equal(t, w.Body.String(), `{"session":1}`)
+		sessionRepositoryMock.AssertNotCalled(t, "Touch", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Sliding expiry", func(t *testing.T) {
+		session := activeSession()
+		session.LastSeenAt = time.Now().Add(-5 * time.Minute)
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		sessionRepositoryMock.On("FindByHash").Return(session)
+		sessionRepositoryMock.On("Touch", uint64(1), mock.MatchedBy(func(expiresAt time.Time) bool {
+			return expiresAt.After(session.ExpiresAt) && !expiresAt.After(session.AbsoluteExpiresAt)
+		})).Return()
+		userRepositoryMock.On("FindById").Return(mockUser)
+
+		w := serve(sessionRepositoryMock, userRepositoryMock, http.MethodGet, "")
+
+		assert.Equal(t, w.Code, http.StatusOK)
+		sessionRepositoryMock.AssertCalled(t, "Touch", uint64(1), mock.Anything)
+	})
+
+	t.Run("State change with CSRF token", func(t *testing.T) {
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		sessionRepositoryMock.On("FindByHash").Return(activeSession())
+		userRepositoryMock.On("FindById").Return(mockUser)
+
+		w := serve(sessionRepositoryMock, userRepositoryMock, http.MethodPost, "csrf")
+
+		assert.Equal(t, w.Code, http.StatusOK)
+	})
+
+	t.Run("State change without CSRF token", func(t *testing.T) {
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		sessionRepositoryMock.On("FindByHash").Return(activeSession())
+		userRepositoryMock.On("FindById").Return(mockUser)
+
+		w := serve(sessionRepositoryMock, userRepositoryMock, http.MethodPost, "")
+
+		assert.Equal(t, w.Code, http.StatusForbidden)
+		assert.Equal(t, w.Body.String(), `{"error":"invalid csrf token"}`)
+	})
+
+	t.Run("State change with wrong CSRF token", func(t *testing.T) {
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		sessionRepositoryMock.On("FindByHash").Return(activeSession())
+		userRepositoryMock.On("FindById").Return(mockUser)
+
+		w := serve(sessionRepositoryMock, userRepositoryMock, http.MethodDelete, "other")
+
+		assert.Equal(t, w.Code, http.StatusForbidden)
+	})
+
+	t.Run("Expired session", func(t *testing.T) {
+		session := activeSession()
+		session.ExpiresAt = time.Now().Add(-time.Second)
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		sessionRepositoryMock.On("FindByHash").Return(session)
+
+		w := serve(sessionRepositoryMock, nil, http.MethodGet, "")
+
+		assert.Equal(t, w.Code, http.StatusUnauthorized)
+		assert.Equal(t, w.Body.String(), `{"error":"invalid or expired session"}`)
+	})
+
+	t.Run("Disabled user", func(t *testing.T) {
+		disabledUser := mockUser
+		disabledUser.DisabledAt = &disabledAt
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		sessionRepositoryMock.On("FindByHash").Return(activeSession())
+		userRepositoryMock.On("FindById").Return(disabledUser)
+
+		w := serve(sessionRepositoryMock, userRepositoryMock, http.MethodGet, "")
+
+		assert.Equal(t, w.Code, http.StatusUnauthorized)
+	})
+}
+
+func TestListSessions(t *testing.T) {
+	t.Run("test mark the current session", func(t *testing.T) {
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		sessionRepositoryMock.On("FindByUserId").Return([]repository.Session{{ID: 1, UserID: 100}, {ID: 2, UserID: 100}})
+
+		sessionUsecase := usecase.NewSessionUsecaseImpl(sessionRepositoryMock, nil, nil)
+		sessions, err := sessionUsecase.ListSessions(100, 2)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, (*sessions)[0].Current, false)
+		assert.Equal(t, (*sessions)[1].Current, true)
+	})
+
+	t.Run("no sessions", func(t *testing.T) {
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		sessionRepositoryMock.On("FindByUserId").Return([]repository.Session(nil))
+
+		sessionUsecase := usecase.NewSessionUsecaseImpl(sessionRepositoryMock, nil, nil)
+		sessions, err := sessionUsecase.ListSessions(100, 0)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, len(*sessions), 0)
+	})
+}
+
+func TestRevokeSession(t *testing.T) {
+	t.Run("test revoke a session", func(t *testing.T) {
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		sessionRepositoryMock.On("FindById").Return(repository.Session{ID: 1, UserID: 100, Device: "Chrome on macOS"})
+		sessionRepositoryMock.On("Delete").Return(true)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		sessionUsecase := usecase.NewSessionUsecaseImpl(sessionRepositoryMock, nil, auditUsecaseMock)
+
+		assert.Equal(t, sessionUsecase.RevokeSession(100, 1, 1), nil)
+		auditUsecaseMock.AssertCalled(t, "Record")
+	})
+
+	t.Run("session of another user", func(t *testing.T) {
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		sessionRepositoryMock.On("FindById").Return(repository.Session{ID: 1, UserID: 101})
+
+		sessionUsecase := usecase.NewSessionUsecaseImpl(sessionRepositoryMock, nil, nil)
+
+		assert.Equal(t, sessionUsecase.RevokeSession(100, 1, 100), helper.StandardError{Error: errors.New("session not found"), ErrorCode: http.StatusNotFound})
+		sessionRepositoryMock.AssertNotCalled(t, "Delete")
+	})
+}
+
+func TestRevokeUserSession(t *testing.T) {
+	t.Run("test revoke a session of an organization user", func(t *testing.T) {
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		userRepositoryMock.On("FindById").Return(mockUser)
+		sessionRepositoryMock.On("FindById").Return(repository.Session{ID: 1, UserID: 100})
+		sessionRepositoryMock.On("Delete").Return(true)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		sessionUsecase := usecase.NewSessionUsecaseImpl(sessionRepositoryMock, userRepositoryMock, auditUsecaseMock)
+
+		assert.Equal(t, sessionUsecase.RevokeUserSession(2, 100, 1, 1), nil)
+		assert.Equal(t, userRepositoryMock.OrganizationID, uint64(2))
+	})
+
+	t.Run("user of another organization", func(t *testing.T) {
+		sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		userRepositoryMock.On("FindById").Return(repository.User{})
+
+		sessionUsecase := usecase.NewSessionUsecaseImpl(sessionRepositoryMock, userRepositoryMock, nil)
+
+		assert.Equal(t, sessionUsecase.RevokeUserSession(2, 100, 1, 1), helper.StandardError{Error: errors.New("user not found"), ErrorCode: http.StatusNotFound})
+		_, err := sessionUsecase.ListUserSessions(2, 100)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("user not found"), ErrorCode: http.StatusNotFound})
+		sessionRepositoryMock.AssertNotCalled(t, "FindById")
+		sessionRepositoryMock.AssertNotCalled(t, "FindByUserId")
+	})
+}
+
+func TestDeleteExpiredSessions(t *testing.T) {
+	sessionRepositoryMock := new(mocks.SessionRepositoryMock)
+	sessionRepositoryMock.On("DeleteExpired").Return(int64(3))
+
+	sessionUsecase := usecase.NewSessionUsecaseImpl(sessionRepositoryMock, nil, nil)
+
+	assert.Equal(t, sessionUsecase.DeleteExpiredSessions(), int64(3))
+}
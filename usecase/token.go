@@ -0,0 +1,160 @@
+package usecase
+
+import (
+	"andikawhy/go-user-management/helper"
+	"andikawhy/go-user-management/repository"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	personalAccessTokenPrefix     = "gum_pat_"
+	personalAccessTokenShownChars = 12
+	defaultTokenExpiryDays        = 30
+	maxTokenExpiryDays            = 365
+	tokenLastUsedResolution       = time.Minute
+)
+
+const (
+	ScopeUsersRead  = "users:read"
+	ScopeUsersWrite = "users:write"
+	ScopeAuditRead  = "audit:read"
+	ScopeTokens     = "tokens"
+	ScopeAccount    = "account"
+	ScopeClients    = "clients"
+	ScopeSCIM       = "scim"
+	ScopeAuthz      = "authz"
+)
+
+var supportedScopes = []string{ScopeUsersRead, ScopeUsersWrite, ScopeAuditRead, ScopeTokens, ScopeAccount, ScopeClients, ScopeSCIM, ScopeAuthz}
+
+type TokenUsecase interface {
+	CreateToken(userId uint64, callerScopes []string, createTokenData repository.CreateToken) (*repository.CreatedTokenResponse, *helper.StandardError)
+	ListTokens(userId uint64) (*[]repository.PersonalAccessToken, *helper.StandardError)
+	RevokeToken(userId uint64, tokenId uint64) (*repository.PersonalAccessToken, *helper.StandardError)
+}
+
+type TokenUsecaseImpl struct {
+	TokenRepository repository.PersonalAccessTokenRepository
+	AuditUsecase    AuditUsecase
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateRandomToken(prefix string) (string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return prefix + base64.RawURLEncoding.EncodeToString(random), nil
+}
+
+func isPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, personalAccessTokenPrefix)
+}
+
+func hasScope(scopes []string, scope string) bool {
+	for _, granted := range scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func validateScopes(scopes []string, allowed []string) error {
+	for _, scope := range scopes {
+		if !hasScope(allowed, scope) {
+			return fmt.Errorf("unsupported scope: %s", scope)
+		}
+	}
+	return nil
+}
+
+// CreateToken issues a personal access token. callerScopes are the scopes of the token making the request, nil for a
+// login token; a scoped token cannot create a token with scopes it does not hold itself.
+func (t *TokenUsecaseImpl) CreateToken(userId uint64, callerScopes []string, createTokenData repository.CreateToken) (*repository.CreatedTokenResponse, *helper.StandardError) {
+	if len(createTokenData.Scopes) == 0 {
+		return nil, &helper.StandardError{Error: errors.New("at least one scope is required"), ErrorCode: http.StatusBadRequest}
+	}
+
+	if err := validateScopes(createTokenData.Scopes, supportedScopes); err != nil {
+		return nil, &helper.StandardError{Error: err, ErrorCode: http.StatusBadRequest}
+	}
+
+	if callerScopes != nil {
+		for _, scope := range createTokenData.Scopes {
+			if !hasScope(callerScopes, scope) {
+				return nil, &helper.StandardError{Error: fmt.Errorf("cannot grant scope %s, the current token does not hold it", scope), ErrorCode: http.StatusForbidden}
+			}
+		}
+	}
+
+	expiresInDays := createTokenData.ExpiresInDays
+	if expiresInDays == 0 {
+		expiresInDays = defaultTokenExpiryDays
+	}
+	if expiresInDays > maxTokenExpiryDays {
+		return nil, &helper.StandardError{Error: fmt.Errorf("token expiry cannot exceed %d days", maxTokenExpiryDays), ErrorCode: http.StatusBadRequest}
+	}
+
+	rawToken, err := generateRandomToken(personalAccessTokenPrefix)
+	if err != nil {
+		return nil, &helper.StandardError{Error: errors.New("failed to generate token"), ErrorCode: http.StatusInternalServerError}
+	}
+
+	token := t.TokenRepository.Save(repository.PersonalAccessToken{
+		UserID:    userId,
+		Name:      createTokenData.Name,
+		Prefix:    rawToken[:personalAccessTokenShownChars],
+		TokenHash: hashToken(rawToken),
+		Scopes:    strings.Join(createTokenData.Scopes, " "),
+		ExpiresAt: time.Now().Add(time.Duration(expiresInDays) * 24 * time.Hour),
+	})
+
+	t.AuditUsecase.Record("token.create", userId, userId, fmt.Sprintf("token_id=%d", token.ID))
+
+	return &repository.CreatedTokenResponse{PersonalAccessToken: token, Token: rawToken}, nil
+}
+
+func (t *TokenUsecaseImpl) ListTokens(userId uint64) (*[]repository.PersonalAccessToken, *helper.StandardError) {
+	tokens := t.TokenRepository.FindByUserId(userId)
+	if tokens == nil {
+		tokens = []repository.PersonalAccessToken{}
+	}
+	return &tokens, nil
+}
+
+func (t *TokenUsecaseImpl) RevokeToken(userId uint64, tokenId uint64) (*repository.PersonalAccessToken, *helper.StandardError) {
+	token := t.TokenRepository.FindById(tokenId)
+
+	if token.ID == 0 || token.UserID != userId {
+		return nil, &helper.StandardError{Error: errors.New("token not found"), ErrorCode: http.StatusNotFound}
+	}
+
+	if token.RevokedAt == nil {
+		now := time.Now()
+		token.RevokedAt = &now
+		token = t.TokenRepository.Update(token)
+		t.AuditUsecase.Record("token.revoke", userId, userId, fmt.Sprintf("token_id=%d", token.ID))
+	}
+
+	return &token, nil
+}
+
+func NewTokenUsecaseImpl(tokenRepository repository.PersonalAccessTokenRepository, auditUsecase AuditUsecase) TokenUsecase {
+	return &TokenUsecaseImpl{
+		TokenRepository: tokenRepository,
+		AuditUsecase:    auditUsecase,
+	}
+}
@@ -0,0 +1,107 @@
+package usecase_test
+
+import (
+	"andikawhy/go-user-management/helper"
+	mocks "andikawhy/go-user-management/mock"
+	"andikawhy/go-user-management/repository"
+	"andikawhy/go-user-management/usecase"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+var mockToken = repository.PersonalAccessToken{
+	ID:     1,
+	UserID: 100,
+	Name:   "ci",
+	Scopes: "users:read",
+}
+
+func TestCreateToken(t *testing.T) {
+	t.Run("test normal create token", func(t *testing.T) {
+		tokenRepositoryMock := new(mocks.PersonalAccessTokenRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		tokenRepositoryMock.On("Save").Return(mockToken)
+
+		tokenUsecase := usecase.NewTokenUsecaseImpl(tokenRepositoryMock, auditUsecaseMock)
+		created, err := tokenUsecase.CreateToken(100, nil, repository.CreateToken{Name: "ci", Scopes: []string{"users:read"}})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, strings.HasPrefix(created.Token, "gum_pat_"), true)
+		assert.Equal(t, created.ID, uint64(1))
+	})
+
+	t.Run("unsupported scope", func(t *testing.T) {
+		tokenUsecase := usecase.NewTokenUsecaseImpl(new(mocks.PersonalAccessTokenRepositoryMock), new(mocks.AuditUsecaseMock))
+		created, err := tokenUsecase.CreateToken(100, nil, repository.CreateToken{Name: "ci", Scopes: []string{"everything"}})
+
+		assert.Equal(t, created, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("unsupported scope: everything"), ErrorCode: http.StatusBadRequest})
+	})
+
+	t.Run("scope the current token does not hold", func(t *testing.T) {
+		tokenRepositoryMock := new(mocks.PersonalAccessTokenRepositoryMock)
+		tokenUsecase := usecase.NewTokenUsecaseImpl(tokenRepositoryMock, new(mocks.AuditUsecaseMock))
+		created, err := tokenUsecase.CreateToken(100, []string{"tokens", "users:read"}, repository.CreateToken{Name: "ci", Scopes: []string{"users:read", "users:write"}})
+
+		assert.Equal(t, created, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("cannot grant scope users:write, the current token does not hold it"), ErrorCode: http.StatusForbidden})
+		tokenRepositoryMock.AssertNotCalled(t, "Save")
+	})
+
+	t.Run("expiry too long", func(t *testing.T) {
+		tokenUsecase := usecase.NewTokenUsecaseImpl(new(mocks.PersonalAccessTokenRepositoryMock), new(mocks.AuditUsecaseMock))
+		created, err := tokenUsecase.CreateToken(100, nil, repository.CreateToken{Name: "ci", Scopes: []string{"users:read"}, ExpiresInDays: 1000})
+
+		assert.Equal(t, created, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("token expiry cannot exceed 365 days"), ErrorCode: http.StatusBadRequest})
+	})
+}
+
+func TestListTokens(t *testing.T) {
+	t.Run("test normal list tokens", func(t *testing.T) {
+		tokenRepositoryMock := new(mocks.PersonalAccessTokenRepositoryMock)
+
+		tokenRepositoryMock.On("FindByUserId").Return([]repository.PersonalAccessToken{mockToken})
+
+		tokenUsecase := usecase.NewTokenUsecaseImpl(tokenRepositoryMock, nil)
+		tokens, err := tokenUsecase.ListTokens(100)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, tokens, &[]repository.PersonalAccessToken{mockToken})
+	})
+}
+
+func TestRevokeToken(t *testing.T) {
+	t.Run("test normal revoke token", func(t *testing.T) {
+		tokenRepositoryMock := new(mocks.PersonalAccessTokenRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
+
+		tokenRepositoryMock.On("FindById").Return(mockToken)
+		tokenRepositoryMock.On("Update").Return(mockToken)
+
+		tokenUsecase := usecase.NewTokenUsecaseImpl(tokenRepositoryMock, auditUsecaseMock)
+		_, err := tokenUsecase.RevokeToken(100, 1)
+
+		assert.Equal(t, err, nil)
+		tokenRepositoryMock.AssertCalled(t, "Update")
+	})
+
+	t.Run("negative: token of another user", func(t *testing.T) {
+		tokenRepositoryMock := new(mocks.PersonalAccessTokenRepositoryMock)
+
+		tokenRepositoryMock.On("FindById").Return(mockToken)
+
+		tokenUsecase := usecase.NewTokenUsecaseImpl(tokenRepositoryMock, nil)
+		token, err := tokenUsecase.RevokeToken(101, 1)
+
+		assert.Equal(t, token, nil)
+		assert.Equal(t, err, helper.StandardError{Error: errors.New("token not found"), ErrorCode: http.StatusNotFound})
+	})
+}
@@ -4,20 +4,24 @@ import (
 	"andikawhy/go-user-management/helper"
 	"andikawhy/go-user-management/repository"
 	"errors"
+	"io"
 	"net/http"
 )
 
 type UserUsecase interface {
-	RemoveUser(deletedUserID uint64, currentUserId uint64) (*repository.UserResponse, *helper.StandardError)
-	ListUsers() (*[]repository.UserResponse, *helper.StandardError)
+	RemoveUser(organizationId uint64, deletedUserID uint64, currentUserId uint64) (*repository.UserResponse, *helper.StandardError)
+	ListUsers(organizationId uint64, attributeFilters map[string]string) (*[]repository.UserResponse, *helper.StandardError)
+	ExportUsers(organizationId uint64, exportData repository.UserExport, attributeFilters map[string]string, writer io.Writer, actorId uint64) *helper.StandardError
 }
 
 type UserUsecaseImpl struct {
 	UserRepository repository.UserRepository
+	AuditUsecase   AuditUsecase
 }
 
-func (t *UserUsecaseImpl) RemoveUser(deleteUserIdRequest uint64, currentUserId uint64) (*repository.UserResponse, *helper.StandardError) {
-	userFound := t.UserRepository.FindById(deleteUserIdRequest)
+func (t *UserUsecaseImpl) RemoveUser(organizationId uint64, deleteUserIdRequest uint64, currentUserId uint64) (*repository.UserResponse, *helper.StandardError) {
+	userRepository := t.UserRepository.ForOrganization(organizationId)
+	userFound := userRepository.FindById(deleteUserIdRequest)
 
 	if userFound.ID == currentUserId {
 		return nil, &helper.StandardError{Error: errors.New("cannot delete current user"), ErrorCode: http.StatusBadRequest}
@@ -27,37 +31,46 @@ func (t *UserUsecaseImpl) RemoveUser(deleteUserIdRequest uint64, currentUserId u
 		return nil, &helper.StandardError{Error: errors.New("user not found"), ErrorCode: http.StatusBadRequest}
 	}
 
-	t.UserRepository.Delete(deleteUserIdRequest)
+	userRepository.Delete(deleteUserIdRequest)
+	t.AuditUsecase.Record("user.remove", currentUserId, userFound.ID, "")
 
 	userResponse := repository.UserResponse{
-		ID:        userFound.ID,
-		Email:     userFound.Email,
-		Username:  userFound.Username,
-		CreatedAt: userFound.CreatedAt,
+		ID:             userFound.ID,
+		OrganizationID: userFound.OrganizationID,
+		Email:          userFound.Email,
+		Username:       userFound.Username,
+		Attributes:     userFound.Attributes,
+		AvatarURL:      userFound.AvatarURL,
+		CreatedAt:      userFound.CreatedAt,
 	}
 
 	return &userResponse, nil
 }
 
-func (t *UserUsecaseImpl) ListUsers() (*[]repository.UserResponse, *helper.StandardError) {
-	users := t.UserRepository.FindAll()
+// ListUsers returns the organization's users that carry every filtered attribute with the given value.
+func (t *UserUsecaseImpl) ListUsers(organizationId uint64, attributeFilters map[string]string) (*[]repository.UserResponse, *helper.StandardError) {
+	users := t.UserRepository.ForOrganization(organizationId).WithAttributes(attributeFilters).FindAll()
 
 	var userResponses = []repository.UserResponse{}
 
 	for _, user := range users {
 		userResponse := repository.UserResponse{
-			ID:        user.ID,
-			Username:  user.Username,
-			Email:     user.Email,
-			CreatedAt: user.CreatedAt,
+			ID:             user.ID,
+			OrganizationID: user.OrganizationID,
+			Username:       user.Username,
+			Email:          user.Email,
+			Attributes:     user.Attributes,
+			AvatarURL:      user.AvatarURL,
+			CreatedAt:      user.CreatedAt,
 		}
 		userResponses = append(userResponses, userResponse)
 	}
 	return &userResponses, nil
 }
 
-func NewUserUsecaseImpl(userRepository repository.UserRepository) UserUsecase {
+func NewUserUsecaseImpl(userRepository repository.UserRepository, auditUsecase AuditUsecase) UserUsecase {
 	return &UserUsecaseImpl{
 		UserRepository: userRepository,
+		AuditUsecase:   auditUsecase,
 	}
 }
@@ -30,14 +30,17 @@ func TestListUsers(t *testing.T) {
 		expectedResponse := []repository.UserResponse{mockUserResponse}
 
 		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
 
 		userRepositoryMock.On("FindAll").Return(findAllMockResponse)
 
-		userUsecase := usecase.NewUserUsecaseImpl(userRepositoryMock)
-		users, err := userUsecase.ListUsers()
+		userUsecase := usecase.NewUserUsecaseImpl(userRepositoryMock, auditUsecaseMock)
+		users, err := userUsecase.ListUsers(2, nil)
 
 		assert.Equal(t, expectedResponse, users)
 		assert.Equal(t, err, nil)
+		assert.Equal(t, uint64(2), userRepositoryMock.OrganizationID)
 	})
 
 	t.Run("test normal case list empty users", func(t *testing.T) {
@@ -45,15 +48,32 @@ func TestListUsers(t *testing.T) {
 		expectedResponse := &[]repository.UserResponse{}
 
 		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
 
 		userRepositoryMock.On("FindAll").Return(findAllMockResponse)
 
-		userUsecase := usecase.NewUserUsecaseImpl(userRepositoryMock)
-		users, err := userUsecase.ListUsers()
+		userUsecase := usecase.NewUserUsecaseImpl(userRepositoryMock, auditUsecaseMock)
+		users, err := userUsecase.ListUsers(2, nil)
 
 		assert.Equal(t, expectedResponse, users)
 		assert.Equal(t, err, nil)
 	})
+
+	t.Run("test filter by attributes", func(t *testing.T) {
+		engineer := repository.User{ID: 101, Username: "engineer", Attributes: repository.UserAttributes{"department": "eng", "level": float64(3), "manager": true}}
+		seller := repository.User{ID: 102, Username: "seller", Attributes: repository.UserAttributes{"department": "sales", "level": float64(3)}}
+
+		userRepositoryMock := new(mocks.UserRepositoryMock)
+		userRepositoryMock.On("FindAll").Return([]repository.User{mockUser, engineer, seller})
+
+		userUsecase := usecase.NewUserUsecaseImpl(userRepositoryMock, nil)
+		users, err := userUsecase.ListUsers(2, map[string]string{"level": "3", "manager": "true"})
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, len(*users), 1)
+		assert.Equal(t, (*users)[0].Attributes, engineer.Attributes)
+	})
 }
 
 func TestRemoveUser(t *testing.T) {
@@ -62,26 +82,31 @@ func TestRemoveUser(t *testing.T) {
 		expectedResponse := mockUserResponse
 
 		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
 
 		userRepositoryMock.On("FindById").Return(deleteMockResponse)
 		userRepositoryMock.On("Delete").Return(deleteMockResponse)
 
-		userUsecase := usecase.NewUserUsecaseImpl(userRepositoryMock)
-		users, err := userUsecase.RemoveUser(100, 101)
+		userUsecase := usecase.NewUserUsecaseImpl(userRepositoryMock, auditUsecaseMock)
+		users, err := userUsecase.RemoveUser(2, 100, 101)
 
 		assert.Equal(t, expectedResponse, users)
 		assert.Equal(t, err, nil)
+		assert.Equal(t, uint64(2), userRepositoryMock.OrganizationID)
 	})
 
 	t.Run("negative: current user == deleted user", func(t *testing.T) {
 		deleteMockResponse := mockUser
 
 		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
 
 		userRepositoryMock.On("FindById").Return(deleteMockResponse)
 
-		userUsecase := usecase.NewUserUsecaseImpl(userRepositoryMock)
-		users, err := userUsecase.RemoveUser(100, 100)
+		userUsecase := usecase.NewUserUsecaseImpl(userRepositoryMock, auditUsecaseMock)
+		users, err := userUsecase.RemoveUser(2, 100, 100)
 
 		assert.Equal(t, users, nil)
 		assert.Equal(t, err, helper.StandardError{Error: errors.New("cannot delete current user"), ErrorCode: 400})
@@ -89,11 +114,13 @@ func TestRemoveUser(t *testing.T) {
 
 	t.Run("negative: user not found", func(t *testing.T) {
 		userRepositoryMock := new(mocks.UserRepositoryMock)
+		auditUsecaseMock := new(mocks.AuditUsecaseMock)
+		auditUsecaseMock.On("Record").Return(nil)
 
 		userRepositoryMock.On("FindById").Return(repository.User{})
 
-		userUsecase := usecase.NewUserUsecaseImpl(userRepositoryMock)
-		users, err := userUsecase.RemoveUser(100, 101)
+		userUsecase := usecase.NewUserUsecaseImpl(userRepositoryMock, auditUsecaseMock)
+		users, err := userUsecase.RemoveUser(2, 100, 101)
 
 		assert.Equal(t, users, nil)
 		assert.Equal(t, err, helper.StandardError{Error: errors.New("user not found"), ErrorCode: 400})